@@ -0,0 +1,254 @@
+// Command adminctl is a break-glass tool for operators.  It talks to the
+// database directly, using the same environment (.env) as the server, so it
+// keeps working when the HTTP auth flow itself is broken.
+//
+// Usage:
+//
+//	adminctl user           -email a@b.c | -id 42
+//	adminctl reset-password -email a@b.c [-password secret]
+//	adminctl set-role       -email a@b.c -role OWNER|ADMIN|STAFF|CUSTOMER
+//	adminctl revoke-tokens  -email a@b.c
+//	adminctl reservations   -email a@b.c
+//
+// Access tokens are stateless JWTs: revoking a user's tokens (which
+// reset-password and set-role also do) invalidates their refresh tokens
+// immediately, while issued access tokens stay valid until they expire.
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+
+	"github.com/iliyamo/cinema-seat-reservation/internal/config"
+	"github.com/iliyamo/cinema-seat-reservation/internal/database"
+	"github.com/iliyamo/cinema-seat-reservation/internal/model"
+	"github.com/iliyamo/cinema-seat-reservation/internal/repository"
+)
+
+// app bundles the repositories used by the subcommands.
+type app struct {
+	cfg    config.Config
+	users  *repository.UserRepo
+	tokens *repository.TokenRepo
+	res    *repository.ReservationRepo
+}
+
+// command is a subcommand: it registers its flags on fs and returns the
+// function to run once they are parsed.
+type command struct {
+	usage string
+	setup func(a *app, fs *flag.FlagSet) func(ctx context.Context) error
+}
+
+var commands = map[string]command{
+	"user":           {"show a user's account", cmdUser},
+	"reset-password": {"set a new password and revoke refresh tokens", cmdResetPassword},
+	"set-role":       {"change a user's role and revoke refresh tokens", cmdSetRole},
+	"revoke-tokens":  {"revoke all refresh tokens of a user", cmdRevokeTokens},
+	"reservations":   {"list a user's reservations as JSON", cmdReservations},
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "adminctl: unknown command %q\n", os.Args[1])
+		usage()
+		os.Exit(2)
+	}
+
+	// Flags are parsed before connecting so that -h works without a database.
+	a := &app{}
+	fs := flag.NewFlagSet(os.Args[1], flag.ExitOnError)
+	run := cmd.setup(a, fs)
+	_ = fs.Parse(os.Args[2:])
+
+	loadDotEnv()
+	a.cfg = config.Load()
+	db, err := database.Open(a.cfg.DBUser, a.cfg.DBPass, a.cfg.DBHost, a.cfg.DBPort, a.cfg.DBName, database.Options{})
+	if err != nil {
+		fatal(err)
+	}
+	defer db.Close()
+	a.users = repository.NewUserRepo(db)
+	a.tokens = repository.NewTokenRepo(db)
+	a.res = repository.NewReservationRepo(db)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := run(ctx); err != nil {
+		fatal(err)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: adminctl <command> [flags]\n\ncommands:")
+	for _, name := range []string{"user", "reset-password", "set-role", "revoke-tokens", "reservations"} {
+		fmt.Fprintf(os.Stderr, "  %-15s %s\n", name, commands[name].usage)
+	}
+	fmt.Fprintln(os.Stderr, "\nrun 'adminctl <command> -h' for the command's flags")
+}
+
+func fatal(err error) {
+	fmt.Fprintf(os.Stderr, "adminctl: %v\n", err)
+	os.Exit(1)
+}
+
+// loadDotEnv loads the first .env found in the current or a parent
+// directory, like the server does.  Missing files are not an error.
+func loadDotEnv() {
+	for _, p := range []string{".env", "../.env", "../../.env"} {
+		if _, err := os.Stat(p); err == nil {
+			_ = godotenv.Overload(p)
+			return
+		}
+	}
+}
+
+// userFlags registers -email and -id and returns a resolver for the
+// selected user.
+func userFlags(a *app, fs *flag.FlagSet) func(ctx context.Context) (model.User, error) {
+	email := fs.String("email", "", "user email")
+	id := fs.Uint64("id", 0, "user id")
+	return func(ctx context.Context) (model.User, error) {
+		var (
+			u   model.User
+			err error
+		)
+		switch {
+		case *email != "" && *id != 0:
+			return u, errors.New("use either -email or -id, not both")
+		case *email != "":
+			u, err = a.users.GetByEmail(ctx, *email)
+		case *id != 0:
+			u, err = a.users.GetByID(ctx, *id)
+		default:
+			return u, errors.New("-email or -id is required")
+		}
+		if errors.Is(err, sql.ErrNoRows) {
+			return u, errors.New("user not found")
+		}
+		return u, err
+	}
+}
+
+func cmdUser(a *app, fs *flag.FlagSet) func(ctx context.Context) error {
+	lookup := userFlags(a, fs)
+	return func(ctx context.Context) error {
+		u, err := lookup(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("id:      %d\nemail:   %s\nrole:    %s\nactive:  %t\ncreated: %s\nupdated: %s\n",
+			u.ID, u.Email, u.Role, u.IsActive, u.CreatedAt.UTC().Format(time.RFC3339), u.UpdatedAt.UTC().Format(time.RFC3339))
+		return nil
+	}
+}
+
+func cmdResetPassword(a *app, fs *flag.FlagSet) func(ctx context.Context) error {
+	lookup := userFlags(a, fs)
+	password := fs.String("password", "", "new password (generated and printed when omitted)")
+	return func(ctx context.Context) error {
+		u, err := lookup(ctx)
+		if err != nil {
+			return err
+		}
+		pw := *password
+		generated := pw == ""
+		if generated {
+			if pw, err = randomPassword(); err != nil {
+				return err
+			}
+		}
+		if err := a.users.UpdatePassword(ctx, u.ID, pw, a.cfg.BcryptCost); err != nil {
+			return err
+		}
+		if err := a.tokens.RevokeAllForUser(ctx, u.ID); err != nil {
+			return fmt.Errorf("password changed but revoking tokens failed: %w", err)
+		}
+		if generated {
+			fmt.Printf("new password for %s: %s\n", u.Email, pw)
+		} else {
+			fmt.Printf("password updated for %s\n", u.Email)
+		}
+		return nil
+	}
+}
+
+func cmdSetRole(a *app, fs *flag.FlagSet) func(ctx context.Context) error {
+	lookup := userFlags(a, fs)
+	role := fs.String("role", "", "CUSTOMER, OWNER, ADMIN or STAFF")
+	return func(ctx context.Context) error {
+		u, err := lookup(ctx)
+		if err != nil {
+			return err
+		}
+		if err := a.users.SetRole(ctx, u.ID, *role); err != nil {
+			if errors.Is(err, repository.ErrUnknownRole) {
+				return fmt.Errorf("invalid -role %q: want CUSTOMER, OWNER, ADMIN or STAFF", *role)
+			}
+			return err
+		}
+		// The role is embedded in issued tokens; force a fresh login.
+		if err := a.tokens.RevokeAllForUser(ctx, u.ID); err != nil {
+			return fmt.Errorf("role changed but revoking tokens failed: %w", err)
+		}
+		fmt.Printf("%s is now %s\n", u.Email, strings.ToUpper(strings.TrimSpace(*role)))
+		return nil
+	}
+}
+
+func cmdRevokeTokens(a *app, fs *flag.FlagSet) func(ctx context.Context) error {
+	lookup := userFlags(a, fs)
+	return func(ctx context.Context) error {
+		u, err := lookup(ctx)
+		if err != nil {
+			return err
+		}
+		if err := a.tokens.RevokeAllForUser(ctx, u.ID); err != nil {
+			return err
+		}
+		fmt.Printf("revoked refresh tokens of %s\n", u.Email)
+		return nil
+	}
+}
+
+func cmdReservations(a *app, fs *flag.FlagSet) func(ctx context.Context) error {
+	lookup := userFlags(a, fs)
+	return func(ctx context.Context) error {
+		u, err := lookup(ctx)
+		if err != nil {
+			return err
+		}
+		list, err := a.res.ListByUser(ctx, u.ID)
+		if err != nil {
+			return err
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(list)
+	}
+}
+
+// randomPassword returns a 128-bit random password encoded for typing.
+func randomPassword() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,360 @@
+// Command seed fills a database with synthetic data for load and
+// performance testing: owners with cinemas, halls with full seat grids,
+// shows with their show seats, customers and random reservations.  It
+// uses the same environment (.env) as the server and only inserts rows,
+// so it can be run repeatedly; every run tags its accounts with a run ID.
+//
+// Usage:
+//
+//	seed [-owners 5] [-cinemas 2] [-halls 3] [-rows 12] [-cols 20]
+//	     [-shows 20] [-days 14] [-customers 200] [-reservations 2000]
+//	     [-max-seats 4] [-cancelled 0.1] [-rand-seed 1]
+//
+// -cinemas is per owner, -halls per cinema and -shows per hall.  Shows
+// start from tomorrow, spread over -days days.  Every account gets the
+// password given by -password.
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"log"
+	"math/rand"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/joho/godotenv"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/iliyamo/cinema-seat-reservation/internal/config"
+	"github.com/iliyamo/cinema-seat-reservation/internal/database"
+	"github.com/iliyamo/cinema-seat-reservation/internal/db"
+	"github.com/iliyamo/cinema-seat-reservation/internal/repository"
+	"github.com/iliyamo/cinema-seat-reservation/internal/service"
+)
+
+// options are the command line flags.
+type options struct {
+	owners, cinemas, halls int
+	rows, cols             int
+	shows, days            int
+	customers              int
+	reservations, maxSeats int
+	cancelled              float64
+	priceCents             int
+	password               string
+	bcryptCost             int
+	randSeed               int64
+}
+
+var (
+	titles = []string{"The Long Night", "Paper Moons", "Harbour Lights", "Northbound", "A Quiet Year",
+		"Glass Garden", "The Last Ferry", "Static", "Wild Orchard", "Second Sun"}
+	cities = []string{"Berlin", "Hamburg", "Munich", "Cologne", "Leipzig", "Vienna", "Zurich"}
+)
+
+// slotsPerDay is how many shows a hall can run per day: they start three
+// hours apart and last two.
+const slotsPerDay = 8
+
+func main() {
+	var o options
+	flag.IntVar(&o.owners, "owners", 5, "number of owners")
+	flag.IntVar(&o.cinemas, "cinemas", 2, "cinemas per owner")
+	flag.IntVar(&o.halls, "halls", 3, "halls per cinema")
+	flag.IntVar(&o.rows, "rows", 12, "seat rows per hall")
+	flag.IntVar(&o.cols, "cols", 20, "seats per row")
+	flag.IntVar(&o.shows, "shows", 20, "shows per hall")
+	flag.IntVar(&o.days, "days", 14, "days the shows are spread over, starting tomorrow")
+	flag.IntVar(&o.customers, "customers", 200, "number of customers")
+	flag.IntVar(&o.reservations, "reservations", 2000, "number of reservations")
+	flag.IntVar(&o.maxSeats, "max-seats", 4, "maximum seats per reservation")
+	flag.Float64Var(&o.cancelled, "cancelled", 0.1, "fraction of reservations created CANCELLED")
+	flag.IntVar(&o.priceCents, "price", 1200, "base seat price in cents")
+	flag.StringVar(&o.password, "password", "password123", "password of every seeded account")
+	flag.IntVar(&o.bcryptCost, "bcrypt-cost", bcrypt.MinCost, "bcrypt cost of the seeded passwords")
+	flag.Int64Var(&o.randSeed, "rand-seed", 0, "random seed (0: time based)")
+	flag.Parse()
+	if err := o.check(); err != nil {
+		fmt.Fprintf(os.Stderr, "seed: %v\n", err)
+		os.Exit(2)
+	}
+
+	loadDotEnv()
+	cfg := config.Load()
+	conn, err := database.Open(cfg.DBUser, cfg.DBPass, cfg.DBHost, cfg.DBPort, cfg.DBName, database.Options{})
+	if err != nil {
+		log.Fatalf("seed: %v", err)
+	}
+	defer conn.Close()
+	if o.randSeed == 0 {
+		o.randSeed = time.Now().UnixNano()
+	}
+	s := &seeder{
+		opts:   o,
+		rnd:    rand.New(rand.NewSource(o.randSeed)),
+		runID:  strconv.FormatInt(time.Now().Unix(), 36),
+		users:  repository.NewUserRepo(conn),
+		cinema: repository.NewCinemaRepo(conn),
+		hall:   repository.NewHallRepo(conn),
+		seat:   repository.NewSeatRepo(conn),
+		res:    repository.NewReservationRepo(conn),
+		seats:  repository.NewShowSeatRepo(conn),
+	}
+	s.shows = service.NewShowService(repository.NewShowRepo(conn), s.seats)
+	start := time.Now()
+	if err := s.run(context.Background()); err != nil {
+		log.Fatalf("seed: %v", err)
+	}
+	log.Printf("seed: run %s (rand seed %d) done in %s", s.runID, o.randSeed, time.Since(start).Round(time.Millisecond))
+	log.Printf("seed: %d owners, %d cinemas, %d halls, %d seats, %d shows, %d customers, %d reservations (%d seats)",
+		s.counts.owners, s.counts.cinemas, s.counts.halls, s.counts.seats, len(s.showList),
+		len(s.customerIDs), s.counts.reservations, s.counts.reservedSeats)
+	log.Printf("seed: log in as %s or %s with password %q", s.email("owner", 1), s.email("customer", 1), o.password)
+}
+
+// check validates the flags.
+func (o options) check() error {
+	switch {
+	case o.owners < 1 || o.cinemas < 1 || o.halls < 1:
+		return fmt.Errorf("-owners, -cinemas and -halls must be at least 1")
+	case o.rows < 1 || o.rows > 100 || o.cols < 1 || o.cols > 100:
+		return fmt.Errorf("-rows and -cols must be 1..100")
+	case o.days < 1 || o.shows < 0 || o.shows > o.days*slotsPerDay:
+		return fmt.Errorf("-shows must be 0..%d for %d days", o.days*slotsPerDay, o.days)
+	case o.customers < 0 || o.reservations < 0 || (o.reservations > 0 && o.customers == 0):
+		return fmt.Errorf("reservations need at least one customer")
+	case o.maxSeats < 1 || o.maxSeats > o.cols:
+		return fmt.Errorf("-max-seats must be 1..-cols")
+	case o.cancelled < 0 || o.cancelled > 1:
+		return fmt.Errorf("-cancelled must be between 0 and 1")
+	case o.priceCents < 0:
+		return fmt.Errorf("-price must not be negative")
+	}
+	return nil
+}
+
+// seededShow is a show and its seats still free for reservations.
+type seededShow struct {
+	show *repository.Show
+	free []repository.Seat
+}
+
+// seeder creates the data of one run.
+type seeder struct {
+	opts  options
+	rnd   *rand.Rand
+	runID string
+
+	users  *repository.UserRepo
+	cinema *repository.CinemaRepo
+	hall   *repository.HallRepo
+	seat   *repository.SeatRepo
+	seats  *repository.ShowSeatRepo
+	res    *repository.ReservationRepo
+	shows  *service.ShowService
+
+	customerIDs []uint64
+	showList    []*seededShow
+	counts      struct {
+		owners, cinemas, halls, seats int
+		reservations, reservedSeats   int
+	}
+}
+
+// email returns the address of the n-th account of role in this run.
+func (s *seeder) email(role string, n int) string {
+	return fmt.Sprintf("seed-%s-%s-%d@example.test", s.runID, role, n)
+}
+
+// createUser inserts an account with the seeded password.
+func (s *seeder) createUser(ctx context.Context, role string, n int) (uint64, error) {
+	return s.users.Create(ctx, s.email(role, n), s.opts.password, role, s.opts.bcryptCost)
+}
+
+func (s *seeder) run(ctx context.Context) error {
+	o := s.opts
+	for i := 1; i <= o.owners; i++ {
+		ownerID, err := s.createUser(ctx, "OWNER", i)
+		if err != nil {
+			return fmt.Errorf("create owner: %w", err)
+		}
+		s.counts.owners++
+		for j := 1; j <= o.cinemas; j++ {
+			if err := s.seedCinema(ctx, ownerID, i, j); err != nil {
+				return err
+			}
+		}
+	}
+	for i := 1; i <= o.customers; i++ {
+		id, err := s.createUser(ctx, "CUSTOMER", i)
+		if err != nil {
+			return fmt.Errorf("create customer: %w", err)
+		}
+		s.customerIDs = append(s.customerIDs, id)
+	}
+	for i := 0; i < o.reservations; i++ {
+		if err := s.seedReservation(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// seedCinema creates cinema j of owner i with its halls and shows.
+func (s *seeder) seedCinema(ctx context.Context, ownerID uint64, i, j int) error {
+	city := cities[s.rnd.Intn(len(cities))]
+	c := &repository.Cinema{OwnerID: ownerID, Name: fmt.Sprintf("Seed %s %d-%d", s.runID, i, j), City: &city}
+	if err := s.cinema.Create(ctx, c); err != nil {
+		return fmt.Errorf("create cinema: %w", err)
+	}
+	s.counts.cinemas++
+	for k := 1; k <= s.opts.halls; k++ {
+		h := &repository.Hall{
+			OwnerID:  ownerID,
+			CinemaID: &c.ID,
+			Name:     "Hall " + strconv.Itoa(k),
+			SeatRows: sql.NullInt32{Int32: int32(s.opts.rows), Valid: true},
+			SeatCols: sql.NullInt32{Int32: int32(s.opts.cols), Valid: true},
+		}
+		if err := s.hall.Create(ctx, h); err != nil {
+			return fmt.Errorf("create hall: %w", err)
+		}
+		s.counts.halls++
+		seats, err := s.seedSeats(ctx, h.ID)
+		if err != nil {
+			return err
+		}
+		if err := s.seedShows(ctx, h, seats); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// seedSeats creates the seat grid of a hall: the first two seats of the
+// first row are ACCESSIBLE, the last two rows VIP and the rest STANDARD.
+func (s *seeder) seedSeats(ctx context.Context, hallID uint64) ([]repository.Seat, error) {
+	grid := make([]repository.Seat, 0, s.opts.rows*s.opts.cols)
+	for r := 0; r < s.opts.rows; r++ {
+		for n := 1; n <= s.opts.cols; n++ {
+			typ := "STANDARD"
+			switch {
+			case r == 0 && n <= 2:
+				typ = "ACCESSIBLE"
+			case r >= s.opts.rows-2 && s.opts.rows > 2:
+				typ = "VIP"
+			}
+			grid = append(grid, repository.Seat{HallID: hallID, RowLabel: rowLabel(r), SeatNumber: uint32(n), SeatType: typ})
+		}
+	}
+	if err := s.seat.CreateBulk(ctx, grid); err != nil {
+		return nil, fmt.Errorf("create seats: %w", err)
+	}
+	seats, err := s.seat.GetByHall(ctx, hallID)
+	if err != nil {
+		return nil, fmt.Errorf("load seats: %w", err)
+	}
+	s.counts.seats += len(seats)
+	return seats, nil
+}
+
+// seedShows schedules the shows of a hall, one slot after the other
+// across the days.
+func (s *seeder) seedShows(ctx context.Context, h *repository.Hall, seats []repository.Seat) error {
+	tomorrow := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, 1)
+	for n := 0; n < s.opts.shows; n++ {
+		day, slot := n%s.opts.days, n/s.opts.days
+		start := tomorrow.AddDate(0, 0, day).Add(time.Duration(slot) * 3 * time.Hour)
+		spec := service.ShowSpec{Hall: h, Title: titles[s.rnd.Intn(len(titles))], PriceCents: uint32(s.opts.priceCents)}
+		show, err := s.shows.Schedule(ctx, spec, seats, start, start.Add(2*time.Hour))
+		if err != nil {
+			return fmt.Errorf("schedule show: %w", err)
+		}
+		s.showList = append(s.showList, &seededShow{show: show, free: append([]repository.Seat(nil), seats...)})
+	}
+	return nil
+}
+
+// seedReservation books consecutive free seats (in seat map order) of a
+// random show for a random customer.  A -cancelled share of the reservations is
+// created CANCELLED without seats, as cancelling leaves them.
+func (s *seeder) seedReservation(ctx context.Context) error {
+	if len(s.showList) == 0 {
+		return nil
+	}
+	sh := s.showList[s.rnd.Intn(len(s.showList))]
+	n := 1 + s.rnd.Intn(s.opts.maxSeats)
+	if n > len(sh.free) {
+		n = len(sh.free)
+	}
+	if n == 0 {
+		return nil // sold out
+	}
+	first := s.rnd.Intn(len(sh.free) - n + 1)
+	picked := sh.free[first : first+n]
+	rec := &repository.ReservationRecord{
+		UserID:           s.customerIDs[s.rnd.Intn(len(s.customerIDs))],
+		ShowID:           sh.show.ID,
+		Status:           "CONFIRMED",
+		SubtotalCents:    uint32(n) * sh.show.BasePriceCents,
+		TotalAmountCents: uint32(n) * sh.show.BasePriceCents,
+	}
+	if s.rnd.Float64() < s.opts.cancelled {
+		rec.Status = "CANCELLED"
+	}
+	err := db.WithTx(ctx, s.shows.ShowRepo.DB(), func(tx *sql.Tx) error {
+		if err := s.res.CreateTx(ctx, tx, rec); err != nil {
+			return fmt.Errorf("create reservation: %w", err)
+		}
+		if rec.Status == "CANCELLED" {
+			return nil
+		}
+		lines := make([]repository.ReservationSeatRecord, len(picked))
+		ids := make([]uint64, len(picked))
+		for i, seat := range picked {
+			lines[i] = repository.ReservationSeatRecord{ReservationID: rec.ID, ShowID: sh.show.ID, SeatID: seat.ID, PriceCents: sh.show.BasePriceCents}
+			ids[i] = seat.ID
+		}
+		if err := s.res.CreateSeatsBulkTx(ctx, tx, lines); err != nil {
+			return fmt.Errorf("create reservation seats: %w", err)
+		}
+		if err := s.seats.BulkUpdateStatusTx(ctx, tx, sh.show.ID, ids, "RESERVED"); err != nil {
+			return fmt.Errorf("reserve show seats: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	s.counts.reservations++
+	if rec.Status == "CONFIRMED" {
+		s.counts.reservedSeats += n
+		sh.free = append(sh.free[:first:first], sh.free[first+n:]...)
+	}
+	return nil
+}
+
+// rowLabel returns the label of the zero-based row i: A..Z, AA, AB, ...
+// as the owner endpoints name rows.
+func rowLabel(i int) string {
+	var b []byte
+	for ; i >= 0; i = i/26 - 1 {
+		b = append([]byte{byte('A' + i%26)}, b...)
+	}
+	return string(b)
+}
+
+// loadDotEnv loads the first .env found in the current or a parent
+// directory, like the server does.  Missing files are not an error.
+func loadDotEnv() {
+	for _, p := range []string{".env", "../.env", "../../.env"} {
+		if _, err := os.Stat(p); err == nil {
+			_ = godotenv.Overload(p)
+			return
+		}
+	}
+}
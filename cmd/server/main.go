@@ -1,17 +1,38 @@
 package main // declare the main package; entry point of the application
 
 import (
-    "log" // log package for logging messages during startup and runtime
-    "os"  // os provides functions for interacting with the environment and filesystem
+    "context"   // context for background workers and shutdown
+    "crypto/rand"   // random bot check key when none is configured
+    "crypto/sha256" // derives the bot pass key
+    "errors"    // errors to tell a closed server from a failed one
+    "flag"      // -config and -set command line settings
+    "log"       // log package for logging messages during startup and runtime
+    "net/http"  // http.ErrServerClosed after shutdown
+    "os"        // os provides functions for interacting with the environment and filesystem
+    "os/signal" // signal to shut down on SIGINT/SIGTERM
+    "syscall"   // syscall.SIGTERM
+    "time"      // time for worker intervals
+    _ "time/tzdata" // embed the time zone database so cinema time zones resolve without system zoneinfo
 
     "github.com/joho/godotenv" // godotenv loads environment variables from .env files
     "github.com/labstack/echo/v4" // echo is the web framework used to create the HTTP server
+    echomw "github.com/labstack/echo/v4/middleware" // gzip response compression
 
+    "github.com/iliyamo/cinema-seat-reservation/internal/apperr"     // import the error envelope renderer
+    "github.com/iliyamo/cinema-seat-reservation/internal/cache"      // import the public response cache
     "github.com/iliyamo/cinema-seat-reservation/internal/config"     // import configuration loader
     "github.com/iliyamo/cinema-seat-reservation/internal/database"   // import database connection helper
+    "github.com/iliyamo/cinema-seat-reservation/internal/graphql"    // import the GraphQL browse schema
+    "github.com/iliyamo/cinema-seat-reservation/internal/grpcapi"    // import the gRPC booking API
     "github.com/iliyamo/cinema-seat-reservation/internal/handler"    // import handlers for business logic
+    "github.com/iliyamo/cinema-seat-reservation/internal/middleware" // import middleware for metrics, quotas and response formatting
+    "github.com/iliyamo/cinema-seat-reservation/internal/money"      // import the default currency
+    "github.com/iliyamo/cinema-seat-reservation/internal/queue"      // import the message queue backends
     "github.com/iliyamo/cinema-seat-reservation/internal/repository" // import repositories for persistence
     "github.com/iliyamo/cinema-seat-reservation/internal/router"     // import router to register routes
+    "github.com/iliyamo/cinema-seat-reservation/internal/service"    // import background workers
+    "github.com/iliyamo/cinema-seat-reservation/internal/tracing"    // import the span exporter
+    "github.com/iliyamo/cinema-seat-reservation/internal/utils"      // import the JWT key set
 )
 
 // loadDotEnv attempts to load environment variables from a list of potential
@@ -30,30 +51,221 @@ func loadDotEnv() {
     log.Println("env: no .env found; expecting system envs") // if no file found, log that we rely on system env
 }
 
+// workerCheck reports a background worker as down when it has not
+// completed a pass within three intervals or its last pass failed.
+func workerCheck(name string, hb *service.Heartbeat, interval time.Duration) handler.ReadinessCheck {
+    return handler.ReadinessCheck{Name: name, Check: func(context.Context) error {
+        return hb.Check(3 * interval)
+    }}
+}
+
+// requestTimeouts returns the request deadlines: REQUEST_TIMEOUT_SEC for
+// every route, the shorter BOOKING_TIMEOUT_SEC for the requests that lock
+// show seats, and none for the CSV exports, which stream for as long as
+// the download takes.
+func requestTimeouts(cfg config.Config) middleware.TimeoutConfig {
+    booking := time.Duration(cfg.BookingTimeoutSec) * time.Second
+    routes := map[string]time.Duration{
+        "GET /v1/my-reservations/export":        0,
+        "GET /v1/shows/:id/reservations/export":  0,
+    }
+    if booking > 0 {
+        for _, r := range []string{
+            "POST /v1/shows/:id/hold",
+            "DELETE /v1/shows/:id/hold",
+            "POST /v1/shows/:id/hold/extend",
+            "POST /v1/shows/:id/hold/auto",
+            "POST /v1/shows/:id/confirm",
+            "POST /v1/shows/:id/checkout",
+            "POST /v1/cart/checkout",
+            "POST /v1/reservations/claim",
+            "DELETE /v1/reservations/:id",
+        } {
+            routes[r] = booking
+        }
+    }
+    return middleware.TimeoutConfig{Default: time.Duration(cfg.RequestTimeoutSec) * time.Second, Routes: routes}
+}
+
+// logSettings logs the settings that differ from their defaults, secrets
+// redacted, and how many kept their default.  GET /v1/admin/config
+// reports all of them.
+func logSettings(cfg config.Config) {
+    defaults := 0
+    for _, s := range cfg.Settings {
+        if s.Source == config.FromDefault {
+            defaults++
+            continue
+        }
+        log.Printf("config: %s=%s (%s)", s.Key, s.Value, s.Source)
+    }
+    log.Printf("config: %d settings at their defaults", defaults)
+}
+
+// newBotGuard builds the bot check configured by BOT_CHECK_PROVIDER, or
+// returns nil when it is disabled.  Passes are signed with a key derived
+// from BOT_CHECK_SECRET, or with a random per-process key when the
+// secret is empty, in which case replicas do not accept each other's
+// passes and proof-of-work challenges.
+func newBotGuard(cfg config.Config) (*service.BotGuard, error) {
+    if cfg.BotCheckProvider == "" {
+        return nil, nil
+    }
+    key := []byte(cfg.BotCheckSecret)
+    if len(key) == 0 {
+        key = make([]byte, 32)
+        if _, err := rand.Read(key); err != nil {
+            return nil, err
+        }
+    }
+    passKey := sha256.Sum256(append([]byte("bot-pass:"), key...))
+    g := &service.BotGuard{
+        Provider: cfg.BotCheckProvider,
+        SiteKey:  cfg.BotCheckSiteKey,
+        PassKey:  passKey[:],
+        PassTTL:  time.Duration(cfg.BotCheckPassSec) * time.Second,
+    }
+    if cfg.BotCheckProvider == service.BotCheckPoW {
+        g.PoW = service.NewProofOfWork(key, cfg.BotCheckDifficulty, 5*time.Minute)
+        g.Verifier = g.PoW
+        return g, nil
+    }
+    v, err := service.NewCaptchaVerifier(cfg.BotCheckProvider, cfg.BotCheckSecret, cfg.BotCheckVerifyURL)
+    if err != nil {
+        return nil, err
+    }
+    g.Verifier = v
+    return g, nil
+}
+
 // main is the application entry point.  It performs setup of configuration,
 // database connections, route registration and starts the HTTP server.
 func main() {
     loadDotEnv()                            // load environment variables from disk if available
 
-    cfg := config.Load()                    // read required configuration values from the environment; will exit on failure
+    // settings come from -set flags, the environment, then the -config file
+    sources := config.RegisterFlags(flag.CommandLine)
+    flag.Parse()
+    cfg, err := config.LoadFrom(*sources)
+    if err != nil {
+        log.Fatalf("config: %v", err)
+    }
+    logSettings(cfg)
+
+    if err := tracing.Init(tracing.Config{     // ship spans to an OTLP collector when OTEL_TRACES_EXPORTER is set
+        Exporter:    cfg.TracesExporter,
+        Endpoint:    cfg.OTLPEndpoint,
+        Headers:     cfg.OTLPHeaders,
+        ServiceName: cfg.ServiceName,
+        SampleRatio: cfg.TraceSampling,
+    }); err != nil {
+        log.Fatalf("tracing: %v", err)
+    }
+
+    // access tokens are signed with the newest (or JWT_SIGNING_KID) key and
+    // verified against every configured key; see JWT_KEYS
+    keys, err := utils.NewKeySet(cfg.JWTSecret, cfg.JWTKeys, cfg.JWTSigningKID)
+    if err != nil {
+        log.Fatalf("jwt keys: %v", err)
+    }
 
-    db, err := database.Open(cfg.DBUser, cfg.DBPass, cfg.DBHost, cfg.DBPort, cfg.DBName) // open a database connection using the config values
+    if err := money.SetDefault(cfg.Currency); err != nil { // currency of cinemas without their own
+        log.Fatalf("currency config error: %v", err)
+    }
+    db, err := database.Open(cfg.DBUser, cfg.DBPass, cfg.DBHost, cfg.DBPort, cfg.DBName, database.Options{ // open a database connection using the config values
+        MaxOpenConns:    cfg.DBMaxOpenConns,
+        MaxIdleConns:    cfg.DBMaxIdleConns,
+        ConnMaxLifetime: time.Duration(cfg.DBConnMaxLifetimeSec) * time.Second,
+        SlowQuery:       time.Duration(cfg.DBSlowQueryMs) * time.Millisecond,
+    })
     if err != nil {                            // handle any connection error
         log.Fatalf("db connect error: %v", err) // abort the program with an error message
     }
+    database.RegisterPoolMetrics(db)          // connection pool statistics on GET /metrics
     defer db.Close()                          // ensure the database connection is closed when main exits
     log.Println("db connected")               // log that the connection succeeded
 
     e := echo.New()                           // create a new Echo instance which will serve HTTP requests
+    e.HTTPErrorHandler = apperr.Handler       // render every error as {code, message, details}
+    proxies, err := cfg.TrustedProxyNets()    // validated by config.Load
+    if err != nil {
+        log.Fatalf("config: %v", err)
+    }
+    e.IPExtractor = middleware.ClientIP(proxies) // c.RealIP(): forwarded headers only from trusted proxies
+    e.Use(middleware.Metrics())               // request counts and latency for GET /metrics
+    if cfg.GzipMinBytes > 0 {                 // compress larger responses for clients sending Accept-Encoding: gzip
+        e.Use(echomw.GzipWithConfig(echomw.GzipConfig{MinLength: cfg.GzipMinBytes}))
+    }
+    e.Use(middleware.Tracing(cfg.ServiceName)) // root spans for traced requests; see OTEL_* variables
+    e.Use(middleware.Timeout(requestTimeouts(cfg))) // request deadlines, cancelling stuck transactions
+    e.Use(middleware.JSONFormat())            // opt-in camelCase keys and legacy error bodies
+    e.Use(middleware.CacheInvalidation())     // apply cache invalidations after the handler's commits
+    // readiness probes for GET /readyz: MySQL is required; background
+    // workers are reported but only degrade the status
+    readyH := handler.NewReadinessHandler(handler.ReadinessCheck{Name: "mysql", Required: true, Check: db.PingContext})
+    // per-client rate limits with separate budgets for reads, auth
+    // endpoints and customer and owner writes, plus one budget per client
+    // address shared by all its accounts
+    limiter := service.NewRateLimiter(map[string]service.RateLimitPolicy{
+        service.RateClassPublic:   {Burst: cfg.RateLimitPublicBurst, PerMinute: cfg.RateLimitPublicPerMin},
+        service.RateClassAuth:     {Burst: cfg.RateLimitAuthBurst, PerMinute: cfg.RateLimitAuthPerMin},
+        service.RateClassCustomer: {Burst: cfg.RateLimitCustomerBurst, PerMinute: cfg.RateLimitCustomerPerMin},
+        service.RateClassOwner:    {Burst: cfg.RateLimitOwnerBurst, PerMinute: cfg.RateLimitOwnerPerMin},
+        service.RateClassIP:       {Burst: cfg.RateLimitIPBurst, PerMinute: cfg.RateLimitIPPerMin},
+    })
+    e.Use(middleware.IPRateLimit(limiter, keys))
+    // owner API keys in X-API-Key, each with a budget of its own in place
+    // of the account buckets
+    apiKeys := service.NewAPIKeyService(repository.NewAPIKeyRepo(db), cfg.RateLimitAPIKeyPerMin)
+    e.Use(middleware.APIKeyAuth(apiKeys, limiter))
+    e.Use(middleware.RateLimit(limiter, keys))
+    // per-account request quotas; counters are flushed to MySQL in the background
+    qr := repository.NewQuotaRepo(db)
+    var quotas *service.QuotaTracker
+    if cfg.QuotaFlushSec > 0 {
+        quotas = service.NewQuotaTracker(qr, time.Duration(cfg.QuotaFlushSec)*time.Second)
+        go quotas.Run(context.Background())
+        readyH.Checks = append(readyH.Checks, workerCheck("quota_flusher", &quotas.Heartbeat, quotas.FlushInterval))
+        e.Use(middleware.Quota(quotas, keys))
+    }
     // register basic routes that do not require authentication
     router.RegisterRoutes(e)
 
     // initialise repositories and handlers for auth endpoints
     ur := repository.NewUserRepo(db)          // create a user repository using the open database
     tr := repository.NewTokenRepo(db)         // create a token repository using the same database
-    authH := handler.NewAuthHandler(cfg, ur, tr) // create an authentication handler with config and repositories
-    // register auth routes with the JWT secret; this adds both public and protected routes
-    router.RegisterAuth(e, authH, cfg.JWTSecret)
+    authH := handler.NewAuthHandler(cfg, keys, ur, tr) // create an authentication handler with config and repositories
+    // password reset and notification emails go through SMTP when
+    // configured; in development they are logged instead, elsewhere the
+    // reset endpoints are disabled and notifications stay queued
+    switch {
+    case cfg.SMTPAddr != "":
+        authH.Mailer = &service.SMTPMailer{Addr: cfg.SMTPAddr, From: cfg.SMTPFrom, Username: cfg.SMTPUser, Password: cfg.SMTPPass}
+    case cfg.Env == "dev":
+        authH.Mailer = service.LogMailer{}
+    }
+    auditr := repository.NewAuditRepo(db) // audit log shared by handlers and services
+    // failed login throttling with escalating lockouts
+    if cfg.LoginMaxFailures > 0 {
+        authH.Guard = &service.LoginGuard{
+            Repo:               repository.NewLoginThrottleRepo(db),
+            Audit:              auditr,
+            MaxAccountFailures: uint32(cfg.LoginMaxFailures),
+            MaxIPFailures:      uint32(cfg.LoginMaxIPFailures),
+            FailureWindow:      time.Duration(cfg.LoginWindowSec) * time.Second,
+            LockoutBase:        time.Duration(cfg.LoginLockoutSec) * time.Second,
+            LockoutMax:         time.Duration(cfg.LoginLockoutMaxSec) * time.Second,
+        }
+    }
+    // optional CAPTCHA or proof-of-work check on registration, login and
+    // the public seat maps
+    botGuard, err := newBotGuard(cfg)
+    if err != nil {
+        log.Fatalf("bot check: %v", err)
+    }
+    authH.BotCheck = botGuard
+    // register auth routes with the JWT key set; this adds both public and protected routes
+    router.RegisterAuth(e, authH, keys)
 
     // initialise repositories for owner operations.  Cinemas, halls, seats,
     // shows and show seats each have their own repository to isolate
@@ -67,32 +279,300 @@ func main() {
         // can be used by both public and customer handlers
         shr := repository.NewSeatHoldRepo(db)        // seat hold repository
         rr := repository.NewReservationRepo(db)      // reservation repository
-        // construct the public handler for unauthenticated browse endpoints.  Include SeatRepo, ShowSeatRepo and SeatHoldRepo
+        // owners' webhook subscriptions; deliveries are queued with the
+        // reservation changes and show cancellations they report
+        whr := repository.NewWebhookRepo(db)
+        rr.Webhooks = whr
+        // record booking events in the outbox when a queue is configured
+        var eventQueue queue.Publisher
+        if cfg.QueueBackend != "" {
+            pub, consumer, err := queue.Open(queue.Config{Backend: cfg.QueueBackend, FileDir: cfg.QueueFileDir})
+            if err != nil {
+                log.Fatalf("queue: %v", err)
+            }
+            eventQueue = pub
+            rr.Events = repository.NewBookingEventRepo(db)
+            // the outbox keeps events while the queue is down, so neither
+            // check is required
+            if p, ok := pub.(queue.Pinger); ok {
+                readyH.Checks = append(readyH.Checks, handler.ReadinessCheck{Name: "queue", Check: p.Ping})
+            }
+            if consumer != nil {
+                readyH.Checks = append(readyH.Checks, handler.ReadinessCheck{
+                    Name:  "booking_consumer",
+                    Check: service.StartBookingConsumer(context.Background(), consumer),
+                })
+            }
+        }
+        // construct the public handler for unauthenticated browse endpoints.  Include SeatRepo and ShowSeatRepo
         publicH := &handler.PublicHandler{
             CinemaRepo:   cr,
             HallRepo:     hr,
             ShowRepo:     shwr,
             SeatRepo:     sr,
             ShowSeatRepo: ssr,
-            SeatHoldRepo: shr,
+            SearchRepo:   repository.NewSearchRepo(db),
+            Browse:       graphql.NewBrowseSchema(cr, hr, shwr, ssr), // GraphQL view of the browse endpoints
+            Booking:      service.NewBookingService(shwr, ssr, shr, rr), // seat quotes
+        }
+        sar := repository.NewSeatAccessibilityRepo(db) // seat accessibility attributes
+        publicH.AccessibilityRepo = sar
+        // register public routes before protected owner and customer routes;
+        // they answer conditional GETs with 304, and their responses are
+        // cached until seats or the catalog change
+        publicMW := []echo.MiddlewareFunc{middleware.ConditionalGET()}
+        if cfg.CacheTTLSec > 0 {
+            responses := cache.New(time.Duration(cfg.CacheTTLSec) * time.Second)
+            cache.Enable(responses)
+            publicMW = append(publicMW, middleware.ResponseCache(responses))
         }
-        // register public routes before protected owner and customer routes
-        router.RegisterPublic(e, publicH)
+        router.RegisterPublic(e, publicH, keys, botGuard, publicMW...)
         // construct the owner handler with all the repositories
         ownerH := handler.NewOwnerHandler(cr, hr, sr, shwr, ssr)
+        ownerH.AccessibilityRepo = sar
+        ownerH.SeatHoldRepo = shr
+        ownerH.ReservationRepo = rr
+        notr := repository.NewNotificationRepo(db) // queued customer notifications
+        ownerH.Notifications = notr
+        onr := repository.NewOwnerNotificationRepo(db) // owner notification preferences
+        ownerH.NotificationSettings = onr
+        refr := repository.NewRefundRepo(db) // refunds of paid reservations
+        ownerH.RefundRepo = refr
+        ownerH.ShowBuffer = time.Duration(cfg.ShowBufferMin) * time.Minute
+        // default scheduling window; cinemas may set their own
+        ownerH.Shows.MinLead = time.Duration(cfg.ShowLeadMin) * time.Minute
+        ownerH.Shows.MaxHorizon = time.Duration(cfg.ShowMaxDays) * 24 * time.Hour
+        // blackout dates and special-date pricing per cinema
+        calr := repository.NewCalendarRepo(db)
+        ownerH.CalendarRepo = calr
+        ownerH.Shows.CalendarRepo = calr
+        ownerH.Audit = auditr
+        ownerH.Webhooks = whr
         // register owner routes requiring JWT auth and OWNER role
-        router.RegisterOwner(e, ownerH, cfg.JWTSecret)
+        router.RegisterOwner(e, ownerH, keys)
+        calH := handler.NewOwnerCalendarHandler(calr, cr)
+        calH.Audit = auditr
+        router.RegisterOwnerCalendar(e, calH, keys)
         // construct reservation handler for owners and register owner reservation routes
         ownerResH := handler.NewOwnerReservationHandler(rr, shwr, hr, ssr)
-        router.RegisterOwnerReservations(e, ownerResH, cfg.JWTSecret)
+        ownerResH.Audit = auditr
+        ownerResH.RefundRepo = refr
+        router.RegisterOwnerReservations(e, ownerResH, keys)
+        // staff memberships delegating hall management to other users
+        staffH := handler.NewOwnerStaffHandler(repository.NewStaffRepo(db), cr, hr, ur)
+        staffH.Audit = auditr
+        router.RegisterOwnerStaff(e, staffH, keys)
+        // API keys for partners' servers
+        apiKeyH := handler.NewOwnerAPIKeyHandler(apiKeys)
+        apiKeyH.Audit = auditr
+        router.RegisterOwnerAPIKeys(e, apiKeyH, keys)
+        // webhook subscriptions and their delivery log
+        webhookH := handler.NewOwnerWebhookHandler(whr)
+        webhookH.Audit = auditr
+        router.RegisterOwnerWebhooks(e, webhookH, keys)
+        // audit log queries for admins and owners
+        router.RegisterAudit(e, handler.NewAuditHandler(auditr), keys)
 
         // construct the customer handler with required repositories.  It uses the same
         // seat hold and reservation repositories as the public handler
         customerH := handler.NewCustomerHandler(sr, shwr, ssr, shr, rr, hr, cr)
+        customerH.Audit = auditr
+        customerH.RefundRepo = refr
+        customerH.TransferRepo = repository.NewTransferRepo(db)
+        customerH.TransferTTL = time.Duration(cfg.TransferTTLHours) * time.Hour
+        favr := repository.NewFavoriteRepo(db) // followed cinemas and movies
+        customerH.Favorites = favr
+        customerH.Users = ur                   // dates of birth for age-restricted shows
+        customerH.Booking.Users = ur
+        customerH.Booking.HoldTTL = time.Duration(cfg.HoldTTLSec) * time.Second
+        customerH.Booking.HoldExtendBy = time.Duration(cfg.HoldExtendSec) * time.Second
+        customerH.Booking.HoldMaxTotal = time.Duration(cfg.HoldMaxSec) * time.Second
+        customerH.Booking.MaxHeldShows = cfg.HoldMaxShows
+        // seat upgrades; the price difference of paid reservations is
+        // charged once a payment gateway is configured below
+        upgrades := service.NewSeatUpgradeService(rr, ssr)
+        customerH.Upgrades = upgrades
+        // promo codes are created by owners and redeemed on confirmation
+        pcr := repository.NewPromoCodeRepo(db)
+        customerH.Booking.PromoCodeRepo = pcr
+        promoH := handler.NewOwnerPromoHandler(pcr)
+        promoH.Audit = auditr
+        router.RegisterOwnerPromos(e, promoH, keys)
+        // multi-show bundles with atomic checkout across shows
+        br := repository.NewBundleRepo(db)
+        bundleSvc := service.NewBundleService(br, shwr, ssr, shr, rr)
+        bundleH := handler.NewBundleHandler(br, bundleSvc)
+        bundleH.Audit = auditr
+        router.RegisterBundles(e, bundleH, keys)
+        // paid checkout through the booking saga; without a payment
+        // gateway POST /v1/shows/:id/checkout answers 501
+        switch cfg.PaymentGateway {
+        case "":
+        case "fake":
+            sagar := repository.NewBookingSagaRepo(db)
+            gateway := service.NewFakePaymentGateway()
+            sagaSvc := service.NewBookingSagaService(sagar, rr, ssr, gateway, time.Duration(cfg.SagaTimeoutSec)*time.Second)
+            customerH.Saga = sagaSvc
+            upgrades.SagaRepo = sagar
+            upgrades.Payments = gateway
+            if cfg.SagaRecoverSec > 0 {
+                sagaSvc.RecoveryInterval = time.Duration(cfg.SagaRecoverSec) * time.Second
+                go sagaSvc.Run(context.Background())
+                readyH.Checks = append(readyH.Checks, workerCheck("saga_recovery", &sagaSvc.Heartbeat, sagaSvc.RecoveryInterval))
+            }
+            // approved refunds of checkouts are returned through the same
+            // gateway; other refunds are settled by the owner
+            refunds := service.NewRefundService(refr, sagar, rr, gateway, time.Duration(cfg.RefundSweepSec)*time.Second)
+            ownerResH.Refunds = refunds
+            if cfg.RefundSweepSec > 0 {
+                go refunds.Run(context.Background())
+                readyH.Checks = append(readyH.Checks, workerCheck("refunds", &refunds.Heartbeat, refunds.Interval))
+            }
+        default:
+            log.Fatalf("unknown PAYMENT_GATEWAY %q", cfg.PaymentGateway)
+        }
+        // waitlists for sold-out shows: cancelled and released seats are
+        // held for the next customer in line
+        var waitlist *service.WaitlistService
+        if cfg.WaitlistOfferSec > 0 && cfg.WaitlistSweepSec > 0 {
+            waitlist = service.NewWaitlistService(repository.NewWaitlistRepo(db), sr, ssr, shr,
+                time.Duration(cfg.WaitlistOfferSec)*time.Second, time.Duration(cfg.WaitlistSweepSec)*time.Second)
+            customerH.Waitlist = waitlist
+            customerH.Booking.Waitlist = waitlist
+            ownerResH.Waitlist = waitlist
+            ownerH.Waitlist = waitlist
+            upgrades.OnFreed = func(showID uint64, _ []uint64) { waitlist.Notify(showID) }
+            go waitlist.Run(context.Background())
+            readyH.Checks = append(readyH.Checks, workerCheck("waitlist", &waitlist.Heartbeat, waitlist.Interval))
+        }
         // register customer routes requiring JWT auth and CUSTOMER role
-        router.RegisterCustomer(e, customerH, cfg.JWTSecret)
+        router.RegisterCustomer(e, customerH, keys)
+
+        // admin-only operational endpoints
+        adminH := handler.NewAdminHandler(repository.NewDiagnosticsRepo(db))
+        adminH.QuotaRepo = qr
+        adminH.Quotas = quotas
+        adminH.Keys = keys
+        adminH.Settings = cfg.Settings
+        adminH.StatsRepo = repository.NewStatsRepo(db)
+        router.RegisterAdmin(e, adminH, keys)
+
+        // release expired seat holds in the background so seats become
+        // FREE promptly instead of waiting for the next request to a show
+        if cfg.HoldSweepSec > 0 {
+            sweeper := service.NewHoldExpiryWorker(shr, ssr, time.Duration(cfg.HoldSweepSec)*time.Second)
+            if waitlist != nil {
+                sweeper.OnExpired = func(showID uint64, _ []uint64) { waitlist.Notify(showID) }
+            }
+            // guest sessions nobody converted are dropped once they lapse
+            sweeper.Guests, sweeper.GuestTTL = ur, time.Duration(cfg.GuestTTLMin)*time.Minute
+            go sweeper.Run(context.Background())
+            readyH.Checks = append(readyH.Checks, workerCheck("hold_sweeper", &sweeper.Heartbeat, sweeper.Interval))
+        }
+        // drop PENDING reservations that were not paid in time and free
+        // their seats
+        if cfg.PendingSweepSec > 0 {
+            expirer := service.NewPendingExpiryWorker(rr, ssr,
+                time.Duration(cfg.PendingTTLSec)*time.Second, time.Duration(cfg.PendingSweepSec)*time.Second)
+            if waitlist != nil {
+                expirer.OnExpired = func(showID uint64, _ []uint64) { waitlist.Notify(showID) }
+            }
+            go expirer.Run(context.Background())
+            readyH.Checks = append(readyH.Checks, workerCheck("pending_expiry", &expirer.Heartbeat, expirer.Interval))
+        }
+        // email queued customer notifications such as show cancellations
+        if authH.Mailer != nil && cfg.NotifySweepSec > 0 {
+            notifier := service.NewNotificationWorker(notr, authH.Mailer, time.Duration(cfg.NotifySweepSec)*time.Second)
+            go notifier.Run(context.Background())
+            readyH.Checks = append(readyH.Checks, workerCheck("notifier", &notifier.Heartbeat, notifier.Interval))
+        }
+        // tell followers of cinemas and movies about new shows; the
+        // notifications are delivered with the others
+        if cfg.AnnounceSweepSec > 0 {
+            announcer := service.NewShowAnnouncer(favr, notr, time.Duration(cfg.AnnounceSweepSec)*time.Second)
+            go announcer.Run(context.Background())
+            readyH.Checks = append(readyH.Checks, workerCheck("show_announcer", &announcer.Heartbeat, announcer.Interval))
+        }
+        // email owners who asked for it a daily summary of bookings
+        if cfg.DigestSweepSec > 0 {
+            digests := service.NewOwnerDigestWorker(onr, notr, time.Duration(cfg.DigestSweepSec)*time.Second)
+            go digests.Run(context.Background())
+            readyH.Checks = append(readyH.Checks, workerCheck("owner_digest", &digests.Heartbeat, digests.Interval))
+        }
+        // repair seat statuses that drifted from holds and reservations
+        if cfg.ReconcileSweepSec > 0 {
+            reconciler := service.NewSeatReconcileWorker(ssr, time.Duration(cfg.ReconcileSweepSec)*time.Second)
+            if waitlist != nil {
+                reconciler.OnFreed = func(showID uint64, _ []uint64) { waitlist.Notify(showID) }
+            }
+            go reconciler.Run(context.Background())
+            readyH.Checks = append(readyH.Checks, workerCheck("seat_reconcile", &reconciler.Heartbeat, reconciler.Interval))
+        }
+        // mark seats nobody checked in to by the end of their show
+        if cfg.NoShowSweepSec > 0 {
+            noShows := service.NewNoShowWorker(rr, time.Duration(cfg.NoShowSweepSec)*time.Second)
+            go noShows.Run(context.Background())
+            readyH.Checks = append(readyH.Checks, workerCheck("no_show", &noShows.Heartbeat, noShows.Interval))
+        }
+        // move shows whose end time has passed to FINISHED
+        if cfg.ShowFinishSweepSec > 0 {
+            finisher := service.NewShowFinishWorker(shwr, time.Duration(cfg.ShowFinishSweepSec)*time.Second)
+            go finisher.Run(context.Background())
+            readyH.Checks = append(readyH.Checks, workerCheck("show_finish", &finisher.Heartbeat, finisher.Interval))
+        }
+        // publish the booking event outbox to the queue
+        if eventQueue != nil && cfg.BookingEventsSec > 0 {
+            relay := service.NewEventRelay(rr.Events, eventQueue, time.Duration(cfg.BookingEventsSec)*time.Second)
+            go relay.Run(context.Background())
+            readyH.Checks = append(readyH.Checks, workerCheck("event_relay", &relay.Heartbeat, relay.Interval))
+        }
+        // post queued webhook deliveries to the owners' URLs
+        if cfg.WebhookSweepSec > 0 {
+            dispatcher := service.NewWebhookDispatcher(whr, time.Duration(cfg.WebhookSweepSec)*time.Second, time.Duration(cfg.WebhookTimeoutSec)*time.Second)
+            go dispatcher.Run(context.Background())
+            readyH.Checks = append(readyH.Checks, workerCheck("webhook_dispatcher", &dispatcher.Heartbeat, dispatcher.Interval))
+        }
+        router.RegisterReadiness(e, readyH)
+
+    // gRPC booking API for kiosks on its own address; it shares the
+    // customer handlers' booking service
+    var grpcSrv *grpcapi.Server
+    if cfg.GRPCAddr != "" {
+        grpcSrv = grpcapi.NewServer(customerH.Booking, shwr, hr, keys)
+        grpcSrv.Limiter, grpcSrv.Quotas = limiter, quotas // the REST budgets apply to gRPC calls too
+        go func() {
+            log.Printf("gRPC listening on %s", cfg.GRPCAddr)
+            if err := grpcSrv.ListenAndServe(cfg.GRPCAddr); err != nil {
+                log.Fatalf("grpc: %v", err)
+            }
+        }()
+    }
 
     addr := ":" + cfg.Port                    // build the address string using the configured port
     log.Printf("listening on %s (env=%s)", addr, cfg.Env) // log where the server is about to start
-    log.Fatal(e.Start(addr))                   // start serving HTTP requests and exit if the server returns an error
+    go func() {
+        if err := e.Start(addr); err != nil && !errors.Is(err, http.ErrServerClosed) {
+            log.Fatal(err)                     // exit if the server fails rather than being shut down
+        }
+    }()
+
+    // on SIGINT/SIGTERM stop accepting requests and give running ones up
+    // to 15 seconds to finish
+    ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+    defer stop()
+    <-ctx.Done()
+    log.Println("shutting down")
+    shutdownCtx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+    defer cancel()
+    if grpcSrv != nil {
+        if err := grpcSrv.Shutdown(shutdownCtx); err != nil {
+            log.Printf("grpc shutdown: %v", err)
+        }
+    }
+    if err := e.Shutdown(shutdownCtx); err != nil {
+        log.Printf("http shutdown: %v", err)
+    }
+    if err := tracing.Shutdown(shutdownCtx); err != nil { // export the spans still queued
+        log.Printf("tracing shutdown: %v", err)
+    }
 }
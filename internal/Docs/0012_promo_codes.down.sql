@@ -0,0 +1,7 @@
+-- Rollback for 0012_promo_codes.up.sql
+ALTER TABLE reservations
+  DROP FOREIGN KEY fk_res_promo,
+  DROP COLUMN promo_code_id,
+  DROP COLUMN discount_cents;
+
+DROP TABLE IF EXISTS promo_codes;
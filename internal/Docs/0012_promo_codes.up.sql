@@ -0,0 +1,29 @@
+-- Promo codes: owner-defined discounts applied when a customer confirms
+-- held seats.  A code belongs to one owner and is only redeemable for
+-- shows in halls owned by that owner.  discount_type selects how
+-- discount_value is interpreted: PERCENT (0-100) or FIXED (cents).
+CREATE TABLE IF NOT EXISTS promo_codes (
+  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  owner_id BIGINT UNSIGNED NOT NULL,
+  code VARCHAR(64) NOT NULL,
+  discount_type ENUM('PERCENT','FIXED') NOT NULL,
+  discount_value INT UNSIGNED NOT NULL,
+  valid_from DATETIME NULL,                       -- NULL = valid immediately
+  valid_until DATETIME NULL,                      -- NULL = never expires
+  max_uses INT UNSIGNED NULL,                     -- NULL = unlimited redemptions
+  used_count INT UNSIGNED NOT NULL DEFAULT 0,
+  is_active TINYINT(1) NOT NULL DEFAULT 1,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (id),
+  UNIQUE KEY uk_promo_code (code),
+  KEY idx_promo_owner (owner_id),
+  CONSTRAINT fk_promo_owner FOREIGN KEY (owner_id) REFERENCES users(id) ON DELETE RESTRICT
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- Record which promo code (if any) was redeemed on a reservation and the
+-- discount that was subtracted from the seat total.
+ALTER TABLE reservations
+  ADD COLUMN promo_code_id BIGINT UNSIGNED NULL AFTER total_amount_cents,
+  ADD COLUMN discount_cents INT UNSIGNED NOT NULL DEFAULT 0 AFTER promo_code_id,
+  ADD CONSTRAINT fk_res_promo FOREIGN KEY (promo_code_id) REFERENCES promo_codes(id) ON DELETE SET NULL;
@@ -0,0 +1,3 @@
+-- Rollback for 0013_seat_hold_prices.up.sql
+ALTER TABLE seat_holds
+  DROP COLUMN price_cents;
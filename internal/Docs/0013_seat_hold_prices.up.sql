@@ -0,0 +1,6 @@
+-- Capture the price quoted to the customer when a seat is held.  The
+-- confirmation step compares this against the current show_seats price so
+-- that an owner price change between hold and confirm is never charged
+-- silently.  NULL means the hold predates this column (no guard applied).
+ALTER TABLE seat_holds
+  ADD COLUMN price_cents INT UNSIGNED NULL AFTER hold_token;
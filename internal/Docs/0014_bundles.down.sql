@@ -0,0 +1,8 @@
+-- Rollback for 0014_bundles.up.sql
+ALTER TABLE reservations
+  DROP FOREIGN KEY fk_res_bundle,
+  DROP INDEX idx_res_bundle,
+  DROP COLUMN bundle_id;
+
+DROP TABLE IF EXISTS bundle_shows;
+DROP TABLE IF EXISTS bundles;
@@ -0,0 +1,32 @@
+-- Bundles: owner-defined packages of several specific shows (e.g. a movie
+-- marathon) sold together at a discount.  Purchasing a bundle reserves a
+-- seat selection in every included show atomically.
+CREATE TABLE IF NOT EXISTS bundles (
+  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  owner_id BIGINT UNSIGNED NOT NULL,
+  name VARCHAR(255) NOT NULL,
+  discount_percent TINYINT UNSIGNED NOT NULL DEFAULT 0,   -- applied to each show's seat prices
+  is_active TINYINT(1) NOT NULL DEFAULT 1,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (id),
+  KEY idx_bundles_owner (owner_id),
+  CONSTRAINT chk_bundle_discount CHECK (discount_percent <= 100),
+  CONSTRAINT fk_bundles_owner FOREIGN KEY (owner_id) REFERENCES users(id) ON DELETE RESTRICT
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS bundle_shows (
+  bundle_id BIGINT UNSIGNED NOT NULL,
+  show_id BIGINT UNSIGNED NOT NULL,
+  PRIMARY KEY (bundle_id, show_id),
+  KEY idx_bundle_shows_show (show_id),
+  CONSTRAINT fk_bundle_shows_bundle FOREIGN KEY (bundle_id) REFERENCES bundles(id) ON DELETE CASCADE,
+  CONSTRAINT fk_bundle_shows_show FOREIGN KEY (show_id) REFERENCES shows(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- Reservations created by a bundle checkout reference the bundle so they
+-- can be grouped together.
+ALTER TABLE reservations
+  ADD COLUMN bundle_id BIGINT UNSIGNED NULL AFTER discount_cents,
+  ADD KEY idx_res_bundle (bundle_id),
+  ADD CONSTRAINT fk_res_bundle FOREIGN KEY (bundle_id) REFERENCES bundles(id) ON DELETE SET NULL;
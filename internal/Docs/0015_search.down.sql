@@ -0,0 +1,8 @@
+-- Rollback for 0015_search.up.sql
+ALTER TABLE shows
+  DROP INDEX idx_shows_title,
+  DROP INDEX idx_shows_starts;
+
+ALTER TABLE cinemas
+  DROP INDEX idx_cinemas_city,
+  DROP COLUMN city;
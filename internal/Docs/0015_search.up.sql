@@ -0,0 +1,10 @@
+-- Support the public search endpoint (GET /v1/search).
+-- Cinemas gain an optional city so guests can narrow results by location,
+-- and show titles get an index for prefix LIKE lookups.
+ALTER TABLE cinemas
+  ADD COLUMN city VARCHAR(100) NULL AFTER name,
+  ADD KEY idx_cinemas_city (city);
+
+ALTER TABLE shows
+  ADD KEY idx_shows_title (title),
+  ADD KEY idx_shows_starts (starts_at);
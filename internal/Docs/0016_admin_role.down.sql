@@ -0,0 +1,4 @@
+-- Rollback for 0016_admin_role.up.sql
+-- Demote any admins to CUSTOMER before removing the role.
+UPDATE users SET role_id = 1 WHERE role_id = 3;
+DELETE FROM roles WHERE id = 3;
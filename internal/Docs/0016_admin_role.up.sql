@@ -0,0 +1,5 @@
+-- Add an ADMIN role for operational endpoints (diagnostics, stats).
+-- Admin accounts cannot be self-registered; promote an existing user with:
+--   UPDATE users SET role_id = 3 WHERE email = '...';
+INSERT INTO roles (id, name) VALUES (3, 'ADMIN')
+ON DUPLICATE KEY UPDATE name = VALUES(name);
@@ -0,0 +1,2 @@
+ALTER TABLE shows
+  DROP COLUMN hold_ttl_sec;
@@ -0,0 +1,4 @@
+-- Per-show override for the seat hold duration.  NULL uses the default
+-- configured through HOLD_TTL_SEC.
+ALTER TABLE shows
+  ADD COLUMN hold_ttl_sec INT UNSIGNED NULL AFTER status;
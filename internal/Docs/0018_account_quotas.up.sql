@@ -0,0 +1,23 @@
+-- Per-account API request quotas.  A row limits an account to a number of
+-- authenticated requests per UTC day and/or calendar month; NULL means
+-- unlimited for that period.  Accounts without a row are not limited.
+CREATE TABLE IF NOT EXISTS account_quotas (
+  user_id BIGINT UNSIGNED NOT NULL,
+  daily_limit INT UNSIGNED NULL,
+  monthly_limit INT UNSIGNED NULL,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (user_id),
+  CONSTRAINT fk_quota_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- Request counters per account and period.  period_start is the first day
+-- of the period (the day itself for DAY, the 1st of the month for MONTH).
+-- Counts are accumulated in memory and flushed here periodically.
+CREATE TABLE IF NOT EXISTS account_usage (
+  user_id BIGINT UNSIGNED NOT NULL,
+  period ENUM('DAY','MONTH') NOT NULL,
+  period_start DATE NOT NULL,
+  request_count INT UNSIGNED NOT NULL DEFAULT 0,
+  PRIMARY KEY (user_id, period, period_start),
+  CONSTRAINT fk_usage_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,12 @@
+-- Accessibility attributes extending seats.  A seat without a row has no
+-- accessibility features.  Rows are removed together with their seat.
+CREATE TABLE IF NOT EXISTS seat_accessibility (
+  seat_id BIGINT UNSIGNED NOT NULL,
+  wheelchair_space TINYINT(1) NOT NULL DEFAULT 0,  -- space for a wheelchair instead of a seat
+  companion_seat TINYINT(1) NOT NULL DEFAULT 0,    -- seat next to a wheelchair space for a companion
+  transfer_seat TINYINT(1) NOT NULL DEFAULT 0,     -- seat with movable armrest for transferring from a wheelchair
+  step_free_access TINYINT(1) NOT NULL DEFAULT 0,  -- reachable from the entrance without steps
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (seat_id),
+  CONSTRAINT fk_access_seat FOREIGN KEY (seat_id) REFERENCES seats(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
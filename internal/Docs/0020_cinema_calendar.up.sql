@@ -0,0 +1,17 @@
+-- Per-cinema calendar of blackout and special dates maintained by owners.
+-- A BLACKOUT date forbids scheduling shows that touch it; a SPECIAL date
+-- scales the seat prices of shows starting on it by price_multiplier_pct
+-- (100 = base price, 150 = +50%).  Dates are calendar days in UTC.
+CREATE TABLE IF NOT EXISTS cinema_calendar (
+  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  cinema_id BIGINT UNSIGNED NOT NULL,
+  date DATE NOT NULL,
+  kind ENUM('BLACKOUT','SPECIAL') NOT NULL,
+  price_multiplier_pct INT UNSIGNED NOT NULL DEFAULT 100,  -- ignored for BLACKOUT
+  label VARCHAR(100) NOT NULL DEFAULT '',                  -- e.g. "New Year's Day"
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (id),
+  UNIQUE KEY uk_calendar_cinema_date (cinema_id, date),
+  CONSTRAINT fk_calendar_cinema FOREIGN KEY (cinema_id) REFERENCES cinemas(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,29 @@
+-- Booking sagas: persisted state of the hold -> pending -> payment ->
+-- confirm flow so a recovery worker can finish or compensate bookings
+-- interrupted by a crash or a payment failure.
+--   RESERVED      reservation PENDING and seats RESERVED; not yet charged
+--   CHARGING      a charge may be in flight (idempotency key saga-<id>)
+--   PAID          charged; reservation still PENDING
+--   CONFIRMED     reservation CONFIRMED (terminal)
+--   COMPENSATING  voiding the charge and releasing the seats
+--   COMPENSATED   charge voided, reservation removed, seats FREE (terminal)
+-- deadline_at is when the recovery worker may take over an unfinished saga.
+CREATE TABLE IF NOT EXISTS booking_sagas (
+  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  user_id BIGINT UNSIGNED NOT NULL,
+  show_id BIGINT UNSIGNED NOT NULL,
+  reservation_id BIGINT UNSIGNED NULL,
+  state ENUM('RESERVED','CHARGING','PAID','CONFIRMED','COMPENSATING','COMPENSATED') NOT NULL,
+  amount_cents INT UNSIGNED NOT NULL,
+  payment_ref VARCHAR(128) NULL,
+  last_error VARCHAR(255) NULL,
+  deadline_at DATETIME NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (id),
+  KEY idx_saga_user (user_id),
+  KEY idx_saga_state_deadline (state, deadline_at),
+  CONSTRAINT fk_saga_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE RESTRICT,
+  CONSTRAINT fk_saga_show FOREIGN KEY (show_id) REFERENCES shows(id) ON DELETE RESTRICT,
+  CONSTRAINT fk_saga_reservation FOREIGN KEY (reservation_id) REFERENCES reservations(id) ON DELETE SET NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
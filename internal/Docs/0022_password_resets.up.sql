@@ -0,0 +1,16 @@
+-- Password reset tokens.  Only the SHA-256 hash of the emailed token is
+-- stored.  A token is valid until expires_at and can be used once;
+-- used_at is set when it is redeemed (or superseded by another redemption
+-- for the same user).
+CREATE TABLE IF NOT EXISTS password_resets (
+  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  user_id BIGINT UNSIGNED NOT NULL,
+  token_hash CHAR(64) NOT NULL,
+  expires_at DATETIME NOT NULL,
+  used_at DATETIME NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (id),
+  UNIQUE KEY uq_password_reset_hash (token_hash),
+  KEY idx_password_reset_user (user_id, created_at),
+  CONSTRAINT fk_password_reset_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
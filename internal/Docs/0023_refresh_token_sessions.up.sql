@@ -0,0 +1,8 @@
+-- Session metadata for refresh tokens so users can review and revoke the
+-- devices they are signed in on (GET/DELETE /v1/me/sessions).  user_agent
+-- and ip are captured when the token is issued; last_used_at is updated
+-- when the token is exchanged for an access token without rotation.
+ALTER TABLE refresh_tokens
+  ADD COLUMN user_agent VARCHAR(255) NULL AFTER revoked_at,
+  ADD COLUMN ip VARCHAR(45) NULL AFTER user_agent,
+  ADD COLUMN last_used_at DATETIME NULL AFTER ip;
@@ -0,0 +1,17 @@
+-- Append-only audit trail of security-relevant and state-changing events.
+-- actor_id is NULL for anonymous or system actions; entity_id is kept as a
+-- string so rows can reference users, emails or IP addresses alike.
+CREATE TABLE IF NOT EXISTS audit_log (
+  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  actor_id BIGINT UNSIGNED NULL,
+  action VARCHAR(64) NOT NULL,
+  entity_type VARCHAR(32) NOT NULL,
+  entity_id VARCHAR(255) NULL,
+  ip VARCHAR(45) NULL,
+  details JSON NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (id),
+  KEY idx_audit_entity (entity_type, entity_id, created_at),
+  KEY idx_audit_actor (actor_id, created_at),
+  KEY idx_audit_action (action, created_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
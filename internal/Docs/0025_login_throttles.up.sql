@@ -0,0 +1,15 @@
+-- Failed login tracking per account (scope ACCOUNT, subject = lowercased
+-- email) and per client address (scope IP).  failures counts recent
+-- consecutive failures; once it reaches the configured limit the subject is
+-- locked until locked_until and lockouts is incremented so the next lockout
+-- lasts twice as long.
+CREATE TABLE IF NOT EXISTS login_throttles (
+  scope ENUM('ACCOUNT','IP') NOT NULL,
+  subject VARCHAR(255) NOT NULL,
+  failures INT UNSIGNED NOT NULL DEFAULT 0,
+  lockouts INT UNSIGNED NOT NULL DEFAULT 0,
+  locked_until DATETIME NULL,
+  last_failure_at DATETIME NOT NULL,
+  PRIMARY KEY (scope, subject),
+  KEY idx_login_throttle_last_failure (last_failure_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
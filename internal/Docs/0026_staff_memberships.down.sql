@@ -0,0 +1,5 @@
+-- Rollback for 0026_staff_memberships.up.sql
+-- Demote any staff to CUSTOMER before removing the role.
+DROP TABLE IF EXISTS staff_memberships;
+UPDATE users SET role_id = 1 WHERE role_id = 4;
+DELETE FROM roles WHERE id = 4;
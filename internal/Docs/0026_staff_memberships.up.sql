@@ -0,0 +1,24 @@
+-- Owner staff.  A STAFF user manages the halls (and their shows and
+-- reservations) of the cinemas an owner has granted them, but cannot
+-- create or delete cinemas, halls or seats.  hall_id NULL grants every
+-- hall of the cinema; hall_key folds NULL to 0 so the unique key also
+-- rejects duplicate cinema-wide grants.
+INSERT INTO roles (id, name) VALUES (4, 'STAFF')
+ON DUPLICATE KEY UPDATE name = VALUES(name);
+
+CREATE TABLE IF NOT EXISTS staff_memberships (
+  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  owner_id BIGINT UNSIGNED NOT NULL,
+  staff_user_id BIGINT UNSIGNED NOT NULL,
+  cinema_id BIGINT UNSIGNED NOT NULL,
+  hall_id BIGINT UNSIGNED NULL,
+  hall_key BIGINT UNSIGNED AS (IFNULL(hall_id, 0)) STORED,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (id),
+  UNIQUE KEY uk_staff_membership (staff_user_id, cinema_id, hall_key),
+  KEY idx_staff_owner (owner_id),
+  CONSTRAINT fk_staff_owner FOREIGN KEY (owner_id) REFERENCES users(id) ON DELETE CASCADE,
+  CONSTRAINT fk_staff_user FOREIGN KEY (staff_user_id) REFERENCES users(id) ON DELETE CASCADE,
+  CONSTRAINT fk_staff_cinema FOREIGN KEY (cinema_id) REFERENCES cinemas(id) ON DELETE CASCADE,
+  CONSTRAINT fk_staff_hall FOREIGN KEY (hall_id) REFERENCES halls(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,5 @@
+ALTER TABLE audit_log
+  DROP KEY idx_audit_owner,
+  DROP COLUMN after_state,
+  DROP COLUMN before_state,
+  DROP COLUMN owner_id;
@@ -0,0 +1,8 @@
+-- Before/after snapshots for audited mutations.  owner_id is the owner of
+-- the affected resource (NULL for account-level events) so owners can read
+-- the history of their own cinemas, halls, shows and reservations.
+ALTER TABLE audit_log
+  ADD COLUMN owner_id BIGINT UNSIGNED NULL AFTER actor_id,
+  ADD COLUMN before_state JSON NULL AFTER details,
+  ADD COLUMN after_state JSON NULL AFTER before_state,
+  ADD KEY idx_audit_owner (owner_id, created_at);
@@ -0,0 +1,2 @@
+-- Rollback for 0028_waitlist.up.sql
+DROP TABLE IF EXISTS waitlist_entries;
@@ -0,0 +1,24 @@
+-- Waitlist for sold-out shows.  Entries are served in id order: when
+-- enough seats come free the head WAITING entry is OFFERED a seat hold
+-- expiring at offer_expires_at.  open_key is 1 while an entry is WAITING
+-- or OFFERED and NULL afterwards, so a user has at most one open entry per
+-- show but may join again later.
+CREATE TABLE IF NOT EXISTS waitlist_entries (
+  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  show_id BIGINT UNSIGNED NOT NULL,
+  user_id BIGINT UNSIGNED NOT NULL,
+  seat_count TINYINT UNSIGNED NOT NULL,
+  adjacent TINYINT(1) NOT NULL DEFAULT 1,
+  status ENUM('WAITING','OFFERED','FULFILLED','DECLINED','EXPIRED','CANCELLED') NOT NULL DEFAULT 'WAITING',
+  offer_expires_at DATETIME NULL,
+  open_key TINYINT AS (IF(status IN ('WAITING','OFFERED'), 1, NULL)) STORED,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (id),
+  UNIQUE KEY uk_waitlist_open (show_id, user_id, open_key),
+  KEY idx_waitlist_queue (show_id, status, id),
+  KEY idx_waitlist_user (user_id, id),
+  KEY idx_waitlist_offer (status, offer_expires_at),
+  CONSTRAINT fk_waitlist_show FOREIGN KEY (show_id) REFERENCES shows(id) ON DELETE CASCADE,
+  CONSTRAINT fk_waitlist_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
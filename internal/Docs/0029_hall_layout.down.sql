@@ -0,0 +1,2 @@
+ALTER TABLE halls
+  DROP COLUMN layout;
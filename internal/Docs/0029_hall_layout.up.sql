@@ -0,0 +1,5 @@
+-- Custom seat layouts.  A hall with a layout has exactly the seats it
+-- lists (gaps and aisles are simply missing seat numbers); halls without
+-- one keep the dense seat_rows x seat_cols grid.
+ALTER TABLE halls
+  ADD COLUMN layout JSON NULL AFTER seat_cols;
@@ -0,0 +1,3 @@
+UPDATE show_seats SET status = 'FREE' WHERE status = 'BLOCKED';
+ALTER TABLE show_seats
+  MODIFY COLUMN status ENUM('FREE','HELD','RESERVED') NOT NULL DEFAULT 'FREE';
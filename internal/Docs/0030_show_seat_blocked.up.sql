@@ -0,0 +1,4 @@
+-- Owners can block individual show seats (maintenance, distancing).
+-- BLOCKED seats cannot be held and are never offered to customers.
+ALTER TABLE show_seats
+  MODIFY COLUMN status ENUM('FREE','HELD','RESERVED','BLOCKED') NOT NULL DEFAULT 'FREE';
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS notifications;
+UPDATE reservations SET status = 'CANCELLED' WHERE status = 'REFUND_PENDING';
+ALTER TABLE reservations
+  MODIFY COLUMN status ENUM('PENDING','CONFIRMED','CANCELLED') NOT NULL DEFAULT 'PENDING';
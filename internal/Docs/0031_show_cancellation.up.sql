@@ -0,0 +1,27 @@
+-- Show cancellation.  Reservations of a cancelled show become CANCELLED,
+-- or REFUND_PENDING when they were paid, and every affected customer is
+-- sent a notification.  Notifications are queued here and delivered by
+-- the notification worker; failed deliveries are retried at
+-- next_attempt_at until they become FAILED.
+ALTER TABLE reservations
+  MODIFY COLUMN status ENUM('PENDING','CONFIRMED','CANCELLED','REFUND_PENDING') NOT NULL DEFAULT 'PENDING';
+
+CREATE TABLE IF NOT EXISTS notifications (
+  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  user_id BIGINT UNSIGNED NOT NULL,
+  reservation_id BIGINT UNSIGNED NULL,
+  kind VARCHAR(32) NOT NULL,
+  subject VARCHAR(255) NOT NULL,
+  body TEXT NOT NULL,
+  status ENUM('PENDING','SENT','FAILED') NOT NULL DEFAULT 'PENDING',
+  attempts INT UNSIGNED NOT NULL DEFAULT 0,
+  next_attempt_at DATETIME NOT NULL,
+  last_error VARCHAR(255) NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  sent_at TIMESTAMP NULL,
+  PRIMARY KEY (id),
+  KEY idx_notifications_due (status, next_attempt_at),
+  KEY idx_notifications_user (user_id, id),
+  CONSTRAINT fk_notifications_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+  CONSTRAINT fk_notifications_reservation FOREIGN KEY (reservation_id) REFERENCES reservations(id) ON DELETE SET NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,26 @@
+-- Refunds of paid reservations.  A customer request starts REQUESTED and
+-- is APPROVED or REJECTED by the owner; cancelling a show approves the
+-- refunds of its paid reservations directly.  Approved refunds of
+-- checkouts are voided through the payment gateway, others are settled
+-- by the owner; either way they end PROCESSED.  A reservation has at most
+-- one refund.
+CREATE TABLE IF NOT EXISTS refunds (
+  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  reservation_id BIGINT UNSIGNED NOT NULL,
+  user_id BIGINT UNSIGNED NOT NULL,
+  amount_cents INT UNSIGNED NOT NULL,
+  status ENUM('REQUESTED','APPROVED','PROCESSED','REJECTED') NOT NULL DEFAULT 'REQUESTED',
+  reason VARCHAR(500) NULL,
+  note VARCHAR(500) NULL,
+  decided_by BIGINT UNSIGNED NULL,
+  payment_ref VARCHAR(128) NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  processed_at DATETIME NULL,
+  PRIMARY KEY (id),
+  UNIQUE KEY uk_refund_reservation (reservation_id),
+  KEY idx_refunds_status (status, id),
+  CONSTRAINT fk_refund_reservation FOREIGN KEY (reservation_id) REFERENCES reservations(id) ON DELETE CASCADE,
+  CONSTRAINT fk_refund_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+  CONSTRAINT fk_refund_decided_by FOREIGN KEY (decided_by) REFERENCES users(id) ON DELETE SET NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
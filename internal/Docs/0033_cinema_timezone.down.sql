@@ -0,0 +1 @@
+ALTER TABLE cinemas DROP COLUMN timezone;
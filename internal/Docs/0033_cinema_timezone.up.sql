@@ -0,0 +1,6 @@
+-- Per-cinema time zones.  Show times are still stored as UTC DATETIMEs;
+-- the time zone (an IANA name such as Europe/Berlin) says how owners'
+-- local times are read and how start times are presented.  Halls use the
+-- zone of their cinema.
+ALTER TABLE cinemas
+  ADD COLUMN timezone VARCHAR(64) NOT NULL DEFAULT 'UTC' AFTER name;
@@ -0,0 +1,22 @@
+-- Booking event outbox.  Confirmations and cancellations of reservations
+-- append a row here inside the transaction that makes the change, so an
+-- event exists exactly when the change committed.  The event relay
+-- publishes PENDING events and marks them PUBLISHED; failed publications
+-- are retried at next_attempt_at until they become FAILED.  reservation_id
+-- has no foreign key because cancelled reservations may be deleted.
+CREATE TABLE IF NOT EXISTS booking_events (
+  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  kind VARCHAR(32) NOT NULL,
+  reservation_id BIGINT UNSIGNED NOT NULL,
+  show_id BIGINT UNSIGNED NOT NULL,
+  user_id BIGINT UNSIGNED NOT NULL,
+  total_amount_cents INT UNSIGNED NOT NULL,
+  status ENUM('PENDING','PUBLISHED','FAILED') NOT NULL DEFAULT 'PENDING',
+  attempts INT UNSIGNED NOT NULL DEFAULT 0,
+  next_attempt_at DATETIME NOT NULL,
+  last_error VARCHAR(255) NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  published_at TIMESTAMP NULL,
+  PRIMARY KEY (id),
+  KEY idx_booking_events_due (status, next_attempt_at, id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,5 @@
+ALTER TABLE cinemas
+  DROP KEY idx_cinemas_geo,
+  DROP COLUMN longitude,
+  DROP COLUMN latitude,
+  DROP COLUMN address;
@@ -0,0 +1,9 @@
+-- Cinema location: a street address and coordinates so guests can browse
+-- cinemas near them.  city was added by 0015_search.  Coordinates are
+-- WGS84 degrees; the index serves the bounding-box prefilter of
+-- GET /v1/cinemas?near=.
+ALTER TABLE cinemas
+  ADD COLUMN address VARCHAR(255) NULL AFTER city,
+  ADD COLUMN latitude DECIMAL(9,6) NULL AFTER address,
+  ADD COLUMN longitude DECIMAL(9,6) NULL AFTER latitude,
+  ADD KEY idx_cinemas_geo (latitude, longitude);
@@ -0,0 +1,21 @@
+-- Reservation transfers.  A customer issues a single-use code for one of
+-- their confirmed reservations; whoever claims it before it expires (and
+-- before the show starts) becomes the reservation's owner.  Only the
+-- SHA-256 hash of the code is stored.  Issuing a new code for a
+-- reservation replaces its unclaimed ones.
+CREATE TABLE IF NOT EXISTS reservation_transfers (
+  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  reservation_id BIGINT UNSIGNED NOT NULL,
+  from_user_id BIGINT UNSIGNED NOT NULL,
+  code_hash CHAR(64) NOT NULL,
+  expires_at DATETIME NOT NULL,
+  claimed_by BIGINT UNSIGNED NULL,
+  claimed_at DATETIME NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (id),
+  UNIQUE KEY uq_reservation_transfer_code (code_hash),
+  KEY idx_reservation_transfer_res (reservation_id, claimed_at),
+  CONSTRAINT fk_reservation_transfer_res FOREIGN KEY (reservation_id) REFERENCES reservations(id) ON DELETE CASCADE,
+  CONSTRAINT fk_reservation_transfer_from FOREIGN KEY (from_user_id) REFERENCES users(id) ON DELETE CASCADE,
+  CONSTRAINT fk_reservation_transfer_to FOREIGN KEY (claimed_by) REFERENCES users(id) ON DELETE SET NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
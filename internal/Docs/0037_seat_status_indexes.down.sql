@@ -0,0 +1,7 @@
+ALTER TABLE seat_holds
+  ADD KEY idx_hold_show (show_id),
+  DROP INDEX idx_hold_show_seat_expires;
+
+ALTER TABLE show_seats
+  ADD KEY idx_show (show_id),
+  DROP INDEX idx_show_seats_status;
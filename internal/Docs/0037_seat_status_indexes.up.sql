@@ -0,0 +1,13 @@
+-- Covering indexes for the seat map query (ShowSeatRepo.ListWithStatus
+-- and Availability), which joins show_seats to seat_holds on
+-- (show_id, seat_id) and filters holds by expires_at.  With these the
+-- join reads only the two indexes.  The single-column show_id indexes
+-- are dropped: the unique (show_id, seat_id) keys already serve them and
+-- their foreign keys.
+ALTER TABLE show_seats
+  ADD KEY idx_show_seats_status (show_id, seat_id, status, price_cents),
+  DROP INDEX idx_show;
+
+ALTER TABLE seat_holds
+  ADD KEY idx_hold_show_seat_expires (show_id, seat_id, expires_at, user_id),
+  DROP INDEX idx_hold_show;
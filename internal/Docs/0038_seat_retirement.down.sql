@@ -0,0 +1,13 @@
+-- Retired seats are deleted with their history where nothing references
+-- them; the migration fails on the unique key if any remain.
+DELETE s FROM seats s
+WHERE s.valid_to IS NOT NULL
+  AND NOT EXISTS (SELECT 1 FROM reservation_seats rs WHERE rs.seat_id = s.id)
+  AND NOT EXISTS (SELECT 1 FROM show_seats ss WHERE ss.seat_id = s.id)
+  AND NOT EXISTS (SELECT 1 FROM seat_holds sh WHERE sh.seat_id = s.id);
+
+ALTER TABLE seats
+  ADD UNIQUE KEY uk_hall_row_no (hall_id, row_label, seat_number),
+  DROP INDEX uk_hall_row_no_current,
+  DROP COLUMN current_key,
+  DROP COLUMN valid_to;
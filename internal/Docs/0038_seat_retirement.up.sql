@@ -0,0 +1,10 @@
+-- Retired seats.  Rebuilding a hall's seat grid in archive mode keeps
+-- the old seats, with valid_to set, so reservations of past shows keep
+-- pointing at the seats they were sold for.  Only current seats
+-- (valid_to IS NULL) need unique positions: current_key is NULL for
+-- retired seats, and NULLs never collide in a unique key.
+ALTER TABLE seats
+  ADD COLUMN valid_to TIMESTAMP NULL AFTER is_active,
+  ADD COLUMN current_key TINYINT AS (IF(valid_to IS NULL, 1, NULL)) VIRTUAL AFTER valid_to,
+  ADD UNIQUE KEY uk_hall_row_no_current (hall_id, row_label, seat_number, current_key),
+  DROP INDEX uk_hall_row_no;
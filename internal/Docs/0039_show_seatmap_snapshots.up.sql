@@ -0,0 +1,18 @@
+-- Immutable seat map snapshots.  A show's seat map is recorded when the
+-- show is scheduled and again whenever its show seats are rebuilt (hall
+-- grid or layout changes, or a move to another hall), so reports can be
+-- reconciled against the seats a show was sold with.  version counts
+-- from 1 per show; seats is a JSON array of seat_id, row_label,
+-- seat_number, seat_type, status and price_cents.
+CREATE TABLE IF NOT EXISTS show_seatmap_snapshots (
+  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  show_id BIGINT UNSIGNED NOT NULL,
+  version INT UNSIGNED NOT NULL,
+  reason VARCHAR(32) NOT NULL,                 -- created, hall_rebuilt or hall_changed
+  seat_count INT UNSIGNED NOT NULL,
+  seats JSON NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (id),
+  UNIQUE KEY uk_seatmap_snapshot_version (show_id, version),
+  CONSTRAINT fk_seatmap_snapshot_show FOREIGN KEY (show_id) REFERENCES shows(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,5 @@
+ALTER TABLE shows
+  DROP KEY idx_shows_followers_notified,
+  DROP COLUMN followers_notified_at;
+
+DROP TABLE IF EXISTS favorites;
@@ -0,0 +1,27 @@
+-- Customer favourites.  A customer follows a cinema (cinema_id) or a
+-- movie (movie_title, matched against shows.title) and is notified when
+-- a new show is scheduled for it.  Exactly one of the two is set.
+-- shows.followers_notified_at records that followers were told about a
+-- show; existing shows are marked so followers are not flooded with the
+-- current programme.
+CREATE TABLE IF NOT EXISTS favorites (
+  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  user_id BIGINT UNSIGNED NOT NULL,
+  cinema_id BIGINT UNSIGNED NULL,
+  movie_title VARCHAR(255) NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (id),
+  UNIQUE KEY uk_favorites_cinema (user_id, cinema_id),
+  UNIQUE KEY uk_favorites_movie (user_id, movie_title),
+  KEY idx_favorites_cinema (cinema_id),
+  KEY idx_favorites_movie (movie_title),
+  CONSTRAINT fk_favorites_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+  CONSTRAINT fk_favorites_cinema FOREIGN KEY (cinema_id) REFERENCES cinemas(id) ON DELETE CASCADE,
+  CONSTRAINT chk_favorites_target CHECK ((cinema_id IS NULL) <> (movie_title IS NULL))
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+ALTER TABLE shows
+  ADD COLUMN followers_notified_at TIMESTAMP NULL,
+  ADD KEY idx_shows_followers_notified (followers_notified_at);
+
+UPDATE shows SET followers_notified_at = CURRENT_TIMESTAMP;
@@ -0,0 +1,4 @@
+ALTER TABLE shows
+  DROP COLUMN cancel_cutoff_min;
+ALTER TABLE cinemas
+  DROP COLUMN cancel_cutoff_min;
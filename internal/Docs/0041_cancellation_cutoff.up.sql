@@ -0,0 +1,7 @@
+-- How long before a show starts customers may still cancel, in minutes.
+-- A show's value overrides its cinema's; NULL on both allows
+-- cancelling until the show starts.
+ALTER TABLE cinemas
+  ADD COLUMN cancel_cutoff_min INT UNSIGNED NULL AFTER longitude;
+ALTER TABLE shows
+  ADD COLUMN cancel_cutoff_min INT UNSIGNED NULL AFTER hold_ttl_sec;
@@ -0,0 +1,2 @@
+ALTER TABLE reservations
+  DROP INDEX idx_res_status_created;
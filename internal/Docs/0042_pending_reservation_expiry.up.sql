@@ -0,0 +1,4 @@
+-- Lets the pending reservation expiry worker find PENDING reservations
+-- past the payment window without scanning the table.
+ALTER TABLE reservations
+  ADD KEY idx_res_status_created (status, created_at);
@@ -0,0 +1,16 @@
+-- Notification preferences of owners.  With daily_digest set the owner
+-- is emailed a summary of the previous day's bookings per show once the
+-- clock in timezone reaches digest_hour.  last_digest_on is the local
+-- date of the last day summarised, so each day is sent once.
+CREATE TABLE IF NOT EXISTS owner_notification_settings (
+  user_id BIGINT UNSIGNED NOT NULL,
+  daily_digest BOOLEAN NOT NULL DEFAULT FALSE,
+  digest_hour TINYINT UNSIGNED NOT NULL DEFAULT 8,
+  timezone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+  last_digest_on DATE NULL,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (user_id),
+  KEY idx_owner_notification_digest (daily_digest),
+  CONSTRAINT fk_owner_notification_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+  CONSTRAINT chk_owner_notification_hour CHECK (digest_hour < 24)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
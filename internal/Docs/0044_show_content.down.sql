@@ -0,0 +1,7 @@
+ALTER TABLE users
+  DROP COLUMN date_of_birth;
+ALTER TABLE shows
+  DROP COLUMN min_age,
+  DROP COLUMN format,
+  DROP COLUMN language,
+  DROP COLUMN rating;
@@ -0,0 +1,11 @@
+-- Content metadata of a show: its age rating (PG-13, 18+, ...), spoken
+-- language and projection format.  min_age > 0 makes the show
+-- age-restricted: confirming needs a date of birth showing the customer
+-- is at least that old when the show starts.
+ALTER TABLE shows
+  ADD COLUMN rating VARCHAR(8) NULL AFTER cancel_cutoff_min,
+  ADD COLUMN language VARCHAR(35) NULL AFTER rating,
+  ADD COLUMN format ENUM('2D','3D','IMAX') NULL AFTER language,
+  ADD COLUMN min_age TINYINT UNSIGNED NOT NULL DEFAULT 0 AFTER format;
+ALTER TABLE users
+  ADD COLUMN date_of_birth DATE NULL AFTER is_active;
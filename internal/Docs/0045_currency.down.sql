@@ -0,0 +1,8 @@
+ALTER TABLE booking_sagas
+  DROP COLUMN currency;
+ALTER TABLE reservations
+  DROP COLUMN currency;
+ALTER TABLE seat_holds
+  DROP COLUMN currency;
+ALTER TABLE cinemas
+  DROP COLUMN currency;
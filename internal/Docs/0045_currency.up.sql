@@ -0,0 +1,14 @@
+-- Currencies of prices.  Amounts stay in minor units (*_cents); the
+-- ISO 4217 code beside them says which currency.  A cinema's currency
+-- applies to all of its shows; NULL means the configured default
+-- (CURRENCY).  Holds record the currency their prices were quoted in and
+-- reservations the currency they were charged in, so a later change of
+-- the cinema's currency cannot mix currencies within a booking.
+ALTER TABLE cinemas
+  ADD COLUMN currency CHAR(3) NULL AFTER timezone;
+ALTER TABLE seat_holds
+  ADD COLUMN currency CHAR(3) NULL AFTER price_cents;
+ALTER TABLE reservations
+  ADD COLUMN currency CHAR(3) NULL AFTER total_amount_cents;
+ALTER TABLE booking_sagas
+  ADD COLUMN currency CHAR(3) NULL AFTER amount_cents;
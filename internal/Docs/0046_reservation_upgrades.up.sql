@@ -0,0 +1,21 @@
+-- Seat upgrades.  A customer swaps STANDARD seats of a confirmed
+-- reservation for FREE VIP seats of the same show; the reservation's
+-- seats and total change in place and each upgrade is recorded here with
+-- the seats swapped (a JSON array of from/to seat IDs and prices) and
+-- the price difference.  payment_ref is set when the difference was
+-- charged through the payment gateway, with the idempotency key
+-- upgrade-<id>.
+CREATE TABLE IF NOT EXISTS reservation_upgrades (
+  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  reservation_id BIGINT UNSIGNED NOT NULL,
+  user_id BIGINT UNSIGNED NOT NULL,
+  seats JSON NOT NULL,
+  delta_cents INT UNSIGNED NOT NULL,
+  currency CHAR(3) NULL,
+  payment_ref VARCHAR(128) NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (id),
+  KEY idx_reservation_upgrade_res (reservation_id),
+  CONSTRAINT fk_reservation_upgrade_res FOREIGN KEY (reservation_id) REFERENCES reservations(id) ON DELETE CASCADE,
+  CONSTRAINT fk_reservation_upgrade_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
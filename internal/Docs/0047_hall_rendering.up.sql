@@ -0,0 +1,6 @@
+-- Seat map rendering metadata of halls, for seat pickers: the edge of the
+-- map the screen is at, named sections (stalls, balcony, ...) as lists of
+-- row labels and per-row horizontal offsets and spacing.  NULL means the
+-- screen at the top and no sections or row adjustments.
+ALTER TABLE halls
+  ADD COLUMN rendering JSON NULL AFTER layout;
@@ -0,0 +1,22 @@
+-- Owner API keys for server-to-server integrations.  A key acts for its
+-- owner with the listed scopes (space separated, a subset of the owner's)
+-- and is sent in the X-API-Key header.  Only the SHA-256 hash of the key
+-- is stored; prefix is its first characters, kept so owners can tell
+-- their keys apart.  rate_per_min overrides the default per-key request
+-- budget; revoked keys are kept for the audit trail.
+CREATE TABLE IF NOT EXISTS api_keys (
+  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  owner_id BIGINT UNSIGNED NOT NULL,
+  name VARCHAR(100) NOT NULL,
+  prefix CHAR(12) NOT NULL,
+  key_hash CHAR(64) NOT NULL,
+  scopes VARCHAR(512) NOT NULL,
+  rate_per_min INT UNSIGNED NULL,
+  last_used_at TIMESTAMP NULL,
+  revoked_at TIMESTAMP NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (id),
+  UNIQUE KEY uq_api_key_hash (key_hash),
+  KEY idx_api_key_owner (owner_id),
+  CONSTRAINT fk_api_key_owner FOREIGN KEY (owner_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,36 @@
+-- Webhooks.  Owners subscribe URLs to booking events (events is a
+-- space-separated list such as "reservation.created show.cancelled");
+-- secret signs every delivery with HMAC-SHA256.  A delivery is queued for
+-- each matching subscription inside the transaction that makes the
+-- change, with the payload as it was then, and the webhook dispatcher
+-- posts PENDING deliveries, retrying failures at next_attempt_at until
+-- they become FAILED.  The rows double as the delivery log.
+CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  owner_id BIGINT UNSIGNED NOT NULL,
+  url VARCHAR(2048) NOT NULL,
+  secret VARCHAR(128) NOT NULL,
+  events VARCHAR(255) NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (id),
+  KEY idx_webhook_owner (owner_id),
+  CONSTRAINT fk_webhook_owner FOREIGN KEY (owner_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  subscription_id BIGINT UNSIGNED NOT NULL,
+  event VARCHAR(32) NOT NULL,
+  payload JSON NOT NULL,
+  status ENUM('PENDING','DELIVERED','FAILED') NOT NULL DEFAULT 'PENDING',
+  attempts INT UNSIGNED NOT NULL DEFAULT 0,
+  next_attempt_at DATETIME NOT NULL,
+  last_status_code SMALLINT UNSIGNED NULL,
+  last_error VARCHAR(255) NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  delivered_at TIMESTAMP NULL,
+  PRIMARY KEY (id),
+  KEY idx_webhook_deliveries_due (status, next_attempt_at, id),
+  KEY idx_webhook_deliveries_sub (subscription_id, id),
+  CONSTRAINT fk_webhook_delivery_sub FOREIGN KEY (subscription_id) REFERENCES webhook_subscriptions(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,8 @@
+ALTER TABLE reservations
+  DROP COLUMN tax_cents,
+  DROP COLUMN fees_cents,
+  DROP COLUMN subtotal_cents;
+ALTER TABLE cinemas
+  DROP COLUMN tax_rate_bp,
+  DROP COLUMN fee_value,
+  DROP COLUMN fee_type;
@@ -0,0 +1,16 @@
+-- Booking fees and tax per cinema.  fee_type PERCENT charges fee_value
+-- basis points of the seat prices after discounts, FLAT charges fee_value
+-- cents per ticket; NULL charges no fee.  tax_rate_bp is added on top of
+-- the discounted seat prices and the fee, in basis points (1900 = 19%).
+-- Reservations keep the breakdown they were charged:
+-- total_amount_cents = subtotal_cents - discount_cents + fees_cents +
+-- tax_cents.  Earlier reservations had neither fees nor tax.
+ALTER TABLE cinemas
+  ADD COLUMN fee_type ENUM('PERCENT','FLAT') NULL AFTER cancel_cutoff_min,
+  ADD COLUMN fee_value INT UNSIGNED NOT NULL DEFAULT 0 AFTER fee_type,
+  ADD COLUMN tax_rate_bp SMALLINT UNSIGNED NOT NULL DEFAULT 0 AFTER fee_value;
+ALTER TABLE reservations
+  ADD COLUMN subtotal_cents INT UNSIGNED NOT NULL DEFAULT 0 AFTER status,
+  ADD COLUMN fees_cents INT UNSIGNED NOT NULL DEFAULT 0 AFTER discount_cents,
+  ADD COLUMN tax_cents INT UNSIGNED NOT NULL DEFAULT 0 AFTER fees_cents;
+UPDATE reservations SET subtotal_cents = total_amount_cents + discount_cents;
@@ -0,0 +1,4 @@
+-- Rollback for 0051_guest_sessions.up.sql
+-- Guest holds are released by the sweeper once their user is gone.
+DELETE FROM users WHERE role_id = 5;
+DELETE FROM roles WHERE id = 5;
@@ -0,0 +1,7 @@
+-- Guest sessions.  POST /v1/auth/guest creates a GUEST user without
+-- usable credentials and returns an access token that can only hold
+-- seats.  Registering or logging in with that token moves the guest's
+-- holds to the account and deletes the guest; guests without holds are
+-- purged by the hold expiry sweeper once their session has lapsed.
+INSERT INTO roles (id, name) VALUES (5, 'GUEST')
+ON DUPLICATE KEY UPDATE name = VALUES(name);
@@ -0,0 +1,5 @@
+ALTER TABLE cinemas
+  DROP COLUMN opening_hours,
+  DROP COLUMN phone,
+  DROP COLUMN description,
+  DROP COLUMN logo_url;
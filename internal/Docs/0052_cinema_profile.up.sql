@@ -0,0 +1,9 @@
+-- Public profile of cinemas for front-ends: a logo URL, a description, a
+-- phone number and the weekly opening hours as a JSON array of day
+-- (MON..SUN), opens and closes (HH:MM; closing before opening runs past
+-- midnight).  NULL means not set.
+ALTER TABLE cinemas
+  ADD COLUMN logo_url VARCHAR(500) NULL AFTER longitude,
+  ADD COLUMN description TEXT NULL AFTER logo_url,
+  ADD COLUMN phone VARCHAR(32) NULL AFTER description,
+  ADD COLUMN opening_hours JSON NULL AFTER phone;
@@ -0,0 +1,4 @@
+ALTER TABLE reservation_seats
+  DROP KEY idx_resseat_status_show,
+  DROP COLUMN checked_in_at,
+  DROP COLUMN status;
@@ -0,0 +1,9 @@
+-- Attendance of reserved seats.  Seats start BOOKED, become CHECKED_IN
+-- when the owner or staff check the customer in (checked_in_at records
+-- when) and are marked NO_SHOW by a background job once the show has
+-- ended without a check-in.  Existing rows start BOOKED, so seats of
+-- shows that already ended are marked NO_SHOW by the job's first pass.
+ALTER TABLE reservation_seats
+  ADD COLUMN status ENUM('BOOKED','CHECKED_IN','NO_SHOW') NOT NULL DEFAULT 'BOOKED' AFTER seat_id,
+  ADD COLUMN checked_in_at TIMESTAMP NULL DEFAULT NULL AFTER status,
+  ADD KEY idx_resseat_status_show (status, show_id);
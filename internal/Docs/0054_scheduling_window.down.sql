@@ -0,0 +1,3 @@
+ALTER TABLE cinemas
+  DROP COLUMN max_horizon_days,
+  DROP COLUMN min_lead_min;
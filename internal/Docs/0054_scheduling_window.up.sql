@@ -0,0 +1,6 @@
+-- How far ahead a cinema's shows may be scheduled: at least
+-- min_lead_min minutes from now and at most max_horizon_days days ahead.
+-- NULL falls back to SHOW_MIN_LEAD_MIN and SHOW_MAX_HORIZON_DAYS.
+ALTER TABLE cinemas
+  ADD COLUMN min_lead_min INT UNSIGNED NULL AFTER cancel_cutoff_min,
+  ADD COLUMN max_horizon_days INT UNSIGNED NULL AFTER min_lead_min;
@@ -0,0 +1,236 @@
+// Package apperr defines the error envelope returned by every API endpoint.
+//
+// Handlers and middleware return an *Error instead of writing an error
+// response themselves; Handler, installed as Echo's HTTPErrorHandler,
+// renders it as
+//
+//	{"code": "SEAT_UNAVAILABLE", "message": "some seats are unavailable", "details": {...}}
+//
+// code is a stable, machine-readable identifier clients can branch on;
+// message is human readable and may change; details is optional
+// structured context (for example the unavailable seat IDs) and is null
+// when there is none.
+package apperr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Code is a machine-readable error identifier.
+type Code string
+
+// Generic codes, one per HTTP status class the API uses.
+const (
+	CodeBadRequest       Code = "BAD_REQUEST"
+	CodeValidation       Code = "VALIDATION_FAILED"
+	CodeUnauthorized     Code = "UNAUTHORIZED"
+	CodeForbidden        Code = "FORBIDDEN"
+	CodeNotFound         Code = "NOT_FOUND"
+	CodeMethodNotAllowed Code = "METHOD_NOT_ALLOWED"
+	CodeConflict         Code = "CONFLICT"
+	CodeRateLimited      Code = "RATE_LIMITED"
+	CodeInternal         Code = "INTERNAL"
+	CodeNotImplemented   Code = "NOT_IMPLEMENTED"
+	CodeUnavailable      Code = "SERVICE_UNAVAILABLE"
+	CodeTimeout          Code = "TIMEOUT"
+)
+
+// Domain codes for conditions clients are expected to handle specifically.
+const (
+	CodeAlreadyExists    Code = "ALREADY_EXISTS"
+	CodeNoChanges        Code = "NO_CHANGES"
+	CodeSeatUnavailable  Code = "SEAT_UNAVAILABLE"
+	CodeShowStarted      Code = "SHOW_STARTED"
+	CodeCancelCutoff     Code = "CANCELLATION_CUTOFF_PASSED"
+	CodeShowNotBookable  Code = "SHOW_NOT_BOOKABLE"
+	CodeShowOverlap      Code = "SHOW_OVERLAP"
+	CodeBlackoutDate     Code = "BLACKOUT_DATE"
+	CodeShowInUse        Code = "SHOW_IN_USE"
+	CodeHallInUse        Code = "HALL_IN_USE"
+	CodePriceChanged     Code = "PRICE_CHANGED"
+	CodeNoActiveHolds    Code = "NO_ACTIVE_HOLDS"
+	CodeHoldNotActive    Code = "HOLD_NOT_ACTIVE"
+	CodeHoldLimitReached Code = "HOLD_LIMIT_REACHED"
+	CodeHoldQuota        Code = "HOLD_QUOTA_EXCEEDED"
+	CodeAgeRestricted    Code = "AGE_RESTRICTED"
+	CodeCurrencyMismatch Code = "CURRENCY_MISMATCH"
+	CodeNotUpgradable    Code = "UPGRADE_NOT_ALLOWED"
+	CodeInvalidPromo     Code = "INVALID_PROMO_CODE"
+	CodeQuotaExceeded    Code = "QUOTA_EXCEEDED"
+	CodeBusy             Code = "BUSY"
+	CodePaymentFailed    Code = "PAYMENT_FAILED"
+	CodeAccountLocked    Code = "ACCOUNT_LOCKED"
+	CodeBotCheck         Code = "BOT_CHECK_REQUIRED"
+	CodeReservationPaid  Code = "RESERVATION_PAID"
+)
+
+// Error is an API error.  Status is the HTTP status to respond with and
+// Err, when set, is the underlying cause; neither is sent to the client.
+type Error struct {
+	Status  int         `json:"-"`
+	Code    Code        `json:"code"`
+	Message string      `json:"message"`
+	Details interface{} `json:"details"`
+	Err     error       `json:"-"`
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %s: %v", e.Code, e.Message, e.Err)
+	}
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+// Unwrap returns the underlying cause so errors.Is/As see through it.
+func (e *Error) Unwrap() error { return e.Err }
+
+// WithDetails returns a copy of e carrying details.
+func (e *Error) WithDetails(details interface{}) *Error {
+	cp := *e
+	cp.Details = details
+	return &cp
+}
+
+// Wrap returns a copy of e recording err as its cause.
+func (e *Error) Wrap(err error) *Error {
+	cp := *e
+	cp.Err = err
+	return &cp
+}
+
+// New returns an error with the given status, code and message.
+func New(status int, code Code, message string) *Error {
+	return &Error{Status: status, Code: code, Message: message}
+}
+
+// BadRequest returns a 400 BAD_REQUEST error.
+func BadRequest(message string) *Error {
+	return New(http.StatusBadRequest, CodeBadRequest, message)
+}
+
+// Validation returns a 422 VALIDATION_FAILED error listing the invalid
+// fields of a request body under details.fields.
+func Validation(fields interface{}) *Error {
+	return New(http.StatusUnprocessableEntity, CodeValidation, "request validation failed").
+		WithDetails(map[string]interface{}{"fields": fields})
+}
+
+// Unauthorized returns a 401 UNAUTHORIZED error.
+func Unauthorized(message string) *Error {
+	return New(http.StatusUnauthorized, CodeUnauthorized, message)
+}
+
+// Forbidden returns a 403 FORBIDDEN error.
+func Forbidden(message string) *Error {
+	return New(http.StatusForbidden, CodeForbidden, message)
+}
+
+// NotFound returns a 404 NOT_FOUND error.
+func NotFound(message string) *Error {
+	return New(http.StatusNotFound, CodeNotFound, message)
+}
+
+// Conflict returns a 409 error with the given code.
+func Conflict(code Code, message string) *Error {
+	return New(http.StatusConflict, code, message)
+}
+
+// Internal returns a 500 INTERNAL error.
+func Internal(message string) *Error {
+	return New(http.StatusInternalServerError, CodeInternal, message)
+}
+
+// NotImplemented returns a 501 NOT_IMPLEMENTED error, used when an
+// optional feature is not configured.
+func NotImplemented(message string) *Error {
+	return New(http.StatusNotImplemented, CodeNotImplemented, message)
+}
+
+// Timeout returns a 504 TIMEOUT error, used when a request ran past its
+// deadline.
+func Timeout(message string) *Error {
+	return New(http.StatusGatewayTimeout, CodeTimeout, message)
+}
+
+// codeForStatus maps a status to a generic code, for errors that did not
+// originate in this package (for example Echo's own 404 and 405).
+func codeForStatus(status int) Code {
+	switch status {
+	case http.StatusBadRequest:
+		return CodeBadRequest
+	case http.StatusUnauthorized:
+		return CodeUnauthorized
+	case http.StatusForbidden:
+		return CodeForbidden
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusMethodNotAllowed:
+		return CodeMethodNotAllowed
+	case http.StatusConflict:
+		return CodeConflict
+	case http.StatusUnprocessableEntity:
+		return CodeValidation
+	case http.StatusTooManyRequests:
+		return CodeRateLimited
+	case http.StatusNotImplemented:
+		return CodeNotImplemented
+	case http.StatusServiceUnavailable:
+		return CodeUnavailable
+	case http.StatusGatewayTimeout:
+		return CodeTimeout
+	}
+	if status >= 500 {
+		return CodeInternal
+	}
+	return CodeBadRequest
+}
+
+// From converts any error into an *Error.  *Error values are returned
+// as is, *echo.HTTPError keeps its status and message, an expired context
+// deadline becomes a 504 and anything else becomes a 500 without leaking
+// the cause.
+func From(err error) *Error {
+	var ae *Error
+	if errors.As(err, &ae) {
+		return ae
+	}
+	var he *echo.HTTPError
+	if errors.As(err, &he) {
+		msg, ok := he.Message.(string)
+		if !ok {
+			msg = http.StatusText(he.Code)
+		}
+		return &Error{Status: he.Code, Code: codeForStatus(he.Code), Message: msg, Err: he.Internal}
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return Timeout("the request took too long and was abandoned").Wrap(err)
+	}
+	return &Error{Status: http.StatusInternalServerError, Code: CodeInternal, Message: "internal server error", Err: err}
+}
+
+// Handler is an echo.HTTPErrorHandler that renders every error returned
+// by a handler or middleware as the standard envelope.  Server errors are
+// logged with their cause.
+func Handler(err error, c echo.Context) {
+	if c.Response().Committed {
+		return
+	}
+	ae := From(err)
+	if ae.Status >= 500 {
+		log.Printf("%s %s: %v", c.Request().Method, c.Request().URL.Path, ae)
+	}
+	if c.Request().Method == http.MethodHead {
+		err = c.NoContent(ae.Status)
+	} else {
+		err = c.JSON(ae.Status, ae)
+	}
+	if err != nil {
+		log.Printf("writing error response: %v", err)
+	}
+}
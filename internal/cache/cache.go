@@ -0,0 +1,216 @@
+// Package cache keeps public GET responses (cinema lists, show details,
+// seat maps, search results) in memory for a short TTL and drops them
+// when the data behind them changes.
+//
+// Invalidation is driven by the repositories: methods that change the
+// seat state of a show call ShowChanged, and methods that change
+// cinemas, halls, seats or shows call CatalogChanged.  Seat state
+// changes only drop the cached seat maps and details of that show;
+// catalog changes are rare and drop everything, since hall and cinema
+// names appear in many responses.  Inside a request wrapped by Defer the
+// invalidation waits until the handler has returned, after its
+// transaction committed, so a concurrent read cannot cache the state
+// from before the commit.  Background workers invalidate immediately;
+// the TTL bounds what such a race can leave behind.
+//
+// Until Enable is called the hooks do nothing.
+package cache
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Entry is a cached response.  ETag and LastModified are the
+// validators the handler set, if any.
+type Entry struct {
+	Status       int
+	ContentType  string
+	ETag         string
+	LastModified string
+	Body         []byte
+}
+
+type item struct {
+	entry   Entry
+	showID  uint64 // show the response belongs to, or 0
+	expires time.Time
+}
+
+// Store is a TTL cache of responses keyed by request URI.  The number of
+// entries is capped at MaxEntries; when full, new responses are not
+// cached until entries expire.
+type Store struct {
+	TTL        time.Duration
+	MaxEntries int
+
+	mu      sync.Mutex
+	items   map[string]*item
+	byShow  map[uint64]map[string]bool
+	purgeAt time.Time
+}
+
+// New constructs a Store whose entries live for ttl.
+func New(ttl time.Duration) *Store {
+	return &Store{
+		TTL:        ttl,
+		MaxEntries: 10000,
+		items:      make(map[string]*item),
+		byShow:     make(map[uint64]map[string]bool),
+	}
+}
+
+// Get returns the live entry for key.
+func (s *Store) Get(key string) (Entry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	it, ok := s.items[key]
+	if !ok || time.Now().After(it.expires) {
+		return Entry{}, false
+	}
+	return it.entry, true
+}
+
+// Set caches e under key.  showID names the show whose seat state the
+// response depends on, or 0 when it depends only on the catalog.
+func (s *Store) Set(key string, e Entry, showID uint64) {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.items[key]; !ok && len(s.items) >= s.MaxEntries {
+		s.dropExpired(now)
+		if len(s.items) >= s.MaxEntries {
+			return
+		}
+	}
+	s.drop(key)
+	s.items[key] = &item{entry: e, showID: showID, expires: now.Add(s.TTL)}
+	if showID != 0 {
+		if s.byShow[showID] == nil {
+			s.byShow[showID] = make(map[string]bool)
+		}
+		s.byShow[showID][key] = true
+	}
+}
+
+// InvalidateShow drops the entries of a show.
+func (s *Store) InvalidateShow(showID uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key := range s.byShow[showID] {
+		s.drop(key)
+	}
+}
+
+// Purge drops every entry.
+func (s *Store) Purge() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.items = make(map[string]*item)
+	s.byShow = make(map[uint64]map[string]bool)
+}
+
+// drop removes key; s.mu must be held.
+func (s *Store) drop(key string) {
+	it, ok := s.items[key]
+	if !ok {
+		return
+	}
+	delete(s.items, key)
+	if it.showID != 0 {
+		delete(s.byShow[it.showID], key)
+		if len(s.byShow[it.showID]) == 0 {
+			delete(s.byShow, it.showID)
+		}
+	}
+}
+
+// dropExpired removes expired entries, at most once per TTL; s.mu must
+// be held.
+func (s *Store) dropExpired(now time.Time) {
+	if now.Before(s.purgeAt) {
+		return
+	}
+	s.purgeAt = now.Add(s.TTL)
+	for key, it := range s.items {
+		if now.After(it.expires) {
+			s.drop(key)
+		}
+	}
+}
+
+var active atomic.Pointer[Store]
+
+// Enable makes s the store invalidated by ShowChanged and CatalogChanged.
+func Enable(s *Store) {
+	active.Store(s)
+}
+
+// pending collects the invalidations of one request.
+type pending struct {
+	mu      sync.Mutex
+	shows   map[uint64]bool
+	catalog bool
+}
+
+type pendingKey struct{}
+
+// Defer returns a context under which invalidations are collected instead
+// of applied, and a function that applies them.  Requests that mutate
+// data call flush after their transaction has committed.
+func Defer(ctx context.Context) (context.Context, func()) {
+	p := &pending{shows: make(map[uint64]bool)}
+	flush := func() {
+		s := active.Load()
+		if s == nil {
+			return
+		}
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if p.catalog {
+			s.Purge()
+		} else {
+			for id := range p.shows {
+				s.InvalidateShow(id)
+			}
+		}
+		p.shows, p.catalog = make(map[uint64]bool), false
+	}
+	return context.WithValue(ctx, pendingKey{}, p), flush
+}
+
+// ShowChanged records that the seat state of the shows changed.
+func ShowChanged(ctx context.Context, showIDs ...uint64) {
+	s := active.Load()
+	if s == nil {
+		return
+	}
+	if p, ok := ctx.Value(pendingKey{}).(*pending); ok {
+		p.mu.Lock()
+		for _, id := range showIDs {
+			p.shows[id] = true
+		}
+		p.mu.Unlock()
+		return
+	}
+	for _, id := range showIDs {
+		s.InvalidateShow(id)
+	}
+}
+
+// CatalogChanged records that cinemas, halls, seats or shows changed.
+func CatalogChanged(ctx context.Context) {
+	s := active.Load()
+	if s == nil {
+		return
+	}
+	if p, ok := ctx.Value(pendingKey{}).(*pending); ok {
+		p.mu.Lock()
+		p.catalog = true
+		p.mu.Unlock()
+		return
+	}
+	s.Purge()
+}
@@ -1,64 +1,264 @@
-package config // package config loads application configuration from environment variables
+package config // package config loads application configuration from the environment, a settings file and flags
 
 import (
+    "fmt"      // fmt reports malformed proxy ranges
     "log"      // log is used to report configuration errors and halt execution
+    "net"      // net parses the trusted proxy ranges
     "os"       // os provides access to environment variables
-    "strconv"  // strconv converts strings to other types
+    "strings"  // strings joins the reported problems
 )
 
 // Config holds all runtime configuration values.  Each field corresponds to
-// an environment variable.  The types reflect how the values are used in
+// an environment variable, which may also be set in a settings file or
+// with a -set flag (see Sources).  The types reflect how the values are used in
 // the application: strings for identifiers and secrets, ints for durations and costs.
 type Config struct {
     Env            string // application environment (e.g. "dev", "prod")
     Port           string // HTTP port to listen on
+    GRPCAddr       string // listen address of the gRPC booking API (empty disables it)
     DBUser         string // database username
     DBPass         string // database password (optional)
     DBHost         string // database host address
     DBPort         string // database port number
     DBName         string // database name
-    JWTSecret      string // secret used to sign JWTs
+    Currency       string // ISO 4217 code of prices of cinemas without their own currency
+    JWTSecret      string // single HMAC secret for JWTs (key ID "default"); optional when JWTKeys is set
+    JWTKeys        string // additional HMAC keys as "kid:secret,kid2:secret2"
+    JWTSigningKID  string // key ID used to sign new tokens (default: the last key)
     AccessTTLMin   int    // access token time‑to‑live in minutes
     RefreshTTLDays int    // refresh token time‑to‑live in days
     BcryptCost     int    // bcrypt cost for password hashing
+    HoldTTLSec     int    // default lifetime of a seat hold in seconds; shows may override it
+    HoldSweepSec   int    // interval in seconds between background hold expiry sweeps (0 disables)
+    HoldExtendSec  int    // seconds added to active holds by POST /v1/shows/:id/hold/extend
+    HoldMaxSec     int    // maximum total lifetime of a hold in seconds, including extensions
+    HoldMaxShows   int    // shows a customer may hold seats on at once (0 disables the limit)
+    GuestTTLMin    int    // lifetime in minutes of a guest session token (0 disables guest sessions)
+    QuotaFlushSec  int    // interval in seconds between quota counter flushes (0 disables quotas)
+    ShowBufferMin  int    // minutes added to a movie's runtime when deriving a show's end time
+    ShowLeadMin    int    // minutes from now a new show must start at least, unless its cinema sets its own
+    ShowMaxDays    int    // days ahead a new show may start at most, unless its cinema sets its own (0 disables)
+    PaymentGateway string // payment provider for checkout: "fake", or empty to disable checkout
+    SagaTimeoutSec int    // seconds a booking saga may stay unfinished before recovery takes over
+    SagaRecoverSec int    // interval in seconds between booking saga recovery runs (0 disables)
+    RefundSweepSec int    // interval in seconds between retries of approved checkout refunds (0 disables)
+    TracesExporter string  // "otlp", "console" or "none"/empty to disable tracing
+    OTLPEndpoint   string  // OTLP/HTTP collector base URL (Jaeger, Tempo, OpenTelemetry collector)
+    OTLPHeaders    string  // extra export headers, "key=value,key2=value2"
+    ServiceName    string  // service.name reported with every span
+    TraceSampling  float64 // fraction of new traces recorded (0..1)
+    ResetTTLMin    int     // password reset token lifetime in minutes
+    ResetURL       string  // reset link prefix; the token is appended
+    SMTPAddr       string  // host:port of the SMTP relay (empty: no email delivery)
+    SMTPFrom       string  // sender address for outgoing email
+    SMTPUser       string  // SMTP username (optional)
+    SMTPPass       string  // SMTP password (optional)
+    LoginMaxFailures   int // failed logins per account before a lockout (0 disables lockouts)
+    LoginMaxIPFailures int // failed logins per client address before a lockout (0: no address limit)
+    LoginWindowSec     int // seconds after which a failure no longer counts
+    LoginLockoutSec    int // first lockout duration in seconds; doubles on each repeat
+    LoginLockoutMaxSec int // upper bound on a lockout in seconds
+    WaitlistOfferSec   int // seconds a waitlisted customer has to confirm offered seats (0 disables waitlists)
+    WaitlistSweepSec   int // interval in seconds between waitlist passes
+    NotifySweepSec     int // interval in seconds between notification delivery passes (0 disables delivery)
+    AnnounceSweepSec   int // interval in seconds between passes notifying followers of new shows (0 disables)
+    PendingTTLSec      int // seconds a reservation may stay PENDING before it expires
+    PendingSweepSec    int // interval in seconds between pending reservation expiry passes (0 disables)
+    DigestSweepSec     int // interval in seconds between checks for due owner digests (0 disables)
+    ReconcileSweepSec  int // interval in seconds between seat status reconciliation passes (0 disables)
+    NoShowSweepSec     int // interval in seconds between passes marking seats of ended shows as no-shows (0 disables)
+    ShowFinishSweepSec int // interval in seconds between passes marking ended shows FINISHED (0 disables)
+    QueueBackend       string // message queue backend: file or memory (empty disables booking events)
+    QueueFileDir       string // directory the file queue backend appends to
+    BookingEventsSec   int    // interval in seconds between booking event relay passes
+    WebhookSweepSec    int    // interval in seconds between webhook delivery passes (0 disables delivery)
+    WebhookTimeoutSec  int    // seconds a webhook receiver has to respond
+    // Rate limits per route class: a client may send Burst requests at
+    // once, then PerMin per minute.  A zero burst disables the class.
+    RateLimitPublicBurst    int
+    RateLimitPublicPerMin   int
+    RateLimitAuthBurst      int
+    RateLimitAuthPerMin     int
+    RateLimitCustomerBurst  int
+    RateLimitCustomerPerMin int
+    RateLimitOwnerBurst     int
+    RateLimitOwnerPerMin    int
+    RateLimitIPBurst        int // per-address bucket across all classes and accounts (0 disables)
+    RateLimitIPPerMin       int
+    RateLimitAPIKeyPerMin   int // default budget of one owner API key, burst included (0 disables)
+    CacheTTLSec             int // seconds public GET responses stay cached (0 disables the cache)
+    TransferTTLHours        int // hours a reservation transfer code stays valid
+    GzipMinBytes            int // smallest response body compressed with gzip (0 disables compression)
+    DBMaxOpenConns          int // open database connections at most
+    DBMaxIdleConns          int // idle database connections kept for reuse
+    DBConnMaxLifetimeSec    int // seconds before a database connection is recycled
+    DBSlowQueryMs           int // statements at least this slow are logged (0 disables)
+    RequestTimeoutSec       int // deadline in seconds of an HTTP request's context (0 disables)
+    BookingTimeoutSec       int // shorter deadline in seconds for hold, checkout and cancellation requests
+    BotCheckProvider        string // "pow", "recaptcha", "hcaptcha", "turnstile", or empty to disable the bot check
+    BotCheckSecret          string // CAPTCHA secret, or the proof-of-work signing key (random per process when empty)
+    BotCheckSiteKey         string // public CAPTCHA site key handed to clients
+    BotCheckVerifyURL       string // overrides the provider's siteverify endpoint
+    BotCheckDifficulty      int    // proof-of-work difficulty in leading zero bits
+    BotCheckPassSec         int    // seconds a passed bot check stays valid for the client address
+    TrustedProxies          string // CIDRs of reverse proxies whose X-Forwarded-For is trusted, comma separated (empty: the peer is the client)
+    // Settings is every setting as loaded, secrets redacted, in load
+    // order; it is logged at startup and served by GET /v1/admin/config.
+    Settings []Setting
 }
 
-// Load reads configuration values from environment variables and returns a
-// Config.  Required variables are enforced by must() and missing values
-// cause the program to exit with a fatal log message.
+// Load reads the configuration from the environment and the file named
+// by $CONFIG_FILE, if any.  When settings are missing or invalid it logs
+// every problem and exits.
 func Load() Config {
-    return Config{
-        Env:            must("APP_ENV"),             // environment (dev/test/prod)
-        Port:           must("APP_PORT"),            // port to bind the HTTP server
-        DBUser:         must("DB_USER"),             // database user
-        DBPass:         os.Getenv("DB_PASS"),        // database password (empty allowed)
-        DBHost:         must("DB_HOST"),             // database host
-        DBPort:         must("DB_PORT"),             // database port
-        DBName:         must("DB_NAME"),             // database name
-        JWTSecret:      must("JWT_SECRET"),          // secret used for signing JWTs
-        AccessTTLMin:   mustInt("ACCESS_TOKEN_TTL_MIN"),   // TTL for access tokens in minutes
-        RefreshTTLDays: mustInt("REFRESH_TOKEN_TTL_DAYS"), // TTL for refresh tokens in days
-        BcryptCost:     mustInt("BCRYPT_COST"),      // bcrypt cost factor
+    cfg, err := LoadFrom(Sources{File: os.Getenv("CONFIG_FILE")})
+    if err != nil {
+        log.Fatalf("config: %v", err)
     }
+    return cfg
 }
 
-// must retrieves the value of a required environment variable.  If the
-// variable is unset or empty, the application logs a fatal error and exits.
-func must(key string) string {
-    v, ok := os.LookupEnv(key)
-    if !ok || v == "" {
-        log.Fatalf("missing required env var: %s", key)
+// LoadFrom reads the configuration from src and checks it with
+// Validate.  The error lists every missing or invalid setting, not just
+// the first.  Settings of the result reports each value and where it
+// came from.
+func LoadFrom(src Sources) (Config, error) {
+    l, err := newLoader(src)
+    if err != nil {
+        return Config{}, err
+    }
+    cfg := Config{
+        Env:            l.must("APP_ENV"),      // environment (dev/test/prod)
+        Port:           l.must("APP_PORT"),     // port to bind the HTTP server
+        GRPCAddr:       l.str("GRPC_ADDR", ""), // gRPC listen address (optional)
+        DBUser:         l.must("DB_USER"),      // database user
+        DBPass:         l.str("DB_PASS", ""),   // database password (empty allowed)
+        DBHost:         l.must("DB_HOST"),      // database host
+        DBPort:         l.must("DB_PORT"),      // database port
+        DBName:         l.must("DB_NAME"),      // database name
+        Currency:       l.str("CURRENCY", "USD"), // default price currency
+        JWTSecret:      l.str("JWT_SECRET", ""), // legacy single signing secret
+        JWTKeys:        l.str("JWT_KEYS", ""),   // rotating signing keys
+        JWTSigningKID:  l.str("JWT_SIGNING_KID", ""), // which key signs new tokens
+        AccessTTLMin:   l.mustInt("ACCESS_TOKEN_TTL_MIN"),   // TTL for access tokens in minutes
+        RefreshTTLDays: l.mustInt("REFRESH_TOKEN_TTL_DAYS"), // TTL for refresh tokens in days
+        BcryptCost:     l.mustInt("BCRYPT_COST"), // bcrypt cost factor
+        HoldTTLSec:     l.int("HOLD_TTL_SEC", 300),          // default seat hold duration
+        HoldSweepSec:   l.int("HOLD_SWEEP_INTERVAL_SEC", 5), // background hold expiry sweep interval
+        HoldExtendSec:  l.int("HOLD_EXTEND_SEC", 120),       // hold extension increment
+        HoldMaxSec:     l.int("HOLD_MAX_TOTAL_SEC", 900),    // cap on a hold's total lifetime
+        HoldMaxShows:   l.int("HOLD_MAX_SHOWS", 5),          // concurrent held shows per customer
+        GuestTTLMin:    l.int("GUEST_SESSION_TTL_MIN", 30),  // guest holds outlive HOLD_MAX_TOTAL_SEC
+        QuotaFlushSec:  l.int("QUOTA_FLUSH_INTERVAL_SEC", 30), // account quota counter flush interval
+        ShowBufferMin:  l.int("SHOW_BUFFER_MIN", 15),          // trailer/cleanup buffer after a show's runtime
+        ShowLeadMin:    l.int("SHOW_MIN_LEAD_MIN", 0),         // minimum lead time of new shows
+        ShowMaxDays:    l.int("SHOW_MAX_HORIZON_DAYS", 365),   // scheduling horizon of new shows
+        PaymentGateway: l.str("PAYMENT_GATEWAY", ""),            // checkout payment provider (optional)
+        SagaTimeoutSec: l.int("SAGA_TIMEOUT_SEC", 300),          // booking saga deadline
+        SagaRecoverSec: l.int("SAGA_RECOVERY_INTERVAL_SEC", 30), // booking saga recovery interval
+        RefundSweepSec: l.int("REFUND_INTERVAL_SEC", 60),        // approved refund retry interval
+        TracesExporter: l.str("OTEL_TRACES_EXPORTER", ""), // tracing exporter (disabled when empty)
+        OTLPEndpoint:   l.str("OTEL_EXPORTER_OTLP_ENDPOINT", "http://localhost:4318"), // where spans are sent
+        OTLPHeaders:    l.str("OTEL_EXPORTER_OTLP_HEADERS", ""),               // e.g. collector auth
+        ServiceName:    l.str("OTEL_SERVICE_NAME", "cinema-seat-reservation"), // service.name resource attribute
+        TraceSampling:  l.float("OTEL_TRACES_SAMPLER_ARG", 1),                 // sampling ratio for new traces
+        ResetTTLMin:    l.int("PASSWORD_RESET_TTL_MIN", 30), // reset link lifetime
+        ResetURL:       l.str("PASSWORD_RESET_URL", "http://localhost:3000/reset-password?token="), // frontend reset page
+        SMTPAddr:       l.str("SMTP_ADDR", ""),                   // outgoing mail relay
+        SMTPFrom:       l.str("SMTP_FROM", "no-reply@localhost"), // envelope sender
+        SMTPUser:       l.str("SMTP_USERNAME", ""),               // relay credentials
+        SMTPPass:       l.str("SMTP_PASSWORD", ""),
+        LoginMaxFailures:   l.int("LOGIN_MAX_FAILURES", 5),         // per-account failure limit
+        LoginMaxIPFailures: l.int("LOGIN_MAX_IP_FAILURES", 20),     // per-address failure limit
+        LoginWindowSec:     l.int("LOGIN_FAILURE_WINDOW_SEC", 900), // failure counting window
+        LoginLockoutSec:    l.int("LOGIN_LOCKOUT_SEC", 60),         // first lockout
+        LoginLockoutMaxSec: l.int("LOGIN_LOCKOUT_MAX_SEC", 3600),   // lockout cap
+        WaitlistOfferSec:   l.int("WAITLIST_OFFER_SEC", 600),       // offer hold lifetime
+        WaitlistSweepSec:   l.int("WAITLIST_SWEEP_INTERVAL_SEC", 30), // waitlist retry interval
+        NotifySweepSec:     l.int("NOTIFICATION_INTERVAL_SEC", 30),   // notification delivery interval
+        AnnounceSweepSec:   l.int("SHOW_ANNOUNCE_INTERVAL_SEC", 60),  // new show announcement interval
+        PendingTTLSec:      l.int("PENDING_RESERVATION_TTL_SEC", 900), // payment window of PENDING reservations
+        PendingSweepSec:    l.int("PENDING_EXPIRY_INTERVAL_SEC", 60),  // pending reservation expiry interval
+        DigestSweepSec:     l.int("OWNER_DIGEST_INTERVAL_SEC", 300),   // owner digest check interval
+        ReconcileSweepSec:  l.int("SEAT_RECONCILE_INTERVAL_SEC", 900), // seat status reconciliation interval
+        NoShowSweepSec:     l.int("NO_SHOW_INTERVAL_SEC", 300),        // no-show marking interval
+        ShowFinishSweepSec: l.int("SHOW_FINISH_INTERVAL_SEC", 60),     // ended show finishing interval
+        QueueBackend:       l.str("QUEUE_BACKEND", ""),                // booking event queue (optional)
+        QueueFileDir:       l.str("QUEUE_FILE_DIR", "."),            // file backend output directory
+        BookingEventsSec:   l.int("BOOKING_EVENTS_INTERVAL_SEC", 5), // booking event relay interval
+        WebhookSweepSec:    l.int("WEBHOOK_INTERVAL_SEC", 10),       // webhook delivery interval
+        WebhookTimeoutSec:  l.int("WEBHOOK_TIMEOUT_SEC", 10),        // webhook request timeout
+        RateLimitPublicBurst:    l.int("RATE_LIMIT_PUBLIC_BURST", 120),    // reads
+        RateLimitPublicPerMin:   l.int("RATE_LIMIT_PUBLIC_PER_MIN", 120),
+        RateLimitAuthBurst:      l.int("RATE_LIMIT_AUTH_BURST", 10),       // login, refresh, password reset
+        RateLimitAuthPerMin:     l.int("RATE_LIMIT_AUTH_PER_MIN", 10),
+        RateLimitCustomerBurst:  l.int("RATE_LIMIT_CUSTOMER_BURST", 20),   // holds, bookings, cancellations
+        RateLimitCustomerPerMin: l.int("RATE_LIMIT_CUSTOMER_PER_MIN", 30),
+        RateLimitOwnerBurst:     l.int("RATE_LIMIT_OWNER_BURST", 60),      // owner and staff changes
+        RateLimitOwnerPerMin:    l.int("RATE_LIMIT_OWNER_PER_MIN", 60),
+        RateLimitIPBurst:        l.int("RATE_LIMIT_IP_BURST", 300),        // everything from one address
+        RateLimitIPPerMin:       l.int("RATE_LIMIT_IP_PER_MIN", 600),
+        RateLimitAPIKeyPerMin:   l.int("RATE_LIMIT_API_KEY_PER_MIN", 120), // per key unless set on the key
+        CacheTTLSec:             l.int("CACHE_TTL_SEC", 30),           // public response cache lifetime
+        TransferTTLHours:        l.int("TRANSFER_CODE_TTL_HOURS", 72), // capped at the show start
+        GzipMinBytes:            l.int("GZIP_MIN_BYTES", 1024),        // response compression threshold
+        DBMaxOpenConns:          l.int("DB_MAX_OPEN_CONNS", 25),       // connection pool size
+        DBMaxIdleConns:          l.int("DB_MAX_IDLE_CONNS", 25),
+        DBConnMaxLifetimeSec:    l.int("DB_CONN_MAX_LIFETIME_SEC", 1800),
+        DBSlowQueryMs:           l.int("DB_SLOW_QUERY_MS", 200),       // slow query log threshold
+        RequestTimeoutSec:       l.int("REQUEST_TIMEOUT_SEC", 30),     // request deadline
+        BookingTimeoutSec:       l.int("BOOKING_TIMEOUT_SEC", 10),     // booking request deadline
+        BotCheckProvider:        l.str("BOT_CHECK_PROVIDER", ""),       // bot check (optional)
+        BotCheckSecret:          l.str("BOT_CHECK_SECRET", ""),
+        BotCheckSiteKey:         l.str("BOT_CHECK_SITE_KEY", ""),
+        BotCheckVerifyURL:       l.str("BOT_CHECK_VERIFY_URL", ""),
+        BotCheckDifficulty:      l.int("BOT_CHECK_POW_DIFFICULTY", 20), // ~1M hashes on average
+        BotCheckPassSec:         l.int("BOT_CHECK_PASS_TTL_SEC", 600),
+        TrustedProxies:          l.str("TRUSTED_PROXIES", ""),          // client address source
+    }
+    cfg.Settings = l.settings
+    // range checks are only meaningful once every value parsed
+    problems := l.problems
+    if len(problems) == 0 {
+        problems = cfg.Validate()
+    }
+    if len(problems) > 0 {
+        return cfg, &Error{Problems: problems}
     }
-    return v
+    return cfg, nil
 }
 
-// mustInt is like must() but converts the retrieved string into an integer.
-// If conversion fails, the application logs a fatal error and exits.
-func mustInt(key string) int {
-    s := must(key)
-    n, err := strconv.Atoi(s)
-    if err != nil {
-        log.Fatalf("invalid int for %s: %q", key, s)
+// TrustedProxyNets parses TrustedProxies.  A bare address is taken as a
+// single-host range.
+func (c *Config) TrustedProxyNets() ([]*net.IPNet, error) {
+    var nets []*net.IPNet
+    for _, s := range strings.Split(c.TrustedProxies, ",") {
+        s = strings.TrimSpace(s)
+        if s == "" {
+            continue
+        }
+        if ip := net.ParseIP(s); ip != nil {
+            bits := 8 * len(ip.To16())
+            if ip.To4() != nil {
+                ip, bits = ip.To4(), 32
+            }
+            nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+            continue
+        }
+        _, n, err := net.ParseCIDR(s)
+        if err != nil {
+            return nil, fmt.Errorf("%q is not an IP address or CIDR range", s)
+        }
+        nets = append(nets, n)
     }
-    return n
+    return nets, nil
+}
+
+// Error is returned by LoadFrom for missing or invalid settings.
+type Error struct {
+    Problems []string
+}
+
+func (e *Error) Error() string {
+    return "invalid configuration: " + strings.Join(e.Problems, "; ")
 }
@@ -0,0 +1,260 @@
+package config
+
+import (
+    "bufio"
+    "flag"
+    "fmt"
+    "os"
+    "sort"
+    "strconv"
+    "strings"
+)
+
+// Sources are where LoadFrom reads settings.  A setting is taken from the
+// first of Overrides, the environment, File and its default that has a
+// non-empty value for it.
+type Sources struct {
+    // File is a YAML file of settings keyed by their environment variable
+    // names (case-insensitive); see ReadFile.  Empty reads no file.
+    File string
+    // Overrides take precedence over everything else, e.g. -set flags.
+    Overrides map[string]string
+}
+
+// Origins of a Setting.
+const (
+    FromDefault = "default"
+    FromFlag    = "flag"
+    FromEnv     = "env"
+    FromFile    = "file"
+)
+
+// Setting is one configuration value as loaded: its key, its value
+// (redacted for secrets) and where it came from.
+type Setting struct {
+    Key    string `json:"key"`
+    Value  string `json:"value"`
+    Source string `json:"source"`
+}
+
+// redacted replaces the value of secret settings in reports.
+const redacted = "[redacted]"
+
+// secretKeys are settings whose values are never reported.
+var secretKeys = map[string]bool{
+    "DB_PASS":                    true,
+    "JWT_SECRET":                 true,
+    "JWT_KEYS":                   true,
+    "SMTP_PASSWORD":              true,
+    "BOT_CHECK_SECRET":           true,
+    "OTEL_EXPORTER_OTLP_HEADERS": true, // usually carries collector credentials
+}
+
+// RegisterFlags adds -config and -set to fs and returns the Sources they
+// fill once fs is parsed.  -config defaults to $CONFIG_FILE; -set
+// KEY=VALUE may be repeated.
+func RegisterFlags(fs *flag.FlagSet) *Sources {
+    src := &Sources{Overrides: map[string]string{}}
+    fs.StringVar(&src.File, "config", os.Getenv("CONFIG_FILE"), "YAML file of settings keyed by environment variable name")
+    fs.Var(overrideFlag(src.Overrides), "set", "override a setting as KEY=VALUE (repeatable)")
+    return src
+}
+
+// overrideFlag collects -set KEY=VALUE flags.
+type overrideFlag map[string]string
+
+func (f overrideFlag) String() string {
+    keys := make([]string, 0, len(f))
+    for k := range f {
+        keys = append(keys, k+"="+f[k])
+    }
+    sort.Strings(keys)
+    return strings.Join(keys, ",")
+}
+
+func (f overrideFlag) Set(s string) error {
+    k, v, ok := strings.Cut(s, "=")
+    k = strings.ToUpper(strings.TrimSpace(k))
+    if !ok || k == "" {
+        return fmt.Errorf("want KEY=VALUE, got %q", s)
+    }
+    f[k] = v
+    return nil
+}
+
+// ReadFile reads a settings file.  It accepts the flat subset of YAML a
+// list of settings needs: one "key: value" per line, values optionally
+// quoted, blank lines, "#" comments and "---" document markers.  Keys are
+// environment variable names and are matched case-insensitively.  Nested
+// mappings and lists are rejected.
+func ReadFile(path string) (map[string]string, error) {
+    f, err := os.Open(path)
+    if err != nil {
+        return nil, err
+    }
+    defer f.Close()
+    values := map[string]string{}
+    sc := bufio.NewScanner(f)
+    for n := 1; sc.Scan(); n++ {
+        line := sc.Text()
+        trimmed := strings.TrimSpace(line)
+        if trimmed == "" || strings.HasPrefix(trimmed, "#") || trimmed == "---" {
+            continue
+        }
+        if line[0] == ' ' || line[0] == '\t' || strings.HasPrefix(trimmed, "- ") {
+            return nil, fmt.Errorf("%s:%d: nested values are not supported", path, n)
+        }
+        key, raw, ok := strings.Cut(trimmed, ":")
+        key = strings.ToUpper(strings.TrimSpace(key))
+        if !ok || key == "" {
+            return nil, fmt.Errorf("%s:%d: want \"key: value\"", path, n)
+        }
+        v, err := yamlScalar(strings.TrimSpace(raw))
+        if err != nil {
+            return nil, fmt.Errorf("%s:%d: %s: %v", path, n, key, err)
+        }
+        values[key] = v
+    }
+    if err := sc.Err(); err != nil {
+        return nil, err
+    }
+    return values, nil
+}
+
+// yamlScalar returns the value of a YAML scalar: a double-quoted string
+// with Go escapes, a single-quoted string ('' for a quote) or plain text
+// up to a " #" comment.
+func yamlScalar(s string) (string, error) {
+    switch {
+    case strings.HasPrefix(s, `"`):
+        end := strings.LastIndex(s, `"`)
+        if end == 0 {
+            return "", fmt.Errorf("unterminated string")
+        }
+        if rest := strings.TrimSpace(s[end+1:]); rest != "" && !strings.HasPrefix(rest, "#") {
+            return "", fmt.Errorf("unexpected %q after string", rest)
+        }
+        return strconv.Unquote(s[:end+1])
+    case strings.HasPrefix(s, "'"):
+        end := strings.LastIndex(s, "'")
+        if end == 0 {
+            return "", fmt.Errorf("unterminated string")
+        }
+        return strings.ReplaceAll(s[1:end], "''", "'"), nil
+    case strings.HasPrefix(s, "{") || strings.HasPrefix(s, "["):
+        return "", fmt.Errorf("nested values are not supported")
+    }
+    if i := strings.Index(s, " #"); i >= 0 {
+        s = strings.TrimSpace(s[:i])
+    }
+    return s, nil
+}
+
+// loader looks settings up in Sources and records each one and every
+// problem found, so LoadFrom can report them all at once.
+type loader struct {
+    src      Sources
+    file     map[string]string
+    settings []Setting
+    problems []string
+}
+
+func newLoader(src Sources) (*loader, error) {
+    l := &loader{src: src}
+    if src.File != "" {
+        var err error
+        if l.file, err = ReadFile(src.File); err != nil {
+            return nil, err
+        }
+    }
+    return l, nil
+}
+
+// lookup returns the value of key and its origin, or "" and FromDefault.
+func (l *loader) lookup(key string) (string, string) {
+    if v := l.src.Overrides[key]; v != "" {
+        return v, FromFlag
+    }
+    if v := os.Getenv(key); v != "" {
+        return v, FromEnv
+    }
+    if v := l.file[key]; v != "" {
+        return v, FromFile
+    }
+    return "", FromDefault
+}
+
+// record notes the effective value of key for the settings report.
+func (l *loader) record(key, value, source string) {
+    if secretKeys[key] && value != "" {
+        value = redacted
+    }
+    l.settings = append(l.settings, Setting{Key: key, Value: value, Source: source})
+}
+
+func (l *loader) problem(format string, args ...interface{}) {
+    l.problems = append(l.problems, fmt.Sprintf(format, args...))
+}
+
+// str returns the setting key, or def when it is not set.
+func (l *loader) str(key, def string) string {
+    v, src := l.lookup(key)
+    if src == FromDefault {
+        v = def
+    }
+    l.record(key, v, src)
+    return v
+}
+
+// must returns a setting that has no default; a missing one is a problem.
+func (l *loader) must(key string) string {
+    v, src := l.lookup(key)
+    if src == FromDefault {
+        l.problem("%s is required", key)
+    }
+    l.record(key, v, src)
+    return v
+}
+
+// int returns an integer setting, or def when it is not set.
+func (l *loader) int(key string, def int) int {
+    v, src := l.lookup(key)
+    if src == FromDefault {
+        l.record(key, strconv.Itoa(def), src)
+        return def
+    }
+    l.record(key, v, src)
+    n, err := strconv.Atoi(v)
+    if err != nil {
+        l.problem("%s: invalid integer %q", key, v)
+    }
+    return n
+}
+
+// mustInt is like int for settings without a default.
+func (l *loader) mustInt(key string) int {
+    v := l.must(key)
+    if v == "" {
+        return 0
+    }
+    n, err := strconv.Atoi(v)
+    if err != nil {
+        l.problem("%s: invalid integer %q", key, v)
+    }
+    return n
+}
+
+// float is like int for floating-point settings.
+func (l *loader) float(key string, def float64) float64 {
+    v, src := l.lookup(key)
+    if src == FromDefault {
+        l.record(key, strconv.FormatFloat(def, 'g', -1, 64), src)
+        return def
+    }
+    l.record(key, v, src)
+    f, err := strconv.ParseFloat(v, 64)
+    if err != nil {
+        l.problem("%s: invalid number %q", key, v)
+    }
+    return f
+}
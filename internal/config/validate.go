@@ -0,0 +1,98 @@
+package config
+
+import (
+    "fmt"
+    "sort"
+    "strconv"
+)
+
+// Validate checks the values of c for consistency and returns one
+// message per problem, or nil.  LoadFrom calls it; it only catches
+// mistakes that would otherwise surface later, such as a port out of
+// range or holds outliving their own cap.
+func (c *Config) Validate() []string {
+    var p []string
+    add := func(format string, args ...interface{}) {
+        p = append(p, fmt.Sprintf(format, args...))
+    }
+    for key, v := range map[string]string{"APP_PORT": c.Port, "DB_PORT": c.DBPort} {
+        if n, err := strconv.Atoi(v); err != nil || n < 1 || n > 65535 {
+            add("%s must be a port number (1-65535), got %q", key, v)
+        }
+    }
+    // lifetimes that must be positive
+    for key, v := range map[string]int{
+        "ACCESS_TOKEN_TTL_MIN":        c.AccessTTLMin,
+        "REFRESH_TOKEN_TTL_DAYS":      c.RefreshTTLDays,
+        "HOLD_TTL_SEC":                c.HoldTTLSec,
+        "HOLD_MAX_TOTAL_SEC":          c.HoldMaxSec,
+        "PENDING_RESERVATION_TTL_SEC": c.PendingTTLSec,
+        "PASSWORD_RESET_TTL_MIN":      c.ResetTTLMin,
+        "TRANSFER_CODE_TTL_HOURS":     c.TransferTTLHours,
+        "WEBHOOK_TIMEOUT_SEC":         c.WebhookTimeoutSec,
+        "SAGA_TIMEOUT_SEC":            c.SagaTimeoutSec,
+    } {
+        if v <= 0 {
+            add("%s must be positive, got %d", key, v)
+        }
+    }
+    // intervals, limits and switches where 0 disables
+    for key, v := range map[string]int{
+        "HOLD_SWEEP_INTERVAL_SEC":     c.HoldSweepSec,
+        "HOLD_EXTEND_SEC":             c.HoldExtendSec,
+        "HOLD_MAX_SHOWS":              c.HoldMaxShows,
+        "GUEST_SESSION_TTL_MIN":       c.GuestTTLMin,
+        "QUOTA_FLUSH_INTERVAL_SEC":    c.QuotaFlushSec,
+        "SHOW_BUFFER_MIN":             c.ShowBufferMin,
+        "SHOW_MIN_LEAD_MIN":           c.ShowLeadMin,
+        "SHOW_MAX_HORIZON_DAYS":       c.ShowMaxDays,
+        "SAGA_RECOVERY_INTERVAL_SEC":  c.SagaRecoverSec,
+        "REFUND_INTERVAL_SEC":         c.RefundSweepSec,
+        "LOGIN_MAX_FAILURES":          c.LoginMaxFailures,
+        "LOGIN_MAX_IP_FAILURES":       c.LoginMaxIPFailures,
+        "WAITLIST_OFFER_SEC":          c.WaitlistOfferSec,
+        "NOTIFICATION_INTERVAL_SEC":   c.NotifySweepSec,
+        "SHOW_ANNOUNCE_INTERVAL_SEC":  c.AnnounceSweepSec,
+        "PENDING_EXPIRY_INTERVAL_SEC": c.PendingSweepSec,
+        "OWNER_DIGEST_INTERVAL_SEC":   c.DigestSweepSec,
+        "SEAT_RECONCILE_INTERVAL_SEC": c.ReconcileSweepSec,
+        "NO_SHOW_INTERVAL_SEC":        c.NoShowSweepSec,
+        "SHOW_FINISH_INTERVAL_SEC":    c.ShowFinishSweepSec,
+        "WEBHOOK_INTERVAL_SEC":        c.WebhookSweepSec,
+        "RATE_LIMIT_PUBLIC_BURST":     c.RateLimitPublicBurst,
+        "RATE_LIMIT_AUTH_BURST":       c.RateLimitAuthBurst,
+        "RATE_LIMIT_CUSTOMER_BURST":   c.RateLimitCustomerBurst,
+        "RATE_LIMIT_OWNER_BURST":      c.RateLimitOwnerBurst,
+        "RATE_LIMIT_IP_BURST":         c.RateLimitIPBurst,
+        "RATE_LIMIT_API_KEY_PER_MIN":  c.RateLimitAPIKeyPerMin,
+        "CACHE_TTL_SEC":               c.CacheTTLSec,
+        "GZIP_MIN_BYTES":              c.GzipMinBytes,
+        "DB_SLOW_QUERY_MS":            c.DBSlowQueryMs,
+        "REQUEST_TIMEOUT_SEC":         c.RequestTimeoutSec,
+        "BOOKING_TIMEOUT_SEC":         c.BookingTimeoutSec,
+    } {
+        if v < 0 {
+            add("%s must not be negative, got %d", key, v)
+        }
+    }
+    if c.BcryptCost < 4 || c.BcryptCost > 31 { // bcrypt.MinCost..MaxCost
+        add("BCRYPT_COST must be 4-31, got %d", c.BcryptCost)
+    }
+    if c.HoldTTLSec > 0 && c.HoldMaxSec > 0 && c.HoldMaxSec < c.HoldTTLSec {
+        add("HOLD_MAX_TOTAL_SEC (%d) must be at least HOLD_TTL_SEC (%d)", c.HoldMaxSec, c.HoldTTLSec)
+    }
+    if c.GuestTTLMin > 0 && c.GuestTTLMin*60 < c.HoldMaxSec {
+        add("GUEST_SESSION_TTL_MIN (%d) must cover HOLD_MAX_TOTAL_SEC (%d) so guest holds can be converted", c.GuestTTLMin, c.HoldMaxSec)
+    }
+    if c.RequestTimeoutSec > 0 && c.BookingTimeoutSec > c.RequestTimeoutSec {
+        add("BOOKING_TIMEOUT_SEC (%d) must not exceed REQUEST_TIMEOUT_SEC (%d)", c.BookingTimeoutSec, c.RequestTimeoutSec)
+    }
+    if _, err := c.TrustedProxyNets(); err != nil {
+        add("TRUSTED_PROXIES: %v", err)
+    }
+    if c.TraceSampling < 0 || c.TraceSampling > 1 {
+        add("OTEL_TRACES_SAMPLER_ARG must be between 0 and 1, got %g", c.TraceSampling)
+    }
+    sort.Strings(p)
+    return p
+}
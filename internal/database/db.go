@@ -4,17 +4,32 @@ import (
     "context"      // context allows us to set timeouts when pinging the database
     "database/sql" // generic database interface from the standard library
     "fmt"          // string formatting utilities
+    "log"          // slow query log
     "time"         // time types for setting connection lifetimes
 
-    _ "github.com/go-sql-driver/mysql" // import MySQL driver anonymously to register it with database/sql
+    "github.com/go-sql-driver/mysql" // MySQL driver; its connector is wrapped for tracing
+
+    "github.com/iliyamo/cinema-seat-reservation/internal/metrics" // slow query and pool metrics
+    "github.com/iliyamo/cinema-seat-reservation/internal/tracing" // SQL spans
 )
 
+// Options tune the connection pool and slow query logging.  Zero values
+// select the defaults.
+type Options struct {
+    MaxOpenConns    int           // open connections at most (default 25)
+    MaxIdleConns    int           // idle connections kept (default MaxOpenConns)
+    ConnMaxLifetime time.Duration // connections are recycled after this long (default 30 minutes)
+    SlowQuery       time.Duration // statements at least this slow are logged and counted (0 disables)
+}
+
 // Open connects to a MySQL database using the provided credentials and
-// connection parameters.  It sets reasonable connection pool settings and
-// verifies the connection by performing a ping with a timeout.  On
-// successful connection it returns a *sql.DB ready for use; otherwise an
-// error is returned.
-func Open(user, pass, host, port, name string) (*sql.DB, error) {
+// connection parameters.  The repositories are written for MySQL 8;
+// MariaDB 10.6 and later understands the same SQL through the same
+// driver, and other servers are not supported.  It configures the
+// connection pool from opts and verifies the connection by performing a
+// ping with a timeout.  On successful connection it returns a *sql.DB
+// ready for use; otherwise an error is returned.
+func Open(user, pass, host, port, name string, opts Options) (*sql.DB, error) {
     // Build the authentication part of the DSN.  If a password is provided,
     // include it in the DSN; otherwise only use the username.
     auth := user
@@ -27,18 +42,36 @@ func Open(user, pass, host, port, name string) (*sql.DB, error) {
     dsn := fmt.Sprintf("%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=true&loc=UTC",
         auth, host, port, name)
 
-    // Open a new database handle.  sql.Open does not establish connections
-    // immediately; it validates the arguments and prepares the handle.
-    db, err := sql.Open("mysql", dsn)
+    // Build the connector and open it through otelsql so statements
+    // issued under a traced request context show up as spans named after
+    // the repository method, and slow statements are logged under the
+    // same name.  Opening does not establish connections immediately.
+    mcfg, err := mysql.ParseDSN(dsn)
+    if err != nil {
+        return nil, err
+    }
+    connector, err := mysql.NewConnector(mcfg)
     if err != nil {
         return nil, err
     }
+    db := tracing.OpenDB(connector, "mysql", "github.com/iliyamo/cinema-seat-reservation/internal/repository",
+        opts.SlowQuery, logSlowQuery)
 
-    // Configure connection pooling.  Set the maximum number of open and
-    // idle connections and limit the lifetime of connections to 30 minutes.
-    db.SetMaxOpenConns(25)
-    db.SetMaxIdleConns(25)
-    db.SetConnMaxLifetime(30 * time.Minute)
+    // Configure connection pooling.  Without a cap on open connections a
+    // burst of requests would open connections until MySQL refuses them;
+    // with it, requests queue for a connection (see RegisterPoolMetrics).
+    if opts.MaxOpenConns <= 0 {
+        opts.MaxOpenConns = 25
+    }
+    if opts.MaxIdleConns <= 0 || opts.MaxIdleConns > opts.MaxOpenConns {
+        opts.MaxIdleConns = opts.MaxOpenConns
+    }
+    if opts.ConnMaxLifetime <= 0 {
+        opts.ConnMaxLifetime = 30 * time.Minute
+    }
+    db.SetMaxOpenConns(opts.MaxOpenConns)
+    db.SetMaxIdleConns(opts.MaxIdleConns)
+    db.SetConnMaxLifetime(opts.ConnMaxLifetime)
 
     // Verify the database connection by pinging it with a timeout.  Use a
     // context with a 5‑second deadline to avoid hanging on broken networks.
@@ -49,3 +82,40 @@ func Open(user, pass, host, port, name string) (*sql.DB, error) {
     }
     return db, nil
 }
+
+// logSlowQuery logs a statement that exceeded the slow query threshold
+// and counts it by name.  Arguments are not logged, only the SQL text.
+func logSlowQuery(q tracing.SlowQuery) {
+    metrics.SlowQueries.Inc(q.Name)
+    if q.Err != nil {
+        log.Printf("slow query: %s took %s (error: %v): %s", q.Name, q.Duration.Round(time.Millisecond), q.Err, q.Statement)
+        return
+    }
+    log.Printf("slow query: %s took %s: %s", q.Name, q.Duration.Round(time.Millisecond), q.Statement)
+}
+
+// RegisterPoolMetrics exposes the connection pool statistics of db on
+// GET /metrics.  A growing wait count or wait duration means requests are
+// queuing for connections and DB_MAX_OPEN_CONNS is too low for the load
+// (or queries hold connections too long).  Call it once per process.
+func RegisterPoolMetrics(db *sql.DB) {
+    stat := func(f func(sql.DBStats) float64) func() float64 {
+        return func() float64 { return f(db.Stats()) }
+    }
+    metrics.NewGaugeFunc("cinema_db_pool_max_open_connections", "Maximum number of open database connections.",
+        stat(func(s sql.DBStats) float64 { return float64(s.MaxOpenConnections) }))
+    metrics.NewGaugeFunc("cinema_db_pool_open_connections", "Open database connections, in use or idle.",
+        stat(func(s sql.DBStats) float64 { return float64(s.OpenConnections) }))
+    metrics.NewGaugeFunc("cinema_db_pool_in_use_connections", "Database connections in use.",
+        stat(func(s sql.DBStats) float64 { return float64(s.InUse) }))
+    metrics.NewGaugeFunc("cinema_db_pool_idle_connections", "Idle database connections.",
+        stat(func(s sql.DBStats) float64 { return float64(s.Idle) }))
+    metrics.NewCounterFunc("cinema_db_pool_wait_total", "Times a request waited for a free database connection.",
+        stat(func(s sql.DBStats) float64 { return float64(s.WaitCount) }))
+    metrics.NewCounterFunc("cinema_db_pool_wait_seconds_total", "Time spent waiting for free database connections.",
+        stat(func(s sql.DBStats) float64 { return s.WaitDuration.Seconds() }))
+    metrics.NewCounterFunc("cinema_db_pool_closed_max_idle_total", "Connections closed because the idle pool was full.",
+        stat(func(s sql.DBStats) float64 { return float64(s.MaxIdleClosed) }))
+    metrics.NewCounterFunc("cinema_db_pool_closed_max_lifetime_total", "Connections closed after reaching their maximum lifetime.",
+        stat(func(s sql.DBStats) float64 { return float64(s.MaxLifetimeClosed) }))
+}
@@ -0,0 +1,39 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+)
+
+// TryLock takes the MySQL named lock name without waiting and reports
+// whether it was acquired.  Named locks are shared by every instance
+// using the database, so TryLock lets replicas agree on who runs a
+// periodic job.  A named lock belongs to a connection: while it is held
+// one connection of conn is reserved, and release must be called to
+// unlock it and return the connection.  When another session holds the
+// lock, ok is false and release is nil.
+func TryLock(ctx context.Context, conn *sql.DB, name string) (release func(), ok bool, err error) {
+	c, err := conn.Conn(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	var got sql.NullInt64
+	if err := c.QueryRowContext(ctx, `SELECT GET_LOCK(?, 0)`, name).Scan(&got); err != nil {
+		_ = c.Close()
+		return nil, false, err
+	}
+	if got.Int64 != 1 {
+		_ = c.Close()
+		return nil, false, nil
+	}
+	release = func() {
+		// When the release fails the connection is discarded instead of
+		// returned to the pool; the server drops the lock with the session.
+		if _, err := c.ExecContext(context.Background(), `DO RELEASE_LOCK(?)`, name); err != nil {
+			_ = c.Raw(func(interface{}) error { return driver.ErrBadConn })
+		}
+		_ = c.Close()
+	}
+	return release, true, nil
+}
@@ -0,0 +1,144 @@
+// Package db provides transaction helpers shared by handlers and services.
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math/rand"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+
+	"github.com/iliyamo/cinema-seat-reservation/internal/metrics"
+)
+
+// tracer records the db.WithTx spans through the global tracer provider.
+var tracer = otel.Tracer("github.com/iliyamo/cinema-seat-reservation/internal/db")
+
+// MySQL error numbers that indicate the transaction was rolled back (or
+// should be) because of contention and can safely be retried from the start.
+const (
+	errLockWaitTimeout = 1205 // ER_LOCK_WAIT_TIMEOUT
+	errDeadlock        = 1213 // ER_LOCK_DEADLOCK
+)
+
+// RetryPolicy controls how WithTx retries transient failures.
+type RetryPolicy struct {
+	MaxAttempts int           // total attempts including the first; values < 1 mean 1
+	BaseDelay   time.Duration // delay before the second attempt; doubled each retry
+	MaxDelay    time.Duration // upper bound for a single delay
+}
+
+// DefaultRetryPolicy is used by WithTx: up to four attempts with 20ms, 40ms
+// and 80ms (plus jitter) between them.
+var DefaultRetryPolicy = RetryPolicy{MaxAttempts: 4, BaseDelay: 20 * time.Millisecond, MaxDelay: 250 * time.Millisecond}
+
+// IsTransient reports whether err (or any error it wraps) is a MySQL
+// deadlock or lock wait timeout.
+func IsTransient(err error) bool {
+	var me *mysql.MySQLError
+	if errors.As(err, &me) {
+		return me.Number == errDeadlock || me.Number == errLockWaitTimeout
+	}
+	return false
+}
+
+// WithTx runs fn inside a transaction using DefaultRetryPolicy.  See
+// WithTxPolicy.
+func WithTx(ctx context.Context, conn *sql.DB, fn func(tx *sql.Tx) error) error {
+	return WithTxPolicy(ctx, conn, DefaultRetryPolicy, fn)
+}
+
+// WithTxPolicy begins a transaction, calls fn and commits when fn returns
+// nil.  When fn returns an error the transaction is rolled back and the
+// error returned unchanged.  If the error (from fn or from Commit) is
+// transient per IsTransient, the whole transaction is retried after an
+// exponential backoff with jitter, up to p.MaxAttempts.  fn must therefore
+// be safe to run more than once: it should only touch the database through
+// tx and must not have side effects outside it.  The total duration,
+// including retries, is recorded in metrics.TxDuration and, for traced
+// requests, as a "db.WithTx" span parenting the transaction's queries.
+func WithTxPolicy(ctx context.Context, conn *sql.DB, p RetryPolicy, fn func(tx *sql.Tx) error) (err error) {
+	start := time.Now()
+	ctx, span := startSpan(ctx)
+	tries := 0
+	defer func() {
+		outcome := "commit"
+		if err != nil {
+			outcome = "rollback"
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		metrics.TxDuration.Observe(time.Since(start).Seconds(), outcome)
+		span.SetAttributes(attribute.Int("db.tx.attempts", tries))
+		span.End()
+	}()
+	attempts := p.MaxAttempts
+	if attempts < 1 {
+		attempts = 1
+	}
+	delay := p.BaseDelay
+	for i := 0; i < attempts; i++ {
+		if i > 0 {
+			metrics.TxRetries.Inc()
+			wait := delay + time.Duration(rand.Int63n(int64(delay)/2+1))
+			select {
+			case <-ctx.Done():
+				return err
+			case <-time.After(wait):
+			}
+			delay *= 2
+			if p.MaxDelay > 0 && delay > p.MaxDelay {
+				delay = p.MaxDelay
+			}
+		}
+		tries++
+		err = runTx(ctx, conn, fn)
+		if err == nil || !IsTransient(err) {
+			return err
+		}
+	}
+	return err
+}
+
+// startSpan starts the "db.WithTx" span when ctx belongs to a traced
+// request.  Transactions of background jobs get a no-op span, so they do
+// not start traces of their own.
+func startSpan(ctx context.Context) (context.Context, trace.Span) {
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		return ctx, noop.Span{}
+	}
+	return tracer.Start(ctx, "db.WithTx")
+}
+
+// runTx executes a single transaction attempt.
+func runTx(ctx context.Context, conn *sql.DB, fn func(tx *sql.Tx) error) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// ReadOnlyTx runs fn inside a READ ONLY transaction that is always rolled
+// back, for callers that check what a change would do without making it.
+// MySQL rejects writes and locking reads in such a transaction, so fn
+// sees a consistent snapshot but must not rely on row locks.
+func ReadOnlyTx(ctx context.Context, conn *sql.DB, fn func(tx *sql.Tx) error) error {
+	tx, err := conn.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	return fn(tx)
+}
@@ -0,0 +1,188 @@
+// Package graphql serves read-only GraphQL queries.  A client can fetch
+// cinemas, their halls, the halls' shows and the shows' availability in
+// one request instead of walking the REST browse endpoints.
+//
+// The schema is schema.graphqls; gqlgen generates the executor
+// (generated.go) and the resolver stubs (schema.resolvers.go) from it.
+// Mutations and subscriptions are not part of the schema.
+//
+// Resolvers of nested fields go through the data loaders of loaders.go:
+// the loads of one level of the response are batched into one query, so
+// fetching the halls of 20 cinemas and the shows of those halls costs
+// two queries rather than one per cinema and hall, and rows are cached
+// for the request.
+package graphql
+
+//go:generate go tool gqlgen generate
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	gql "github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/lru"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+
+	"github.com/iliyamo/cinema-seat-reservation/internal/repository"
+)
+
+// DefaultMaxDepth is the nesting limit of Schema.MaxDepth when zero.
+const DefaultMaxDepth = 10
+
+// Schema serves the browse schema over HTTP.
+type Schema struct {
+	// MaxDepth bounds how deeply selections may nest; zero means
+	// DefaultMaxDepth.
+	MaxDepth int
+
+	resolver *Resolver
+	srv      *handler.Server
+	sdl      string
+}
+
+// NewBrowseSchema returns the read-only schema over cinemas, halls,
+// shows and seat availability.
+func NewBrowseSchema(cinemas *repository.CinemaRepo, halls *repository.HallRepo, shows *repository.ShowRepo, showSeats *repository.ShowSeatRepo) *Schema {
+	if cinemas == nil || halls == nil || shows == nil || showSeats == nil {
+		panic("nil repository passed to NewBrowseSchema")
+	}
+	r := &Resolver{cinemas: cinemas, halls: halls, shows: shows, showSeats: showSeats}
+	es := NewExecutableSchema(Config{Resolvers: r})
+	s := &Schema{resolver: r, sdl: sources[0].Input}
+	s.srv = handler.New(es)
+	s.srv.AddTransport(transport.GET{})
+	s.srv.AddTransport(transport.POST{})
+	s.srv.AddTransport(transport.GRAPHQL{})
+	s.srv.SetQueryCache(lru.New[*ast.QueryDocument](1000))
+	s.srv.Use(extension.Introspection{})
+	s.srv.Use(depthLimit{s})
+	s.srv.SetErrorPresenter(presentError)
+	s.srv.SetRecoverFunc(func(_ context.Context, err interface{}) error {
+		log.Printf("graphql: panic: %v", err)
+		return errors.New("internal error")
+	})
+	return s
+}
+
+// ServeHTTP answers a GraphQL request: GET with the query in the URL,
+// POST with a JSON body or an application/graphql body.  Every request
+// gets fresh data loaders.
+func (s *Schema) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.srv.ServeHTTP(w, r.WithContext(s.resolver.withLoaders(r.Context())))
+}
+
+// SDL returns the schema in the GraphQL schema definition language.
+func (s *Schema) SDL() string { return s.sdl }
+
+// presentError reports errors from resolvers: *gqlerror.Error values,
+// which the executor and resolvers return for problems the client
+// should see, such as an argument out of range, are passed on; other
+// errors are logged and reported as "internal error".
+func presentError(ctx context.Context, err error) *gqlerror.Error {
+	var gqlErr *gqlerror.Error
+	if errors.As(err, &gqlErr) && gqlErr.Err == nil {
+		return gql.DefaultErrorPresenter(ctx, err)
+	}
+	log.Printf("graphql: %v", err)
+	e := gql.DefaultErrorPresenter(ctx, err)
+	e.Message = "internal error"
+	return e
+}
+
+// depthLimit rejects operations whose selections nest deeper than the
+// schema's MaxDepth.
+type depthLimit struct{ s *Schema }
+
+var _ interface {
+	gql.HandlerExtension
+	gql.OperationContextMutator
+} = depthLimit{}
+
+func (depthLimit) ExtensionName() string                        { return "DepthLimit" }
+func (depthLimit) Validate(gql.ExecutableSchema) error            { return nil }
+func (d depthLimit) MutateOperationContext(_ context.Context, oc *gql.OperationContext) *gqlerror.Error {
+	max := d.s.MaxDepth
+	if max <= 0 {
+		max = DefaultMaxDepth
+	}
+	if depth(oc.Operation.SelectionSet, 0) > max {
+		return gqlerror.Errorf("selections nest more than %d levels deep", max)
+	}
+	return nil
+}
+
+// depth returns how deeply set nests, counting fields; fragments are
+// followed, and validation has already rejected fragment cycles.
+func depth(set ast.SelectionSet, level int) int {
+	deepest := level
+	for _, sel := range set {
+		var d int
+		switch sel := sel.(type) {
+		case *ast.Field:
+			d = depth(sel.SelectionSet, level+1)
+		case *ast.InlineFragment:
+			d = depth(sel.SelectionSet, level)
+		case *ast.FragmentSpread:
+			d = depth(sel.Definition.SelectionSet, level)
+		}
+		if d > deepest {
+			deepest = d
+		}
+	}
+	return deepest
+}
+
+// ID is the ID scalar, carried as a uint64 database id.
+type ID = uint64
+
+// MarshalID writes an id as a string, as GraphQL clients expect.
+func MarshalID(id uint64) gql.Marshaler {
+	return gql.MarshalString(strconv.FormatUint(id, 10))
+}
+
+// UnmarshalID reads an id given as a string or an integer.  Its errors,
+// like those of UnmarshalDateTime, are *gqlerror.Error so the client
+// sees them.
+func UnmarshalID(v interface{}) (uint64, error) {
+	s, err := gql.UnmarshalString(v)
+	if err != nil {
+		return 0, gqlerror.Errorf("ID must be a string or an integer")
+	}
+	id, err := strconv.ParseUint(s, 10, 64)
+	if err != nil || id == 0 {
+		return 0, gqlerror.Errorf("invalid id %q", s)
+	}
+	return id, nil
+}
+
+// DateTime is the DateTime scalar, an RFC 3339 timestamp.
+type DateTime = time.Time
+
+// MarshalDateTime writes t in UTC as RFC 3339.
+func MarshalDateTime(t time.Time) gql.Marshaler {
+	return gql.WriterFunc(func(w io.Writer) {
+		io.WriteString(w, strconv.Quote(t.UTC().Format(time.RFC3339)))
+	})
+}
+
+// UnmarshalDateTime reads an RFC 3339 string.
+func UnmarshalDateTime(v interface{}) (time.Time, error) {
+	s, ok := v.(string)
+	if !ok {
+		return time.Time{}, gqlerror.Errorf("DateTime must be an RFC 3339 string")
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, gqlerror.Errorf("DateTime must be an RFC 3339 string such as 2025-08-09T19:30:00Z")
+	}
+	return t, nil
+}
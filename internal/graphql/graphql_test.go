@@ -0,0 +1,211 @@
+package graphql_test
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/iliyamo/cinema-seat-reservation/internal/graphql"
+	"github.com/iliyamo/cinema-seat-reservation/internal/repository"
+	"github.com/iliyamo/cinema-seat-reservation/internal/repository/mock"
+)
+
+// browseDB answers the browse schema's queries from three cinemas with
+// two halls each and one show per hall, and records the queries.
+type browseDB struct {
+	mu      sync.Mutex
+	queries []string
+	fail    bool
+}
+
+var start = time.Date(2025, 8, 9, 19, 30, 0, 0, time.UTC)
+
+// ids returns the id arguments of an IN list.
+func ids(args []driver.NamedValue) map[int64]bool {
+	out := map[int64]bool{}
+	for _, a := range args {
+		if id, ok := a.Value.(int64); ok {
+			out[id] = true
+		}
+	}
+	return out
+}
+
+func (b *browseDB) query(q string, args []driver.NamedValue) ([]string, [][]driver.Value, error) {
+	b.mu.Lock()
+	b.queries = append(b.queries, q)
+	b.mu.Unlock()
+	if b.fail {
+		return nil, nil, errors.New("connection refused")
+	}
+	want := ids(args)
+	var rows [][]driver.Value
+	switch {
+	case strings.Contains(q, "FROM halls"): // before cinemas, which the hall's time zone is selected from
+		// hall 10c+n is hall n of cinema c
+		for c := int64(1); c <= 3; c++ {
+			for n := int64(1); n <= 2; n++ {
+				if id := 10*c + n; want[c] || want[id] {
+					rows = append(rows, []driver.Value{id, int64(1), c, "Hall", nil, int64(5), nil, true, "Europe/Berlin", "EUR", start, start})
+				}
+			}
+		}
+		return strings.Split("id owner_id cinema_id name description seat_rows seat_cols is_active timezone currency created_at updated_at", " "), rows, nil
+	case strings.Contains(q, "FROM cinemas"):
+		for c := int64(1); c <= 3; c++ {
+			rows = append(rows, []driver.Value{c, int64(1), "Cinema", "Europe/Berlin", "EUR", "Berlin", nil, nil, nil, nil, nil, nil, nil, start, start})
+		}
+		return strings.Split("id owner_id name timezone currency city address latitude longitude logo_url description phone opening_hours created_at updated_at", " "), rows, nil
+	case strings.Contains(q, "FROM shows"):
+		// show 10h is the show of hall h
+		for hall := range want {
+			rows = append(rows, []driver.Value{10 * hall, hall, "Film", start, start.Add(2 * time.Hour), int64(1000), "SCHEDULED", nil, start, start})
+		}
+		return strings.Split("id hall_id title starts_at ends_at base_price_cents status hold_ttl_sec created_at updated_at", " "), rows, nil
+	case strings.Contains(q, "FROM show_seats"):
+		for show := range want {
+			rows = append(rows,
+				[]driver.Value{show, "STANDARD", "FREE", int64(8)},
+				[]driver.Value{show, "STANDARD", "RESERVED", int64(2)},
+				[]driver.Value{show, "VIP", "FREE", int64(4)})
+		}
+		return []string{"show_id", "seat_type", "computed", "count"}, rows, nil
+	}
+	return nil, nil, errors.New("unexpected query " + q)
+}
+
+func newSchema(t *testing.T, b *browseDB) *graphql.Schema {
+	t.Helper()
+	conn := (&mock.Driver{QueryFn: b.query}).DB()
+	t.Cleanup(func() { conn.Close() })
+	return graphql.NewBrowseSchema(repository.NewCinemaRepo(conn), repository.NewHallRepo(conn),
+		repository.NewShowRepo(conn), repository.NewShowSeatRepo(conn))
+}
+
+type response struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// post runs query against s and decodes the response.
+func post(t *testing.T, s *graphql.Schema, query string) response {
+	t.Helper()
+	body, _ := json.Marshal(map[string]string{"query": query})
+	req := httptest.NewRequest(http.MethodPost, "/v1/graphql", strings.NewReader(string(body)))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	var resp response
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decoding %q: %v", rec.Body.String(), err)
+	}
+	return resp
+}
+
+func TestNestedQueryLoadsEachLevelOnce(t *testing.T) {
+	b := &browseDB{}
+	resp := post(t, newSchema(t, b), `{
+		cinemas {
+			id
+			halls {
+				id seatRows seatCols
+				cinema { id }
+				shows {
+					id startTime timezone localStartTime
+					hall { id }
+					availability { total free reserved bySeatType { seatType free } }
+				}
+			}
+		}
+	}`)
+	if len(resp.Errors) > 0 {
+		t.Fatalf("errors: %+v", resp.Errors)
+	}
+	// cinemas, their halls, the halls' shows and the shows' seats; the
+	// halls' cinemas and the shows' halls come from the loaders' caches
+	if len(b.queries) != 4 {
+		t.Fatalf("%d queries, want 4:\n%s", len(b.queries), strings.Join(b.queries, "\n"))
+	}
+	var data struct {
+		Cinemas []struct {
+			ID    string
+			Halls []struct {
+				ID       string
+				SeatRows *int
+				SeatCols *int
+				Cinema   struct{ ID string }
+				Shows    []struct {
+					ID             string
+					StartTime      string
+					Timezone       string
+					LocalStartTime string
+					Hall           struct{ ID string }
+					Availability   struct {
+						Total, Free, Reserved int
+						BySeatType            []struct {
+							SeatType string
+							Free     int
+						}
+					}
+				}
+			}
+		}
+	}
+	if err := json.Unmarshal(resp.Data, &data); err != nil {
+		t.Fatal(err)
+	}
+	if len(data.Cinemas) != 3 {
+		t.Fatalf("%d cinemas, want 3", len(data.Cinemas))
+	}
+	for _, c := range data.Cinemas {
+		if len(c.Halls) != 2 {
+			t.Fatalf("cinema %s has %d halls, want 2", c.ID, len(c.Halls))
+		}
+		for _, h := range c.Halls {
+			if h.Cinema.ID != c.ID || h.SeatRows == nil || *h.SeatRows != 5 || h.SeatCols != nil || len(h.Shows) != 1 {
+				t.Fatalf("hall %+v of cinema %s", h, c.ID)
+			}
+			s := h.Shows[0]
+			if s.Hall.ID != h.ID || s.StartTime != "2025-08-09T19:30:00Z" || s.Timezone != "Europe/Berlin" ||
+				s.LocalStartTime != "2025-08-09T21:30:00+02:00" {
+				t.Fatalf("show %+v of hall %s", s, h.ID)
+			}
+			if a := s.Availability; a.Total != 14 || a.Free != 12 || a.Reserved != 2 || len(a.BySeatType) != 2 {
+				t.Fatalf("availability %+v", a)
+			}
+		}
+	}
+}
+
+func TestErrors(t *testing.T) {
+	cases := []struct {
+		name  string
+		fail  bool
+		query string
+		want  string
+	}{
+		{"limit out of range", false, `{ cinemas(limit: 500) { id } }`, "limit must be between 1 and 200"},
+		{"invalid id", false, `{ cinema(id: "x") { id } }`, `invalid id "x"`},
+		{"invalid time", false, `{ hall(id: 11) { shows(from: "tomorrow") { id } } }`, "DateTime must be an RFC 3339 string such as 2025-08-09T19:30:00Z"},
+		{"unknown field", false, `{ cinemas { owner } }`, `Cannot query field "owner" on type "Cinema".`},
+		{"too deep", false, `{ cinemas { halls { cinema { halls { cinema { halls { cinema { halls { cinema { halls { id } } } } } } } } } } }`,
+			"selections nest more than 10 levels deep"},
+		{"database error", true, `{ cinemas { id } }`, "internal error"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp := post(t, newSchema(t, &browseDB{fail: tc.fail}), tc.query)
+			if len(resp.Errors) != 1 || resp.Errors[0].Message != tc.want {
+				t.Fatalf("errors = %+v, want %q", resp.Errors, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,203 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/vikstrous/dataloadgen"
+
+	"github.com/iliyamo/cinema-seat-reservation/internal/repository"
+)
+
+// showsKey selects the shows of a hall for Hall.shows.  Keys with the
+// same filter are loaded in one query.
+type showsKey struct {
+	HallID uint64
+	Filter showsFilter
+}
+
+type showsFilter struct {
+	From, To time.Time // zero when not set
+	All      bool
+}
+
+// loaders holds the data loaders of one request, one per resolver that
+// reads the database for nested fields.  Loaded rows are cached for the
+// request, so a cinema or hall referenced by many shows is read once.
+type loaders struct {
+	cinema       *dataloadgen.Loader[uint64, *repository.Cinema]
+	hall         *dataloadgen.Loader[uint64, *repository.Hall]
+	cinemaHalls  *dataloadgen.Loader[uint64, []*repository.Hall]
+	hallShows    *dataloadgen.Loader[showsKey, []*repository.Show]
+	availability *dataloadgen.Loader[uint64, *SeatCounts]
+}
+
+type loadersKey struct{}
+
+// withLoaders returns ctx with fresh loaders reading through r.  A
+// loader collects keys for dataloadgen's default wait of 16ms before it
+// queries; gqlgen resolves the fields of a list's elements concurrently,
+// so the loads of one level of the response arrive well within it.
+func (r *Resolver) withLoaders(ctx context.Context) context.Context {
+	return context.WithValue(ctx, loadersKey{}, &loaders{
+		cinema:       dataloadgen.NewLoader(r.fetchCinemas),
+		hall:         dataloadgen.NewLoader(r.fetchHalls),
+		cinemaHalls:  dataloadgen.NewLoader(r.fetchCinemaHalls),
+		hallShows:    dataloadgen.NewLoader(r.fetchHallShows),
+		availability: dataloadgen.NewLoader(r.fetchAvailability),
+	})
+}
+
+// loadersFor returns the loaders of the request of ctx.
+func (r *Resolver) loadersFor(ctx context.Context) *loaders {
+	if l, ok := ctx.Value(loadersKey{}).(*loaders); ok {
+		return l
+	}
+	return r.withLoaders(ctx).Value(loadersKey{}).(*loaders)
+}
+
+// failed returns the result of a fetch of n keys that failed with err:
+// zero values and err for every key.
+func failed[V any](n int, err error) ([]V, []error) {
+	errs := make([]error, n)
+	for i := range errs {
+		errs[i] = err
+	}
+	return make([]V, n), errs
+}
+
+// fetchCinemas loads cinemas by id; unknown ids yield nil.
+func (r *Resolver) fetchCinemas(ctx context.Context, ids []uint64) ([]*repository.Cinema, []error) {
+	rows, err := r.cinemas.GetByIDs(ctx, ids)
+	if err != nil {
+		return failed[*repository.Cinema](len(ids), err)
+	}
+	byID := make(map[uint64]*repository.Cinema, len(rows))
+	for _, c := range rows {
+		byID[c.ID] = c
+	}
+	out := make([]*repository.Cinema, len(ids))
+	for i, id := range ids {
+		out[i] = byID[id]
+	}
+	return out, nil
+}
+
+// fetchHalls loads halls by id; unknown ids yield nil.
+func (r *Resolver) fetchHalls(ctx context.Context, ids []uint64) ([]*repository.Hall, []error) {
+	rows, err := r.halls.GetByIDs(ctx, ids)
+	if err != nil {
+		return failed[*repository.Hall](len(ids), err)
+	}
+	byID := make(map[uint64]*repository.Hall, len(rows))
+	for _, h := range rows {
+		byID[h.ID] = h
+	}
+	out := make([]*repository.Hall, len(ids))
+	for i, id := range ids {
+		out[i] = byID[id]
+	}
+	return out, nil
+}
+
+// fetchCinemaHalls loads the halls of cinemas by cinema id and primes
+// the hall loader with them.
+func (r *Resolver) fetchCinemaHalls(ctx context.Context, cinemaIDs []uint64) ([][]*repository.Hall, []error) {
+	rows, err := r.halls.ListByCinemas(ctx, cinemaIDs)
+	if err != nil {
+		return failed[[]*repository.Hall](len(cinemaIDs), err)
+	}
+	l := r.loadersFor(ctx)
+	byCinema := make(map[uint64][]*repository.Hall)
+	for _, h := range rows {
+		l.hall.Prime(h.ID, h)
+		byCinema[*h.CinemaID] = append(byCinema[*h.CinemaID], h)
+	}
+	out := make([][]*repository.Hall, len(cinemaIDs))
+	for i, id := range cinemaIDs {
+		out[i] = append([]*repository.Hall{}, byCinema[id]...)
+	}
+	return out, nil
+}
+
+// fetchHallShows loads the shows of halls, one query per distinct
+// filter among the keys.
+func (r *Resolver) fetchHallShows(ctx context.Context, keys []showsKey) ([][]*repository.Show, []error) {
+	byFilter := make(map[showsFilter][]uint64)
+	var filters []showsFilter
+	for _, k := range keys {
+		if _, ok := byFilter[k.Filter]; !ok {
+			filters = append(filters, k.Filter)
+		}
+		byFilter[k.Filter] = append(byFilter[k.Filter], k.HallID)
+	}
+	shows := make(map[showsKey][]*repository.Show, len(keys))
+	for _, f := range filters {
+		var from, to *time.Time
+		if !f.From.IsZero() {
+			from = &f.From
+		}
+		if !f.To.IsZero() {
+			to = &f.To
+		}
+		rows, err := r.shows.ListByHalls(ctx, byFilter[f], from, to, f.All)
+		if err != nil {
+			return failed[[]*repository.Show](len(keys), err)
+		}
+		for i := range rows {
+			k := showsKey{HallID: rows[i].HallID, Filter: f}
+			shows[k] = append(shows[k], &rows[i])
+		}
+	}
+	out := make([][]*repository.Show, len(keys))
+	for i, k := range keys {
+		out[i] = append([]*repository.Show{}, shows[k]...)
+	}
+	return out, nil
+}
+
+// fetchAvailability loads the seat counts of shows by show id.
+func (r *Resolver) fetchAvailability(ctx context.Context, showIDs []uint64) ([]*SeatCounts, []error) {
+	rows, err := r.showSeats.AvailabilityForShows(ctx, showIDs)
+	if err != nil {
+		return failed[*SeatCounts](len(showIDs), err)
+	}
+	byShow := map[uint64]*SeatCounts{}
+	byType := map[uint64]map[string]*SeatCounts{}
+	for _, a := range rows {
+		total := byShow[a.ShowID]
+		if total == nil {
+			total = &SeatCounts{BySeatType: []*SeatCounts{}}
+			byShow[a.ShowID] = total
+			byType[a.ShowID] = map[string]*SeatCounts{}
+		}
+		total.add(a.Status, a.Seats)
+		t := byType[a.ShowID][a.SeatType]
+		if t == nil {
+			t = &SeatCounts{SeatType: a.SeatType}
+			byType[a.ShowID][a.SeatType] = t
+			total.BySeatType = append(total.BySeatType, t)
+		}
+		t.add(a.Status, a.Seats)
+	}
+	out := make([]*SeatCounts, len(showIDs))
+	for i, id := range showIDs {
+		if out[i] = byShow[id]; out[i] == nil {
+			out[i] = &SeatCounts{BySeatType: []*SeatCounts{}}
+		}
+	}
+	return out, nil
+}
+
+// hallOf returns the hall of show s, which must exist.
+func (r *Resolver) hallOf(ctx context.Context, s *repository.Show) (*repository.Hall, error) {
+	h, err := r.loadersFor(ctx).hall.Load(ctx, s.HallID)
+	if err != nil {
+		return nil, err
+	}
+	if h == nil {
+		return nil, errors.New("hall " + formatID(s.HallID) + " of a show not found")
+	}
+	return h, nil
+}
@@ -0,0 +1,79 @@
+package graphql
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"time"
+
+	"github.com/iliyamo/cinema-seat-reservation/internal/repository"
+)
+
+// Limits of the browse schema's list arguments.
+const (
+	maxCinemas      = 200
+	maxShowsPerHall = 500
+)
+
+// Resolver resolves the public browse schema.  Like the REST browse
+// endpoints it exposes only public fields: no owners, prices or
+// timestamps.
+type Resolver struct {
+	cinemas   *repository.CinemaRepo
+	halls     *repository.HallRepo
+	shows     *repository.ShowRepo
+	showSeats *repository.ShowSeatRepo
+}
+
+// SeatCounts is the availability of a show's seats, overall or of one
+// seat type (SeatTypeCounts).
+type SeatCounts struct {
+	SeatType                             string
+	Total, Free, Held, Reserved, Blocked int
+	BySeatType                           []*SeatCounts // per seat type; overall counts only
+}
+
+func (s *SeatCounts) add(status string, n int) {
+	s.Total += n
+	switch status {
+	case "FREE":
+		s.Free += n
+	case "HELD":
+		s.Held += n
+	case "RESERVED":
+		s.Reserved += n
+	case "BLOCKED":
+		s.Blocked += n
+	}
+}
+
+func formatID(id uint64) string { return strconv.FormatUint(id, 10) }
+
+// intArg returns the Int argument v, or def when it is null.
+func intArg(v *int, def int) int {
+	if v == nil {
+		return def
+	}
+	return *v
+}
+
+func nullInt(n sql.NullInt32) *int {
+	if !n.Valid {
+		return nil
+	}
+	v := int(n.Int32)
+	return &v
+}
+
+// location returns the time zone of the cinema of show s.
+func (r *Resolver) location(ctx context.Context, s *repository.Show) (*time.Location, error) {
+	h, err := r.hallOf(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+	loc, err := time.LoadLocation(h.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	return loc, nil
+}
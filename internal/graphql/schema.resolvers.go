@@ -0,0 +1,153 @@
+package graphql
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+// Code generated by github.com/99designs/gqlgen
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/iliyamo/cinema-seat-reservation/internal/repository"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// Halls is the resolver for the halls field.
+func (r *cinemaResolver) Halls(ctx context.Context, obj *repository.Cinema) ([]*repository.Hall, error) {
+	return r.loadersFor(ctx).cinemaHalls.Load(ctx, obj.ID)
+}
+
+// SeatRows is the resolver for the seatRows field.
+func (r *hallResolver) SeatRows(ctx context.Context, obj *repository.Hall) (*int, error) {
+	return nullInt(obj.SeatRows), nil
+}
+
+// SeatCols is the resolver for the seatCols field.
+func (r *hallResolver) SeatCols(ctx context.Context, obj *repository.Hall) (*int, error) {
+	return nullInt(obj.SeatCols), nil
+}
+
+// Cinema is the resolver for the cinema field.
+func (r *hallResolver) Cinema(ctx context.Context, obj *repository.Hall) (*repository.Cinema, error) {
+	if obj.CinemaID == nil {
+		return nil, nil
+	}
+	return r.loadersFor(ctx).cinema.Load(ctx, *obj.CinemaID)
+}
+
+// Shows is the resolver for the shows field.
+func (r *hallResolver) Shows(ctx context.Context, obj *repository.Hall, from *time.Time, to *time.Time, all *bool, limit *int) ([]*repository.Show, error) {
+	n := intArg(limit, 100)
+	if n < 1 || n > maxShowsPerHall {
+		return nil, gqlerror.Errorf("limit must be between 1 and %d", maxShowsPerHall)
+	}
+	key := showsKey{HallID: obj.ID}
+	if from != nil {
+		key.Filter.From = from.UTC()
+	}
+	if to != nil {
+		key.Filter.To = to.UTC()
+	}
+	key.Filter.All = all != nil && *all
+	shows, err := r.loadersFor(ctx).hallShows.Load(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if len(shows) > n {
+		shows = shows[:n]
+	}
+	return shows, nil
+}
+
+// Cinemas is the resolver for the cinemas field.
+func (r *queryResolver) Cinemas(ctx context.Context, city *string, limit *int, offset *int) ([]*repository.Cinema, error) {
+	n, skip := intArg(limit, 50), intArg(offset, 0)
+	if n < 1 || n > maxCinemas {
+		return nil, gqlerror.Errorf("limit must be between 1 and %d", maxCinemas)
+	}
+	if skip < 0 {
+		return nil, gqlerror.Errorf("offset must not be negative")
+	}
+	var f repository.CinemaFilter
+	if city != nil {
+		f.City = *city
+	}
+	rows, err := r.cinemas.ListAll(ctx, f)
+	if err != nil {
+		return nil, err
+	}
+	l := r.loadersFor(ctx)
+	out := []*repository.Cinema{}
+	for i, c := range rows {
+		l.cinema.Prime(c.ID, c)
+		if i >= skip && len(out) < n {
+			out = append(out, c)
+		}
+	}
+	return out, nil
+}
+
+// Cinema is the resolver for the cinema field.
+func (r *queryResolver) Cinema(ctx context.Context, id uint64) (*repository.Cinema, error) {
+	return r.loadersFor(ctx).cinema.Load(ctx, id)
+}
+
+// Hall is the resolver for the hall field.
+func (r *queryResolver) Hall(ctx context.Context, id uint64) (*repository.Hall, error) {
+	return r.loadersFor(ctx).hall.Load(ctx, id)
+}
+
+// Show is the resolver for the show field.
+func (r *queryResolver) Show(ctx context.Context, id uint64) (*repository.Show, error) {
+	s, err := r.shows.GetByID(ctx, id)
+	if errors.Is(err, repository.ErrShowNotFound) {
+		return nil, nil
+	}
+	return s, err
+}
+
+// Timezone is the resolver for the timezone field.
+func (r *showResolver) Timezone(ctx context.Context, obj *repository.Show) (string, error) {
+	loc, err := r.location(ctx, obj)
+	if err != nil {
+		return "", err
+	}
+	return loc.String(), nil
+}
+
+// LocalStartTime is the resolver for the localStartTime field.
+func (r *showResolver) LocalStartTime(ctx context.Context, obj *repository.Show) (string, error) {
+	loc, err := r.location(ctx, obj)
+	if err != nil {
+		return "", err
+	}
+	return obj.StartsAt.In(loc).Format(time.RFC3339), nil
+}
+
+// Hall is the resolver for the hall field.
+func (r *showResolver) Hall(ctx context.Context, obj *repository.Show) (*repository.Hall, error) {
+	return r.hallOf(ctx, obj)
+}
+
+// Availability is the resolver for the availability field.
+func (r *showResolver) Availability(ctx context.Context, obj *repository.Show) (*SeatCounts, error) {
+	return r.loadersFor(ctx).availability.Load(ctx, obj.ID)
+}
+
+// Cinema returns CinemaResolver implementation.
+func (r *Resolver) Cinema() CinemaResolver { return &cinemaResolver{r} }
+
+// Hall returns HallResolver implementation.
+func (r *Resolver) Hall() HallResolver { return &hallResolver{r} }
+
+// Query returns QueryResolver implementation.
+func (r *Resolver) Query() QueryResolver { return &queryResolver{r} }
+
+// Show returns ShowResolver implementation.
+func (r *Resolver) Show() ShowResolver { return &showResolver{r} }
+
+type cinemaResolver struct{ *Resolver }
+type hallResolver struct{ *Resolver }
+type queryResolver struct{ *Resolver }
+type showResolver struct{ *Resolver }
@@ -0,0 +1,973 @@
+// Booking API for partner systems such as box office kiosks.  It exposes
+// the same browse and booking operations as the REST API, over gRPC on
+// GRPC_ADDR.  Times are RFC 3339 strings in UTC and amounts are in cents,
+// as in the REST responses.
+//
+// Browse methods are public.  The other methods require the access token
+// of a customer in the "authorization" metadata ("Bearer <token>") and
+// the booking:write scope; they act on behalf of that customer.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.9
+// 	protoc        (unknown)
+// source: booking.proto
+
+package grpcapi
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Show struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Id             uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	HallId         uint64                 `protobuf:"varint,2,opt,name=hall_id,json=hallId,proto3" json:"hall_id,omitempty"`
+	Title          string                 `protobuf:"bytes,3,opt,name=title,proto3" json:"title,omitempty"`
+	StartsAt       string                 `protobuf:"bytes,4,opt,name=starts_at,json=startsAt,proto3" json:"starts_at,omitempty"`
+	EndsAt         string                 `protobuf:"bytes,5,opt,name=ends_at,json=endsAt,proto3" json:"ends_at,omitempty"`
+	BasePriceCents uint32                 `protobuf:"varint,6,opt,name=base_price_cents,json=basePriceCents,proto3" json:"base_price_cents,omitempty"`
+	Status         string                 `protobuf:"bytes,7,opt,name=status,proto3" json:"status,omitempty"` // SCHEDULED, CANCELLED or FINISHED
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *Show) Reset() {
+	*x = Show{}
+	mi := &file_booking_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Show) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Show) ProtoMessage() {}
+
+func (x *Show) ProtoReflect() protoreflect.Message {
+	mi := &file_booking_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Show.ProtoReflect.Descriptor instead.
+func (*Show) Descriptor() ([]byte, []int) {
+	return file_booking_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Show) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Show) GetHallId() uint64 {
+	if x != nil {
+		return x.HallId
+	}
+	return 0
+}
+
+func (x *Show) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *Show) GetStartsAt() string {
+	if x != nil {
+		return x.StartsAt
+	}
+	return ""
+}
+
+func (x *Show) GetEndsAt() string {
+	if x != nil {
+		return x.EndsAt
+	}
+	return ""
+}
+
+func (x *Show) GetBasePriceCents() uint32 {
+	if x != nil {
+		return x.BasePriceCents
+	}
+	return 0
+}
+
+func (x *Show) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+type GetShowRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ShowId        uint64                 `protobuf:"varint,1,opt,name=show_id,json=showId,proto3" json:"show_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetShowRequest) Reset() {
+	*x = GetShowRequest{}
+	mi := &file_booking_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetShowRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetShowRequest) ProtoMessage() {}
+
+func (x *GetShowRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_booking_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetShowRequest.ProtoReflect.Descriptor instead.
+func (*GetShowRequest) Descriptor() ([]byte, []int) {
+	return file_booking_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *GetShowRequest) GetShowId() uint64 {
+	if x != nil {
+		return x.ShowId
+	}
+	return 0
+}
+
+type ListShowsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	HallId        uint64                 `protobuf:"varint,1,opt,name=hall_id,json=hallId,proto3" json:"hall_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListShowsRequest) Reset() {
+	*x = ListShowsRequest{}
+	mi := &file_booking_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListShowsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListShowsRequest) ProtoMessage() {}
+
+func (x *ListShowsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_booking_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListShowsRequest.ProtoReflect.Descriptor instead.
+func (*ListShowsRequest) Descriptor() ([]byte, []int) {
+	return file_booking_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ListShowsRequest) GetHallId() uint64 {
+	if x != nil {
+		return x.HallId
+	}
+	return 0
+}
+
+type ListShowsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Shows         []*Show                `protobuf:"bytes,1,rep,name=shows,proto3" json:"shows,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListShowsResponse) Reset() {
+	*x = ListShowsResponse{}
+	mi := &file_booking_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListShowsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListShowsResponse) ProtoMessage() {}
+
+func (x *ListShowsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_booking_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListShowsResponse.ProtoReflect.Descriptor instead.
+func (*ListShowsResponse) Descriptor() ([]byte, []int) {
+	return file_booking_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ListShowsResponse) GetShows() []*Show {
+	if x != nil {
+		return x.Shows
+	}
+	return nil
+}
+
+type ListSeatsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ShowId        uint64                 `protobuf:"varint,1,opt,name=show_id,json=showId,proto3" json:"show_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListSeatsRequest) Reset() {
+	*x = ListSeatsRequest{}
+	mi := &file_booking_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListSeatsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListSeatsRequest) ProtoMessage() {}
+
+func (x *ListSeatsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_booking_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListSeatsRequest.ProtoReflect.Descriptor instead.
+func (*ListSeatsRequest) Descriptor() ([]byte, []int) {
+	return file_booking_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ListSeatsRequest) GetShowId() uint64 {
+	if x != nil {
+		return x.ShowId
+	}
+	return 0
+}
+
+type Seat struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SeatId        uint64                 `protobuf:"varint,1,opt,name=seat_id,json=seatId,proto3" json:"seat_id,omitempty"`
+	RowLabel      string                 `protobuf:"bytes,2,opt,name=row_label,json=rowLabel,proto3" json:"row_label,omitempty"`
+	SeatNumber    uint32                 `protobuf:"varint,3,opt,name=seat_number,json=seatNumber,proto3" json:"seat_number,omitempty"`
+	SeatType      string                 `protobuf:"bytes,4,opt,name=seat_type,json=seatType,proto3" json:"seat_type,omitempty"` // STANDARD, VIP or ACCESSIBLE
+	Status        string                 `protobuf:"bytes,5,opt,name=status,proto3" json:"status,omitempty"`                     // FREE, HELD, RESERVED or BLOCKED
+	PriceCents    uint32                 `protobuf:"varint,6,opt,name=price_cents,json=priceCents,proto3" json:"price_cents,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Seat) Reset() {
+	*x = Seat{}
+	mi := &file_booking_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Seat) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Seat) ProtoMessage() {}
+
+func (x *Seat) ProtoReflect() protoreflect.Message {
+	mi := &file_booking_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Seat.ProtoReflect.Descriptor instead.
+func (*Seat) Descriptor() ([]byte, []int) {
+	return file_booking_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Seat) GetSeatId() uint64 {
+	if x != nil {
+		return x.SeatId
+	}
+	return 0
+}
+
+func (x *Seat) GetRowLabel() string {
+	if x != nil {
+		return x.RowLabel
+	}
+	return ""
+}
+
+func (x *Seat) GetSeatNumber() uint32 {
+	if x != nil {
+		return x.SeatNumber
+	}
+	return 0
+}
+
+func (x *Seat) GetSeatType() string {
+	if x != nil {
+		return x.SeatType
+	}
+	return ""
+}
+
+func (x *Seat) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *Seat) GetPriceCents() uint32 {
+	if x != nil {
+		return x.PriceCents
+	}
+	return 0
+}
+
+type ListSeatsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Seats         []*Seat                `protobuf:"bytes,1,rep,name=seats,proto3" json:"seats,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListSeatsResponse) Reset() {
+	*x = ListSeatsResponse{}
+	mi := &file_booking_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListSeatsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListSeatsResponse) ProtoMessage() {}
+
+func (x *ListSeatsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_booking_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListSeatsResponse.ProtoReflect.Descriptor instead.
+func (*ListSeatsResponse) Descriptor() ([]byte, []int) {
+	return file_booking_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ListSeatsResponse) GetSeats() []*Seat {
+	if x != nil {
+		return x.Seats
+	}
+	return nil
+}
+
+type HoldSeatsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ShowId        uint64                 `protobuf:"varint,1,opt,name=show_id,json=showId,proto3" json:"show_id,omitempty"`
+	SeatIds       []uint64               `protobuf:"varint,2,rep,packed,name=seat_ids,json=seatIds,proto3" json:"seat_ids,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HoldSeatsRequest) Reset() {
+	*x = HoldSeatsRequest{}
+	mi := &file_booking_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HoldSeatsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HoldSeatsRequest) ProtoMessage() {}
+
+func (x *HoldSeatsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_booking_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HoldSeatsRequest.ProtoReflect.Descriptor instead.
+func (*HoldSeatsRequest) Descriptor() ([]byte, []int) {
+	return file_booking_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *HoldSeatsRequest) GetShowId() uint64 {
+	if x != nil {
+		return x.ShowId
+	}
+	return 0
+}
+
+func (x *HoldSeatsRequest) GetSeatIds() []uint64 {
+	if x != nil {
+		return x.SeatIds
+	}
+	return nil
+}
+
+type Hold struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SeatId        uint64                 `protobuf:"varint,1,opt,name=seat_id,json=seatId,proto3" json:"seat_id,omitempty"`
+	HoldToken     string                 `protobuf:"bytes,2,opt,name=hold_token,json=holdToken,proto3" json:"hold_token,omitempty"`
+	PriceCents    uint32                 `protobuf:"varint,3,opt,name=price_cents,json=priceCents,proto3" json:"price_cents,omitempty"` // price quoted at hold time
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Hold) Reset() {
+	*x = Hold{}
+	mi := &file_booking_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Hold) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Hold) ProtoMessage() {}
+
+func (x *Hold) ProtoReflect() protoreflect.Message {
+	mi := &file_booking_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Hold.ProtoReflect.Descriptor instead.
+func (*Hold) Descriptor() ([]byte, []int) {
+	return file_booking_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *Hold) GetSeatId() uint64 {
+	if x != nil {
+		return x.SeatId
+	}
+	return 0
+}
+
+func (x *Hold) GetHoldToken() string {
+	if x != nil {
+		return x.HoldToken
+	}
+	return ""
+}
+
+func (x *Hold) GetPriceCents() uint32 {
+	if x != nil {
+		return x.PriceCents
+	}
+	return 0
+}
+
+type HoldSeatsResponse struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	ExpiresAt        string                 `protobuf:"bytes,1,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	Holds            []*Hold                `protobuf:"bytes,2,rep,name=holds,proto3" json:"holds,omitempty"`
+	QuotedTotalCents uint32                 `protobuf:"varint,3,opt,name=quoted_total_cents,json=quotedTotalCents,proto3" json:"quoted_total_cents,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *HoldSeatsResponse) Reset() {
+	*x = HoldSeatsResponse{}
+	mi := &file_booking_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HoldSeatsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HoldSeatsResponse) ProtoMessage() {}
+
+func (x *HoldSeatsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_booking_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HoldSeatsResponse.ProtoReflect.Descriptor instead.
+func (*HoldSeatsResponse) Descriptor() ([]byte, []int) {
+	return file_booking_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *HoldSeatsResponse) GetExpiresAt() string {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return ""
+}
+
+func (x *HoldSeatsResponse) GetHolds() []*Hold {
+	if x != nil {
+		return x.Holds
+	}
+	return nil
+}
+
+func (x *HoldSeatsResponse) GetQuotedTotalCents() uint32 {
+	if x != nil {
+		return x.QuotedTotalCents
+	}
+	return 0
+}
+
+type ConfirmSeatsRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	ShowId uint64                 `protobuf:"varint,1,opt,name=show_id,json=showId,proto3" json:"show_id,omitempty"`
+	// When set, only these holds are confirmed; otherwise every active hold
+	// of the caller on the show is.
+	HoldTokens []string `protobuf:"bytes,2,rep,name=hold_tokens,json=holdTokens,proto3" json:"hold_tokens,omitempty"`
+	PromoCode  string   `protobuf:"bytes,3,opt,name=promo_code,json=promoCode,proto3" json:"promo_code,omitempty"`
+	// Pay the current prices when they differ from the quoted ones;
+	// otherwise such a confirmation fails with FAILED_PRECONDITION.
+	AcceptPriceChange bool `protobuf:"varint,4,opt,name=accept_price_change,json=acceptPriceChange,proto3" json:"accept_price_change,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *ConfirmSeatsRequest) Reset() {
+	*x = ConfirmSeatsRequest{}
+	mi := &file_booking_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConfirmSeatsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConfirmSeatsRequest) ProtoMessage() {}
+
+func (x *ConfirmSeatsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_booking_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConfirmSeatsRequest.ProtoReflect.Descriptor instead.
+func (*ConfirmSeatsRequest) Descriptor() ([]byte, []int) {
+	return file_booking_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *ConfirmSeatsRequest) GetShowId() uint64 {
+	if x != nil {
+		return x.ShowId
+	}
+	return 0
+}
+
+func (x *ConfirmSeatsRequest) GetHoldTokens() []string {
+	if x != nil {
+		return x.HoldTokens
+	}
+	return nil
+}
+
+func (x *ConfirmSeatsRequest) GetPromoCode() string {
+	if x != nil {
+		return x.PromoCode
+	}
+	return ""
+}
+
+func (x *ConfirmSeatsRequest) GetAcceptPriceChange() bool {
+	if x != nil {
+		return x.AcceptPriceChange
+	}
+	return false
+}
+
+type Reservation struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Id               uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	ShowId           uint64                 `protobuf:"varint,2,opt,name=show_id,json=showId,proto3" json:"show_id,omitempty"`
+	Status           string                 `protobuf:"bytes,3,opt,name=status,proto3" json:"status,omitempty"`
+	TotalAmountCents uint32                 `protobuf:"varint,4,opt,name=total_amount_cents,json=totalAmountCents,proto3" json:"total_amount_cents,omitempty"`
+	DiscountCents    uint32                 `protobuf:"varint,5,opt,name=discount_cents,json=discountCents,proto3" json:"discount_cents,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *Reservation) Reset() {
+	*x = Reservation{}
+	mi := &file_booking_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Reservation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Reservation) ProtoMessage() {}
+
+func (x *Reservation) ProtoReflect() protoreflect.Message {
+	mi := &file_booking_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Reservation.ProtoReflect.Descriptor instead.
+func (*Reservation) Descriptor() ([]byte, []int) {
+	return file_booking_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *Reservation) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Reservation) GetShowId() uint64 {
+	if x != nil {
+		return x.ShowId
+	}
+	return 0
+}
+
+func (x *Reservation) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *Reservation) GetTotalAmountCents() uint32 {
+	if x != nil {
+		return x.TotalAmountCents
+	}
+	return 0
+}
+
+func (x *Reservation) GetDiscountCents() uint32 {
+	if x != nil {
+		return x.DiscountCents
+	}
+	return 0
+}
+
+type CancelReservationRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ReservationId uint64                 `protobuf:"varint,1,opt,name=reservation_id,json=reservationId,proto3" json:"reservation_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CancelReservationRequest) Reset() {
+	*x = CancelReservationRequest{}
+	mi := &file_booking_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CancelReservationRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CancelReservationRequest) ProtoMessage() {}
+
+func (x *CancelReservationRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_booking_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CancelReservationRequest.ProtoReflect.Descriptor instead.
+func (*CancelReservationRequest) Descriptor() ([]byte, []int) {
+	return file_booking_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *CancelReservationRequest) GetReservationId() uint64 {
+	if x != nil {
+		return x.ReservationId
+	}
+	return 0
+}
+
+type CancelReservationResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CancelReservationResponse) Reset() {
+	*x = CancelReservationResponse{}
+	mi := &file_booking_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CancelReservationResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CancelReservationResponse) ProtoMessage() {}
+
+func (x *CancelReservationResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_booking_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CancelReservationResponse.ProtoReflect.Descriptor instead.
+func (*CancelReservationResponse) Descriptor() ([]byte, []int) {
+	return file_booking_proto_rawDescGZIP(), []int{13}
+}
+
+var File_booking_proto protoreflect.FileDescriptor
+
+const file_booking_proto_rawDesc = "" +
+	"\n" +
+	"\rbooking.proto\x12\x11cinema.booking.v1\"\xbd\x01\n" +
+	"\x04Show\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\x12\x17\n" +
+	"\ahall_id\x18\x02 \x01(\x04R\x06hallId\x12\x14\n" +
+	"\x05title\x18\x03 \x01(\tR\x05title\x12\x1b\n" +
+	"\tstarts_at\x18\x04 \x01(\tR\bstartsAt\x12\x17\n" +
+	"\aends_at\x18\x05 \x01(\tR\x06endsAt\x12(\n" +
+	"\x10base_price_cents\x18\x06 \x01(\rR\x0ebasePriceCents\x12\x16\n" +
+	"\x06status\x18\a \x01(\tR\x06status\")\n" +
+	"\x0eGetShowRequest\x12\x17\n" +
+	"\ashow_id\x18\x01 \x01(\x04R\x06showId\"+\n" +
+	"\x10ListShowsRequest\x12\x17\n" +
+	"\ahall_id\x18\x01 \x01(\x04R\x06hallId\"B\n" +
+	"\x11ListShowsResponse\x12-\n" +
+	"\x05shows\x18\x01 \x03(\v2\x17.cinema.booking.v1.ShowR\x05shows\"+\n" +
+	"\x10ListSeatsRequest\x12\x17\n" +
+	"\ashow_id\x18\x01 \x01(\x04R\x06showId\"\xb3\x01\n" +
+	"\x04Seat\x12\x17\n" +
+	"\aseat_id\x18\x01 \x01(\x04R\x06seatId\x12\x1b\n" +
+	"\trow_label\x18\x02 \x01(\tR\browLabel\x12\x1f\n" +
+	"\vseat_number\x18\x03 \x01(\rR\n" +
+	"seatNumber\x12\x1b\n" +
+	"\tseat_type\x18\x04 \x01(\tR\bseatType\x12\x16\n" +
+	"\x06status\x18\x05 \x01(\tR\x06status\x12\x1f\n" +
+	"\vprice_cents\x18\x06 \x01(\rR\n" +
+	"priceCents\"B\n" +
+	"\x11ListSeatsResponse\x12-\n" +
+	"\x05seats\x18\x01 \x03(\v2\x17.cinema.booking.v1.SeatR\x05seats\"F\n" +
+	"\x10HoldSeatsRequest\x12\x17\n" +
+	"\ashow_id\x18\x01 \x01(\x04R\x06showId\x12\x19\n" +
+	"\bseat_ids\x18\x02 \x03(\x04R\aseatIds\"_\n" +
+	"\x04Hold\x12\x17\n" +
+	"\aseat_id\x18\x01 \x01(\x04R\x06seatId\x12\x1d\n" +
+	"\n" +
+	"hold_token\x18\x02 \x01(\tR\tholdToken\x12\x1f\n" +
+	"\vprice_cents\x18\x03 \x01(\rR\n" +
+	"priceCents\"\x8f\x01\n" +
+	"\x11HoldSeatsResponse\x12\x1d\n" +
+	"\n" +
+	"expires_at\x18\x01 \x01(\tR\texpiresAt\x12-\n" +
+	"\x05holds\x18\x02 \x03(\v2\x17.cinema.booking.v1.HoldR\x05holds\x12,\n" +
+	"\x12quoted_total_cents\x18\x03 \x01(\rR\x10quotedTotalCents\"\x9e\x01\n" +
+	"\x13ConfirmSeatsRequest\x12\x17\n" +
+	"\ashow_id\x18\x01 \x01(\x04R\x06showId\x12\x1f\n" +
+	"\vhold_tokens\x18\x02 \x03(\tR\n" +
+	"holdTokens\x12\x1d\n" +
+	"\n" +
+	"promo_code\x18\x03 \x01(\tR\tpromoCode\x12.\n" +
+	"\x13accept_price_change\x18\x04 \x01(\bR\x11acceptPriceChange\"\xa3\x01\n" +
+	"\vReservation\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\x12\x17\n" +
+	"\ashow_id\x18\x02 \x01(\x04R\x06showId\x12\x16\n" +
+	"\x06status\x18\x03 \x01(\tR\x06status\x12,\n" +
+	"\x12total_amount_cents\x18\x04 \x01(\rR\x10totalAmountCents\x12%\n" +
+	"\x0ediscount_cents\x18\x05 \x01(\rR\rdiscountCents\"A\n" +
+	"\x18CancelReservationRequest\x12%\n" +
+	"\x0ereservation_id\x18\x01 \x01(\x04R\rreservationId\"\x1b\n" +
+	"\x19CancelReservationResponse2\xa0\x04\n" +
+	"\aBooking\x12E\n" +
+	"\aGetShow\x12!.cinema.booking.v1.GetShowRequest\x1a\x17.cinema.booking.v1.Show\x12V\n" +
+	"\tListShows\x12#.cinema.booking.v1.ListShowsRequest\x1a$.cinema.booking.v1.ListShowsResponse\x12V\n" +
+	"\tListSeats\x12#.cinema.booking.v1.ListSeatsRequest\x1a$.cinema.booking.v1.ListSeatsResponse\x12V\n" +
+	"\tHoldSeats\x12#.cinema.booking.v1.HoldSeatsRequest\x1a$.cinema.booking.v1.HoldSeatsResponse\x12V\n" +
+	"\fConfirmSeats\x12&.cinema.booking.v1.ConfirmSeatsRequest\x1a\x1e.cinema.booking.v1.Reservation\x12n\n" +
+	"\x11CancelReservation\x12+.cinema.booking.v1.CancelReservationRequest\x1a,.cinema.booking.v1.CancelReservationResponseB=Z;github.com/iliyamo/cinema-seat-reservation/internal/grpcapib\x06proto3"
+
+var (
+	file_booking_proto_rawDescOnce sync.Once
+	file_booking_proto_rawDescData []byte
+)
+
+func file_booking_proto_rawDescGZIP() []byte {
+	file_booking_proto_rawDescOnce.Do(func() {
+		file_booking_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_booking_proto_rawDesc), len(file_booking_proto_rawDesc)))
+	})
+	return file_booking_proto_rawDescData
+}
+
+var file_booking_proto_msgTypes = make([]protoimpl.MessageInfo, 14)
+var file_booking_proto_goTypes = []any{
+	(*Show)(nil),                      // 0: cinema.booking.v1.Show
+	(*GetShowRequest)(nil),            // 1: cinema.booking.v1.GetShowRequest
+	(*ListShowsRequest)(nil),          // 2: cinema.booking.v1.ListShowsRequest
+	(*ListShowsResponse)(nil),         // 3: cinema.booking.v1.ListShowsResponse
+	(*ListSeatsRequest)(nil),          // 4: cinema.booking.v1.ListSeatsRequest
+	(*Seat)(nil),                      // 5: cinema.booking.v1.Seat
+	(*ListSeatsResponse)(nil),         // 6: cinema.booking.v1.ListSeatsResponse
+	(*HoldSeatsRequest)(nil),          // 7: cinema.booking.v1.HoldSeatsRequest
+	(*Hold)(nil),                      // 8: cinema.booking.v1.Hold
+	(*HoldSeatsResponse)(nil),         // 9: cinema.booking.v1.HoldSeatsResponse
+	(*ConfirmSeatsRequest)(nil),       // 10: cinema.booking.v1.ConfirmSeatsRequest
+	(*Reservation)(nil),               // 11: cinema.booking.v1.Reservation
+	(*CancelReservationRequest)(nil),  // 12: cinema.booking.v1.CancelReservationRequest
+	(*CancelReservationResponse)(nil), // 13: cinema.booking.v1.CancelReservationResponse
+}
+var file_booking_proto_depIdxs = []int32{
+	0,  // 0: cinema.booking.v1.ListShowsResponse.shows:type_name -> cinema.booking.v1.Show
+	5,  // 1: cinema.booking.v1.ListSeatsResponse.seats:type_name -> cinema.booking.v1.Seat
+	8,  // 2: cinema.booking.v1.HoldSeatsResponse.holds:type_name -> cinema.booking.v1.Hold
+	1,  // 3: cinema.booking.v1.Booking.GetShow:input_type -> cinema.booking.v1.GetShowRequest
+	2,  // 4: cinema.booking.v1.Booking.ListShows:input_type -> cinema.booking.v1.ListShowsRequest
+	4,  // 5: cinema.booking.v1.Booking.ListSeats:input_type -> cinema.booking.v1.ListSeatsRequest
+	7,  // 6: cinema.booking.v1.Booking.HoldSeats:input_type -> cinema.booking.v1.HoldSeatsRequest
+	10, // 7: cinema.booking.v1.Booking.ConfirmSeats:input_type -> cinema.booking.v1.ConfirmSeatsRequest
+	12, // 8: cinema.booking.v1.Booking.CancelReservation:input_type -> cinema.booking.v1.CancelReservationRequest
+	0,  // 9: cinema.booking.v1.Booking.GetShow:output_type -> cinema.booking.v1.Show
+	3,  // 10: cinema.booking.v1.Booking.ListShows:output_type -> cinema.booking.v1.ListShowsResponse
+	6,  // 11: cinema.booking.v1.Booking.ListSeats:output_type -> cinema.booking.v1.ListSeatsResponse
+	9,  // 12: cinema.booking.v1.Booking.HoldSeats:output_type -> cinema.booking.v1.HoldSeatsResponse
+	11, // 13: cinema.booking.v1.Booking.ConfirmSeats:output_type -> cinema.booking.v1.Reservation
+	13, // 14: cinema.booking.v1.Booking.CancelReservation:output_type -> cinema.booking.v1.CancelReservationResponse
+	9,  // [9:15] is the sub-list for method output_type
+	3,  // [3:9] is the sub-list for method input_type
+	3,  // [3:3] is the sub-list for extension type_name
+	3,  // [3:3] is the sub-list for extension extendee
+	0,  // [0:3] is the sub-list for field type_name
+}
+
+func init() { file_booking_proto_init() }
+func file_booking_proto_init() {
+	if File_booking_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_booking_proto_rawDesc), len(file_booking_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   14,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_booking_proto_goTypes,
+		DependencyIndexes: file_booking_proto_depIdxs,
+		MessageInfos:      file_booking_proto_msgTypes,
+	}.Build()
+	File_booking_proto = out.File
+	file_booking_proto_goTypes = nil
+	file_booking_proto_depIdxs = nil
+}
@@ -0,0 +1,336 @@
+// Booking API for partner systems such as box office kiosks.  It exposes
+// the same browse and booking operations as the REST API, over gRPC on
+// GRPC_ADDR.  Times are RFC 3339 strings in UTC and amounts are in cents,
+// as in the REST responses.
+//
+// Browse methods are public.  The other methods require the access token
+// of a customer in the "authorization" metadata ("Bearer <token>") and
+// the booking:write scope; they act on behalf of that customer.
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: booking.proto
+
+package grpcapi
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Booking_GetShow_FullMethodName           = "/cinema.booking.v1.Booking/GetShow"
+	Booking_ListShows_FullMethodName         = "/cinema.booking.v1.Booking/ListShows"
+	Booking_ListSeats_FullMethodName         = "/cinema.booking.v1.Booking/ListSeats"
+	Booking_HoldSeats_FullMethodName         = "/cinema.booking.v1.Booking/HoldSeats"
+	Booking_ConfirmSeats_FullMethodName      = "/cinema.booking.v1.Booking/ConfirmSeats"
+	Booking_CancelReservation_FullMethodName = "/cinema.booking.v1.Booking/CancelReservation"
+)
+
+// BookingClient is the client API for Booking service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type BookingClient interface {
+	// GetShow returns one show.
+	GetShow(ctx context.Context, in *GetShowRequest, opts ...grpc.CallOption) (*Show, error)
+	// ListShows returns the shows of a hall.
+	ListShows(ctx context.Context, in *ListShowsRequest, opts ...grpc.CallOption) (*ListShowsResponse, error)
+	// ListSeats returns the seat map of a show with each seat's status.
+	ListSeats(ctx context.Context, in *ListSeatsRequest, opts ...grpc.CallOption) (*ListSeatsResponse, error)
+	// HoldSeats holds FREE seats of a show for the show's hold TTL.
+	HoldSeats(ctx context.Context, in *HoldSeatsRequest, opts ...grpc.CallOption) (*HoldSeatsResponse, error)
+	// ConfirmSeats turns the caller's holds on a show into a CONFIRMED
+	// reservation.
+	ConfirmSeats(ctx context.Context, in *ConfirmSeatsRequest, opts ...grpc.CallOption) (*Reservation, error)
+	// CancelReservation cancels one of the caller's reservations before the
+	// show starts.
+	CancelReservation(ctx context.Context, in *CancelReservationRequest, opts ...grpc.CallOption) (*CancelReservationResponse, error)
+}
+
+type bookingClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewBookingClient(cc grpc.ClientConnInterface) BookingClient {
+	return &bookingClient{cc}
+}
+
+func (c *bookingClient) GetShow(ctx context.Context, in *GetShowRequest, opts ...grpc.CallOption) (*Show, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Show)
+	err := c.cc.Invoke(ctx, Booking_GetShow_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bookingClient) ListShows(ctx context.Context, in *ListShowsRequest, opts ...grpc.CallOption) (*ListShowsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListShowsResponse)
+	err := c.cc.Invoke(ctx, Booking_ListShows_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bookingClient) ListSeats(ctx context.Context, in *ListSeatsRequest, opts ...grpc.CallOption) (*ListSeatsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListSeatsResponse)
+	err := c.cc.Invoke(ctx, Booking_ListSeats_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bookingClient) HoldSeats(ctx context.Context, in *HoldSeatsRequest, opts ...grpc.CallOption) (*HoldSeatsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(HoldSeatsResponse)
+	err := c.cc.Invoke(ctx, Booking_HoldSeats_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bookingClient) ConfirmSeats(ctx context.Context, in *ConfirmSeatsRequest, opts ...grpc.CallOption) (*Reservation, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Reservation)
+	err := c.cc.Invoke(ctx, Booking_ConfirmSeats_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bookingClient) CancelReservation(ctx context.Context, in *CancelReservationRequest, opts ...grpc.CallOption) (*CancelReservationResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CancelReservationResponse)
+	err := c.cc.Invoke(ctx, Booking_CancelReservation_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// BookingServer is the server API for Booking service.
+// All implementations must embed UnimplementedBookingServer
+// for forward compatibility.
+type BookingServer interface {
+	// GetShow returns one show.
+	GetShow(context.Context, *GetShowRequest) (*Show, error)
+	// ListShows returns the shows of a hall.
+	ListShows(context.Context, *ListShowsRequest) (*ListShowsResponse, error)
+	// ListSeats returns the seat map of a show with each seat's status.
+	ListSeats(context.Context, *ListSeatsRequest) (*ListSeatsResponse, error)
+	// HoldSeats holds FREE seats of a show for the show's hold TTL.
+	HoldSeats(context.Context, *HoldSeatsRequest) (*HoldSeatsResponse, error)
+	// ConfirmSeats turns the caller's holds on a show into a CONFIRMED
+	// reservation.
+	ConfirmSeats(context.Context, *ConfirmSeatsRequest) (*Reservation, error)
+	// CancelReservation cancels one of the caller's reservations before the
+	// show starts.
+	CancelReservation(context.Context, *CancelReservationRequest) (*CancelReservationResponse, error)
+	mustEmbedUnimplementedBookingServer()
+}
+
+// UnimplementedBookingServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedBookingServer struct{}
+
+func (UnimplementedBookingServer) GetShow(context.Context, *GetShowRequest) (*Show, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetShow not implemented")
+}
+func (UnimplementedBookingServer) ListShows(context.Context, *ListShowsRequest) (*ListShowsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListShows not implemented")
+}
+func (UnimplementedBookingServer) ListSeats(context.Context, *ListSeatsRequest) (*ListSeatsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListSeats not implemented")
+}
+func (UnimplementedBookingServer) HoldSeats(context.Context, *HoldSeatsRequest) (*HoldSeatsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method HoldSeats not implemented")
+}
+func (UnimplementedBookingServer) ConfirmSeats(context.Context, *ConfirmSeatsRequest) (*Reservation, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ConfirmSeats not implemented")
+}
+func (UnimplementedBookingServer) CancelReservation(context.Context, *CancelReservationRequest) (*CancelReservationResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CancelReservation not implemented")
+}
+func (UnimplementedBookingServer) mustEmbedUnimplementedBookingServer() {}
+func (UnimplementedBookingServer) testEmbeddedByValue()                 {}
+
+// UnsafeBookingServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to BookingServer will
+// result in compilation errors.
+type UnsafeBookingServer interface {
+	mustEmbedUnimplementedBookingServer()
+}
+
+func RegisterBookingServer(s grpc.ServiceRegistrar, srv BookingServer) {
+	// If the following call pancis, it indicates UnimplementedBookingServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Booking_ServiceDesc, srv)
+}
+
+func _Booking_GetShow_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetShowRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BookingServer).GetShow(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Booking_GetShow_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BookingServer).GetShow(ctx, req.(*GetShowRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Booking_ListShows_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListShowsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BookingServer).ListShows(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Booking_ListShows_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BookingServer).ListShows(ctx, req.(*ListShowsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Booking_ListSeats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListSeatsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BookingServer).ListSeats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Booking_ListSeats_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BookingServer).ListSeats(ctx, req.(*ListSeatsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Booking_HoldSeats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HoldSeatsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BookingServer).HoldSeats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Booking_HoldSeats_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BookingServer).HoldSeats(ctx, req.(*HoldSeatsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Booking_ConfirmSeats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ConfirmSeatsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BookingServer).ConfirmSeats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Booking_ConfirmSeats_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BookingServer).ConfirmSeats(ctx, req.(*ConfirmSeatsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Booking_CancelReservation_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CancelReservationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BookingServer).CancelReservation(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Booking_CancelReservation_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BookingServer).CancelReservation(ctx, req.(*CancelReservationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Booking_ServiceDesc is the grpc.ServiceDesc for Booking service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Booking_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "cinema.booking.v1.Booking",
+	HandlerType: (*BookingServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetShow",
+			Handler:    _Booking_GetShow_Handler,
+		},
+		{
+			MethodName: "ListShows",
+			Handler:    _Booking_ListShows_Handler,
+		},
+		{
+			MethodName: "ListSeats",
+			Handler:    _Booking_ListSeats_Handler,
+		},
+		{
+			MethodName: "HoldSeats",
+			Handler:    _Booking_HoldSeats_Handler,
+		},
+		{
+			MethodName: "ConfirmSeats",
+			Handler:    _Booking_ConfirmSeats_Handler,
+		},
+		{
+			MethodName: "CancelReservation",
+			Handler:    _Booking_CancelReservation_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "booking.proto",
+}
@@ -0,0 +1,94 @@
+package grpcapi
+
+import (
+	"context"
+	"log"
+	"net"
+	"strconv"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/iliyamo/cinema-seat-reservation/internal/metrics"
+	"github.com/iliyamo/cinema-seat-reservation/internal/service"
+)
+
+// limit applies the budgets the REST API applies to the same operations,
+// so a client cannot get around them by switching transports: the
+// per-address bucket of middleware.IPRateLimit, the per-class bucket of
+// middleware.RateLimit, keyed on the account when the call is
+// authenticated and on the address otherwise, and the account quota of
+// middleware.Quota.  ADMIN accounts are not limited.  An exhausted budget
+// yields ResourceExhausted with a retry-after header in seconds.
+func (s *Server) limit(ctx context.Context, method string, who caller) error {
+	if who.Role == "ADMIN" {
+		return nil
+	}
+	addr := peerAddr(ctx)
+	if s.Limiter != nil {
+		if st := s.Limiter.Allow(service.RateClassIP, addr); !st.Allowed {
+			metrics.RateLimitRejections.Inc(service.RateClassIP)
+			return exhausted(ctx, st.RetryAfter, "too many requests from this address")
+		}
+		key := "ip:" + addr
+		if who.UserID != 0 {
+			key = "user:" + strconv.FormatUint(who.UserID, 10)
+		}
+		class := rateClass(method, who.Role)
+		if st := s.Limiter.Allow(class, key); !st.Allowed {
+			metrics.RateLimitRejections.Inc(class)
+			return exhausted(ctx, st.RetryAfter, "too many requests")
+		}
+	}
+	if s.Quotas != nil && who.UserID != 0 {
+		st, err := s.Quotas.Allow(ctx, who.UserID)
+		if err != nil {
+			// like middleware.Quota, fail open
+			log.Printf("quota check failed for user %d: %v", who.UserID, err)
+			return nil
+		}
+		if !st.Allowed {
+			metrics.QuotaRejections.Inc()
+			return exhausted(ctx, time.Until(st.ResetAt), "request quota exceeded")
+		}
+	}
+	return nil
+}
+
+// rateClass returns the rate limit class of a call the way
+// middleware.RateClass does for REST routes: public methods are reads,
+// the others writes by owners and staff or by everyone else.
+func rateClass(method, role string) string {
+	if _, write := scopes[method]; !write {
+		return service.RateClassPublic
+	}
+	if role == "OWNER" || role == "STAFF" {
+		return service.RateClassOwner
+	}
+	return service.RateClassCustomer
+}
+
+// peerAddr returns the client's address without the port.  Calls through
+// a proxy share the proxy's address.
+func peerAddr(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	addr := p.Addr.String()
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
+
+// exhausted returns a ResourceExhausted status and tells the client when
+// to retry.
+func exhausted(ctx context.Context, retryAfter time.Duration, msg string) error {
+	_ = grpc.SetHeader(ctx, metadata.Pairs("retry-after", strconv.FormatInt(int64(retryAfter/time.Second)+1, 10)))
+	return status.Error(codes.ResourceExhausted, msg)
+}
@@ -0,0 +1,89 @@
+package grpcapi_test
+
+import (
+	"context"
+	"database/sql/driver"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/iliyamo/cinema-seat-reservation/internal/grpcapi"
+	"github.com/iliyamo/cinema-seat-reservation/internal/repository"
+	"github.com/iliyamo/cinema-seat-reservation/internal/repository/mock"
+	"github.com/iliyamo/cinema-seat-reservation/internal/service"
+)
+
+// expectExhausted checks that err is ResourceExhausted and that hdr
+// tells the client when to retry.
+func expectExhausted(t *testing.T, err error, hdr metadata.MD) {
+	t.Helper()
+	expectCode(t, err, codes.ResourceExhausted)
+	if len(hdr.Get("retry-after")) == 0 {
+		t.Fatal("no retry-after header")
+	}
+}
+
+func TestRateLimits(t *testing.T) {
+	once := service.RateLimitPolicy{Burst: 1, PerMinute: 1}
+	cases := []struct {
+		name  string
+		class string
+		call  func(grpcapi.BookingClient, *fixture, *testing.T, ...grpc.CallOption) error
+	}{
+		{"hold", service.RateClassCustomer, func(c grpcapi.BookingClient, f *fixture, t *testing.T, opts ...grpc.CallOption) error {
+			_, err := c.HoldSeats(f.asCustomer(t), &grpcapi.HoldSeatsRequest{}, opts...)
+			return err
+		}},
+		{"confirm", service.RateClassCustomer, func(c grpcapi.BookingClient, f *fixture, t *testing.T, opts ...grpc.CallOption) error {
+			_, err := c.ConfirmSeats(f.asCustomer(t), &grpcapi.ConfirmSeatsRequest{}, opts...)
+			return err
+		}},
+		{"read", service.RateClassPublic, func(c grpcapi.BookingClient, _ *fixture, _ *testing.T, opts ...grpc.CallOption) error {
+			_, err := c.GetShow(context.Background(), &grpcapi.GetShowRequest{}, opts...)
+			return err
+		}},
+		{"address", service.RateClassIP, func(c grpcapi.BookingClient, f *fixture, t *testing.T, opts ...grpc.CallOption) error {
+			_, err := c.HoldSeats(f.asCustomer(t), &grpcapi.HoldSeatsRequest{}, opts...)
+			return err
+		}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			f := newFixture(t)
+			f.limiter = service.NewRateLimiter(map[string]service.RateLimitPolicy{tc.class: once})
+			c := f.client(t)
+			// the first call reaches request validation
+			expectCode(t, tc.call(c, f, t), codes.InvalidArgument)
+			var hdr metadata.MD
+			expectExhausted(t, tc.call(c, f, t, grpc.Header(&hdr)), hdr)
+		})
+	}
+}
+
+func TestQuota(t *testing.T) {
+	f := newFixture(t)
+	conn := (&mock.Driver{QueryFn: func(query string, _ []driver.NamedValue) ([]string, [][]driver.Value, error) {
+		if strings.Contains(query, "FROM account_quotas") {
+			// one request a day
+			return []string{"user_id", "daily_limit", "monthly_limit", "updated_at"},
+				[][]driver.Value{{int64(customerID), int64(1), nil, time.Now()}}, nil
+		}
+		return []string{"request_count"}, nil, nil
+	}}).DB()
+	t.Cleanup(func() { conn.Close() })
+	f.quotas = service.NewQuotaTracker(repository.NewQuotaRepo(conn), time.Minute)
+	c := f.client(t)
+
+	_, err := c.HoldSeats(f.asCustomer(t), &grpcapi.HoldSeatsRequest{})
+	expectCode(t, err, codes.InvalidArgument)
+	var hdr metadata.MD
+	_, err = c.ConfirmSeats(f.asCustomer(t), &grpcapi.ConfirmSeatsRequest{}, grpc.Header(&hdr))
+	expectExhausted(t, err, hdr)
+	// anonymous reads have no account to charge
+	_, err = c.GetShow(context.Background(), &grpcapi.GetShowRequest{})
+	expectCode(t, err, codes.InvalidArgument)
+}
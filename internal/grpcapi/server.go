@@ -0,0 +1,352 @@
+// Package grpcapi serves the booking operations of the REST API over gRPC
+// for partner systems such as box office kiosks.  The service is defined
+// in booking.proto; booking.pb.go and booking_grpc.pb.go are generated
+// from it by protoc-gen-go and protoc-gen-go-grpc.  The package shares the
+// service layer, the repositories, the JWT access tokens, the rate limits
+// and the account quotas of the REST API.
+//
+// The server speaks HTTP/2 without TLS, as kiosks reach it on an internal
+// network or through a TLS-terminating proxy.
+package grpcapi
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative booking.proto
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/iliyamo/cinema-seat-reservation/internal/cache"
+	"github.com/iliyamo/cinema-seat-reservation/internal/metrics"
+	"github.com/iliyamo/cinema-seat-reservation/internal/permissions"
+	"github.com/iliyamo/cinema-seat-reservation/internal/repository"
+	"github.com/iliyamo/cinema-seat-reservation/internal/service"
+	"github.com/iliyamo/cinema-seat-reservation/internal/utils"
+)
+
+// maxMessageSize bounds request messages.
+const maxMessageSize = 1 << 20
+
+// scopes lists the scopes each authenticated method requires, by full
+// method name.  The other methods are public.
+var scopes = map[string][]permissions.Scope{
+	Booking_HoldSeats_FullMethodName:         {permissions.BookingWrite},
+	Booking_ConfirmSeats_FullMethodName:      {permissions.BookingWrite},
+	Booking_CancelReservation_FullMethodName: {permissions.BookingWrite},
+}
+
+// caller is the authenticated customer of a call.
+type caller struct {
+	UserID uint64
+	Role   string
+}
+
+type callerKey struct{}
+
+// callerFrom returns the caller the interceptor stored in ctx; public
+// methods get a zero caller.
+func callerFrom(ctx context.Context) caller {
+	who, _ := ctx.Value(callerKey{}).(caller)
+	return who
+}
+
+// Server serves the Booking service.
+type Server struct {
+	UnimplementedBookingServer
+
+	Booking  *service.BookingService
+	ShowRepo *repository.ShowRepo
+	HallRepo repository.HallStore
+	Keys     *utils.KeySet
+	// Limiter and Quotas, when set, apply the REST API's rate limits and
+	// account quotas to calls (see limit).
+	Limiter *service.RateLimiter
+	Quotas  *service.QuotaTracker
+
+	srv *grpc.Server
+}
+
+// NewServer constructs a Server.  All dependencies must be non-nil.
+func NewServer(booking *service.BookingService, showRepo *repository.ShowRepo, hallRepo repository.HallStore, keys *utils.KeySet) *Server {
+	if booking == nil || showRepo == nil || hallRepo == nil || keys == nil {
+		panic("nil dependency passed to grpcapi.NewServer")
+	}
+	s := &Server{Booking: booking, ShowRepo: showRepo, HallRepo: hallRepo, Keys: keys}
+	s.srv = grpc.NewServer(
+		grpc.MaxRecvMsgSize(maxMessageSize),
+		grpc.ConnectionTimeout(10*time.Second),
+		grpc.UnaryInterceptor(s.intercept),
+	)
+	RegisterBookingServer(s.srv, s)
+	return s
+}
+
+// ListenAndServe serves gRPC on addr until Shutdown is called, after
+// which it returns nil.
+func (s *Server) ListenAndServe(addr string) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(ln)
+}
+
+// Serve serves gRPC on ln until Shutdown is called.
+func (s *Server) Serve(ln net.Listener) error {
+	return s.srv.Serve(ln)
+}
+
+// Shutdown stops accepting calls and waits for running calls to finish
+// until ctx is done, when it closes the remaining connections.
+func (s *Server) Shutdown(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		s.srv.GracefulStop()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		s.srv.Stop()
+		return ctx.Err()
+	}
+}
+
+// intercept runs around every call: it authenticates the caller when the
+// method requires it, applies the rate limits and quotas, applies the
+// call's cache invalidations once its transactions committed, converts errors into statuses and counts the
+// call.
+func (s *Server) intercept(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	name := info.FullMethod[strings.LastIndex(info.FullMethod, "/")+1:]
+	resp, err := s.call(ctx, req, info.FullMethod, handler)
+	if err != nil {
+		err = toStatus(name, err).Err()
+	}
+	metrics.GRPCRequests.Inc(name, strconv.Itoa(int(status.Code(err))))
+	return resp, err
+}
+
+func (s *Server) call(ctx context.Context, req interface{}, method string, handler grpc.UnaryHandler) (interface{}, error) {
+	var who caller
+	var authErr error
+	if required, ok := scopes[method]; ok {
+		var auth string
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if v := md.Get("authorization"); len(v) > 0 {
+				auth = v[0]
+			}
+		}
+		who, authErr = s.authenticate(auth, required)
+	}
+	// failed authentications count against the address, as in REST
+	if err := s.limit(ctx, method, who); err != nil {
+		return nil, err
+	}
+	if authErr != nil {
+		return nil, authErr
+	}
+	if who.UserID != 0 {
+		ctx = context.WithValue(ctx, callerKey{}, who)
+	}
+	ctx, flush := cache.Defer(ctx)
+	defer flush()
+	return handler(ctx, req)
+}
+
+// authenticate checks a "Bearer <token>" authorization value the way
+// middleware.JWTAuth and RequireScope do for REST routes and returns the
+// token's subject.
+func (s *Server) authenticate(auth string, required []permissions.Scope) (caller, error) {
+	if !strings.HasPrefix(auth, "Bearer ") {
+		return caller{}, status.Error(codes.Unauthenticated, "missing bearer token")
+	}
+	tok, err := s.Keys.Parse(strings.TrimPrefix(auth, "Bearer "))
+	if err != nil || !tok.Valid {
+		return caller{}, status.Error(codes.Unauthenticated, "invalid token")
+	}
+	claims, ok := tok.Claims.(jwt.MapClaims)
+	if !ok {
+		return caller{}, status.Error(codes.Unauthenticated, "invalid claims")
+	}
+	var userID uint64
+	switch sub := claims["sub"].(type) {
+	case float64:
+		userID = uint64(sub)
+	case string:
+		userID, _ = strconv.ParseUint(sub, 10, 64)
+	}
+	if userID == 0 {
+		return caller{}, status.Error(codes.Unauthenticated, "invalid claims")
+	}
+	// Tokens issued before scopes were embedded fall back to the role's
+	// scopes.
+	role, _ := claims["role"].(string)
+	granted := permissions.NewSet(permissions.ForRole(role)...)
+	if sc, ok := claims["scope"].(string); ok {
+		granted = permissions.Parse(sc)
+	}
+	if !granted.HasAll(required...) {
+		return caller{}, status.Error(codes.PermissionDenied, "insufficient scope")
+	}
+	return caller{UserID: userID, Role: role}, nil
+}
+
+// formatTime formats t like the REST API does; the zero time is omitted.
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func newShow(s *repository.Show) *Show {
+	return &Show{
+		Id:             s.ID,
+		HallId:         s.HallID,
+		Title:          s.Title,
+		StartsAt:       formatTime(s.StartsAt),
+		EndsAt:         formatTime(s.EndsAt),
+		BasePriceCents: s.BasePriceCents,
+		Status:         s.Status,
+	}
+}
+
+// GetShow implements BookingServer.
+func (s *Server) GetShow(ctx context.Context, req *GetShowRequest) (*Show, error) {
+	if req.ShowId == 0 {
+		return nil, status.Error(codes.InvalidArgument, "invalid show id")
+	}
+	show, err := s.ShowRepo.GetByID(ctx, req.ShowId)
+	if err != nil {
+		return nil, err
+	}
+	return newShow(show), nil
+}
+
+// ListShows implements BookingServer.
+func (s *Server) ListShows(ctx context.Context, req *ListShowsRequest) (*ListShowsResponse, error) {
+	if req.HallId == 0 {
+		return nil, status.Error(codes.InvalidArgument, "invalid hall id")
+	}
+	if _, err := s.HallRepo.GetByID(ctx, req.HallId); err != nil {
+		return nil, err
+	}
+	shows, err := s.ShowRepo.ListByHall(ctx, req.HallId, false)
+	if err != nil {
+		return nil, err
+	}
+	resp := &ListShowsResponse{Shows: make([]*Show, 0, len(shows))}
+	for i := range shows {
+		resp.Shows = append(resp.Shows, newShow(&shows[i]))
+	}
+	return resp, nil
+}
+
+// ListSeats implements BookingServer.
+func (s *Server) ListSeats(ctx context.Context, req *ListSeatsRequest) (*ListSeatsResponse, error) {
+	if req.ShowId == 0 {
+		return nil, status.Error(codes.InvalidArgument, "invalid show id")
+	}
+	if _, err := s.ShowRepo.GetByID(ctx, req.ShowId); err != nil {
+		return nil, err
+	}
+	seats, err := s.Booking.SeatMap(ctx, req.ShowId)
+	if err != nil {
+		return nil, err
+	}
+	resp := &ListSeatsResponse{Seats: make([]*Seat, 0, len(seats))}
+	for _, seat := range seats {
+		resp.Seats = append(resp.Seats, &Seat{
+			SeatId:     seat.SeatID,
+			RowLabel:   seat.RowLabel,
+			SeatNumber: seat.SeatNumber,
+			SeatType:   seat.SeatType,
+			Status:     seat.Status,
+			PriceCents: seat.PriceCents,
+		})
+	}
+	return resp, nil
+}
+
+// HoldSeats implements BookingServer.
+func (s *Server) HoldSeats(ctx context.Context, req *HoldSeatsRequest) (*HoldSeatsResponse, error) {
+	if req.ShowId == 0 {
+		return nil, status.Error(codes.InvalidArgument, "invalid show id")
+	}
+	unique := make([]uint64, 0, len(req.SeatIds))
+	seen := make(map[uint64]bool, len(req.SeatIds))
+	for _, id := range req.SeatIds {
+		if id != 0 && !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	if len(unique) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "no valid seat IDs provided")
+	}
+	show, err := s.ShowRepo.GetByID(ctx, req.ShowId)
+	if err != nil {
+		return nil, err
+	}
+	res, err := s.Booking.Hold(ctx, callerFrom(ctx).UserID, show, unique, false)
+	if err != nil {
+		return nil, err
+	}
+	resp := &HoldSeatsResponse{ExpiresAt: formatTime(res.ExpiresAt), QuotedTotalCents: res.QuotedTotalCents}
+	for _, h := range res.Holds {
+		resp.Holds = append(resp.Holds, &Hold{SeatId: h.SeatID, HoldToken: h.HoldToken, PriceCents: res.Prices[h.SeatID]})
+	}
+	return resp, nil
+}
+
+// ConfirmSeats implements BookingServer.
+func (s *Server) ConfirmSeats(ctx context.Context, req *ConfirmSeatsRequest) (*Reservation, error) {
+	if req.ShowId == 0 {
+		return nil, status.Error(codes.InvalidArgument, "invalid show id")
+	}
+	if len(req.PromoCode) > 64 {
+		return nil, status.Error(codes.InvalidArgument, "promo code is too long")
+	}
+	if _, err := s.ShowRepo.GetByID(ctx, req.ShowId); err != nil {
+		return nil, err
+	}
+	rec, err := s.Booking.Reserve(ctx, service.ReserveRequest{
+		UserID:            callerFrom(ctx).UserID,
+		ShowID:            req.ShowId,
+		HoldTokens:        req.HoldTokens,
+		PromoCode:         strings.ToUpper(strings.TrimSpace(req.PromoCode)),
+		AcceptPriceChange: req.AcceptPriceChange,
+		Status:            "CONFIRMED",
+	})
+	if err != nil {
+		return nil, err
+	}
+	metrics.ReservationsConfirmed.Inc("grpc")
+	return &Reservation{
+		Id:               rec.ID,
+		ShowId:           rec.ShowID,
+		Status:           rec.Status,
+		TotalAmountCents: rec.TotalAmountCents,
+		DiscountCents:    rec.DiscountCents,
+	}, nil
+}
+
+// CancelReservation implements BookingServer.
+func (s *Server) CancelReservation(ctx context.Context, req *CancelReservationRequest) (*CancelReservationResponse, error) {
+	if req.ReservationId == 0 {
+		return nil, status.Error(codes.InvalidArgument, "invalid reservation id")
+	}
+	if err := s.Booking.CancelReservation(ctx, callerFrom(ctx).UserID, req.ReservationId); err != nil {
+		return nil, err
+	}
+	return &CancelReservationResponse{}, nil
+}
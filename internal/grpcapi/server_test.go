@@ -0,0 +1,201 @@
+package grpcapi_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/iliyamo/cinema-seat-reservation/internal/grpcapi"
+	"github.com/iliyamo/cinema-seat-reservation/internal/repository"
+	"github.com/iliyamo/cinema-seat-reservation/internal/repository/mock"
+	"github.com/iliyamo/cinema-seat-reservation/internal/service"
+	"github.com/iliyamo/cinema-seat-reservation/internal/utils"
+)
+
+// customerID is the customer the tests' access tokens are issued to.
+const customerID = 7
+
+// fixture is a Server on an in-memory listener, backed by store doubles.
+type fixture struct {
+	keys         *utils.KeySet
+	shows        *mock.ShowStore
+	showSeats    *mock.ShowSeatStore
+	reservations *mock.ReservationStore
+	halls        *mock.HallStore
+	limiter      *service.RateLimiter  // optional
+	quotas       *service.QuotaTracker // optional
+}
+
+func newFixture(t *testing.T) *fixture {
+	t.Helper()
+	keys, err := utils.NewKeySet("test-secret", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn := (&mock.Driver{}).DB()
+	t.Cleanup(func() { conn.Close() })
+	return &fixture{
+		keys:         keys,
+		shows:        &mock.ShowStore{Conn: conn},
+		showSeats:    &mock.ShowSeatStore{},
+		reservations: &mock.ReservationStore{},
+		halls:        &mock.HallStore{},
+	}
+}
+
+// client starts the server and returns a client connected to it.
+func (f *fixture) client(t *testing.T) grpcapi.BookingClient {
+	t.Helper()
+	booking := service.NewBookingService(f.shows, f.showSeats, &mock.SeatHoldStore{}, f.reservations)
+	srv := grpcapi.NewServer(booking, repository.NewShowRepo(f.shows.Conn), f.halls, f.keys)
+	srv.Limiter, srv.Quotas = f.limiter, f.quotas
+	ln := bufconn.Listen(1 << 20)
+	go srv.Serve(ln)
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(ctx)
+	})
+	cc, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return ln.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { cc.Close() })
+	return grpcapi.NewBookingClient(cc)
+}
+
+// withToken returns a context sending a token signed by f's keys with
+// the given claims.
+func (f *fixture) withToken(t *testing.T, claims jwt.MapClaims) context.Context {
+	t.Helper()
+	tok, err := f.keys.Sign(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+tok)
+}
+
+// asCustomer returns a context authenticated as customerID.
+func (f *fixture) asCustomer(t *testing.T) context.Context {
+	t.Helper()
+	tok, err := utils.NewAccessToken(f.keys, customerID, "CUSTOMER", 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+tok.Token)
+}
+
+func expectCode(t *testing.T, err error, want codes.Code) {
+	t.Helper()
+	if got := status.Code(err); got != want {
+		t.Fatalf("code = %v (%v), want %v", got, err, want)
+	}
+}
+
+func TestAuthentication(t *testing.T) {
+	f := newFixture(t)
+	c := f.client(t)
+	exp := time.Now().Add(time.Minute).Unix()
+	cases := []struct {
+		name string
+		ctx  context.Context
+		want codes.Code
+	}{
+		{"no token", context.Background(), codes.Unauthenticated},
+		{"bad token", metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer nope"), codes.Unauthenticated},
+		{"no subject", f.withToken(t, jwt.MapClaims{"role": "CUSTOMER", "exp": exp}), codes.Unauthenticated},
+		{"missing scope", f.withToken(t, jwt.MapClaims{"sub": customerID, "scope": "booking:read", "exp": exp}), codes.PermissionDenied},
+		// authenticated calls reach request validation
+		{"customer", f.asCustomer(t), codes.InvalidArgument},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := c.HoldSeats(tc.ctx, &grpcapi.HoldSeatsRequest{})
+			expectCode(t, err, tc.want)
+		})
+	}
+}
+
+func TestPublicMethods(t *testing.T) {
+	f := newFixture(t)
+	c := f.client(t)
+	_, err := c.ListShows(context.Background(), &grpcapi.ListShowsRequest{HallId: 4})
+	expectCode(t, err, codes.NotFound)
+	_, err = c.GetShow(context.Background(), &grpcapi.GetShowRequest{})
+	expectCode(t, err, codes.InvalidArgument)
+}
+
+func TestCancelReservation(t *testing.T) {
+	f := newFixture(t)
+	var cancelled uint64
+	var freed []uint64
+	f.reservations.GetInfoForUserTxFn = func(_ context.Context, _ *sql.Tx, resID, userID uint64) (uint64, time.Time, []uint64, error) {
+		if resID != 9 || userID != customerID {
+			return 0, time.Time{}, nil, sql.ErrNoRows
+		}
+		return 3, time.Now().Add(24 * time.Hour), []uint64{11, 12}, nil
+	}
+	f.reservations.CancelTxFn = func(_ context.Context, _ *sql.Tx, resID uint64) error {
+		cancelled = resID
+		return nil
+	}
+	f.shows.CancelCutoffTxFn = func(context.Context, *sql.Tx, uint64) (time.Duration, error) { return 0, nil }
+	f.showSeats.BulkUpdateStatusTxFn = func(_ context.Context, _ *sql.Tx, _ uint64, seatIDs []uint64, st string) error {
+		if st != "FREE" {
+			t.Errorf("seats set to %s, want FREE", st)
+		}
+		freed = seatIDs
+		return nil
+	}
+	c := f.client(t)
+
+	if _, err := c.CancelReservation(f.asCustomer(t), &grpcapi.CancelReservationRequest{ReservationId: 9}); err != nil {
+		t.Fatal(err)
+	}
+	if cancelled != 9 || len(freed) != 2 {
+		t.Fatalf("cancelled reservation %d, freed seats %v", cancelled, freed)
+	}
+
+	_, err := c.CancelReservation(f.asCustomer(t), &grpcapi.CancelReservationRequest{ReservationId: 10})
+	expectCode(t, err, codes.NotFound)
+}
+
+func TestCancelReservationErrors(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+		want codes.Code
+	}{
+		{"paid", repository.ErrReservationPaid, codes.FailedPrecondition},
+		{"cancelled", repository.ErrReservationCancelled, codes.FailedPrecondition},
+		{"unexpected", errors.New("disk on fire"), codes.Internal},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			f := newFixture(t)
+			f.reservations.GetInfoForUserTxFn = func(context.Context, *sql.Tx, uint64, uint64) (uint64, time.Time, []uint64, error) {
+				return 3, time.Now().Add(24 * time.Hour), nil, nil
+			}
+			f.shows.CancelCutoffTxFn = func(context.Context, *sql.Tx, uint64) (time.Duration, error) { return 0, nil }
+			f.reservations.CancelTxFn = func(context.Context, *sql.Tx, uint64) error { return tc.err }
+			_, err := f.client(t).CancelReservation(f.asCustomer(t), &grpcapi.CancelReservationRequest{ReservationId: 9})
+			expectCode(t, err, tc.want)
+			if tc.want == codes.Internal && status.Convert(err).Message() != "internal error" {
+				t.Fatalf("internal error leaked: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package grpcapi
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/iliyamo/cinema-seat-reservation/internal/db"
+	"github.com/iliyamo/cinema-seat-reservation/internal/repository"
+	"github.com/iliyamo/cinema-seat-reservation/internal/service"
+)
+
+// toStatus converts an error from a method into the status sent to the
+// client, mirroring the REST API's error responses.  Unexpected errors
+// are logged and reported as INTERNAL without details.
+func toStatus(method string, err error) *status.Status {
+	var (
+		notBookable *service.ShowNotBookableError
+		unavailable *service.SeatsUnavailableError
+		notHeld     *service.SeatsNotHeldError
+		notActive   *service.HoldsNotActiveError
+		changed     *service.PriceChangedError
+	)
+	switch {
+	case isStatus(err):
+		return status.Convert(err)
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.New(codes.DeadlineExceeded, "deadline exceeded")
+	case errors.Is(err, context.Canceled):
+		return status.New(codes.Canceled, "request canceled")
+	case db.IsTransient(err):
+		return status.New(codes.Unavailable, "the booking system is busy, please retry")
+	case errors.Is(err, repository.ErrShowNotFound):
+		return status.New(codes.NotFound, "show not found")
+	case errors.Is(err, repository.ErrHallNotFound):
+		return status.New(codes.NotFound, "hall not found")
+	case errors.As(err, &notBookable):
+		return status.New(codes.FailedPrecondition, "show is not bookable")
+	case errors.As(err, &unavailable):
+		return status.New(codes.FailedPrecondition, "some seats are unavailable: "+joinIDs(unavailable.SeatIDs))
+	case errors.As(err, &notHeld):
+		return status.New(codes.FailedPrecondition, "some seats cannot be confirmed: "+joinIDs(notHeld.SeatIDs))
+	case errors.As(err, &notActive):
+		return status.New(codes.FailedPrecondition, "some holds are no longer active: "+strings.Join(notActive.Tokens, ","))
+	case errors.Is(err, service.ErrNoActiveHolds):
+		return status.New(codes.FailedPrecondition, "no active holds for this show")
+	case errors.As(err, &changed):
+		return status.New(codes.FailedPrecondition, fmt.Sprintf("seat prices changed since the seats were held: total %d instead of %d cents",
+			changed.CurrentTotalCents, changed.QuotedTotalCents))
+	case errors.Is(err, repository.ErrPromoCodeNotFound):
+		return status.New(codes.InvalidArgument, "promo code not found")
+	case errors.Is(err, service.ErrPromoCodeInvalid):
+		return status.New(codes.InvalidArgument, "promo code is not valid")
+	case errors.Is(err, service.ErrPromoCodesUnsupported):
+		return status.New(codes.Unimplemented, "promo codes are not supported")
+	case errors.Is(err, sql.ErrNoRows):
+		return status.New(codes.NotFound, "reservation not found")
+	case errors.Is(err, repository.ErrForbidden):
+		return status.New(codes.PermissionDenied, "forbidden")
+	case errors.Is(err, repository.ErrReservationCancelled):
+		return status.New(codes.FailedPrecondition, "reservation is already cancelled")
+	case errors.Is(err, repository.ErrReservationPaid):
+		return status.New(codes.FailedPrecondition, "paid reservations are cancelled by requesting a refund")
+	case errors.Is(err, service.ErrShowStarted):
+		return status.New(codes.FailedPrecondition, "show already started")
+	case errors.As(err, new(*service.CancelCutoffError)):
+		return status.New(codes.FailedPrecondition, err.Error())
+	case errors.As(err, new(*service.HoldQuotaError)):
+		return status.New(codes.ResourceExhausted, err.Error())
+	case errors.As(err, new(*service.AgeRestrictedError)):
+		return status.New(codes.PermissionDenied, err.Error())
+	case errors.As(err, new(*service.CurrencyMismatchError)):
+		return status.New(codes.FailedPrecondition, err.Error())
+	}
+	log.Printf("grpc %s: %v", method, err)
+	return status.New(codes.Internal, "internal error")
+}
+
+// isStatus reports whether err already carries a gRPC status, as the
+// errors of the interceptor and of request validation do.
+func isStatus(err error) bool {
+	_, ok := status.FromError(err)
+	return ok
+}
+
+func joinIDs(ids []uint64) string {
+	s := make([]string, len(ids))
+	for i, id := range ids {
+		s[i] = fmt.Sprint(id)
+	}
+	return strings.Join(s, ",")
+}
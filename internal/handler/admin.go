@@ -0,0 +1,287 @@
+package handler
+
+// This file defines HTTP handlers for administrators.  These endpoints are
+// operational tools for on-call engineers and require the ADMIN role.
+
+import (
+    "net/http"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/iliyamo/cinema-seat-reservation/internal/apperr"
+    "github.com/iliyamo/cinema-seat-reservation/internal/config"
+    "github.com/iliyamo/cinema-seat-reservation/internal/repository"
+    "github.com/iliyamo/cinema-seat-reservation/internal/service"
+    "github.com/iliyamo/cinema-seat-reservation/internal/utils"
+    "github.com/labstack/echo/v4"
+)
+
+// AdminHandler groups repositories used by admin-only endpoints.
+type AdminHandler struct {
+    DiagnosticsRepo *repository.DiagnosticsRepo // read-only operational queries
+    QuotaRepo       *repository.QuotaRepo       // optional; enables the quota endpoints
+    Quotas          *service.QuotaTracker       // optional; invalidated when a quota changes
+    Keys            *utils.KeySet               // optional; reported by GET /v1/admin/jwt-keys
+    StatsRepo       *repository.StatsRepo       // optional; enables GET /v1/admin/stats
+    Settings        []config.Setting            // optional; reported by GET /v1/admin/config
+}
+
+// NewAdminHandler constructs an AdminHandler.  All dependencies must be non-nil.
+func NewAdminHandler(diagRepo *repository.DiagnosticsRepo) *AdminHandler {
+    if diagRepo == nil {
+        panic("nil repository passed to NewAdminHandler")
+    }
+    return &AdminHandler{DiagnosticsRepo: diagRepo}
+}
+
+// GetDiagnostics handles GET /v1/admin/diagnostics.  It reports InnoDB
+// transactions open longer than min_age_sec (default 5), current lock
+// waits and the shows with the most active seat holds, so contention can
+// be traced to a specific on-sale without direct DB access.  Each section
+// is collected independently; a section that fails (for example because
+// the DB user lacks the PROCESS privilege) is reported under "errors"
+// while the remaining sections are still returned.
+func (h *AdminHandler) GetDiagnostics(c echo.Context) error {
+    minAge := 5 * time.Second
+    if s := c.QueryParam("min_age_sec"); s != "" {
+        n, err := strconv.Atoi(s)
+        if err != nil || n < 0 {
+            return apperr.BadRequest("invalid min_age_sec")
+        }
+        minAge = time.Duration(n) * time.Second
+    }
+    ctx := c.Request().Context()
+    errs := echo.Map{}
+    trx, err := h.DiagnosticsRepo.LongTransactions(ctx, minAge)
+    if err != nil {
+        errs["long_transactions"] = err.Error()
+        trx = []repository.LongTransaction{}
+    }
+    waits, err := h.DiagnosticsRepo.LockWaits(ctx)
+    if err != nil {
+        errs["lock_waits"] = err.Error()
+        waits = []repository.LockWait{}
+    }
+    holds, err := h.DiagnosticsRepo.ActiveHoldCounts(ctx, 50)
+    if err != nil {
+        errs["active_holds"] = err.Error()
+        holds = []repository.ShowHoldCount{}
+    }
+    resp := echo.Map{
+        "generated_at":         time.Now().UTC().Format(time.RFC3339),
+        "min_age_sec":          int(minAge / time.Second),
+        "long_transactions":    trx,
+        "lock_waits":           waits,
+        "active_holds_by_show": holds,
+    }
+    if len(errs) > 0 {
+        resp["errors"] = errs
+    }
+    return c.JSON(http.StatusOK, resp)
+}
+
+// quotaUserID parses the :user_id path parameter.
+func quotaUserID(c echo.Context) (uint64, bool) {
+    id, err := strconv.ParseUint(c.Param("user_id"), 10, 64)
+    return id, err == nil && id > 0
+}
+
+// quotaResponse renders a quota together with the usage recorded for the
+// current UTC day and month.  Usage counted since the last flush is not
+// yet included.
+func (h *AdminHandler) quotaResponse(c echo.Context, q *repository.AccountQuota) error {
+    ctx := c.Request().Context()
+    now := time.Now().UTC()
+    day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+    month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+    dayUsed, err := h.QuotaRepo.Usage(ctx, q.UserID, repository.QuotaPeriodDay, day)
+    if err != nil {
+        return apperr.Internal("database error")
+    }
+    monUsed, err := h.QuotaRepo.Usage(ctx, q.UserID, repository.QuotaPeriodMonth, month)
+    if err != nil {
+        return apperr.Internal("database error")
+    }
+    return c.JSON(http.StatusOK, echo.Map{
+        "user_id":       q.UserID,
+        "daily_limit":   q.DailyLimit,
+        "monthly_limit": q.MonthlyLimit,
+        "daily_used":    dayUsed,
+        "monthly_used":  monUsed,
+    })
+}
+
+// GetQuota handles GET /v1/admin/quotas/:user_id and returns the account's
+// limits and persisted usage.  Accounts without a quota are reported with
+// null limits.
+func (h *AdminHandler) GetQuota(c echo.Context) error {
+    if h.QuotaRepo == nil {
+        return apperr.NotFound("quotas are not enabled")
+    }
+    userID, ok := quotaUserID(c)
+    if !ok {
+        return apperr.BadRequest("invalid user id")
+    }
+    q, err := h.QuotaRepo.Get(c.Request().Context(), userID)
+    if err != nil {
+        return apperr.Internal("database error")
+    }
+    if q == nil {
+        q = &repository.AccountQuota{UserID: userID}
+    }
+    return h.quotaResponse(c, q)
+}
+
+// PutQuota handles PUT /v1/admin/quotas/:user_id.  The body sets
+// daily_limit and monthly_limit; a null or omitted limit is unlimited.
+// The change takes effect on the account's next request.
+func (h *AdminHandler) PutQuota(c echo.Context) error {
+    if h.QuotaRepo == nil {
+        return apperr.NotFound("quotas are not enabled")
+    }
+    userID, ok := quotaUserID(c)
+    if !ok {
+        return apperr.BadRequest("invalid user id")
+    }
+    var body struct {
+        DailyLimit   *uint32 `json:"daily_limit"`
+        MonthlyLimit *uint32 `json:"monthly_limit"`
+    }
+    if err := bindValid(c, &body); err != nil {
+        return err
+    }
+    q := &repository.AccountQuota{UserID: userID, DailyLimit: body.DailyLimit, MonthlyLimit: body.MonthlyLimit}
+    if err := h.QuotaRepo.Upsert(c.Request().Context(), q); err != nil {
+        if strings.Contains(err.Error(), "1452") {
+            return apperr.NotFound("user not found")
+        }
+        return apperr.Internal("failed to save quota")
+    }
+    if h.Quotas != nil {
+        h.Quotas.Invalidate(userID)
+    }
+    return h.quotaResponse(c, q)
+}
+
+// DeleteQuota handles DELETE /v1/admin/quotas/:user_id and removes the
+// account's quota, making it unlimited.
+func (h *AdminHandler) DeleteQuota(c echo.Context) error {
+    if h.QuotaRepo == nil {
+        return apperr.NotFound("quotas are not enabled")
+    }
+    userID, ok := quotaUserID(c)
+    if !ok {
+        return apperr.BadRequest("invalid user id")
+    }
+    found, err := h.QuotaRepo.Delete(c.Request().Context(), userID)
+    if err != nil {
+        return apperr.Internal("failed to delete quota")
+    }
+    if !found {
+        return apperr.NotFound("quota not found")
+    }
+    if h.Quotas != nil {
+        h.Quotas.Invalidate(userID)
+    }
+    return c.NoContent(http.StatusNoContent)
+}
+
+// GetJWTKeys handles GET /v1/admin/jwt-keys.  It lists the IDs of the
+// access token keys this replica accepts and the one it signs with, so a
+// rotation can be verified on every replica before the old key is
+// removed.  Secrets are never returned.
+func (h *AdminHandler) GetJWTKeys(c echo.Context) error {
+    if h.Keys == nil {
+        return apperr.NotImplemented("JWT key set is not available")
+    }
+    return c.JSON(http.StatusOK, echo.Map{
+        "signing_kid":   h.Keys.SigningKeyID(),
+        "accepted_kids": h.Keys.KeyIDs(),
+    })
+}
+
+// GetConfig handles GET /v1/admin/config.  It reports the effective
+// configuration of this replica: every setting with its value, secrets
+// redacted, and whether it came from a flag, the environment, the
+// settings file or the default.
+func (h *AdminHandler) GetConfig(c echo.Context) error {
+    if h.Settings == nil {
+        return apperr.NotImplemented("configuration report is not available")
+    }
+    return c.JSON(http.StatusOK, echo.Map{"settings": h.Settings})
+}
+
+// maxStatsDays bounds the range of GET /v1/admin/stats.
+const maxStatsDays = 366
+
+// GetStats handles GET /v1/admin/stats.  It reports platform-wide totals
+// (users by role, cinemas, shows scheduled to start today) and, for each
+// UTC day between the from and to query parameters (YYYY-MM-DD,
+// inclusive), the reservations created and the revenue of those
+// confirmed.  The range defaults to the last 30 days including today and
+// may span at most 366 days.
+func (h *AdminHandler) GetStats(c echo.Context) error {
+    if h.StatsRepo == nil {
+        return apperr.NotImplemented("statistics are not available")
+    }
+    today := time.Now().UTC().Truncate(24 * time.Hour)
+    from, to := today.AddDate(0, 0, -29), today
+    var err error
+    if v := c.QueryParam("from"); v != "" {
+        if from, err = parseDateParam("from", v); err != nil {
+            return err
+        }
+    }
+    if v := c.QueryParam("to"); v != "" {
+        if to, err = parseDateParam("to", v); err != nil {
+            return err
+        }
+    }
+    if to.Before(from) {
+        return apperr.BadRequest("to must not be before from")
+    }
+    if to.Sub(from) >= maxStatsDays*24*time.Hour {
+        return apperr.BadRequest("the range may span at most 366 days")
+    }
+    ctx := c.Request().Context()
+    roles, err := h.StatsRepo.UsersByRole(ctx)
+    if err != nil {
+        return apperr.Internal("database error")
+    }
+    cinemas, err := h.StatsRepo.CinemaCount(ctx)
+    if err != nil {
+        return apperr.Internal("database error")
+    }
+    showsToday, err := h.StatsRepo.ScheduledShows(ctx, today, today.AddDate(0, 0, 1))
+    if err != nil {
+        return apperr.Internal("database error")
+    }
+    days, err := h.StatsRepo.DailyBookings(ctx, from, to)
+    if err != nil {
+        return apperr.Internal("database error")
+    }
+    var reservations, confirmed, cancelled int
+    var revenue uint64
+    for _, d := range days {
+        reservations += d.Reservations
+        confirmed += d.Confirmed
+        cancelled += d.Cancelled
+        revenue += d.RevenueCents
+    }
+    return c.JSON(http.StatusOK, echo.Map{
+        "generated_at":          time.Now().UTC().Format(time.RFC3339),
+        "users_by_role":         roles,
+        "cinemas":               cinemas,
+        "shows_scheduled_today": showsToday,
+        "from":                  from.Format(repository.DateLayout),
+        "to":                    to.Format(repository.DateLayout),
+        "totals": echo.Map{
+            "reservations":  reservations,
+            "confirmed":     confirmed,
+            "cancelled":     cancelled,
+            "revenue_cents": revenue,
+        },
+        "days": days,
+    })
+}
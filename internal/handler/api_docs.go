@@ -0,0 +1,234 @@
+package handler
+
+import (
+    "net/http"
+    "regexp"
+    "strings"
+    "sync"
+    "unicode"
+
+    "github.com/iliyamo/cinema-seat-reservation/internal/apperr"
+    "github.com/iliyamo/cinema-seat-reservation/internal/config"
+    "github.com/iliyamo/cinema-seat-reservation/internal/openapi"
+    "github.com/iliyamo/cinema-seat-reservation/internal/repository"
+    "github.com/labstack/echo/v4"
+)
+
+// apiDocs annotates routes for the OpenAPI document, keyed by method and
+// Echo path.  Routes without an entry are still documented, with a summary
+// and tag derived from the handler method name (see routeDoc); add an
+// entry when a route binds or returns a named DTO so its schema is
+// published.
+var apiDocs = map[string]openapi.Doc{
+    "GET /v1/auth/bot-check":       {Summary: "Get the bot check provider and a challenge", Tag: "Auth", Response: botCheckResp{}},
+    "POST /v1/auth/register":       {Summary: "Register a customer or owner account", Tag: "Auth", Request: registerReq{}, Response: authResp{}, Status: http.StatusCreated},
+    "POST /v1/auth/login":          {Summary: "Log in and obtain an access/refresh token pair (423/429 with Retry-After after repeated failures)", Tag: "Auth", Request: loginReq{}, Response: authResp{}},
+    "POST /v1/auth/guest":          {Summary: "Start a guest session that may hold seats until it registers or logs in", Tag: "Auth", Response: guestResp{}, Status: http.StatusCreated},
+    "POST /v1/auth/refresh":        {Summary: "Rotate the refresh token and issue a new pair", Tag: "Auth", Request: refreshReq{}, Response: authResp{}},
+    "POST /v1/auth/refresh-access": {Summary: "Issue a new access token without rotating the refresh token", Tag: "Auth", Request: refreshReq{}},
+    "POST /v1/auth/logout":         {Summary: "Revoke a refresh token (or all of the caller's tokens)", Tag: "Auth", Status: http.StatusNoContent},
+    "POST /v1/logout":              {Summary: "Revoke a refresh token (alias of /v1/auth/logout)", Tag: "Auth", Status: http.StatusNoContent},
+    "POST /v1/auth/forgot-password": {Summary: "Email a single-use password reset link", Tag: "Auth", Request: forgotPasswordReq{}, Status: http.StatusAccepted},
+    "POST /v1/auth/reset-password":  {Summary: "Set a new password using a reset token", Tag: "Auth", Request: resetPasswordReq{}, Status: http.StatusNoContent},
+    "GET /v1/me":                   {Summary: "Return the authenticated user", Tag: "Auth", Auth: true},
+    "GET /v1/me/sessions":          {Summary: "List the caller's active sessions (refresh tokens) with device metadata", Tag: "Auth", Auth: true, Response: repository.Session{}},
+    "DELETE /v1/me/sessions/:id":   {Summary: "Revoke one of the caller's sessions", Tag: "Auth", Auth: true, Status: http.StatusNoContent},
+
+    "GET /v1/search": {Summary: "Search scheduled shows", Tag: "Public", Query: []string{"q", "date", "city", "page", "page_size"}},
+    "GET /v1/shows":  {Summary: "List scheduled shows of every cinema by date", Tag: "Public", Query: []string{"date", "from", "to", "cinema_id", "city", "page", "page_size"}},
+    "GET /v1/cinemas": {Summary: "List cinemas, optionally by city or distance", Tag: "Public", Query: []string{"city", "near", "radius_km"}},
+    "GET /v1/cinemas/:id":          {Summary: "Get a cinema with its logo, description, phone and opening hours", Tag: "Public", Response: PublicCinema{}},
+    "GET /v1/cinemas/:id/profile":  {Summary: "Show a cinema's public profile", Tag: "Owner", Auth: true, Response: repository.CinemaProfile{}},
+    "PUT /v1/cinemas/:id/profile":  {Summary: "Set a cinema's logo URL, description, phone and opening hours", Tag: "Owner", Auth: true, Request: cinemaProfileReq{}, Response: repository.CinemaProfile{}},
+    "PUT /v1/cinemas/:id/location": {Summary: "Set a cinema's city, address and coordinates", Tag: "Owner", Auth: true, Request: cinemaLocationReq{}, Response: repository.Cinema{}},
+    "PUT /v1/cinemas/:id/cancellation-policy": {Summary: "Set how many minutes before a show customers may still cancel, for all of a cinema's shows", Tag: "Owner", Auth: true, Request: cancellationPolicyReq{}},
+    "GET /v1/cinemas/:id/scheduling-window":   {Summary: "Show how soon and how far ahead a cinema's shows may be scheduled", Tag: "Owner", Auth: true, Response: schedulingWindowResp{}},
+    "PUT /v1/cinemas/:id/scheduling-window":   {Summary: "Set a cinema's minimum lead time and scheduling horizon for new shows", Tag: "Owner", Auth: true, Request: schedulingWindowReq{}, Response: schedulingWindowResp{}},
+    "GET /v1/cinemas/:id/fees":                {Summary: "Show a cinema's booking fee and tax rate", Tag: "Owner", Auth: true, Response: repository.CinemaFees{}},
+    "PUT /v1/cinemas/:id/fees":                {Summary: "Set a cinema's booking fee (percent or flat per ticket) and tax rate", Tag: "Owner", Auth: true, Request: cinemaFeesReq{}, Response: repository.CinemaFees{}},
+    "GET /v1/shows/:id/cancellation-policy":   {Summary: "Show a show's cancellation cutoff, its cinema's and the one that applies", Tag: "Owner", Auth: true, Response: repository.CancellationPolicy{}},
+    "PUT /v1/shows/:id/cancellation-policy":   {Summary: "Override the cinema's cancellation cutoff for one show", Tag: "Owner", Auth: true, Request: cancellationPolicyReq{}, Response: repository.CancellationPolicy{}},
+    "GET /v1/shows/:id/content":               {Summary: "Show a show's rating, language, format and minimum age", Tag: "Owner", Auth: true, Response: repository.ShowContent{}},
+    "PUT /v1/shows/:id/content":               {Summary: "Set a show's rating, language, format and minimum age", Tag: "Owner", Auth: true, Request: showContentReq{}, Response: repository.ShowContent{}},
+    "GET /v1/halls/:id/seats": {Summary: "List the seats of a hall", Tag: "Public", Query: []string{"active"}},
+    "GET /v1/halls/:id/shows": {Summary: "List the scheduled shows of a hall, or all of them", Tag: "Public", Query: []string{"all"}},
+    "POST /v1/shows/availability\\:batch": {Summary: "Seat counts of up to 100 shows, overall and per seat type", Tag: "Public", Request: availabilityBatchReq{}},
+    "POST /v1/shows/:id/quote":            {Summary: "Price a seat selection, by seat ID or row and number, without holding it", Tag: "Public", Request: quoteReq{}},
+    "GET /v1/graphql":         {Summary: "Run a read-only GraphQL query over cinemas, halls, shows and availability", Tag: "Public", Query: []string{"query", "operationName", "variables"}},
+    "POST /v1/graphql":        {Summary: "Run a read-only GraphQL query (JSON body or application/graphql)", Tag: "Public"},
+    "GET /v1/graphql/schema":  {Summary: "Return the GraphQL schema in SDL", Tag: "Public"},
+
+    "POST /v1/shows/:id/hold":                {Summary: "Hold seats for a show", Tag: "Customer", Auth: true, Request: holdSeatsReq{}, Query: []string{"dry_run", "partial"}, Status: http.StatusCreated},
+    "POST /v1/shows/:id/hold/auto":           {Summary: "Hold N seats chosen by the server, adjacent by default", Tag: "Customer", Auth: true, Query: []string{"count", "adjacent"}, Status: http.StatusCreated},
+    "POST /v1/shows/:id/confirm":             {Summary: "Confirm held seats into a reservation", Tag: "Customer", Auth: true, Request: confirmSeatsReq{}, Query: []string{"dry_run"}, Status: http.StatusCreated},
+    "POST /v1/shows/:id/reserve":             {Summary: "Confirm held seats into a reservation (alias of /confirm)", Tag: "Customer", Auth: true, Request: confirmSeatsReq{}, Query: []string{"dry_run"}, Status: http.StatusCreated},
+    "POST /v1/shows/:id/checkout":            {Summary: "Reserve held seats and pay for them", Tag: "Customer", Auth: true, Request: confirmSeatsReq{}, Response: repository.BookingSaga{}, Status: http.StatusCreated},
+    "GET /v1/booking-sagas/:id":              {Summary: "Show the state of one of the caller's checkouts", Tag: "Customer", Auth: true, Response: repository.BookingSaga{}},
+    "GET /v1/checkout-session/:show_id":      {Summary: "Resume checkout from the caller's active holds", Tag: "Customer", Auth: true, Response: CheckoutSession{}},
+    "GET /v1/cart":                           {Summary: "List the caller's holds across shows", Tag: "Customer", Auth: true},
+    "POST /v1/cart/checkout":                 {Summary: "Confirm held seats of several shows at once", Tag: "Customer", Auth: true, Request: cartCheckoutReq{}, Status: http.StatusCreated},
+    "GET /v1/my-reservations":                {Summary: "List the caller's reservations", Tag: "Customer", Auth: true},
+    "GET /v1/my-reservations/export":         {Summary: "Download the caller's reservation history as CSV", Tag: "Customer", Auth: true, Query: []string{"format"}},
+    "GET /v1/reservations/:id/calendar.ics":  {Summary: "Download a reservation as an iCalendar event", Tag: "Customer", Auth: true},
+    "POST /v1/shows/:id/waitlist":            {Summary: "Join a show's waitlist", Tag: "Customer", Auth: true, Request: joinWaitlistReq{}, Response: repository.WaitlistEntry{}, Status: http.StatusCreated},
+    "GET /v1/waitlist":                       {Summary: "List the caller's waitlist entries", Tag: "Customer", Auth: true},
+    "POST /v1/waitlist/:id/decline":          {Summary: "Leave a waitlist or decline its offer", Tag: "Customer", Auth: true, Response: repository.WaitlistEntry{}},
+    "POST /v1/reservations/:id/refund-request": {Summary: "Ask for a refund of a paid reservation", Tag: "Customer", Auth: true, Request: refundRequestReq{}, Response: repository.Refund{}, Status: http.StatusCreated},
+    "GET /v1/reservations/:id/refund":          {Summary: "Show the refund of one of the caller's reservations", Tag: "Customer", Auth: true, Response: repository.Refund{}},
+    "POST /v1/reservations/:id/transfer":       {Summary: "Issue a one-time code transferring a reservation", Tag: "Customer", Auth: true, Status: http.StatusCreated},
+    "POST /v1/reservations/claim":              {Summary: "Claim a reservation with a transfer code", Tag: "Customer", Auth: true, Request: claimReservationReq{}},
+    "POST /v1/reservations/:id/upgrade":        {Summary: "Upgrade STANDARD seats of a reservation to VIP seats", Tag: "Customer", Auth: true, Request: upgradeSeatsReq{}},
+    "POST /v1/me/favorites":                    {Summary: "Follow a cinema or a movie title to hear about new shows", Tag: "Customer", Auth: true, Request: addFavoriteReq{}, Response: repository.Favorite{}, Status: http.StatusCreated},
+    "GET /v1/me/favorites":                     {Summary: "List the caller's followed cinemas and movies", Tag: "Customer", Auth: true, Response: repository.Favorite{}},
+    "DELETE /v1/me/favorites/:id":              {Summary: "Stop following a cinema or movie", Tag: "Customer", Auth: true, Status: http.StatusNoContent},
+    "GET /v1/me/date-of-birth":                 {Summary: "Show the date of birth age-restricted shows are checked against", Tag: "Customer", Auth: true},
+    "PUT /v1/me/date-of-birth":                 {Summary: "Set or clear the caller's date of birth", Tag: "Customer", Auth: true, Request: dateOfBirthReq{}},
+    "POST /v1/halls":                         {Summary: "Create a hall with its seat grid", Tag: "Owner", Auth: true, Request: createHallReq{}, Status: http.StatusCreated},
+    "POST /v1/halls/:id/clone":               {Summary: "Copy a hall with its seats into a new hall", Tag: "Owner", Auth: true, Request: cloneHallReq{}, Status: http.StatusCreated},
+    "GET /v1/halls/:id/layout":               {Summary: "Show a hall's seats row by row", Tag: "Owner", Auth: true},
+    "PUT /v1/halls/:id/layout":               {Summary: "Replace a hall's seats with a custom layout", Tag: "Owner", Auth: true, Request: hallLayoutReq{}},
+    "GET /v1/halls/:id/layout/rendering":     {Summary: "Show a hall's seat map rendering metadata", Tag: "Owner", Auth: true},
+    "PUT /v1/halls/:id/layout/rendering":     {Summary: "Set a hall's screen edge, sections and row offsets", Tag: "Owner", Auth: true, Request: hallRenderingReq{}},
+    "POST /v1/seats":                         {Summary: "Add a seat to a hall", Tag: "Owner", Auth: true, Request: createSeatReq{}, Status: http.StatusCreated},
+    "POST /v1/shows":                         {Summary: "Schedule a show", Tag: "Owner", Auth: true, Request: createShowReq{}, Response: repository.Show{}, Status: http.StatusCreated},
+    "POST /v1/shows/:id/duplicate":           {Summary: "Copy a show to a new time, optionally repeating", Tag: "Owner", Auth: true, Request: duplicateShowReq{}, Response: repository.Show{}, Status: http.StatusCreated},
+    "GET /v1/owner/shows/:id/seatmap-snapshot": {Summary: "List a show's seat map snapshots", Tag: "Owner", Auth: true, Query: []string{"version"}, Response: repository.SeatMapSnapshot{}},
+    "POST /v1/owner/shows/import":            {Summary: "Schedule shows from a CSV upload", Tag: "Owner", Auth: true},
+    "POST /v1/owner/shows/:id/reconcile":     {Summary: "Repair seat statuses that disagree with the show's holds and reservations", Tag: "Owner", Auth: true, Response: repository.SeatRepair{}},
+    "GET /v1/owner/shows/:id/holds":          {Summary: "List a show's active seat holds with their users and expiry", Tag: "Owner", Auth: true, Response: []repository.ShowHold{}},
+    "DELETE /v1/owner/shows/:id/holds/:hold_id": {Summary: "Force-release a seat hold and free its seat", Tag: "Owner", Auth: true, Status: http.StatusNoContent},
+    "POST /v1/owner/reservations/:id/check-in": {Summary: "Check in all or some seats of a confirmed reservation", Tag: "Owner", Auth: true, Request: checkInReq{}},
+    "GET /v1/owner/shows/:id/attendance":       {Summary: "Count a show's booked, checked-in and no-show seats", Tag: "Owner", Auth: true, Response: repository.ShowAttendance{}},
+    "POST /v1/shows/:id/cancel":              {Summary: "Cancel a show, voiding its reservations and notifying customers", Tag: "Owner", Auth: true, Request: cancelShowReq{}},
+    "POST /v1/shows/:id/seats/block":         {Summary: "Block free seats of one show", Tag: "Owner", Auth: true, Request: blockSeatsReq{}},
+    "POST /v1/shows/:id/seats/unblock":       {Summary: "Release blocked seats of one show", Tag: "Owner", Auth: true, Request: blockSeatsReq{}},
+    "PATCH /v1/halls/:id/seats/bulk":         {Summary: "Set the type or active flag of selected seats", Tag: "Owner", Auth: true, Request: bulkSeatsReq{}},
+    "PATCH /v1/shows/:id/seats/prices":       {Summary: "Set the price of selected seats of one show", Tag: "Owner", Auth: true, Request: showSeatPricesReq{}},
+    "PUT /v1/halls/:id/seats/accessibility":  {Summary: "Set seat accessibility attributes", Tag: "Owner", Auth: true, Request: struct {
+        Seats []repository.SeatAccessibility `json:"seats" validate:"required"`
+    }{}},
+
+    "GET /v1/owner/cinemas/:id/calendar":          {Summary: "List a cinema's blackout and special dates", Tag: "Owner", Auth: true, Query: []string{"from", "to"}},
+    "PUT /v1/owner/cinemas/:id/calendar/:date":    {Summary: "Create or replace a blackout or special date", Tag: "Owner", Auth: true, Request: calendarDateReq{}, Response: repository.CalendarDate{}},
+    "DELETE /v1/owner/cinemas/:id/calendar/:date": {Summary: "Remove a calendar date", Tag: "Owner", Auth: true, Status: http.StatusNoContent},
+    "POST /v1/owner/staff":                        {Summary: "Grant a user management of a cinema or hall", Tag: "Owner", Auth: true, Request: inviteStaffReq{}, Response: repository.StaffMembership{}, Status: http.StatusCreated},
+    "GET /v1/shows/:id/reservations/export":       {Summary: "Download a show's reservations as CSV", Tag: "Owner", Auth: true, Query: []string{"format"}},
+    "GET /v1/owner/staff":                         {Summary: "List the caller's staff memberships", Tag: "Owner", Auth: true},
+    "DELETE /v1/owner/staff/:id":                  {Summary: "Revoke a staff membership", Tag: "Owner", Auth: true, Status: http.StatusNoContent},
+    "POST /v1/owner/api-keys":                     {Summary: "Issue an API key; the key is only in this response", Tag: "Owner", Auth: true, Request: createAPIKeyReq{}, Status: http.StatusCreated},
+    "GET /v1/owner/api-keys":                      {Summary: "List the caller's API keys", Tag: "Owner", Auth: true},
+    "DELETE /v1/owner/api-keys/:id":               {Summary: "Revoke an API key", Tag: "Owner", Auth: true, Status: http.StatusNoContent},
+    "POST /v1/owner/webhooks":                     {Summary: "Subscribe a URL to booking events; the secret is only in this response", Tag: "Owner", Auth: true, Request: createWebhookReq{}, Status: http.StatusCreated},
+    "GET /v1/owner/webhooks":                      {Summary: "List the caller's webhook subscriptions", Tag: "Owner", Auth: true},
+    "DELETE /v1/owner/webhooks/:id":               {Summary: "Remove a webhook subscription and its delivery log", Tag: "Owner", Auth: true, Status: http.StatusNoContent},
+    "GET /v1/owner/webhooks/:id/deliveries":       {Summary: "Delivery log of a webhook subscription, newest first", Tag: "Owner", Auth: true, Query: []string{"status", "before_id", "limit"}},
+    "GET /v1/owner/refunds":                       {Summary: "List refunds of reservations on the caller's halls", Tag: "Owner", Auth: true, Query: []string{"status"}},
+    "POST /v1/owner/refunds/:id/approve":          {Summary: "Approve a refund request, releasing its seats", Tag: "Owner", Auth: true, Request: refundDecisionReq{}, Response: repository.Refund{}},
+    "POST /v1/owner/refunds/:id/deny":             {Summary: "Reject a refund request", Tag: "Owner", Auth: true, Request: refundDecisionReq{}, Response: repository.Refund{}},
+    "POST /v1/owner/refunds/:id/process":          {Summary: "Mark an approved refund as paid out", Tag: "Owner", Auth: true, Request: refundProcessReq{}, Response: repository.Refund{}},
+
+    "GET /v1/bundles/:id":           {Summary: "Show a bundle and its shows", Tag: "Bundles"},
+    "POST /v1/owner/bundles":        {Summary: "Create a bundle of shows", Tag: "Bundles", Auth: true, Status: http.StatusCreated},
+    "GET /v1/owner/bundles":         {Summary: "List the caller's bundles", Tag: "Bundles", Auth: true},
+    "POST /v1/bundles/:id/checkout": {Summary: "Reserve seats for every show in a bundle", Tag: "Bundles", Auth: true, Status: http.StatusCreated},
+    "PUT /v1/admin/quotas/:user_id": {Summary: "Set an account's request quota", Tag: "Admin", Auth: true, Response: repository.AccountQuota{}},
+    "GET /v1/admin/quotas/:user_id": {Summary: "Show an account's request quota and usage", Tag: "Admin", Auth: true},
+    "GET /v1/admin/jwt-keys":        {Summary: "List accepted access token key IDs and the signing key ID", Tag: "Admin", Auth: true},
+    "GET /v1/admin/config":          {Summary: "Report the effective configuration with secrets redacted", Tag: "Admin", Auth: true, Response: []config.Setting{}},
+    "GET /v1/admin/stats":           {Summary: "Platform-wide totals and reservations and revenue per day", Tag: "Admin", Auth: true, Query: []string{"from", "to"}},
+    "GET /v1/admin/audit":           {Summary: "Query the audit log", Tag: "Admin", Auth: true, Query: []string{"actor_id", "owner_id", "action", "entity_type", "entity_id", "from", "to", "before_id", "limit"}},
+    "GET /v1/owner/notification-settings":   {Summary: "Get the caller's notification preferences, such as the daily bookings digest", Tag: "Owner", Auth: true, Response: repository.OwnerNotificationSettings{}},
+    "PATCH /v1/owner/notification-settings": {Summary: "Turn the daily bookings digest on or off and set its hour and time zone", Tag: "Owner", Auth: true, Request: notificationSettingsReq{}, Response: repository.OwnerNotificationSettings{}},
+    "GET /v1/owner/audit":           {Summary: "Query the audit log of the caller's resources", Tag: "Owner", Auth: true, Query: []string{"actor_id", "action", "entity_type", "entity_id", "from", "to", "before_id", "limit"}},
+
+    "GET /v1/openapi.json": {Summary: "This document", Tag: "Meta"},
+    "GET /v1/docs":         {Summary: "Swagger UI", Tag: "Meta"},
+}
+
+// authenticatedHandlers lists handler types whose routes all require a
+// bearer token; used for routes without an apiDocs entry.
+var authenticatedHandlers = map[string]bool{
+    "CustomerHandler":         true,
+    "OwnerHandler":            true,
+    "OwnerReservationHandler": true,
+    "OwnerPromoHandler":       true,
+    "OwnerCalendarHandler":    true,
+    "AdminHandler":            true,
+}
+
+// handlerName matches Echo route names such as
+// ".../handler.(*OwnerHandler).CreateHall-fm" and "handler.Health".
+var handlerName = regexp.MustCompile(`(?:\(\*(\w+)\)|handler)\.(\w+)(?:-fm)?$`)
+
+// routeDoc returns the annotation for r, deriving one from the handler
+// name when apiDocs has none.
+func routeDoc(r *echo.Route) (openapi.Doc, bool) {
+    if d, ok := apiDocs[r.Method+" "+r.Path]; ok {
+        return d, true
+    }
+    d := openapi.Doc{}
+    if m := handlerName.FindStringSubmatch(r.Name); m != nil {
+        d.Summary = splitWords(m[2])
+        if m[1] != "" {
+            d.Tag = strings.TrimSuffix(m[1], "Handler")
+            d.Auth = authenticatedHandlers[m[1]]
+        }
+    }
+    if d.Tag == "" {
+        d.Tag = "Public"
+    }
+    if r.Path == "/healthz" || r.Path == "/readyz" || r.Path == "/metrics" {
+        d.Tag = "Meta"
+    }
+    return d, true
+}
+
+// splitWords turns CreateHall into "Create hall".
+func splitWords(s string) string {
+    var b strings.Builder
+    for i, r := range s {
+        if i > 0 && unicode.IsUpper(r) {
+            b.WriteByte(' ')
+            r = unicode.ToLower(r)
+        }
+        b.WriteRune(r)
+    }
+    return b.String()
+}
+
+var (
+    specOnce sync.Once
+    spec     *openapi.Document
+)
+
+// OpenAPI handles GET /v1/openapi.json.  The document is built on first
+// use from the routes registered on the server, so it always lists every
+// endpoint.
+func OpenAPI(c echo.Context) error {
+    specOnce.Do(func() {
+        spec = openapi.Build(openapi.Info{Title: "Cinema Seat Reservation API", Version: "1.0.0"},
+            c.Echo().Routes(), routeDoc, apperr.Error{})
+    })
+    return c.JSON(http.StatusOK, spec)
+}
+
+// swaggerPage loads Swagger UI from a CDN and points it at the document.
+const swaggerPage = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Cinema Seat Reservation API</title>
+<link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+<div id="swagger-ui"></div>
+<script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+<script>SwaggerUIBundle({url: "/v1/openapi.json", dom_id: "#swagger-ui"});</script>
+</body>
+</html>`
+
+// SwaggerUI handles GET /v1/docs and serves an interactive view of the
+// OpenAPI document.
+func SwaggerUI(c echo.Context) error {
+    return c.HTML(http.StatusOK, swaggerPage)
+}
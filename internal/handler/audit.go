@@ -0,0 +1,170 @@
+package handler
+
+// This file records state-changing operations in the audit log and serves
+// the endpoints that query it.  Admins can read the whole log; owners read
+// the events concerning their own cinemas, halls, shows and reservations.
+
+import (
+    "bytes"
+    "context"
+    "encoding/json"
+    "log"
+    "net/http"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/iliyamo/cinema-seat-reservation/internal/apperr"
+    "github.com/iliyamo/cinema-seat-reservation/internal/repository"
+    "github.com/labstack/echo/v4"
+)
+
+// auditEvent builds an audit event for an entity identified by a numeric
+// ID.  ownerID is the owner of the affected resource, or 0 for none.
+func auditEvent(action, entityType string, id, ownerID uint64) repository.AuditEvent {
+    e := repository.AuditEvent{Action: action, EntityType: entityType, EntityID: strconv.FormatUint(id, 10)}
+    if ownerID != 0 {
+        e.OwnerID = &ownerID
+    }
+    return e
+}
+
+// hallOwnerID returns the owner of a hall for an audit event, or 0 when
+// the hall cannot be loaded.
+func hallOwnerID(ctx context.Context, halls repository.HallStore, hallID uint64) uint64 {
+    hall, err := halls.GetByID(ctx, hallID)
+    if err != nil {
+        return 0
+    }
+    return hall.OwnerID
+}
+
+// recordAudit writes e to the audit log with JSON snapshots of the entity
+// before and after the mutation (nil for creates and deletes).  The actor
+// and client address are taken from the request.  Auditing is best effort:
+// a nil repository disables it and failures are logged rather than turned
+// into an error for a mutation that has already been applied.
+func recordAudit(c echo.Context, r *repository.AuditRepo, e repository.AuditEvent, before, after interface{}) {
+    if r == nil {
+        return
+    }
+    if uid, err := getUserID(c); err == nil {
+        e.ActorID = &uid
+    }
+    e.IP = c.RealIP()
+    e.Before, e.After = snapshot(before), snapshot(after)
+    if err := r.Record(c.Request().Context(), &e, nil); err != nil {
+        log.Printf("audit %s %s/%s: %v", e.Action, e.EntityType, e.EntityID, err)
+    }
+}
+
+// snapshot marshals v for the audit log; nil values (including nil
+// pointers) and values that fail to marshal yield an empty snapshot.
+func snapshot(v interface{}) json.RawMessage {
+    if v == nil {
+        return nil
+    }
+    b, err := json.Marshal(v)
+    if err != nil || bytes.Equal(b, []byte("null")) {
+        return nil
+    }
+    return b
+}
+
+// AuditHandler serves the audit log query endpoints.
+type AuditHandler struct {
+    Repo *repository.AuditRepo
+}
+
+// NewAuditHandler constructs an AuditHandler.  The repository must be
+// non-nil.
+func NewAuditHandler(repo *repository.AuditRepo) *AuditHandler {
+    if repo == nil {
+        panic("nil repository passed to NewAuditHandler")
+    }
+    return &AuditHandler{Repo: repo}
+}
+
+// ListAudit handles GET /v1/admin/audit.  Query parameters actor_id,
+// owner_id, action (exact, or a prefix ending in "*" such as "show.*"),
+// entity_type, entity_id, from and to (RFC3339), before_id and limit
+// filter the log; results are newest first.  When a full page is
+// returned, next_before_id continues from the last item.
+func (h *AuditHandler) ListAudit(c echo.Context) error {
+    f, err := auditFilter(c)
+    if err != nil {
+        return err
+    }
+    if s := c.QueryParam("owner_id"); s != "" {
+        if f.OwnerID, err = strconv.ParseUint(s, 10, 64); err != nil {
+            return fieldError("owner_id", "must be a positive integer")
+        }
+    }
+    return h.list(c, f)
+}
+
+// ListOwnerAudit handles GET /v1/owner/audit.  It accepts the same
+// filters as ListAudit except owner_id, which is always the caller.
+func (h *AuditHandler) ListOwnerAudit(c echo.Context) error {
+    ownerID, err := getUserID(c)
+    if err != nil {
+        return apperr.Unauthorized("unauthorized")
+    }
+    f, err := auditFilter(c)
+    if err != nil {
+        return err
+    }
+    f.OwnerID = ownerID
+    return h.list(c, f)
+}
+
+func (h *AuditHandler) list(c echo.Context, f repository.AuditFilter) error {
+    items, err := h.Repo.List(c.Request().Context(), f)
+    if err != nil {
+        return apperr.Internal("failed to load audit log")
+    }
+    resp := echo.Map{"items": items, "count": len(items)}
+    if f.Limit > 0 && len(items) == f.Limit {
+        resp["next_before_id"] = items[len(items)-1].ID
+    }
+    return c.JSON(http.StatusOK, resp)
+}
+
+// auditFilter parses the filters shared by both audit endpoints.
+func auditFilter(c echo.Context) (repository.AuditFilter, error) {
+    f := repository.AuditFilter{
+        Action:     strings.TrimSpace(c.QueryParam("action")),
+        EntityType: strings.TrimSpace(c.QueryParam("entity_type")),
+        EntityID:   strings.TrimSpace(c.QueryParam("entity_id")),
+        Limit:      50,
+    }
+    var err error
+    for _, p := range []struct {
+        name string
+        dst  *uint64
+    }{{"actor_id", &f.ActorID}, {"before_id", &f.BeforeID}} {
+        if s := c.QueryParam(p.name); s != "" {
+            if *p.dst, err = strconv.ParseUint(s, 10, 64); err != nil {
+                return f, fieldError(p.name, "must be a positive integer")
+            }
+        }
+    }
+    for _, p := range []struct {
+        name string
+        dst  *time.Time
+    }{{"from", &f.From}, {"to", &f.To}} {
+        if s := c.QueryParam(p.name); s != "" {
+            if *p.dst, err = time.Parse(time.RFC3339, s); err != nil {
+                return f, fieldError(p.name, "must be RFC3339")
+            }
+        }
+    }
+    if s := c.QueryParam("limit"); s != "" {
+        n, err := strconv.Atoi(s)
+        if err != nil || n < 1 || n > 500 {
+            return f, fieldError("limit", "must be between 1 and 500")
+        }
+        f.Limit = n
+    }
+    return f, nil
+}
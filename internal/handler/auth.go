@@ -3,6 +3,7 @@ package handler
 import (
     "context"             // provides context with cancellation for DB calls
     "database/sql"         // SQL database interactions
+    "log"                  // reports failed reset emails
     "net/http"             // HTTP status codes and primitives
     "strings"              // string manipulation utilities
     "time"                 // timeouts for DB calls
@@ -12,35 +13,56 @@ import (
     "github.com/golang-jwt/jwt/v5" // JSON Web Token library for parsing access tokens
     "github.com/labstack/echo/v4"  // Echo framework for HTTP routing
 
+    "github.com/iliyamo/cinema-seat-reservation/internal/apperr" // apperr builds error responses
     "github.com/iliyamo/cinema-seat-reservation/internal/config"    // app configuration
     "github.com/iliyamo/cinema-seat-reservation/internal/repository" // DB repositories
+    "github.com/iliyamo/cinema-seat-reservation/internal/service"    // mailer for password reset links
     "github.com/iliyamo/cinema-seat-reservation/internal/utils"      // helper functions (hashing, token issuing)
 )
 
 // AuthHandler bundles dependencies for auth endpoints.
 type AuthHandler struct {
 	Cfg    config.Config
+	Keys   *utils.KeySet // signs access tokens and verifies them on logout
 	Users  *repository.UserRepo
 	Tokens *repository.TokenRepo
+	// Mailer delivers password reset links.  When nil the forgot/reset
+	// password endpoints respond 501.
+	Mailer service.Mailer
+	// Guard throttles failed logins; nil disables lockouts.
+	Guard *service.LoginGuard
+	// BotCheck protects registration and login from bots; nil disables it.
+	BotCheck *service.BotGuard
 }
 
-func NewAuthHandler(cfg config.Config, u *repository.UserRepo, t *repository.TokenRepo) *AuthHandler {
-	return &AuthHandler{Cfg: cfg, Users: u, Tokens: t}
+func NewAuthHandler(cfg config.Config, keys *utils.KeySet, u *repository.UserRepo, t *repository.TokenRepo) *AuthHandler {
+	return &AuthHandler{Cfg: cfg, Keys: keys, Users: u, Tokens: t}
 }
 
 // ----- DTOs -----
 
 type registerReq struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
-	Role     string `json:"role"` // CUSTOMER | OWNER
+	Email    string `json:"email" validate:"required,email,max=255"`
+	Password string `json:"password" validate:"required,min=8,max=72"` // bcrypt ignores bytes past 72
+	Role     string `json:"role" validate:"oneof=CUSTOMER OWNER"`
+	// GuestToken optionally names a guest session whose holds move to
+	// the new account (see GuestSession).
+	GuestToken string `json:"guest_token"`
 }
 type loginReq struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Email      string `json:"email" validate:"required"`
+	Password   string `json:"password" validate:"required"`
+	GuestToken string `json:"guest_token"` // as in registerReq
 }
 type refreshReq struct {
-	RefreshToken string `json:"refresh_token"`
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+type forgotPasswordReq struct {
+	Email string `json:"email" validate:"required,email,max=255"`
+}
+type resetPasswordReq struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required,min=8,max=72"`
 }
 
 type tokenPart struct {
@@ -56,22 +78,25 @@ type authResp struct {
 	User    userPart  `json:"user"`
 	Access  tokenPart `json:"access"`
 	Refresh tokenPart `json:"refresh"`
+	// TransferredHolds counts the active guest holds moved to the account.
+	TransferredHolds int64 `json:"transferred_holds,omitempty"`
 }
 
 // Register: create user and return tokens immediately.
 func (h *AuthHandler) Register(c echo.Context) error {
 	var req registerReq
-	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid body"})
+	if err := bindValid(c, &req); err != nil {
+		return err
 	}
 	req.Email = strings.ToLower(strings.TrimSpace(req.Email))
-	if req.Email == "" || req.Password == "" {
-		return c.JSON(http.StatusBadRequest, echo.Map{"error": "email/password required"})
-	}
 	role := strings.ToUpper(strings.TrimSpace(req.Role))
-	if role != "OWNER" && role != "CUSTOMER" {
+	if role == "" {
 		role = "CUSTOMER"
 	}
+	guestID, err := h.guestID(req.GuestToken)
+	if err != nil {
+		return err
+	}
 
 	ctx, cancel := context.WithTimeout(c.Request().Context(), 5*time.Second)
 	defer cancel()
@@ -79,79 +104,127 @@ func (h *AuthHandler) Register(c echo.Context) error {
 	uid, err := h.Users.Create(ctx, req.Email, req.Password, role, h.Cfg.BcryptCost)
 	if err != nil {
 		if err == repository.ErrEmailExists {
-			return c.JSON(http.StatusConflict, echo.Map{"error": "email already exists"})
+			return apperr.Conflict(apperr.CodeAlreadyExists, "email already exists")
 		}
-		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "create user failed"})
+		return apperr.Internal("create user failed")
+	}
+	moved, err := h.adoptGuest(ctx, guestID, uid)
+	if err != nil {
+		return err
 	}
 
-	access, err := utils.NewAccessToken(h.Cfg.JWTSecret, uid, role, h.Cfg.AccessTTLMin)
+	access, err := utils.NewAccessToken(h.Keys, uid, role, h.Cfg.AccessTTLMin)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "issue access failed"})
+		return apperr.Internal("issue access failed")
 	}
 	refresh, err := utils.NewRefreshToken(h.Cfg.RefreshTTLDays)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "issue refresh failed"})
+		return apperr.Internal("issue refresh failed")
 	}
-	if err := h.Tokens.StoreRefresh(ctx, uid, utils.HashRefreshRaw(refresh.Raw), refresh.Exp); err != nil {
-		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "save refresh failed"})
+	if err := h.Tokens.StoreRefresh(ctx, uid, utils.HashRefreshRaw(refresh.Raw), refresh.Exp, sessionMeta(c)); err != nil {
+		return apperr.Internal("save refresh failed")
 	}
 
 	return c.JSON(http.StatusCreated, authResp{
-		User:    userPart{ID: uid, Email: req.Email, Role: role},
-		Access:  tokenPart{Token: access.Token, Expires: access.Exp},
-		Refresh: tokenPart{Token: refresh.Raw, Expires: refresh.Exp}, // raw back to client
+		User:             userPart{ID: uid, Email: req.Email, Role: role},
+		Access:           tokenPart{Token: access.Token, Expires: access.Exp},
+		Refresh:          tokenPart{Token: refresh.Raw, Expires: refresh.Exp}, // raw back to client
+		TransferredHolds: moved,
 	})
 }
 
 // Login: verify and return new pair.
 func (h *AuthHandler) Login(c echo.Context) error {
 	var req loginReq
-	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid body"})
+	if err := bindValid(c, &req); err != nil {
+		return err
 	}
 	req.Email = strings.ToLower(strings.TrimSpace(req.Email))
-	if req.Email == "" || req.Password == "" {
-		return c.JSON(http.StatusBadRequest, echo.Map{"error": "email/password required"})
+	guestID, err := h.guestID(req.GuestToken)
+	if err != nil {
+		return err
 	}
 
 	ctx, cancel := context.WithTimeout(c.Request().Context(), 5*time.Second)
 	defer cancel()
 
+	ip := c.RealIP()
+	if h.Guard != nil {
+		if err := h.Guard.Check(ctx, req.Email, ip); err != nil {
+			return loginLocked(c, err)
+		}
+	}
+
 	u, err := h.Users.GetByEmail(ctx, req.Email)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return c.JSON(http.StatusUnauthorized, echo.Map{"error": "invalid credentials"})
+			return h.loginFailed(ctx, c, req.Email, ip, 0)
 		}
-		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "query failed"})
+		return apperr.Internal("query failed")
 	}
 	if !utils.VerifyPassword(u.PasswordHash, req.Password) {
-		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "invalid credentials"})
+		return h.loginFailed(ctx, c, req.Email, ip, u.ID)
+	}
+	if h.Guard != nil {
+		h.Guard.Success(ctx, req.Email)
+	}
+	moved, err := h.adoptGuest(ctx, guestID, u.ID)
+	if err != nil {
+		return err
 	}
 
-	access, err := utils.NewAccessToken(h.Cfg.JWTSecret, u.ID, u.Role, h.Cfg.AccessTTLMin)
+	access, err := utils.NewAccessToken(h.Keys, u.ID, u.Role, h.Cfg.AccessTTLMin)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "issue access failed"})
+		return apperr.Internal("issue access failed")
 	}
 	refresh, err := utils.NewRefreshToken(h.Cfg.RefreshTTLDays)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "issue refresh failed"})
+		return apperr.Internal("issue refresh failed")
 	}
-	if err := h.Tokens.StoreRefresh(ctx, u.ID, utils.HashRefreshRaw(refresh.Raw), refresh.Exp); err != nil {
-		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "save refresh failed"})
+	if err := h.Tokens.StoreRefresh(ctx, u.ID, utils.HashRefreshRaw(refresh.Raw), refresh.Exp, sessionMeta(c)); err != nil {
+		return apperr.Internal("save refresh failed")
 	}
 
 	return c.JSON(http.StatusOK, authResp{
-		User:    userPart{ID: u.ID, Email: u.Email, Role: u.Role},
-		Access:  tokenPart{Token: access.Token, Expires: access.Exp},
-		Refresh: tokenPart{Token: refresh.Raw, Expires: refresh.Exp},
+		User:             userPart{ID: u.ID, Email: u.Email, Role: u.Role},
+		Access:           tokenPart{Token: access.Token, Expires: access.Exp},
+		Refresh:          tokenPart{Token: refresh.Raw, Expires: refresh.Exp},
+		TransferredHolds: moved,
 	})
 }
 
+// loginFailed records a failed login with the guard and returns the error
+// for it: 401, or the lockout response when this failure triggered one.
+func (h *AuthHandler) loginFailed(ctx context.Context, c echo.Context, email, ip string, userID uint64) error {
+	if h.Guard != nil {
+		if err := h.Guard.Failure(ctx, email, ip, userID); err != nil {
+			return loginLocked(c, err)
+		}
+	}
+	return apperr.Unauthorized("invalid credentials")
+}
+
+// loginLocked converts a *service.LoginLockedError into 423 (account) or
+// 429 (client address) with Retry-After.
+func loginLocked(c echo.Context, err error) error {
+	le, ok := err.(*service.LoginLockedError)
+	if !ok {
+		return apperr.Internal("login check failed")
+	}
+	retry := int64(le.RetryAfter/time.Second) + 1
+	c.Response().Header().Set("Retry-After", strconv.FormatInt(retry, 10))
+	details := echo.Map{"retry_after_sec": retry}
+	if le.Scope == repository.ThrottleIP {
+		return apperr.New(http.StatusTooManyRequests, apperr.CodeRateLimited, "too many failed logins from this address").WithDetails(details)
+	}
+	return apperr.New(http.StatusLocked, apperr.CodeAccountLocked, "account temporarily locked after repeated failed logins").WithDetails(details)
+}
+
 // Refresh: validate by hash, revoke old, issue new.
 func (h *AuthHandler) Refresh(c echo.Context) error {
 	var req refreshReq
-	if err := c.Bind(&req); err != nil || strings.TrimSpace(req.RefreshToken) == "" {
-		return c.JSON(http.StatusBadRequest, echo.Map{"error": "refresh_token required"})
+	if err := bindValid(c, &req); err != nil {
+		return err
 	}
 	raw := strings.TrimSpace(req.RefreshToken)
 	hash := utils.HashRefreshRaw(raw)
@@ -161,25 +234,25 @@ func (h *AuthHandler) Refresh(c echo.Context) error {
 
 	userID, err := h.Tokens.ValidateRefresh(ctx, hash)
 	if err != nil {
-		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "invalid refresh"})
+		return apperr.Unauthorized("invalid refresh")
 	}
 	_ = h.Tokens.RevokeByHash(ctx, hash)
 
 	u, err := h.Users.GetByID(ctx, userID)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "load user failed"})
+		return apperr.Internal("load user failed")
 	}
 
-	access, err := utils.NewAccessToken(h.Cfg.JWTSecret, userID, u.Role, h.Cfg.AccessTTLMin)
+	access, err := utils.NewAccessToken(h.Keys, userID, u.Role, h.Cfg.AccessTTLMin)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "issue access failed"})
+		return apperr.Internal("issue access failed")
 	}
 	newRef, err := utils.NewRefreshToken(h.Cfg.RefreshTTLDays)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "issue refresh failed"})
+		return apperr.Internal("issue refresh failed")
 	}
-	if err := h.Tokens.StoreRefresh(ctx, userID, utils.HashRefreshRaw(newRef.Raw), newRef.Exp); err != nil {
-		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "save refresh failed"})
+	if err := h.Tokens.StoreRefresh(ctx, userID, utils.HashRefreshRaw(newRef.Raw), newRef.Exp, sessionMeta(c)); err != nil {
+		return apperr.Internal("save refresh failed")
 	}
 
 	return c.JSON(http.StatusOK, authResp{
@@ -193,8 +266,8 @@ func (h *AuthHandler) Refresh(c echo.Context) error {
 // This endpoint can be used to obtain a fresh short-lived access token while reusing an existing refresh token.
 func (h *AuthHandler) RefreshAccess(c echo.Context) error {
     var req refreshReq
-    if err := c.Bind(&req); err != nil || strings.TrimSpace(req.RefreshToken) == "" {
-        return c.JSON(http.StatusBadRequest, echo.Map{"error": "refresh_token required"})
+    if err := bindValid(c, &req); err != nil {
+        return err
     }
     raw := strings.TrimSpace(req.RefreshToken)
     hash := utils.HashRefreshRaw(raw)
@@ -205,18 +278,19 @@ func (h *AuthHandler) RefreshAccess(c echo.Context) error {
     userID, err := h.Tokens.ValidateRefresh(ctx, hash)
     if err != nil {
         // Invalid, expired or revoked refresh token
-        return c.JSON(http.StatusUnauthorized, echo.Map{"error": "invalid refresh"})
+        return apperr.Unauthorized("invalid refresh")
     }
+    _ = h.Tokens.TouchRefresh(ctx, hash)
     u, err := h.Users.GetByID(ctx, userID)
     if err != nil {
         if err == sql.ErrNoRows {
-            return c.JSON(http.StatusUnauthorized, echo.Map{"error": "invalid refresh"})
+            return apperr.Unauthorized("invalid refresh")
         }
-        return c.JSON(http.StatusInternalServerError, echo.Map{"error": "load user failed"})
+        return apperr.Internal("load user failed")
     }
-    access, err := utils.NewAccessToken(h.Cfg.JWTSecret, userID, u.Role, h.Cfg.AccessTTLMin)
+    access, err := utils.NewAccessToken(h.Keys, userID, u.Role, h.Cfg.AccessTTLMin)
     if err != nil {
-        return c.JSON(http.StatusInternalServerError, echo.Map{"error": "issue access failed"})
+        return apperr.Internal("issue access failed")
     }
     // Only return a new access token; do not rotate the refresh token
     return c.JSON(http.StatusOK, echo.Map{
@@ -246,18 +320,9 @@ func (h *AuthHandler) Logout(c echo.Context) error {
     if strings.HasPrefix(authHeader, "Bearer ") {
         // Trim the "Bearer " prefix to get the raw token value.
         rawToken := strings.TrimPrefix(authHeader, "Bearer ")
-        // Parse the JWT using the configured secret.  Use the same signing
-        // method (HMAC) that was used when generating the token.  If the
-        // signature algorithm does not match, the parser will reject the
-        // token.  This callback is required by the jwt library.
-        tok, err := jwt.Parse(rawToken, func(t *jwt.Token) (interface{}, error) {
-            // Ensure the signing method is what we expect.  Reject tokens
-            // using different algorithms.
-            if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-                return nil, echo.ErrUnauthorized
-            }
-            return []byte(h.Cfg.JWTSecret), nil
-        })
+        // Parse the JWT against the configured key set, which rejects
+        // non-HMAC algorithms and unknown key IDs.
+        tok, err := h.Keys.Parse(rawToken)
         // If parsing succeeded and the token is valid, extract the `sub`
         // (subject) claim which contains the user ID and set the flag.
         if err == nil && tok.Valid {
@@ -299,12 +364,12 @@ func (h *AuthHandler) Logout(c echo.Context) error {
         // Ensure we extracted a non‑zero user ID; if not, treat as
         // unauthorized.
         if uid == 0 {
-            return c.JSON(http.StatusUnauthorized, echo.Map{"error": "unauthorized"})
+            return apperr.Unauthorized("unauthorized")
         }
         // Revoke all active tokens for the user.  Any error indicates a
         // server problem and will be reported as HTTP 500.
         if err := h.Tokens.RevokeAllForUser(ctx, uid); err != nil {
-            return c.JSON(http.StatusInternalServerError, echo.Map{"error": "logout failed"})
+            return apperr.Internal("logout failed")
         }
         // Indicate success with no content.
         return c.NoContent(http.StatusNoContent)
@@ -318,18 +383,156 @@ func (h *AuthHandler) Logout(c echo.Context) error {
         hash := utils.HashRefreshRaw(refreshToken)
         // Check that the hashed token exists and is not expired or revoked.
         if _, err := h.Tokens.ValidateRefresh(ctx, hash); err != nil {
-            return c.JSON(http.StatusUnauthorized, echo.Map{"error": "invalid refresh token"})
+            return apperr.Unauthorized("invalid refresh token")
         }
         // Mark the specific token as revoked.  On failure, report a 500 error.
         if err := h.Tokens.RevokeByHash(ctx, hash); err != nil {
-            return c.JSON(http.StatusInternalServerError, echo.Map{"error": "logout failed"})
+            return apperr.Internal("logout failed")
         }
         // Successfully logged out this session.
         return c.NoContent(http.StatusNoContent)
     }
     // If neither a bearer token nor a refresh token were provided, the client
     // has not supplied enough information to perform a logout operation.
-    return c.JSON(http.StatusBadRequest, echo.Map{"error": "provide Authorization header or refresh_token"})
+    return apperr.BadRequest("provide Authorization header or refresh_token")
+}
+
+// sessionMeta captures the client details stored with a new refresh token.
+func sessionMeta(c echo.Context) repository.SessionMeta {
+	ua := c.Request().UserAgent()
+	if len(ua) > 255 {
+		ua = ua[:255]
+	}
+	return repository.SessionMeta{UserAgent: ua, IP: c.RealIP()}
+}
+
+// ListSessions: active refresh tokens (signed-in devices) of the caller.
+func (h *AuthHandler) ListSessions(c echo.Context) error {
+	uid, err := getUserID(c)
+	if err != nil {
+		return apperr.Unauthorized("unauthorized")
+	}
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 5*time.Second)
+	defer cancel()
+
+	sessions, err := h.Tokens.ListSessions(ctx, uid)
+	if err != nil {
+		return apperr.Internal("list sessions failed")
+	}
+	return c.JSON(http.StatusOK, echo.Map{"sessions": sessions})
+}
+
+// RevokeSession: sign out one device by revoking its refresh token.  Its
+// access token remains valid until it expires.
+func (h *AuthHandler) RevokeSession(c echo.Context) error {
+	uid, err := getUserID(c)
+	if err != nil {
+		return apperr.Unauthorized("unauthorized")
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		return apperr.BadRequest("invalid session id")
+	}
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 5*time.Second)
+	defer cancel()
+
+	if err := h.Tokens.RevokeSession(ctx, uid, id); err != nil {
+		if err == sql.ErrNoRows {
+			return apperr.NotFound("session not found")
+		}
+		return apperr.Internal("revoke session failed")
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// maxResetsPerHour bounds how many reset emails one account can trigger.
+const maxResetsPerHour = 3
+
+// ForgotPassword: email a single-use reset link.  The response is the same
+// whether or not the address belongs to an account, so the endpoint cannot
+// be used to discover registered emails.
+func (h *AuthHandler) ForgotPassword(c echo.Context) error {
+	if h.Mailer == nil {
+		return apperr.NotImplemented("password reset is not configured")
+	}
+	var req forgotPasswordReq
+	if err := bindValid(c, &req); err != nil {
+		return err
+	}
+	email := strings.ToLower(strings.TrimSpace(req.Email))
+	accepted := echo.Map{"message": "if the address is registered, a reset link has been sent"}
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 5*time.Second)
+	defer cancel()
+
+	u, err := h.Users.GetByEmail(ctx, email)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return c.JSON(http.StatusAccepted, accepted)
+		}
+		return apperr.Internal("query failed")
+	}
+	if !u.IsActive {
+		return c.JSON(http.StatusAccepted, accepted)
+	}
+	n, err := h.Tokens.CountPasswordResetsSince(ctx, u.ID, time.Now().Add(-time.Hour))
+	if err != nil {
+		return apperr.Internal("query failed")
+	}
+	if n >= maxResetsPerHour {
+		return c.JSON(http.StatusAccepted, accepted)
+	}
+
+	tok, err := utils.NewResetToken(time.Duration(h.Cfg.ResetTTLMin) * time.Minute)
+	if err != nil {
+		return apperr.Internal("issue reset token failed")
+	}
+	if err := h.Tokens.StorePasswordReset(ctx, u.ID, utils.HashRefreshRaw(tok.Raw), tok.Exp); err != nil {
+		return apperr.Internal("save reset token failed")
+	}
+	// Send after responding so the response time does not reveal whether
+	// the account exists.
+	body := "Use the link below to choose a new password. It expires at " +
+		tok.Exp.Format(time.RFC1123) + " and can be used once.\n\n" + h.Cfg.ResetURL + tok.Raw +
+		"\n\nIf you did not ask for a password reset, you can ignore this email.\n"
+	go func(to string) {
+		sctx, scancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer scancel()
+		if err := h.Mailer.Send(sctx, to, "Reset your password", body); err != nil {
+			log.Printf("password reset mail for user %d failed: %v", u.ID, err)
+		}
+	}(u.Email)
+	return c.JSON(http.StatusAccepted, accepted)
+}
+
+// ResetPassword: redeem a reset token and set a new password.  All refresh
+// tokens of the account are revoked, signing out every session.
+func (h *AuthHandler) ResetPassword(c echo.Context) error {
+	if h.Mailer == nil {
+		return apperr.NotImplemented("password reset is not configured")
+	}
+	var req resetPasswordReq
+	if err := bindValid(c, &req); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 5*time.Second)
+	defer cancel()
+
+	uid, err := h.Tokens.ConsumePasswordReset(ctx, utils.HashRefreshRaw(strings.TrimSpace(req.Token)))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return apperr.BadRequest("invalid or expired reset token")
+		}
+		return apperr.Internal("reset failed")
+	}
+	if err := h.Users.UpdatePassword(ctx, uid, req.Password, h.Cfg.BcryptCost); err != nil {
+		return apperr.Internal("update password failed")
+	}
+	if err := h.Tokens.RevokeAllForUser(ctx, uid); err != nil {
+		return apperr.Internal("revoke sessions failed")
+	}
+	return c.NoContent(http.StatusNoContent)
 }
 
 // Me: simple protected endpoint.
@@ -0,0 +1,39 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/iliyamo/cinema-seat-reservation/internal/apperr"
+	"github.com/iliyamo/cinema-seat-reservation/internal/service"
+	"github.com/labstack/echo/v4"
+)
+
+// botCheckResp is the body of GET /v1/auth/bot-check.  SiteKey is set
+// for CAPTCHA providers, Challenge for proof of work.
+type botCheckResp struct {
+	Provider  string                `json:"provider"`
+	SiteKey   string                `json:"site_key,omitempty"`
+	Challenge *service.PoWChallenge `json:"challenge,omitempty"`
+}
+
+// BotCheckChallenge handles GET /v1/auth/bot-check.  It tells clients
+// how to pass the bot check: the CAPTCHA provider and its site key, or a
+// fresh proof-of-work challenge.  The solved token goes in the
+// X-Bot-Token header of the protected request.  It responds 404 when the
+// bot check is disabled.
+func (h *AuthHandler) BotCheckChallenge(c echo.Context) error {
+	g := h.BotCheck
+	if g == nil {
+		return apperr.NotFound("bot check is disabled")
+	}
+	resp := botCheckResp{Provider: g.Provider, SiteKey: g.SiteKey}
+	if g.PoW != nil {
+		ch, err := g.PoW.Challenge()
+		if err != nil {
+			return apperr.Internal("failed to issue challenge")
+		}
+		resp.Challenge = &ch
+	}
+	c.Response().Header().Set("Cache-Control", "no-store")
+	return c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+
+	"github.com/iliyamo/cinema-seat-reservation/internal/apperr"
+	"github.com/iliyamo/cinema-seat-reservation/internal/utils"
+)
+
+// guestRole is the role of guest session users.
+const guestRole = "GUEST"
+
+// guestResp is the body of POST /v1/auth/guest.
+type guestResp struct {
+	User   userPart  `json:"user"`
+	Access tokenPart `json:"access"`
+}
+
+// GuestSession handles POST /v1/auth/guest.  It starts an anonymous
+// guest session: a GUEST user and an access token for it that lives
+// GUEST_SESSION_TTL_MIN minutes and can only hold, extend and release
+// seats.  Passing the token as guest_token to register or login moves
+// the guest's holds to the account before they are confirmed.  There is
+// no refresh token.  It responds 501 when guest sessions are disabled.
+func (h *AuthHandler) GuestSession(c echo.Context) error {
+	if h.Cfg.GuestTTLMin <= 0 {
+		return apperr.NotImplemented("guest sessions are disabled")
+	}
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 5*time.Second)
+	defer cancel()
+
+	id, err := h.Users.CreateGuest(ctx)
+	if err != nil {
+		return apperr.Internal("create guest failed")
+	}
+	access, err := utils.NewAccessToken(h.Keys, id, guestRole, h.Cfg.GuestTTLMin)
+	if err != nil {
+		return apperr.Internal("issue access failed")
+	}
+	return c.JSON(http.StatusCreated, guestResp{
+		User:   userPart{ID: id, Role: guestRole},
+		Access: tokenPart{Token: access.Token, Expires: access.Exp},
+	})
+}
+
+// guestID returns the guest user of a guest session token.  An empty
+// token yields 0; a token that is not a valid, unexpired guest token
+// yields a 422 on the guest_token field.
+func (h *AuthHandler) guestID(raw string) (uint64, error) {
+	if raw == "" {
+		return 0, nil
+	}
+	invalid := fieldError("guest_token", "invalid or expired guest session")
+	tok, err := h.Keys.Parse(raw)
+	if err != nil || !tok.Valid {
+		return 0, invalid
+	}
+	claims, ok := tok.Claims.(jwt.MapClaims)
+	if !ok || claims["role"] != guestRole {
+		return 0, invalid
+	}
+	sub, ok := claims["sub"].(float64)
+	if !ok || sub <= 0 {
+		return 0, invalid
+	}
+	return uint64(sub), nil
+}
+
+// adoptGuest moves the holds of guest user guestID, if any, to userID
+// and returns how many active holds moved.
+func (h *AuthHandler) adoptGuest(ctx context.Context, guestID, userID uint64) (int64, error) {
+	if guestID == 0 {
+		return 0, nil
+	}
+	n, err := h.Users.AdoptGuest(ctx, guestID, userID)
+	if err != nil {
+		log.Printf("guest %d: moving holds to user %d failed: %v", guestID, userID, err)
+		return 0, apperr.Internal("transfer guest holds failed")
+	}
+	return n, nil
+}
@@ -0,0 +1,92 @@
+package handler_test
+
+import (
+	"database/sql/driver"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/iliyamo/cinema-seat-reservation/internal/apperr"
+	"github.com/iliyamo/cinema-seat-reservation/internal/config"
+	"github.com/iliyamo/cinema-seat-reservation/internal/handler"
+	"github.com/iliyamo/cinema-seat-reservation/internal/middleware"
+	"github.com/iliyamo/cinema-seat-reservation/internal/repository"
+	"github.com/iliyamo/cinema-seat-reservation/internal/repository/mock"
+	"github.com/iliyamo/cinema-seat-reservation/internal/service"
+)
+
+// lockedIP is the client address the login tests have locked out.
+const lockedIP = "192.0.2.10"
+
+// loginServer returns an echo instance serving POST /v1/auth/login whose
+// login throttle has lockedIP locked out and knows no accounts.
+func loginServer(t *testing.T, trusted []*net.IPNet) *echo.Echo {
+	t.Helper()
+	d := &mock.Driver{
+		QueryFn: func(query string, args []driver.NamedValue) ([]string, [][]driver.Value, error) {
+			if strings.Contains(query, "FROM login_throttles") && args[0].Value == repository.ThrottleIP && args[1].Value == lockedIP {
+				return []string{"failures", "lockouts", "locked_until"},
+					[][]driver.Value{{int64(20), int64(1), time.Now().Add(time.Hour)}}, nil
+			}
+			return nil, nil, nil
+		},
+	}
+	conn := d.DB()
+	t.Cleanup(func() { conn.Close() })
+	h := handler.NewAuthHandler(config.Config{}, nil, repository.NewUserRepo(conn), repository.NewTokenRepo(conn))
+	h.Guard = &service.LoginGuard{
+		Repo:               repository.NewLoginThrottleRepo(conn),
+		MaxAccountFailures: 5,
+		MaxIPFailures:      20,
+		FailureWindow:      15 * time.Minute,
+		LockoutBase:        time.Minute,
+		LockoutMax:         time.Hour,
+	}
+	e := echo.New()
+	e.HTTPErrorHandler = apperr.Handler
+	e.IPExtractor = middleware.ClientIP(trusted)
+	e.POST("/v1/auth/login", h.Login)
+	return e
+}
+
+func login(e *echo.Echo, remote, forwardedFor string) int {
+	req := httptest.NewRequest(http.MethodPost, "/v1/auth/login", strings.NewReader(`{"email":"a@example.com","password":"wrong-password"}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.RemoteAddr = remote + ":40000"
+	if forwardedFor != "" {
+		req.Header.Set(echo.HeaderXForwardedFor, forwardedFor)
+		req.Header.Set(echo.HeaderXRealIP, forwardedFor)
+	}
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec.Code
+}
+
+func TestLoginLockoutIgnoresSpoofedForwardedFor(t *testing.T) {
+	e := loginServer(t, nil)
+	if code := login(e, lockedIP, ""); code != http.StatusTooManyRequests {
+		t.Fatalf("locked address: status %d, want 429", code)
+	}
+	if code := login(e, lockedIP, "203.0.113.7"); code != http.StatusTooManyRequests {
+		t.Fatalf("locked address with spoofed X-Forwarded-For: status %d, want 429", code)
+	}
+}
+
+func TestLoginLockoutTrustedProxy(t *testing.T) {
+	_, proxies, _ := net.ParseCIDR("10.0.0.0/8")
+	e := loginServer(t, []*net.IPNet{proxies})
+	if code := login(e, "10.1.2.3", "203.0.113.7, "+lockedIP); code != http.StatusTooManyRequests {
+		t.Fatalf("locked address via trusted proxy: status %d, want 429", code)
+	}
+	if code := login(e, lockedIP, "203.0.113.7"); code != http.StatusTooManyRequests {
+		t.Fatalf("locked address with spoofed X-Forwarded-For: status %d, want 429", code)
+	}
+	if code := login(e, "198.51.100.4", lockedIP); code != http.StatusUnauthorized {
+		t.Fatalf("X-Forwarded-For from an untrusted peer: status %d, want 401", code)
+	}
+}
@@ -0,0 +1,113 @@
+package handler
+
+// This file exposes paid checkout.  POST /v1/shows/:id/checkout turns the
+// caller's holds into a PENDING reservation and hands it to the booking
+// saga, which charges the payment provider and confirms the reservation,
+// or voids the charge and frees the seats when a step fails.  The saga is
+// persisted, so its outcome can be polled at GET /v1/booking-sagas/:id.
+
+import (
+    "database/sql"
+    "errors"
+    "log"
+    "net/http"
+    "strconv"
+
+    "github.com/iliyamo/cinema-seat-reservation/internal/apperr"
+    "github.com/iliyamo/cinema-seat-reservation/internal/metrics"
+    "github.com/iliyamo/cinema-seat-reservation/internal/repository"
+    "github.com/iliyamo/cinema-seat-reservation/internal/service"
+    "github.com/labstack/echo/v4"
+)
+
+// Checkout handles POST /v1/shows/:id/checkout.  It accepts the same body
+// as ConfirmSeats.  The response is 201 with the saga when the reservation
+// was confirmed, 402 PAYMENT_FAILED when the payment was declined and 409
+// when the saga compensated for another reason; in both failure cases the
+// seats are free again.  When the payment outcome is unknown it responds
+// 202 with the unfinished saga, which the recovery worker completes.
+func (h *CustomerHandler) Checkout(c echo.Context) error {
+    if h.Saga == nil {
+        return apperr.NotImplemented("checkout is not enabled")
+    }
+    userID, err := getUserID(c)
+    if err != nil {
+        return apperr.Unauthorized("unauthorized")
+    }
+    showID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+    if err != nil || showID == 0 {
+        return apperr.BadRequest("invalid show id")
+    }
+    ctx := c.Request().Context()
+    show, err := h.ShowRepo.GetByID(ctx, showID)
+    if err != nil {
+        if err == repository.ErrShowNotFound {
+            return apperr.NotFound("show not found")
+        }
+        return apperr.Internal("database error")
+    }
+    var body confirmSeatsReq
+    if err := bindValid(c, &body); err != nil {
+        return err
+    }
+    req := body.reserveRequest(userID, showID, "PENDING")
+    if req.PromoCode != "" && h.Booking.PromoCodeRepo == nil {
+        return apperr.BadRequest("promo codes are not supported")
+    }
+    var expiredCount int
+    saga, err := h.Saga.Start(ctx, userID, showID, func(tx *sql.Tx) (uint64, uint32, string, error) {
+        resRec, expired, err := h.Booking.ReserveTx(ctx, tx, req)
+        if err != nil {
+            return 0, 0, "", bookingError(err)
+        }
+        expiredCount = expired
+        return resRec.ID, resRec.TotalAmountCents, resRec.Currency, nil
+    })
+    if saga == nil {
+        return txError(c, err)
+    }
+    metrics.HoldsExpired.Add(float64(expiredCount), "request")
+    if h.Audit != nil {
+        recordAudit(c, h.Audit, auditEvent("checkout.start", "booking_saga", saga.ID, hallOwnerID(ctx, h.HallRepo, show.HallID)), nil, saga)
+    }
+    if err != nil {
+        log.Printf("booking saga %d left in state %s: %v", saga.ID, saga.State, err)
+        return c.JSON(http.StatusAccepted, saga)
+    }
+    if saga.State == repository.SagaConfirmed {
+        return c.JSON(http.StatusCreated, saga)
+    }
+    if saga.LastError != nil && *saga.LastError == service.ErrPaymentDeclined.Error() {
+        return apperr.New(http.StatusPaymentRequired, apperr.CodePaymentFailed, "payment was declined").
+            WithDetails(echo.Map{"saga_id": saga.ID})
+    }
+    reason := "checkout could not be completed"
+    if saga.LastError != nil {
+        reason = *saga.LastError
+    }
+    return apperr.Conflict(apperr.CodeConflict, reason).WithDetails(echo.Map{"saga_id": saga.ID})
+}
+
+// GetBookingSaga handles GET /v1/booking-sagas/:id and returns one of the
+// caller's sagas.
+func (h *CustomerHandler) GetBookingSaga(c echo.Context) error {
+    if h.Saga == nil {
+        return apperr.NotImplemented("checkout is not enabled")
+    }
+    userID, err := getUserID(c)
+    if err != nil {
+        return apperr.Unauthorized("unauthorized")
+    }
+    id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+    if err != nil || id == 0 {
+        return apperr.BadRequest("invalid saga id")
+    }
+    saga, err := h.Saga.SagaRepo.GetByIDForUser(c.Request().Context(), id, userID)
+    if err != nil {
+        if errors.Is(err, repository.ErrSagaNotFound) {
+            return apperr.NotFound("booking saga not found")
+        }
+        return apperr.Internal("failed to load booking saga")
+    }
+    return c.JSON(http.StatusOK, saga)
+}
@@ -0,0 +1,199 @@
+package handler
+
+// This file defines HTTP handlers for show bundles.  Owners create bundles
+// of shows sold together at a discount; anyone can view a bundle; customers
+// purchase a bundle through a checkout that reserves seats in every show
+// atomically (see service.BundleService).
+
+import (
+    "errors"
+    "net/http"
+    "strconv"
+    "strings"
+
+    "github.com/iliyamo/cinema-seat-reservation/internal/apperr"
+    "github.com/iliyamo/cinema-seat-reservation/internal/db"
+    "github.com/iliyamo/cinema-seat-reservation/internal/metrics"
+    "github.com/iliyamo/cinema-seat-reservation/internal/money"
+    "github.com/iliyamo/cinema-seat-reservation/internal/repository"
+    "github.com/iliyamo/cinema-seat-reservation/internal/service"
+    "github.com/labstack/echo/v4"
+)
+
+// BundleHandler groups the dependencies for bundle endpoints.
+type BundleHandler struct {
+    BundleRepo *repository.BundleRepo // access to bundles and bundle_shows
+    Service    *service.BundleService // coordinates multi-show checkout
+    Audit      *repository.AuditRepo  // optional; records new bundles in the audit log
+}
+
+// NewBundleHandler constructs a BundleHandler.  All dependencies must be non-nil.
+func NewBundleHandler(bundleRepo *repository.BundleRepo, svc *service.BundleService) *BundleHandler {
+    if bundleRepo == nil || svc == nil {
+        panic("nil dependency passed to NewBundleHandler")
+    }
+    return &BundleHandler{BundleRepo: bundleRepo, Service: svc}
+}
+
+// CreateBundle handles POST /v1/owner/bundles.  The body names the bundle,
+// lists at least two show IDs owned by the caller and an optional
+// discount_percent (0-100) applied to each show's seat prices.
+func (h *BundleHandler) CreateBundle(c echo.Context) error {
+    ownerID, err := getUserID(c)
+    if err != nil {
+        return apperr.Unauthorized("unauthorized")
+    }
+    var body struct {
+        Name            string   `json:"name" validate:"required,max=255"`
+        ShowIDs         []uint64 `json:"show_ids" validate:"required"`
+        DiscountPercent uint8    `json:"discount_percent" validate:"max=100"`
+    }
+    if err := bindValid(c, &body); err != nil {
+        return err
+    }
+    name := strings.TrimSpace(body.Name)
+    showIDs := make([]uint64, 0, len(body.ShowIDs))
+    seen := make(map[uint64]struct{})
+    for _, id := range body.ShowIDs {
+        if id == 0 {
+            continue
+        }
+        if _, ok := seen[id]; !ok {
+            seen[id] = struct{}{}
+            showIDs = append(showIDs, id)
+        }
+    }
+    if len(showIDs) < 2 {
+        return fieldError("show_ids", "must list at least two distinct shows")
+    }
+    ctx := c.Request().Context()
+    owned, err := h.BundleRepo.CountOwnedShows(ctx, ownerID, showIDs)
+    if err != nil {
+        return apperr.Internal("database error")
+    }
+    if owned != len(showIDs) {
+        return apperr.Forbidden("all shows must belong to your halls")
+    }
+    bundle := &repository.Bundle{
+        OwnerID:         ownerID,
+        Name:            name,
+        DiscountPercent: body.DiscountPercent,
+        IsActive:        true,
+        ShowIDs:         showIDs,
+    }
+    if err := h.BundleRepo.Create(ctx, bundle); err != nil {
+        return apperr.Internal("failed to create bundle")
+    }
+    recordAudit(c, h.Audit, auditEvent("bundle.create", "bundle", bundle.ID, ownerID), nil, bundle)
+    return c.JSON(http.StatusCreated, bundle)
+}
+
+// ListOwnerBundles handles GET /v1/owner/bundles.
+func (h *BundleHandler) ListOwnerBundles(c echo.Context) error {
+    ownerID, err := getUserID(c)
+    if err != nil {
+        return apperr.Unauthorized("unauthorized")
+    }
+    items, err := h.BundleRepo.ListByOwner(c.Request().Context(), ownerID)
+    if err != nil {
+        return apperr.Internal("failed to load bundles")
+    }
+    return c.JSON(http.StatusOK, echo.Map{
+        "items": items,
+        "count": len(items),
+    })
+}
+
+// GetBundle handles GET /v1/bundles/:id and returns an active bundle with
+// its show IDs.  Inactive bundles are reported as not found.
+func (h *BundleHandler) GetBundle(c echo.Context) error {
+    id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+    if err != nil || id == 0 {
+        return apperr.BadRequest("invalid bundle id")
+    }
+    b, err := h.BundleRepo.GetByID(c.Request().Context(), id)
+    if err != nil {
+        if errors.Is(err, repository.ErrBundleNotFound) {
+            return apperr.NotFound("bundle not found")
+        }
+        return apperr.Internal("database error")
+    }
+    if !b.IsActive {
+        return apperr.NotFound("bundle not found")
+    }
+    return c.JSON(http.StatusOK, echo.Map{
+        "id":               b.ID,
+        "name":             b.Name,
+        "discount_percent": b.DiscountPercent,
+        "show_ids":         b.ShowIDs,
+    })
+}
+
+// CheckoutBundle handles POST /v1/bundles/:id/checkout.  The body lists the
+// seats to reserve in each show of the bundle:
+//
+//     {"selections": [{"show_id": 1, "seat_ids": [10, 11]}, ...]}
+//
+// Every show must be covered.  Seats are reserved in all shows or in none;
+// when any seat is unavailable the response is 409 naming the show and seats.
+func (h *BundleHandler) CheckoutBundle(c echo.Context) error {
+    userID, err := getUserID(c)
+    if err != nil {
+        return apperr.Unauthorized("unauthorized")
+    }
+    bundleID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+    if err != nil || bundleID == 0 {
+        return apperr.BadRequest("invalid bundle id")
+    }
+    var body struct {
+        Selections []struct {
+            ShowID  uint64   `json:"show_id" validate:"required"`
+            SeatIDs []uint64 `json:"seat_ids" validate:"required"`
+        } `json:"selections" validate:"required"`
+    }
+    if err := bindValid(c, &body); err != nil {
+        return err
+    }
+    selection := make(map[uint64][]uint64, len(body.Selections))
+    for _, sel := range body.Selections {
+        selection[sel.ShowID] = append(selection[sel.ShowID], sel.SeatIDs...)
+    }
+    items, err := h.Service.Checkout(c.Request().Context(), userID, bundleID, selection)
+    if err != nil {
+        var unavailable *service.SeatsUnavailableError
+        var notBookable *service.ShowNotBookableError
+        var currency *service.CurrencyMismatchError
+        switch {
+        case errors.Is(err, repository.ErrBundleNotFound), errors.Is(err, service.ErrBundleInactive):
+            return apperr.NotFound("bundle not found")
+        case errors.Is(err, service.ErrBundleSelection):
+            return apperr.BadRequest(err.Error())
+        case errors.As(err, &notBookable):
+            return apperr.Conflict(apperr.CodeShowNotBookable, "show is not bookable").
+                WithDetails(echo.Map{"show_id": notBookable.ShowID})
+        case errors.As(err, &unavailable):
+            metrics.SeatConflicts.Inc("bundle")
+            return apperr.Conflict(apperr.CodeSeatUnavailable, "some seats are unavailable").
+                WithDetails(echo.Map{"show_id": unavailable.ShowID, "unavailable": unavailable.SeatIDs})
+        case errors.As(err, &currency):
+            return currencyMismatch(currency)
+        }
+        if db.IsTransient(err) {
+            return txError(c, err)
+        }
+        return apperr.Internal("failed to checkout bundle").Wrap(err)
+    }
+    metrics.ReservationsConfirmed.Add(float64(len(items)), "bundle")
+    total := uint32(0)
+    currency := money.Default()
+    for _, it := range items {
+        total += it.TotalAmountCents
+        currency = it.Currency // the same for every show
+    }
+    return c.JSON(http.StatusCreated, echo.Map{
+        "bundle_id":          bundleID,
+        "reservations":       items,
+        "total_amount_cents": total,
+        "currency":           currency,
+    })
+}
@@ -0,0 +1,91 @@
+package handler
+
+// This file exposes the customer's checkout session for a show.  Holds are
+// keyed to the user rather than to a device, so a session started on one
+// device (POST /v1/shows/:id/hold) can be resumed on another by fetching
+// GET /v1/checkout-session/:show_id with the same account.
+
+import (
+    "net/http"
+    "strconv"
+    "time"
+
+    "github.com/iliyamo/cinema-seat-reservation/internal/apperr"
+    "github.com/iliyamo/cinema-seat-reservation/internal/money"
+    "github.com/iliyamo/cinema-seat-reservation/internal/repository"
+    "github.com/labstack/echo/v4"
+)
+
+// CheckoutSession summarises a customer's active holds for one show: the
+// selected seats, their hold tokens, the prices quoted when they were held
+// in their currency and when the earliest hold expires.
+type CheckoutSession struct {
+    ShowID           uint64            `json:"show_id"`
+    SeatIDs          []uint64          `json:"seat_ids"`
+    HoldTokens       map[uint64]string `json:"hold_tokens"`
+    Prices           map[uint64]uint32 `json:"prices"`
+    QuotedTotalCents uint32            `json:"quoted_total_cents"`
+    Currency         string            `json:"currency"`
+    ExpiresAt        string            `json:"expires_at"`
+    ExpiresInSec     int64             `json:"expires_in_sec"`
+}
+
+// newCheckoutSession builds a session from active hold records.  It
+// returns nil when holds is empty.  Holds created before prices were
+// captured contribute no price; without a captured currency the default
+// currency is reported.
+func newCheckoutSession(showID uint64, holds []repository.SeatHoldRecord, now time.Time) *CheckoutSession {
+    if len(holds) == 0 {
+        return nil
+    }
+    sess := &CheckoutSession{
+        ShowID:  showID,
+        SeatIDs:    make([]uint64, 0, len(holds)),
+        HoldTokens: make(map[uint64]string, len(holds)),
+        Prices:     make(map[uint64]uint32, len(holds)),
+        Currency:   money.Default(),
+    }
+    expiresAt := holds[0].ExpiresAt
+    for _, hld := range holds {
+        sess.SeatIDs = append(sess.SeatIDs, hld.SeatID)
+        sess.HoldTokens[hld.SeatID] = hld.HoldToken
+        if hld.PriceCents != nil {
+            sess.Prices[hld.SeatID] = *hld.PriceCents
+            sess.QuotedTotalCents += *hld.PriceCents
+        }
+        if hld.Currency != nil {
+            sess.Currency = *hld.Currency
+        }
+        if hld.ExpiresAt.Before(expiresAt) {
+            expiresAt = hld.ExpiresAt
+        }
+    }
+    sess.ExpiresAt = expiresAt.UTC().Format(time.RFC3339)
+    if remaining := int64(expiresAt.Sub(now) / time.Second); remaining > 0 {
+        sess.ExpiresInSec = remaining
+    }
+    return sess
+}
+
+// GetCheckoutSession handles GET /v1/checkout-session/:show_id.  It returns
+// the caller's active checkout session for the show, or 404 when the user
+// holds no seats (or every hold has expired).
+func (h *CustomerHandler) GetCheckoutSession(c echo.Context) error {
+    userID, err := getUserID(c)
+    if err != nil {
+        return apperr.Unauthorized("unauthorized")
+    }
+    showID, err := strconv.ParseUint(c.Param("show_id"), 10, 64)
+    if err != nil || showID == 0 {
+        return apperr.BadRequest("invalid show id")
+    }
+    holds, err := h.SeatHoldRepo.ActiveHoldsByUserAndShow(c.Request().Context(), userID, showID)
+    if err != nil {
+        return apperr.Internal("database error")
+    }
+    sess := newCheckoutSession(showID, holds, time.Now().UTC())
+    if sess == nil {
+        return apperr.NotFound("no active checkout session for this show")
+    }
+    return c.JSON(http.StatusOK, sess)
+}
@@ -0,0 +1,90 @@
+package handler
+
+// This file implements group booking: the server picks and holds N seats
+// for the caller, optionally guaranteeing they sit next to each other in
+// one row.  Holds are placed with service.BookingService.Hold, the same
+// locking path as POST /v1/shows/:id/hold, so either every chosen seat is
+// held or none is.
+
+import (
+    "errors"
+    "net/http"
+    "strconv"
+
+    "github.com/iliyamo/cinema-seat-reservation/internal/apperr"
+    "github.com/iliyamo/cinema-seat-reservation/internal/repository"
+    "github.com/iliyamo/cinema-seat-reservation/internal/service"
+    "github.com/labstack/echo/v4"
+)
+
+const (
+    maxAutoHoldSeats = 20 // largest group AutoHoldSeats accepts
+    autoHoldAttempts = 3  // picks tried when concurrent requests take the chosen seats
+)
+
+// AutoHoldSeats handles POST /v1/shows/:id/hold/auto?count=N&adjacent=true.
+// It picks count FREE seats and holds them all, or none.  With adjacent
+// (the default) the seats are consecutive active seats of a single row;
+// otherwise they may be spread out.  Seats are chosen by
+// service.PickSeats.  The response matches
+// POST /v1/shows/:id/hold.  When no suitable seats exist it responds 409
+// SEAT_UNAVAILABLE.
+func (h *CustomerHandler) AutoHoldSeats(c echo.Context) error {
+    userID, err := getUserID(c)
+    if err != nil {
+        return apperr.Unauthorized("unauthorized")
+    }
+    showID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+    if err != nil || showID == 0 {
+        return apperr.BadRequest("invalid show id")
+    }
+    count, err := strconv.Atoi(c.QueryParam("count"))
+    if err != nil || count < 1 || count > maxAutoHoldSeats {
+        return fieldError("count", "must be between 1 and "+strconv.Itoa(maxAutoHoldSeats))
+    }
+    adjacent := true
+    if s := c.QueryParam("adjacent"); s != "" {
+        if adjacent, err = strconv.ParseBool(s); err != nil {
+            return fieldError("adjacent", "must be true or false")
+        }
+    }
+    ctx := c.Request().Context()
+    show, err := h.ShowRepo.GetByID(ctx, showID)
+    if err != nil {
+        if err == repository.ErrShowNotFound {
+            return apperr.NotFound("show not found")
+        }
+        return apperr.Internal("database error")
+    }
+    layout, err := h.SeatRepo.GetByHall(ctx, show.HallID)
+    if err != nil {
+        return apperr.Internal("failed to load seats")
+    }
+    var res *service.HoldResult
+    for attempt := 1; ; attempt++ {
+        seats, err := h.ShowSeatRepo.ListWithStatus(ctx, showID)
+        if err != nil {
+            return apperr.Internal("failed to load seat availability")
+        }
+        pick := service.PickSeats(layout, seats, count, adjacent)
+        if pick == nil {
+            msg := "not enough free seats"
+            if adjacent {
+                msg = "no " + strconv.Itoa(count) + " adjacent free seats in one row"
+            }
+            return apperr.Conflict(apperr.CodeSeatUnavailable, msg)
+        }
+        res, err = h.Booking.Hold(ctx, userID, show, pick, false)
+        if err == nil {
+            break
+        }
+        // Another request took one of the chosen seats between reading
+        // availability and locking them; pick again from fresh data.
+        var unavailable *service.SeatsUnavailableError
+        if attempt < autoHoldAttempts && errors.As(err, &unavailable) {
+            continue
+        }
+        return txError(c, bookingError(err))
+    }
+    return c.JSON(http.StatusCreated, holdResponse(showID, res))
+}
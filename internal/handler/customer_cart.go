@@ -0,0 +1,244 @@
+package handler
+
+// This file implements the multi-show cart.  Holds are placed per show
+// with the usual hold endpoints; the cart is simply every active hold of
+// the customer.  POST /v1/cart/checkout confirms the holds of several
+// shows in one transaction, producing one reservation per show that share
+// a payment_ref, so the whole cart is booked or nothing is.
+
+import (
+    "crypto/rand"
+    "database/sql"
+    "encoding/hex"
+    "errors"
+    "net/http"
+    "sort"
+    "time"
+
+    "github.com/iliyamo/cinema-seat-reservation/internal/apperr"
+    "github.com/iliyamo/cinema-seat-reservation/internal/db"
+    "github.com/iliyamo/cinema-seat-reservation/internal/metrics"
+    "github.com/iliyamo/cinema-seat-reservation/internal/money"
+    "github.com/iliyamo/cinema-seat-reservation/internal/repository"
+    "github.com/iliyamo/cinema-seat-reservation/internal/service"
+    "github.com/labstack/echo/v4"
+)
+
+// cartCheckoutReq is the body of POST /v1/cart/checkout.  Without items
+// every show the caller holds seats for is checked out.
+type cartCheckoutReq struct {
+    Items             []cartItemReq `json:"items"`
+    AcceptPriceChange bool          `json:"accept_price_change"`
+}
+
+// cartItemReq selects one show of the cart; HoldTokens narrows it to
+// those holds as in POST /v1/shows/:id/confirm.
+type cartItemReq struct {
+    ShowID     uint64   `json:"show_id" validate:"required"`
+    HoldTokens []string `json:"hold_tokens"`
+}
+
+// cartReservation is one reservation created by a cart checkout.
+type cartReservation struct {
+    ReservationID    uint64 `json:"reservation_id"`
+    ShowID           uint64 `json:"show_id"`
+    SubtotalCents    uint32 `json:"subtotal_cents"`
+    DiscountCents    uint32 `json:"discount_cents"`
+    FeesCents        uint32 `json:"fees_cents"`
+    TaxCents         uint32 `json:"tax_cents"`
+    TotalAmountCents uint32 `json:"total_amount_cents"`
+    Currency         string `json:"currency"`
+}
+
+// GetCart handles GET /v1/cart.  It returns one checkout session per show
+// the caller holds seats for, and their combined quoted total and its
+// currency.  Both are null when the shows are priced in different
+// currencies, which cannot be checked out together.
+func (h *CustomerHandler) GetCart(c echo.Context) error {
+    userID, err := getUserID(c)
+    if err != nil {
+        return apperr.Unauthorized("unauthorized")
+    }
+    holds, err := h.SeatHoldRepo.ActiveHoldsByUser(c.Request().Context(), userID)
+    if err != nil {
+        return apperr.Internal("database error")
+    }
+    items := checkoutSessionsByShow(holds, time.Now().UTC())
+    var total interface{}
+    var currency interface{}
+    if sum, cur, ok := cartTotal(items); ok {
+        total, currency = sum, cur
+    }
+    return c.JSON(http.StatusOK, echo.Map{
+        "items":              items,
+        "quoted_total_cents": total,
+        "currency":           currency,
+    })
+}
+
+// checkoutSessionsByShow groups holds ordered by show into one checkout
+// session per show.
+func checkoutSessionsByShow(holds []repository.SeatHoldRecord, now time.Time) []*CheckoutSession {
+    items := make([]*CheckoutSession, 0)
+    for start := 0; start < len(holds); {
+        end := start
+        for end < len(holds) && holds[end].ShowID == holds[start].ShowID {
+            end++
+        }
+        items = append(items, newCheckoutSession(holds[start].ShowID, holds[start:end], now))
+        start = end
+    }
+    return items
+}
+
+// cartTotal returns the combined quoted total of sessions and its
+// currency; ok is false when the sessions are in different currencies.
+// An empty cart totals zero in the default currency.
+func cartTotal(sessions []*CheckoutSession) (total uint32, currency string, ok bool) {
+    currency = money.Default()
+    for i, sess := range sessions {
+        if i > 0 && sess.Currency != currency {
+            return 0, "", false
+        }
+        currency = sess.Currency
+        total += sess.QuotedTotalCents
+    }
+    return total, currency, true
+}
+
+// CheckoutCart handles POST /v1/cart/checkout.  Each selected show is
+// confirmed exactly like POST /v1/shows/:id/confirm (promo codes are not
+// accepted), all inside one transaction: when any show fails, for example
+// because a hold expired or a price changed, nothing is booked and the
+// error carries the show_id.  All shows must be priced in one currency
+// (409 CURRENCY_MISMATCH otherwise).  Shows are processed in ascending ID order
+// so concurrent checkouts lock seats in a consistent order.  On success
+// it responds 201 with the reservations and their shared payment_ref.
+func (h *CustomerHandler) CheckoutCart(c echo.Context) error {
+    userID, err := getUserID(c)
+    if err != nil {
+        return apperr.Unauthorized("unauthorized")
+    }
+    var body cartCheckoutReq
+    if err := bindValid(c, &body); err != nil {
+        return err
+    }
+    ctx := c.Request().Context()
+    tokens := make(map[uint64][]string, len(body.Items))
+    for _, it := range body.Items {
+        if _, dup := tokens[it.ShowID]; dup {
+            return fieldError("items", "lists a show more than once")
+        }
+        tokens[it.ShowID] = it.HoldTokens
+    }
+    if len(tokens) == 0 {
+        holds, err := h.SeatHoldRepo.ActiveHoldsByUser(ctx, userID)
+        if err != nil {
+            return apperr.Internal("database error")
+        }
+        for _, hld := range holds {
+            tokens[hld.ShowID] = nil
+        }
+    }
+    if len(tokens) == 0 {
+        return apperr.New(http.StatusBadRequest, apperr.CodeNoActiveHolds, "the cart is empty")
+    }
+    showIDs := make([]uint64, 0, len(tokens))
+    for id := range tokens {
+        showIDs = append(showIDs, id)
+    }
+    sort.Slice(showIDs, func(i, j int) bool { return showIDs[i] < showIDs[j] })
+    paymentRef, err := newCartRef()
+    if err != nil {
+        return apperr.Internal("failed to generate payment reference")
+    }
+    var (
+        items        []cartReservation
+        expiredCount int
+    )
+    err = db.WithTx(ctx, h.ShowRepo.DB(), func(tx *sql.Tx) error {
+        items, expiredCount = items[:0], 0
+        for _, showID := range showIDs {
+            resRec, expired, err := h.Booking.ReserveTx(ctx, tx, service.ReserveRequest{
+                UserID:            userID,
+                ShowID:            showID,
+                HoldTokens:        tokens[showID],
+                AcceptPriceChange: body.AcceptPriceChange,
+                Status:            "CONFIRMED",
+            })
+            if err != nil {
+                return cartItemError(bookingError(err), showID)
+            }
+            if err := h.ReservationRepo.SetPaymentRefTx(ctx, tx, resRec.ID, paymentRef); err != nil {
+                return failTx("failed to record payment reference", err)
+            }
+            if len(items) > 0 && items[0].Currency != resRec.Currency {
+                return cartItemError(currencyMismatch(&service.CurrencyMismatchError{Quoted: items[0].Currency, Current: resRec.Currency}), showID)
+            }
+            expiredCount += expired
+            items = append(items, cartReservation{
+                ReservationID:    resRec.ID,
+                ShowID:           showID,
+                SubtotalCents:    resRec.SubtotalCents,
+                DiscountCents:    resRec.DiscountCents,
+                FeesCents:        resRec.FeesCents,
+                TaxCents:         resRec.TaxCents,
+                TotalAmountCents: resRec.TotalAmountCents,
+                Currency:         resRec.Currency,
+            })
+        }
+        return nil
+    })
+    if err != nil {
+        return txError(c, err)
+    }
+    metrics.HoldsExpired.Add(float64(expiredCount), "request")
+    metrics.ReservationsConfirmed.Add(float64(len(items)), "cart")
+    total := uint32(0)
+    for _, it := range items {
+        total += it.TotalAmountCents
+        if h.Audit != nil {
+            after, _ := h.ReservationRepo.GetByIDForUser(ctx, it.ReservationID, userID)
+            ownerID := uint64(0)
+            if after != nil {
+                ownerID = hallOwnerID(ctx, h.HallRepo, after.HallID)
+            }
+            recordAudit(c, h.Audit, auditEvent("reservation.create", "reservation", it.ReservationID, ownerID), nil, after)
+        }
+    }
+    return c.JSON(http.StatusCreated, echo.Map{
+        "payment_ref":        paymentRef,
+        "reservations":       items,
+        "total_amount_cents": total,
+        "currency":           items[0].Currency,
+    })
+}
+
+// cartItemError adds the failing show to a client error from one show of
+// a cart checkout.  Server errors are returned unchanged so db.WithTx
+// still sees transient causes.
+func cartItemError(err error, showID uint64) error {
+    var ae *apperr.Error
+    if !errors.As(err, &ae) || ae.Status >= http.StatusInternalServerError {
+        return err
+    }
+    details := echo.Map{"show_id": showID}
+    if m, ok := ae.Details.(echo.Map); ok {
+        for k, v := range m {
+            details[k] = v
+        }
+    }
+    out := *ae
+    out.Details = details
+    return &out
+}
+
+// newCartRef returns a random payment reference shared by the
+// reservations of one cart checkout.
+func newCartRef() (string, error) {
+    b := make([]byte, 12)
+    if _, err := rand.Read(b); err != nil {
+        return "", err
+    }
+    return "cart_" + hex.EncodeToString(b), nil
+}
@@ -0,0 +1,110 @@
+package handler
+
+// This file lets customers follow cinemas and movies.  Movies have no
+// table of their own, so a movie is followed by its title as shows carry
+// it.  The show announcer (service.ShowAnnouncer) notifies followers when
+// a new show is scheduled in a followed cinema or for a followed movie.
+
+import (
+    "errors"
+    "net/http"
+    "strconv"
+    "strings"
+
+    "github.com/iliyamo/cinema-seat-reservation/internal/apperr"
+    "github.com/iliyamo/cinema-seat-reservation/internal/repository"
+    "github.com/labstack/echo/v4"
+)
+
+// addFavoriteReq is the body of POST /v1/me/favorites.  Exactly one of
+// CinemaID and MovieTitle must be set.
+type addFavoriteReq struct {
+    CinemaID   *uint64 `json:"cinema_id"`
+    MovieTitle *string `json:"movie_title" validate:"max=255"`
+}
+
+// AddFavorite handles POST /v1/me/favorites.  It follows a cinema or a
+// movie title and responds 201 with the favourite, 404 for an unknown
+// cinema and 409 ALREADY_EXISTS when the caller already follows it.
+func (h *CustomerHandler) AddFavorite(c echo.Context) error {
+    if h.Favorites == nil {
+        return apperr.NotImplemented("favourites are not enabled")
+    }
+    userID, err := getUserID(c)
+    if err != nil {
+        return apperr.Unauthorized("unauthorized")
+    }
+    var body addFavoriteReq
+    if err := bindValid(c, &body); err != nil {
+        return err
+    }
+    if body.MovieTitle != nil {
+        title := strings.TrimSpace(*body.MovieTitle)
+        if title == "" {
+            return fieldError("movie_title", "must not be empty")
+        }
+        body.MovieTitle = &title
+    }
+    if (body.CinemaID == nil) == (body.MovieTitle == nil) {
+        return apperr.BadRequest("exactly one of cinema_id and movie_title is required")
+    }
+    ctx := c.Request().Context()
+    fav := repository.Favorite{UserID: userID, CinemaID: body.CinemaID, MovieTitle: body.MovieTitle}
+    if body.CinemaID != nil {
+        cinema, err := h.CinemaRepo.GetByID(ctx, *body.CinemaID)
+        if err != nil {
+            if errors.Is(err, repository.ErrCinemaNotFound) {
+                return apperr.NotFound("cinema not found")
+            }
+            return apperr.Internal("failed to load cinema")
+        }
+        fav.CinemaName = cinema.Name
+    }
+    if err := h.Favorites.Create(ctx, &fav); err != nil {
+        if errors.Is(err, repository.ErrFavoriteExists) {
+            return apperr.Conflict(apperr.CodeAlreadyExists, "already a favourite")
+        }
+        return apperr.Internal("failed to add favourite")
+    }
+    return c.JSON(http.StatusCreated, echo.Map{"item": fav})
+}
+
+// ListFavorites handles GET /v1/me/favorites and returns the caller's
+// favourites, newest first.
+func (h *CustomerHandler) ListFavorites(c echo.Context) error {
+    if h.Favorites == nil {
+        return apperr.NotImplemented("favourites are not enabled")
+    }
+    userID, err := getUserID(c)
+    if err != nil {
+        return apperr.Unauthorized("unauthorized")
+    }
+    items, err := h.Favorites.ListByUser(c.Request().Context(), userID)
+    if err != nil {
+        return apperr.Internal("failed to load favourites")
+    }
+    return c.JSON(http.StatusOK, echo.Map{"items": items})
+}
+
+// DeleteFavorite handles DELETE /v1/me/favorites/:id.  It stops following
+// a cinema or movie and responds 204.
+func (h *CustomerHandler) DeleteFavorite(c echo.Context) error {
+    if h.Favorites == nil {
+        return apperr.NotImplemented("favourites are not enabled")
+    }
+    userID, err := getUserID(c)
+    if err != nil {
+        return apperr.Unauthorized("unauthorized")
+    }
+    id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+    if err != nil || id == 0 {
+        return apperr.BadRequest("invalid favourite id")
+    }
+    if err := h.Favorites.Delete(c.Request().Context(), id, userID); err != nil {
+        if errors.Is(err, repository.ErrFavoriteNotFound) {
+            return apperr.NotFound("favourite not found")
+        }
+        return apperr.Internal("failed to delete favourite")
+    }
+    return c.NoContent(http.StatusNoContent)
+}
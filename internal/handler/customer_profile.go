@@ -0,0 +1,74 @@
+package handler
+
+import (
+    "net/http"
+    "strings"
+    "time"
+
+    "github.com/iliyamo/cinema-seat-reservation/internal/apperr"
+    "github.com/labstack/echo/v4"
+)
+
+// dateOfBirthReq is the body of PUT /v1/me/date-of-birth.  A null or
+// absent date_of_birth removes it.
+type dateOfBirthReq struct {
+    DateOfBirth *string `json:"date_of_birth"`
+}
+
+// GetDateOfBirth handles GET /v1/me/date-of-birth and returns the date of
+// birth age-restricted shows are checked against, null when none was given.
+func (h *CustomerHandler) GetDateOfBirth(c echo.Context) error {
+    if h.Users == nil {
+        return apperr.NotImplemented("dates of birth are not enabled")
+    }
+    userID, err := getUserID(c)
+    if err != nil {
+        return apperr.Unauthorized("unauthorized")
+    }
+    dob, err := h.Users.DateOfBirth(c.Request().Context(), userID)
+    if err != nil {
+        return apperr.Internal("failed to load date of birth")
+    }
+    return c.JSON(http.StatusOK, echo.Map{"date_of_birth": formatDate(dob)})
+}
+
+// SetDateOfBirth handles PUT /v1/me/date-of-birth.  The date (YYYY-MM-DD)
+// must lie in the past; confirming seats for an age-restricted show
+// needs one.
+func (h *CustomerHandler) SetDateOfBirth(c echo.Context) error {
+    if h.Users == nil {
+        return apperr.NotImplemented("dates of birth are not enabled")
+    }
+    userID, err := getUserID(c)
+    if err != nil {
+        return apperr.Unauthorized("unauthorized")
+    }
+    var body dateOfBirthReq
+    if err := bindValid(c, &body); err != nil {
+        return err
+    }
+    var dob *time.Time
+    if body.DateOfBirth != nil {
+        d, err := time.Parse("2006-01-02", strings.TrimSpace(*body.DateOfBirth))
+        if err != nil {
+            return fieldError("date_of_birth", "must be a date (YYYY-MM-DD)")
+        }
+        if !d.Before(time.Now().UTC()) || d.Year() < 1900 {
+            return fieldError("date_of_birth", "must be a past date after 1900")
+        }
+        dob = &d
+    }
+    if err := h.Users.SetDateOfBirth(c.Request().Context(), userID, dob); err != nil {
+        return apperr.Internal("failed to save date of birth")
+    }
+    return c.JSON(http.StatusOK, echo.Map{"date_of_birth": formatDate(dob)})
+}
+
+// formatDate formats d as YYYY-MM-DD, or returns nil for a nil d.
+func formatDate(d *time.Time) *string {
+    if d == nil {
+        return nil
+    }
+    s := d.Format("2006-01-02")
+    return &s
+}
@@ -0,0 +1,128 @@
+package handler
+
+// This file lets customers ask for their money back.  A refund request
+// does not release the reservation; the seats are only freed once the
+// owner approves the refund (see owner_refund.go).
+
+import (
+    "database/sql"
+    "errors"
+    "net/http"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/iliyamo/cinema-seat-reservation/internal/apperr"
+    "github.com/iliyamo/cinema-seat-reservation/internal/db"
+    "github.com/iliyamo/cinema-seat-reservation/internal/repository"
+    "github.com/iliyamo/cinema-seat-reservation/internal/service"
+    "github.com/labstack/echo/v4"
+)
+
+// refundRequestReq is the body of POST /v1/reservations/:id/refund-request.
+type refundRequestReq struct {
+    Reason string `json:"reason" validate:"max=500"`
+}
+
+// RequestRefund handles POST /v1/reservations/:id/refund-request.  Only
+// paid CONFIRMED reservations (with a payment reference or paid at
+// checkout) can be refunded, 409 CONFLICT otherwise; unpaid ones are
+// cancelled instead.  Like cancellations, refunds are requested before
+// the show starts (409 SHOW_STARTED) and its cancellation cutoff (409
+// CANCELLATION_CUTOFF_PASSED), and only once (409 ALREADY_EXISTS).  It
+// responds 201 with the REQUESTED refund for the full reservation total.
+func (h *CustomerHandler) RequestRefund(c echo.Context) error {
+    userID, err := getUserID(c)
+    if err != nil {
+        return apperr.Unauthorized("unauthorized")
+    }
+    resID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+    if err != nil || resID == 0 {
+        return apperr.BadRequest("invalid reservation id")
+    }
+    if h.RefundRepo == nil {
+        return apperr.NotImplemented("refunds are not available")
+    }
+    var body refundRequestReq
+    if err := bindValid(c, &body); err != nil {
+        return err
+    }
+    ctx := c.Request().Context()
+    res, err := h.ReservationRepo.GetByIDForUser(ctx, resID, userID)
+    if err != nil {
+        if errors.Is(err, sql.ErrNoRows) {
+            return apperr.NotFound("reservation not found")
+        }
+        return apperr.Internal("failed to fetch reservation")
+    }
+    charged := false
+    if res.Status == "CONFIRMED" && res.TotalAmountCents > 0 {
+        if charged, err = h.ReservationRepo.IsCharged(ctx, resID); err != nil {
+            return apperr.Internal("failed to fetch reservation")
+        }
+    }
+    if !charged {
+        return apperr.Conflict(apperr.CodeConflict, "only paid, confirmed reservations can be refunded; cancel unpaid ones instead")
+    }
+    show, err := h.ShowRepo.GetByID(ctx, res.ShowID)
+    if err != nil {
+        return apperr.Internal("database error")
+    }
+    now := time.Now().UTC()
+    if !show.StartsAt.After(now) {
+        return apperr.Conflict(apperr.CodeShowStarted, "show already started")
+    }
+    refund := repository.Refund{ReservationID: resID, UserID: userID, AmountCents: res.TotalAmountCents}
+    if reason := strings.TrimSpace(body.Reason); reason != "" {
+        refund.Reason = &reason
+    }
+    err = db.WithTx(ctx, h.ShowRepo.DB(), func(tx *sql.Tx) error {
+        cutoff, err := h.ShowRepo.CancelCutoffTx(ctx, tx, res.ShowID)
+        if err != nil {
+            return failTx("failed to load cancellation cutoff", err)
+        }
+        if deadline := show.StartsAt.Add(-cutoff); cutoff > 0 && !now.Before(deadline) {
+            return cutoffConflict(&service.CancelCutoffError{Cutoff: cutoff, Deadline: deadline})
+        }
+        if err := h.RefundRepo.CreateTx(ctx, tx, &refund); err != nil {
+            if errors.Is(err, repository.ErrRefundExists) {
+                return apperr.Conflict(apperr.CodeAlreadyExists, "a refund was already requested for this reservation")
+            }
+            return failTx("failed to request refund", err)
+        }
+        return nil
+    })
+    if err != nil {
+        return txError(c, err)
+    }
+    created, err := h.RefundRepo.GetByReservationForUser(ctx, resID, userID)
+    if err != nil {
+        return apperr.Internal("failed to load refund")
+    }
+    recordAudit(c, h.Audit, auditEvent("refund.request", "refund", created.ID, hallOwnerID(ctx, h.HallRepo, res.HallID)), nil, created)
+    return c.JSON(http.StatusCreated, created)
+}
+
+// GetRefund handles GET /v1/reservations/:id/refund and returns the refund
+// of one of the caller's reservations, or 404 when none was requested.
+func (h *CustomerHandler) GetRefund(c echo.Context) error {
+    userID, err := getUserID(c)
+    if err != nil {
+        return apperr.Unauthorized("unauthorized")
+    }
+    resID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+    if err != nil || resID == 0 {
+        return apperr.BadRequest("invalid reservation id")
+    }
+    if h.RefundRepo == nil {
+        return apperr.NotImplemented("refunds are not available")
+    }
+    refund, err := h.RefundRepo.GetByReservationForUser(c.Request().Context(), resID, userID)
+    if err != nil {
+        if errors.Is(err, repository.ErrRefundNotFound) {
+            return apperr.NotFound("refund not found")
+        }
+        return apperr.Internal("failed to load refund")
+    }
+    return c.JSON(http.StatusOK, refund)
+}
@@ -0,0 +1,65 @@
+package handler_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/iliyamo/cinema-seat-reservation/internal/apperr"
+	"github.com/iliyamo/cinema-seat-reservation/internal/handler"
+	"github.com/iliyamo/cinema-seat-reservation/internal/repository"
+	"github.com/iliyamo/cinema-seat-reservation/internal/repository/mock"
+)
+
+func TestRequestRefundErrors(t *testing.T) {
+	cases := []struct {
+		name    string
+		status  string
+		total   uint32
+		charged bool
+		cutoff  time.Duration
+		code    apperr.Code
+	}{
+		{"pending", "PENDING", 2000, true, 0, apperr.CodeConflict},
+		{"free", "CONFIRMED", 0, false, 0, apperr.CodeConflict},
+		// /confirm takes no payment; such reservations are cancelled instead
+		{"not charged", "CONFIRMED", 2000, false, 0, apperr.CodeConflict},
+		{"after the cutoff", "CONFIRMED", 2000, true, 48 * time.Hour, apperr.CodeCancelCutoff},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := newBookingStores(t)
+			s.reservations.GetByIDForUserFn = func(context.Context, uint64, uint64) (*repository.ReservationDetail, error) {
+				return &repository.ReservationDetail{ID: 5, ShowID: showID, Status: tc.status, TotalAmountCents: tc.total}, nil
+			}
+			s.reservations.IsChargedFn = func(context.Context, uint64) (bool, error) { return tc.charged, nil }
+			s.shows.CancelCutoffTxFn = func(context.Context, *sql.Tx, uint64) (time.Duration, error) { return tc.cutoff, nil }
+			conn := (&mock.Driver{ExecFn: func(query string, _ []driver.NamedValue) (driver.Result, error) {
+				t.Fatalf("refund created: %s", query)
+				return nil, nil
+			}}).DB()
+			t.Cleanup(func() { conn.Close() })
+
+			h := handler.NewCustomerHandler(&mock.SeatStore{}, s.shows, s.showSeats, s.holds, s.reservations, &mock.HallStore{}, nil)
+			h.RefundRepo = repository.NewRefundRepo(conn)
+			e := echo.New()
+			e.HTTPErrorHandler = apperr.Handler
+			e.POST("/v1/reservations/:id/refund-request", h.RequestRefund, func(next echo.HandlerFunc) echo.HandlerFunc {
+				return func(c echo.Context) error {
+					c.Set("user_id", uint64(customerID))
+					return next(c)
+				}
+			})
+
+			rec := serve(e, http.MethodPost, "/v1/reservations/5/refund-request", `{}`)
+			if rec.Code != http.StatusConflict || errorCode(t, rec) != tc.code {
+				t.Fatalf("status %d, want 409 %s: %s", rec.Code, tc.code, rec.Body)
+			}
+		})
+	}
+}
@@ -5,9 +5,13 @@ import (
     "errors"         // for errors.Is comparisons
     "net/http"       // HTTP status codes
     "strconv"        // parsing path parameters
+    "strings"        // normalising promo codes
     "time"           // working with timestamps
 
+    "github.com/iliyamo/cinema-seat-reservation/internal/apperr" // apperr builds error responses
+    "github.com/iliyamo/cinema-seat-reservation/internal/metrics"    // booking counters
     "github.com/iliyamo/cinema-seat-reservation/internal/repository" // repository layer
+    "github.com/iliyamo/cinema-seat-reservation/internal/service"    // booking workflow and saga
     "github.com/labstack/echo/v4"                                    // Echo web framework
 )
 
@@ -17,20 +21,38 @@ import (
 // already been performed by middleware.  Methods may return 401
 // Unauthorized if the user ID cannot be extracted from the context.
 // Each method runs critical DB operations inside a transaction to
-// guarantee atomicity.
+// guarantee atomicity; transactions go through db.WithTx so deadlocks
+// and lock wait timeouts are retried before the client sees a 503.
 type CustomerHandler struct {
-	SeatRepo        *repository.SeatRepo        // access to seats (unused directly but retained for future)
-	ShowRepo        *repository.ShowRepo        // access to shows
-	ShowSeatRepo    *repository.ShowSeatRepo    // access to show_seats for status updates and price queries
-	SeatHoldRepo    *repository.SeatHoldRepo    // access to seat_holds for creating and deleting holds
-	ReservationRepo *repository.ReservationRepo // access to reservations and reservation_seats
-	HallRepo        *repository.HallRepo        // access to halls for potential lookups
-	CinemaRepo      *repository.CinemaRepo      // access to cinemas for reservation listing
+	SeatRepo        repository.SeatStore         // access to seats (unused directly but retained for future)
+	ShowRepo        repository.ShowStore         // access to shows
+	ShowSeatRepo    repository.ShowSeatStore     // access to show_seats for status updates and price queries
+	SeatHoldRepo    repository.SeatHoldStore     // access to seat_holds for creating and deleting holds
+	ReservationRepo repository.ReservationStore  // access to reservations and reservation_seats
+	HallRepo        repository.HallStore         // access to halls for potential lookups
+	CinemaRepo      *repository.CinemaRepo       // access to cinemas for reservation listing
+	Saga            *service.BookingSagaService  // optional; enables paid checkout
+	Audit           *repository.AuditRepo        // optional; records bookings and cancellations in the audit log
+	Waitlist        *service.WaitlistService     // optional; enables waitlists and offers freed seats to them
+	RefundRepo      *repository.RefundRepo       // optional; enables refund requests
+	TransferRepo    *repository.TransferRepo     // optional; enables reservation transfers
+	TransferTTL     time.Duration                // lifetime of transfer codes; zero means 72 hours
+	Notifications   *repository.NotificationRepo // optional; tells both customers about a transfer
+	Favorites       *repository.FavoriteRepo     // optional; enables followed cinemas and movies
+	Users           *repository.UserRepo         // optional; enables dates of birth for age-restricted shows
+	Upgrades        *service.SeatUpgradeService  // optional; enables seat upgrades
+
+	// Booking holds and reserves seats.  NewCustomerHandler builds it from
+	// the repositories above; hold lifetimes, promo codes and the
+	// waitlist are configured on it.
+	Booking *service.BookingService
 }
 
 // NewCustomerHandler constructs a new CustomerHandler with the provided
-// repositories.  All dependencies must be non-nil.
-func NewCustomerHandler(seatRepo *repository.SeatRepo, showRepo *repository.ShowRepo, showSeatRepo *repository.ShowSeatRepo, seatHoldRepo *repository.SeatHoldRepo, reservationRepo *repository.ReservationRepo, hallRepo *repository.HallRepo, cinemaRepo *repository.CinemaRepo) *CustomerHandler {
+// repositories.  All dependencies must be non-nil.  The SQL repositories
+// satisfy the store interfaces; tests may pass the doubles from package
+// repository/mock instead.
+func NewCustomerHandler(seatRepo repository.SeatStore, showRepo repository.ShowStore, showSeatRepo repository.ShowSeatStore, seatHoldRepo repository.SeatHoldStore, reservationRepo repository.ReservationStore, hallRepo repository.HallStore, cinemaRepo *repository.CinemaRepo) *CustomerHandler {
 	if seatRepo == nil || showRepo == nil || showSeatRepo == nil || seatHoldRepo == nil || reservationRepo == nil {
 		panic("nil repository passed to NewCustomerHandler")
 	}
@@ -42,44 +64,49 @@ func NewCustomerHandler(seatRepo *repository.SeatRepo, showRepo *repository.Show
 		ReservationRepo: reservationRepo,
 		HallRepo:        hallRepo,
 		CinemaRepo:      cinemaRepo,
+		Booking:         service.NewBookingService(showRepo, showSeatRepo, seatHoldRepo, reservationRepo),
 	}
 }
 
+// holdSeatsReq is the body of POST /v1/shows/:id/hold.
+type holdSeatsReq struct {
+	SeatIDs []uint64 `json:"seat_ids" validate:"required"`
+}
+
 // HoldSeats handles POST /v1/shows/:id/hold.  It allows a customer to
-// temporarily hold one or more seats for five minutes.  To prevent
-// race conditions when multiple users attempt to hold the same seat
-// concurrently, this handler uses row‑level locks on show_seats via
-// SELECT ... FOR UPDATE.  Each requested seat is locked and its
-// current status checked; only seats with status FREE and no active
-// seat_holds are holdable.  If a seat is RESERVED or already HELD,
-// the handler rejects the request and returns the unavailable seat IDs.
-// On success it inserts seat_holds records, updates show_seats.status
-// to HELD and commits the transaction, releasing the locks.
+// temporarily hold one or more seats for the show's hold TTL (see
+// service.BookingService.HoldTTLFor).  The seats are locked with SELECT
+// ... FOR UPDATE so concurrent requests cannot hold the same seat; only
+// seats with status FREE and no active seat_holds are holdable.  If a
+// seat is RESERVED or already HELD the request is rejected with the
+// unavailable seat IDs.  On success it responds 201 with the hold tokens,
+// the quoted prices and the caller's checkout session for the show.  With
+// ?partial=true the hold is best-effort: the available seats are held and
+// the others are listed under "unavailable"; it fails only when no seat is
+// available.  With ?dry_run=true the same checks run in a read-only
+// transaction and it responds 200 with what would be held, without hold
+// tokens.
 func (h *CustomerHandler) HoldSeats(c echo.Context) error {
 	userID, err := getUserID(c)
 	if err != nil {
-		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "unauthorized"})
+		return apperr.Unauthorized("unauthorized")
 	}
 	showID, err := strconv.ParseUint(c.Param("id"), 10, 64)
 	if err != nil || showID == 0 {
-		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid show id"})
+		return apperr.BadRequest("invalid show id")
 	}
 	// ensure show exists
-	if _, err := h.ShowRepo.GetByID(c.Request().Context(), showID); err != nil {
+	show, err := h.ShowRepo.GetByID(c.Request().Context(), showID)
+	if err != nil {
 		if err == repository.ErrShowNotFound {
-			return c.JSON(http.StatusNotFound, echo.Map{"error": "show not found"})
+			return apperr.NotFound("show not found")
 		}
-		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "database error"})
+		return apperr.Internal("database error")
 	}
 	// bind request body
-	var body struct {
-		SeatIDs []uint64 `json:"seat_ids"`
-	}
-	if err := c.Bind(&body); err != nil {
-		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid request body"})
-	}
-	if len(body.SeatIDs) == 0 {
-		return c.JSON(http.StatusBadRequest, echo.Map{"error": "seat_ids is required"})
+	var body holdSeatsReq
+	if err := bindValid(c, &body); err != nil {
+		return err
 	}
 	// deduplicate seat IDs to avoid duplicate holds
 	unique := make([]uint64, 0, len(body.SeatIDs))
@@ -94,125 +121,64 @@ func (h *CustomerHandler) HoldSeats(c echo.Context) error {
 		}
 	}
 	if len(unique) == 0 {
-		return c.JSON(http.StatusBadRequest, echo.Map{"error": "no valid seat IDs provided"})
+		return apperr.BadRequest("no valid seat IDs provided")
 	}
-	ctx := c.Request().Context()
-	tx, err := h.ShowRepo.DB().BeginTx(ctx, nil)
+	dry, err := dryRun(c)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to start transaction"})
+		return err
 	}
-	committed := false
-	defer func() {
-		if !committed {
-			_ = tx.Rollback()
+	partial, err := boolParam(c, "partial")
+	if err != nil {
+		return err
+	}
+	if dry {
+		res, err := h.Booking.PreviewHold(c.Request().Context(), userID, show, unique, partial)
+		if err != nil {
+			return txError(c, bookingError(err))
 		}
-	}()
-	// expire any holds that have passed expiration before checking availability
-	if h.SeatHoldRepo != nil {
-		if expired, errExp := h.SeatHoldRepo.ExpireHoldsTx(ctx, tx, showID); errExp == nil {
-			if len(expired) > 0 {
-				if errUp := h.ShowSeatRepo.BulkUpdateStatusTx(ctx, tx, showID, expired, "FREE"); errUp != nil {
-					return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to cleanup expired holds"})
-				}
-			}
-		} else {
-			return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to cleanup expired holds"})
+		resp := holdResponse(showID, res)
+		if partial {
+			resp["unavailable"] = unavailableSeats(res)
 		}
+		resp["dry_run"] = true
+		return c.JSON(http.StatusOK, resp)
+	}
+	res, err := h.Booking.Hold(c.Request().Context(), userID, show, unique, partial)
+	if err != nil {
+		return txError(c, bookingError(err))
+	}
+	resp := holdResponse(showID, res)
+	if partial {
+		resp["unavailable"] = unavailableSeats(res)
+	}
+	return c.JSON(http.StatusCreated, resp)
+}
+
+// unavailableSeats returns the seats a partial hold skipped, never nil so
+// that they render as a JSON array.
+func unavailableSeats(res *service.HoldResult) []uint64 {
+	if res.Unavailable == nil {
+		return []uint64{}
+	}
+	return res.Unavailable
+}
+
+// holdResponse renders the result of a hold request.  HoldSeats and
+// AutoHoldSeats share it.
+func holdResponse(showID uint64, res *service.HoldResult) echo.Map {
+	tokens := make([]string, 0, len(res.Holds))
+	for _, hld := range res.Holds {
+		tokens = append(tokens, hld.HoldToken)
+	}
+	return echo.Map{
+		"expires_at":         res.ExpiresAt.Format(time.RFC3339),
+		"seat_ids":           res.SeatIDs,
+		"hold_tokens":        tokens,
+		"prices":             res.Prices,
+		"quoted_total_cents": res.QuotedTotalCents,
+		"currency":           res.Currency,
+		"checkout_session":   newCheckoutSession(showID, res.Active, time.Now().UTC()),
 	}
-    // ------------------------------------------------------------------
-    // Use row‑level locks to safely check and hold seats.  Without locking
-    // concurrent requests could both see a seat as FREE and then both
-    // update it to HELD, resulting in double booking.  SELECT … FOR UPDATE
-    // locks the show_seats row until the transaction commits.
-    // We'll build two lists: holdable (available seats) and unavailable.
-    unavailable := make([]uint64, 0)
-    holdable := make([]uint64, 0, len(unique))
-    for _, sid := range unique {
-        // Acquire lock on the show_seats row for this seat.  This lock
-        // prevents other transactions from reading or updating the row
-        // until we decide whether it's free.  If the row is missing this
-        // scan will return sql.ErrNoRows which we treat as unavailable.
-        var seatStatus string
-        err := tx.QueryRowContext(ctx,
-            `SELECT status FROM show_seats WHERE show_id = ? AND seat_id = ? FOR UPDATE`,
-            showID, sid,
-        ).Scan(&seatStatus)
-        if err != nil {
-            // If the seat does not exist, treat it as unavailable
-            if errors.Is(err, sql.ErrNoRows) {
-                unavailable = append(unavailable, sid)
-                continue
-            }
-            return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to lock seat"})
-        }
-        // Only seats with status FREE can be held.  RESERVED or HELD
-        // seats are considered unavailable.  Using row‑level lock ensures
-        // the status cannot change between this check and the update.
-        if seatStatus != "FREE" {
-            unavailable = append(unavailable, sid)
-            continue
-        }
-        // Check if there is an active hold on this seat by any user.
-        // Even if the show_seats.status is FREE, there may be an
-        // unexpired seat_hold record.  We do not append FOR UPDATE
-        // here because we already hold a lock on show_seats; counting
-        // seat_holds does not require locking rows as we won't update
-        // seat_holds until later.
-        var holdCount int
-        if err := tx.QueryRowContext(ctx,
-            `SELECT COUNT(*) FROM seat_holds WHERE show_id = ? AND seat_id = ? AND expires_at > UTC_TIMESTAMP()`,
-            showID, sid,
-        ).Scan(&holdCount); err != nil {
-            return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to check active holds"})
-        }
-        if holdCount > 0 {
-            unavailable = append(unavailable, sid)
-            continue
-        }
-        // Seat is free and not held; mark as holdable.  We keep the
-        // row lock until the transaction commits to prevent others from
-        // grabbing it concurrently.
-        holdable = append(holdable, sid)
-    }
-    // If any seats are unavailable, abort the operation and return
-    // them to the client.  The unavailable slice lists seats that are
-    // either already HELD/RESERVED or missing.  We do not commit the
-    // transaction in this case; the deferred rollback will release locks.
-    if len(unavailable) > 0 {
-        return c.JSON(http.StatusBadRequest, echo.Map{
-            "error":       "some seats are unavailable",
-            "unavailable": unavailable,
-        })
-    }
-    // At this point we have locked all requested seats and verified
-    // they are free.  Generate hold records with a 5 minute expiration.
-    expiresAt := time.Now().UTC().Add(5 * time.Minute)
-    holds, err := repository.GenerateHoldRecords(userID, showID, holdable, expiresAt)
-    if err != nil {
-        return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to generate hold tokens"})
-    }
-    // Insert seat_holds rows.  This does not conflict with the locked
-    // show_seats rows because we do not lock seat_holds when reading.
-    if err := h.SeatHoldRepo.CreateMultipleTx(ctx, tx, holds); err != nil {
-        return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to create holds"})
-    }
-    // Update show_seats.status to HELD for each seat.  Because we still
-    // hold the row locks from the earlier SELECT ... FOR UPDATE, this
-    // update cannot conflict with another transaction.  The status and
-    // version columns are updated atomically.
-    if err := h.ShowSeatRepo.BulkUpdateStatusTx(ctx, tx, showID, holdable, "HELD"); err != nil {
-        return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to update seat status"})
-    }
-    // Commit the transaction.  This releases all row locks and makes
-    // the holds visible to other transactions.
-    if err := tx.Commit(); err != nil {
-        return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to commit transaction"})
-    }
-    committed = true
-    return c.JSON(http.StatusCreated, echo.Map{
-        "expires_at": expiresAt.Format(time.RFC3339),
-        "seat_ids":   holdable,
-    })
 }
 
 // ReleaseHolds handles DELETE /v1/shows/:id/hold.  It releases all holds for
@@ -222,224 +188,249 @@ func (h *CustomerHandler) HoldSeats(c echo.Context) error {
 func (h *CustomerHandler) ReleaseHolds(c echo.Context) error {
 	userID, err := getUserID(c)
 	if err != nil {
-		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "unauthorized"})
+		return apperr.Unauthorized("unauthorized")
 	}
 	showID, err := strconv.ParseUint(c.Param("id"), 10, 64)
 	if err != nil || showID == 0 {
-		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid show id"})
+		return apperr.BadRequest("invalid show id")
 	}
-	ctx := c.Request().Context()
-	tx, err := h.ShowRepo.DB().BeginTx(ctx, nil)
+	released, err := h.Booking.ReleaseHolds(c.Request().Context(), userID, showID)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to start transaction"})
+		return txError(c, bookingError(err))
 	}
-	committed := false
-	defer func() {
-		if !committed {
-			_ = tx.Rollback()
-		}
-	}()
-	seatIDs, err := h.SeatHoldRepo.DeleteByUserAndShowTx(ctx, tx, userID, showID)
+	return c.JSON(http.StatusOK, echo.Map{
+		"released": released,
+	})
+}
+
+// ExtendHolds handles POST /v1/shows/:id/hold/extend.  It pushes the
+// expiry of all of the user's active holds for the show forward by the
+// configured increment, never beyond the configured maximum hold
+// lifetime.  It returns 404 when the user has no active holds and 409
+// when the holds have already reached the maximum duration.
+func (h *CustomerHandler) ExtendHolds(c echo.Context) error {
+	userID, err := getUserID(c)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to release holds"})
+		return apperr.Unauthorized("unauthorized")
 	}
-	// update seats back to FREE
-	if len(seatIDs) > 0 {
-		if err := h.ShowSeatRepo.BulkUpdateStatusTx(ctx, tx, showID, seatIDs, "FREE"); err != nil {
-			return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to update seat status"})
-		}
+	showID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || showID == 0 {
+		return apperr.BadRequest("invalid show id")
 	}
-	if err := tx.Commit(); err != nil {
-		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to commit transaction"})
+	holds, err := h.Booking.ExtendHolds(c.Request().Context(), userID, showID)
+	if errors.Is(err, service.ErrNoActiveHolds) {
+		return apperr.New(http.StatusNotFound, apperr.CodeNoActiveHolds, "no active holds for this show")
+	}
+	if err != nil {
+		return txError(c, bookingError(err))
+	}
+	// Report the earliest expiry; that is when the first seat would be lost.
+	seatIDs := make([]uint64, 0, len(holds))
+	expiresAt := holds[0].ExpiresAt
+	for _, hld := range holds {
+		seatIDs = append(seatIDs, hld.SeatID)
+		if hld.ExpiresAt.Before(expiresAt) {
+			expiresAt = hld.ExpiresAt
+		}
 	}
-	committed = true
 	return c.JSON(http.StatusOK, echo.Map{
-		"released": len(seatIDs),
+		"expires_at": expiresAt.UTC().Format(time.RFC3339),
+		"seat_ids":   seatIDs,
 	})
 }
 
+// confirmSeatsReq is the optional body of POST /v1/shows/:id/confirm.
+// HoldTokens, when non-empty, limits the reservation to those holds.
+type confirmSeatsReq struct {
+	PromoCode         string   `json:"promo_code" validate:"max=64"`
+	AcceptPriceChange bool     `json:"accept_price_change"`
+	HoldTokens        []string `json:"hold_tokens"`
+}
+
+// reserveRequest builds the service request for a confirm or checkout
+// body, normalising the promo code.
+func (b confirmSeatsReq) reserveRequest(userID, showID uint64, status string) service.ReserveRequest {
+	return service.ReserveRequest{
+		UserID:            userID,
+		ShowID:            showID,
+		HoldTokens:        b.HoldTokens,
+		PromoCode:         strings.ToUpper(strings.TrimSpace(b.PromoCode)),
+		AcceptPriceChange: b.AcceptPriceChange,
+		Status:            status,
+	}
+}
+
 // ConfirmSeats (also mapped to POST /v1/shows/:id/reserve) finalises
-// previously held seats into a confirmed reservation.  To prevent
-// race conditions with concurrent reservations, it acquires row‑level
-// locks on each selected show_seats row via SELECT ... FOR UPDATE.
-// This ensures that the seat remains HELD by the current user until the
-// transaction commits.  The handler verifies that the seat status is
-// HELD and that there is an active seat_hold for the user; if not it
-// aborts.  After validation it creates a reservation and associated
-// reservation_seats, updates show_seats.status to RESERVED and
-// deletes the seat_holds.  The locks are released upon commit.
+// previously held seats into a confirmed reservation through
+// service.BookingService.Reserve: the held seats are locked and must
+// still be HELD by the caller, the reservation and its seats are created,
+// the seats become RESERVED and the holds are deleted, all in one
+// transaction.  An optional JSON body {"promo_code": "..."} redeems an
+// owner promo code; the code is validated inside the same transaction and
+// the discount is recorded on the reservation.  When the current seat
+// prices differ from the prices quoted at hold time the handler responds
+// 409 with error "price_changed" and the per-seat differences; the client
+// must resend with accept_price_change=true to pay the new prices.
+// hold_tokens (as returned by the hold endpoints) confirms only those
+// holds, so one account can keep separate carts for a show; without it
+// every active hold of the caller on the show is confirmed.  With
+// ?dry_run=true nothing is reserved: the checks run in a read-only
+// transaction and it responds 200 with the seats, prices, discount and
+// total the reservation would have.
 func (h *CustomerHandler) ConfirmSeats(c echo.Context) error {
 	userID, err := getUserID(c)
 	if err != nil {
-		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "unauthorized"})
+		return apperr.Unauthorized("unauthorized")
 	}
 	showID, err := strconv.ParseUint(c.Param("id"), 10, 64)
 	if err != nil || showID == 0 {
-		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid show id"})
+		return apperr.BadRequest("invalid show id")
 	}
 	// ensure show exists
-	if _, err := h.ShowRepo.GetByID(c.Request().Context(), showID); err != nil {
+	show, err := h.ShowRepo.GetByID(c.Request().Context(), showID)
+	if err != nil {
 		if err == repository.ErrShowNotFound {
-			return c.JSON(http.StatusNotFound, echo.Map{"error": "show not found"})
+			return apperr.NotFound("show not found")
 		}
-		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "database error"})
+		return apperr.Internal("database error")
+	}
+	// The body is optional; it may carry a promo code to redeem against
+	// this reservation.
+	var body confirmSeatsReq
+	if err := bindValid(c, &body); err != nil {
+		return err
 	}
 	ctx := c.Request().Context()
-	tx, err := h.ShowRepo.DB().BeginTx(ctx, nil)
+	dry, err := dryRun(c)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to start transaction"})
+		return err
 	}
-	committed := false
-	defer func() {
-		if !committed {
-			_ = tx.Rollback()
-		}
-	}()
-	// expire any holds that have passed expiration before confirming
-	if h.SeatHoldRepo != nil {
-		if expired, errExp := h.SeatHoldRepo.ExpireHoldsTx(ctx, tx, showID); errExp == nil {
-			if len(expired) > 0 {
-				if errUp := h.ShowSeatRepo.BulkUpdateStatusTx(ctx, tx, showID, expired, "FREE"); errUp != nil {
-					return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to cleanup expired holds"})
-				}
-			}
-		} else {
-			return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to cleanup expired holds"})
+	if dry {
+		pv, err := h.Booking.PreviewReserve(ctx, body.reserveRequest(userID, showID, "CONFIRMED"))
+		if err != nil {
+			return txError(c, bookingError(err))
 		}
+		return c.JSON(http.StatusOK, echo.Map{
+			"dry_run":            true,
+			"seat_ids":           pv.SeatIDs,
+			"prices":             pv.Prices,
+			"price_changes":      pv.PriceChanges,
+			"quoted_total_cents": pv.QuotedTotalCents,
+			"subtotal_cents":     pv.SubtotalCents,
+			"discount_cents":     pv.DiscountCents,
+			"fees_cents":         pv.FeesCents,
+			"tax_cents":          pv.TaxCents,
+			"total_amount_cents": pv.TotalAmountCents,
+			"currency":           pv.Currency,
+		})
 	}
-    // load active holds for user + show.  This fetches all seat_holds
-    // belonging to the current user that have not expired.  We will
-    // validate each hold individually under row‑level locks below.
-    holds, err := h.SeatHoldRepo.ActiveHoldsByUserAndShowTx(ctx, tx, userID, showID)
-    if err != nil {
-        return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to load holds"})
-    }
-    if len(holds) == 0 {
-        return c.JSON(http.StatusBadRequest, echo.Map{"error": "no active holds for this show"})
-    }
-    // Build a set of held seat IDs for quick lookup and preserve order.
-    seatIDs := make([]uint64, 0, len(holds))
-    heldByUser := make(map[uint64]struct{})
-    for _, hld := range holds {
-        seatIDs = append(seatIDs, hld.SeatID)
-        heldByUser[hld.SeatID] = struct{}{}
-    }
-    // Use row‑level locks to ensure that each seat is still HELD by this
-    // user and has not been reserved or held by someone else in the
-    // meantime.  Without locking, concurrent confirmations could both
-    // see the seat as HELD and reserve it twice.  We track any seats
-    // failing validation in unavailable.
-    unavailable := make([]uint64, 0)
-    for _, sid := range seatIDs {
-        // Lock the show_seats row for this seat.  This prevents status
-        // changes until we commit.  If the row is missing, treat as
-        // unavailable.
-        var seatStatus string
-        if err := tx.QueryRowContext(ctx,
-            `SELECT status FROM show_seats WHERE show_id = ? AND seat_id = ? FOR UPDATE`,
-            showID, sid,
-        ).Scan(&seatStatus); err != nil {
-            if errors.Is(err, sql.ErrNoRows) {
-                unavailable = append(unavailable, sid)
-                continue
-            }
-            return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to lock seat"})
-        }
-        // Seat must currently be HELD.  If it is FREE or RESERVED, the
-        // hold is invalid or has been overtaken by another transaction.
-        if seatStatus != "HELD" {
-            unavailable = append(unavailable, sid)
-            continue
-        }
-        // Verify the seat hold record still belongs to the user.  We
-        // query seat_holds to ensure there is exactly one active hold by
-        // this user for this seat.  Without this check, a seat could be
-        // held by another user but still have status HELD.
-        var cnt int
-        if err := tx.QueryRowContext(ctx,
-            `SELECT COUNT(*) FROM seat_holds WHERE show_id = ? AND seat_id = ? AND user_id = ? AND expires_at > UTC_TIMESTAMP()`,
-            showID, sid, userID,
-        ).Scan(&cnt); err != nil {
-            return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to verify seat hold"})
-        }
-        if cnt == 0 {
-            unavailable = append(unavailable, sid)
-            continue
-        }
-    }
-    if len(unavailable) > 0 {
-        // One or more seats cannot be confirmed.  Abort without
-        // committing; rollback will release locks.  Return a 400 so
-        // the client knows which seats failed.  Removing holds or
-        // cleaning up is not performed here; clients may retry.
-        return c.JSON(http.StatusBadRequest, echo.Map{
-            "error":       "some seats cannot be confirmed",
-            "unavailable": unavailable,
-        })
-    }
-    // Compute total price from show_seats for the held seats.  We do
-    // this after locking to ensure consistent pricing.  If any seat is
-    // missing a price, return an error.  priceMap maps seat_id to price.
-    priceMap, err := h.ShowSeatRepo.GetPricesBySeatIDsTx(ctx, tx, showID, seatIDs)
-    if err != nil {
-        return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to fetch seat prices"})
-    }
-    total := uint32(0)
-    for _, sid := range seatIDs {
-        if p, ok := priceMap[sid]; ok {
-            total += p
-        } else {
-            return c.JSON(http.StatusInternalServerError, echo.Map{"error": "price not found for seat"})
-        }
-    }
-    // Insert the reservation record.  We set status to CONFIRMED as
-    // holds are turned into a final reservation.  The ID is
-    // auto‑generated by the database.
-    resRec := &repository.ReservationRecord{
-        UserID:           userID,
-        ShowID:           showID,
-        Status:           "CONFIRMED",
-        TotalAmountCents: total,
-    }
-    if err := h.ReservationRepo.CreateTx(ctx, tx, resRec); err != nil {
-        return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to create reservation"})
-    }
-    // Prepare reservation_seats entries for each seat.  These map the
-    // reservation to individual seats and their prices.
-    seats := make([]repository.ReservationSeatRecord, 0, len(seatIDs))
-    for _, sid := range seatIDs {
-        seats = append(seats, repository.ReservationSeatRecord{
-            ReservationID: resRec.ID,
-            ShowID:        showID,
-            SeatID:        sid,
-            PriceCents:    priceMap[sid],
-        })
-    }
-    if err := h.ReservationRepo.CreateSeatsBulkTx(ctx, tx, seats); err != nil {
-        return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to create reservation seats"})
-    }
-    // Update show_seats.status to RESERVED for all seats.  Because we
-    // still hold row‑level locks, no other transaction can change the
-    // status concurrently.  BulkUpdateStatusTx increments the version
-    // and updates updated_at.
-    if err := h.ShowSeatRepo.BulkUpdateStatusTx(ctx, tx, showID, seatIDs, "RESERVED"); err != nil {
-        return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to update seat status"})
-    }
-    // Remove seat_holds for this user and show.  This frees the
-    // seat_holds rows and prevents duplicate confirmations.  We ignore
-    // the returned list of seat IDs here since we already know them.
-    if _, err := h.SeatHoldRepo.DeleteByUserAndShowTx(ctx, tx, userID, showID); err != nil {
-        return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to delete holds"})
-    }
-    // Commit the transaction to persist all changes and release locks.
-    if err := tx.Commit(); err != nil {
-        return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to commit transaction"})
-    }
-    committed = true
-    return c.JSON(http.StatusCreated, echo.Map{
-        "reservation_id":     resRec.ID,
-        "total_amount_cents": total,
-    })
+	resRec, err := h.Booking.Reserve(ctx, body.reserveRequest(userID, showID, "CONFIRMED"))
+	if err != nil {
+		return txError(c, bookingError(err))
+	}
+	metrics.ReservationsConfirmed.Inc("single")
+	if h.Audit != nil {
+		after, _ := h.ReservationRepo.GetByIDForUser(ctx, resRec.ID, userID)
+		recordAudit(c, h.Audit, auditEvent("reservation.create", "reservation", resRec.ID, hallOwnerID(ctx, h.HallRepo, show.HallID)), nil, after)
+	}
+	return c.JSON(http.StatusCreated, echo.Map{
+		"reservation_id":     resRec.ID,
+		"subtotal_cents":     resRec.SubtotalCents,
+		"discount_cents":     resRec.DiscountCents,
+		"fees_cents":         resRec.FeesCents,
+		"tax_cents":          resRec.TaxCents,
+		"total_amount_cents": resRec.TotalAmountCents,
+		"currency":           resRec.Currency,
+	})
+}
+
+// dryRun reports whether the request asks for a dry run with
+// ?dry_run=true.
+func dryRun(c echo.Context) (bool, error) {
+	return boolParam(c, "dry_run")
+}
+
+// boolParam returns the boolean query parameter name, false when absent.
+func boolParam(c echo.Context, name string) (bool, error) {
+	s := c.QueryParam(name)
+	if s == "" {
+		return false, nil
+	}
+	v, err := strconv.ParseBool(s)
+	if err != nil {
+		return false, fieldError(name, "must be true or false")
+	}
+	return v, nil
+}
+
+// bookingError converts an error from service.BookingService into the
+// client error it stands for.  Other errors are database errors; they are
+// wrapped with failTx so that, inside a transaction, db.WithTx still sees
+// transient causes.
+func bookingError(err error) error {
+	var (
+		notBookable *service.ShowNotBookableError
+		unavailable *service.SeatsUnavailableError
+		notHeld     *service.SeatsNotHeldError
+		notActive   *service.HoldsNotActiveError
+		changed     *service.PriceChangedError
+		quota       *service.HoldQuotaError
+		tooYoung    *service.AgeRestrictedError
+		currency    *service.CurrencyMismatchError
+	)
+	switch {
+	case errors.Is(err, repository.ErrShowNotFound):
+		return apperr.NotFound("show not found")
+	case errors.As(err, &notBookable):
+		return apperr.Conflict(apperr.CodeShowNotBookable, "show is not bookable")
+	case errors.As(err, &unavailable):
+		return apperr.New(http.StatusBadRequest, apperr.CodeSeatUnavailable, "some seats are unavailable").
+			WithDetails(echo.Map{"unavailable": unavailable.SeatIDs})
+	case errors.As(err, &notHeld):
+		return apperr.New(http.StatusBadRequest, apperr.CodeSeatUnavailable, "some seats cannot be confirmed").
+			WithDetails(echo.Map{"unavailable": notHeld.SeatIDs})
+	case errors.As(err, &notActive):
+		return apperr.Conflict(apperr.CodeHoldNotActive, "some holds are no longer active").
+			WithDetails(echo.Map{"hold_tokens": notActive.Tokens})
+	case errors.Is(err, service.ErrNoActiveHolds):
+		return apperr.New(http.StatusBadRequest, apperr.CodeNoActiveHolds, "no active holds for this show")
+	case errors.As(err, &changed):
+		return apperr.Conflict(apperr.CodePriceChanged, "seat prices changed since the seats were held").
+			WithDetails(echo.Map{
+				"changes":             changed.Changes,
+				"quoted_total_cents":  changed.QuotedTotalCents,
+				"current_total_cents": changed.CurrentTotalCents,
+			})
+	case errors.Is(err, repository.ErrPromoCodeNotFound):
+		return apperr.New(http.StatusBadRequest, apperr.CodeInvalidPromo, "promo code not found")
+	case errors.Is(err, service.ErrPromoCodeInvalid):
+		return apperr.New(http.StatusBadRequest, apperr.CodeInvalidPromo, "promo code is not valid")
+	case errors.Is(err, service.ErrPromoCodesUnsupported):
+		return apperr.BadRequest("promo codes are not supported")
+	case errors.Is(err, service.ErrHoldExtensionsDisabled):
+		return apperr.Conflict(apperr.CodeConflict, "hold extensions are disabled")
+	case errors.Is(err, service.ErrHoldLimitReached):
+		return apperr.Conflict(apperr.CodeHoldLimitReached, "maximum hold duration reached")
+	case errors.As(err, &tooYoung):
+		return apperr.New(http.StatusForbidden, apperr.CodeAgeRestricted, tooYoung.Error()).WithDetails(echo.Map{
+			"min_age":                tooYoung.MinAge,
+			"date_of_birth_required": tooYoung.NeedDateOfBirth,
+		})
+	case errors.As(err, &currency):
+		return currencyMismatch(currency)
+	case errors.As(err, &quota):
+		sessions := checkoutSessionsByShow(quota.Active, time.Now().UTC())
+		return apperr.Conflict(apperr.CodeHoldQuota, quota.Error()).
+			WithDetails(echo.Map{"max_held_shows": quota.Limit, "active_holds": sessions})
+	}
+	return failTx("booking failed", err)
+}
+
+// currencyMismatch renders a CurrencyMismatchError as 409
+// CURRENCY_MISMATCH.
+func currencyMismatch(e *service.CurrencyMismatchError) error {
+	return apperr.Conflict(apperr.CodeCurrencyMismatch, e.Error()).
+		WithDetails(echo.Map{"quoted_currency": e.Quoted, "current_currency": e.Current})
 }
 
 // ListReservations handles GET /v1/my-reservations.  It returns all
@@ -450,12 +441,12 @@ func (h *CustomerHandler) ConfirmSeats(c echo.Context) error {
 func (h *CustomerHandler) ListReservations(c echo.Context) error {
 	userID, err := getUserID(c)
 	if err != nil {
-		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "unauthorized"})
+		return apperr.Unauthorized("unauthorized")
 	}
 	ctx := c.Request().Context()
 	details, err := h.ReservationRepo.ListByUser(ctx, userID)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to load reservations"})
+		return apperr.Internal("failed to load reservations")
 	}
 	return c.JSON(http.StatusOK, echo.Map{
 		"items": details,
@@ -470,20 +461,20 @@ func (h *CustomerHandler) ListReservations(c echo.Context) error {
 func (h *CustomerHandler) GetReservation(c echo.Context) error {
     userID, err := getUserID(c)
     if err != nil {
-        return c.JSON(http.StatusUnauthorized, echo.Map{"error": "unauthorized"})
+        return apperr.Unauthorized("unauthorized")
     }
     resID, err := strconv.ParseUint(c.Param("id"), 10, 64)
     if err != nil || resID == 0 {
-        return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid reservation id"})
+        return apperr.BadRequest("invalid reservation id")
     }
     ctx := c.Request().Context()
     detail, err := h.ReservationRepo.GetByIDForUser(ctx, resID, userID)
     if err != nil {
         if errors.Is(err, sql.ErrNoRows) {
             // reservation not found or not owned by user (ownership enforced in repo)
-            return c.JSON(http.StatusNotFound, echo.Map{"error": "reservation not found"})
+            return apperr.NotFound("reservation not found")
         }
-        return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to fetch reservation"})
+        return apperr.Internal("failed to fetch reservation")
     }
     return c.JSON(http.StatusOK, echo.Map{
         "item": detail,
@@ -494,56 +485,56 @@ func (h *CustomerHandler) GetReservation(c echo.Context) error {
 // reservation belonging to the current user if the associated show has
 // not yet started.  It returns 204 on success, 404 when the
 // reservation does not exist, 403 when the reservation belongs to
-// another user, and 409 when the show has already started.  All
-// operations are executed within a transaction.
+// another user, and 409 when the show has already started, its
+// cancellation cutoff has passed (CANCELLATION_CUTOFF_PASSED, with the
+// cutoff and deadline in details), the reservation was already
+// cancelled (for example with its show) or it was paid for
+// (RESERVATION_PAID; paid reservations are cancelled by requesting a
+// refund).  All operations are executed within a transaction.
 func (h *CustomerHandler) DeleteReservation(c echo.Context) error {
     userID, err := getUserID(c)
     if err != nil {
-        return c.JSON(http.StatusUnauthorized, echo.Map{"error": "unauthorized"})
+        return apperr.Unauthorized("unauthorized")
     }
     resID, err := strconv.ParseUint(c.Param("id"), 10, 64)
     if err != nil || resID == 0 {
-        return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid reservation id"})
+        return apperr.BadRequest("invalid reservation id")
     }
     ctx := c.Request().Context()
-    tx, err := h.ShowRepo.DB().BeginTx(ctx, nil)
-    if err != nil {
-        return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to start transaction"})
+    var before *repository.ReservationDetail
+    if h.Audit != nil {
+        before, _ = h.ReservationRepo.GetByIDForUser(ctx, resID, userID)
     }
-    committed := false
-    defer func() {
-        if !committed {
-            _ = tx.Rollback()
+    if err := h.Booking.CancelReservation(ctx, userID, resID); err != nil {
+        var closed *service.CancelCutoffError
+        switch {
+        case errors.Is(err, sql.ErrNoRows):
+            return apperr.NotFound("reservation not found")
+        case errors.Is(err, repository.ErrForbidden):
+            return apperr.Forbidden("forbidden")
+        case errors.Is(err, repository.ErrReservationCancelled):
+            return apperr.Conflict(apperr.CodeConflict, "reservation is already cancelled")
+        case errors.Is(err, repository.ErrReservationPaid):
+            return apperr.Conflict(apperr.CodeReservationPaid, "paid reservations are cancelled by requesting a refund")
+        case errors.Is(err, service.ErrShowStarted):
+            return apperr.Conflict(apperr.CodeShowStarted, "show already started")
+        case errors.As(err, &closed):
+            return cutoffConflict(closed)
         }
-    }()
-    showID, startTime, seatIDs, err := h.ReservationRepo.GetInfoForUserTx(ctx, tx, resID, userID)
-    if err != nil {
-        if errors.Is(err, sql.ErrNoRows) {
-            return c.JSON(http.StatusNotFound, echo.Map{"error": "reservation not found"})
-        }
-        if errors.Is(err, repository.ErrForbidden) {
-            return c.JSON(http.StatusForbidden, echo.Map{"error": "forbidden"})
-        }
-        return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to load reservation info"})
-    }
-    // Check if the show has already started; if so, return conflict
-    if !startTime.After(time.Now().UTC()) {
-        return c.JSON(http.StatusConflict, echo.Map{"error": "show already started"})
+        return txError(c, failTx("failed to cancel reservation", err))
     }
-    // Delete the reservation; cascade deletes reservation_seats due to FK
-    const del = `DELETE FROM reservations WHERE id = ?`
-    if _, err := tx.ExecContext(ctx, del, resID); err != nil {
-        return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to delete reservation"})
+    if before != nil {
+        recordAudit(c, h.Audit, auditEvent("reservation.cancel", "reservation", resID, hallOwnerID(ctx, h.HallRepo, before.HallID)), before, nil)
     }
-    // Return seats to FREE status
-    if len(seatIDs) > 0 {
-        if err := h.ShowSeatRepo.BulkUpdateStatusTx(ctx, tx, showID, seatIDs, "FREE"); err != nil {
-            return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to update seat status"})
-        }
-    }
-    if err := tx.Commit(); err != nil {
-        return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to commit transaction"})
-    }
-    committed = true
     return c.NoContent(http.StatusNoContent)
 }
+
+// cutoffConflict is the 409 CANCELLATION_CUTOFF_PASSED response for a
+// cancellation or refund request after the show's cutoff.
+func cutoffConflict(closed *service.CancelCutoffError) error {
+    return apperr.Conflict(apperr.CodeCancelCutoff, closed.Error()).
+        WithDetails(echo.Map{
+            "cutoff_minutes": int(closed.Cutoff / time.Minute),
+            "deadline":       closed.Deadline,
+        })
+}
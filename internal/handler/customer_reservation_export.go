@@ -0,0 +1,186 @@
+package handler
+
+// This file implements the customer's own exports: the reservation
+// history as CSV and a single reservation as an iCalendar event.
+
+import (
+    "bytes"
+    "database/sql"
+    "encoding/csv"
+    "errors"
+    "fmt"
+    "net/http"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/iliyamo/cinema-seat-reservation/internal/apperr"
+    "github.com/iliyamo/cinema-seat-reservation/internal/money"
+    "github.com/iliyamo/cinema-seat-reservation/internal/repository"
+    "github.com/labstack/echo/v4"
+)
+
+// historyExportHeader names the columns of a reservation history export.
+var historyExportHeader = []string{
+    "reservation_id", "show_id", "show_title", "cinema", "hall",
+    "starts_at", "ends_at", "seats", "seat_count",
+    "subtotal_cents", "discount_cents", "fees_cents", "tax_cents",
+    "total_amount_cents", "total_amount", "currency", "status", "created_at",
+}
+
+// seatList formats the seats of a reservation as "A1 A2 B7".
+func seatList(d *repository.ReservationDetail) string {
+    labels := make([]string, len(d.Seats))
+    for i, s := range d.Seats {
+        labels[i] = s.RowLabel + strconv.FormatUint(uint64(s.SeatNumber), 10)
+    }
+    return strings.Join(labels, " ")
+}
+
+// formatTimePtr formats t in RFC3339 UTC, or returns "" when t is nil.
+func formatTimePtr(t *time.Time) string {
+    if t == nil {
+        return ""
+    }
+    return t.UTC().Format(time.RFC3339)
+}
+
+// ExportReservations handles GET /v1/my-reservations/export.  It returns
+// every reservation of the caller, newest first, as a CSV attachment
+// (format=csv, the default and only format) with times in RFC3339 UTC.
+func (h *CustomerHandler) ExportReservations(c echo.Context) error {
+    userID, err := getUserID(c)
+    if err != nil {
+        return apperr.Unauthorized("unauthorized")
+    }
+    if format := strings.ToLower(c.QueryParam("format")); format != "" && format != "csv" {
+        return fieldError("format", "must be csv")
+    }
+    details, err := h.ReservationRepo.ListByUser(c.Request().Context(), userID)
+    if err != nil {
+        return apperr.Internal("failed to load reservations")
+    }
+    var buf bytes.Buffer
+    w := csv.NewWriter(&buf)
+    _ = w.Write(historyExportHeader)
+    for i := range details {
+        d := &details[i]
+        cinema := ""
+        if d.CinemaName != nil {
+            cinema = *d.CinemaName
+        }
+        _ = w.Write([]string{
+            strconv.FormatUint(d.ID, 10),
+            strconv.FormatUint(d.ShowID, 10),
+            d.ShowTitle,
+            cinema,
+            d.HallName,
+            formatTimePtr(d.StartTime),
+            formatTimePtr(d.EndTime),
+            seatList(d),
+            strconv.Itoa(len(d.Seats)),
+            strconv.FormatUint(uint64(d.SubtotalCents), 10),
+            strconv.FormatUint(uint64(d.DiscountCents), 10),
+            strconv.FormatUint(uint64(d.FeesCents), 10),
+            strconv.FormatUint(uint64(d.TaxCents), 10),
+            strconv.FormatUint(uint64(d.TotalAmountCents), 10),
+            money.Decimal(int64(d.TotalAmountCents), d.Currency),
+            d.Currency,
+            d.Status,
+            d.CreatedAt.UTC().Format(time.RFC3339),
+        })
+    }
+    w.Flush()
+    if err := w.Error(); err != nil {
+        return apperr.Internal("failed to export reservations")
+    }
+    c.Response().Header().Set(echo.HeaderContentDisposition, `attachment; filename="my-reservations.csv"`)
+    return c.Blob(http.StatusOK, "text/csv; charset=utf-8", buf.Bytes())
+}
+
+// ReservationCalendar handles GET /v1/reservations/:id/calendar.ics.  It
+// returns the caller's reservation as an iCalendar (RFC 5545) event
+// spanning the show, located at its hall and cinema and listing the
+// seats.  Cancelled reservations are returned with STATUS:CANCELLED so a
+// calendar that imported the event earlier can drop it.
+func (h *CustomerHandler) ReservationCalendar(c echo.Context) error {
+    userID, err := getUserID(c)
+    if err != nil {
+        return apperr.Unauthorized("unauthorized")
+    }
+    resID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+    if err != nil || resID == 0 {
+        return apperr.BadRequest("invalid reservation id")
+    }
+    d, err := h.ReservationRepo.GetByIDForUser(c.Request().Context(), resID, userID)
+    if err != nil {
+        if errors.Is(err, sql.ErrNoRows) {
+            return apperr.NotFound("reservation not found")
+        }
+        return apperr.Internal("failed to fetch reservation")
+    }
+    c.Response().Header().Set(echo.HeaderContentDisposition,
+        `attachment; filename="reservation-`+strconv.FormatUint(d.ID, 10)+`.ics"`)
+    return c.Blob(http.StatusOK, "text/calendar; charset=utf-8", reservationICS(d, time.Now()))
+}
+
+// icsTimeLayout is the UTC date-time format of iCalendar.
+const icsTimeLayout = "20060102T150405Z"
+
+// reservationICS renders d as a VCALENDAR with a single VEVENT.
+func reservationICS(d *repository.ReservationDetail, now time.Time) []byte {
+    location := d.HallName
+    if d.CinemaName != nil && *d.CinemaName != "" {
+        location += ", " + *d.CinemaName
+    }
+    seats := seatList(d)
+    desc := fmt.Sprintf("Reservation %d\nSeats: %s", d.ID, seats)
+    status := "CONFIRMED"
+    switch d.Status {
+    case "PENDING":
+        status = "TENTATIVE"
+    case "CANCELLED":
+        status = "CANCELLED"
+    }
+    var b bytes.Buffer
+    line := func(s string) {
+        // Lines longer than 75 octets are folded (RFC 5545, 3.1).
+        for len(s) > 75 {
+            cut := 75
+            for cut > 0 && s[cut]&0xC0 == 0x80 {
+                cut-- // do not split a UTF-8 sequence
+            }
+            b.WriteString(s[:cut] + "\r\n")
+            s = " " + s[cut:]
+        }
+        b.WriteString(s + "\r\n")
+    }
+    line("BEGIN:VCALENDAR")
+    line("VERSION:2.0")
+    line("PRODID:-//cinema-seat-reservation//reservations//EN")
+    line("CALSCALE:GREGORIAN")
+    line("METHOD:PUBLISH")
+    line("BEGIN:VEVENT")
+    line("UID:reservation-" + strconv.FormatUint(d.ID, 10) + "@cinema-seat-reservation")
+    line("DTSTAMP:" + now.UTC().Format(icsTimeLayout))
+    if d.StartTime != nil {
+        line("DTSTART:" + d.StartTime.UTC().Format(icsTimeLayout))
+    }
+    if d.EndTime != nil {
+        line("DTEND:" + d.EndTime.UTC().Format(icsTimeLayout))
+    }
+    line("SUMMARY:" + icsEscape(d.ShowTitle))
+    line("LOCATION:" + icsEscape(location))
+    line("DESCRIPTION:" + icsEscape(desc))
+    line("STATUS:" + status)
+    line("END:VEVENT")
+    line("END:VCALENDAR")
+    return b.Bytes()
+}
+
+// icsEscaper escapes the characters that are special in iCalendar TEXT
+// values.
+var icsEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`, "\r", "")
+
+// icsEscape escapes an iCalendar TEXT value.
+func icsEscape(s string) string { return icsEscaper.Replace(s) }
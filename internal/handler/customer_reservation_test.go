@@ -0,0 +1,357 @@
+package handler_test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/iliyamo/cinema-seat-reservation/internal/apperr"
+	"github.com/iliyamo/cinema-seat-reservation/internal/handler"
+	"github.com/iliyamo/cinema-seat-reservation/internal/repository"
+	"github.com/iliyamo/cinema-seat-reservation/internal/repository/mock"
+)
+
+// customerID is the authenticated customer of the booking tests and
+// showID the show they book.
+const (
+	customerID = 7
+	showID     = 3
+)
+
+// bookingStores are the doubles behind a CustomerHandler.  newBookingStores
+// sets up show showID as SCHEDULED with every seat at 1000 cents; tests
+// override the functions they exercise.
+type bookingStores struct {
+	shows        *mock.ShowStore
+	showSeats    *mock.ShowSeatStore
+	holds        *mock.SeatHoldStore
+	reservations *mock.ReservationStore
+}
+
+func newBookingStores(t *testing.T) *bookingStores {
+	t.Helper()
+	conn := (&mock.Driver{}).DB()
+	t.Cleanup(func() { conn.Close() })
+	return &bookingStores{
+		shows: &mock.ShowStore{
+			Conn: conn,
+			GetByIDFn: func(_ context.Context, id uint64) (*repository.Show, error) {
+				if id != showID {
+					return nil, repository.ErrShowNotFound
+				}
+				return &repository.Show{ID: id, HallID: 1, Status: "SCHEDULED", StartsAt: time.Now().Add(24 * time.Hour)}, nil
+			},
+			StatusForShareTxFn: func(context.Context, *sql.Tx, uint64) (string, error) { return "SCHEDULED", nil },
+			CurrencyTxFn:       func(context.Context, *sql.Tx, uint64) (string, error) { return "USD", nil },
+		},
+		showSeats: &mock.ShowSeatStore{
+			GetPricesBySeatIDsTxFn: func(_ context.Context, _ *sql.Tx, _ uint64, seatIDs []uint64) (map[uint64]uint32, error) {
+				prices := make(map[uint64]uint32, len(seatIDs))
+				for _, sid := range seatIDs {
+					prices[sid] = 1000
+				}
+				return prices, nil
+			},
+		},
+		holds:        &mock.SeatHoldStore{},
+		reservations: &mock.ReservationStore{},
+	}
+}
+
+// server returns an echo instance serving the hold, confirm and cancel
+// routes as customerID.
+func (s *bookingStores) server() *echo.Echo {
+	h := handler.NewCustomerHandler(&mock.SeatStore{}, s.shows, s.showSeats, s.holds, s.reservations, &mock.HallStore{}, nil)
+	e := echo.New()
+	e.HTTPErrorHandler = apperr.Handler
+	g := e.Group("/v1", func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.Set("user_id", uint64(customerID))
+			return next(c)
+		}
+	})
+	g.POST("/shows/:id/hold", h.HoldSeats)
+	g.POST("/shows/:id/confirm", h.ConfirmSeats)
+	g.DELETE("/reservations/:id", h.DeleteReservation)
+	return e
+}
+
+// serve sends a request to e and returns the recorded response.
+func serve(e *echo.Echo, method, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+// errorCode returns the error code of an apperr response body.
+func errorCode(t *testing.T, rec *httptest.ResponseRecorder) apperr.Code {
+	t.Helper()
+	var body struct {
+		Code apperr.Code `json:"code"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decoding %q: %v", rec.Body.String(), err)
+	}
+	return body.Code
+}
+
+// freeSeats answers LockSeatsTx with every requested seat FREE except
+// those in taken, which are RESERVED.
+func freeSeats(taken ...uint64) func(context.Context, *sql.Tx, uint64, []uint64) (map[uint64]repository.LockedSeat, error) {
+	return func(_ context.Context, _ *sql.Tx, _ uint64, seatIDs []uint64) (map[uint64]repository.LockedSeat, error) {
+		seats := make(map[uint64]repository.LockedSeat, len(seatIDs))
+		for _, sid := range seatIDs {
+			seats[sid] = repository.LockedSeat{SeatID: sid, Status: "FREE", PriceCents: 1000}
+		}
+		for _, sid := range taken {
+			seats[sid] = repository.LockedSeat{SeatID: sid, Status: "RESERVED", PriceCents: 1000}
+		}
+		return seats, nil
+	}
+}
+
+func TestHoldSeats(t *testing.T) {
+	s := newBookingStores(t)
+	s.showSeats.LockSeatsTxFn = freeSeats()
+	var created []repository.SeatHoldRecord
+	s.holds.CreateMultipleTxFn = func(_ context.Context, _ *sql.Tx, holds []repository.SeatHoldRecord) error {
+		created = holds
+		return nil
+	}
+	var held []uint64
+	s.showSeats.BulkUpdateStatusTxFn = func(_ context.Context, _ *sql.Tx, _ uint64, seatIDs []uint64, status string) error {
+		if status == "HELD" {
+			held = seatIDs
+		}
+		return nil
+	}
+
+	rec := serve(s.server(), http.MethodPost, "/v1/shows/3/hold", `{"seat_ids":[11,12,11]}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("status %d, want 201: %s", rec.Code, rec.Body)
+	}
+	if len(created) != 2 || len(held) != 2 {
+		t.Fatalf("created %d holds and held seats %v, want 2 of each", len(created), held)
+	}
+	for _, hld := range created {
+		if hld.UserID != customerID || hld.ShowID != showID || hld.HoldToken == "" {
+			t.Fatalf("hold %+v", hld)
+		}
+		if hld.PriceCents == nil || *hld.PriceCents != 1000 {
+			t.Fatalf("hold for seat %d quoted %v, want 1000", hld.SeatID, hld.PriceCents)
+		}
+	}
+	var body struct {
+		SeatIDs    []uint64 `json:"seat_ids"`
+		HoldTokens []string `json:"hold_tokens"`
+		Total      uint32   `json:"quoted_total_cents"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if len(body.SeatIDs) != 2 || len(body.HoldTokens) != 2 || body.Total != 2000 {
+		t.Fatalf("response %s", rec.Body)
+	}
+}
+
+func TestHoldSeatsUnavailable(t *testing.T) {
+	s := newBookingStores(t)
+	s.showSeats.LockSeatsTxFn = freeSeats(12)
+	s.holds.CreateMultipleTxFn = func(context.Context, *sql.Tx, []repository.SeatHoldRecord) error {
+		t.Fatal("holds created for an unavailable seat")
+		return nil
+	}
+
+	rec := serve(s.server(), http.MethodPost, "/v1/shows/3/hold", `{"seat_ids":[11,12]}`)
+	if rec.Code != http.StatusBadRequest || errorCode(t, rec) != apperr.CodeSeatUnavailable {
+		t.Fatalf("status %d, want 400 %s: %s", rec.Code, apperr.CodeSeatUnavailable, rec.Body)
+	}
+	if !strings.Contains(rec.Body.String(), `"unavailable":[12]`) {
+		t.Fatalf("response does not list seat 12: %s", rec.Body)
+	}
+}
+
+func TestHoldSeatsRejectsBadRequests(t *testing.T) {
+	cases := []struct {
+		name, path, body string
+		status           int
+	}{
+		{"unknown show", "/v1/shows/4/hold", `{"seat_ids":[11]}`, http.StatusNotFound},
+		{"invalid show id", "/v1/shows/x/hold", `{"seat_ids":[11]}`, http.StatusBadRequest},
+		{"no seats", "/v1/shows/3/hold", `{"seat_ids":[0]}`, http.StatusBadRequest},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := newBookingStores(t)
+			if rec := serve(s.server(), http.MethodPost, tc.path, tc.body); rec.Code != tc.status {
+				t.Fatalf("status %d, want %d: %s", rec.Code, tc.status, rec.Body)
+			}
+		})
+	}
+}
+
+// heldSeats sets up active holds of customerID on the given seats, quoted
+// at quoted cents each, with LockSeatsTx reporting them HELD by the
+// customer.
+func (s *bookingStores) heldSeats(quoted uint32, seatIDs ...uint64) {
+	currency := "USD"
+	var holds []repository.SeatHoldRecord
+	for i, sid := range seatIDs {
+		holds = append(holds, repository.SeatHoldRecord{
+			ID: uint64(100 + i), UserID: customerID, ShowID: showID, SeatID: sid,
+			HoldToken: "token", PriceCents: &quoted, Currency: &currency,
+			ExpiresAt: time.Now().Add(5 * time.Minute),
+		})
+	}
+	s.holds.ActiveHoldsByUserAndShowTxFn = func(context.Context, *sql.Tx, uint64, uint64) ([]repository.SeatHoldRecord, error) {
+		return holds, nil
+	}
+	s.showSeats.LockSeatsTxFn = func(_ context.Context, _ *sql.Tx, _ uint64, ids []uint64) (map[uint64]repository.LockedSeat, error) {
+		seats := make(map[uint64]repository.LockedSeat, len(ids))
+		for _, sid := range ids {
+			by := uint64(customerID)
+			seats[sid] = repository.LockedSeat{SeatID: sid, Status: "HELD", PriceCents: 1000, HeldBy: &by}
+		}
+		return seats, nil
+	}
+}
+
+func TestConfirmSeats(t *testing.T) {
+	s := newBookingStores(t)
+	s.heldSeats(1000, 11, 12)
+	var created *repository.ReservationRecord
+	s.reservations.CreateTxFn = func(_ context.Context, _ *sql.Tx, res *repository.ReservationRecord) error {
+		res.ID = 99
+		created = res
+		return nil
+	}
+	var reserved []uint64
+	s.showSeats.BulkUpdateStatusTxFn = func(_ context.Context, _ *sql.Tx, _ uint64, seatIDs []uint64, status string) error {
+		if status == "RESERVED" {
+			reserved = seatIDs
+		}
+		return nil
+	}
+	released := false
+	s.holds.DeleteByUserAndShowTxFn = func(context.Context, *sql.Tx, uint64, uint64) ([]uint64, error) {
+		released = true
+		return nil, nil
+	}
+
+	rec := serve(s.server(), http.MethodPost, "/v1/shows/3/confirm", `{}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("status %d, want 201: %s", rec.Code, rec.Body)
+	}
+	if created == nil || created.Status != "CONFIRMED" || created.TotalAmountCents != 2000 || created.Currency != "USD" {
+		t.Fatalf("reservation %+v", created)
+	}
+	if len(reserved) != 2 || !released {
+		t.Fatalf("reserved seats %v, holds released %v", reserved, released)
+	}
+	if !strings.Contains(rec.Body.String(), `"reservation_id":99`) {
+		t.Fatalf("response %s", rec.Body)
+	}
+}
+
+func TestConfirmSeatsPriceChanged(t *testing.T) {
+	s := newBookingStores(t)
+	s.heldSeats(800, 11)
+	s.reservations.CreateTxFn = func(context.Context, *sql.Tx, *repository.ReservationRecord) error {
+		t.Fatal("reservation created at an unaccepted price")
+		return nil
+	}
+
+	rec := serve(s.server(), http.MethodPost, "/v1/shows/3/confirm", `{}`)
+	if rec.Code != http.StatusConflict || errorCode(t, rec) != apperr.CodePriceChanged {
+		t.Fatalf("status %d, want 409 %s: %s", rec.Code, apperr.CodePriceChanged, rec.Body)
+	}
+
+	s.reservations.CreateTxFn = nil
+	rec = serve(s.server(), http.MethodPost, "/v1/shows/3/confirm", `{"accept_price_change":true}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("accepted price change: status %d, want 201: %s", rec.Code, rec.Body)
+	}
+}
+
+func TestConfirmSeatsWithoutActiveHolds(t *testing.T) {
+	s := newBookingStores(t)
+	s.reservations.CreateTxFn = func(context.Context, *sql.Tx, *repository.ReservationRecord) error {
+		t.Fatal("reservation created without holds")
+		return nil
+	}
+
+	rec := serve(s.server(), http.MethodPost, "/v1/shows/3/confirm", `{}`)
+	if rec.Code != http.StatusBadRequest || errorCode(t, rec) != apperr.CodeNoActiveHolds {
+		t.Fatalf("status %d, want 400 %s: %s", rec.Code, apperr.CodeNoActiveHolds, rec.Body)
+	}
+}
+
+func TestDeleteReservation(t *testing.T) {
+	s := newBookingStores(t)
+	s.reservations.GetInfoForUserTxFn = func(_ context.Context, _ *sql.Tx, resID, userID uint64) (uint64, time.Time, []uint64, error) {
+		if resID != 5 || userID != customerID {
+			t.Fatalf("reservation %d of user %d", resID, userID)
+		}
+		return showID, time.Now().Add(24 * time.Hour), []uint64{11, 12}, nil
+	}
+	var freed []uint64
+	s.showSeats.BulkUpdateStatusTxFn = func(_ context.Context, _ *sql.Tx, _ uint64, seatIDs []uint64, status string) error {
+		if status == "FREE" {
+			freed = seatIDs
+		}
+		return nil
+	}
+
+	rec := serve(s.server(), http.MethodDelete, "/v1/reservations/5", "")
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("status %d, want 204: %s", rec.Code, rec.Body)
+	}
+	if len(freed) != 2 {
+		t.Fatalf("freed seats %v, want 11 and 12", freed)
+	}
+}
+
+func TestDeleteReservationErrors(t *testing.T) {
+	future := time.Now().Add(24 * time.Hour)
+	cases := []struct {
+		name      string
+		infoErr   error
+		startsAt  time.Time
+		cancelErr error
+		status    int
+		code      apperr.Code
+	}{
+		{"not found", sql.ErrNoRows, future, nil, http.StatusNotFound, apperr.CodeNotFound},
+		{"other customer", repository.ErrForbidden, future, nil, http.StatusForbidden, apperr.CodeForbidden},
+		{"already cancelled", nil, future, repository.ErrReservationCancelled, http.StatusConflict, apperr.CodeConflict},
+		{"paid", nil, future, repository.ErrReservationPaid, http.StatusConflict, apperr.CodeReservationPaid},
+		{"show started", nil, time.Now().Add(-time.Minute), nil, http.StatusConflict, apperr.CodeShowStarted},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := newBookingStores(t)
+			s.reservations.GetInfoForUserTxFn = func(context.Context, *sql.Tx, uint64, uint64) (uint64, time.Time, []uint64, error) {
+				return showID, tc.startsAt, []uint64{11}, tc.infoErr
+			}
+			s.reservations.CancelTxFn = func(context.Context, *sql.Tx, uint64) error { return tc.cancelErr }
+			s.showSeats.BulkUpdateStatusTxFn = func(context.Context, *sql.Tx, uint64, []uint64, string) error {
+				t.Fatal("seats freed although the cancellation failed")
+				return nil
+			}
+
+			rec := serve(s.server(), http.MethodDelete, "/v1/reservations/5", "")
+			if rec.Code != tc.status || errorCode(t, rec) != tc.code {
+				t.Fatalf("status %d, want %d %s: %s", rec.Code, tc.status, tc.code, rec.Body)
+			}
+		})
+	}
+}
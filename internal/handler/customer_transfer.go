@@ -0,0 +1,187 @@
+package handler
+
+// This file lets a customer hand a reservation to someone else, for
+// example a gift or a companion's ticket.  The holder issues a
+// single-use transfer code and passes it on; whoever claims the code
+// before the show starts becomes the reservation's owner.  Only the
+// SHA-256 hash of the code is stored.
+
+import (
+    "database/sql"
+    "errors"
+    "fmt"
+    "net/http"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/iliyamo/cinema-seat-reservation/internal/apperr"
+    "github.com/iliyamo/cinema-seat-reservation/internal/db"
+    "github.com/iliyamo/cinema-seat-reservation/internal/repository"
+    "github.com/iliyamo/cinema-seat-reservation/internal/utils"
+    "github.com/labstack/echo/v4"
+)
+
+// defaultTransferTTL is the lifetime of a transfer code when
+// CustomerHandler.TransferTTL is zero.
+const defaultTransferTTL = 72 * time.Hour
+
+// claimReservationReq is the body of POST /v1/reservations/claim.
+type claimReservationReq struct {
+    Code string `json:"code" validate:"required,max=128"`
+}
+
+// TransferReservation handles POST /v1/reservations/:id/transfer.  It
+// issues a transfer code for one of the caller's CONFIRMED reservations
+// whose show has not started and that has no open refund request (409
+// otherwise).  Issuing a new code voids the earlier unclaimed one.  The
+// code expires after TransferTTL or when the show starts, whichever is
+// first, and is only returned in this 201 response.
+func (h *CustomerHandler) TransferReservation(c echo.Context) error {
+    userID, err := getUserID(c)
+    if err != nil {
+        return apperr.Unauthorized("unauthorized")
+    }
+    resID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+    if err != nil || resID == 0 {
+        return apperr.BadRequest("invalid reservation id")
+    }
+    if h.TransferRepo == nil {
+        return apperr.NotImplemented("reservation transfers are not available")
+    }
+    ctx := c.Request().Context()
+    res, err := h.ReservationRepo.GetByIDForUser(ctx, resID, userID)
+    if err != nil {
+        if errors.Is(err, sql.ErrNoRows) {
+            return apperr.NotFound("reservation not found")
+        }
+        return apperr.Internal("failed to fetch reservation")
+    }
+    if res.Status != "CONFIRMED" {
+        return apperr.Conflict(apperr.CodeConflict, "only confirmed reservations can be transferred")
+    }
+    show, err := h.ShowRepo.GetByID(ctx, res.ShowID)
+    if err != nil {
+        return apperr.Internal("database error")
+    }
+    now := time.Now()
+    if !show.StartsAt.After(now) {
+        return apperr.Conflict(apperr.CodeShowStarted, "show already started")
+    }
+    if h.RefundRepo != nil {
+        refund, err := h.RefundRepo.GetByReservationForUser(ctx, resID, userID)
+        if err != nil && !errors.Is(err, repository.ErrRefundNotFound) {
+            return apperr.Internal("failed to load refund")
+        }
+        if refund != nil && (refund.Status == "REQUESTED" || refund.Status == "APPROVED") {
+            return apperr.Conflict(apperr.CodeConflict, "a refund is open for this reservation")
+        }
+    }
+    ttl := h.TransferTTL
+    if ttl <= 0 {
+        ttl = defaultTransferTTL
+    }
+    if until := show.StartsAt.Sub(now); until < ttl {
+        ttl = until
+    }
+    tok, err := utils.NewResetToken(ttl)
+    if err != nil {
+        return apperr.Internal("failed to generate transfer code")
+    }
+    transfer := repository.ReservationTransfer{ReservationID: resID, FromUserID: userID, ExpiresAt: tok.Exp}
+    err = db.WithTx(ctx, h.TransferRepo.DB(), func(tx *sql.Tx) error {
+        if err := h.TransferRepo.CreateTx(ctx, tx, &transfer, utils.HashRefreshRaw(tok.Raw)); err != nil {
+            return failTx("failed to create transfer code", err)
+        }
+        return nil
+    })
+    if err != nil {
+        return txError(c, err)
+    }
+    recordAudit(c, h.Audit, auditEvent("reservation.transfer", "reservation", resID, hallOwnerID(ctx, h.HallRepo, res.HallID)), nil, transfer)
+    return c.JSON(http.StatusCreated, echo.Map{
+        "reservation_id": resID,
+        "code":           tok.Raw,
+        "expires_at":     transfer.ExpiresAt.UTC(),
+    })
+}
+
+// ClaimReservation handles POST /v1/reservations/claim.  It redeems a
+// transfer code: the reservation moves to the caller, the code is used
+// up and both customers are notified.  Unknown, used and expired codes
+// give 404; a reservation that was cancelled, refunded or whose show
+// started since the code was issued gives 409, as does claiming one's
+// own code.  It responds with the reservation as the caller now sees it.
+func (h *CustomerHandler) ClaimReservation(c echo.Context) error {
+    userID, err := getUserID(c)
+    if err != nil {
+        return apperr.Unauthorized("unauthorized")
+    }
+    if h.TransferRepo == nil {
+        return apperr.NotImplemented("reservation transfers are not available")
+    }
+    var body claimReservationReq
+    if err := bindValid(c, &body); err != nil {
+        return err
+    }
+    ctx := c.Request().Context()
+    codeHash := utils.HashRefreshRaw(strings.TrimSpace(body.Code))
+    var transfer *repository.ReservationTransfer
+    err = db.WithTx(ctx, h.TransferRepo.DB(), func(tx *sql.Tx) error {
+        t, err := h.TransferRepo.ClaimTx(ctx, tx, codeHash, userID)
+        switch {
+        case errors.Is(err, repository.ErrTransferNotFound):
+            return apperr.NotFound("transfer code not found or expired")
+        case errors.Is(err, repository.ErrTransferNotClaimable):
+            return apperr.Conflict(apperr.CodeConflict, "reservation can no longer be transferred")
+        case errors.Is(err, repository.ErrTransferToSelf):
+            return apperr.Conflict(apperr.CodeConflict, "you cannot claim your own transfer code")
+        case err != nil:
+            return failTx("failed to claim reservation", err)
+        }
+        transfer = t
+        if h.Notifications != nil {
+            for _, n := range transferNotifications(t) {
+                if err := h.Notifications.EnqueueTx(ctx, tx, &n); err != nil {
+                    return failTx("failed to queue notifications", err)
+                }
+            }
+        }
+        return nil
+    })
+    if err != nil {
+        return txError(c, err)
+    }
+    detail, err := h.ReservationRepo.GetByIDForUser(ctx, transfer.ReservationID, userID)
+    if err != nil {
+        return apperr.Internal("failed to fetch reservation")
+    }
+    recordAudit(c, h.Audit, auditEvent("reservation.claim", "reservation", transfer.ReservationID, hallOwnerID(ctx, h.HallRepo, detail.HallID)),
+        echo.Map{"user_id": transfer.FromUserID}, echo.Map{"user_id": userID})
+    return c.JSON(http.StatusOK, echo.Map{
+        "item": detail,
+    })
+}
+
+// transferNotifications returns the messages telling the previous and
+// the new holder of a reservation that it changed hands.
+func transferNotifications(t *repository.ReservationTransfer) []repository.Notification {
+    resID := t.ReservationID
+    starts := t.StartsAt.UTC().Format(time.RFC3339)
+    return []repository.Notification{
+        {
+            UserID:        t.FromUserID,
+            ReservationID: &resID,
+            Kind:          "reservation.transferred",
+            Subject:       "Reservation transferred: " + t.ShowTitle,
+            Body:          fmt.Sprintf("Your reservation #%d for %q on %s was claimed with your transfer code and now belongs to another account.\n", resID, t.ShowTitle, starts),
+        },
+        {
+            UserID:        *t.ClaimedBy,
+            ReservationID: &resID,
+            Kind:          "reservation.received",
+            Subject:       "Reservation received: " + t.ShowTitle,
+            Body:          fmt.Sprintf("Reservation #%d for %q on %s is now yours.\n", resID, t.ShowTitle, starts),
+        },
+    }
+}
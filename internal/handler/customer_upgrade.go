@@ -0,0 +1,108 @@
+package handler
+
+// This file lets customers move to better seats after booking: STANDARD
+// seats of a confirmed reservation are swapped for FREE VIP seats of the
+// same show and the price difference is added to the reservation.
+
+import (
+    "database/sql"
+    "errors"
+    "net/http"
+    "strconv"
+
+    "github.com/iliyamo/cinema-seat-reservation/internal/apperr"
+    "github.com/iliyamo/cinema-seat-reservation/internal/repository"
+    "github.com/iliyamo/cinema-seat-reservation/internal/service"
+    "github.com/labstack/echo/v4"
+)
+
+// upgradeSeatsReq is the body of POST /v1/reservations/:id/upgrade.
+type upgradeSeatsReq struct {
+    Seats []upgradeSeatReq `json:"seats" validate:"required,max=50"`
+}
+
+// upgradeSeatReq swaps one seat of the reservation for another.
+type upgradeSeatReq struct {
+    FromSeatID uint64 `json:"from_seat_id" validate:"required"`
+    ToSeatID   uint64 `json:"to_seat_id" validate:"required"`
+}
+
+// UpgradeReservation handles POST /v1/reservations/:id/upgrade.  It
+// swaps STANDARD seats of one of the caller's CONFIRMED reservations for
+// FREE VIP seats of the same show before the show starts, in one
+// transaction: the reservation keeps its ID, its seats and total change
+// and the old seats become FREE.  The price difference is charged through
+// the payment gateway when the reservation was paid through checkout (402
+// PAYMENT_FAILED leaves the reservation unchanged).  Targets that are
+// taken give 400 SEAT_UNAVAILABLE; swaps that are not an upgrade give 409
+// UPGRADE_NOT_ALLOWED with the offending seat_ids.  It responds 200 with
+// the upgrade, including price_difference_cents and the new
+// total_amount_cents.
+func (h *CustomerHandler) UpgradeReservation(c echo.Context) error {
+    userID, err := getUserID(c)
+    if err != nil {
+        return apperr.Unauthorized("unauthorized")
+    }
+    resID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+    if err != nil || resID == 0 {
+        return apperr.BadRequest("invalid reservation id")
+    }
+    if h.Upgrades == nil {
+        return apperr.NotImplemented("seat upgrades are not available")
+    }
+    var body upgradeSeatsReq
+    if err := bindValid(c, &body); err != nil {
+        return err
+    }
+    ctx := c.Request().Context()
+    // A refund in progress is for the reservation as it was; upgrading
+    // it now would change what is being refunded.
+    if h.RefundRepo != nil {
+        refund, err := h.RefundRepo.GetByReservationForUser(ctx, resID, userID)
+        if err != nil && !errors.Is(err, repository.ErrRefundNotFound) {
+            return apperr.Internal("failed to load refund")
+        }
+        if refund != nil && (refund.Status == "REQUESTED" || refund.Status == "APPROVED") {
+            return apperr.Conflict(apperr.CodeConflict, "a refund is open for this reservation")
+        }
+    }
+    var before *repository.ReservationDetail
+    if h.Audit != nil {
+        before, _ = h.ReservationRepo.GetByIDForUser(ctx, resID, userID)
+    }
+    req := service.UpgradeRequest{UserID: userID, ReservationID: resID}
+    for _, s := range body.Seats {
+        req.Seats = append(req.Seats, service.SeatSwap{FromSeatID: s.FromSeatID, ToSeatID: s.ToSeatID})
+    }
+    up, err := h.Upgrades.Upgrade(ctx, req)
+    if err != nil {
+        var (
+            notAllowed  *service.UpgradeNotAllowedError
+            unavailable *service.SeatsUnavailableError
+        )
+        switch {
+        case errors.Is(err, sql.ErrNoRows):
+            return apperr.NotFound("reservation not found")
+        case errors.Is(err, repository.ErrForbidden):
+            return apperr.Forbidden("forbidden")
+        case errors.Is(err, repository.ErrReservationCancelled):
+            return apperr.Conflict(apperr.CodeConflict, "only confirmed reservations can be upgraded")
+        case errors.Is(err, service.ErrShowStarted):
+            return apperr.Conflict(apperr.CodeShowStarted, "show already started")
+        case errors.As(err, &notAllowed):
+            return apperr.Conflict(apperr.CodeNotUpgradable, notAllowed.Error()).
+                WithDetails(echo.Map{"seat_ids": notAllowed.SeatIDs})
+        case errors.As(err, &unavailable):
+            return apperr.New(http.StatusBadRequest, apperr.CodeSeatUnavailable, "some seats are unavailable").
+                WithDetails(echo.Map{"unavailable": unavailable.SeatIDs})
+        case errors.Is(err, service.ErrPaymentDeclined):
+            return apperr.New(http.StatusPaymentRequired, apperr.CodePaymentFailed, "payment was declined")
+        }
+        return txError(c, failTx("failed to upgrade seats", err))
+    }
+    if before != nil {
+        after, _ := h.ReservationRepo.GetByIDForUser(ctx, resID, userID)
+        recordAudit(c, h.Audit, auditEvent("reservation.upgrade", "reservation", resID, hallOwnerID(ctx, h.HallRepo, before.HallID)), before, after)
+    }
+    return c.JSON(http.StatusOK, up)
+}
@@ -0,0 +1,167 @@
+package handler
+
+// This file exposes show waitlists.  A customer who cannot get seats joins
+// the waitlist of a show; when seats come free the waitlist worker
+// (service.WaitlistService) holds them for the head of the queue and the
+// entry turns OFFERED.  The customer then confirms the held seats through
+// POST /v1/shows/:id/confirm, or declines the offer so it passes on.
+
+import (
+    "database/sql"
+    "errors"
+    "net/http"
+    "strconv"
+    "time"
+
+    "github.com/iliyamo/cinema-seat-reservation/internal/apperr"
+    "github.com/iliyamo/cinema-seat-reservation/internal/db"
+    "github.com/iliyamo/cinema-seat-reservation/internal/repository"
+    "github.com/iliyamo/cinema-seat-reservation/internal/service"
+    "github.com/labstack/echo/v4"
+)
+
+// joinWaitlistReq is the body of POST /v1/shows/:id/waitlist.  Adjacent
+// defaults to true.
+type joinWaitlistReq struct {
+    SeatCount int   `json:"seat_count" validate:"required"`
+    Adjacent  *bool `json:"adjacent"`
+}
+
+// JoinWaitlist handles POST /v1/shows/:id/waitlist.  It queues the caller
+// for seat_count seats of a show that cannot currently seat them and
+// responds 201 with the entry and its position.  It responds 409 CONFLICT
+// when enough seats are free (the client should hold them directly), 409
+// ALREADY_EXISTS when the caller is already on the waitlist and 409
+// SHOW_NOT_BOOKABLE for cancelled or started shows.
+func (h *CustomerHandler) JoinWaitlist(c echo.Context) error {
+    if h.Waitlist == nil {
+        return apperr.NotImplemented("waitlists are not enabled")
+    }
+    userID, err := getUserID(c)
+    if err != nil {
+        return apperr.Unauthorized("unauthorized")
+    }
+    showID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+    if err != nil || showID == 0 {
+        return apperr.BadRequest("invalid show id")
+    }
+    var body joinWaitlistReq
+    if err := bindValid(c, &body); err != nil {
+        return err
+    }
+    if body.SeatCount < 1 || body.SeatCount > maxAutoHoldSeats {
+        return fieldError("seat_count", "must be between 1 and "+strconv.Itoa(maxAutoHoldSeats))
+    }
+    adjacent := body.Adjacent == nil || *body.Adjacent
+    ctx := c.Request().Context()
+    show, err := h.ShowRepo.GetByID(ctx, showID)
+    if err != nil {
+        if err == repository.ErrShowNotFound {
+            return apperr.NotFound("show not found")
+        }
+        return apperr.Internal("database error")
+    }
+    if show.Status != "SCHEDULED" || !show.StartsAt.After(time.Now()) {
+        return apperr.Conflict(apperr.CodeShowNotBookable, "show is not bookable")
+    }
+    layout, err := h.SeatRepo.GetByHall(ctx, show.HallID)
+    if err != nil {
+        return apperr.Internal("failed to load seats")
+    }
+    seats, err := h.ShowSeatRepo.ListWithStatus(ctx, showID)
+    if err != nil {
+        return apperr.Internal("failed to load seat availability")
+    }
+    if service.PickSeats(layout, seats, body.SeatCount, adjacent) != nil {
+        return apperr.Conflict(apperr.CodeConflict, "seats are available; hold them instead")
+    }
+    entry := repository.WaitlistEntry{ShowID: showID, UserID: userID, SeatCount: body.SeatCount, Adjacent: adjacent}
+    if err := h.Waitlist.Repo.Create(ctx, &entry); err != nil {
+        if errors.Is(err, repository.ErrWaitlistExists) {
+            return apperr.Conflict(apperr.CodeAlreadyExists, "already on the waitlist for this show")
+        }
+        return apperr.Internal("failed to join waitlist")
+    }
+    return c.JSON(http.StatusCreated, echo.Map{"item": entry})
+}
+
+// ListWaitlist handles GET /v1/waitlist and returns the caller's waitlist
+// entries, newest first.  OFFERED entries carry offer_expires_at; the
+// offered seats are the caller's holds on that show.
+func (h *CustomerHandler) ListWaitlist(c echo.Context) error {
+    if h.Waitlist == nil {
+        return apperr.NotImplemented("waitlists are not enabled")
+    }
+    userID, err := getUserID(c)
+    if err != nil {
+        return apperr.Unauthorized("unauthorized")
+    }
+    items, err := h.Waitlist.Repo.ListByUser(c.Request().Context(), userID)
+    if err != nil {
+        return apperr.Internal("failed to load waitlist")
+    }
+    return c.JSON(http.StatusOK, echo.Map{"items": items})
+}
+
+// DeclineWaitlist handles POST /v1/waitlist/:id/decline.  A WAITING entry
+// leaves the queue (CANCELLED).  An OFFERED entry is DECLINED and the
+// caller's holds on the show are released so the seats pass to the next
+// customer in line.  Other entries respond 409.
+func (h *CustomerHandler) DeclineWaitlist(c echo.Context) error {
+    if h.Waitlist == nil {
+        return apperr.NotImplemented("waitlists are not enabled")
+    }
+    userID, err := getUserID(c)
+    if err != nil {
+        return apperr.Unauthorized("unauthorized")
+    }
+    id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+    if err != nil || id == 0 {
+        return apperr.BadRequest("invalid waitlist entry id")
+    }
+    ctx := c.Request().Context()
+    var (
+        entry    *repository.WaitlistEntry
+        released int
+    )
+    err = db.WithTx(ctx, h.ShowRepo.DB(), func(tx *sql.Tx) error {
+        var err error
+        entry, err = h.Waitlist.Repo.GetForUserTx(ctx, tx, id, userID)
+        if err != nil {
+            if errors.Is(err, sql.ErrNoRows) {
+                return apperr.NotFound("waitlist entry not found")
+            }
+            return failTx("failed to load waitlist entry", err)
+        }
+        switch entry.Status {
+        case repository.WaitlistWaiting:
+            entry.Status = repository.WaitlistCancelled
+        case repository.WaitlistOffered:
+            seatIDs, err := h.SeatHoldRepo.DeleteByUserAndShowTx(ctx, tx, userID, entry.ShowID)
+            if err != nil {
+                return failTx("failed to release holds", err)
+            }
+            if len(seatIDs) > 0 {
+                if err := h.ShowSeatRepo.BulkUpdateStatusTx(ctx, tx, entry.ShowID, seatIDs, "FREE"); err != nil {
+                    return failTx("failed to update seat status", err)
+                }
+            }
+            released = len(seatIDs)
+            entry.Status = repository.WaitlistDeclined
+        default:
+            return apperr.Conflict(apperr.CodeConflict, "waitlist entry is no longer open")
+        }
+        entry.OfferExpiresAt = nil
+        if err := h.Waitlist.Repo.SetStatusTx(ctx, tx, entry.ID, entry.Status, nil); err != nil {
+            return failTx("failed to update waitlist entry", err)
+        }
+        return nil
+    })
+    if err != nil {
+        return txError(c, err)
+    }
+    if released > 0 {
+        h.Waitlist.Notify(entry.ShowID)
+    }
+    return c.JSON(http.StatusOK, echo.Map{"item": entry, "released": released})
+}
@@ -1,7 +1,10 @@
 package handler // declare the package name; contains HTTP handlers
 
 import (
+    "context"           // per-check deadlines
     "net/http"          // net/http provides status codes and response helpers
+    "sync"              // checks run concurrently
+    "time"              // check latency and timeout
 
     "github.com/labstack/echo/v4" // echo is the web framework used for this project
 )
@@ -12,3 +15,77 @@ import (
 func Health(c echo.Context) error { // Health handler signature accepts an echo context and returns an error
     return c.String(http.StatusOK, "ok") // write "ok" with a 200 OK status; String writes plain text
 }
+
+// ReadinessCheck is one dependency probed by GET /readyz.  Check returns
+// nil when the dependency is usable.
+type ReadinessCheck struct {
+    Name     string
+    Required bool // a failing required check makes /readyz answer 503
+    Check    func(ctx context.Context) error
+}
+
+// ReadinessHandler serves GET /readyz.  Unlike /healthz, which only shows
+// the process is up, it probes every registered dependency (the MySQL
+// pool, the event queue, background workers) concurrently and reports each one.
+type ReadinessHandler struct {
+    Checks  []ReadinessCheck
+    Timeout time.Duration // per-check deadline; defaults to two seconds
+}
+
+// NewReadinessHandler constructs a ReadinessHandler with the given checks.
+func NewReadinessHandler(checks ...ReadinessCheck) *ReadinessHandler {
+    return &ReadinessHandler{Checks: checks, Timeout: 2 * time.Second}
+}
+
+// readinessResult is the per-dependency entry of the /readyz body.
+type readinessResult struct {
+    Status    string `json:"status"` // "up" or "down"
+    Required  bool   `json:"required"`
+    LatencyMS int64  `json:"latency_ms"`
+    Error     string `json:"error,omitempty"`
+}
+
+// Ready handles GET /readyz.  The body lists every check under "checks"
+// and an overall "status": "ready" when all checks pass, "degraded" when
+// only optional ones fail (still 200, the instance can serve bookings)
+// and "unavailable" with 503 when a required dependency is down.
+func (h *ReadinessHandler) Ready(c echo.Context) error {
+    timeout := h.Timeout
+    if timeout <= 0 {
+        timeout = 2 * time.Second
+    }
+    results := make(map[string]readinessResult, len(h.Checks))
+    var mu sync.Mutex
+    var wg sync.WaitGroup
+    for _, chk := range h.Checks {
+        wg.Add(1)
+        go func(chk ReadinessCheck) {
+            defer wg.Done()
+            ctx, cancel := context.WithTimeout(c.Request().Context(), timeout)
+            defer cancel()
+            start := time.Now()
+            err := chk.Check(ctx)
+            r := readinessResult{Status: "up", Required: chk.Required, LatencyMS: time.Since(start).Milliseconds()}
+            if err != nil {
+                r.Status = "down"
+                r.Error = err.Error()
+            }
+            mu.Lock()
+            results[chk.Name] = r
+            mu.Unlock()
+        }(chk)
+    }
+    wg.Wait()
+    status, code := "ready", http.StatusOK
+    for _, r := range results {
+        if r.Status == "up" {
+            continue
+        }
+        if r.Required {
+            status, code = "unavailable", http.StatusServiceUnavailable
+            break
+        }
+        status = "degraded"
+    }
+    return c.JSON(code, echo.Map{"status": status, "checks": results})
+}
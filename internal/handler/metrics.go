@@ -0,0 +1,18 @@
+package handler
+
+import (
+    "bytes"
+    "net/http"
+
+    "github.com/iliyamo/cinema-seat-reservation/internal/metrics"
+    "github.com/labstack/echo/v4"
+)
+
+// Metrics handles GET /metrics and returns every registered metric in the
+// Prometheus text exposition format for scraping.  The endpoint is not
+// authenticated; restrict access to it at the network edge.
+func Metrics(c echo.Context) error {
+    var buf bytes.Buffer
+    metrics.WriteText(&buf)
+    return c.Blob(http.StatusOK, metrics.ContentType, buf.Bytes())
+}
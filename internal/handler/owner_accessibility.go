@@ -0,0 +1,84 @@
+package handler // handler package contains owner-specific seat accessibility handlers
+
+import (
+    "net/http" // http defines status code constants
+    "strconv"  // strconv parses identifiers from path params
+
+    "github.com/iliyamo/cinema-seat-reservation/internal/apperr" // apperr builds error responses
+    "github.com/iliyamo/cinema-seat-reservation/internal/repository" // repository defines data models
+    "github.com/labstack/echo/v4"                                    // echo framework provides context and JSON helpers
+)
+
+// UpdateSeatAccessibility handles PUT /v1/halls/:id/seats/accessibility and
+// sets accessibility attributes for many seats of a hall at once.  The body
+// lists the seats to update:
+//
+//     {"seats": [{"seat_id": 1, "wheelchair_space": true, "step_free_access": true}, ...]}
+//
+// Each listed seat's attributes are replaced; omitted attributes are false.
+// Seats not listed are left unchanged.  Every seat must belong to the hall,
+// which must be owned by the caller.
+func (h *OwnerHandler) UpdateSeatAccessibility(c echo.Context) error {
+    if h.AccessibilityRepo == nil {
+        return apperr.NotImplemented("seat accessibility is not available")
+    }
+    ownerID, err := getUserID(c)
+    if err != nil {
+        return apperr.Unauthorized("unauthorized")
+    }
+    hallID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+    if err != nil || hallID == 0 {
+        return apperr.BadRequest("invalid id")
+    }
+    var body struct {
+        Seats []repository.SeatAccessibility `json:"seats" validate:"required"`
+    }
+    if err := bindValid(c, &body); err != nil {
+        return err
+    }
+    ctx := c.Request().Context()
+    hall, err := h.HallRepo.GetByIDAndOwner(ctx, hallID, ownerID)
+    if err != nil {
+        if err == repository.ErrHallNotFound {
+            return apperr.NotFound("hall not found")
+        }
+        return apperr.Internal("could not verify hall")
+    }
+    // Every seat must belong to this hall; later entries for the same seat win.
+    seats, err := h.SeatRepo.GetByHall(ctx, hallID)
+    if err != nil {
+        return apperr.Internal("failed to load seats")
+    }
+    inHall := make(map[uint64]struct{}, len(seats))
+    for _, s := range seats {
+        inHall[s.ID] = struct{}{}
+    }
+    byID := make(map[uint64]int, len(body.Seats))
+    items := make([]repository.SeatAccessibility, 0, len(body.Seats))
+    invalid := make([]uint64, 0)
+    for _, a := range body.Seats {
+        if _, ok := inHall[a.SeatID]; !ok {
+            invalid = append(invalid, a.SeatID)
+            continue
+        }
+        if i, ok := byID[a.SeatID]; ok {
+            items[i] = a
+            continue
+        }
+        byID[a.SeatID] = len(items)
+        items = append(items, a)
+    }
+    if len(invalid) > 0 {
+        return apperr.BadRequest("seats do not belong to this hall").
+            WithDetails(map[string]any{"invalid": invalid})
+    }
+    if err := h.AccessibilityRepo.UpsertBulk(ctx, items); err != nil {
+        return apperr.Internal("failed to update accessibility")
+    }
+    recordAudit(c, h.Audit, auditEvent("hall.accessibility", "hall", hallID, hall.OwnerID), nil, items)
+    return c.JSON(http.StatusOK, map[string]any{
+        "hall_id": hallID,
+        "updated": len(items),
+        "seats":   items,
+    })
+}
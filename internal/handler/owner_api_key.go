@@ -0,0 +1,111 @@
+package handler
+
+// This file defines HTTP handlers for owners to manage API keys.  A key
+// lets a partner's servers call the owner API as the owner, with a subset
+// of the owner's scopes and a request budget of its own, by sending it in
+// the X-API-Key header instead of a bearer token.
+
+import (
+    "database/sql"
+    "errors"
+    "net/http"
+    "strconv"
+
+    "github.com/iliyamo/cinema-seat-reservation/internal/apperr"
+    "github.com/iliyamo/cinema-seat-reservation/internal/repository"
+    "github.com/iliyamo/cinema-seat-reservation/internal/service"
+    "github.com/labstack/echo/v4"
+)
+
+// OwnerAPIKeyHandler exposes API key management to owners.
+type OwnerAPIKeyHandler struct {
+    Keys  *service.APIKeyService // issues and stores keys
+    Audit *repository.AuditRepo  // optional; records creations and revocations in the audit log
+}
+
+// NewOwnerAPIKeyHandler constructs an OwnerAPIKeyHandler.  The service
+// must be non-nil.
+func NewOwnerAPIKeyHandler(keys *service.APIKeyService) *OwnerAPIKeyHandler {
+    if keys == nil {
+        panic("nil service passed to NewOwnerAPIKeyHandler")
+    }
+    return &OwnerAPIKeyHandler{Keys: keys}
+}
+
+// createAPIKeyReq is the body of POST /v1/owner/api-keys.
+type createAPIKeyReq struct {
+    Name       string   `json:"name" validate:"required,max=100"`
+    Scopes     []string `json:"scopes" validate:"max=20"`
+    RatePerMin *uint32  `json:"rate_limit_per_minute" validate:"min=1,max=100000"`
+}
+
+// CreateAPIKey handles POST /v1/owner/api-keys.  scopes defaults to every
+// scope a key may hold (see GET /v1/owner/api-keys); a scope outside them
+// gives 400.  rate_limit_per_minute overrides the default per-key budget.
+// Returns 201 with the key's record and, in "key", the key itself, which
+// is not shown again.
+func (h *OwnerAPIKeyHandler) CreateAPIKey(c echo.Context) error {
+    ownerID, err := getUserID(c)
+    if err != nil {
+        return apperr.Unauthorized("unauthorized")
+    }
+    var body createAPIKeyReq
+    if err := bindValid(c, &body); err != nil {
+        return err
+    }
+    k, raw, err := h.Keys.Create(c.Request().Context(), ownerID, body.Name, body.Scopes, body.RatePerMin)
+    if err != nil {
+        var badScope *service.InvalidScopeError
+        if errors.As(err, &badScope) {
+            return fieldError("scopes", badScope.Error())
+        }
+        return apperr.Internal("failed to create api key")
+    }
+    recordAudit(c, h.Audit, auditEvent("api_key.create", "api_key", k.ID, ownerID), nil, k)
+    return c.JSON(http.StatusCreated, echo.Map{
+        "api_key": k,
+        "key":     raw,
+    })
+}
+
+// ListAPIKeys handles GET /v1/owner/api-keys and returns the caller's
+// keys, revoked ones included, without the keys themselves, along with
+// the scopes a key may be granted.
+func (h *OwnerAPIKeyHandler) ListAPIKeys(c echo.Context) error {
+    ownerID, err := getUserID(c)
+    if err != nil {
+        return apperr.Unauthorized("unauthorized")
+    }
+    items, err := h.Keys.Repo.ListByOwner(c.Request().Context(), ownerID)
+    if err != nil {
+        return apperr.Internal("failed to load api keys")
+    }
+    return c.JSON(http.StatusOK, echo.Map{
+        "items":            items,
+        "count":            len(items),
+        "grantable_scopes": service.APIKeyScopes,
+    })
+}
+
+// RevokeAPIKey handles DELETE /v1/owner/api-keys/:id.  The key stops
+// working immediately.  Returns 204, or 404 when the caller has no such
+// key.
+func (h *OwnerAPIKeyHandler) RevokeAPIKey(c echo.Context) error {
+    ownerID, err := getUserID(c)
+    if err != nil {
+        return apperr.Unauthorized("unauthorized")
+    }
+    id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+    if err != nil || id == 0 {
+        return apperr.BadRequest("invalid id")
+    }
+    k, err := h.Keys.Repo.Revoke(c.Request().Context(), ownerID, id)
+    if err != nil {
+        if errors.Is(err, sql.ErrNoRows) {
+            return apperr.NotFound("api key not found")
+        }
+        return apperr.Internal("failed to revoke api key")
+    }
+    recordAudit(c, h.Audit, auditEvent("api_key.revoke", "api_key", id, ownerID), nil, k)
+    return c.NoContent(http.StatusNoContent)
+}
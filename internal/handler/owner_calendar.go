@@ -0,0 +1,192 @@
+package handler
+
+// This file defines the per-cinema calendar of blackout and special dates.
+// Owners maintain it through OwnerCalendarHandler; service.ShowService
+// consults it when scheduling shows (blackout dates are rejected) and when
+// pricing show seats (special dates scale the base price).
+
+import (
+    "errors"
+    "net/http"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/iliyamo/cinema-seat-reservation/internal/apperr"
+    "github.com/iliyamo/cinema-seat-reservation/internal/repository"
+    "github.com/labstack/echo/v4"
+)
+
+// OwnerCalendarHandler exposes cinema calendar management to owners.
+type OwnerCalendarHandler struct {
+    CalendarRepo *repository.CalendarRepo // access to cinema_calendar
+    CinemaRepo   *repository.CinemaRepo   // ownership checks
+    Audit        *repository.AuditRepo    // optional; records calendar changes in the audit log
+}
+
+// NewOwnerCalendarHandler constructs an OwnerCalendarHandler.  The
+// repositories must be non-nil.
+func NewOwnerCalendarHandler(calendarRepo *repository.CalendarRepo, cinemaRepo *repository.CinemaRepo) *OwnerCalendarHandler {
+    if calendarRepo == nil || cinemaRepo == nil {
+        panic("nil repository passed to NewOwnerCalendarHandler")
+    }
+    return &OwnerCalendarHandler{CalendarRepo: calendarRepo, CinemaRepo: cinemaRepo}
+}
+
+// calendarDateReq is the body of PUT /v1/owner/cinemas/:id/calendar/:date.
+type calendarDateReq struct {
+    Kind               string `json:"kind" validate:"required,oneof=BLACKOUT SPECIAL"`
+    PriceMultiplierPct uint32 `json:"price_multiplier_pct" validate:"max=1000"` // required for SPECIAL; 100 = base price
+    Label              string `json:"label" validate:"max=100"`
+}
+
+// ownedCinemaID parses the :id path parameter and verifies the caller owns
+// the cinema.
+func (h *OwnerCalendarHandler) ownedCinemaID(c echo.Context) (uint64, error) {
+    ownerID, err := getUserID(c)
+    if err != nil {
+        return 0, apperr.Unauthorized("unauthorized")
+    }
+    cinemaID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+    if err != nil || cinemaID == 0 {
+        return 0, apperr.BadRequest("invalid cinema id")
+    }
+    if _, err := h.CinemaRepo.GetByIDAndOwner(c.Request().Context(), cinemaID, ownerID); err != nil {
+        if errors.Is(err, repository.ErrCinemaNotFound) {
+            return 0, apperr.NotFound("cinema not found")
+        }
+        return 0, apperr.Internal("failed to verify cinema")
+    }
+    return cinemaID, nil
+}
+
+// auditCalendar records a calendar change against the cinema.  before is
+// the entry previously stored for the date, if any.
+func (h *OwnerCalendarHandler) auditCalendar(c echo.Context, action string, cinemaID uint64, before []repository.CalendarDate, after *repository.CalendarDate) {
+    ownerID, _ := getUserID(c)
+    var prev *repository.CalendarDate
+    if len(before) > 0 {
+        prev = &before[0]
+    }
+    recordAudit(c, h.Audit, auditEvent(action, "cinema", cinemaID, ownerID), prev, after)
+}
+
+// parseDateParam parses a YYYY-MM-DD value.
+func parseDateParam(name, v string) (time.Time, error) {
+    t, err := time.Parse(repository.DateLayout, strings.TrimSpace(v))
+    if err != nil {
+        return time.Time{}, apperr.BadRequest(name + " must be a date in YYYY-MM-DD format")
+    }
+    return t, nil
+}
+
+// ListCalendar handles GET /v1/owner/cinemas/:id/calendar.  The optional
+// from and to query parameters (YYYY-MM-DD) bound the range; it defaults
+// to today through one year ahead.
+func (h *OwnerCalendarHandler) ListCalendar(c echo.Context) error {
+    cinemaID, err := h.ownedCinemaID(c)
+    if err != nil {
+        return err
+    }
+    from := time.Now().UTC().Truncate(24 * time.Hour)
+    to := from.AddDate(1, 0, 0)
+    if v := c.QueryParam("from"); v != "" {
+        if from, err = parseDateParam("from", v); err != nil {
+            return err
+        }
+    }
+    if v := c.QueryParam("to"); v != "" {
+        if to, err = parseDateParam("to", v); err != nil {
+            return err
+        }
+    }
+    if to.Before(from) {
+        return apperr.BadRequest("to must not be before from")
+    }
+    items, err := h.CalendarRepo.ListRange(c.Request().Context(), cinemaID, from, to)
+    if err != nil {
+        return apperr.Internal("failed to load calendar")
+    }
+    return c.JSON(http.StatusOK, echo.Map{
+        "items": items,
+        "count": len(items),
+    })
+}
+
+// PutCalendarDate handles PUT /v1/owner/cinemas/:id/calendar/:date and
+// creates or replaces the entry for the date.  A BLACKOUT date is refused
+// with 409 while scheduled shows fall on it; cancel or move them first.
+// SPECIAL dates require price_multiplier_pct, which applies to shows
+// created (or moved) afterwards; existing seat prices are not changed.
+func (h *OwnerCalendarHandler) PutCalendarDate(c echo.Context) error {
+    cinemaID, err := h.ownedCinemaID(c)
+    if err != nil {
+        return err
+    }
+    day, err := parseDateParam("date", c.Param("date"))
+    if err != nil {
+        return err
+    }
+    var body calendarDateReq
+    if err := bindValid(c, &body); err != nil {
+        return err
+    }
+    entry := &repository.CalendarDate{
+        CinemaID:           cinemaID,
+        Date:               day.Format(repository.DateLayout),
+        Kind:               body.Kind,
+        PriceMultiplierPct: 100,
+        Label:              strings.TrimSpace(body.Label),
+    }
+    ctx := c.Request().Context()
+    switch body.Kind {
+    case repository.CalendarSpecial:
+        if body.PriceMultiplierPct == 0 {
+            return fieldError("price_multiplier_pct", "is required for SPECIAL dates")
+        }
+        entry.PriceMultiplierPct = body.PriceMultiplierPct
+    case repository.CalendarBlackout:
+        shows, err := h.CalendarRepo.ScheduledShowsOn(ctx, cinemaID, day)
+        if err != nil {
+            return apperr.Internal("failed to check scheduled shows")
+        }
+        if len(shows) > 0 {
+            return apperr.Conflict(apperr.CodeConflict, "shows are scheduled on this date").
+                WithDetails(echo.Map{"show_ids": shows})
+        }
+    }
+    var before []repository.CalendarDate
+    if h.Audit != nil {
+        before, _ = h.CalendarRepo.ListRange(ctx, cinemaID, day, day)
+    }
+    if err := h.CalendarRepo.Upsert(ctx, entry); err != nil {
+        return apperr.Internal("failed to save calendar date")
+    }
+    h.auditCalendar(c, "calendar.put", cinemaID, before, entry)
+    return c.JSON(http.StatusOK, entry)
+}
+
+// DeleteCalendarDate handles DELETE /v1/owner/cinemas/:id/calendar/:date.
+func (h *OwnerCalendarHandler) DeleteCalendarDate(c echo.Context) error {
+    cinemaID, err := h.ownedCinemaID(c)
+    if err != nil {
+        return err
+    }
+    day, err := parseDateParam("date", c.Param("date"))
+    if err != nil {
+        return err
+    }
+    ctx := c.Request().Context()
+    var before []repository.CalendarDate
+    if h.Audit != nil {
+        before, _ = h.CalendarRepo.ListRange(ctx, cinemaID, day, day)
+    }
+    if err := h.CalendarRepo.Delete(ctx, cinemaID, day.Format(repository.DateLayout)); err != nil {
+        if errors.Is(err, repository.ErrCalendarDateNotFound) {
+            return apperr.NotFound("calendar date not found")
+        }
+        return apperr.Internal("failed to delete calendar date")
+    }
+    h.auditCalendar(c, "calendar.delete", cinemaID, before, nil)
+    return c.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,109 @@
+package handler
+
+import (
+    "errors"
+    "net/http"
+    "strconv"
+
+    "github.com/iliyamo/cinema-seat-reservation/internal/apperr"
+    "github.com/iliyamo/cinema-seat-reservation/internal/repository"
+    "github.com/labstack/echo/v4"
+)
+
+// cancellationPolicyReq sets a cancellation cutoff in minutes before the
+// show starts, at most a week.  Null or an absent cutoff_minutes removes
+// the cutoff, so a show falls back to its cinema's and a cinema allows
+// cancelling until the show starts.
+type cancellationPolicyReq struct {
+    CutoffMinutes *uint32 `json:"cutoff_minutes" validate:"max=10080"`
+}
+
+// UpdateCinemaCancellationPolicy handles PUT
+// /v1/cinemas/:id/cancellation-policy and sets the default cancellation
+// cutoff of the cinema's shows.
+func (h *OwnerHandler) UpdateCinemaCancellationPolicy(c echo.Context) error {
+    ownerID, err := getUserID(c)
+    if err != nil {
+        return apperr.Unauthorized("unauthorized")
+    }
+    id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+    if err != nil || id == 0 {
+        return apperr.BadRequest("invalid id")
+    }
+    var body cancellationPolicyReq
+    if err := bindValid(c, &body); err != nil {
+        return err
+    }
+    ctx := c.Request().Context()
+    before, err := h.CinemaRepo.CancelCutoff(ctx, id, ownerID)
+    if err != nil {
+        if errors.Is(err, repository.ErrCinemaNotFound) {
+            return apperr.NotFound("cinema not found")
+        }
+        return apperr.Internal("db error")
+    }
+    if err := h.CinemaRepo.SetCancelCutoff(ctx, id, ownerID, body.CutoffMinutes); err != nil {
+        if errors.Is(err, repository.ErrCinemaNotFound) {
+            return apperr.NotFound("cinema not found")
+        }
+        return apperr.Internal("update failed")
+    }
+    recordAudit(c, h.Audit, auditEvent("cinema.cancellation_policy", "cinema", id, ownerID),
+        echo.Map{"cutoff_minutes": before}, echo.Map{"cutoff_minutes": body.CutoffMinutes})
+    return c.JSON(http.StatusOK, echo.Map{
+        "cinema_id":      id,
+        "cutoff_minutes": body.CutoffMinutes,
+    })
+}
+
+// GetShowCancellationPolicy handles GET /v1/shows/:id/cancellation-policy
+// and returns the show's cutoff, its cinema's and the one that applies.
+func (h *OwnerHandler) GetShowCancellationPolicy(c echo.Context) error {
+    policy, _, err := h.ownedShowPolicy(c)
+    if err != nil {
+        return err
+    }
+    return c.JSON(http.StatusOK, policy)
+}
+
+// UpdateShowCancellationPolicy handles PUT
+// /v1/shows/:id/cancellation-policy and overrides the cinema's
+// cancellation cutoff for one show.  It responds like
+// GetShowCancellationPolicy.
+func (h *OwnerHandler) UpdateShowCancellationPolicy(c echo.Context) error {
+    var body cancellationPolicyReq
+    if err := bindValid(c, &body); err != nil {
+        return err
+    }
+    before, hall, err := h.ownedShowPolicy(c)
+    if err != nil {
+        return err
+    }
+    ctx := c.Request().Context()
+    if err := h.ShowRepo.SetCancelCutoff(ctx, before.ShowID, body.CutoffMinutes); err != nil {
+        return apperr.Internal("update failed")
+    }
+    after, err := h.ShowRepo.CancellationPolicy(ctx, before.ShowID)
+    if err != nil {
+        return apperr.Internal("db error")
+    }
+    recordAudit(c, h.Audit, auditEvent("show.cancellation_policy", "show", before.ShowID, hall.OwnerID), before, after)
+    return c.JSON(http.StatusOK, after)
+}
+
+// ownedShowPolicy loads the cancellation policy of the show in the :id
+// parameter and the show's hall, which must be managed by the caller.
+func (h *OwnerHandler) ownedShowPolicy(c echo.Context) (*repository.CancellationPolicy, *repository.Hall, error) {
+    showID, hall, err := h.ownedShow(c)
+    if err != nil {
+        return nil, nil, err
+    }
+    policy, err := h.ShowRepo.CancellationPolicy(c.Request().Context(), showID)
+    if err != nil {
+        if errors.Is(err, repository.ErrShowNotFound) {
+            return nil, nil, apperr.NotFound("show not found")
+        }
+        return nil, nil, apperr.Internal("database error")
+    }
+    return policy, hall, nil
+}
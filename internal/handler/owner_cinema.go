@@ -6,6 +6,8 @@ import (
     "strconv"                                                // strconv parses string identifiers to numeric types
     "strings"                                                // strings offers trimming utilities
 
+    "github.com/iliyamo/cinema-seat-reservation/internal/apperr" // apperr builds error responses
+    "github.com/iliyamo/cinema-seat-reservation/internal/money"  // money validates currency codes
     "github.com/iliyamo/cinema-seat-reservation/internal/repository" // repository holds database models
     "github.com/labstack/echo/v4"                                   // echo is the web framework used for handlers
 )
@@ -14,67 +16,108 @@ import (
 func (h *OwnerHandler) CreateCinema(c echo.Context) error { // begin CreateCinema handler
     ownerID, err := getUserID(c) // extract the owner ID from context
     if err != nil { // check if the user ID was not available or invalid
-        return c.JSON(http.StatusUnauthorized, map[string]string{"error": "unauthorized"}) // respond with unauthorized when user ID cannot be obtained
+        return apperr.Unauthorized("unauthorized") // respond with unauthorized when user ID cannot be obtained
     }
     var body struct { // anonymous struct to bind incoming JSON
-        Name string `json:"name"` // Name is the only required field for a cinema
+        Name     string `json:"name" validate:"required,max=100"` // Name is the only required field for a cinema
+        Timezone string `json:"timezone" validate:"max=64"`       // optional IANA time zone; defaults to UTC
+        Currency string `json:"currency" validate:"max=3"`        // optional ISO 4217 code; defaults to CURRENCY
+        cinemaLocationReq                                         // optional city, address and coordinates
     }
-    if err := c.Bind(&body); err != nil { // attempt to bind the request body into the struct
-        return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request body"}) // return bad request when binding fails
+    if err := bindValid(c, &body); err != nil { // bind and validate the request body
+        return err // respond with 400/422 describing the problem
+    }
+    if err := body.check(); err != nil { // coordinates come in pairs
+        return err // respond with the invalid field
     }
     name := strings.TrimSpace(body.Name) // trim spaces around the cinema name
-    if name == "" { // ensure the name is not empty after trimming
-        return c.JSON(http.StatusBadRequest, map[string]string{"error": "name is required"}) // respond with error when name is empty
+    tz := strings.TrimSpace(body.Timezone) // trim spaces around the time zone
+    if tz == "" { // no time zone given
+        tz = "UTC" // show times default to UTC
+    }
+    if !validTimezone(tz) { // reject names the time zone database does not know
+        return fieldError("timezone", "must be an IANA time zone such as Europe/Berlin") // respond with the invalid field
+    }
+    currency := "" // empty stores the default currency
+    if strings.TrimSpace(body.Currency) != "" { // a currency was given
+        if currency, err = money.Normalize(body.Currency); err != nil { // it must look like an ISO 4217 code
+            return fieldError("currency", err.Error()) // respond with the invalid field
+        }
     }
     cinema := &repository.Cinema{ // instantiate a new cinema model
-        OwnerID: ownerID, // assign the owner ID to the cinema
-        Name:    name,    // assign the trimmed name
+        OwnerID:  ownerID,  // assign the owner ID to the cinema
+        Name:     name,     // assign the trimmed name
+        Timezone: tz,       // assign the validated time zone
+        Currency: currency, // assign the validated currency
     }
+    cinema.City, cinema.Address, cinema.Latitude, cinema.Longitude = body.values() // assign the optional location
     if err := h.CinemaRepo.Create(c.Request().Context(), cinema); err != nil { // delegate creation to the repository
         if strings.Contains(err.Error(), "1062") { // check for duplicate key error
-            return c.JSON(http.StatusConflict, map[string]string{"error": "cinema name already exists"}) // respond with conflict when the name is not unique
+            return apperr.Conflict(apperr.CodeAlreadyExists, "cinema name already exists") // respond with conflict when the name is not unique
         }
-        return c.JSON(http.StatusInternalServerError, map[string]string{"error": "could not create cinema"}) // respond with internal error for other failures
+        return apperr.Internal("could not create cinema") // respond with internal error for other failures
     }
+    recordAudit(c, h.Audit, auditEvent("cinema.create", "cinema", cinema.ID, ownerID), nil, cinema) // record the new cinema
     return c.JSON(http.StatusCreated, cinema) // return 201 and the created cinema on success
 }
 
 // UpdateCinema handles PUT/PATCH /v1/cinemas/:id and updates the cinema name
+// and, when given, its time zone and currency.  Existing shows keep their
+// UTC times.  A new currency applies to all of the cinema's shows; holds
+// quoted in the old one can no longer be confirmed (409
+// CURRENCY_MISMATCH) and existing reservations keep their currency.
 func (h *OwnerHandler) UpdateCinema(c echo.Context) error { // begin UpdateCinema handler
     ownerID, err := getUserID(c) // extract the owner ID from context
     if err != nil { // if user ID is invalid
-        return c.JSON(http.StatusUnauthorized, map[string]string{"error": "unauthorized"}) // unauthorized error
+        return apperr.Unauthorized("unauthorized") // unauthorized error
     }
     id, err := strconv.ParseUint(c.Param("id"), 10, 64) // parse the cinema ID from the URL
     if err != nil { // validate that the ID is numeric
-        return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid id"}) // invalid ID error response
+        return apperr.BadRequest("invalid id") // invalid ID error response
     }
     var body struct { // struct for binding the JSON payload
-        Name string `json:"name"` // Name is the only updatable field
+        Name     string  `json:"name" validate:"required,max=100"` // Name is always required
+        Timezone *string `json:"timezone" validate:"max=64"`       // optional IANA time zone; unchanged when absent
+        Currency *string `json:"currency" validate:"max=3"`        // optional ISO 4217 code; unchanged when absent
     }
-    if err := c.Bind(&body); err != nil { // attempt to bind the request body
-        return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request body"}) // return bad request when binding fails
+    if err := bindValid(c, &body); err != nil { // bind and validate the request body
+        return err // respond with 400/422 describing the problem
     }
     name := strings.TrimSpace(body.Name) // trim spaces from the provided name
-    if name == "" { // name cannot be empty after trimming
-        return c.JSON(http.StatusBadRequest, map[string]string{"error": "name is required"}) // respond with bad request if name is empty
+    if body.Timezone != nil && !validTimezone(strings.TrimSpace(*body.Timezone)) { // a given time zone must be known
+        return fieldError("timezone", "must be an IANA time zone such as Europe/Berlin") // respond with the invalid field
     }
-    if _, err := h.CinemaRepo.GetByIDAndOwner(c.Request().Context(), id, ownerID); err != nil { // verify the cinema exists and belongs to the owner
+    var currency string // validated new currency, if any
+    if body.Currency != nil { // a currency was given
+        if currency, err = money.Normalize(*body.Currency); err != nil { // it must look like an ISO 4217 code
+            return fieldError("currency", err.Error()) // respond with the invalid field
+        }
+    }
+    before, err := h.CinemaRepo.GetByIDAndOwner(c.Request().Context(), id, ownerID) // verify the cinema exists and belongs to the owner
+    if err != nil { // lookup failed
         if err == repository.ErrCinemaNotFound { // when the cinema is not found
-            return c.JSON(http.StatusNotFound, map[string]string{"error": "cinema not found"}) // respond with not found
+            return apperr.NotFound("cinema not found") // respond with not found
         }
-        return c.JSON(http.StatusInternalServerError, map[string]string{"error": "db error"}) // respond with database error
+        return apperr.Internal("db error") // respond with database error
+    }
+    tz := before.Timezone // keep the current time zone by default
+    if body.Timezone != nil { // a new time zone was supplied
+        tz = strings.TrimSpace(*body.Timezone) // use the validated time zone
+    }
+    if currency == "" { // no currency was supplied
+        currency = before.Currency // keep the current currency
     }
-    if err := h.CinemaRepo.UpdateName(c.Request().Context(), id, ownerID, name); err != nil { // update the cinema name in the repository
+    if err := h.CinemaRepo.Update(c.Request().Context(), id, ownerID, name, tz, currency); err != nil { // update the cinema in the repository
         if err == sql.ErrNoRows { // no rows affected means not found
-            return c.JSON(http.StatusNotFound, map[string]string{"error": "cinema not found"}) // respond with not found
+            return apperr.NotFound("cinema not found") // respond with not found
         }
         if strings.Contains(err.Error(), "1062") { // duplicate name violation
-            return c.JSON(http.StatusConflict, map[string]string{"error": "cinema name already exists"}) // respond with conflict
+            return apperr.Conflict(apperr.CodeAlreadyExists, "cinema name already exists") // respond with conflict
         }
-        return c.JSON(http.StatusInternalServerError, map[string]string{"error": "update failed"}) // respond with generic update failure
+        return apperr.Internal("update failed") // respond with generic update failure
     }
     updated, _ := h.CinemaRepo.GetByID(c.Request().Context(), id) // fetch the updated record without ownership filter
+    recordAudit(c, h.Audit, auditEvent("cinema.update", "cinema", id, ownerID), before, updated) // record the rename
     return c.JSON(http.StatusOK, updated) // return the updated cinema with OK status
 }
 
@@ -82,11 +125,84 @@ func (h *OwnerHandler) UpdateCinema(c echo.Context) error { // begin UpdateCinem
 func (h *OwnerHandler) ListCinemas(c echo.Context) error { // begin ListCinemas handler
     ownerID, err := getUserID(c) // extract the user ID from context
     if err != nil { // invalid user means unauthorized
-        return c.JSON(http.StatusUnauthorized, map[string]string{"error": "unauthorized"}) // respond unauthorized
+        return apperr.Unauthorized("unauthorized") // respond unauthorized
     }
     items, err := h.CinemaRepo.ListByOwner(c.Request().Context(), ownerID) // fetch cinemas for this owner
     if err != nil { // handle repository errors
-        return c.JSON(http.StatusInternalServerError, map[string]string{"error": "db error"}) // respond with internal server error
+        return apperr.Internal("db error") // respond with internal server error
     }
     return c.JSON(http.StatusOK, map[string]any{"items": items}) // return the list wrapped in a JSON object
-}
\ No newline at end of file
+}
+// cinemaLocationReq is the location of a cinema as sent by owners.  Empty
+// strings and omitted fields are stored as NULL.
+type cinemaLocationReq struct {
+    City      *string  `json:"city" validate:"max=100"`
+    Address   *string  `json:"address" validate:"max=255"`
+    Latitude  *float64 `json:"latitude" validate:"min=-90,max=90"`
+    Longitude *float64 `json:"longitude" validate:"min=-180,max=180"`
+}
+
+// check rejects a latitude without a longitude and vice versa.
+func (l *cinemaLocationReq) check() error {
+    if (l.Latitude == nil) != (l.Longitude == nil) {
+        if l.Latitude == nil {
+            return fieldError("latitude", "must be given together with longitude")
+        }
+        return fieldError("longitude", "must be given together with latitude")
+    }
+    return nil
+}
+
+// values returns the trimmed location; blank strings become nil.
+func (l *cinemaLocationReq) values() (city, address *string, lat, lng *float64) {
+    trim := func(s *string) *string {
+        if s == nil || strings.TrimSpace(*s) == "" {
+            return nil
+        }
+        t := strings.TrimSpace(*s)
+        return &t
+    }
+    return trim(l.City), trim(l.Address), l.Latitude, l.Longitude
+}
+
+// UpdateCinemaLocation handles PUT /v1/cinemas/:id/location and replaces
+// the cinema's city, address and coordinates.  Omitted or null fields are
+// cleared; latitude and longitude must be given together.
+func (h *OwnerHandler) UpdateCinemaLocation(c echo.Context) error {
+    ownerID, err := getUserID(c)
+    if err != nil {
+        return apperr.Unauthorized("unauthorized")
+    }
+    id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+    if err != nil {
+        return apperr.BadRequest("invalid id")
+    }
+    var body cinemaLocationReq
+    if err := bindValid(c, &body); err != nil {
+        return err
+    }
+    if err := body.check(); err != nil {
+        return err
+    }
+    ctx := c.Request().Context()
+    before, err := h.CinemaRepo.GetByIDAndOwner(ctx, id, ownerID)
+    if err != nil {
+        if err == repository.ErrCinemaNotFound {
+            return apperr.NotFound("cinema not found")
+        }
+        return apperr.Internal("db error")
+    }
+    city, address, lat, lng := body.values()
+    if err := h.CinemaRepo.UpdateLocation(ctx, id, ownerID, city, address, lat, lng); err != nil {
+        if err == sql.ErrNoRows {
+            return apperr.NotFound("cinema not found")
+        }
+        return apperr.Internal("update failed")
+    }
+    updated, err := h.CinemaRepo.GetByID(ctx, id)
+    if err != nil {
+        return apperr.Internal("db error")
+    }
+    recordAudit(c, h.Audit, auditEvent("cinema.update", "cinema", id, ownerID), before, updated)
+    return c.JSON(http.StatusOK, updated)
+}
@@ -0,0 +1,96 @@
+package handler
+
+import (
+    "errors"
+    "net/http"
+    "strconv"
+
+    "github.com/iliyamo/cinema-seat-reservation/internal/apperr"
+    "github.com/iliyamo/cinema-seat-reservation/internal/repository"
+    "github.com/labstack/echo/v4"
+)
+
+// cinemaProfileReq is the body of PUT /v1/cinemas/:id/profile.  Omitted,
+// null or blank fields are cleared.
+type cinemaProfileReq struct {
+    LogoURL      *string           `json:"logo_url" validate:"max=500"`
+    Description  *string           `json:"description" validate:"max=2000"`
+    Phone        *string           `json:"phone" validate:"max=32"`
+    OpeningHours []openingHoursReq `json:"opening_hours" validate:"max=28"`
+}
+
+// openingHoursReq is one opening period of a cinemaProfileReq.
+type openingHoursReq struct {
+    Day    string `json:"day" validate:"required,oneof=MON TUE WED THU FRI SAT SUN"`
+    Opens  string `json:"opens" validate:"required,max=5"`
+    Closes string `json:"closes" validate:"required,max=5"`
+}
+
+// GetCinemaProfile handles GET /v1/cinemas/:id/profile and returns the
+// public profile of one of the caller's cinemas.
+func (h *OwnerHandler) GetCinemaProfile(c echo.Context) error {
+    ownerID, err := getUserID(c)
+    if err != nil {
+        return apperr.Unauthorized("unauthorized")
+    }
+    id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+    if err != nil || id == 0 {
+        return apperr.BadRequest("invalid id")
+    }
+    cin, err := h.CinemaRepo.GetByIDAndOwner(c.Request().Context(), id, ownerID)
+    if err != nil {
+        if errors.Is(err, repository.ErrCinemaNotFound) {
+            return apperr.NotFound("cinema not found")
+        }
+        return apperr.Internal("db error")
+    }
+    return c.JSON(http.StatusOK, cin.Profile())
+}
+
+// UpdateCinemaProfile handles PUT /v1/cinemas/:id/profile and replaces
+// the cinema's logo URL, description, phone number and opening hours,
+// which GET /v1/cinemas and GET /v1/cinemas/:id show to everyone.  The
+// values are sanitized by repository.CinemaProfile.Normalize; the
+// response is the profile as stored.
+func (h *OwnerHandler) UpdateCinemaProfile(c echo.Context) error {
+    ownerID, err := getUserID(c)
+    if err != nil {
+        return apperr.Unauthorized("unauthorized")
+    }
+    id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+    if err != nil || id == 0 {
+        return apperr.BadRequest("invalid id")
+    }
+    var body cinemaProfileReq
+    if err := bindValid(c, &body); err != nil {
+        return err
+    }
+    after := &repository.CinemaProfile{LogoURL: body.LogoURL, Description: body.Description, Phone: body.Phone}
+    for _, oh := range body.OpeningHours {
+        after.OpeningHours = append(after.OpeningHours, repository.OpeningHours{Day: oh.Day, Opens: oh.Opens, Closes: oh.Closes})
+    }
+    switch err := after.Normalize(); {
+    case errors.Is(err, repository.ErrInvalidLogoURL):
+        return fieldError("logo_url", "must be an absolute http or https URL")
+    case errors.Is(err, repository.ErrInvalidPhone):
+        return fieldError("phone", "must be 3 to 15 digits with an optional leading + and spaces, dashes, dots or parentheses")
+    case errors.Is(err, repository.ErrInvalidOpeningHours):
+        return fieldError("opening_hours", "must have HH:MM opens and closes that differ, and no period may repeat")
+    }
+    ctx := c.Request().Context()
+    cin, err := h.CinemaRepo.GetByIDAndOwner(ctx, id, ownerID)
+    if err != nil {
+        if errors.Is(err, repository.ErrCinemaNotFound) {
+            return apperr.NotFound("cinema not found")
+        }
+        return apperr.Internal("db error")
+    }
+    if err := h.CinemaRepo.SetProfile(ctx, id, ownerID, after); err != nil {
+        if errors.Is(err, repository.ErrCinemaNotFound) {
+            return apperr.NotFound("cinema not found")
+        }
+        return apperr.Internal("update failed")
+    }
+    recordAudit(c, h.Audit, auditEvent("cinema.profile", "cinema", id, ownerID), cin.Profile(), after)
+    return c.JSON(http.StatusOK, after)
+}
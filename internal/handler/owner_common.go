@@ -4,8 +4,10 @@ import (
     "errors"       // errors provides sentinel values used in getUserID
     "strconv"      // strconv converts strings to numeric types
     "strings"      // strings provides trimming and case helpers
+    "time"         // time for show scheduling settings
 
     "github.com/iliyamo/cinema-seat-reservation/internal/repository" // repository holds data access layer
+    "github.com/iliyamo/cinema-seat-reservation/internal/service"    // service provides the waitlist worker
     "github.com/labstack/echo/v4"                                    // echo defines request context types
 )
 
@@ -16,6 +18,24 @@ type OwnerHandler struct {
     SeatRepo     *repository.SeatRepo     // SeatRepo provides seat persistence
     ShowRepo     *repository.ShowRepo     // ShowRepo provides show persistence
     ShowSeatRepo *repository.ShowSeatRepo // ShowSeatRepo provides show seat persistence
+    SeatHoldRepo *repository.SeatHoldRepo // optional; releases expired holds before seats are blocked
+
+    AccessibilityRepo *repository.SeatAccessibilityRepo // optional; enables bulk accessibility updates
+    CalendarRepo      *repository.CalendarRepo          // optional; enables blackout dates and special-date pricing
+    Audit             *repository.AuditRepo             // optional; records mutations in the audit log
+    Waitlist          *service.WaitlistService          // optional; offers unblocked seats to the show's waitlist
+    ReservationRepo   *repository.ReservationRepo       // optional; enables show cancellation
+    Notifications     *repository.NotificationRepo      // optional; notifies customers of cancelled shows
+    RefundRepo        *repository.RefundRepo            // optional; approves refunds of cancelled shows
+    Webhooks          *repository.WebhookRepo           // optional; queues show.cancelled webhooks
+
+    NotificationSettings *repository.OwnerNotificationRepo // optional; enables the owner's notification settings
+
+    ShowBuffer time.Duration // trailer/cleanup time added to runtime_minutes when deriving ends_at
+
+    // Shows schedules shows.  NewOwnerHandler builds it from the show
+    // repositories; its CalendarRepo should match CalendarRepo above.
+    Shows *service.ShowService
 }
 
 // NewOwnerHandler constructs a new OwnerHandler and panics if any dependency is nil
@@ -29,6 +49,7 @@ func NewOwnerHandler(cinemaRepo *repository.CinemaRepo, hallRepo *repository.Hal
         SeatRepo:     seatRepo,     // assign seat repository
         ShowRepo:     showRepo,     // assign show repository
         ShowSeatRepo: showSeatRepo, // assign show seat repository
+        Shows:        service.NewShowService(showRepo, showSeatRepo), // scheduling rules over the same repositories
     }
 }
 
@@ -12,6 +12,7 @@ import (
     "net/http"                                         // status code constants
     "strconv"                                          // string-to-integer conversion
 
+    "github.com/iliyamo/cinema-seat-reservation/internal/apperr" // apperr builds error responses
     "github.com/iliyamo/cinema-seat-reservation/internal/repository" // repository defines error types
     "github.com/labstack/echo/v4"                                   // echo provides request/response handling
 )
@@ -24,50 +25,64 @@ import (
 func (h *OwnerHandler) DeleteCinema(c echo.Context) error {
     ownerID, err := getUserID(c)
     if err != nil {
-        return c.JSON(http.StatusUnauthorized, echo.Map{"error": "unauthorized"})
+        return apperr.Unauthorized("unauthorized")
     }
     id, err := strconv.ParseUint(c.Param("id"), 10, 64)
     if err != nil {
-        return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid id"})
+        return apperr.BadRequest("invalid id")
+    }
+    var before *repository.Cinema
+    if h.Audit != nil {
+        before, _ = h.CinemaRepo.GetByIDAndOwner(c.Request().Context(), id, ownerID)
     }
     err = h.CinemaRepo.DeleteByIDAndOwner(c.Request().Context(), id, ownerID)
     if err != nil {
         switch err {
         case sql.ErrNoRows:
-            return c.JSON(http.StatusNotFound, echo.Map{"error": "cinema not found"})
+            return apperr.NotFound("cinema not found")
         case repository.ErrForbidden:
-            return c.JSON(http.StatusForbidden, echo.Map{"error": "forbidden"})
+            return apperr.Forbidden("forbidden")
         default:
-            return c.JSON(http.StatusInternalServerError, echo.Map{"error": "delete failed"})
+            return apperr.Internal("delete failed")
         }
     }
+    recordAudit(c, h.Audit, auditEvent("cinema.delete", "cinema", id, ownerID), before, nil)
     return c.NoContent(http.StatusNoContent)
 }
 
 // DeleteHall handles DELETE /v1/halls/:id. It removes a hall and its dependent
 // seats, shows, show seats, reservations and reservation seats for the
-// authenticated owner. Returns 204 on success, 404 if not found and
-// 403 if the hall belongs to another owner.
+// authenticated owner in one transaction. Returns 204 on success, 404 if not
+// found, 403 if the hall belongs to another owner and 409 HALL_IN_USE while
+// shows of the hall that have not ended have open (PENDING or CONFIRMED)
+// reservations; those have to be cancelled or moved first.
 func (h *OwnerHandler) DeleteHall(c echo.Context) error {
     ownerID, err := getUserID(c)
     if err != nil {
-        return c.JSON(http.StatusUnauthorized, echo.Map{"error": "unauthorized"})
+        return apperr.Unauthorized("unauthorized")
     }
     id, err := strconv.ParseUint(c.Param("id"), 10, 64)
     if err != nil {
-        return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid id"})
+        return apperr.BadRequest("invalid id")
+    }
+    var before *repository.Hall
+    if h.Audit != nil {
+        before, _ = h.HallRepo.GetByIDAndOwner(c.Request().Context(), id, ownerID)
     }
     err = h.HallRepo.DeleteByIDAndOwner(c.Request().Context(), id, ownerID)
     if err != nil {
         switch err {
         case sql.ErrNoRows:
-            return c.JSON(http.StatusNotFound, echo.Map{"error": "hall not found"})
+            return apperr.NotFound("hall not found")
         case repository.ErrForbidden:
-            return c.JSON(http.StatusForbidden, echo.Map{"error": "forbidden"})
+            return apperr.Forbidden("forbidden")
+        case repository.ErrHallInUse:
+            return apperr.Conflict(apperr.CodeHallInUse, "cannot delete hall: upcoming shows have reservations")
         default:
-            return c.JSON(http.StatusInternalServerError, echo.Map{"error": "delete failed"})
+            return apperr.Internal("delete failed")
         }
     }
+    recordAudit(c, h.Audit, auditEvent("hall.delete", "hall", id, ownerID), before, nil)
     return c.NoContent(http.StatusNoContent)
 }
 
@@ -80,28 +95,40 @@ func (h *OwnerHandler) DeleteHall(c echo.Context) error {
 func (h *OwnerHandler) DeleteShow(c echo.Context) error {
     ownerID, err := getUserID(c)
     if err != nil {
-        return c.JSON(http.StatusUnauthorized, echo.Map{"error": "unauthorized"})
+        return apperr.Unauthorized("unauthorized")
     }
     id, err := strconv.ParseUint(c.Param("id"), 10, 64)
     if err != nil {
-        return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid id"})
+        return apperr.BadRequest("invalid id")
+    }
+    // Snapshot the show (and its hall's owner, who may not be the caller
+    // when staff delete it) for the audit log.
+    var (
+        before      *repository.Show
+        showOwnerID uint64
+    )
+    if h.Audit != nil {
+        if before, err = h.ShowRepo.GetByID(c.Request().Context(), id); err == nil {
+            showOwnerID = hallOwnerID(c.Request().Context(), h.HallRepo, before.HallID)
+        }
     }
     err = h.ShowRepo.DeleteByIDAndOwner(c.Request().Context(), id, ownerID)
     if err != nil {
         switch err {
         case repository.ErrShowNotFound:
             // Not found sentinel is defined in show repository
-            return c.JSON(http.StatusNotFound, echo.Map{"error": "show not found"})
+            return apperr.NotFound("show not found")
         case sql.ErrNoRows:
             // In case DeleteByIDAndOwner uses sql.ErrNoRows for not found
-            return c.JSON(http.StatusNotFound, echo.Map{"error": "show not found"})
+            return apperr.NotFound("show not found")
         case repository.ErrForbidden:
-            return c.JSON(http.StatusForbidden, echo.Map{"error": "forbidden"})
+            return apperr.Forbidden("forbidden")
         case repository.ErrConflict:
-            return c.JSON(http.StatusConflict, echo.Map{"error": "cannot delete show with reservations"})
+            return apperr.Conflict(apperr.CodeShowInUse, "cannot delete show with reservations")
         default:
-            return c.JSON(http.StatusInternalServerError, echo.Map{"error": "delete failed"})
+            return apperr.Internal("delete failed")
         }
     }
+    recordAudit(c, h.Audit, auditEvent("show.delete", "show", id, showOwnerID), before, nil)
     return c.NoContent(http.StatusNoContent)
 }
\ No newline at end of file
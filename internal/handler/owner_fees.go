@@ -0,0 +1,86 @@
+package handler
+
+import (
+    "errors"
+    "net/http"
+    "strconv"
+    "strings"
+
+    "github.com/iliyamo/cinema-seat-reservation/internal/apperr"
+    "github.com/iliyamo/cinema-seat-reservation/internal/repository"
+    "github.com/labstack/echo/v4"
+)
+
+// cinemaFeesReq sets what a cinema charges on top of its seat prices.
+// fee_type PERCENT charges fee_value basis points of the discounted seat
+// prices, FLAT charges fee_value cents per ticket; null or absent charges
+// no fee.  tax_rate_bp is the tax in basis points (1900 = 19%) of the
+// discounted seat prices plus the fee.
+type cinemaFeesReq struct {
+    FeeType   *string `json:"fee_type" validate:"oneof=PERCENT FLAT"`
+    FeeValue  uint32  `json:"fee_value"`
+    TaxRateBP uint32  `json:"tax_rate_bp" validate:"max=10000"`
+}
+
+// GetCinemaFees handles GET /v1/cinemas/:id/fees and returns the booking
+// fee and tax rate of one of the caller's cinemas.
+func (h *OwnerHandler) GetCinemaFees(c echo.Context) error {
+    ownerID, err := getUserID(c)
+    if err != nil {
+        return apperr.Unauthorized("unauthorized")
+    }
+    id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+    if err != nil || id == 0 {
+        return apperr.BadRequest("invalid id")
+    }
+    fees, err := h.CinemaRepo.Fees(c.Request().Context(), id, ownerID)
+    if err != nil {
+        if errors.Is(err, repository.ErrCinemaNotFound) {
+            return apperr.NotFound("cinema not found")
+        }
+        return apperr.Internal("db error")
+    }
+    return c.JSON(http.StatusOK, fees)
+}
+
+// UpdateCinemaFees handles PUT /v1/cinemas/:id/fees and replaces the
+// booking fee and tax rate of a cinema.  They apply to reservations made
+// from then on; existing reservations keep what they were charged.
+func (h *OwnerHandler) UpdateCinemaFees(c echo.Context) error {
+    ownerID, err := getUserID(c)
+    if err != nil {
+        return apperr.Unauthorized("unauthorized")
+    }
+    id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+    if err != nil || id == 0 {
+        return apperr.BadRequest("invalid id")
+    }
+    var body cinemaFeesReq
+    if err := bindValid(c, &body); err != nil {
+        return err
+    }
+    after := &repository.CinemaFees{TaxRateBP: body.TaxRateBP}
+    if body.FeeType != nil {
+        t := strings.ToUpper(*body.FeeType)
+        if t == repository.FeePercent && body.FeeValue > 10000 {
+            return fieldError("fee_value", "must be at most 10000 basis points")
+        }
+        after.FeeType, after.FeeValue = &t, body.FeeValue
+    }
+    ctx := c.Request().Context()
+    before, err := h.CinemaRepo.Fees(ctx, id, ownerID)
+    if err != nil {
+        if errors.Is(err, repository.ErrCinemaNotFound) {
+            return apperr.NotFound("cinema not found")
+        }
+        return apperr.Internal("db error")
+    }
+    if err := h.CinemaRepo.SetFees(ctx, id, ownerID, after); err != nil {
+        if errors.Is(err, repository.ErrCinemaNotFound) {
+            return apperr.NotFound("cinema not found")
+        }
+        return apperr.Internal("update failed")
+    }
+    recordAudit(c, h.Audit, auditEvent("cinema.fees", "cinema", id, ownerID), before, after)
+    return c.JSON(http.StatusOK, after)
+}
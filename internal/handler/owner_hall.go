@@ -1,33 +1,44 @@
 package handler // handler package contains owner-specific hall handlers
 
 import (
+    "context"                                                   // context carries request deadlines into helpers
     "database/sql"                                              // sql provides nullable types and error values
     "net/http"                                                 // http defines status code constants
     "strconv"                                                 // strconv parses URL parameters to numbers
     "strings"                                                 // strings manipulates and trims text
     "errors"                                                  // errors package for comparing sentinels
+    "time"                                                    // time for show start dates
 
+    "github.com/iliyamo/cinema-seat-reservation/internal/apperr" // apperr builds error responses
+    "github.com/iliyamo/cinema-seat-reservation/internal/cache"  // cache drops public responses of the rebuilt hall
     "github.com/iliyamo/cinema-seat-reservation/internal/repository" // repository exposes database models
+    "github.com/iliyamo/cinema-seat-reservation/internal/service"    // service prices seats on special dates
+    "github.com/iliyamo/cinema-seat-reservation/internal/validate"   // validate checks request bodies
     "github.com/labstack/echo/v4"                                   // echo framework supplies request context
 )
 
-// CreateHall handles POST /v1/halls and creates a hall along with its initial seat layout
+// createHallReq is the body of POST /v1/halls.
+type createHallReq struct {
+    CinemaID    *uint64 `json:"cinema_id"`                      // optional ID of the parent cinema
+    Name        string  `json:"name" validate:"required,max=100"` // required hall name
+    Description *string `json:"description"`                    // optional description
+    SeatRows    *uint32 `json:"seat_rows" validate:"min=1"`     // number of seating rows
+    SeatCols    *uint32 `json:"seat_cols" validate:"min=1"`     // number of seats per row
+    Rows        *uint32 `json:"rows" validate:"min=1"`          // legacy alias for seat_rows
+    Cols        *uint32 `json:"cols" validate:"min=1"`          // legacy alias for seat_cols
+    Layout      *hallLayoutReq `json:"layout"`                  // optional custom layout instead of seat_rows/seat_cols
+}
+
+// CreateHall handles POST /v1/halls and creates a hall along with its initial seat layout.
+// With layout the seats follow the custom layout and seat_rows/seat_cols must be omitted.
 func (h *OwnerHandler) CreateHall(c echo.Context) error { // begin CreateHall handler
     ownerID, err := getUserID(c) // retrieve authenticated user ID
     if err != nil { // check authentication error
-        return c.JSON(http.StatusUnauthorized, map[string]string{"error": "unauthorized"}) // respond unauthorized when user ID is invalid
-    }
-    var body struct { // anonymous struct to bind JSON payload
-        CinemaID    *uint64 `json:"cinema_id"`    // optional ID of the parent cinema
-        Name        string  `json:"name"`         // required hall name
-        Description *string `json:"description"`  // optional description
-        SeatRows    *uint32 `json:"seat_rows"`    // number of seating rows
-        SeatCols    *uint32 `json:"seat_cols"`    // number of seats per row
-        Rows        *uint32 `json:"rows"`         // legacy alias for seat_rows
-        Cols        *uint32 `json:"cols"`         // legacy alias for seat_cols
+        return apperr.Unauthorized("unauthorized") // respond unauthorized when user ID is invalid
     }
-    if err := c.Bind(&body); err != nil { // bind the incoming JSON
-        return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request body"}) // respond bad request on binding errors
+    var body createHallReq // JSON payload
+    if err := bindValid(c, &body); err != nil { // bind and validate the incoming JSON
+        return err // respond with 400/422 describing the problem
     }
     rowsPtr := body.SeatRows // seatRows may be nil
     if rowsPtr == nil { // fallback to legacy field when seatRows is absent
@@ -37,19 +48,35 @@ func (h *OwnerHandler) CreateHall(c echo.Context) error { // begin CreateHall ha
     if colsPtr == nil { // fallback to legacy field when seatCols is absent
         colsPtr = body.Cols // use legacy cols field
     }
-    if strings.TrimSpace(body.Name) == "" || rowsPtr == nil || colsPtr == nil || *rowsPtr == 0 || *colsPtr == 0 { // validate required fields
-        return c.JSON(http.StatusBadRequest, map[string]string{ // respond with bad request when validation fails
-            "error": "name, seat_rows and seat_cols are required and must be greater than zero", // descriptive error message
-        })
+    var layout *repository.HallLayout // custom layout; nil for a dense grid
+    if body.Layout != nil {
+        if rowsPtr != nil || colsPtr != nil {
+            return fieldError("layout", "cannot be combined with seat_rows/seat_cols")
+        }
+        if layout, err = body.Layout.layout(); err != nil {
+            return err
+        }
+        nRows, nCols := layout.Dims() // the grid dimensions implied by the layout
+        rowsPtr, colsPtr = &nRows, &nCols
+    }
+    var errs validate.Errors // seat_rows/seat_cols may come from either alias
+    if rowsPtr == nil {
+        errs.Add("seat_rows", "is required")
+    }
+    if colsPtr == nil {
+        errs.Add("seat_cols", "is required")
+    }
+    if len(errs) > 0 {
+        return apperr.Validation(errs)
     }
     var cinemaIDVal uint64 // hold resolved cinema ID
     if body.CinemaID != nil { // if a cinema ID was provided
         cinemaIDVal = *body.CinemaID // dereference the pointer
         if _, err := h.CinemaRepo.GetByIDAndOwner(c.Request().Context(), cinemaIDVal, ownerID); err != nil { // verify the cinema belongs to owner
             if err == repository.ErrCinemaNotFound { // not found error
-                return c.JSON(http.StatusNotFound, map[string]string{"error": "cinema not found"}) // respond with not found
+                return apperr.NotFound("cinema not found") // respond with not found
             }
-            return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to verify cinema"}) // respond with internal error
+            return apperr.Internal("failed to verify cinema") // respond with internal error
         }
     }
     seatRows := int32(*rowsPtr) // convert row count to int32 for sql.NullInt32
@@ -75,58 +102,58 @@ func (h *OwnerHandler) CreateHall(c echo.Context) error { // begin CreateHall ha
     // Before creating the hall, ensure no other hall exists with identical attributes
     if ok, err := h.HallRepo.ExistsExact(c.Request().Context(),
         ownerID, hall.CinemaID, hall.Name, hall.Description, hall.SeatRows, hall.SeatCols, nil); err != nil {
-        return c.JSON(http.StatusInternalServerError, map[string]string{"error": "db error"})
+        return apperr.Internal("db error")
     } else if ok {
-        return c.JSON(http.StatusConflict, map[string]string{"error": "hall already exists with identical attributes"})
+        return apperr.Conflict(apperr.CodeAlreadyExists, "hall already exists with identical attributes")
     }
     if err := h.HallRepo.Create(c.Request().Context(), hall); err != nil { // create hall in repository
         // Unexpected error occurred
-        return c.JSON(http.StatusInternalServerError, map[string]string{"error": "could not create hall"})
-    }
-    total := int(*rowsPtr) * int(*colsPtr) // calculate total seats to preallocate slice
-    seats := make([]repository.Seat, 0, total) // slice to hold seat definitions
-    for rIdx := uint32(0); rIdx < *rowsPtr; rIdx++ { // iterate rows
-        label := indexToRowLabel(int(rIdx)) // compute row label for index
-        for cIdx := uint32(0); cIdx < *colsPtr; cIdx++ { // iterate columns
-            seats = append(seats, repository.Seat{ // append a seat definition
-                HallID:     hall.ID,           // assign hall ID
-                RowLabel:   label,             // assign computed row label
-                SeatNumber: cIdx + 1,          // seat numbers start from 1
-                SeatType:   "STANDARD",       // default seat type
-            })
+        return apperr.Internal("could not create hall")
+    }
+    seats := gridSeats(hall.ID, *rowsPtr, *colsPtr) // one seat per grid position
+    if layout != nil { // a custom layout lists its seats explicitly
+        if err := h.HallRepo.SetLayout(c.Request().Context(), hall.ID, layout); err != nil {
+            return apperr.Internal("could not store hall layout")
         }
+        seats = layout.Seats(hall.ID)
     }
     if err := h.SeatRepo.CreateBulk(c.Request().Context(), seats); err != nil { // insert all seats in bulk
-        return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to create seats"}) // respond with error on failure
+        return apperr.Internal("failed to create seats") // respond with error on failure
     }
+    recordAudit(c, h.Audit, auditEvent("hall.create", "hall", hall.ID, ownerID), nil, hall) // record the new hall
     return c.JSON(http.StatusCreated, hall) // return the created hall with created status
 }
 
-// UpdateHall handles PUT/PATCH /v1/halls/:id and updates hall properties.  When seat counts change it rebuilds the seat layout.
+// UpdateHall handles PUT/PATCH /v1/halls/:id and updates hall properties.  When seat counts change it rebuilds the seat layout
+// as a dense grid, replacing any custom layout (see PutHallLayout).  A plain rebuild deletes the old seats and is refused
+// once any hold or reservation used them; with archive the old seats are retired instead, so past shows keep their seat
+// maps and reservations, and only shows that have not started are moved to the new seats (see archiveHallSeatsTx).
 func (h *OwnerHandler) UpdateHall(c echo.Context) error { // begin UpdateHall handler
     ownerID, err := getUserID(c) // fetch user ID from context
     if err != nil { // unauthorized when user ID is invalid
-        return c.JSON(http.StatusUnauthorized, map[string]string{"error": "unauthorized"}) // respond unauthorized
+        return apperr.Unauthorized("unauthorized") // respond unauthorized
     }
     id, err := strconv.ParseUint(c.Param("id"), 10, 64) // parse hall ID from path
     if err != nil { // ensure the hall ID is numeric
-        return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid id"}) // invalid ID error
+        return apperr.BadRequest("invalid id") // invalid ID error
     }
     cur, err := h.HallRepo.GetByIDAndOwner(c.Request().Context(), id, ownerID) // load current hall to verify ownership
     if err != nil { // handle fetch error
         if err == repository.ErrHallNotFound { // hall not found for this owner
-            return c.JSON(http.StatusNotFound, map[string]string{"error": "hall not found"}) // respond with not found
+            return apperr.NotFound("hall not found") // respond with not found
         }
-        return c.JSON(http.StatusInternalServerError, map[string]string{"error": "db error"}) // generic database error
+        return apperr.Internal("db error") // generic database error
     }
+    ownerID = cur.OwnerID // staff members edit on behalf of the hall's owner
     var body struct { // struct to bind JSON body
-        Name        *string `json:"name"`        // optional new name
-        Description *string `json:"description"` // optional new description
-        SeatRows    *uint32 `json:"seat_rows"`   // optional new number of rows
-        SeatCols    *uint32 `json:"seat_cols"`   // optional new number of columns
+        Name        *string `json:"name" validate:"max=100"`    // optional new name
+        Description *string `json:"description"`                // optional new description
+        SeatRows    *uint32 `json:"seat_rows" validate:"min=1"` // optional new number of rows
+        SeatCols    *uint32 `json:"seat_cols" validate:"min=1"` // optional new number of columns
+        Archive     bool    `json:"archive"`                    // optional; retire the old seats instead of deleting them
     }
-    if err := c.Bind(&body); err != nil { // bind JSON payload
-        return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request body"}) // respond bad request on binding error
+    if err := bindValid(c, &body); err != nil { // bind and validate JSON payload
+        return err // respond with 400/422 describing the problem
     }
     name := cur.Name // start with current name
     // Update name when provided and non empty
@@ -147,9 +174,6 @@ func (h *OwnerHandler) UpdateHall(c echo.Context) error { // begin UpdateHall ha
     // Determine new seat rows
     rows := cur.SeatRows
     if body.SeatRows != nil {
-        if *body.SeatRows == 0 {
-            return c.JSON(http.StatusBadRequest, map[string]string{"error": "seat_rows must be greater than zero"})
-        }
         rows = sql.NullInt32{Int32: int32(*body.SeatRows), Valid: true}
     }
     // Determine new seat columns
@@ -157,9 +181,6 @@ func (h *OwnerHandler) UpdateHall(c echo.Context) error { // begin UpdateHall ha
 
     // If no seat columns were provided in the body, cols remains the current value.
     if body.SeatCols != nil {
-        if *body.SeatCols == 0 {
-            return c.JSON(http.StatusBadRequest, map[string]string{"error": "seat_cols must be greater than zero"})
-        }
         cols = sql.NullInt32{Int32: int32(*body.SeatCols), Valid: true}
     }
     // If all four attributes are unchanged, return a 409 Conflict: nothing to update
@@ -168,14 +189,14 @@ func (h *OwnerHandler) UpdateHall(c echo.Context) error { // begin UpdateHall ha
     sameRows := (rows.Valid == cur.SeatRows.Valid) && (!rows.Valid || rows.Int32 == cur.SeatRows.Int32)
     sameCols := (cols.Valid == cur.SeatCols.Valid) && (!cols.Valid || cols.Int32 == cur.SeatCols.Int32)
     if sameName && sameDesc && sameRows && sameCols {
-        return c.JSON(http.StatusConflict, map[string]string{"error": "hall already has these parameters"})
+        return apperr.Conflict(apperr.CodeAlreadyExists, "hall already has these parameters")
     }
     // Check if another hall exists with identical attributes.  If so, return conflict.
     {
         if ok, err := h.HallRepo.ExistsExact(c.Request().Context(), ownerID, cur.CinemaID, name, desc, rows, cols, &id); err != nil {
-            return c.JSON(http.StatusInternalServerError, map[string]string{"error": "db error"})
+            return apperr.Internal("db error")
         } else if ok {
-            return c.JSON(http.StatusConflict, map[string]string{"error": "hall name/rows/cols/desc already used by another hall"})
+            return apperr.Conflict(apperr.CodeAlreadyExists, "hall name/rows/cols/desc already used by another hall")
         }
     }
     // Determine whether the seat layout will change based on requested values.
@@ -197,33 +218,23 @@ func (h *OwnerHandler) UpdateHall(c echo.Context) error { // begin UpdateHall ha
     }
     gridChanged := newRows != curRows || newCols != curCols
     if gridChanged {
-        // Before rebuilding the seat grid, ensure there are no active reservations or holds
-        // that reference seats in this hall.  If any exist, abort with a clear error.
         ctx := c.Request().Context()
-        // Count seat holds referencing seats in this hall via seat_id join.
-        var holdCount int
-        if err := h.ShowRepo.DB().QueryRowContext(ctx,
-            `SELECT COUNT(*) FROM seat_holds h JOIN seats s ON h.seat_id = s.id WHERE s.hall_id = ?`, id,
-        ).Scan(&holdCount); err != nil {
-            return c.JSON(http.StatusInternalServerError, map[string]string{"error": "db error"})
-        }
-        // Count reservation seats referencing seats in this hall via seat_id join.
-        var resCount int
-        if err := h.ShowRepo.DB().QueryRowContext(ctx,
-            `SELECT COUNT(*) FROM reservation_seats rs JOIN seats s ON rs.seat_id = s.id WHERE s.hall_id = ?`, id,
-        ).Scan(&resCount); err != nil {
-            return c.JSON(http.StatusInternalServerError, map[string]string{"error": "db error"})
+        if body.Archive {
+            if err := h.checkFutureSeatsUnused(ctx, id); err != nil {
+                return err
+            }
+        } else if err := h.checkHallSeatsUnused(ctx, id); err != nil {
+            return err
         }
-        if holdCount > 0 || resCount > 0 {
-            return c.JSON(http.StatusBadRequest, map[string]string{
-                "error": "Cannot update seat grid: shows or reservations are using seats",
-            })
+        // Insert new seat grid.  Ensure non-zero dimensions have been validated earlier.
+        if newRows == 0 || newCols == 0 {
+            return apperr.BadRequest("seat_rows and seat_cols must be greater than zero")
         }
 
         // Rebuild the seat layout and associated show_seats in a single transaction.
         tx, err := h.ShowRepo.DB().BeginTx(ctx, nil)
         if err != nil {
-            return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to start transaction"})
+            return apperr.Internal("failed to start transaction")
         }
         committed := false
         defer func() {
@@ -232,120 +243,31 @@ func (h *OwnerHandler) UpdateHall(c echo.Context) error { // begin UpdateHall ha
             }
         }()
 
-        // Update hall metadata inside the transaction.  At this point we know the grid will change.
+        // Update hall metadata inside the transaction.  At this point we know
+        // the grid will change; a dense grid replaces any custom layout.
         _, err = tx.ExecContext(ctx,
-            `UPDATE halls SET name = ?, description = ?, seat_rows = ?, seat_cols = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ? AND owner_id = ?`,
+            `UPDATE halls SET name = ?, description = ?, seat_rows = ?, seat_cols = ?, layout = NULL, updated_at = CURRENT_TIMESTAMP WHERE id = ? AND owner_id = ?`,
             name, desc, rows, cols, id, ownerID,
         )
         if err != nil {
-            return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to update hall"})
+            return apperr.Internal("failed to update hall")
         }
-
-        // Remove all show_seats for shows in this hall before deleting seats to avoid FK violations.
-        if _, err = tx.ExecContext(ctx,
-            `DELETE ss FROM show_seats ss JOIN shows sh ON sh.id = ss.show_id WHERE sh.hall_id = ?`, id,
-        ); err != nil {
-            return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to clear show_seats"})
+        rebuild := h.rebuildHallSeatsTx
+        if body.Archive {
+            rebuild = h.archiveHallSeatsTx
         }
-
-        // Delete old seats now that show_seats are cleared.
-        if _, err = tx.ExecContext(ctx, `DELETE FROM seats WHERE hall_id = ?`, id); err != nil {
-            return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to delete old seats"})
-        }
-
-        // Insert new seat grid.  Ensure non-zero dimensions have been validated earlier.
-        if newRows == 0 || newCols == 0 {
-            return c.JSON(http.StatusBadRequest, map[string]string{"error": "seat_rows and seat_cols must be greater than zero"})
-        }
-        var sb strings.Builder
-        sb.WriteString(`INSERT INTO seats (hall_id, row_label, seat_number, seat_type) VALUES `)
-        args := make([]any, 0, int(newRows*newCols)*4)
-        first := true
-        for r := uint32(0); r < newRows; r++ {
-            lbl := indexToRowLabel(int(r))
-            for n := uint32(1); n <= newCols; n++ {
-                if !first {
-                    sb.WriteByte(',')
-                } else {
-                    first = false
-                }
-                sb.WriteString("(?, ?, ?, ?)")
-                args = append(args, id, lbl, n, "STANDARD")
-            }
-        }
-        if _, err = tx.ExecContext(ctx, sb.String(), args...); err != nil {
-            return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to create new seats"})
-        }
-
-        // Fetch all shows for this hall to rebuild their show seats.
-        showRows, err := tx.QueryContext(ctx, `SELECT id, base_price_cents FROM shows WHERE hall_id = ?`, id)
-        if err != nil {
-            return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to load shows"})
-        }
-        type showInfo struct {
-            id    uint64
-            price uint32
-        }
-        var shows []showInfo
-        for showRows.Next() {
-            var sid uint64
-            var price uint32
-            if err = showRows.Scan(&sid, &price); err != nil {
-                showRows.Close()
-                return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to read show"})
-            }
-            shows = append(shows, showInfo{id: sid, price: price})
-        }
-        if err = showRows.Err(); err != nil {
-            showRows.Close()
-            return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to load shows"})
-        }
-        showRows.Close()
-
-        // Load the new seat IDs for this hall, ordered for consistent seat numbering.
-        seatRows, err := tx.QueryContext(ctx, `SELECT id FROM seats WHERE hall_id = ? ORDER BY row_label, seat_number`, id)
-        if err != nil {
-            return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to load seats"})
-        }
-        var seatIDs []uint64
-        for seatRows.Next() {
-            var sid uint64
-            if err = seatRows.Scan(&sid); err != nil {
-                seatRows.Close()
-                return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to read seats"})
-            }
-            seatIDs = append(seatIDs, sid)
-        }
-        if err = seatRows.Err(); err != nil {
-            seatRows.Close()
-            return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to load seats"})
-        }
-        seatRows.Close()
-
-        // For each show, rebuild its show_seats using the new seats and base price.
-        for _, sh := range shows {
-            ss := make([]repository.ShowSeat, 0, len(seatIDs))
-            for _, sid := range seatIDs {
-                ss = append(ss, repository.ShowSeat{
-                    ShowID:     sh.id,
-                    SeatID:     sid,
-                    Status:     "FREE",
-                    PriceCents: sh.price,
-                    Version:    1,
-                })
-            }
-            if err = h.ShowSeatRepo.CreateBulkTx(ctx, tx, ss); err != nil {
-                return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to rebuild show_seats"})
-            }
+        if err := rebuild(ctx, tx, cur, gridSeats(id, newRows, newCols)); err != nil {
+            return err
         }
 
         if err = tx.Commit(); err != nil {
-            return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to commit transaction"})
+            return apperr.Internal("failed to commit transaction")
         }
         committed = true
 
         // Reload and return the updated hall record.
         fresh, err := h.HallRepo.GetByID(ctx, id)
+        recordAudit(c, h.Audit, auditEvent("hall.update", "hall", id, ownerID), cur, fresh)
         if err != nil {
             // If retrieving the hall fails, still return the user-supplied fields.
             return c.JSON(http.StatusOK, map[string]any{
@@ -373,36 +295,249 @@ func (h *OwnerHandler) UpdateHall(c echo.Context) error { // begin UpdateHall ha
     }
     if err := h.HallRepo.UpdateByIDAndOwner(c.Request().Context(), upd); err != nil {
         if err == sql.ErrNoRows {
-            return c.JSON(http.StatusNotFound, map[string]string{"error": "hall not found"})
+            return apperr.NotFound("hall not found")
         }
         if errors.Is(err, repository.ErrHallConflict) {
-            return c.JSON(http.StatusConflict, map[string]string{"error": "hall name/rows/cols/desc already used by another hall"})
+            return apperr.Conflict(apperr.CodeAlreadyExists, "hall name/rows/cols/desc already used by another hall")
         }
-        return c.JSON(http.StatusInternalServerError, map[string]string{"error": "update failed"})
+        return apperr.Internal("update failed")
     }
     fresh, _ := h.HallRepo.GetByID(c.Request().Context(), id)
+    recordAudit(c, h.Audit, auditEvent("hall.update", "hall", id, ownerID), cur, fresh)
     return c.JSON(http.StatusOK, fresh)
 }
 
+// gridSeats returns a STANDARD seat for every (row, number) pair of a
+// dense rows x cols grid.
+func gridSeats(hallID uint64, rows, cols uint32) []repository.Seat {
+    seats := make([]repository.Seat, 0, int(rows)*int(cols))
+    for r := uint32(0); r < rows; r++ {
+        lbl := indexToRowLabel(int(r))
+        for n := uint32(1); n <= cols; n++ {
+            seats = append(seats, repository.Seat{HallID: hallID, RowLabel: lbl, SeatNumber: n, SeatType: "STANDARD"})
+        }
+    }
+    return seats
+}
+
+// checkHallSeatsUnused returns 400 HALL_IN_USE when holds or reservations
+// reference seats of the hall, which must not be rebuilt then.
+func (h *OwnerHandler) checkHallSeatsUnused(ctx context.Context, hallID uint64) error {
+    // Count seat holds referencing seats in this hall via seat_id join.
+    var holdCount int
+    if err := h.ShowRepo.DB().QueryRowContext(ctx,
+        `SELECT COUNT(*) FROM seat_holds h JOIN seats s ON h.seat_id = s.id WHERE s.hall_id = ?`, hallID,
+    ).Scan(&holdCount); err != nil {
+        return apperr.Internal("db error")
+    }
+    // Count reservation seats referencing seats in this hall via seat_id join.
+    var resCount int
+    if err := h.ShowRepo.DB().QueryRowContext(ctx,
+        `SELECT COUNT(*) FROM reservation_seats rs JOIN seats s ON rs.seat_id = s.id WHERE s.hall_id = ?`, hallID,
+    ).Scan(&resCount); err != nil {
+        return apperr.Internal("db error")
+    }
+    if holdCount > 0 || resCount > 0 {
+        return apperr.New(http.StatusBadRequest, apperr.CodeHallInUse, "Cannot update seat grid: shows or reservations are using seats")
+    }
+    return nil
+}
+
+// checkFutureSeatsUnused returns 400 HALL_IN_USE when shows of the hall
+// that have not started have active holds or open reservations, whose
+// seats an archived rebuild could not move.
+func (h *OwnerHandler) checkFutureSeatsUnused(ctx context.Context, hallID uint64) error {
+    var holdCount, resCount int
+    if err := h.ShowRepo.DB().QueryRowContext(ctx,
+        `SELECT COUNT(*) FROM seat_holds sh JOIN shows s ON s.id = sh.show_id
+         WHERE s.hall_id = ? AND s.starts_at > UTC_TIMESTAMP() AND sh.expires_at > UTC_TIMESTAMP()`, hallID,
+    ).Scan(&holdCount); err != nil {
+        return apperr.Internal("db error")
+    }
+    if err := h.ShowRepo.DB().QueryRowContext(ctx,
+        `SELECT COUNT(*) FROM reservations r JOIN shows s ON s.id = r.show_id
+         WHERE s.hall_id = ? AND s.starts_at > UTC_TIMESTAMP() AND r.status IN ('PENDING', 'CONFIRMED')`, hallID,
+    ).Scan(&resCount); err != nil {
+        return apperr.Internal("db error")
+    }
+    if holdCount > 0 || resCount > 0 {
+        return apperr.New(http.StatusBadRequest, apperr.CodeHallInUse, "Cannot update seat grid: upcoming shows have holds or reservations")
+    }
+    return nil
+}
+
+// rebuildHallSeatsTx replaces every seat of a hall with seats and rebuilds
+// the show seats of all its shows from them.  seats must not be empty.
+func (h *OwnerHandler) rebuildHallSeatsTx(ctx context.Context, tx *sql.Tx, hall *repository.Hall, seats []repository.Seat) error {
+    id := hall.ID
+    // Remove all show_seats for shows in this hall before deleting seats to avoid FK violations.
+    if _, err := tx.ExecContext(ctx,
+        `DELETE ss FROM show_seats ss JOIN shows sh ON sh.id = ss.show_id WHERE sh.hall_id = ?`, id,
+    ); err != nil {
+        return apperr.Internal("failed to clear show_seats")
+    }
+
+    // Delete old seats now that show_seats are cleared.
+    if _, err := tx.ExecContext(ctx, `DELETE FROM seats WHERE hall_id = ?`, id); err != nil {
+        return apperr.Internal("failed to delete old seats")
+    }
+    if err := insertHallSeatsTx(ctx, tx, id, seats); err != nil {
+        return err
+    }
+    return h.rebuildShowSeatsTx(ctx, tx, hall, false)
+}
+
+// archiveHallSeatsTx is rebuildHallSeatsTx for halls with history.  The
+// current seats are retired (valid_to is set) rather than deleted, so the
+// show seats, holds and reservations of past shows keep referencing the
+// seats they were sold for.  Shows that have not started get show seats
+// for the new seats; they must have no active holds or open reservations
+// (see checkFutureSeatsUnused).
+func (h *OwnerHandler) archiveHallSeatsTx(ctx context.Context, tx *sql.Tx, hall *repository.Hall, seats []repository.Seat) error {
+    id := hall.ID
+    if _, err := tx.ExecContext(ctx,
+        `DELETE ss FROM show_seats ss JOIN shows sh ON sh.id = ss.show_id WHERE sh.hall_id = ? AND sh.starts_at > UTC_TIMESTAMP()`, id,
+    ); err != nil {
+        return apperr.Internal("failed to clear show_seats")
+    }
+    if _, err := tx.ExecContext(ctx,
+        `UPDATE seats SET valid_to = CURRENT_TIMESTAMP WHERE hall_id = ? AND valid_to IS NULL`, id,
+    ); err != nil {
+        return apperr.Internal("failed to retire old seats")
+    }
+    if err := insertHallSeatsTx(ctx, tx, id, seats); err != nil {
+        return err
+    }
+    return h.rebuildShowSeatsTx(ctx, tx, hall, true)
+}
+
+// insertHallSeatsTx inserts seats into hall id.
+func insertHallSeatsTx(ctx context.Context, tx *sql.Tx, id uint64, seats []repository.Seat) error {
+    var sb strings.Builder
+    sb.WriteString(`INSERT INTO seats (hall_id, row_label, seat_number, seat_type) VALUES `)
+    args := make([]any, 0, len(seats)*4)
+    for i, st := range seats {
+        if i > 0 {
+            sb.WriteByte(',')
+        }
+        sb.WriteString("(?, ?, ?, ?)")
+        args = append(args, id, st.RowLabel, st.SeatNumber, st.SeatType)
+    }
+    if _, err := tx.ExecContext(ctx, sb.String(), args...); err != nil {
+        return apperr.Internal("failed to create new seats")
+    }
+    return nil
+}
+
+// rebuildShowSeatsTx creates show seats for the current seats of a hall
+// for all its shows, or only those that have not started when
+// futureOnly is set, and snapshots each new seat map.  The shows must
+// have no show seats left.
+func (h *OwnerHandler) rebuildShowSeatsTx(ctx context.Context, tx *sql.Tx, hall *repository.Hall, futureOnly bool) error {
+    id := hall.ID
+    // Fetch the shows of this hall to rebuild their show seats.
+    q := `SELECT id, base_price_cents, starts_at FROM shows WHERE hall_id = ?`
+    if futureOnly {
+        q += ` AND starts_at > UTC_TIMESTAMP()`
+    }
+    showRows, err := tx.QueryContext(ctx, q, id)
+    if err != nil {
+        return apperr.Internal("failed to load shows")
+    }
+    type showInfo struct {
+        id     uint64
+        price  uint32
+        starts time.Time
+    }
+    var shows []showInfo
+    for showRows.Next() {
+        var sid uint64
+        var price uint32
+        var starts time.Time
+        if err = showRows.Scan(&sid, &price, &starts); err != nil {
+            showRows.Close()
+            return apperr.Internal("failed to read show")
+        }
+        shows = append(shows, showInfo{id: sid, price: price, starts: starts})
+    }
+    if err = showRows.Err(); err != nil {
+        showRows.Close()
+        return apperr.Internal("failed to load shows")
+    }
+    showRows.Close()
+
+    // Load the new seat IDs for this hall, ordered for consistent seat numbering.
+    seatRows, err := tx.QueryContext(ctx, `SELECT id FROM seats WHERE hall_id = ? AND valid_to IS NULL ORDER BY row_label, seat_number`, id)
+    if err != nil {
+        return apperr.Internal("failed to load seats")
+    }
+    var seatIDs []uint64
+    for seatRows.Next() {
+        var sid uint64
+        if err = seatRows.Scan(&sid); err != nil {
+            seatRows.Close()
+            return apperr.Internal("failed to read seats")
+        }
+        seatIDs = append(seatIDs, sid)
+    }
+    if err = seatRows.Err(); err != nil {
+        seatRows.Close()
+        return apperr.Internal("failed to load seats")
+    }
+    seatRows.Close()
+
+    // For each show, rebuild its show_seats using the new seats and base
+    // price, scaled when the show starts on a special date.
+    for _, sh := range shows {
+        price := sh.price
+        if h.CalendarRepo != nil && hall.CinemaID != nil {
+            starts := sh.starts.In(loadTimezone(hall.Timezone))
+            days, err := h.CalendarRepo.ListRangeTx(ctx, tx, *hall.CinemaID, starts, starts)
+            if err != nil {
+                return apperr.Internal("failed to load cinema calendar")
+            }
+            price = repository.ApplyPriceMultiplier(price, service.PriceMultiplierPct(days, starts))
+        }
+        ss := make([]repository.ShowSeat, 0, len(seatIDs))
+        for _, sid := range seatIDs {
+            ss = append(ss, repository.ShowSeat{
+                ShowID:     sh.id,
+                SeatID:     sid,
+                Status:     "FREE",
+                PriceCents: price,
+                Version:    1,
+            })
+        }
+        if err = h.ShowSeatRepo.CreateBulkTx(ctx, tx, ss); err != nil {
+            return apperr.Internal("failed to rebuild show_seats")
+        }
+        if err = h.ShowSeatRepo.SnapshotTx(ctx, tx, sh.id, repository.SnapshotHallRebuilt); err != nil {
+            return apperr.Internal("failed to snapshot seat map")
+        }
+    }
+    cache.CatalogChanged(ctx)
+    return nil
+}
+
 // ListHallsInCinema handles GET /v1/cinemas/:cinema_id/halls and lists halls for a cinema owned by the user
 func (h *OwnerHandler) ListHallsInCinema(c echo.Context) error { // begin ListHallsInCinema handler
     ownerID, err := getUserID(c) // extract user ID
     if err != nil { // invalid user ID
-        return c.JSON(http.StatusUnauthorized, map[string]string{"error": "unauthorized"}) // respond unauthorized
+        return apperr.Unauthorized("unauthorized") // respond unauthorized
     }
     cinemaID, err := strconv.ParseUint(c.Param("cinema_id"), 10, 64) // parse cinema ID from path
     if err != nil { // invalid ID format
-        return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid cinema_id"}) // respond bad request
+        return apperr.BadRequest("invalid cinema_id") // respond bad request
     }
     if _, err := h.CinemaRepo.GetByIDAndOwner(c.Request().Context(), cinemaID, ownerID); err != nil { // verify ownership of cinema
         if err == repository.ErrCinemaNotFound { // cinema does not exist for this owner
-            return c.JSON(http.StatusNotFound, map[string]string{"error": "cinema not found"}) // respond not found
+            return apperr.NotFound("cinema not found") // respond not found
         }
-        return c.JSON(http.StatusInternalServerError, map[string]string{"error": "db error"}) // generic database error
+        return apperr.Internal("db error") // generic database error
     }
     items, err := h.HallRepo.ListByCinemaAndOwner(c.Request().Context(), cinemaID, ownerID) // list halls in the cinema
     if err != nil { // handle errors from repository
-        return c.JSON(http.StatusInternalServerError, map[string]string{"error": "db error"}) // respond with internal error
+        return apperr.Internal("db error") // respond with internal error
     }
     return c.JSON(http.StatusOK, map[string]any{"items": items}) // return halls list wrapped in JSON
 }
\ No newline at end of file
@@ -0,0 +1,104 @@
+package handler
+
+import (
+    "database/sql"
+    "errors"
+    "net/http"
+    "strconv"
+    "strings"
+
+    "github.com/iliyamo/cinema-seat-reservation/internal/apperr"
+    "github.com/iliyamo/cinema-seat-reservation/internal/db"
+    "github.com/iliyamo/cinema-seat-reservation/internal/repository"
+    "github.com/labstack/echo/v4"
+)
+
+// cloneHallReq is the body of POST /v1/halls/:id/clone.
+type cloneHallReq struct {
+    CinemaID *uint64 `json:"cinema_id"`                // optional; another cinema of the owner (default: the source's)
+    Name     *string `json:"name" validate:"max=100"` // optional; defaults to the source name, with " (copy)" in the same cinema
+}
+
+// CloneHall handles POST /v1/halls/:id/clone.  It creates a new hall
+// with the source hall's description, seat grid or custom layout, seat
+// types, active flags and accessibility attributes, in the same cinema
+// or another one the owner owns.  Shows are not copied.  It responds 201
+// with the new hall and its seat count, 404 when the hall or cinema is
+// not the owner's, and 409 when the target cinema already has a hall
+// with identical attributes.
+func (h *OwnerHandler) CloneHall(c echo.Context) error {
+    ownerID, err := getUserID(c)
+    if err != nil {
+        return apperr.Unauthorized("unauthorized")
+    }
+    id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+    if err != nil || id == 0 {
+        return apperr.BadRequest("invalid id")
+    }
+    var body cloneHallReq
+    if err := bindValid(c, &body); err != nil {
+        return err
+    }
+    ctx := c.Request().Context()
+    src, err := h.HallRepo.GetByIDAndOwner(ctx, id, ownerID)
+    if err != nil {
+        if errors.Is(err, repository.ErrHallNotFound) {
+            return apperr.NotFound("hall not found")
+        }
+        return apperr.Internal("db error")
+    }
+    cinemaID := src.CinemaID
+    if body.CinemaID != nil {
+        if _, err := h.CinemaRepo.GetByIDAndOwner(ctx, *body.CinemaID, ownerID); err != nil {
+            if errors.Is(err, repository.ErrCinemaNotFound) {
+                return apperr.NotFound("cinema not found")
+            }
+            return apperr.Internal("failed to verify cinema")
+        }
+        cinemaID = body.CinemaID
+    }
+    sameCinema := (cinemaID == nil && src.CinemaID == nil) ||
+        (cinemaID != nil && src.CinemaID != nil && *cinemaID == *src.CinemaID)
+    name := src.Name
+    if body.Name != nil && strings.TrimSpace(*body.Name) != "" {
+        name = strings.TrimSpace(*body.Name)
+    } else if sameCinema {
+        base := []rune(src.Name)
+        if max := 100 - len(" (copy)"); len(base) > max { // keep within the 100 character name limit
+            base = base[:max]
+        }
+        name = string(base) + " (copy)"
+    }
+    hall := &repository.Hall{
+        OwnerID:     ownerID,
+        CinemaID:    cinemaID,
+        Name:        name,
+        Description: src.Description,
+    }
+    if ok, err := h.HallRepo.ExistsExact(ctx, ownerID, cinemaID, name, src.Description, src.SeatRows, src.SeatCols, nil); err != nil {
+        return apperr.Internal("db error")
+    } else if ok {
+        return apperr.Conflict(apperr.CodeAlreadyExists, "hall already exists with identical attributes")
+    }
+    var seats int
+    err = db.WithTx(ctx, h.ShowRepo.DB(), func(tx *sql.Tx) error {
+        n, err := h.HallRepo.CloneTx(ctx, tx, id, hall)
+        if err != nil {
+            return failTx("could not clone hall", err)
+        }
+        seats = n
+        return nil
+    })
+    if err != nil {
+        return txError(c, err)
+    }
+    fresh, err := h.HallRepo.GetByID(ctx, hall.ID)
+    if err != nil {
+        return apperr.Internal("failed to load hall")
+    }
+    recordAudit(c, h.Audit, auditEvent("hall.clone", "hall", fresh.ID, ownerID), echo.Map{"source_hall_id": id}, fresh)
+    return c.JSON(http.StatusCreated, echo.Map{
+        "hall":       fresh,
+        "seat_count": seats,
+    })
+}
@@ -0,0 +1,289 @@
+package handler
+
+// This file implements custom hall layouts.  A layout lists the seats of
+// every row explicitly, so halls can have aisles, gaps and rows of
+// different lengths instead of the dense seat_rows x seat_cols grid.
+// Seats created, moved or deleted later keep the stored layout in sync,
+// and no missing grid positions are backfilled for such halls.
+
+import (
+    "context"
+    "database/sql"
+    "errors"
+    "net/http"
+    "strconv"
+
+    "github.com/iliyamo/cinema-seat-reservation/internal/apperr"
+    "github.com/iliyamo/cinema-seat-reservation/internal/db"
+    "github.com/iliyamo/cinema-seat-reservation/internal/repository"
+    "github.com/labstack/echo/v4"
+)
+
+const (
+    maxLayoutRows = 100 // rows accepted in a layout
+    maxLayoutSeat = 200 // highest seat number accepted in a layout
+)
+
+// hallLayoutReq describes a layout either as a matrix or as explicit
+// rows; exactly one must be given.  In a matrix every inner slice is a
+// row, a non-zero cell is a seat numbered by its 1-based column and a
+// zero cell is a gap; rows get the labels A, B, ... and rows without
+// seats (cross aisles) are skipped without using a label.  Explicit rows
+// name their seat numbers, and their label defaults to the label of their
+// position.
+type hallLayoutReq struct {
+    Matrix [][]int            `json:"matrix"`
+    Rows   []hallLayoutRowReq `json:"rows"`
+}
+
+// hallLayoutRowReq is one explicit row of a hallLayoutReq.
+type hallLayoutRowReq struct {
+    Label string   `json:"label" validate:"max=8"`
+    Seats []uint32 `json:"seats"`
+}
+
+// layout validates the request and converts it to a normalized layout.
+func (r *hallLayoutReq) layout() (*repository.HallLayout, error) {
+    if (len(r.Matrix) == 0) == (len(r.Rows) == 0) {
+        return nil, fieldError("layout", "must give either matrix or rows")
+    }
+    l := &repository.HallLayout{}
+    if len(r.Matrix) > 0 {
+        if len(r.Matrix) > maxLayoutRows {
+            return nil, fieldError("matrix", "must have at most "+strconv.Itoa(maxLayoutRows)+" rows")
+        }
+        for _, cells := range r.Matrix {
+            if len(cells) > maxLayoutSeat {
+                return nil, fieldError("matrix", "rows must have at most "+strconv.Itoa(maxLayoutSeat)+" columns")
+            }
+            var seats []uint32
+            for col, cell := range cells {
+                if cell != 0 {
+                    seats = append(seats, uint32(col+1))
+                }
+            }
+            if len(seats) > 0 {
+                l.Rows = append(l.Rows, repository.HallLayoutRow{Label: indexToRowLabel(len(l.Rows)), Seats: seats})
+            }
+        }
+    } else {
+        if len(r.Rows) > maxLayoutRows {
+            return nil, fieldError("rows", "must have at most "+strconv.Itoa(maxLayoutRows)+" rows")
+        }
+        for i, row := range r.Rows {
+            label := indexToRowLabel(i)
+            if row.Label != "" {
+                if label = normalizeRowLabel(row.Label); label == "" {
+                    return nil, fieldError("rows", "labels must contain letters")
+                }
+            }
+            for _, n := range row.Seats {
+                if n < 1 || n > maxLayoutSeat {
+                    return nil, fieldError("rows", "seat numbers must be between 1 and "+strconv.Itoa(maxLayoutSeat))
+                }
+            }
+            l.Rows = append(l.Rows, repository.HallLayoutRow{Label: label, Seats: append([]uint32(nil), row.Seats...)})
+        }
+    }
+    if err := l.Normalize(); err != nil {
+        return nil, fieldError("layout", "must contain seats, with unique row labels and unique seats per row")
+    }
+    return l, nil
+}
+
+// GetHallLayout handles GET /v1/halls/:id/layout.  It returns the hall's
+// seats as rows of seat numbers; custom is false for halls that use the
+// dense grid, whose layout is derived from their seats.
+func (h *OwnerHandler) GetHallLayout(c echo.Context) error {
+    ownerID, err := getUserID(c)
+    if err != nil {
+        return apperr.Unauthorized("unauthorized")
+    }
+    id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+    if err != nil {
+        return apperr.BadRequest("invalid id")
+    }
+    ctx := c.Request().Context()
+    if _, err := h.HallRepo.GetByIDAndOwner(ctx, id, ownerID); err != nil {
+        if errors.Is(err, repository.ErrHallNotFound) {
+            return apperr.NotFound("hall not found")
+        }
+        return apperr.Internal("db error")
+    }
+    layout, err := h.HallRepo.GetLayout(ctx, id)
+    if err != nil {
+        return apperr.Internal("failed to load hall layout")
+    }
+    custom := layout != nil
+    if !custom {
+        seats, err := h.SeatRepo.GetByHall(ctx, id)
+        if err != nil {
+            return apperr.Internal("failed to load seats")
+        }
+        layout = repository.LayoutFromSeats(seats, nil)
+    }
+    return c.JSON(http.StatusOK, echo.Map{"hall_id": id, "custom": custom, "rows": layout.Rows})
+}
+
+// PutHallLayout handles PUT /v1/halls/:id/layout.  It replaces the seats
+// of the hall with the given layout, stores the layout and sets
+// seat_rows and seat_cols to the number of rows and the highest seat
+// number.  Show seats of the hall's shows are rebuilt as when the grid
+// changes through PUT /v1/halls/:id, which is refused with 400
+// HALL_IN_USE while holds or reservations reference the hall's seats.
+func (h *OwnerHandler) PutHallLayout(c echo.Context) error {
+    ownerID, err := getUserID(c)
+    if err != nil {
+        return apperr.Unauthorized("unauthorized")
+    }
+    id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+    if err != nil {
+        return apperr.BadRequest("invalid id")
+    }
+    var body hallLayoutReq
+    if err := bindValid(c, &body); err != nil {
+        return err
+    }
+    layout, err := body.layout()
+    if err != nil {
+        return err
+    }
+    ctx := c.Request().Context()
+    hall, err := h.HallRepo.GetByIDAndOwner(ctx, id, ownerID)
+    if err != nil {
+        if errors.Is(err, repository.ErrHallNotFound) {
+            return apperr.NotFound("hall not found")
+        }
+        return apperr.Internal("db error")
+    }
+    before, err := h.HallRepo.GetLayout(ctx, id)
+    if err != nil {
+        return apperr.Internal("failed to load hall layout")
+    }
+    if err := h.checkHallSeatsUnused(ctx, id); err != nil {
+        return err
+    }
+    err = db.WithTx(ctx, h.ShowRepo.DB(), func(tx *sql.Tx) error {
+        if err := h.HallRepo.SetLayoutTx(ctx, tx, id, layout); err != nil {
+            return failTx("failed to store hall layout", err)
+        }
+        return h.rebuildHallSeatsTx(ctx, tx, hall, layout.Seats(id))
+    })
+    if err != nil {
+        return txError(c, err)
+    }
+    recordAudit(c, h.Audit, auditEvent("hall.layout", "hall", id, hall.OwnerID), before, layout)
+    return c.JSON(http.StatusOK, echo.Map{"hall_id": id, "custom": true, "rows": layout.Rows})
+}
+
+// syncHallLayout rewrites a custom layout from the hall's current seats
+// after a single seat was created, moved or deleted.  layout is the
+// stored layout; halls without one are left alone.
+func (h *OwnerHandler) syncHallLayout(ctx context.Context, hallID uint64, layout *repository.HallLayout) error {
+    if layout == nil {
+        return nil
+    }
+    seats, err := h.SeatRepo.GetByHall(ctx, hallID)
+    if err != nil {
+        return apperr.Internal("failed to load seats")
+    }
+    if err := h.HallRepo.SetLayout(ctx, hallID, repository.LayoutFromSeats(seats, layout)); err != nil {
+        return apperr.Internal("failed to update hall layout")
+    }
+    return nil
+}
+
+// hallRenderingReq is the body of PUT /v1/halls/:id/layout/rendering.
+type hallRenderingReq struct {
+    Screen   string                `json:"screen" validate:"oneof=top bottom left right"`
+    Sections []hallSectionReq      `json:"sections" validate:"max=20"`
+    Rows     []hallRowRenderingReq `json:"rows" validate:"max=100"`
+}
+
+// hallSectionReq is one section of a hallRenderingReq.
+type hallSectionReq struct {
+    Name string   `json:"name" validate:"required,max=40"`
+    Rows []string `json:"rows" validate:"required,max=100"`
+}
+
+// hallRowRenderingReq adjusts one row of a hallRenderingReq.
+type hallRowRenderingReq struct {
+    Label   string  `json:"label" validate:"required,max=8"`
+    Offset  float64 `json:"offset" validate:"min=-50,max=50"`
+    Spacing uint8   `json:"spacing" validate:"max=10"`
+}
+
+// GetHallRendering handles GET /v1/halls/:id/layout/rendering.  It
+// returns the hall's seat map rendering metadata; halls without any have
+// the screen at the top and no sections or row adjustments.
+func (h *OwnerHandler) GetHallRendering(c echo.Context) error {
+    ownerID, err := getUserID(c)
+    if err != nil {
+        return apperr.Unauthorized("unauthorized")
+    }
+    id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+    if err != nil {
+        return apperr.BadRequest("invalid id")
+    }
+    ctx := c.Request().Context()
+    if _, err := h.HallRepo.GetByIDAndOwner(ctx, id, ownerID); err != nil {
+        if errors.Is(err, repository.ErrHallNotFound) {
+            return apperr.NotFound("hall not found")
+        }
+        return apperr.Internal("db error")
+    }
+    rendering, err := h.HallRepo.GetRendering(ctx, id)
+    if err != nil {
+        return apperr.Internal("failed to load hall rendering")
+    }
+    return c.JSON(http.StatusOK, rendering)
+}
+
+// PutHallRendering handles PUT /v1/halls/:id/layout/rendering.  It
+// replaces the hall's rendering metadata: screen (top, bottom, left or
+// right; default top), sections naming their rows, and rows with an
+// offset in seat widths and a spacing in empty rows.  Seats are not
+// touched, so it works while the hall is in use.  A row may belong to one
+// section and be adjusted once; rows that do not exist are kept but
+// ignored by the seat maps.
+func (h *OwnerHandler) PutHallRendering(c echo.Context) error {
+    ownerID, err := getUserID(c)
+    if err != nil {
+        return apperr.Unauthorized("unauthorized")
+    }
+    id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+    if err != nil {
+        return apperr.BadRequest("invalid id")
+    }
+    var body hallRenderingReq
+    if err := bindValid(c, &body); err != nil {
+        return err
+    }
+    rendering := &repository.HallRendering{Screen: body.Screen}
+    for _, s := range body.Sections {
+        rendering.Sections = append(rendering.Sections, repository.HallSection{Name: s.Name, Rows: s.Rows})
+    }
+    for _, r := range body.Rows {
+        rendering.Rows = append(rendering.Rows, repository.HallRowRendering{Label: r.Label, Offset: r.Offset, Spacing: r.Spacing})
+    }
+    if err := rendering.Normalize(); err != nil {
+        return fieldError("sections", "must have unique names, and rows may belong to one section and be adjusted once")
+    }
+    ctx := c.Request().Context()
+    hall, err := h.HallRepo.GetByIDAndOwner(ctx, id, ownerID)
+    if err != nil {
+        if errors.Is(err, repository.ErrHallNotFound) {
+            return apperr.NotFound("hall not found")
+        }
+        return apperr.Internal("db error")
+    }
+    before, err := h.HallRepo.GetRendering(ctx, id)
+    if err != nil {
+        return apperr.Internal("failed to load hall rendering")
+    }
+    if err := h.HallRepo.SetRendering(ctx, id, rendering); err != nil {
+        return apperr.Internal("failed to store hall rendering")
+    }
+    recordAudit(c, h.Audit, auditEvent("hall.rendering", "hall", id, hall.OwnerID), before, rendering)
+    return c.JSON(http.StatusOK, rendering)
+}
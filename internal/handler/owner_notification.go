@@ -0,0 +1,81 @@
+package handler
+
+import (
+    "net/http"
+    "strings"
+
+    "github.com/iliyamo/cinema-seat-reservation/internal/apperr"
+    "github.com/labstack/echo/v4"
+)
+
+// notificationSettingsReq is the body of PATCH
+// /v1/owner/notification-settings.  Omitted fields keep their values.
+type notificationSettingsReq struct {
+    DailyDigest *bool   `json:"daily_digest"`
+    DigestHour  *int    `json:"digest_hour" validate:"min=0,max=23"`
+    Timezone    *string `json:"timezone" validate:"max=64"`
+}
+
+// GetNotificationSettings handles GET /v1/owner/notification-settings and
+// returns the caller's notification preferences, or the defaults (digest
+// off, 08:00 UTC) when they never saved any.
+func (h *OwnerHandler) GetNotificationSettings(c echo.Context) error {
+    if h.NotificationSettings == nil {
+        return apperr.NotImplemented("notification settings are not enabled")
+    }
+    ownerID, err := getUserID(c)
+    if err != nil {
+        return apperr.Unauthorized("unauthorized")
+    }
+    s, err := h.NotificationSettings.Get(c.Request().Context(), ownerID)
+    if err != nil {
+        return apperr.Internal("failed to load notification settings")
+    }
+    return c.JSON(http.StatusOK, s)
+}
+
+// UpdateNotificationSettings handles PATCH
+// /v1/owner/notification-settings.  daily_digest turns the daily bookings
+// summary on or off; digest_hour (0-23) and timezone choose when it is
+// sent and which day it covers.  Returns the resulting settings.
+func (h *OwnerHandler) UpdateNotificationSettings(c echo.Context) error {
+    if h.NotificationSettings == nil {
+        return apperr.NotImplemented("notification settings are not enabled")
+    }
+    ownerID, err := getUserID(c)
+    if err != nil {
+        return apperr.Unauthorized("unauthorized")
+    }
+    var body notificationSettingsReq
+    if err := bindValid(c, &body); err != nil {
+        return err
+    }
+    ctx := c.Request().Context()
+    s, err := h.NotificationSettings.Get(ctx, ownerID)
+    if err != nil {
+        return apperr.Internal("failed to load notification settings")
+    }
+    before := *s
+    if body.DailyDigest != nil {
+        s.DailyDigest = *body.DailyDigest
+    }
+    if body.DigestHour != nil {
+        s.DigestHour = *body.DigestHour
+    }
+    if body.Timezone != nil {
+        tz := strings.TrimSpace(*body.Timezone)
+        if !validTimezone(tz) {
+            return fieldError("timezone", "must be an IANA time zone such as Europe/Berlin")
+        }
+        s.Timezone = tz
+    }
+    if err := h.NotificationSettings.Save(ctx, s); err != nil {
+        return apperr.Internal("failed to save notification settings")
+    }
+    updated, err := h.NotificationSettings.Get(ctx, ownerID)
+    if err != nil {
+        return apperr.Internal("failed to load notification settings")
+    }
+    recordAudit(c, h.Audit, auditEvent("owner.notification_settings", "user", ownerID, ownerID), before, updated)
+    return c.JSON(http.StatusOK, updated)
+}
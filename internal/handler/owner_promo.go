@@ -0,0 +1,109 @@
+package handler
+
+// This file defines HTTP handlers for owners to manage promo codes.  Codes
+// created here can be redeemed by customers when confirming held seats for
+// shows in the owner's halls (see CustomerHandler.ConfirmSeats).
+
+import (
+    "net/http"
+    "strings"
+    "time"
+
+    "github.com/iliyamo/cinema-seat-reservation/internal/apperr"
+    "github.com/iliyamo/cinema-seat-reservation/internal/repository"
+    "github.com/labstack/echo/v4"
+)
+
+// OwnerPromoHandler exposes promo code management to owners.
+type OwnerPromoHandler struct {
+    PromoCodeRepo *repository.PromoCodeRepo // access to promo_codes
+    Audit         *repository.AuditRepo     // optional; records new codes in the audit log
+}
+
+// NewOwnerPromoHandler constructs an OwnerPromoHandler.  The repository
+// must be non-nil.
+func NewOwnerPromoHandler(promoRepo *repository.PromoCodeRepo) *OwnerPromoHandler {
+    if promoRepo == nil {
+        panic("nil repository passed to NewOwnerPromoHandler")
+    }
+    return &OwnerPromoHandler{PromoCodeRepo: promoRepo}
+}
+
+// CreatePromoCode handles POST /v1/owner/promo-codes.  The body must
+// contain a code, a discount_type (PERCENT or FIXED) and a positive
+// discount_value.  valid_from/valid_until (RFC3339) and max_uses are
+// optional.  Codes are stored upper-cased and must be globally unique.
+func (h *OwnerPromoHandler) CreatePromoCode(c echo.Context) error {
+    ownerID, err := getUserID(c)
+    if err != nil {
+        return apperr.Unauthorized("unauthorized")
+    }
+    var body struct {
+        Code          string  `json:"code" validate:"required,max=64"`
+        DiscountType  string  `json:"discount_type" validate:"required,oneof=PERCENT FIXED"`
+        DiscountValue uint32  `json:"discount_value" validate:"required"`
+        ValidFrom     string  `json:"valid_from" validate:"rfc3339"`
+        ValidUntil    string  `json:"valid_until" validate:"rfc3339"`
+        MaxUses       *uint32 `json:"max_uses" validate:"min=1"`
+    }
+    if err := bindValid(c, &body); err != nil {
+        return err
+    }
+    code := strings.ToUpper(strings.TrimSpace(body.Code))
+    dtype := strings.ToUpper(strings.TrimSpace(body.DiscountType))
+    if dtype == "PERCENT" && body.DiscountValue > 100 {
+        return fieldError("discount_value", "must be at most 100 for PERCENT discounts")
+    }
+    promo := &repository.PromoCode{
+        OwnerID:       ownerID,
+        Code:          code,
+        DiscountType:  dtype,
+        DiscountValue: body.DiscountValue,
+        MaxUses:       body.MaxUses,
+        IsActive:      true,
+    }
+    if s := strings.TrimSpace(body.ValidFrom); s != "" {
+        t, err := time.Parse(time.RFC3339, s)
+        if err != nil {
+            return apperr.BadRequest("valid_from must be RFC3339")
+        }
+        t = t.UTC()
+        promo.ValidFrom = &t
+    }
+    if s := strings.TrimSpace(body.ValidUntil); s != "" {
+        t, err := time.Parse(time.RFC3339, s)
+        if err != nil {
+            return apperr.BadRequest("valid_until must be RFC3339")
+        }
+        t = t.UTC()
+        promo.ValidUntil = &t
+    }
+    if promo.ValidFrom != nil && promo.ValidUntil != nil && !promo.ValidUntil.After(*promo.ValidFrom) {
+        return fieldError("valid_until", "must be after valid_from")
+    }
+    if err := h.PromoCodeRepo.Create(c.Request().Context(), promo); err != nil {
+        if strings.Contains(err.Error(), "1062") {
+            return apperr.Conflict(apperr.CodeAlreadyExists, "promo code already exists")
+        }
+        return apperr.Internal("failed to create promo code")
+    }
+    recordAudit(c, h.Audit, auditEvent("promo.create", "promo_code", promo.ID, ownerID), nil, promo)
+    return c.JSON(http.StatusCreated, promo)
+}
+
+// ListPromoCodes handles GET /v1/owner/promo-codes and returns all codes
+// created by the authenticated owner along with their usage counts.
+func (h *OwnerPromoHandler) ListPromoCodes(c echo.Context) error {
+    ownerID, err := getUserID(c)
+    if err != nil {
+        return apperr.Unauthorized("unauthorized")
+    }
+    items, err := h.PromoCodeRepo.ListByOwner(c.Request().Context(), ownerID)
+    if err != nil {
+        return apperr.Internal("failed to load promo codes")
+    }
+    return c.JSON(http.StatusOK, echo.Map{
+        "items": items,
+        "count": len(items),
+    })
+}
@@ -0,0 +1,218 @@
+package handler
+
+// This file implements refund management for owners.  Owners decide on
+// the refunds customers request for reservations on their halls.
+// Approving a refund releases the reservation's seats; the money is then
+// returned through the payment gateway for reservations paid at checkout,
+// while other refunds are settled by the owner and marked processed here.
+
+import (
+    "database/sql"
+    "errors"
+    "log"
+    "net/http"
+    "strconv"
+    "strings"
+
+    "github.com/iliyamo/cinema-seat-reservation/internal/apperr"
+    "github.com/iliyamo/cinema-seat-reservation/internal/db"
+    "github.com/iliyamo/cinema-seat-reservation/internal/metrics"
+    "github.com/iliyamo/cinema-seat-reservation/internal/repository"
+    "github.com/iliyamo/cinema-seat-reservation/internal/service"
+    "github.com/labstack/echo/v4"
+)
+
+// refundDecisionReq is the body of POST /v1/owner/refunds/:id/approve and
+// /deny.  Note is shown to the customer with the refund.
+type refundDecisionReq struct {
+    Note string `json:"note" validate:"max=500"`
+}
+
+// refundProcessReq is the body of POST /v1/owner/refunds/:id/process.
+// PaymentRef optionally records the reference of the returned payment.
+type refundProcessReq struct {
+    PaymentRef string `json:"payment_ref" validate:"max=128"`
+}
+
+// ListRefunds handles GET /v1/owner/refunds.  It lists the refunds of
+// reservations on halls the caller manages, newest first; the optional
+// status query parameter filters by state.
+func (h *OwnerReservationHandler) ListRefunds(c echo.Context) error {
+    ownerID, err := getUserID(c)
+    if err != nil {
+        return apperr.Unauthorized("unauthorized")
+    }
+    if h.RefundRepo == nil {
+        return apperr.NotImplemented("refunds are not available")
+    }
+    status := strings.ToUpper(strings.TrimSpace(c.QueryParam("status")))
+    switch status {
+    case "", repository.RefundRequested, repository.RefundApproved, repository.RefundProcessed, repository.RefundRejected:
+    default:
+        return fieldError("status", "must be one of REQUESTED, APPROVED, PROCESSED, REJECTED")
+    }
+    refunds, err := h.RefundRepo.ListForOwner(c.Request().Context(), ownerID, status)
+    if err != nil {
+        return apperr.Internal("failed to load refunds")
+    }
+    return c.JSON(http.StatusOK, echo.Map{"items": refunds})
+}
+
+// ApproveRefund handles POST /v1/owner/refunds/:id/approve.  A REQUESTED
+// refund becomes APPROVED, its reservation REFUND_PENDING and the seats
+// FREE.  When the reservation was paid at checkout and a payment gateway
+// is configured, the charge is refunded right away and the refund
+// responds PROCESSED; a gateway failure leaves it APPROVED for the
+// background retry.  Refunds in other states respond 409 CONFLICT.
+func (h *OwnerReservationHandler) ApproveRefund(c echo.Context) error {
+    return h.decideRefund(c, true)
+}
+
+// DenyRefund handles POST /v1/owner/refunds/:id/deny.  A REQUESTED refund
+// becomes REJECTED and the reservation stays CONFIRMED.  Refunds in other
+// states respond 409 CONFLICT.
+func (h *OwnerReservationHandler) DenyRefund(c echo.Context) error {
+    return h.decideRefund(c, false)
+}
+
+// decideRefund implements ApproveRefund and DenyRefund.
+func (h *OwnerReservationHandler) decideRefund(c echo.Context, approve bool) error {
+    var body refundDecisionReq
+    ownerID, refund, err := h.loadRefund(c, &body)
+    if err != nil {
+        return err
+    }
+    if refund.Status != repository.RefundRequested {
+        return apperr.Conflict(apperr.CodeConflict, "refund is not awaiting a decision")
+    }
+    var note *string
+    if n := strings.TrimSpace(body.Note); n != "" {
+        note = &n
+    }
+    ctx := c.Request().Context()
+    to, action := repository.RefundRejected, "refund.deny"
+    if approve {
+        to, action = repository.RefundApproved, "refund.approve"
+    }
+    var showID uint64
+    err = db.WithTx(ctx, h.ShowRepo.DB(), func(tx *sql.Tx) error {
+        if err := h.RefundRepo.TransitionTx(ctx, tx, refund.ID, repository.RefundRequested, to, &ownerID, note, nil); err != nil {
+            if errors.Is(err, repository.ErrRefundStateChanged) {
+                return apperr.Conflict(apperr.CodeConflict, "refund is not awaiting a decision")
+            }
+            return failTx("failed to update refund", err)
+        }
+        if !approve {
+            return nil
+        }
+        var seatIDs []uint64
+        var err error
+        showID, seatIDs, err = h.ReservationRepo.ReleaseForRefundTx(ctx, tx, refund.ReservationID)
+        if err != nil {
+            if errors.Is(err, repository.ErrReservationCancelled) {
+                return apperr.Conflict(apperr.CodeConflict, "reservation is no longer confirmed")
+            }
+            return failTx("failed to release reservation", err)
+        }
+        if err := h.ShowSeatRepo.BulkUpdateStatusTx(ctx, tx, showID, seatIDs, "FREE"); err != nil {
+            return failTx("failed to update seat status", err)
+        }
+        return nil
+    })
+    if err != nil {
+        return txError(c, err)
+    }
+    if approve {
+        metrics.ReservationsCancelled.Inc("owner")
+        if h.Waitlist != nil {
+            h.Waitlist.Notify(showID)
+        }
+        if h.Refunds != nil {
+            approved := *refund
+            approved.Status = repository.RefundApproved
+            if err := h.Refunds.Process(ctx, &approved); err != nil && !errors.Is(err, service.ErrRefundNotCharged) {
+                log.Printf("refund %d left APPROVED: %v", refund.ID, err)
+            }
+        }
+    }
+    return h.respondRefund(c, action, refund)
+}
+
+// ProcessRefund handles POST /v1/owner/refunds/:id/process.  It records
+// that the owner returned the money of an APPROVED refund outside the
+// payment gateway, optionally with the payment reference; the refund
+// becomes PROCESSED and its reservation CANCELLED.  Refunds in other
+// states respond 409 CONFLICT.
+func (h *OwnerReservationHandler) ProcessRefund(c echo.Context) error {
+    var body refundProcessReq
+    ownerID, refund, err := h.loadRefund(c, &body)
+    if err != nil {
+        return err
+    }
+    if refund.Status != repository.RefundApproved {
+        return apperr.Conflict(apperr.CodeConflict, "only approved refunds can be processed")
+    }
+    var ref *string
+    if r := strings.TrimSpace(body.PaymentRef); r != "" {
+        ref = &r
+    }
+    ctx := c.Request().Context()
+    err = db.WithTx(ctx, h.ShowRepo.DB(), func(tx *sql.Tx) error {
+        if err := h.RefundRepo.TransitionTx(ctx, tx, refund.ID, repository.RefundApproved, repository.RefundProcessed, &ownerID, nil, ref); err != nil {
+            if errors.Is(err, repository.ErrRefundStateChanged) {
+                return apperr.Conflict(apperr.CodeConflict, "only approved refunds can be processed")
+            }
+            return failTx("failed to update refund", err)
+        }
+        if err := h.ReservationRepo.FinishRefundTx(ctx, tx, refund.ReservationID); err != nil {
+            return failTx("failed to update reservation", err)
+        }
+        return nil
+    })
+    if err != nil {
+        return txError(c, err)
+    }
+    return h.respondRefund(c, "refund.process", refund)
+}
+
+// loadRefund parses the refund ID and body of a refund action and loads
+// the refund, checking that the caller manages its hall.
+func (h *OwnerReservationHandler) loadRefund(c echo.Context, body interface{}) (uint64, *repository.Refund, error) {
+    ownerID, err := getUserID(c)
+    if err != nil {
+        return 0, nil, apperr.Unauthorized("unauthorized")
+    }
+    id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+    if err != nil || id == 0 {
+        return 0, nil, apperr.BadRequest("invalid refund id")
+    }
+    if h.RefundRepo == nil {
+        return 0, nil, apperr.NotImplemented("refunds are not available")
+    }
+    if err := bindValid(c, body); err != nil {
+        return 0, nil, err
+    }
+    refund, err := h.RefundRepo.GetForOwner(c.Request().Context(), id, ownerID)
+    if err != nil {
+        if errors.Is(err, repository.ErrRefundNotFound) {
+            return 0, nil, apperr.NotFound("refund not found")
+        }
+        if errors.Is(err, repository.ErrForbidden) {
+            return 0, nil, apperr.Forbidden("forbidden")
+        }
+        return 0, nil, apperr.Internal("failed to load refund")
+    }
+    return ownerID, refund, nil
+}
+
+// respondRefund reloads a refund after an action, records the action in
+// the audit log and responds 200 with the refund.
+func (h *OwnerReservationHandler) respondRefund(c echo.Context, action string, before *repository.Refund) error {
+    ownerID, _ := getUserID(c)
+    after, err := h.RefundRepo.GetForOwner(c.Request().Context(), before.ID, ownerID)
+    if err != nil {
+        return apperr.Internal("failed to load refund")
+    }
+    recordAudit(c, h.Audit, auditEvent(action, "refund", before.ID, before.OwnerID), before, after)
+    return c.JSON(http.StatusOK, after)
+}
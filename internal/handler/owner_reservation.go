@@ -14,7 +14,11 @@ import (
     "strconv"
     "time"
 
+    "github.com/iliyamo/cinema-seat-reservation/internal/apperr"
+    "github.com/iliyamo/cinema-seat-reservation/internal/db"
+    "github.com/iliyamo/cinema-seat-reservation/internal/metrics"
     "github.com/iliyamo/cinema-seat-reservation/internal/repository"
+    "github.com/iliyamo/cinema-seat-reservation/internal/service"
     "github.com/labstack/echo/v4"
 )
 
@@ -27,6 +31,10 @@ type OwnerReservationHandler struct {
     ShowRepo        *repository.ShowRepo        // access to shows for transaction and existence checks
     HallRepo        *repository.HallRepo        // access to halls (unused directly but kept for symmetry)
     ShowSeatRepo    *repository.ShowSeatRepo    // access to show_seats for freeing seats on cancellation
+    Audit           *repository.AuditRepo       // optional; records cancellations in the audit log
+    Waitlist        *service.WaitlistService    // optional; offers cancelled seats to the show's waitlist
+    RefundRepo      *repository.RefundRepo      // optional; enables refund management
+    Refunds         *service.RefundService      // optional; refunds approved checkouts through the payment gateway
 }
 
 // NewOwnerReservationHandler constructs an OwnerReservationHandler with
@@ -52,11 +60,11 @@ func NewOwnerReservationHandler(resRepo *repository.ReservationRepo, showRepo *r
 func (h *OwnerReservationHandler) ListShowReservations(c echo.Context) error {
     ownerID, err := getUserID(c)
     if err != nil {
-        return c.JSON(http.StatusUnauthorized, echo.Map{"error": "unauthorized"})
+        return apperr.Unauthorized("unauthorized")
     }
     showID, err := strconv.ParseUint(c.Param("id"), 10, 64)
     if err != nil || showID == 0 {
-        return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid show id"})
+        return apperr.BadRequest("invalid show id")
     }
     ctx := c.Request().Context()
     details, err := h.ReservationRepo.ListByShowForOwner(ctx, showID, ownerID)
@@ -65,12 +73,12 @@ func (h *OwnerReservationHandler) ListShowReservations(c echo.Context) error {
         // Surface that as a 404 to the client.  A forbidden error indicates that
         // the show exists but belongs to a different owner.
         if errors.Is(err, sql.ErrNoRows) {
-            return c.JSON(http.StatusNotFound, echo.Map{"error": "show not found"})
+            return apperr.NotFound("show not found")
         }
         if errors.Is(err, repository.ErrForbidden) {
-            return c.JSON(http.StatusForbidden, echo.Map{"error": "forbidden"})
+            return apperr.Forbidden("forbidden")
         }
-        return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to load reservations"})
+        return apperr.Internal("failed to load reservations")
     }
     // Always return a count and items.  When no reservations exist, details will
     // be an empty slice and count will be zero.
@@ -89,22 +97,22 @@ func (h *OwnerReservationHandler) ListShowReservations(c echo.Context) error {
 func (h *OwnerReservationHandler) GetOwnerReservation(c echo.Context) error {
     ownerID, err := getUserID(c)
     if err != nil {
-        return c.JSON(http.StatusUnauthorized, echo.Map{"error": "unauthorized"})
+        return apperr.Unauthorized("unauthorized")
     }
     resID, err := strconv.ParseUint(c.Param("id"), 10, 64)
     if err != nil || resID == 0 {
-        return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid reservation id"})
+        return apperr.BadRequest("invalid reservation id")
     }
     ctx := c.Request().Context()
     detail, err := h.ReservationRepo.GetByIDForOwner(ctx, resID, ownerID)
     if err != nil {
         if errors.Is(err, sql.ErrNoRows) {
-            return c.JSON(http.StatusNotFound, echo.Map{"error": "reservation not found"})
+            return apperr.NotFound("reservation not found")
         }
         if errors.Is(err, repository.ErrForbidden) {
-            return c.JSON(http.StatusForbidden, echo.Map{"error": "forbidden"})
+            return apperr.Forbidden("forbidden")
         }
-        return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to fetch reservation"})
+        return apperr.Internal("failed to fetch reservation")
     }
     return c.JSON(http.StatusOK, echo.Map{
         "item": detail,
@@ -116,56 +124,70 @@ func (h *OwnerReservationHandler) GetOwnerReservation(c echo.Context) error {
 // show belongs to the owner and has not started yet.  It returns
 // HTTP 204 on success.  When the reservation does not exist it
 // responds with 404.  When ownership is violated it responds with
-// 403.  When the show has already started it responds with 409.
+// 403.  When the show has already started, the reservation was
+// already cancelled or it was paid for (RESERVATION_PAID; paid
+// reservations are cancelled by refunding them) it responds with 409.
 // Operations are performed within a single transaction to ensure
 // atomicity.
 func (h *OwnerReservationHandler) DeleteOwnerReservation(c echo.Context) error {
     ownerID, err := getUserID(c)
     if err != nil {
-        return c.JSON(http.StatusUnauthorized, echo.Map{"error": "unauthorized"})
+        return apperr.Unauthorized("unauthorized")
     }
     resID, err := strconv.ParseUint(c.Param("id"), 10, 64)
     if err != nil || resID == 0 {
-        return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid reservation id"})
+        return apperr.BadRequest("invalid reservation id")
     }
     ctx := c.Request().Context()
-    tx, err := h.ShowRepo.DB().BeginTx(ctx, nil)
-    if err != nil {
-        return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to start transaction"})
-    }
-    committed := false
-    defer func() {
-        if !committed {
-            _ = tx.Rollback()
+    var before *repository.OwnerReservationDetail
+    if h.Audit != nil {
+        before, _ = h.ReservationRepo.GetByIDForOwner(ctx, resID, ownerID)
+    }
+    var showID uint64
+    err = db.WithTx(ctx, h.ShowRepo.DB(), func(tx *sql.Tx) error {
+        var startTime time.Time
+        var seatIDs []uint64
+        var err error
+        showID, startTime, seatIDs, err = h.ReservationRepo.GetInfoForOwnerTx(ctx, tx, resID, ownerID)
+        if err != nil {
+            if errors.Is(err, sql.ErrNoRows) {
+                return apperr.NotFound("reservation not found")
+            }
+            if errors.Is(err, repository.ErrForbidden) {
+                return apperr.Forbidden("forbidden")
+            }
+            if errors.Is(err, repository.ErrReservationCancelled) {
+                return apperr.Conflict(apperr.CodeConflict, "reservation is already cancelled")
+            }
+            return failTx("failed to load reservation info", err)
         }
-    }()
-    showID, startTime, seatIDs, err := h.ReservationRepo.GetInfoForOwnerTx(ctx, tx, resID, ownerID)
-    if err != nil {
-        if errors.Is(err, sql.ErrNoRows) {
-            return c.JSON(http.StatusNotFound, echo.Map{"error": "reservation not found"})
+        if !startTime.After(time.Now().UTC()) {
+            return apperr.Conflict(apperr.CodeShowStarted, "show already started")
         }
-        if errors.Is(err, repository.ErrForbidden) {
-            return c.JSON(http.StatusForbidden, echo.Map{"error": "forbidden"})
+        // Cancel the reservation; paid ones go through a refund
+        if err := h.ReservationRepo.CancelTx(ctx, tx, resID); err != nil {
+            if errors.Is(err, repository.ErrReservationPaid) {
+                return apperr.Conflict(apperr.CodeReservationPaid, "paid reservations are cancelled by refunding them")
+            }
+            return failTx("failed to cancel reservation", err)
         }
-        return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to load reservation info"})
-    }
-    if !startTime.After(time.Now().UTC()) {
-        return c.JSON(http.StatusConflict, echo.Map{"error": "show already started"})
-    }
-    // Delete reservation (cascade deletes its reservation_seats)
-    const del = `DELETE FROM reservations WHERE id = ?`
-    if _, err := tx.ExecContext(ctx, del, resID); err != nil {
-        return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to delete reservation"})
-    }
-    // Free seats
-    if len(seatIDs) > 0 {
-        if err := h.ShowSeatRepo.BulkUpdateStatusTx(ctx, tx, showID, seatIDs, "FREE"); err != nil {
-            return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to update seat status"})
+        // Free seats
+        if len(seatIDs) > 0 {
+            if err := h.ShowSeatRepo.BulkUpdateStatusTx(ctx, tx, showID, seatIDs, "FREE"); err != nil {
+                return failTx("failed to update seat status", err)
+            }
         }
+        return nil
+    })
+    if err != nil {
+        return txError(c, err)
+    }
+    metrics.ReservationsCancelled.Inc("owner")
+    if h.Waitlist != nil {
+        h.Waitlist.Notify(showID)
     }
-    if err := tx.Commit(); err != nil {
-        return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to commit transaction"})
+    if before != nil {
+        recordAudit(c, h.Audit, auditEvent("reservation.cancel", "reservation", resID, hallOwnerID(ctx, h.HallRepo, before.HallID)), before, nil)
     }
-    committed = true
     return c.NoContent(http.StatusNoContent)
 }
\ No newline at end of file
@@ -0,0 +1,116 @@
+package handler
+
+// This file implements check-in at the door: owners and staff mark the
+// seats of a reservation as CHECKED_IN, and see how many seats of a show
+// were checked in or missed.
+
+import (
+    "database/sql"
+    "errors"
+    "net/http"
+    "strconv"
+    "time"
+
+    "github.com/iliyamo/cinema-seat-reservation/internal/apperr"
+    "github.com/iliyamo/cinema-seat-reservation/internal/db"
+    "github.com/iliyamo/cinema-seat-reservation/internal/metrics"
+    "github.com/iliyamo/cinema-seat-reservation/internal/repository"
+    "github.com/labstack/echo/v4"
+)
+
+// checkInReq is the optional body of POST
+// /v1/owner/reservations/:id/check-in.  Without seat_ids every seat of
+// the reservation is checked in.
+type checkInReq struct {
+    SeatIDs []uint64 `json:"seat_ids" validate:"max=100"`
+}
+
+// CheckInReservation handles POST /v1/owner/reservations/:id/check-in.
+// It marks the given seats of a CONFIRMED reservation on a show the
+// caller manages, or all of them, as CHECKED_IN and responds with the
+// reservation and the number of seats checked in.  Check-in is possible
+// until the show ends; seats not checked in by then become NO_SHOW.  It
+// responds 404 for unknown reservations, 403 for foreign ones, 409 when
+// the reservation is not confirmed, the show has ended or every seat is
+// already checked in, and 422 for seats outside the reservation.
+func (h *OwnerReservationHandler) CheckInReservation(c echo.Context) error {
+    ownerID, err := getUserID(c)
+    if err != nil {
+        return apperr.Unauthorized("unauthorized")
+    }
+    resID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+    if err != nil || resID == 0 {
+        return apperr.BadRequest("invalid reservation id")
+    }
+    var body checkInReq
+    if err := bindValid(c, &body); err != nil {
+        return err
+    }
+    ctx := c.Request().Context()
+    var before *repository.OwnerReservationDetail
+    if h.Audit != nil {
+        before, _ = h.ReservationRepo.GetByIDForOwner(ctx, resID, ownerID)
+    }
+    var n int
+    err = db.WithTx(ctx, h.ShowRepo.DB(), func(tx *sql.Tx) error {
+        var err error
+        n, err = h.ReservationRepo.CheckInTx(ctx, tx, resID, ownerID, body.SeatIDs, time.Now().UTC())
+        switch {
+        case errors.Is(err, sql.ErrNoRows):
+            return apperr.NotFound("reservation not found")
+        case errors.Is(err, repository.ErrForbidden):
+            return apperr.Forbidden("forbidden")
+        case errors.Is(err, repository.ErrReservationNotConfirmed):
+            return apperr.Conflict(apperr.CodeConflict, "only confirmed reservations can be checked in")
+        case errors.Is(err, repository.ErrCheckInClosed):
+            return apperr.Conflict(apperr.CodeConflict, "the show has ended")
+        case errors.Is(err, repository.ErrAlreadyCheckedIn):
+            return apperr.Conflict(apperr.CodeConflict, "seats are already checked in")
+        case errors.Is(err, repository.ErrSeatNotInReservation):
+            return fieldError("seat_ids", "must be seats of the reservation")
+        case err != nil:
+            return failTx("failed to check in", err)
+        }
+        return nil
+    })
+    if err != nil {
+        return txError(c, err)
+    }
+    metrics.SeatAttendance.Add(float64(n), "checked_in")
+    detail, err := h.ReservationRepo.GetByIDForOwner(ctx, resID, ownerID)
+    if err != nil {
+        return apperr.Internal("failed to fetch reservation")
+    }
+    if before != nil {
+        recordAudit(c, h.Audit, auditEvent("reservation.check_in", "reservation", resID, hallOwnerID(ctx, h.HallRepo, before.HallID)), before, detail)
+    }
+    return c.JSON(http.StatusOK, echo.Map{
+        "item":       detail,
+        "checked_in": n,
+    })
+}
+
+// GetShowAttendance handles GET /v1/owner/shows/:id/attendance.  It
+// counts the seats of the show's confirmed reservations that are still
+// BOOKED, CHECKED_IN or NO_SHOW, with the check-in and no-show rates.
+func (h *OwnerReservationHandler) GetShowAttendance(c echo.Context) error {
+    ownerID, err := getUserID(c)
+    if err != nil {
+        return apperr.Unauthorized("unauthorized")
+    }
+    showID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+    if err != nil || showID == 0 {
+        return apperr.BadRequest("invalid show id")
+    }
+    att, err := h.ReservationRepo.AttendanceByShow(c.Request().Context(), showID, ownerID)
+    if err != nil {
+        if errors.Is(err, sql.ErrNoRows) {
+            return apperr.NotFound("show not found")
+        }
+        if errors.Is(err, repository.ErrForbidden) {
+            return apperr.Forbidden("forbidden")
+        }
+        return apperr.Internal("failed to load attendance")
+    }
+    return c.JSON(http.StatusOK, att)
+}
@@ -0,0 +1,114 @@
+package handler
+
+// This file implements the reservation export for owners.  The CSV is
+// written while the reservations are read from the database, so the
+// size of a show does not affect memory use.
+
+import (
+    "database/sql"
+    "encoding/csv"
+    "errors"
+    "net/http"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/iliyamo/cinema-seat-reservation/internal/apperr"
+    "github.com/iliyamo/cinema-seat-reservation/internal/money"
+    "github.com/iliyamo/cinema-seat-reservation/internal/repository"
+    "github.com/labstack/echo/v4"
+)
+
+// exportFlushRows is how many CSV rows are buffered before they are
+// flushed to the client.
+const exportFlushRows = 200
+
+// reservationExportHeader names the columns of a reservation export.
+var reservationExportHeader = []string{
+    "reservation_id", "customer_email", "seats", "seat_count",
+    "subtotal_cents", "discount_cents", "fees_cents", "tax_cents",
+    "total_amount_cents", "total_amount", "currency", "status", "payment_ref", "created_at", "updated_at",
+}
+
+// ExportShowReservations handles GET /v1/shows/:id/reservations/export.
+// It streams every reservation of a show the caller manages as a CSV
+// attachment (format=csv, the default and only format), oldest first,
+// with times in RFC3339 UTC.  Ownership is checked before anything is
+// written, so missing or foreign shows still respond 404 or 403; an
+// error after streaming has begun truncates the file.
+func (h *OwnerReservationHandler) ExportShowReservations(c echo.Context) error {
+    ownerID, err := getUserID(c)
+    if err != nil {
+        return apperr.Unauthorized("unauthorized")
+    }
+    showID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+    if err != nil || showID == 0 {
+        return apperr.BadRequest("invalid show id")
+    }
+    if format := strings.ToLower(c.QueryParam("format")); format != "" && format != "csv" {
+        return fieldError("format", "must be csv")
+    }
+    resp := c.Response()
+    w := csv.NewWriter(resp)
+    started := false
+    start := func() error {
+        started = true
+        resp.Header().Set(echo.HeaderContentType, "text/csv; charset=utf-8")
+        resp.Header().Set(echo.HeaderContentDisposition,
+            `attachment; filename="show-`+strconv.FormatUint(showID, 10)+`-reservations.csv"`)
+        resp.WriteHeader(http.StatusOK)
+        return w.Write(reservationExportHeader)
+    }
+    n := 0
+    err = h.ReservationRepo.ExportByShowForOwner(c.Request().Context(), showID, ownerID, func(r *repository.ReservationExportRow) error {
+        if !started {
+            if err := start(); err != nil {
+                return err
+            }
+        }
+        if err := w.Write([]string{
+            strconv.FormatUint(r.ID, 10),
+            r.CustomerEmail,
+            r.Seats,
+            strconv.Itoa(r.SeatCount),
+            strconv.FormatUint(uint64(r.SubtotalCents), 10),
+            strconv.FormatUint(uint64(r.DiscountCents), 10),
+            strconv.FormatUint(uint64(r.FeesCents), 10),
+            strconv.FormatUint(uint64(r.TaxCents), 10),
+            strconv.FormatUint(uint64(r.TotalAmountCents), 10),
+            money.Decimal(int64(r.TotalAmountCents), r.Currency),
+            r.Currency,
+            r.Status,
+            r.PaymentRef,
+            r.CreatedAt.UTC().Format(time.RFC3339),
+            r.UpdatedAt.UTC().Format(time.RFC3339),
+        }); err != nil {
+            return err
+        }
+        if n++; n%exportFlushRows == 0 {
+            w.Flush()
+            resp.Flush()
+        }
+        return w.Error()
+    })
+    if err != nil {
+        if started {
+            // Headers are already sent; all that can be done is to stop.
+            return err
+        }
+        if errors.Is(err, sql.ErrNoRows) {
+            return apperr.NotFound("show not found")
+        }
+        if errors.Is(err, repository.ErrForbidden) {
+            return apperr.Forbidden("forbidden")
+        }
+        return apperr.Internal("failed to export reservations")
+    }
+    if !started {
+        if err := start(); err != nil {
+            return err
+        }
+    }
+    w.Flush()
+    return w.Error()
+}
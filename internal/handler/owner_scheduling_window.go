@@ -0,0 +1,101 @@
+package handler
+
+import (
+    "errors"
+    "net/http"
+    "strconv"
+
+    "github.com/iliyamo/cinema-seat-reservation/internal/apperr"
+    "github.com/iliyamo/cinema-seat-reservation/internal/repository"
+    "github.com/labstack/echo/v4"
+)
+
+// schedulingWindowReq sets how far ahead a cinema's shows may be
+// scheduled: at least min_lead_minutes from now (at most 30 days) and at
+// most max_horizon_days ahead (1 to 3650).  Null or absent fields fall
+// back to the server's defaults.
+type schedulingWindowReq struct {
+    MinLeadMinutes *uint32 `json:"min_lead_minutes" validate:"max=43200"`
+    MaxHorizonDays *uint32 `json:"max_horizon_days" validate:"min=1,max=3650"`
+}
+
+// schedulingWindowResp is the response of GET and PUT
+// /v1/cinemas/:id/scheduling-window: the cinema's own window and the one
+// that applies.
+type schedulingWindowResp struct {
+    CinemaID uint64 `json:"cinema_id"`
+    repository.SchedulingWindow
+    EffectiveMinLeadMinutes uint32 `json:"effective_min_lead_minutes"`
+    EffectiveMaxHorizonDays uint32 `json:"effective_max_horizon_days"` // 0 means no limit
+}
+
+func (h *OwnerHandler) schedulingWindowResp(id uint64, w *repository.SchedulingWindow) schedulingWindowResp {
+    minLead, maxHorizon := h.Shows.Window(w)
+    return schedulingWindowResp{
+        CinemaID:                id,
+        SchedulingWindow:        *w,
+        EffectiveMinLeadMinutes: uint32(minLead.Minutes()),
+        EffectiveMaxHorizonDays: uint32(maxHorizon.Hours() / 24),
+    }
+}
+
+// GetCinemaSchedulingWindow handles GET
+// /v1/cinemas/:id/scheduling-window and returns how far ahead shows of
+// one of the caller's cinemas may be scheduled.
+func (h *OwnerHandler) GetCinemaSchedulingWindow(c echo.Context) error {
+    ownerID, err := getUserID(c)
+    if err != nil {
+        return apperr.Unauthorized("unauthorized")
+    }
+    id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+    if err != nil || id == 0 {
+        return apperr.BadRequest("invalid id")
+    }
+    w, err := h.CinemaRepo.SchedulingWindow(c.Request().Context(), id, ownerID)
+    if err != nil {
+        if errors.Is(err, repository.ErrCinemaNotFound) {
+            return apperr.NotFound("cinema not found")
+        }
+        return apperr.Internal("db error")
+    }
+    return c.JSON(http.StatusOK, h.schedulingWindowResp(id, w))
+}
+
+// UpdateCinemaSchedulingWindow handles PUT
+// /v1/cinemas/:id/scheduling-window and overrides the server's minimum
+// lead time and scheduling horizon for the cinema's new shows.  Shows
+// already scheduled are kept.
+func (h *OwnerHandler) UpdateCinemaSchedulingWindow(c echo.Context) error {
+    ownerID, err := getUserID(c)
+    if err != nil {
+        return apperr.Unauthorized("unauthorized")
+    }
+    id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+    if err != nil || id == 0 {
+        return apperr.BadRequest("invalid id")
+    }
+    var body schedulingWindowReq
+    if err := bindValid(c, &body); err != nil {
+        return err
+    }
+    after := &repository.SchedulingWindow{MinLeadMinutes: body.MinLeadMinutes, MaxHorizonDays: body.MaxHorizonDays}
+    if after.MinLeadMinutes != nil && after.MaxHorizonDays != nil && *after.MinLeadMinutes >= *after.MaxHorizonDays*24*60 {
+        return fieldError("min_lead_minutes", "must be shorter than max_horizon_days")
+    }
+    ctx := c.Request().Context()
+    before, err := h.CinemaRepo.SchedulingWindow(ctx, id, ownerID)
+    if err != nil {
+        if errors.Is(err, repository.ErrCinemaNotFound) {
+            return apperr.NotFound("cinema not found")
+        }
+        return apperr.Internal("db error")
+    }
+    if err := h.CinemaRepo.SetSchedulingWindow(ctx, id, ownerID, after); err != nil {
+        if errors.Is(err, repository.ErrCinemaNotFound) {
+            return apperr.NotFound("cinema not found")
+        }
+        return apperr.Internal("update failed")
+    }
+    recordAudit(c, h.Audit, auditEvent("cinema.scheduling_window", "cinema", id, ownerID), before, after)
+    return c.JSON(http.StatusOK, h.schedulingWindowResp(id, after))
+}
@@ -6,31 +6,35 @@ import (
     "strconv"                                                // strconv parses identifiers from path params
     "strings"                                                // strings manipulates text and case
 
+    "github.com/iliyamo/cinema-seat-reservation/internal/apperr" // apperr builds error responses
     "github.com/iliyamo/cinema-seat-reservation/internal/repository" // repository defines data models
+    "github.com/iliyamo/cinema-seat-reservation/internal/validate"   // validate checks request bodies
     "github.com/labstack/echo/v4"                                   // echo framework provides context and JSON helpers
 )
 
-// CreateSeat handles POST /v1/seats and adds a single seat to an existing hall.  It auto-expands the hall when necessary.
+// createSeatReq is the body of POST /v1/seats.
+type createSeatReq struct {
+    HallID     uint64  `json:"hall_id" validate:"required"`                               // required hall identifier
+    Row        string  `json:"row" validate:"max=8"`                                      // legacy row field
+    RowLabel   string  `json:"row_label" validate:"max=8"`                                // preferred row label field
+    Number     *uint32 `json:"number" validate:"min=1"`                                   // legacy seat number field
+    SeatNumber *uint32 `json:"seat_number" validate:"min=1"`                              // preferred seat number field
+    Type       string  `json:"type" validate:"oneof=STANDARD VIP ACCESSIBLE DISABLED"`      // legacy seat type field
+    SeatType   string  `json:"seat_type" validate:"oneof=STANDARD VIP ACCESSIBLE DISABLED"` // preferred seat type field
+}
+
+// CreateSeat handles POST /v1/seats and adds a single seat to an existing hall.  It auto-expands the hall when necessary;
+// halls with a custom layout get only the new seat, which is added to the layout.
 func (h *OwnerHandler) CreateSeat(c echo.Context) error { // begin CreateSeat handler
     ownerID, err := getUserID(c) // extract user ID from context
     if err != nil { // user ID missing or invalid
-        return c.JSON(http.StatusUnauthorized, map[string]string{"error": "unauthorized"}) // respond unauthorized
-    }
-    var body struct { // structure to bind JSON body
-        HallID     uint64  `json:"hall_id"`     // required hall identifier
-        Row        string  `json:"row"`         // legacy row field
-        RowLabel   string  `json:"row_label"`   // preferred row label field
-        Number     *uint32 `json:"number"`      // legacy seat number field
-        SeatNumber *uint32 `json:"seat_number"` // preferred seat number field
-        Type       string  `json:"type"`        // legacy seat type field
-        SeatType   string  `json:"seat_type"`   // preferred seat type field
+        return apperr.Unauthorized("unauthorized") // respond unauthorized
     }
-    if err := c.Bind(&body); err != nil { // bind incoming JSON
-        return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request body"}) // respond bad request when binding fails
-    }
-    if body.HallID == 0 { // hall ID must be specified
-        return c.JSON(http.StatusBadRequest, map[string]string{"error": "hall_id is required"}) // respond when hall ID is zero
+    var body createSeatReq // structure to bind JSON body
+    if err := bindValid(c, &body); err != nil { // bind and validate incoming JSON
+        return err // respond with 400/422 describing the problem
     }
+    var errs validate.Errors // row label and seat number may come from either alias
     // determine row label: prefer RowLabel but fall back to Row
     rawLabel := strings.TrimSpace(body.RowLabel) // trim whitespace from RowLabel
     if rawLabel == "" { // when RowLabel is empty
@@ -38,7 +42,7 @@ func (h *OwnerHandler) CreateSeat(c echo.Context) error { // begin CreateSeat ha
     }
     rowLabel := normalizeRowLabel(rawLabel) // sanitize row label to uppercase ASCII letters only
     if rowLabel == "" { // row label is still empty after normalization
-        return c.JSON(http.StatusBadRequest, map[string]string{"error": "row_label is required"}) // respond with validation error
+        errs.Add("row_label", "is required and must contain letters") // record validation error
     }
     // determine seat number from either SeatNumber or Number
     var seatNum uint32 // hold the resolved seat number
@@ -48,7 +52,10 @@ func (h *OwnerHandler) CreateSeat(c echo.Context) error { // begin CreateSeat ha
         seatNum = *body.Number // use legacy value
     }
     if seatNum == 0 { // seat number must be positive
-        return c.JSON(http.StatusBadRequest, map[string]string{"error": "seat_number is required and must be greater than zero"}) // respond invalid number
+        errs.Add("seat_number", "is required") // record validation error
+    }
+    if len(errs) > 0 {
+        return apperr.Validation(errs)
     }
     // normalize seat type; allow empty, STANDARD, VIP, ACCESSIBLE, DISABLED
     seatType := strings.ToUpper(strings.TrimSpace(body.SeatType)) // normalize preferred field
@@ -63,19 +70,19 @@ func (h *OwnerHandler) CreateSeat(c echo.Context) error { // begin CreateSeat ha
     case "DISABLED": // map DISABLED to ACCESSIBLE
         seatType = "ACCESSIBLE" // assign accessible seat type
     default: // any other string is invalid
-        return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid seat type"}) // respond invalid type
+        return apperr.BadRequest("invalid seat type") // respond invalid type
     }
     hall, err := h.HallRepo.GetByIDAndOwner(c.Request().Context(), body.HallID, ownerID) // load the hall to verify ownership
     if err != nil { // handle hall retrieval error
         if err == repository.ErrHallNotFound { // hall not found for owner
-            return c.JSON(http.StatusNotFound, map[string]string{"error": "hall not found"}) // respond not found
+            return apperr.NotFound("hall not found") // respond not found
         }
-        return c.JSON(http.StatusInternalServerError, map[string]string{"error": "could not verify hall"}) // respond generic error
+        return apperr.Internal("could not verify hall") // respond generic error
     }
     // convert row label to index for expansion calculation
     reqRowIdx, ok := rowLabelToIndex(rowLabel) // convert row label to zero-based index
     if !ok { // invalid row label when conversion fails
-        return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid row_label"}) // respond invalid row label
+        return apperr.BadRequest("invalid row_label") // respond invalid row label
     }
     // determine current seat capacity of the hall
     curRows := uint32(0) // default when seat rows are nil
@@ -86,7 +93,11 @@ func (h *OwnerHandler) CreateSeat(c echo.Context) error { // begin CreateSeat ha
     if hall.SeatCols.Valid { // check if cols field has a value
         curCols = uint32(hall.SeatCols.Int32) // convert current columns to uint32
     }
-    needsExpand := uint32(reqRowIdx+1) > curRows || seatNum > curCols // determine if hall layout must be expanded
+    layout, err := h.HallRepo.GetLayout(c.Request().Context(), hall.ID) // custom layouts are never backfilled
+    if err != nil { // handle layout retrieval error
+        return apperr.Internal("failed to load hall layout") // respond generic error
+    }
+    needsExpand := layout == nil && (uint32(reqRowIdx+1) > curRows || seatNum > curCols) // determine if hall layout must be expanded
     if needsExpand { // perform hall expansion when needed
         newRows := curRows // start with current row count
         newCols := curCols // start with current column count
@@ -109,11 +120,11 @@ func (h *OwnerHandler) CreateSeat(c echo.Context) error { // begin CreateSeat ha
             UpdatedAt:   hall.UpdatedAt,                                                      // preserve update time
         }
         if err := h.HallRepo.UpdateByIDAndOwner(c.Request().Context(), upd); err != nil { // update hall to reflect new capacity
-            return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to expand hall capacity"}) // respond failure expanding hall
+            return apperr.Internal("failed to expand hall capacity") // respond failure expanding hall
         }
         existing, err := h.SeatRepo.GetByHall(c.Request().Context(), hall.ID) // load existing seats to identify which exist
         if err != nil { // handle error loading seats
-            return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to load seats"}) // respond error
+            return apperr.Internal("failed to load seats") // respond error
         }
         type key struct { // composite key used to check existing seats
             r string // row label in uppercase
@@ -142,7 +153,7 @@ func (h *OwnerHandler) CreateSeat(c echo.Context) error { // begin CreateSeat ha
             }
         }
         if err := h.SeatRepo.CreateBulk(c.Request().Context(), toCreate); err != nil { // insert backfill seats
-            return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to backfill seats after expanding hall"}) // respond error when insertion fails
+            return apperr.Internal("failed to backfill seats after expanding hall") // respond error when insertion fails
         }
     }
     seat := &repository.Seat{ // build the seat model to insert
@@ -153,12 +164,16 @@ func (h *OwnerHandler) CreateSeat(c echo.Context) error { // begin CreateSeat ha
     }
     if err := h.SeatRepo.Create(c.Request().Context(), seat); err != nil { // attempt to create the requested seat
         if strings.Contains(err.Error(), "1062") { // duplicate entry error indicates seat exists
-            return c.JSON(http.StatusConflict, map[string]string{"error": "seat already exists"}) // respond conflict when seat duplicates existing
+            return apperr.Conflict(apperr.CodeAlreadyExists, "seat already exists") // respond conflict when seat duplicates existing
         }
-        return c.JSON(http.StatusInternalServerError, map[string]string{"error": "could not create seat"}) // respond generic error when creation fails
+        return apperr.Internal("could not create seat") // respond generic error when creation fails
+    }
+    if err := h.syncHallLayout(c.Request().Context(), hall.ID, layout); err != nil { // add the seat to a custom layout
+        return err
     }
     // fetch the full seat including timestamps after creation
     full, err := h.SeatRepo.GetByID(c.Request().Context(), seat.ID) // load the inserted seat
+    recordAudit(c, h.Audit, auditEvent("seat.create", "seat", seat.ID, ownerID), nil, seat) // record the new seat
     if err != nil { // handle error fetching seat
         // if retrieval fails, still return the partially populated seat
         return c.JSON(http.StatusCreated, seat) // respond with created seat without timestamps
@@ -166,28 +181,29 @@ func (h *OwnerHandler) CreateSeat(c echo.Context) error { // begin CreateSeat ha
     return c.JSON(http.StatusCreated, full) // return the fully populated seat with timestamps
 }
 
-// UpdateSeat handles PUT/PATCH /v1/seats/:id and modifies seat attributes.  It can relocate a seat and expand the hall if necessary.
+// UpdateSeat handles PUT/PATCH /v1/seats/:id and modifies seat attributes.  It can relocate a seat and expand the hall if necessary;
+// in halls with a custom layout the seat is moved within the layout instead.
 func (h *OwnerHandler) UpdateSeat(c echo.Context) error { // begin UpdateSeat handler
     ownerID, err := getUserID(c) // retrieve user ID
     if err != nil { // unauthorized when user ID is invalid
-        return c.JSON(http.StatusUnauthorized, map[string]string{"error": "unauthorized"}) // respond unauthorized
+        return apperr.Unauthorized("unauthorized") // respond unauthorized
     }
     id, err := strconv.ParseUint(c.Param("id"), 10, 64) // parse seat ID from path
     if err != nil { // invalid seat ID
-        return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid id"}) // respond invalid id
+        return apperr.BadRequest("invalid id") // respond invalid id
     }
     var body struct { // structure to bind JSON body
-        RowLabel   string  `json:"row_label"` // new row label
-        SeatNumber uint32  `json:"seat_number"` // new seat number
-        SeatType   *string `json:"seat_type"` // optional new seat type
-        IsActive   *bool   `json:"is_active"` // optional active flag
+        RowLabel   string  `json:"row_label" validate:"required,max=8"`                          // new row label
+        SeatNumber uint32  `json:"seat_number" validate:"required"`                            // new seat number
+        SeatType   *string `json:"seat_type" validate:"oneof=STANDARD VIP ACCESSIBLE DISABLED"` // optional new seat type
+        IsActive   *bool   `json:"is_active"`                                                  // optional active flag
     }
-    if err := c.Bind(&body); err != nil { // bind incoming JSON
-        return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request body"}) // respond bad request when binding fails
+    if err := bindValid(c, &body); err != nil { // bind and validate incoming JSON
+        return err // respond with 400/422 describing the problem
     }
     rowLabel := normalizeRowLabel(body.RowLabel) // sanitize the row label
-    if rowLabel == "" || body.SeatNumber == 0 { // row label and seat number are mandatory
-        return c.JSON(http.StatusBadRequest, map[string]string{"error": "row_label and seat_number are required"}) // respond validation error
+    if rowLabel == "" { // nothing left after normalization
+        return apperr.Validation(validate.Errors{{Field: "row_label", Message: "must contain letters"}})
     }
     var normalizedType string // local variable for the normalized seat type
     if body.SeatType != nil { // seat type provided
@@ -200,15 +216,15 @@ func (h *OwnerHandler) UpdateSeat(c echo.Context) error { // begin UpdateSeat ha
         case "DISABLED": // map DISABLED to ACCESSIBLE
             normalizedType = "ACCESSIBLE" // assign accessible seat type
         default: // invalid seat type detected
-            return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid seat type"}) // respond invalid seat type
+            return apperr.BadRequest("invalid seat type") // respond invalid seat type
         }
     }
     curSeat, err := h.SeatRepo.GetByIDAndOwner(c.Request().Context(), id, ownerID) // load the current seat to verify ownership
     if err != nil { // handle retrieval errors
         if err == repository.ErrSeatNotFound { // seat not found for owner
-            return c.JSON(http.StatusNotFound, map[string]string{"error": "seat not found"}) // respond not found
+            return apperr.NotFound("seat not found") // respond not found
         }
-        return c.JSON(http.StatusInternalServerError, map[string]string{"error": "db error"}) // generic database error
+        return apperr.Internal("db error") // generic database error
     }
     isActive := curSeat.IsActive // start with current active flag
     if body.IsActive != nil { // update when provided
@@ -217,13 +233,13 @@ func (h *OwnerHandler) UpdateSeat(c echo.Context) error { // begin UpdateSeat ha
     hall, err := h.HallRepo.GetByIDAndOwner(c.Request().Context(), curSeat.HallID, ownerID) // fetch the hall owning the seat
     if err != nil { // handle hall retrieval errors
         if err == repository.ErrHallNotFound { // hall not found for owner
-            return c.JSON(http.StatusNotFound, map[string]string{"error": "hall not found"}) // respond not found
+            return apperr.NotFound("hall not found") // respond not found
         }
-        return c.JSON(http.StatusInternalServerError, map[string]string{"error": "db error"}) // generic database error
+        return apperr.Internal("db error") // generic database error
     }
     reqRowIdx, ok := rowLabelToIndex(rowLabel) // convert row label to index for expansion logic
     if !ok { // invalid row label conversion
-        return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid row_label"}) // respond invalid row label
+        return apperr.BadRequest("invalid row_label") // respond invalid row label
     }
     curRows := uint32(0) // current row count in hall
     curCols := uint32(0) // current column count in hall
@@ -233,7 +249,11 @@ func (h *OwnerHandler) UpdateSeat(c echo.Context) error { // begin UpdateSeat ha
     if hall.SeatCols.Valid { // hall has a column count specified
         curCols = uint32(hall.SeatCols.Int32) // convert to uint32
     }
-    needsExpand := uint32(reqRowIdx+1) > curRows || body.SeatNumber > curCols // determine if hall expansion is necessary
+    layout, err := h.HallRepo.GetLayout(c.Request().Context(), hall.ID) // custom layouts are never backfilled
+    if err != nil { // handle layout retrieval error
+        return apperr.Internal("failed to load hall layout") // respond generic error
+    }
+    needsExpand := layout == nil && (uint32(reqRowIdx+1) > curRows || body.SeatNumber > curCols) // determine if hall expansion is necessary
     if needsExpand { // perform hall expansion when seat moves beyond current layout
         newRows := curRows // start with current rows
         newCols := curCols // start with current cols
@@ -256,11 +276,11 @@ func (h *OwnerHandler) UpdateSeat(c echo.Context) error { // begin UpdateSeat ha
             UpdatedAt:   hall.UpdatedAt,                                                          // preserve update time
         }
         if err := h.HallRepo.UpdateByIDAndOwner(c.Request().Context(), updHall); err != nil { // persist hall expansion
-            return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to expand hall capacity"}) // respond error on failure
+            return apperr.Internal("failed to expand hall capacity") // respond error on failure
         }
         existing, err := h.SeatRepo.GetByHall(c.Request().Context(), hall.ID) // load existing seats to know which seats exist
         if err != nil { // handle error loading seats
-            return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to load seats"}) // respond error
+            return apperr.Internal("failed to load seats") // respond error
         }
         type key struct { // key to index existing seats
             r string // row label in uppercase
@@ -290,35 +310,39 @@ func (h *OwnerHandler) UpdateSeat(c echo.Context) error { // begin UpdateSeat ha
         }
         if len(toCreate) > 0 { // if there are seats to create
             if err := h.SeatRepo.CreateBulk(c.Request().Context(), toCreate); err != nil { // insert missing seats
-                return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to backfill seats after expanding hall"}) // respond error on failure
+                return apperr.Internal("failed to backfill seats after expanding hall") // respond error on failure
             }
         }
     }
     if body.SeatType != nil { // seat type is being updated
         if err := h.SeatRepo.UpdateWithTypeByIDAndOwner(c.Request().Context(), id, ownerID, rowLabel, body.SeatNumber, normalizedType, isActive); err != nil { // update seat with type
             if err == sql.ErrNoRows { // seat not found during update
-                return c.JSON(http.StatusNotFound, map[string]string{"error": "seat not found"}) // respond not found
+                return apperr.NotFound("seat not found") // respond not found
             }
             if strings.Contains(err.Error(), "1062") { // duplicate seat error
-                return c.JSON(http.StatusConflict, map[string]string{"error": "seat already exists"}) // respond conflict
+                return apperr.Conflict(apperr.CodeAlreadyExists, "seat already exists") // respond conflict
             }
-            return c.JSON(http.StatusInternalServerError, map[string]string{"error": "update failed"}) // generic update error
+            return apperr.Internal("update failed") // generic update error
         }
     } else { // seat type is not being updated
         if err := h.SeatRepo.UpdateByIDAndOwner(c.Request().Context(), id, ownerID, rowLabel, body.SeatNumber, isActive); err != nil { // update seat without type change
             if err == sql.ErrNoRows { // seat not found
-                return c.JSON(http.StatusNotFound, map[string]string{"error": "seat not found"}) // respond not found
+                return apperr.NotFound("seat not found") // respond not found
             }
             if strings.Contains(err.Error(), "1062") { // duplicate seat placement
-                return c.JSON(http.StatusConflict, map[string]string{"error": "seat already exists"}) // respond conflict
+                return apperr.Conflict(apperr.CodeAlreadyExists, "seat already exists") // respond conflict
             }
-            return c.JSON(http.StatusInternalServerError, map[string]string{"error": "update failed"}) // generic update error
+            return apperr.Internal("update failed") // generic update error
         }
     }
+    if err := h.syncHallLayout(c.Request().Context(), hall.ID, layout); err != nil { // move the seat in a custom layout
+        return err
+    }
     updated, err := h.SeatRepo.GetByIDAndOwner(c.Request().Context(), id, ownerID) // retrieve the updated seat
     if err != nil { // handle fetch error after update
-        return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to load updated seat"}) // respond error when unable to load seat
+        return apperr.Internal("failed to load updated seat") // respond error when unable to load seat
     }
+    recordAudit(c, h.Audit, auditEvent("seat.update", "seat", id, ownerID), curSeat, updated) // record the change
     return c.JSON(http.StatusOK, updated) // return the updated seat with OK status
 }
 
@@ -326,17 +350,28 @@ func (h *OwnerHandler) UpdateSeat(c echo.Context) error { // begin UpdateSeat ha
 func (h *OwnerHandler) DeleteSeat(c echo.Context) error { // begin DeleteSeat handler
     ownerID, err := getUserID(c) // extract user ID
     if err != nil { // user not authenticated
-        return c.JSON(http.StatusUnauthorized, map[string]string{"error": "unauthorized"}) // respond unauthorized
+        return apperr.Unauthorized("unauthorized") // respond unauthorized
     }
     id, err := strconv.ParseUint(c.Param("id"), 10, 64) // parse seat ID from path
     if err != nil { // invalid seat ID provided
-        return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid id"}) // respond invalid id
+        return apperr.BadRequest("invalid id") // respond invalid id
     }
+    before, _ := h.SeatRepo.GetByIDAndOwner(c.Request().Context(), id, ownerID) // snapshot for the audit log and the hall's layout
     if err := h.SeatRepo.DeleteByIDAndOwner(c.Request().Context(), id, ownerID); err != nil { // attempt to delete seat ensuring ownership
         if err == sql.ErrNoRows { // seat not found or not owned
-            return c.JSON(http.StatusNotFound, map[string]string{"error": "seat not found"}) // respond not found
+            return apperr.NotFound("seat not found") // respond not found
+        }
+        return apperr.Internal("delete failed") // generic delete failure
+    }
+    if before != nil { // remove the seat from a custom layout
+        layout, err := h.HallRepo.GetLayout(c.Request().Context(), before.HallID)
+        if err != nil {
+            return apperr.Internal("failed to load hall layout")
+        }
+        if err := h.syncHallLayout(c.Request().Context(), before.HallID, layout); err != nil {
+            return err
         }
-        return c.JSON(http.StatusInternalServerError, map[string]string{"error": "delete failed"}) // generic delete failure
     }
+    recordAudit(c, h.Audit, auditEvent("seat.delete", "seat", id, ownerID), before, nil) // record the deletion
     return c.NoContent(http.StatusNoContent) // respond with 204 No Content on success
 }
\ No newline at end of file
@@ -0,0 +1,173 @@
+package handler
+
+import (
+    "database/sql"
+    "errors"
+    "net/http"
+    "sort"
+    "strconv"
+    "strings"
+
+    "github.com/iliyamo/cinema-seat-reservation/internal/apperr"
+    "github.com/iliyamo/cinema-seat-reservation/internal/db"
+    "github.com/iliyamo/cinema-seat-reservation/internal/repository"
+    "github.com/iliyamo/cinema-seat-reservation/internal/validate"
+    "github.com/labstack/echo/v4"
+)
+
+// seatSelector picks seats of a hall: whole rows, a range of seat
+// numbers within those rows (from/to, inclusive), or explicit seat IDs.
+type seatSelector struct {
+    Rows    []string `json:"rows" validate:"max=100"`      // row labels, e.g. ["A", "B"]
+    From    *uint32  `json:"from" validate:"min=1"`        // optional; first seat number of the range
+    To      *uint32  `json:"to" validate:"min=1"`          // optional; last seat number of the range
+    SeatIDs []uint64 `json:"seat_ids" validate:"max=1000"` // explicit seats instead of rows
+}
+
+// bulkSeatsReq is the body of PATCH /v1/halls/:id/seats/bulk.
+type bulkSeatsReq struct {
+    Selectors []seatSelector `json:"selectors" validate:"required,max=100"`
+    SeatType  *string        `json:"seat_type" validate:"oneof=STANDARD VIP ACCESSIBLE DISABLED"` // optional new seat type
+    IsActive  *bool          `json:"is_active"`                                                  // optional new active flag
+}
+
+// UpdateSeatsBulk handles PATCH /v1/halls/:id/seats/bulk.  It sets the
+// seat type and/or active flag of every seat matched by any of the
+// selectors in one transaction:
+//
+//     {"selectors": [{"rows": ["A"]}, {"rows": ["C"], "from": 3, "to": 8}, {"seat_ids": [17]}],
+//      "seat_type": "VIP"}
+//
+// Unknown rows and seats of other halls give 400 with the offending
+// values and change nothing.  It responds with the number of seats
+// matched and changed and the IDs of the matched seats.
+func (h *OwnerHandler) UpdateSeatsBulk(c echo.Context) error {
+    ownerID, err := getUserID(c)
+    if err != nil {
+        return apperr.Unauthorized("unauthorized")
+    }
+    hallID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+    if err != nil || hallID == 0 {
+        return apperr.BadRequest("invalid id")
+    }
+    var body bulkSeatsReq
+    if err := bindValid(c, &body); err != nil {
+        return err
+    }
+    errs := checkSeatSelectors(body.Selectors)
+    if body.SeatType == nil && body.IsActive == nil {
+        errs.Add("seat_type", "seat_type or is_active is required")
+    }
+    if len(errs) > 0 {
+        return apperr.Validation(errs)
+    }
+    var seatType *string
+    if body.SeatType != nil {
+        t := strings.ToUpper(strings.TrimSpace(*body.SeatType))
+        if t == "DISABLED" { // legacy name of ACCESSIBLE, as in UpdateSeat
+            t = "ACCESSIBLE"
+        }
+        seatType = &t
+    }
+    ctx := c.Request().Context()
+    hall, err := h.HallRepo.GetByIDAndOwner(ctx, hallID, ownerID)
+    if err != nil {
+        if errors.Is(err, repository.ErrHallNotFound) {
+            return apperr.NotFound("hall not found")
+        }
+        return apperr.Internal("could not verify hall")
+    }
+    seats, err := h.SeatRepo.GetByHall(ctx, hallID)
+    if err != nil {
+        return apperr.Internal("failed to load seats")
+    }
+    ids, unknownRows, invalidIDs := selectSeats(seats, body.Selectors)
+    if len(unknownRows) > 0 || len(invalidIDs) > 0 {
+        return apperr.BadRequest("selectors match rows or seats not in this hall").
+            WithDetails(map[string]any{"unknown_rows": unknownRows, "invalid_seat_ids": invalidIDs})
+    }
+    var changed int64
+    err = db.WithTx(ctx, h.ShowRepo.DB(), func(tx *sql.Tx) error {
+        n, err := h.SeatRepo.UpdateBulkTx(ctx, tx, hallID, ids, seatType, body.IsActive)
+        if err != nil {
+            return failTx("failed to update seats", err)
+        }
+        changed = n
+        return nil
+    })
+    if err != nil {
+        return txError(c, err)
+    }
+    recordAudit(c, h.Audit, auditEvent("hall.seats_bulk", "hall", hallID, hall.OwnerID), nil,
+        echo.Map{"seat_ids": ids, "seat_type": seatType, "is_active": body.IsActive})
+    return c.JSON(http.StatusOK, echo.Map{
+        "hall_id":  hallID,
+        "matched":  len(ids),
+        "updated":  changed,
+        "seat_ids": ids,
+    })
+}
+
+// checkSeatSelectors reports selectors that pick nothing or mix rows and
+// seat IDs.
+func checkSeatSelectors(selectors []seatSelector) validate.Errors {
+    var errs validate.Errors
+    for i, sel := range selectors {
+        field := "selectors[" + strconv.Itoa(i) + "]"
+        switch {
+        case len(sel.Rows) == 0 && len(sel.SeatIDs) == 0:
+            errs.Add(field, "needs rows or seat_ids")
+        case len(sel.Rows) > 0 && len(sel.SeatIDs) > 0:
+            errs.Add(field, "cannot combine rows and seat_ids")
+        case len(sel.SeatIDs) > 0 && (sel.From != nil || sel.To != nil):
+            errs.Add(field, "from/to only apply to rows")
+        case sel.From != nil && sel.To != nil && *sel.From > *sel.To:
+            errs.Add(field+".to", "must not be less than from")
+        }
+    }
+    return errs
+}
+
+// selectSeats resolves selectors against the seats of a hall.  It returns
+// the matched seat IDs in ascending order without duplicates, the row
+// labels no seat has, and the seat IDs not in the hall.
+func selectSeats(seats []repository.Seat, selectors []seatSelector) (ids []uint64, unknownRows []string, invalidIDs []uint64) {
+    byRow := make(map[string][]repository.Seat)
+    inHall := make(map[uint64]bool, len(seats))
+    for _, s := range seats {
+        lbl := strings.ToUpper(s.RowLabel)
+        byRow[lbl] = append(byRow[lbl], s)
+        inHall[s.ID] = true
+    }
+    picked := make(map[uint64]bool)
+    unknownRows = make([]string, 0)
+    invalidIDs = make([]uint64, 0)
+    for _, sel := range selectors {
+        for _, id := range sel.SeatIDs {
+            if !inHall[id] {
+                invalidIDs = append(invalidIDs, id)
+                continue
+            }
+            picked[id] = true
+        }
+        for _, raw := range sel.Rows {
+            row, ok := byRow[normalizeRowLabel(raw)]
+            if !ok {
+                unknownRows = append(unknownRows, raw)
+                continue
+            }
+            for _, s := range row {
+                if (sel.From != nil && s.SeatNumber < *sel.From) || (sel.To != nil && s.SeatNumber > *sel.To) {
+                    continue
+                }
+                picked[s.ID] = true
+            }
+        }
+    }
+    ids = make([]uint64, 0, len(picked))
+    for id := range picked {
+        ids = append(ids, id)
+    }
+    sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+    return ids, unknownRows, invalidIDs
+}
@@ -3,70 +3,88 @@ package handler // handler package contains owner-specific show handlers
 import (
 	"database/sql" // sql is needed for sentinel errors during show updates
 	"errors"
+	"fmt"      // fmt formats scheduling window messages
 	"net/http" // http defines status codes
 	"strconv"  // strconv converts path params to integers
 	"strings"  // strings helps with trimming whitespace
 	"time"     // time is used for parsing and formatting timestamps
 
+	"github.com/iliyamo/cinema-seat-reservation/internal/apperr" // apperr builds error responses
+	"github.com/iliyamo/cinema-seat-reservation/internal/cache"  // cache drops public responses of the moved show
 	"github.com/iliyamo/cinema-seat-reservation/internal/repository" // repository defines data models
+	"github.com/iliyamo/cinema-seat-reservation/internal/service"    // service applies the scheduling rules
 	"github.com/labstack/echo/v4"                                    // echo provides the web context and JSON helpers
 )
 
+// createShowReq is the body of POST /v1/shows.
+type createShowReq struct {
+	HallID         uint64  `json:"hall_id" validate:"required"`             // ID of the hall where the show will take place
+	Title          string  `json:"title" validate:"max=255"`                // legacy field for movie title
+	MovieTitle     string  `json:"movie_title" validate:"max=255"`          // preferred field for movie title
+	StartsAt       string  `json:"starts_at" validate:"required,timestamp"` // RFC3339, or local to the hall's cinema without an offset
+	EndsAt         string  `json:"ends_at" validate:"timestamp"`            // RFC3339, or local to the hall's cinema without an offset
+	BasePriceCents *uint32 `json:"base_price_cents"`                        // optional base price for seats
+	RuntimeMinutes *uint32 `json:"runtime_minutes" validate:"max=1440"`     // movie runtime; used to derive ends_at when it is omitted
+	HoldTTLSec     *uint32 `json:"hold_ttl_sec" validate:"max=3600"`        // optional seat hold duration override; 0 or absent uses the default
+	Repeat         string  `json:"repeat" validate:"oneof=daily weekly"`    // optional; schedules the show every day or week
+	Until          string  `json:"until"`                                   // last date (YYYY-MM-DD, cinema time) of a repeating schedule
+}
+
 // CreateShow handles POST /v1/shows and schedules a new show in a hall.  It creates show seats for all hall seats.
+// ends_at may be omitted when runtime_minutes is given; it is then starts_at + runtime + OwnerHandler.ShowBuffer.
+// With repeat the show is scheduled daily or weekly through until; see scheduleRecurring.
+// starts_at must lie in the scheduling window of the hall's cinema (see
+// service.ShowService.CheckWindow); otherwise it responds 422.
 func (h *OwnerHandler) CreateShow(c echo.Context) error { // begin CreateShow handler
 	ownerID, err := getUserID(c) // extract user ID from context
 	if err != nil {              // unauthorized when user ID is invalid
-		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "unauthorized"}) // respond unauthorized
-	}
-	var body struct { // struct to bind JSON request body
-		HallID         uint64  `json:"hall_id"`          // ID of the hall where the show will take place
-		Title          string  `json:"title"`            // legacy field for movie title
-		MovieTitle     string  `json:"movie_title"`      // preferred field for movie title
-		StartsAt       string  `json:"starts_at"`        // ISO start time (RFC3339)
-		EndsAt         string  `json:"ends_at"`          // ISO end time (RFC3339)
-		BasePriceCents *uint32 `json:"base_price_cents"` // optional base price for seats
-	}
-	if err := c.Bind(&body); err != nil { // bind incoming JSON
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request body"}) // respond bad request on binding failure
+		return apperr.Unauthorized("unauthorized") // respond unauthorized
 	}
-	if body.HallID == 0 { // hall ID must be provided
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "hall_id is required"}) // respond missing hall id
+	var body createShowReq // struct to bind JSON request body
+	if err := bindValid(c, &body); err != nil { // bind and validate incoming JSON
+		return err // respond with 400/422 describing the problem
 	}
 	title := strings.TrimSpace(body.MovieTitle) // prefer movie_title field
 	if title == "" {                            // fallback to legacy title field
 		title = strings.TrimSpace(body.Title) // use trimmed legacy title
 	}
 	if title == "" { // no title provided
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "movie_title is required"}) // respond missing title
+		return fieldError("movie_title", "is required") // respond missing title
 	}
 	startsAt := strings.TrimSpace(body.StartsAt) // trim the start time
 	endsAt := strings.TrimSpace(body.EndsAt)     // trim the end time
-	if startsAt == "" || endsAt == "" {          // both times are required
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "starts_at and ends_at are required"}) // respond missing times
+	if endsAt == "" && (body.RuntimeMinutes == nil || *body.RuntimeMinutes == 0) { // end time must be given or derivable
+		return fieldError("ends_at", "is required unless runtime_minutes is given") // respond missing end time
 	}
 	// verify hall ownership
-	if _, err := h.HallRepo.GetByIDAndOwner(c.Request().Context(), body.HallID, ownerID); err != nil {
+	hall, err := h.HallRepo.GetByIDAndOwner(c.Request().Context(), body.HallID, ownerID)
+	if err != nil {
 		if err == repository.ErrHallNotFound {
-			return c.JSON(http.StatusNotFound, map[string]string{"error": "hall not found"})
+			return apperr.NotFound("hall not found")
 		}
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to verify hall"})
+		return apperr.Internal("failed to verify hall")
 	}
 
-	// parse RFC3339 and normalize to UTC to match DB DATETIME storage
-    startTime, err := time.Parse(time.RFC3339, startsAt)
+	// parse the times in the cinema's time zone unless they carry an
+	// offset, and normalize to UTC to match DB DATETIME storage
+	loc := loadTimezone(hall.Timezone)
+    startTime, err := parseShowTime(startsAt, loc)
     if err != nil {
-        return c.JSON(http.StatusBadRequest, map[string]string{
-            "error": "Invalid starts_at format. Must be RFC3339 (e.g. 2025-08-09T10:55:13Z)",
-        })
+        return fieldError("starts_at", timestampHint)
     }
-    endTime, err := time.Parse(time.RFC3339, endsAt)
-    if err != nil {
-        return c.JSON(http.StatusBadRequest, map[string]string{
-            "error": "Invalid ends_at format. Must be RFC3339 (e.g. 2025-08-09T10:55:13Z)",
-        })
+    // When ends_at is omitted it is derived from the runtime plus the
+    // configured trailer/cleanup buffer, so it can never precede starts_at.
+    var endTime time.Time
+    if endsAt == "" {
+        endTime = startTime.Add(time.Duration(*body.RuntimeMinutes)*time.Minute + h.ShowBuffer)
+    } else {
+        endTime, err = parseShowTime(endsAt, loc)
+        if err != nil {
+            return fieldError("ends_at", timestampHint)
+        }
     }
 	if !endTime.After(startTime) {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "ends_at must be after starts_at"})
+		return fieldError("ends_at", "must be after starts_at")
 	}
 
 	var price uint32
@@ -74,165 +92,156 @@ func (h *OwnerHandler) CreateShow(c echo.Context) error { // begin CreateShow ha
 		price = *body.BasePriceCents
 	}
 
-	// Convert to DB-friendly UTC string "YYYY-MM-DD HH:MM:SS"
-	startStr := startTime.UTC().Format("2006-01-02 15:04:05")
-	endStr := endTime.UTC().Format("2006-01-02 15:04:05")
-
-	// Ensure no overlap in this hall
-	overlaps, err := h.ShowRepo.FindOverlapping(c.Request().Context(), body.HallID, startStr, endStr)
-	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to check existing shows"})
-	}
-	if len(overlaps) > 0 {
-		return c.JSON(http.StatusConflict, map[string]any{
-			"error":    "show time overlaps with existing show",
-			"overlaps": overlaps,
-		})
+	var holdTTL *uint32
+	if body.HoldTTLSec != nil && *body.HoldTTLSec > 0 {
+		holdTTL = body.HoldTTLSec
 	}
 
-    // Build new show record to be persisted.  ID and timestamp fields will be
-    // populated after insertion.  Times have already been validated and formatted.
-    show := &repository.Show{
-        HallID:         body.HallID,
-        Title:          title,
-        StartsAt:       startStr,
-        EndsAt:         endStr,
-        BasePriceCents: price,
-    }
+	spec := service.ShowSpec{Hall: hall, Title: title, PriceCents: price, HoldTTLSec: holdTTL}
+	if body.Repeat != "" {
+		return h.scheduleRecurring(c, spec, startTime, endTime, body.Repeat, body.Until)
+	}
 
-    // Preload all seats for the hall before beginning the transaction.  Should an
-    // error occur here, no transaction will be opened and the handler returns
-    // immediately.  Seats are read outside the transaction because they are
-    // immutable during show creation.
+    // Preload all seats for the hall.  Seats are read outside the
+    // transaction because they are immutable during show creation.
     seats, err := h.SeatRepo.GetByHall(c.Request().Context(), body.HallID)
     if err != nil {
-        return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to load seats"})
+        return apperr.Internal("failed to load seats")
     }
-
-    // Obtain the context and begin a new transaction on the shows repository's DB.
-    ctx := c.Request().Context()
-    tx, err := h.ShowRepo.DB().BeginTx(ctx, nil)
+    show, err := h.scheduleShow(c, spec, seats, startTime, endTime)
     if err != nil {
-        return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to start transaction"})
-    }
-    // Ensure the transaction is properly closed.  This deferred function will
-    // rollback if err is set, otherwise commit.  Note that err is captured
-    // from the surrounding scope.
-    committed := false
-    defer func() {
-        if !committed {
-            _ = tx.Rollback()
-        }
-    }()
-
-    // Insert the show row within the transaction.  On success the show ID and
-    // other default fields will be populated on the struct.
-    if err = h.ShowRepo.CreateTx(ctx, tx, show); err != nil {
-        return c.JSON(http.StatusInternalServerError, map[string]string{"error": "could not create show"})
-    }
-
-    // Construct show_seat entries corresponding to every seat in the hall.  Each
-    // seat is initialized as FREE and priced according to the show's base price.
-    ss := make([]repository.ShowSeat, 0, len(seats))
-    for _, seat := range seats {
-        ss = append(ss, repository.ShowSeat{
-            ShowID:     show.ID,
-            SeatID:     seat.ID,
-            Status:     "FREE",
-            PriceCents: price,
-            Version:    1,
-        })
-    }
-    // Persist the show_seat records using the same transaction.  Should this
-    // operation fail, the deferred rollback will execute.
-    if err = h.ShowSeatRepo.CreateBulkTx(ctx, tx, ss); err != nil {
-        return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to create show seats"})
-    }
-    // Commit the transaction.  If commit fails, the deferred rollback will run
-    // implicitly when the handler returns.
-    if err = tx.Commit(); err != nil {
-        return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to commit transaction"})
+        return err
     }
-    committed = true
 
     // Return the fully populated show row by fetching it outside the transaction.
-    fresh, err := h.ShowRepo.GetByID(ctx, show.ID)
+    fresh, err := h.ShowRepo.GetByID(c.Request().Context(), show.ID)
     if err != nil {
         // In the unlikely event that retrieving the fresh show fails, fall
         // back to returning the partially populated show structure.
+        localizeShow(loc, hall.Currency, show)
         return c.JSON(http.StatusCreated, show)
     }
+    localizeShow(loc, hall.Currency, fresh)
     return c.JSON(http.StatusCreated, fresh)
 }
 
+// scheduleShow creates a show of spec from startTime to endTime together
+// with a FREE show seat for each of the hall's seats through
+// service.ShowService.Schedule and records it in the audit log.
+func (h *OwnerHandler) scheduleShow(c echo.Context, spec service.ShowSpec, seats []repository.Seat, startTime, endTime time.Time) (*repository.Show, error) {
+	show, err := h.Shows.Schedule(c.Request().Context(), spec, seats, startTime, endTime)
+	if err != nil {
+		return nil, scheduleError(err)
+	}
+	recordAudit(c, h.Audit, auditEvent("show.create", "show", show.ID, spec.Hall.OwnerID), nil, show)
+	return show, nil
+}
+
+// scheduleError converts an error from service.ShowService into the
+// client error it stands for: a 422 on starts_at outside the scheduling
+// window, BLACKOUT_DATE, SHOW_OVERLAP or a 500.
+func scheduleError(err error) error {
+	var window *service.ScheduleWindowError
+	var blackout *service.BlackoutDateError
+	var overlap *service.ShowOverlapError
+	switch {
+	case errors.As(err, &window):
+		return windowError("starts_at", window)
+	case errors.As(err, &blackout):
+		return apperr.Conflict(apperr.CodeBlackoutDate, "shows cannot be scheduled on a blackout date").
+			WithDetails(echo.Map{"date": blackout.Date, "label": blackout.Label})
+	case errors.As(err, &overlap):
+		return apperr.Conflict(apperr.CodeShowOverlap, "show time overlaps with existing show").
+			WithDetails(map[string]any{"overlaps": overlap.Overlaps})
+	}
+	return apperr.Internal("failed to schedule show").Wrap(err)
+}
+
+// windowError is the validation error on field for a show starting
+// outside its cinema's scheduling window.
+func windowError(field string, e *service.ScheduleWindowError) error {
+	switch {
+	case e.TooFar:
+		return fieldError(field, fmt.Sprintf("must be at most %d days ahead", int(e.MaxHorizon/(24*time.Hour))))
+	case e.MinLead > 0:
+		return fieldError(field, fmt.Sprintf("must be at least %d minutes from now", int(e.MinLead/time.Minute)))
+	}
+	return fieldError(field, "must not be in the past")
+}
+
 // ListShowsInHall handles GET /v1/halls/:hall_id/shows and returns all shows for a hall owned by the caller.
 func (h *OwnerHandler) ListShowsInHall(c echo.Context) error { // begin ListShowsInHall
 	ownerID, err := getUserID(c) // extract user ID from context
 	if err != nil {
-		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "unauthorized"}) // unauthorized when user ID invalid
+		return apperr.Unauthorized("unauthorized") // unauthorized when user ID invalid
 	}
 	// Parse hall_id path parameter.
 	hallID, err := strconv.ParseUint(c.Param("hall_id"), 10, 64)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid hall_id"})
+		return apperr.BadRequest("invalid hall_id")
 	}
 	// Ensure the hall exists and belongs to the owner.  Use HallRepo for verification.
-	if _, err := h.HallRepo.GetByIDAndOwner(c.Request().Context(), hallID, ownerID); err != nil {
+	hall, err := h.HallRepo.GetByIDAndOwner(c.Request().Context(), hallID, ownerID)
+	if err != nil {
 		if err == repository.ErrHallNotFound {
-			return c.JSON(http.StatusNotFound, map[string]string{"error": "hall not found"})
+			return apperr.NotFound("hall not found")
 		}
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "db error"})
+		return apperr.Internal("db error")
 	}
 	// Fetch all shows for this hall and owner.
 	shows, err := h.ShowRepo.ListByHallAndOwner(c.Request().Context(), hallID, ownerID)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to load shows"})
+		return apperr.Internal("failed to load shows")
 	}
+	localizeShows(loadTimezone(hall.Timezone), hall.Currency, shows)
 	return c.JSON(http.StatusOK, map[string]any{"items": shows})
 }
 
 // UpdateShow handles PUT/PATCH /v1/shows/:id and updates a show.  It allows modifying
 // the title, start/end times, base price and status while enforcing ownership and
 // avoiding schedule conflicts.  When times are changed, it checks for overlaps.
+// Shows are cancelled through CancelShow, never by setting status here.
 func (h *OwnerHandler) UpdateShow(c echo.Context) error {
 	ownerID, err := getUserID(c)
 	if err != nil {
-		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return apperr.Unauthorized("unauthorized")
 	}
 
 	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid id"})
+		return apperr.BadRequest("invalid id")
 	}
 
 	cur, err := h.ShowRepo.GetByID(c.Request().Context(), id)
 	if err != nil {
 		if err == repository.ErrShowNotFound {
-			return c.JSON(http.StatusNotFound, map[string]string{"error": "show not found"})
+			return apperr.NotFound("show not found")
 		}
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to load show"})
+		return apperr.Internal("failed to load show")
 	}
 
 	// verify ownership by hall
-	if _, err := h.HallRepo.GetByIDAndOwner(c.Request().Context(), cur.HallID, ownerID); err != nil {
+	hall, err := h.HallRepo.GetByIDAndOwner(c.Request().Context(), cur.HallID, ownerID)
+	if err != nil {
 		if err == repository.ErrHallNotFound {
-			return c.JSON(http.StatusNotFound, map[string]string{"error": "show not found"})
+			return apperr.NotFound("show not found")
 		}
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to verify ownership"})
+		return apperr.Internal("failed to verify ownership")
 	}
 
 	// optional inputs
     var body struct {
-        Title          *string `json:"title"`
-        MovieTitle     *string `json:"movie_title"`
-        StartsAt       *string `json:"starts_at"` // RFC3339 formatted start time
-        EndsAt         *string `json:"ends_at"`   // RFC3339 formatted end time
+        Title          *string `json:"title" validate:"max=255"`
+        MovieTitle     *string `json:"movie_title" validate:"max=255"`
+        StartsAt       *string `json:"starts_at" validate:"timestamp"` // RFC3339, or local to the hall's cinema without an offset
+        EndsAt         *string `json:"ends_at" validate:"timestamp"`   // RFC3339, or local to the hall's cinema without an offset
         BasePriceCents *uint32 `json:"base_price_cents"`
-        Status         *string `json:"status"`    // SCHEDULED|CANCELLED|FINISHED
-        HallID         *uint64 `json:"hall_id"`   // optional hall change; if provided and different, seats will be rebuilt
+        HoldTTLSec     *uint32 `json:"hold_ttl_sec" validate:"max=3600"`                        // seat hold duration override; 0 clears it
+        Status         *string `json:"status" validate:"oneof=SCHEDULED CANCELLED FINISHED"` // SCHEDULED|CANCELLED|FINISHED
+        HallID         *uint64 `json:"hall_id" validate:"min=1"`                             // optional hall change; if provided and different, seats will be rebuilt
     }
-	if err := c.Bind(&body); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+	if err := bindValid(c, &body); err != nil {
+		return err
 	}
 
 	// derive new values (default to current DB values)
@@ -246,6 +255,7 @@ func (h *OwnerHandler) UpdateShow(c echo.Context) error {
     start := cur.StartsAt
     end := cur.EndsAt
     var startChanged, endChanged bool
+    multiplierPct := uint32(100) // special-date multiplier for rebuilt show seats
 
     // Determine whether the hall is changing.  Default to the current hall ID.
     newHallID := cur.HallID
@@ -257,25 +267,34 @@ func (h *OwnerHandler) UpdateShow(c echo.Context) error {
         newHallID = *body.HallID
         hallChanged = true
     }
+    // When the hall is being changed, ensure the new hall exists and is owned
+    // by the caller.  This prevents moving a show to a hall owned by someone
+    // else, and local times are read in the new hall's time zone.
+    if hallChanged {
+        hall, err = h.HallRepo.GetByIDAndOwner(c.Request().Context(), newHallID, ownerID)
+        if err != nil {
+            if err == repository.ErrHallNotFound {
+                return apperr.NotFound("hall not found")
+            }
+            return apperr.Internal("failed to verify hall")
+        }
+    }
+    loc := loadTimezone(hall.Timezone)
 
     if body.StartsAt != nil && strings.TrimSpace(*body.StartsAt) != "" {
-        t, err := time.Parse(time.RFC3339, strings.TrimSpace(*body.StartsAt))
+        t, err := parseShowTime(strings.TrimSpace(*body.StartsAt), loc)
         if err != nil {
-            return c.JSON(http.StatusBadRequest, map[string]string{
-                "error": "Invalid starts_at format. Must be RFC3339 (e.g. 2025-08-09T10:55:13Z)",
-            })
+            return fieldError("starts_at", timestampHint)
         }
-        start = t.UTC().Format("2006-01-02 15:04:05") // normalize to UTC
+        start = t
         startChanged = true
     }
     if body.EndsAt != nil && strings.TrimSpace(*body.EndsAt) != "" {
-        t, err := time.Parse(time.RFC3339, strings.TrimSpace(*body.EndsAt))
+        t, err := parseShowTime(strings.TrimSpace(*body.EndsAt), loc)
         if err != nil {
-            return c.JSON(http.StatusBadRequest, map[string]string{
-                "error": "Invalid ends_at format. Must be RFC3339 (e.g. 2025-08-09T10:55:13Z)",
-            })
+            return fieldError("ends_at", timestampHint)
         }
-        end = t.UTC().Format("2006-01-02 15:04:05") // normalize to UTC
+        end = t
         endChanged = true
     }
 
@@ -283,47 +302,24 @@ func (h *OwnerHandler) UpdateShow(c echo.Context) error {
     // changes (start or end time) or the hall changes.  A hall change may also
     // require a conflict check even if the times remain the same.
     if hallChanged || startChanged || endChanged {
-        // Parse the start and end strings back into time.Time for validation.
-        ts, err := time.Parse("2006-01-02 15:04:05", start)
-        if err != nil {
-            return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid starts_at"})
-        }
-        te, err := time.Parse("2006-01-02 15:04:05", end)
-        if err != nil {
-            return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid ends_at"})
-        }
         // If the schedule has been modified, verify that end occurs after start.
         if startChanged || endChanged {
-            if !te.After(ts) {
-                return c.JSON(http.StatusBadRequest, map[string]string{"error": "ends_at must be after starts_at"})
+            if !end.After(start) {
+                return fieldError("ends_at", "must be after starts_at")
             }
         }
-        // When the hall is being changed, ensure the new hall exists and is owned
-        // by the caller.  This prevents moving a show to a hall owned by someone else.
-        if hallChanged {
-            if newHallID == 0 {
-                return c.JSON(http.StatusBadRequest, map[string]string{"error": "hall_id is required"})
-            }
-            if _, err := h.HallRepo.GetByIDAndOwner(c.Request().Context(), newHallID, ownerID); err != nil {
-                if err == repository.ErrHallNotFound {
-                    return c.JSON(http.StatusNotFound, map[string]string{"error": "hall not found"})
-                }
-                return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to verify hall"})
+        // A new start time must lie in the cinema's scheduling window.
+        if startChanged {
+            if err := h.Shows.CheckWindow(c.Request().Context(), hall, start); err != nil {
+                return scheduleError(err)
             }
         }
-        // Check for overlapping shows in the target hall.  Use newHallID when the
-        // hall is changing or the current hall otherwise.  Always exclude the
-        // show being updated to allow it to overlap with itself.
-        targetHallID := newHallID
-        overlaps, err := h.ShowRepo.FindOverlappingExcluding(c.Request().Context(), targetHallID, cur.ID, start, end)
+        // The new schedule (or the new hall's cinema) must not touch a
+        // blackout date or overlap other shows in the target hall.  The
+        // show being updated is excluded so it cannot overlap itself.
+        multiplierPct, err = h.Shows.CheckSchedule(c.Request().Context(), hall, cur.ID, start, end)
         if err != nil {
-            return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to check overlapping shows"})
-        }
-        if len(overlaps) > 0 {
-            return c.JSON(http.StatusConflict, map[string]any{
-                "error":    "show time overlaps with existing show",
-                "overlaps": overlaps,
-            })
+            return scheduleError(err)
         }
     }
 
@@ -332,6 +328,16 @@ func (h *OwnerHandler) UpdateShow(c echo.Context) error {
 		price = *body.BasePriceCents
 	}
 
+	holdTTL := cur.HoldTTLSec
+	if body.HoldTTLSec != nil {
+		switch {
+		case *body.HoldTTLSec == 0:
+			holdTTL = nil
+		default:
+			holdTTL = body.HoldTTLSec
+		}
+	}
+
 	status := cur.Status
 	if body.Status != nil {
 		s := strings.ToUpper(strings.TrimSpace(*body.Status))
@@ -339,15 +345,23 @@ func (h *OwnerHandler) UpdateShow(c echo.Context) error {
 		case "SCHEDULED", "CANCELLED", "FINISHED":
 			status = s
 		default:
-			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid status"})
+			return fieldError("status", "must be one of SCHEDULED, CANCELLED, FINISHED")
+		}
+		// Cancelling must void reservations and notify customers, which
+		// only POST /v1/shows/:id/cancel does; cancellation is final.
+		switch {
+		case status == "CANCELLED" && cur.Status != "CANCELLED":
+			return fieldError("status", "use POST /v1/shows/:id/cancel to cancel a show")
+		case status != "CANCELLED" && cur.Status == "CANCELLED":
+			return fieldError("status", "cancelled shows cannot be reopened")
 		}
 	}
 
     // 🔒 guard: if nothing changed (and hall remains the same), do not update.  A
     // hall change alone counts as a modification even when other fields are
     // identical.
-    if !hallChanged && title == cur.Title && start == cur.StartsAt && end == cur.EndsAt && price == cur.BasePriceCents && status == cur.Status {
-        return c.JSON(http.StatusConflict, map[string]string{"error": "no changes"})
+    if !hallChanged && title == cur.Title && start.Equal(cur.StartsAt) && end.Equal(cur.EndsAt) && price == cur.BasePriceCents && status == cur.Status && sameHoldTTL(holdTTL, cur.HoldTTLSec) {
+        return apperr.Conflict(apperr.CodeNoChanges, "no changes")
     }
 
     if hallChanged {
@@ -356,12 +370,12 @@ func (h *OwnerHandler) UpdateShow(c echo.Context) error {
         // before beginning the transaction.  If seat lookup fails, abort early.
         seats, err := h.SeatRepo.GetByHall(c.Request().Context(), newHallID)
         if err != nil {
-            return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to load seats"})
+            return apperr.Internal("failed to load seats")
         }
         ctx := c.Request().Context()
         tx, err := h.ShowRepo.DB().BeginTx(ctx, nil)
         if err != nil {
-            return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to start transaction"})
+            return apperr.Internal("failed to start transaction")
         }
         committed := false
         defer func() {
@@ -373,39 +387,46 @@ func (h *OwnerHandler) UpdateShow(c echo.Context) error {
         // updated_at implicitly via CURRENT_TIMESTAMP.  Ownership of the show
         // was previously verified via cur.HallID; the new hall's ownership was
         // validated above.
-        const uq = `UPDATE shows SET hall_id = ?, title = ?, starts_at = ?, ends_at = ?, base_price_cents = ?, status = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`
-        if _, err = tx.ExecContext(ctx, uq, newHallID, title, start, end, price, status, cur.ID); err != nil {
-            return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to update show"})
+        const uq = `UPDATE shows SET hall_id = ?, title = ?, starts_at = ?, ends_at = ?, base_price_cents = ?, status = ?, hold_ttl_sec = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`
+        if _, err = tx.ExecContext(ctx, uq, newHallID, title, start, end, price, status, holdTTL, cur.ID); err != nil {
+            return apperr.Internal("failed to update show")
         }
+        cache.CatalogChanged(ctx)
         // Remove all existing show seats for this show.  They are no longer
         // relevant because the hall has changed.
         if _, err = tx.ExecContext(ctx, `DELETE FROM show_seats WHERE show_id = ?`, cur.ID); err != nil {
-            return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to clear show seats"})
+            return apperr.Internal("failed to clear show seats")
         }
         // Build new show_seats for the target hall.  Each seat is marked as
-        // FREE and priced according to the potentially updated base price.
+        // FREE and priced according to the potentially updated base price
+        // and the special-date multiplier.
+        seatPrice := repository.ApplyPriceMultiplier(price, multiplierPct)
         ss := make([]repository.ShowSeat, 0, len(seats))
         for _, seat := range seats {
             ss = append(ss, repository.ShowSeat{
                 ShowID:     cur.ID,
                 SeatID:     seat.ID,
                 Status:     "FREE",
-                PriceCents: price,
+                PriceCents: seatPrice,
                 Version:    1,
             })
         }
         if err = h.ShowSeatRepo.CreateBulkTx(ctx, tx, ss); err != nil {
-            return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to create show seats"})
+            return apperr.Internal("failed to create show seats")
+        }
+        if err = h.ShowSeatRepo.SnapshotTx(ctx, tx, cur.ID, repository.SnapshotHallChanged); err != nil {
+            return apperr.Internal("failed to snapshot seat map")
         }
         if err = tx.Commit(); err != nil {
-            return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to commit transaction"})
+            return apperr.Internal("failed to commit transaction")
         }
         committed = true
         // Fetch and return the updated show record.  This will include the
         // updated hall ID and any DB-managed fields.
         fresh, err := h.ShowRepo.GetByID(ctx, cur.ID)
+        recordAudit(c, h.Audit, auditEvent("show.update", "show", cur.ID, hall.OwnerID), cur, fresh)
         if err != nil {
-            return c.JSON(http.StatusOK, &repository.Show{
+            partial := &repository.Show{
                 ID:             cur.ID,
                 HallID:         newHallID,
                 Title:          title,
@@ -413,8 +434,12 @@ func (h *OwnerHandler) UpdateShow(c echo.Context) error {
                 EndsAt:         end,
                 BasePriceCents: price,
                 Status:         status,
-            })
+                HoldTTLSec:     holdTTL,
+            }
+            localizeShow(loc, hall.Currency, partial)
+            return c.JSON(http.StatusOK, partial)
         }
+        localizeShow(loc, hall.Currency, fresh)
         return c.JSON(http.StatusOK, fresh)
     }
 
@@ -427,19 +452,30 @@ func (h *OwnerHandler) UpdateShow(c echo.Context) error {
         EndsAt:         end,
         BasePriceCents: price,
         Status:         status,
+        HoldTTLSec:     holdTTL,
     }
     if err := h.ShowRepo.UpdateByIDAndOwner(c.Request().Context(), upd, ownerID); err != nil {
         if errors.Is(err, repository.ErrNoChange) {
-            return c.JSON(http.StatusConflict, map[string]string{"error": "no changes"})
+            return apperr.Conflict(apperr.CodeNoChanges, "no changes")
         }
         if err == sql.ErrNoRows {
-            return c.JSON(http.StatusNotFound, map[string]string{"error": "show not found"})
+            return apperr.NotFound("show not found")
         }
-        return c.JSON(http.StatusInternalServerError, map[string]string{"error": "update failed"})
+        return apperr.Internal("update failed")
     }
     fresh, err := h.ShowRepo.GetByID(c.Request().Context(), cur.ID)
+    recordAudit(c, h.Audit, auditEvent("show.update", "show", cur.ID, hall.OwnerID), cur, fresh)
     if err != nil {
-        return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to load show"})
+        return apperr.Internal("failed to load show")
     }
+    localizeShow(loc, hall.Currency, fresh)
     return c.JSON(http.StatusOK, fresh)
 }
+
+// sameHoldTTL reports whether two optional hold TTL values are equal.
+func sameHoldTTL(a, b *uint32) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
@@ -0,0 +1,169 @@
+package handler
+
+// This file lets owners block seats of a single show, for example for
+// maintenance or social distancing, without deactivating the physical
+// seat for every show.  Blocked show seats have the status BLOCKED: they
+// cannot be held, are never picked for automatic holds or waitlist offers
+// and appear as BLOCKED in the public seat map.
+
+import (
+    "context"
+    "database/sql"
+    "errors"
+    "net/http"
+    "strconv"
+
+    "github.com/iliyamo/cinema-seat-reservation/internal/apperr"
+    "github.com/iliyamo/cinema-seat-reservation/internal/db"
+    "github.com/iliyamo/cinema-seat-reservation/internal/metrics"
+    "github.com/iliyamo/cinema-seat-reservation/internal/repository"
+    "github.com/labstack/echo/v4"
+)
+
+// maxBlockSeats bounds the seats of one block or unblock request.
+const maxBlockSeats = 1000
+
+// blockSeatsReq is the body of POST /v1/shows/:id/seats/block and
+// /unblock.
+type blockSeatsReq struct {
+    SeatIDs []uint64 `json:"seat_ids" validate:"required"`
+}
+
+// BlockShowSeats handles POST /v1/shows/:id/seats/block.  Every listed
+// seat must be FREE (or already BLOCKED); when any is held, reserved or
+// not part of the show nothing is blocked and the response is 400
+// SEAT_UNAVAILABLE listing those seats.  It responds 200 with the seats
+// that were newly blocked.
+func (h *OwnerHandler) BlockShowSeats(c echo.Context) error {
+    return h.setSeatsBlocked(c, true)
+}
+
+// UnblockShowSeats handles POST /v1/shows/:id/seats/unblock.  BLOCKED
+// seats among those listed become FREE again; other seats of the show are
+// left untouched, while seats outside the show respond 400
+// SEAT_UNAVAILABLE.  It responds 200 with the seats that were unblocked.
+func (h *OwnerHandler) UnblockShowSeats(c echo.Context) error {
+    return h.setSeatsBlocked(c, false)
+}
+
+// setSeatsBlocked implements BlockShowSeats and UnblockShowSeats.
+func (h *OwnerHandler) setSeatsBlocked(c echo.Context, block bool) error {
+    ownerID, err := getUserID(c)
+    if err != nil {
+        return apperr.Unauthorized("unauthorized")
+    }
+    showID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+    if err != nil || showID == 0 {
+        return apperr.BadRequest("invalid show id")
+    }
+    var body blockSeatsReq
+    if err := bindValid(c, &body); err != nil {
+        return err
+    }
+    // deduplicate seat IDs so each seat is reported once
+    seatIDs := make([]uint64, 0, len(body.SeatIDs))
+    seen := make(map[uint64]struct{})
+    for _, id := range body.SeatIDs {
+        if id == 0 {
+            continue
+        }
+        if _, ok := seen[id]; !ok {
+            seen[id] = struct{}{}
+            seatIDs = append(seatIDs, id)
+        }
+    }
+    if len(seatIDs) == 0 {
+        return apperr.BadRequest("no valid seat IDs provided")
+    }
+    if len(seatIDs) > maxBlockSeats {
+        return fieldError("seat_ids", "must list at most "+strconv.Itoa(maxBlockSeats)+" seats")
+    }
+    ctx := c.Request().Context()
+    show, err := h.ShowRepo.GetByID(ctx, showID)
+    if err != nil {
+        if errors.Is(err, repository.ErrShowNotFound) {
+            return apperr.NotFound("show not found")
+        }
+        return apperr.Internal("database error")
+    }
+    hall, err := h.HallRepo.GetByIDAndOwner(ctx, show.HallID, ownerID)
+    if err != nil {
+        if errors.Is(err, repository.ErrHallNotFound) {
+            return apperr.NotFound("show not found")
+        }
+        return apperr.Internal("failed to verify hall")
+    }
+    var (
+        changed []uint64
+        expired int
+    )
+    err = db.WithTx(ctx, h.ShowRepo.DB(), func(tx *sql.Tx) error {
+        var err error
+        changed, expired, err = h.setSeatsBlockedTx(ctx, tx, showID, seatIDs, block)
+        return err
+    })
+    if err != nil {
+        return txError(c, err)
+    }
+    metrics.HoldsExpired.Add(float64(expired), "request")
+    action, key := "show.block_seats", "blocked"
+    if !block {
+        action, key = "show.unblock_seats", "unblocked"
+        if len(changed) > 0 && h.Waitlist != nil {
+            h.Waitlist.Notify(showID)
+        }
+    }
+    if len(changed) > 0 {
+        recordAudit(c, h.Audit, auditEvent(action, "show", showID, hall.OwnerID), nil, echo.Map{"seat_ids": changed})
+    }
+    return c.JSON(http.StatusOK, echo.Map{"show_id": showID, key: changed})
+}
+
+// setSeatsBlockedTx locks the seats and moves them between FREE and
+// BLOCKED.  Expired holds of the show are released first so their seats
+// count as FREE.  It returns the seats whose status changed and the
+// number of expired holds.
+func (h *OwnerHandler) setSeatsBlockedTx(ctx context.Context, tx *sql.Tx, showID uint64, seatIDs []uint64, block bool) ([]uint64, int, error) {
+    expired := 0
+    if h.SeatHoldRepo != nil {
+        released, err := h.SeatHoldRepo.ExpireHoldsTx(ctx, tx, showID)
+        if err != nil {
+            return nil, 0, failTx("failed to cleanup expired holds", err)
+        }
+        if err := h.ShowSeatRepo.BulkUpdateStatusTx(ctx, tx, showID, released, "FREE"); err != nil {
+            return nil, 0, failTx("failed to cleanup expired holds", err)
+        }
+        expired = len(released)
+    }
+    locked, err := h.ShowSeatRepo.LockSeatsTx(ctx, tx, showID, seatIDs)
+    if err != nil {
+        return nil, 0, failTx("failed to lock seats", err)
+    }
+    changed := make([]uint64, 0, len(seatIDs))
+    unavailable := make([]uint64, 0)
+    for _, sid := range seatIDs {
+        ls, ok := locked[sid]
+        switch {
+        case !ok:
+            unavailable = append(unavailable, sid)
+        case block && ls.Status == "FREE" && ls.HeldBy == nil:
+            changed = append(changed, sid)
+        case block && ls.Status != "BLOCKED":
+            unavailable = append(unavailable, sid)
+        case !block && ls.Status == "BLOCKED":
+            changed = append(changed, sid)
+        }
+    }
+    if len(unavailable) > 0 {
+        return nil, 0, apperr.New(http.StatusBadRequest, apperr.CodeSeatUnavailable, "some seats are held, reserved or not part of the show").
+            WithDetails(echo.Map{"unavailable": unavailable})
+    }
+    status := "BLOCKED"
+    if !block {
+        status = "FREE"
+    }
+    if err := h.ShowSeatRepo.BulkUpdateStatusTx(ctx, tx, showID, changed, status); err != nil {
+        return nil, 0, failTx("failed to update seat status", err)
+    }
+    return changed, expired, nil
+}
@@ -0,0 +1,164 @@
+package handler
+
+// This file implements the show cancellation workflow.  Cancelling a show
+// voids its reservations, releases its seats and holds, closes its
+// waitlist and queues a notification for every affected customer, all in
+// one transaction.  Paid reservations become REFUND_PENDING and their
+// refunds are approved, to be processed like any other refund (see
+// owner_refund.go); cancelled shows can no longer be held or reopened.
+
+import (
+    "database/sql"
+    "errors"
+    "fmt"
+    "net/http"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/iliyamo/cinema-seat-reservation/internal/apperr"
+    "github.com/iliyamo/cinema-seat-reservation/internal/db"
+    "github.com/iliyamo/cinema-seat-reservation/internal/metrics"
+    "github.com/iliyamo/cinema-seat-reservation/internal/repository"
+    "github.com/labstack/echo/v4"
+)
+
+// cancelShowReq is the optional body of POST /v1/shows/:id/cancel.
+// Reason is included in the customers' notification.
+type cancelShowReq struct {
+    Reason string `json:"reason" validate:"max=500"`
+}
+
+// CancelShow handles POST /v1/shows/:id/cancel.  Only SCHEDULED shows can
+// be cancelled; other shows respond 409 CONFLICT.  The show becomes
+// CANCELLED, its holds are dropped, held and reserved seats return to
+// FREE, PENDING and unpaid reservations become CANCELLED and paid ones
+// REFUND_PENDING with an APPROVED refund, and waiting or offered
+// waitlist entries expire.  It responds 200 with the number of cancelled
+// reservations and the IDs of those awaiting a refund.
+func (h *OwnerHandler) CancelShow(c echo.Context) error {
+    ownerID, err := getUserID(c)
+    if err != nil {
+        return apperr.Unauthorized("unauthorized")
+    }
+    showID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+    if err != nil || showID == 0 {
+        return apperr.BadRequest("invalid show id")
+    }
+    if h.ReservationRepo == nil {
+        return apperr.NotImplemented("show cancellation is not available")
+    }
+    var body cancelShowReq
+    if err := bindValid(c, &body); err != nil {
+        return err
+    }
+    reason := strings.TrimSpace(body.Reason)
+    ctx := c.Request().Context()
+    show, err := h.ShowRepo.GetByID(ctx, showID)
+    if err != nil {
+        if errors.Is(err, repository.ErrShowNotFound) {
+            return apperr.NotFound("show not found")
+        }
+        return apperr.Internal("database error")
+    }
+    hall, err := h.HallRepo.GetByIDAndOwner(ctx, show.HallID, ownerID)
+    if err != nil {
+        if errors.Is(err, repository.ErrHallNotFound) {
+            return apperr.NotFound("show not found")
+        }
+        return apperr.Internal("failed to verify hall")
+    }
+    notCancellable := apperr.Conflict(apperr.CodeConflict, "only scheduled shows can be cancelled")
+    if show.Status != "SCHEDULED" {
+        return notCancellable
+    }
+    var cancelled []repository.CancelledReservation
+    err = db.WithTx(ctx, h.ShowRepo.DB(), func(tx *sql.Tx) error {
+        // Updating the show row first takes its lock, so holds placed
+        // concurrently either commit before it or see the show CANCELLED.
+        if err := h.ShowRepo.CancelTx(ctx, tx, showID); err != nil {
+            if errors.Is(err, repository.ErrConflict) {
+                return notCancellable
+            }
+            return failTx("failed to cancel show", err)
+        }
+        if h.Webhooks != nil {
+            if err := h.Webhooks.EnqueueShowCancelledTx(ctx, tx, showID, reason); err != nil {
+                return failTx("failed to queue webhooks", err)
+            }
+        }
+        if h.SeatHoldRepo != nil {
+            if _, err := h.SeatHoldRepo.DeleteByShowTx(ctx, tx, showID); err != nil {
+                return failTx("failed to release holds", err)
+            }
+        }
+        var err error
+        cancelled, err = h.ReservationRepo.CancelByShowTx(ctx, tx, showID)
+        if err != nil {
+            return failTx("failed to cancel reservations", err)
+        }
+        if h.RefundRepo != nil {
+            if err := h.RefundRepo.ApproveForShowTx(ctx, tx, showID, ownerID); err != nil {
+                return failTx("failed to approve refunds", err)
+            }
+        }
+        if err := h.ShowSeatRepo.FreeShowTx(ctx, tx, showID); err != nil {
+            return failTx("failed to update seat status", err)
+        }
+        if h.Waitlist != nil {
+            if err := h.Waitlist.Repo.CloseShowTx(ctx, tx, showID); err != nil {
+                return failTx("failed to close waitlist", err)
+            }
+        }
+        if h.Notifications != nil {
+            for _, r := range cancelled {
+                n := showCancelledNotification(show, loadTimezone(hall.Timezone), r, reason)
+                if err := h.Notifications.EnqueueTx(ctx, tx, &n); err != nil {
+                    return failTx("failed to queue notifications", err)
+                }
+            }
+        }
+        return nil
+    })
+    if err != nil {
+        return txError(c, err)
+    }
+    refunds := make([]uint64, 0)
+    for _, r := range cancelled {
+        if r.Status == "REFUND_PENDING" {
+            refunds = append(refunds, r.ID)
+        }
+    }
+    metrics.ReservationsCancelled.Add(float64(len(cancelled)), "show")
+    recordAudit(c, h.Audit, auditEvent("show.cancel", "show", showID, hall.OwnerID),
+        echo.Map{"status": show.Status},
+        echo.Map{"status": "CANCELLED", "reason": reason, "cancelled_reservations": len(cancelled), "refund_pending": refunds})
+    return c.JSON(http.StatusOK, echo.Map{
+        "show_id":                showID,
+        "status":                 "CANCELLED",
+        "cancelled_reservations": len(cancelled),
+        "refund_pending":         refunds,
+    })
+}
+
+// showCancelledNotification builds the message telling the owner of r
+// that show was cancelled.  The start time is given in loc, the time zone
+// of the show's cinema.
+func showCancelledNotification(show *repository.Show, loc *time.Location, r repository.CancelledReservation, reason string) repository.Notification {
+    var b strings.Builder
+    fmt.Fprintf(&b, "We are sorry: %q on %s has been cancelled, and your reservation #%d is void.\n", show.Title, show.StartsAt.In(loc).Format(time.RFC3339), r.ID)
+    if reason != "" {
+        fmt.Fprintf(&b, "\nReason: %s\n", reason)
+    }
+    if r.Status == "REFUND_PENDING" {
+        fmt.Fprintf(&b, "\nYour payment of %d.%02d will be refunded.\n", r.TotalAmountCents/100, r.TotalAmountCents%100)
+    }
+    resID := r.ID
+    return repository.Notification{
+        UserID:        r.UserID,
+        ReservationID: &resID,
+        Kind:          "show.cancelled",
+        Subject:       "Show cancelled: " + show.Title,
+        Body:          b.String(),
+    }
+}
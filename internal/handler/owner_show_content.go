@@ -0,0 +1,129 @@
+package handler
+
+import (
+    "errors"
+    "net/http"
+    "regexp"
+    "strconv"
+    "strings"
+
+    "github.com/iliyamo/cinema-seat-reservation/internal/apperr"
+    "github.com/iliyamo/cinema-seat-reservation/internal/repository"
+    "github.com/labstack/echo/v4"
+)
+
+// showContentReq is the body of PUT /v1/shows/:id/content.  It replaces
+// all of the show's content metadata; omitted fields are cleared.
+// min_age defaults to the age the rating implies (18 for NC-17, N for
+// N+, otherwise none).
+type showContentReq struct {
+    Rating   *string `json:"rating" validate:"oneof=G PG PG-13 R NC-17 0+ 6+ 12+ 16+ 18+"`
+    Language *string `json:"language" validate:"max=35"`
+    Format   *string `json:"format" validate:"oneof=2D 3D IMAX"`
+    MinAge   *uint8  `json:"min_age" validate:"max=21"`
+}
+
+// languageTag matches BCP 47 language tags such as en, fa-IR or zh-Hant.
+var languageTag = regexp.MustCompile(`^[A-Za-z]{2,3}(-[A-Za-z0-9]{2,8})*$`)
+
+// GetShowContent handles GET /v1/shows/:id/content and returns the show's
+// rating, language, format and minimum age.
+func (h *OwnerHandler) GetShowContent(c echo.Context) error {
+    showID, _, err := h.ownedShow(c)
+    if err != nil {
+        return err
+    }
+    sc, err := h.ShowRepo.Content(c.Request().Context(), showID)
+    if err != nil {
+        return apperr.Internal("database error")
+    }
+    return c.JSON(http.StatusOK, sc)
+}
+
+// UpdateShowContent handles PUT /v1/shows/:id/content.  A min_age above
+// zero makes the show age-restricted: confirming seats then requires a
+// date of birth (PUT /v1/me/date-of-birth) showing the customer is old
+// enough on the day of the show.  It responds like GetShowContent.
+func (h *OwnerHandler) UpdateShowContent(c echo.Context) error {
+    var body showContentReq
+    if err := bindValid(c, &body); err != nil {
+        return err
+    }
+    showID, hall, err := h.ownedShow(c)
+    if err != nil {
+        return err
+    }
+    after := &repository.ShowContent{ShowID: showID}
+    if body.Rating != nil {
+        r := strings.ToUpper(strings.TrimSpace(*body.Rating))
+        after.Rating = &r
+        after.MinAge = ratingMinAge(r)
+    }
+    if body.Language != nil {
+        lang := strings.TrimSpace(*body.Language)
+        if !languageTag.MatchString(lang) {
+            return fieldError("language", "must be a language tag such as en or fa-IR")
+        }
+        after.Language = &lang
+    }
+    if body.Format != nil {
+        f := strings.ToUpper(strings.TrimSpace(*body.Format))
+        after.Format = &f
+    }
+    if body.MinAge != nil {
+        after.MinAge = *body.MinAge
+    }
+    ctx := c.Request().Context()
+    before, err := h.ShowRepo.Content(ctx, showID)
+    if err != nil {
+        return apperr.Internal("database error")
+    }
+    if err := h.ShowRepo.SetContent(ctx, after); err != nil {
+        return apperr.Internal("update failed")
+    }
+    recordAudit(c, h.Audit, auditEvent("show.content", "show", showID, hall.OwnerID), before, after)
+    return c.JSON(http.StatusOK, after)
+}
+
+// ratingMinAge returns the minimum age a rating implies: 18 for NC-17 and
+// N for the N+ ratings.
+func ratingMinAge(rating string) uint8 {
+    if rating == "NC-17" {
+        return 18
+    }
+    if age, ok := strings.CutSuffix(rating, "+"); ok {
+        if n, err := strconv.ParseUint(age, 10, 8); err == nil {
+            return uint8(n)
+        }
+    }
+    return 0
+}
+
+// ownedShow returns the ID of the show in the :id parameter and the
+// show's hall, which must be managed by the caller.
+func (h *OwnerHandler) ownedShow(c echo.Context) (uint64, *repository.Hall, error) {
+    ownerID, err := getUserID(c)
+    if err != nil {
+        return 0, nil, apperr.Unauthorized("unauthorized")
+    }
+    showID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+    if err != nil || showID == 0 {
+        return 0, nil, apperr.BadRequest("invalid show id")
+    }
+    ctx := c.Request().Context()
+    show, err := h.ShowRepo.GetByID(ctx, showID)
+    if err != nil {
+        if errors.Is(err, repository.ErrShowNotFound) {
+            return 0, nil, apperr.NotFound("show not found")
+        }
+        return 0, nil, apperr.Internal("database error")
+    }
+    hall, err := h.HallRepo.GetByIDAndOwner(ctx, show.HallID, ownerID)
+    if err != nil {
+        if errors.Is(err, repository.ErrHallNotFound) {
+            return 0, nil, apperr.NotFound("show not found")
+        }
+        return 0, nil, apperr.Internal("failed to verify hall")
+    }
+    return showID, hall, nil
+}
@@ -0,0 +1,72 @@
+package handler
+
+import (
+    "database/sql"
+    "errors"
+    "net/http"
+    "strconv"
+
+    "github.com/iliyamo/cinema-seat-reservation/internal/apperr"
+    "github.com/iliyamo/cinema-seat-reservation/internal/db"
+    "github.com/iliyamo/cinema-seat-reservation/internal/repository"
+    "github.com/labstack/echo/v4"
+)
+
+// ListShowHolds handles GET /v1/owner/shows/:id/holds.  It lists the
+// unexpired seat holds of one of the owner's shows with the seat, the
+// user holding it and when the hold expires, so stuck holds can be found
+// and released with ReleaseShowHold.
+func (h *OwnerHandler) ListShowHolds(c echo.Context) error {
+    if h.SeatHoldRepo == nil {
+        return apperr.NotImplemented("hold inspection is not available")
+    }
+    showID, _, err := h.ownedShow(c)
+    if err != nil {
+        return err
+    }
+    holds, err := h.SeatHoldRepo.ActiveHoldsByShow(c.Request().Context(), showID)
+    if err != nil {
+        return apperr.Internal("database error")
+    }
+    return c.JSON(http.StatusOK, echo.Map{"show_id": showID, "holds": holds})
+}
+
+// ReleaseShowHold handles DELETE /v1/owner/shows/:id/holds/:hold_id.  It
+// deletes one hold of the owner's show, expired or not, and frees its
+// seat in the same transaction, then offers the seat to the show's
+// waitlist.  The released hold is recorded in the audit log.  It responds
+// 204, or 404 when the show has no such hold.
+func (h *OwnerHandler) ReleaseShowHold(c echo.Context) error {
+    if h.SeatHoldRepo == nil {
+        return apperr.NotImplemented("hold release is not available")
+    }
+    showID, hall, err := h.ownedShow(c)
+    if err != nil {
+        return err
+    }
+    holdID, err := strconv.ParseUint(c.Param("hold_id"), 10, 64)
+    if err != nil || holdID == 0 {
+        return apperr.BadRequest("invalid hold id")
+    }
+    ctx := c.Request().Context()
+    var released *repository.SeatHoldRecord
+    err = db.WithTx(ctx, h.ShowSeatRepo.DB(), func(tx *sql.Tx) error {
+        var err error
+        released, err = h.SeatHoldRepo.ReleaseByIDTx(ctx, tx, showID, holdID)
+        if err != nil {
+            return err
+        }
+        return h.ShowSeatRepo.BulkUpdateStatusTx(ctx, tx, showID, []uint64{released.SeatID}, "FREE")
+    })
+    if err != nil {
+        if errors.Is(err, repository.ErrHoldNotFound) {
+            return apperr.NotFound("hold not found")
+        }
+        return apperr.Internal("release failed")
+    }
+    recordAudit(c, h.Audit, auditEvent("show.hold_release", "show", showID, hall.OwnerID), released, nil)
+    if h.Waitlist != nil {
+        h.Waitlist.Notify(showID)
+    }
+    return c.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,182 @@
+package handler
+
+// This file implements bulk show import.  Owners upload a schedule as CSV
+// and every row is scheduled on its own through scheduleShow, so one bad
+// row does not prevent the others and the response reports the outcome
+// of each row.
+
+import (
+    "encoding/csv"
+    "errors"
+    "io"
+    "net/http"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/iliyamo/cinema-seat-reservation/internal/apperr"
+    "github.com/iliyamo/cinema-seat-reservation/internal/repository"
+    "github.com/iliyamo/cinema-seat-reservation/internal/service"
+    "github.com/labstack/echo/v4"
+)
+
+const (
+    maxImportRows  = 500     // data rows accepted per import
+    maxImportBytes = 1 << 20 // size limit of the uploaded CSV
+)
+
+// importColumns are the CSV columns of a show import.  base_price_cents
+// may be left empty (0); the other columns are required.
+var importColumns = []string{"hall_id", "title", "starts_at", "ends_at", "base_price_cents"}
+
+// importRowResult is the outcome of one CSV data row.  Row counts data
+// rows from 1, excluding the header.
+type importRowResult struct {
+    Row     int         `json:"row"`
+    Status  string      `json:"status"` // "created" or "error"
+    ShowID  uint64      `json:"show_id,omitempty"`
+    Code    apperr.Code `json:"code,omitempty"`
+    Message string      `json:"message,omitempty"`
+    Details interface{} `json:"details,omitempty"`
+}
+
+// ImportShows handles POST /v1/owner/shows/import.  The CSV is sent either
+// as the request body (Content-Type text/csv) or as the "file" field of a
+// multipart form.  The first line is a header naming the columns
+// hall_id, title, starts_at, ends_at and base_price_cents in any order;
+// times are RFC3339, or local to the hall's cinema without an offset.
+// Each row is validated (format, hall ownership,
+// blackout dates and overlaps, including with earlier rows of the same
+// file) and its show and show seats are created in a transaction of its
+// own.  The response is 200 with a per-row report; malformed CSV, a
+// missing column or more than 500 rows reject the whole file with 400.
+func (h *OwnerHandler) ImportShows(c echo.Context) error {
+    ownerID, err := getUserID(c)
+    if err != nil {
+        return apperr.Unauthorized("unauthorized")
+    }
+    var src io.Reader = c.Request().Body
+    if strings.HasPrefix(c.Request().Header.Get(echo.HeaderContentType), echo.MIMEMultipartForm) {
+        fh, err := c.FormFile("file")
+        if err != nil {
+            return fieldError("file", "is required")
+        }
+        f, err := fh.Open()
+        if err != nil {
+            return apperr.BadRequest("failed to read upload")
+        }
+        defer f.Close()
+        src = f
+    }
+    r := csv.NewReader(io.LimitReader(src, maxImportBytes))
+    r.TrimLeadingSpace = true
+    header, err := r.Read()
+    if err != nil {
+        return apperr.BadRequest("missing CSV header")
+    }
+    col := make(map[string]int, len(header))
+    for i, name := range header {
+        col[strings.ToLower(strings.TrimSpace(name))] = i
+    }
+    for _, name := range importColumns {
+        if _, ok := col[name]; !ok && name != "base_price_cents" {
+            return apperr.BadRequest("missing CSV column " + name)
+        }
+    }
+    records, err := r.ReadAll()
+    if err != nil {
+        return apperr.BadRequest("invalid CSV: " + err.Error())
+    }
+    if len(records) > maxImportRows {
+        return apperr.BadRequest("at most " + strconv.Itoa(maxImportRows) + " rows can be imported at once")
+    }
+
+    ctx := c.Request().Context()
+    halls := make(map[uint64]*repository.Hall)
+    seats := make(map[uint64][]repository.Seat)
+    results := make([]importRowResult, 0, len(records))
+    created := 0
+    for i, rec := range records {
+        res := importRowResult{Row: i + 1, Status: "error"}
+        field := func(name string) string {
+            if j, ok := col[name]; ok && j < len(rec) {
+                return strings.TrimSpace(rec[j])
+            }
+            return ""
+        }
+        spec, start, end, err := h.parseImportRow(c, ownerID, field, halls)
+        if err == nil {
+            if _, ok := seats[spec.Hall.ID]; !ok {
+                hallSeats, errSeats := h.SeatRepo.GetByHall(ctx, spec.Hall.ID)
+                if errSeats != nil {
+                    return apperr.Internal("failed to load seats")
+                }
+                seats[spec.Hall.ID] = hallSeats
+            }
+            var show *repository.Show
+            if show, err = h.scheduleShow(c, spec, seats[spec.Hall.ID], start, end); err == nil {
+                res.Status, res.ShowID = "created", show.ID
+                created++
+            }
+        }
+        if err != nil {
+            var ae *apperr.Error
+            if !errors.As(err, &ae) || ae.Status >= http.StatusInternalServerError {
+                return err
+            }
+            res.Code, res.Message, res.Details = ae.Code, ae.Message, ae.Details
+        }
+        results = append(results, res)
+    }
+    return c.JSON(http.StatusOK, echo.Map{
+        "created": created,
+        "failed":  len(results) - created,
+        "rows":    results,
+    })
+}
+
+// parseImportRow validates one CSV row and resolves its hall, which must
+// be managed by the caller.  Halls are cached across rows.
+func (h *OwnerHandler) parseImportRow(c echo.Context, ownerID uint64, field func(string) string, halls map[uint64]*repository.Hall) (service.ShowSpec, time.Time, time.Time, error) {
+    var spec service.ShowSpec
+    hallID, err := strconv.ParseUint(field("hall_id"), 10, 64)
+    if err != nil || hallID == 0 {
+        return spec, time.Time{}, time.Time{}, fieldError("hall_id", "must be a hall ID")
+    }
+    spec.Title = field("title")
+    if spec.Title == "" || len(spec.Title) > 255 {
+        return spec, time.Time{}, time.Time{}, fieldError("title", "is required and at most 255 characters")
+    }
+    if p := field("base_price_cents"); p != "" {
+        price, err := strconv.ParseUint(p, 10, 32)
+        if err != nil {
+            return spec, time.Time{}, time.Time{}, fieldError("base_price_cents", "must be a non-negative integer")
+        }
+        spec.PriceCents = uint32(price)
+    }
+    hall, ok := halls[hallID]
+    if !ok {
+        hall, err = h.HallRepo.GetByIDAndOwner(c.Request().Context(), hallID, ownerID)
+        if err != nil {
+            if errors.Is(err, repository.ErrHallNotFound) {
+                return spec, time.Time{}, time.Time{}, apperr.NotFound("hall not found")
+            }
+            return spec, time.Time{}, time.Time{}, apperr.Internal("failed to verify hall")
+        }
+        halls[hallID] = hall
+    }
+    loc := loadTimezone(hall.Timezone)
+    start, err := parseShowTime(field("starts_at"), loc)
+    if err != nil {
+        return spec, time.Time{}, time.Time{}, fieldError("starts_at", timestampHint)
+    }
+    end, err := parseShowTime(field("ends_at"), loc)
+    if err != nil {
+        return spec, time.Time{}, time.Time{}, fieldError("ends_at", timestampHint)
+    }
+    if !end.After(start) {
+        return spec, time.Time{}, time.Time{}, fieldError("ends_at", "must be after starts_at")
+    }
+    spec.Hall = hall
+    return spec, start, end, nil
+}
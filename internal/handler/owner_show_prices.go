@@ -0,0 +1,105 @@
+package handler
+
+import (
+    "database/sql"
+    "errors"
+    "net/http"
+    "strconv"
+
+    "github.com/iliyamo/cinema-seat-reservation/internal/apperr"
+    "github.com/iliyamo/cinema-seat-reservation/internal/db"
+    "github.com/iliyamo/cinema-seat-reservation/internal/repository"
+    "github.com/labstack/echo/v4"
+)
+
+// showSeatPricesReq is the body of PATCH /v1/shows/:id/seats/prices.
+type showSeatPricesReq struct {
+    Selectors  []seatSelector `json:"selectors" validate:"required,max=100"`
+    PriceCents *uint32        `json:"price_cents" validate:"required"`
+}
+
+// UpdateShowSeatPrices handles PATCH /v1/shows/:id/seats/prices.  It sets
+// the price of the seats of one show matched by the selectors (see
+// UpdateSeatsBulk), for example to discount the front row of a single
+// screening; other shows of the hall keep their prices.  The seats are
+// locked and updated in one transaction.  When any of them is RESERVED
+// nothing changes and the response is 409 SEAT_UNAVAILABLE listing
+// them.  Held seats take the new price when their hold is confirmed.
+// It responds with the number of seats matched and repriced.
+func (h *OwnerHandler) UpdateShowSeatPrices(c echo.Context) error {
+    ownerID, err := getUserID(c)
+    if err != nil {
+        return apperr.Unauthorized("unauthorized")
+    }
+    showID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+    if err != nil || showID == 0 {
+        return apperr.BadRequest("invalid show id")
+    }
+    var body showSeatPricesReq
+    if err := bindValid(c, &body); err != nil {
+        return err
+    }
+    if errs := checkSeatSelectors(body.Selectors); len(errs) > 0 {
+        return apperr.Validation(errs)
+    }
+    ctx := c.Request().Context()
+    show, err := h.ShowRepo.GetByID(ctx, showID)
+    if err != nil {
+        if errors.Is(err, repository.ErrShowNotFound) {
+            return apperr.NotFound("show not found")
+        }
+        return apperr.Internal("database error")
+    }
+    hall, err := h.HallRepo.GetByIDAndOwner(ctx, show.HallID, ownerID)
+    if err != nil {
+        if errors.Is(err, repository.ErrHallNotFound) {
+            return apperr.NotFound("show not found")
+        }
+        return apperr.Internal("failed to verify hall")
+    }
+    seats, err := h.SeatRepo.GetByHall(ctx, hall.ID)
+    if err != nil {
+        return apperr.Internal("failed to load seats")
+    }
+    seatIDs, unknownRows, invalidIDs := selectSeats(seats, body.Selectors)
+    if len(unknownRows) > 0 || len(invalidIDs) > 0 {
+        return apperr.BadRequest("selectors match rows or seats not in this hall").
+            WithDetails(map[string]any{"unknown_rows": unknownRows, "invalid_seat_ids": invalidIDs})
+    }
+    var changed int64
+    err = db.WithTx(ctx, h.ShowRepo.DB(), func(tx *sql.Tx) error {
+        locked, err := h.ShowSeatRepo.LockSeatsTx(ctx, tx, showID, seatIDs)
+        if err != nil {
+            return failTx("failed to lock seats", err)
+        }
+        reserved := make([]uint64, 0)
+        for _, sid := range seatIDs {
+            if ls, ok := locked[sid]; ok && ls.Status == "RESERVED" {
+                reserved = append(reserved, sid)
+            }
+        }
+        if len(reserved) > 0 {
+            return apperr.Conflict(apperr.CodeSeatUnavailable, "some seats are already reserved").
+                WithDetails(echo.Map{"reserved": reserved})
+        }
+        changed, err = h.ShowSeatRepo.UpdatePricesTx(ctx, tx, showID, seatIDs, *body.PriceCents)
+        if err != nil {
+            return failTx("failed to update seat prices", err)
+        }
+        return nil
+    })
+    if err != nil {
+        return txError(c, err)
+    }
+    if changed > 0 {
+        recordAudit(c, h.Audit, auditEvent("show.seat_prices", "show", showID, hall.OwnerID), nil,
+            echo.Map{"seat_ids": seatIDs, "price_cents": *body.PriceCents})
+    }
+    return c.JSON(http.StatusOK, echo.Map{
+        "show_id":     showID,
+        "matched":     len(seatIDs),
+        "updated":     changed,
+        "price_cents": *body.PriceCents,
+        "seat_ids":    seatIDs,
+    })
+}
@@ -0,0 +1,52 @@
+package handler
+
+import (
+    "database/sql"
+    "net/http"
+
+    "github.com/iliyamo/cinema-seat-reservation/internal/apperr"
+    "github.com/iliyamo/cinema-seat-reservation/internal/db"
+    "github.com/iliyamo/cinema-seat-reservation/internal/metrics"
+    "github.com/iliyamo/cinema-seat-reservation/internal/repository"
+    "github.com/labstack/echo/v4"
+)
+
+// ReconcileShow handles POST /v1/owner/shows/:id/reconcile.  It checks
+// the status of every seat of one of the owner's shows against the
+// show's holds and reservations and repairs the seats that disagree, for
+// example a seat still RESERVED after its reservation was deleted.  The
+// response lists each repair with the seat's old and new status and why
+// it changed; an empty list means the show was consistent.  The same
+// check runs in the background every SEAT_RECONCILE_INTERVAL_SEC.
+func (h *OwnerHandler) ReconcileShow(c echo.Context) error {
+    showID, hall, err := h.ownedShow(c)
+    if err != nil {
+        return err
+    }
+    ctx := c.Request().Context()
+    var repairs []repository.SeatRepair
+    var checked int
+    err = db.WithTx(ctx, h.ShowSeatRepo.DB(), func(tx *sql.Tx) error {
+        var err error
+        repairs, checked, err = h.ShowSeatRepo.ReconcileTx(ctx, tx, showID)
+        return err
+    })
+    if err != nil {
+        return apperr.Internal("reconciliation failed")
+    }
+    if repairs == nil {
+        repairs = []repository.SeatRepair{}
+    }
+    if len(repairs) > 0 {
+        metrics.SeatsRepaired.Add(float64(len(repairs)), "owner")
+        recordAudit(c, h.Audit, auditEvent("show.reconcile", "show", showID, hall.OwnerID), nil, repairs)
+        if h.Waitlist != nil {
+            h.Waitlist.Notify(showID)
+        }
+    }
+    return c.JSON(http.StatusOK, echo.Map{
+        "show_id":  showID,
+        "checked":  checked,
+        "repaired": repairs,
+    })
+}
@@ -0,0 +1,172 @@
+package handler
+
+// This file lets owners schedule a show more than once: POST
+// /v1/shows/:id/duplicate copies an existing show to a new start time, and
+// repeat/until on POST /v1/shows (or on a duplicate) generates a daily or
+// weekly series.  Every occurrence is checked on its own, so a clash with
+// another show or a blackout date skips that occurrence instead of failing
+// the series.
+
+import (
+    "errors"
+    "net/http"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/iliyamo/cinema-seat-reservation/internal/apperr"
+    "github.com/iliyamo/cinema-seat-reservation/internal/repository"
+    "github.com/iliyamo/cinema-seat-reservation/internal/service"
+    "github.com/labstack/echo/v4"
+)
+
+// maxRecurringShows caps the number of occurrences one request may create.
+const maxRecurringShows = 366
+
+// duplicateShowReq is the body of POST /v1/shows/:id/duplicate.
+type duplicateShowReq struct {
+    StartsAt string `json:"starts_at" validate:"required,timestamp"`
+    Repeat   string `json:"repeat" validate:"oneof=daily weekly"`
+    Until    string `json:"until"`
+}
+
+// skippedShow is an occurrence of a series that was not created.
+type skippedShow struct {
+    StartsAt string      `json:"starts_at"`
+    Code     apperr.Code `json:"code"`
+    Message  string      `json:"message"`
+    Details  interface{} `json:"details"`
+}
+
+// DuplicateShow handles POST /v1/shows/:id/duplicate.  It schedules a copy
+// of a show in the same hall with the same title, base price, hold TTL and
+// duration at starts_at.  Seat prices follow the cinema calendar of the
+// new date.  Without repeat it responds 201 with the new show, 422 when
+// starts_at is outside the scheduling window, or 409 on an overlap or
+// blackout date; with repeat it behaves like a repeating
+// POST /v1/shows.
+func (h *OwnerHandler) DuplicateShow(c echo.Context) error {
+    ownerID, err := getUserID(c)
+    if err != nil {
+        return apperr.Unauthorized("unauthorized")
+    }
+    id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+    if err != nil || id == 0 {
+        return apperr.BadRequest("invalid show id")
+    }
+    var body duplicateShowReq
+    if err := bindValid(c, &body); err != nil {
+        return err
+    }
+    ctx := c.Request().Context()
+    src, err := h.ShowRepo.GetByID(ctx, id)
+    if err != nil {
+        if errors.Is(err, repository.ErrShowNotFound) {
+            return apperr.NotFound("show not found")
+        }
+        return apperr.Internal("failed to load show")
+    }
+    hall, err := h.HallRepo.GetByIDAndOwner(ctx, src.HallID, ownerID)
+    if err != nil {
+        if errors.Is(err, repository.ErrHallNotFound) {
+            return apperr.NotFound("show not found")
+        }
+        return apperr.Internal("failed to verify hall")
+    }
+    loc := loadTimezone(hall.Timezone)
+    startTime, err := parseShowTime(strings.TrimSpace(body.StartsAt), loc)
+    if err != nil {
+        return fieldError("starts_at", timestampHint)
+    }
+    endTime := startTime.Add(src.EndsAt.Sub(src.StartsAt))
+    spec := service.ShowSpec{Hall: hall, Title: src.Title, PriceCents: src.BasePriceCents, HoldTTLSec: src.HoldTTLSec}
+    if body.Repeat != "" {
+        return h.scheduleRecurring(c, spec, startTime, endTime, body.Repeat, body.Until)
+    }
+    seats, err := h.SeatRepo.GetByHall(ctx, hall.ID)
+    if err != nil {
+        return apperr.Internal("failed to load seats")
+    }
+    show, err := h.scheduleShow(c, spec, seats, startTime, endTime)
+    if err != nil {
+        return err
+    }
+    if fresh, err := h.ShowRepo.GetByID(ctx, show.ID); err == nil {
+        show = fresh
+    }
+    localizeShow(loc, hall.Currency, show)
+    return c.JSON(http.StatusCreated, show)
+}
+
+// scheduleRecurring schedules spec every day or week (repeat) starting at
+// startTime, for every occurrence that starts on or before the until date.
+// Days are those of the hall's cinema, and occurrences keep their local
+// start time across daylight saving changes.  The first and last occurrence
+// must lie in the cinema's scheduling window, or nothing is created.
+// Occurrences that overlap another show or fall on a blackout date
+// are skipped and reported with their error code; any other failure stops
+// the series, keeping the occurrences already created.  It responds 201
+// with the created shows and the skipped occurrences.
+func (h *OwnerHandler) scheduleRecurring(c echo.Context, spec service.ShowSpec, startTime, endTime time.Time, repeat, until string) error {
+    days := 1
+    if strings.EqualFold(repeat, "weekly") {
+        days = 7
+    }
+    if strings.TrimSpace(until) == "" {
+        return fieldError("until", "is required with repeat")
+    }
+    loc := loadTimezone(spec.Hall.Timezone)
+    lastDay, err := time.ParseInLocation(repository.DateLayout, strings.TrimSpace(until), loc)
+    if err != nil {
+        return fieldError("until", "must be a date (YYYY-MM-DD)")
+    }
+    stop := lastDay.AddDate(0, 0, 1) // occurrences must start before the day after until
+    if !startTime.Before(stop) {
+        return fieldError("until", "must not be before starts_at")
+    }
+    if n := int(stop.Sub(startTime)/(time.Duration(days)*24*time.Hour)) + 1; n > maxRecurringShows {
+        return fieldError("until", "must not create more than "+strconv.Itoa(maxRecurringShows)+" shows")
+    }
+    first := startTime.In(loc)
+    last := first
+    for i := 1; first.AddDate(0, 0, i*days).UTC().Before(stop); i++ {
+        last = first.AddDate(0, 0, i*days)
+    }
+    if err := h.Shows.CheckWindow(c.Request().Context(), spec.Hall, startTime); err != nil {
+        return scheduleError(err)
+    }
+    var tooFar *service.ScheduleWindowError
+    if err := h.Shows.CheckWindow(c.Request().Context(), spec.Hall, last.UTC()); errors.As(err, &tooFar) {
+        return windowError("until", tooFar)
+    } else if err != nil {
+        return scheduleError(err)
+    }
+    seats, err := h.SeatRepo.GetByHall(c.Request().Context(), spec.Hall.ID)
+    if err != nil {
+        return apperr.Internal("failed to load seats")
+    }
+    duration := endTime.Sub(startTime)
+    created := make([]*repository.Show, 0)
+    skipped := make([]skippedShow, 0)
+    for i := 0; ; i++ {
+        at := first.AddDate(0, 0, i*days).UTC()
+        if !at.Before(stop) {
+            break
+        }
+        show, err := h.scheduleShow(c, spec, seats, at, at.Add(duration))
+        if err != nil {
+            var ae *apperr.Error
+            if errors.As(err, &ae) && ae.Status == http.StatusConflict {
+                skipped = append(skipped, skippedShow{StartsAt: at.In(loc).Format(time.RFC3339), Code: ae.Code, Message: ae.Message, Details: ae.Details})
+                continue
+            }
+            return err
+        }
+        localizeShow(loc, spec.Hall.Currency, show)
+        created = append(created, show)
+    }
+    return c.JSON(http.StatusCreated, echo.Map{
+        "created": created,
+        "skipped": skipped,
+    })
+}
@@ -0,0 +1,60 @@
+package handler
+
+import (
+    "errors"
+    "net/http"
+    "strconv"
+
+    "github.com/iliyamo/cinema-seat-reservation/internal/apperr"
+    "github.com/iliyamo/cinema-seat-reservation/internal/repository"
+    "github.com/labstack/echo/v4"
+)
+
+// GetSeatMapSnapshots handles GET /v1/owner/shows/:id/seatmap-snapshot.
+// It returns the immutable seat map snapshots of one of the owner's
+// shows, oldest first: the map the show was scheduled with and one for
+// every later rebuild of its show seats, each with the seats' positions,
+// types, statuses and prices at that moment.  ?version=N returns only
+// that snapshot (404 when it does not exist).
+func (h *OwnerHandler) GetSeatMapSnapshots(c echo.Context) error {
+    ownerID, err := getUserID(c)
+    if err != nil {
+        return apperr.Unauthorized("unauthorized")
+    }
+    showID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+    if err != nil || showID == 0 {
+        return apperr.BadRequest("invalid show id")
+    }
+    version := 0
+    if v := c.QueryParam("version"); v != "" {
+        if version, err = strconv.Atoi(v); err != nil || version < 1 {
+            return fieldError("version", "must be a positive integer")
+        }
+    }
+    ctx := c.Request().Context()
+    show, err := h.ShowRepo.GetByID(ctx, showID)
+    if err != nil {
+        if errors.Is(err, repository.ErrShowNotFound) {
+            return apperr.NotFound("show not found")
+        }
+        return apperr.Internal("database error")
+    }
+    if _, err := h.HallRepo.GetByIDAndOwner(ctx, show.HallID, ownerID); err != nil {
+        if errors.Is(err, repository.ErrHallNotFound) {
+            return apperr.NotFound("show not found")
+        }
+        return apperr.Internal("failed to verify hall")
+    }
+    items, err := h.ShowSeatRepo.ListSnapshots(ctx, showID, version)
+    if err != nil {
+        return apperr.Internal("failed to load seat map snapshots")
+    }
+    if version > 0 && len(items) == 0 {
+        return apperr.NotFound("snapshot not found")
+    }
+    return c.JSON(http.StatusOK, echo.Map{
+        "show_id": showID,
+        "count":   len(items),
+        "items":   items,
+    })
+}
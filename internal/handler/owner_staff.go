@@ -0,0 +1,157 @@
+package handler
+
+// This file defines HTTP handlers for owners to delegate hall management to
+// staff.  A staff membership lets another user edit the granted halls,
+// schedule their shows and view or cancel their reservations; the
+// ownership checks in HallRepo, ShowRepo and ReservationRepo admit staff
+// for the halls they were granted.
+
+import (
+    "database/sql"
+    "errors"
+    "net/http"
+    "strconv"
+    "strings"
+
+    "github.com/iliyamo/cinema-seat-reservation/internal/apperr"
+    "github.com/iliyamo/cinema-seat-reservation/internal/repository"
+    "github.com/labstack/echo/v4"
+)
+
+// OwnerStaffHandler exposes staff management to owners.
+type OwnerStaffHandler struct {
+    StaffRepo  *repository.StaffRepo  // access to staff_memberships
+    CinemaRepo *repository.CinemaRepo // verifies cinema ownership
+    HallRepo   *repository.HallRepo   // verifies hall ownership
+    Users      *repository.UserRepo   // looks up and promotes invited users
+    Audit      *repository.AuditRepo  // optional; records grants and revocations in the audit log
+}
+
+// NewOwnerStaffHandler constructs an OwnerStaffHandler.  All repositories
+// must be non-nil.
+func NewOwnerStaffHandler(staffRepo *repository.StaffRepo, cinemaRepo *repository.CinemaRepo, hallRepo *repository.HallRepo, users *repository.UserRepo) *OwnerStaffHandler {
+    if staffRepo == nil || cinemaRepo == nil || hallRepo == nil || users == nil {
+        panic("nil repository passed to NewOwnerStaffHandler")
+    }
+    return &OwnerStaffHandler{StaffRepo: staffRepo, CinemaRepo: cinemaRepo, HallRepo: hallRepo, Users: users}
+}
+
+// inviteStaffReq is the body of POST /v1/owner/staff.
+type inviteStaffReq struct {
+    Email    string  `json:"email" validate:"required,email"`
+    CinemaID uint64  `json:"cinema_id" validate:"required"`
+    HallID   *uint64 `json:"hall_id"`
+}
+
+// InviteStaff handles POST /v1/owner/staff.  The body names an existing
+// user by email and a cinema owned by the caller; hall_id optionally
+// narrows the grant to one hall of that cinema.  A CUSTOMER is promoted to
+// STAFF (the new scopes apply from their next token refresh); owners and
+// admins cannot be made staff.  Returns 201 with the membership.
+func (h *OwnerStaffHandler) InviteStaff(c echo.Context) error {
+    ownerID, err := getUserID(c)
+    if err != nil {
+        return apperr.Unauthorized("unauthorized")
+    }
+    var body inviteStaffReq
+    if err := bindValid(c, &body); err != nil {
+        return err
+    }
+    ctx := c.Request().Context()
+    if _, err := h.CinemaRepo.GetByIDAndOwner(ctx, body.CinemaID, ownerID); err != nil {
+        if errors.Is(err, repository.ErrCinemaNotFound) {
+            return apperr.NotFound("cinema not found")
+        }
+        return apperr.Internal("could not verify cinema")
+    }
+    if body.HallID != nil {
+        hall, err := h.HallRepo.GetByIDAndOwner(ctx, *body.HallID, ownerID)
+        if err != nil {
+            if errors.Is(err, repository.ErrHallNotFound) {
+                return apperr.NotFound("hall not found")
+            }
+            return apperr.Internal("could not verify hall")
+        }
+        if hall.OwnerID != ownerID || hall.CinemaID == nil || *hall.CinemaID != body.CinemaID {
+            return fieldError("hall_id", "must be a hall of the given cinema")
+        }
+    }
+    user, err := h.Users.GetByEmail(ctx, body.Email)
+    if err != nil {
+        if errors.Is(err, sql.ErrNoRows) {
+            return apperr.NotFound("user not found")
+        }
+        return apperr.Internal("could not load user")
+    }
+    if user.ID == ownerID {
+        return apperr.BadRequest("cannot add yourself as staff")
+    }
+    switch strings.ToUpper(user.Role) {
+    case "STAFF":
+    case "CUSTOMER":
+        if err := h.Users.SetRole(ctx, user.ID, "STAFF"); err != nil {
+            return apperr.Internal("could not update user role")
+        }
+    default:
+        return apperr.Conflict(apperr.CodeConflict, "only customers can be added as staff")
+    }
+    m := &repository.StaffMembership{
+        OwnerID:     ownerID,
+        StaffUserID: user.ID,
+        StaffEmail:  user.Email,
+        CinemaID:    body.CinemaID,
+        HallID:      body.HallID,
+    }
+    if err := h.StaffRepo.Create(ctx, m); err != nil {
+        if errors.Is(err, repository.ErrStaffExists) {
+            return apperr.Conflict(apperr.CodeAlreadyExists, "staff membership already exists")
+        }
+        return apperr.Internal("failed to create staff membership")
+    }
+    recordAudit(c, h.Audit, auditEvent("staff.invite", "staff_membership", m.ID, ownerID), nil, m)
+    return c.JSON(http.StatusCreated, m)
+}
+
+// ListStaff handles GET /v1/owner/staff and returns the memberships the
+// authenticated owner has granted.
+func (h *OwnerStaffHandler) ListStaff(c echo.Context) error {
+    ownerID, err := getUserID(c)
+    if err != nil {
+        return apperr.Unauthorized("unauthorized")
+    }
+    items, err := h.StaffRepo.ListByOwner(c.Request().Context(), ownerID)
+    if err != nil {
+        return apperr.Internal("failed to load staff")
+    }
+    return c.JSON(http.StatusOK, echo.Map{
+        "items": items,
+        "count": len(items),
+    })
+}
+
+// RemoveStaff handles DELETE /v1/owner/staff/:id.  It revokes one
+// membership; a staff user left without memberships reverts to CUSTOMER.
+// Returns 204, or 404 when the owner granted no such membership.
+func (h *OwnerStaffHandler) RemoveStaff(c echo.Context) error {
+    ownerID, err := getUserID(c)
+    if err != nil {
+        return apperr.Unauthorized("unauthorized")
+    }
+    id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+    if err != nil || id == 0 {
+        return apperr.BadRequest("invalid id")
+    }
+    ctx := c.Request().Context()
+    staffID, err := h.StaffRepo.Delete(ctx, ownerID, id)
+    if err != nil {
+        if errors.Is(err, sql.ErrNoRows) {
+            return apperr.NotFound("staff membership not found")
+        }
+        return apperr.Internal("failed to remove staff membership")
+    }
+    if n, err := h.StaffRepo.CountForStaff(ctx, staffID); err == nil && n == 0 {
+        _ = h.Users.SetRole(ctx, staffID, "CUSTOMER")
+    }
+    recordAudit(c, h.Audit, auditEvent("staff.remove", "staff_membership", id, ownerID), echo.Map{"staff_user_id": staffID}, nil)
+    return c.NoContent(http.StatusNoContent)
+}
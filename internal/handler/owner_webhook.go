@@ -0,0 +1,200 @@
+package handler
+
+// This file defines HTTP handlers for owners to subscribe URLs to booking
+// events.  Deliveries are queued with the change they report and posted
+// by service.WebhookDispatcher, signed with the subscription's secret;
+// their outcomes form the delivery log served here.
+
+import (
+    "database/sql"
+    "errors"
+    "net/http"
+    "net/url"
+    "strconv"
+    "strings"
+
+    "github.com/iliyamo/cinema-seat-reservation/internal/apperr"
+    "github.com/iliyamo/cinema-seat-reservation/internal/repository"
+    "github.com/iliyamo/cinema-seat-reservation/internal/service"
+    "github.com/iliyamo/cinema-seat-reservation/internal/utils"
+    "github.com/labstack/echo/v4"
+)
+
+// OwnerWebhookHandler exposes webhook subscriptions and their delivery log
+// to owners.
+type OwnerWebhookHandler struct {
+    Repo  *repository.WebhookRepo // access to webhook_subscriptions and webhook_deliveries
+    Audit *repository.AuditRepo   // optional; records subscriptions and removals in the audit log
+}
+
+// NewOwnerWebhookHandler constructs an OwnerWebhookHandler.  The
+// repository must be non-nil.
+func NewOwnerWebhookHandler(repo *repository.WebhookRepo) *OwnerWebhookHandler {
+    if repo == nil {
+        panic("nil repository passed to NewOwnerWebhookHandler")
+    }
+    return &OwnerWebhookHandler{Repo: repo}
+}
+
+// createWebhookReq is the body of POST /v1/owner/webhooks.
+type createWebhookReq struct {
+    URL    string   `json:"url" validate:"required,max=2048"`
+    Events []string `json:"events" validate:"max=10"`
+    Secret string   `json:"secret" validate:"max=128"`
+}
+
+// CreateWebhook handles POST /v1/owner/webhooks.  url must be an absolute
+// http or https URL whose host resolves to public addresses only, not
+// loopback, private or link-local ones; events defaults to every event.  secret, at least 16
+// characters, signs the deliveries; one is generated when omitted.
+// Returns 201 with the subscription and, in "secret", the secret, which
+// is not shown again.
+func (h *OwnerWebhookHandler) CreateWebhook(c echo.Context) error {
+    ownerID, err := getUserID(c)
+    if err != nil {
+        return apperr.Unauthorized("unauthorized")
+    }
+    var body createWebhookReq
+    if err := bindValid(c, &body); err != nil {
+        return err
+    }
+    u, err := url.Parse(strings.TrimSpace(body.URL))
+    if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+        return fieldError("url", "must be an absolute http or https URL")
+    }
+    if err := service.CheckWebhookHost(c.Request().Context(), u.Hostname()); err != nil {
+        if errors.Is(err, service.ErrWebhookDestination) {
+            return fieldError("url", "must not point to a loopback, private or link-local address")
+        }
+        return fieldError("url", "host does not resolve")
+    }
+    events := repository.WebhookEvents
+    if len(body.Events) > 0 {
+        known := make(map[string]bool, len(repository.WebhookEvents))
+        for _, ev := range repository.WebhookEvents {
+            known[ev] = true
+        }
+        seen := make(map[string]bool, len(body.Events))
+        events = make([]string, 0, len(body.Events))
+        for _, ev := range body.Events {
+            if !known[ev] {
+                return fieldError("events", "must be among "+strings.Join(repository.WebhookEvents, ", "))
+            }
+            if !seen[ev] {
+                seen[ev] = true
+                events = append(events, ev)
+            }
+        }
+    }
+    secret := body.Secret
+    if secret == "" {
+        raw, err := utils.NewSecret()
+        if err != nil {
+            return apperr.Internal("failed to generate secret")
+        }
+        secret = "whsec_" + raw
+    } else if len(secret) < 16 {
+        return fieldError("secret", "must be at least 16 characters")
+    }
+    s := &repository.WebhookSubscription{OwnerID: ownerID, URL: u.String(), Secret: secret, Events: events}
+    if err := h.Repo.Create(c.Request().Context(), s); err != nil {
+        return apperr.Internal("failed to create webhook")
+    }
+    recordAudit(c, h.Audit, auditEvent("webhook.create", "webhook", s.ID, ownerID), nil, s)
+    return c.JSON(http.StatusCreated, echo.Map{
+        "webhook": s,
+        "secret":  secret,
+    })
+}
+
+// ListWebhooks handles GET /v1/owner/webhooks and returns the caller's
+// subscriptions without their secrets.
+func (h *OwnerWebhookHandler) ListWebhooks(c echo.Context) error {
+    ownerID, err := getUserID(c)
+    if err != nil {
+        return apperr.Unauthorized("unauthorized")
+    }
+    items, err := h.Repo.ListByOwner(c.Request().Context(), ownerID)
+    if err != nil {
+        return apperr.Internal("failed to load webhooks")
+    }
+    return c.JSON(http.StatusOK, echo.Map{
+        "items": items,
+        "count": len(items),
+    })
+}
+
+// DeleteWebhook handles DELETE /v1/owner/webhooks/:id.  The subscription
+// and its delivery log are removed and pending deliveries are dropped.
+// Returns 204, or 404 when the caller has no such subscription.
+func (h *OwnerWebhookHandler) DeleteWebhook(c echo.Context) error {
+    ownerID, err := getUserID(c)
+    if err != nil {
+        return apperr.Unauthorized("unauthorized")
+    }
+    id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+    if err != nil || id == 0 {
+        return apperr.BadRequest("invalid id")
+    }
+    if err := h.Repo.Delete(c.Request().Context(), ownerID, id); err != nil {
+        if errors.Is(err, sql.ErrNoRows) {
+            return apperr.NotFound("webhook not found")
+        }
+        return apperr.Internal("failed to delete webhook")
+    }
+    recordAudit(c, h.Audit, auditEvent("webhook.delete", "webhook", id, ownerID), nil, nil)
+    return c.NoContent(http.StatusNoContent)
+}
+
+// ListWebhookDeliveries handles GET /v1/owner/webhooks/:id/deliveries,
+// the delivery log of one of the caller's subscriptions, newest first.
+// status (PENDING, DELIVERED or FAILED), before_id and limit (default 50,
+// at most 500) filter it; when a full page is returned, next_before_id
+// continues from the last item.
+func (h *OwnerWebhookHandler) ListWebhookDeliveries(c echo.Context) error {
+    ownerID, err := getUserID(c)
+    if err != nil {
+        return apperr.Unauthorized("unauthorized")
+    }
+    id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+    if err != nil || id == 0 {
+        return apperr.BadRequest("invalid id")
+    }
+    status := strings.ToUpper(strings.TrimSpace(c.QueryParam("status")))
+    switch status {
+    case "", repository.WebhookDeliveryPending, repository.WebhookDeliveryDelivered, repository.WebhookDeliveryFailed:
+    default:
+        return fieldError("status", "must be PENDING, DELIVERED or FAILED")
+    }
+    var beforeID uint64
+    if s := c.QueryParam("before_id"); s != "" {
+        if beforeID, err = strconv.ParseUint(s, 10, 64); err != nil {
+            return fieldError("before_id", "must be a positive integer")
+        }
+    }
+    limit := 50
+    if s := c.QueryParam("limit"); s != "" {
+        n, err := strconv.Atoi(s)
+        if err != nil || n < 1 || n > 500 {
+            return fieldError("limit", "must be between 1 and 500")
+        }
+        limit = n
+    }
+    ctx := c.Request().Context()
+    owns, err := h.Repo.OwnsSubscription(ctx, ownerID, id)
+    if err != nil {
+        return apperr.Internal("failed to load webhook")
+    }
+    if !owns {
+        return apperr.NotFound("webhook not found")
+    }
+    items, err := h.Repo.ListDeliveries(ctx, id, status, beforeID, limit)
+    if err != nil {
+        return apperr.Internal("failed to load deliveries")
+    }
+    resp := echo.Map{"items": items, "count": len(items)}
+    if len(items) == limit {
+        resp["next_before_id"] = items[len(items)-1].ID
+    }
+    return c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,59 @@
+package handler_test
+
+import (
+	"database/sql/driver"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/iliyamo/cinema-seat-reservation/internal/apperr"
+	"github.com/iliyamo/cinema-seat-reservation/internal/handler"
+	"github.com/iliyamo/cinema-seat-reservation/internal/repository"
+	"github.com/iliyamo/cinema-seat-reservation/internal/repository/mock"
+)
+
+func TestCreateWebhookRefusesInternalAddresses(t *testing.T) {
+	created := false
+	conn := (&mock.Driver{ExecFn: func(string, []driver.NamedValue) (driver.Result, error) {
+		created = true
+		return nil, errors.New("not stored")
+	}}).DB()
+	defer conn.Close()
+	h := handler.NewOwnerWebhookHandler(repository.NewWebhookRepo(conn))
+	e := echo.New()
+	e.HTTPErrorHandler = apperr.Handler
+	e.POST("/v1/owner/webhooks", h.CreateWebhook, func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.Set("user_id", uint64(2))
+			return next(c)
+		}
+	})
+
+	for _, url := range []string{
+		"http://127.0.0.1:8080/hook",
+		"http://[::1]/hook",
+		"https://10.0.0.5/hook",
+		"https://192.168.1.20/hook",
+		"http://169.254.169.254/latest/meta-data",
+		"http://[fe80::1]/hook",
+		"http://0.0.0.0/hook",
+	} {
+		t.Run(url, func(t *testing.T) {
+			rec := serve(e, http.MethodPost, "/v1/owner/webhooks", `{"url":"`+url+`"}`)
+			if rec.Code != http.StatusUnprocessableEntity || errorCode(t, rec) != apperr.CodeValidation {
+				t.Fatalf("status %d, body %s; want 422", rec.Code, rec.Body)
+			}
+		})
+	}
+	if created {
+		t.Fatal("a subscription to an internal address was stored")
+	}
+
+	// a public address gets as far as the repository
+	serve(e, http.MethodPost, "/v1/owner/webhooks", `{"url":"https://93.184.216.34/hook"}`)
+	if !created {
+		t.Fatal("a subscription to a public address was not stored")
+	}
+}
@@ -7,6 +7,7 @@
 package handler
 
 import (
+    "math"      // rounding distances
     "net/http"  // HTTP status codes and request context
     "strconv"   // string to integer conversion utilities
     "strings"   // trimming and other string helpers
@@ -14,7 +15,12 @@ import (
     "sort"      // sorting helpers for row labels
 
     "github.com/labstack/echo/v4"                         // Echo web framework
+    "github.com/iliyamo/cinema-seat-reservation/internal/apperr" // apperr builds error responses
+    "github.com/iliyamo/cinema-seat-reservation/internal/middleware" // conditional request checks
+    "github.com/iliyamo/cinema-seat-reservation/internal/graphql"    // GraphQL view of the browse endpoints
+    "github.com/iliyamo/cinema-seat-reservation/internal/money"      // default currency
     "github.com/iliyamo/cinema-seat-reservation/internal/repository" // repository interfaces
+    "github.com/iliyamo/cinema-seat-reservation/internal/service"    // seat quotes
 )
 
 // PublicHandler aggregates repositories needed for unauthenticated browsing.
@@ -31,17 +37,79 @@ type PublicHandler struct {
     // ShowSeatRepo gives access to show_seats for seat status computation.
     ShowSeatRepo *repository.ShowSeatRepo
 
-    // SeatHoldRepo gives access to seat_holds for expiring holds prior to
-    // computing seat status.  It may be nil in legacy constructions; when
-    // non-nil it will be used to expire holds before listing seats.
-    SeatHoldRepo *repository.SeatHoldRepo
+    // SearchRepo backs GET /v1/search.  When nil the endpoint responds 501.
+    SearchRepo *repository.SearchRepo
+
+    // AccessibilityRepo backs GET /v1/shows/:id/seats/accessibility.  When
+    // nil the endpoint responds 501.
+    AccessibilityRepo *repository.SeatAccessibilityRepo
+
+    // Browse is the schema served by /v1/graphql.  When nil the endpoint
+    // responds 501.
+    Browse *graphql.Schema
+
+    // Booking prices seat selections for POST /v1/shows/:id/quote.  When
+    // nil the endpoint responds 501.
+    Booking *service.BookingService
 }
 
 // PublicCinema represents a cinema exposed via the public API. It contains
-// only safe fields.
+// only safe fields; the profile fields were sanitized when the owner set
+// them (see repository.CinemaProfile.Normalize).
 type PublicCinema struct {
-    ID   uint64 `json:"id"`
-    Name string `json:"name"`
+    ID           uint64                    `json:"id"`
+    Name         string                    `json:"name"`
+    Timezone     string                    `json:"timezone,omitempty"` // IANA time zone of the cinema's show times
+    Currency     string                    `json:"currency,omitempty"` // ISO 4217 code of the cinema's prices
+    City         *string                   `json:"city,omitempty"`
+    Address      *string                   `json:"address,omitempty"`
+    Latitude     *float64                  `json:"latitude,omitempty"`
+    Longitude    *float64                  `json:"longitude,omitempty"`
+    LogoURL      *string                   `json:"logo_url,omitempty"`
+    Description  *string                   `json:"description,omitempty"` // plain text
+    Phone        *string                   `json:"phone,omitempty"`
+    OpeningHours []repository.OpeningHours `json:"opening_hours,omitempty"`
+    DistanceKm   *float64                  `json:"distance_km,omitempty"` // only with ?near=
+}
+
+// newPublicCinema copies the public fields of c.
+func newPublicCinema(c *repository.Cinema) PublicCinema {
+    return PublicCinema{ID: c.ID, Name: c.Name, Timezone: c.Timezone, Currency: c.Currency, City: c.City,
+        Address: c.Address, Latitude: c.Latitude, Longitude: c.Longitude,
+        LogoURL: c.LogoURL, Description: c.Description, Phone: c.Phone, OpeningHours: c.OpeningHours}
+}
+
+const (
+    defaultNearRadiusKm = 10  // radius_km when near is given without it
+    maxNearRadiusKm     = 500 // upper bound for radius_km
+)
+
+// parseNear parses near=lat,lng and radius_km into a filter.
+func parseNear(c echo.Context, f *repository.CinemaFilter) error {
+    near := strings.TrimSpace(c.QueryParam("near"))
+    radius := strings.TrimSpace(c.QueryParam("radius_km"))
+    if near == "" {
+        if radius != "" {
+            return apperr.BadRequest("radius_km requires near")
+        }
+        return nil
+    }
+    latStr, lngStr, ok := strings.Cut(near, ",")
+    lat, err1 := strconv.ParseFloat(strings.TrimSpace(latStr), 64)
+    lng, err2 := strconv.ParseFloat(strings.TrimSpace(lngStr), 64)
+    if !ok || err1 != nil || err2 != nil || lat < -90 || lat > 90 || lng < -180 || lng > 180 {
+        return apperr.BadRequest("near must be latitude,longitude in degrees")
+    }
+    f.Near = &repository.GeoPoint{Lat: lat, Lng: lng}
+    f.RadiusKm = defaultNearRadiusKm
+    if radius != "" {
+        r, err := strconv.ParseFloat(radius, 64)
+        if err != nil || r <= 0 || r > maxNearRadiusKm {
+            return apperr.BadRequest("radius_km must be greater than 0 and at most 500")
+        }
+        f.RadiusKm = r
+    }
+    return nil
 }
 
 // PublicHall represents a hall exposed via the public API.
@@ -59,6 +127,7 @@ type PublicHall struct {
 type PublicShow struct {
     ID        uint64  `json:"id"`        // ID uniquely identifies the show
     Title     string  `json:"title"`     // Title is the movie or event name
+    Status    string  `json:"status"`    // Status is SCHEDULED, CANCELLED or FINISHED
     // StartTime is the ISO 8601 formatted start time of the show. It is a pointer
     // so that null values can be encoded as JSON null. The omitempty directive is
     // omitted to ensure the field always appears in the response, even when nil.
@@ -67,6 +136,10 @@ type PublicShow struct {
     // it is a pointer to allow null values when no end time is provided. The
     // absence of omitempty causes the field to appear with a null value when nil.
     EndTime   *string `json:"end_time"`
+    // LocalStartTime is the start time in the cinema's time zone, with
+    // its offset; Timezone names that zone.
+    LocalStartTime *string `json:"local_start_time"`
+    Timezone       string  `json:"timezone"`
 }
 
 // PublicShowDetail represents a single show with related cinema and hall names.
@@ -80,6 +153,18 @@ type PublicShowDetail struct {
     StartTime *string       `json:"start_time"`
     // EndTime is the ISO 8601 formatted end time or null.
     EndTime   *string       `json:"end_time"`
+    // LocalStartTime is the start time in the cinema's time zone, with
+    // its offset; Timezone names that zone.
+    LocalStartTime *string `json:"local_start_time"`
+    Timezone       string  `json:"timezone"`
+    // Currency is the ISO 4217 code of the show's seat prices.
+    Currency string `json:"currency"`
+    // Rating, Language and Format describe the content; MinAge above
+    // zero means confirming seats requires a date of birth.
+    Rating   *string `json:"rating"`
+    Language *string `json:"language"`
+    Format   *string `json:"format"`
+    MinAge   uint8   `json:"min_age"`
     // Cinema contains the minimal cinema info (id, name) if available.
     Cinema    *PublicCinema `json:"cinema,omitempty"`
     // Hall contains the minimal hall info (id, name) if available.
@@ -90,38 +175,67 @@ type PublicShowDetail struct {
 }
 
 // GetPublicCinemas returns a list of all cinemas accessible to unauthenticated users.
-// Response JSON contains an "items" array of PublicCinema.
+// Response JSON contains an "items" array of PublicCinema.  The optional
+// city parameter keeps cinemas in that city; near=lat,lng keeps cinemas
+// within radius_km (default 10, at most 500) of that point, nearest
+// first, each with its distance_km.
 func (h *PublicHandler) GetPublicCinemas(c echo.Context) error {
     ctx := c.Request().Context()
-    cinemas, err := h.CinemaRepo.ListAll(ctx)
+    filter := repository.CinemaFilter{City: strings.TrimSpace(c.QueryParam("city"))}
+    if err := parseNear(c, &filter); err != nil {
+        return err
+    }
+    cinemas, err := h.CinemaRepo.ListAll(ctx, filter)
     if err != nil {
-        return c.JSON(http.StatusInternalServerError, echo.Map{"error": "database error"})
+        return apperr.Internal("database error")
     }
     out := make([]PublicCinema, 0, len(cinemas))
     for _, cin := range cinemas {
-        out = append(out, PublicCinema{ID: cin.ID, Name: cin.Name})
+        pc := newPublicCinema(cin)
+        if filter.Near != nil {
+            d := math.Round(filter.Near.DistanceKm(repository.GeoPoint{Lat: *cin.Latitude, Lng: *cin.Longitude})*100) / 100
+            pc.DistanceKm = &d
+        }
+        out = append(out, pc)
     }
     return c.JSON(http.StatusOK, echo.Map{"items": out})
 }
 
+// GetPublicCinema returns one cinema, with its profile, as a
+// PublicCinema.
+func (h *PublicHandler) GetPublicCinema(c echo.Context) error {
+    id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+    if err != nil || id == 0 {
+        return apperr.BadRequest("invalid id")
+    }
+    cin, err := h.CinemaRepo.GetByID(c.Request().Context(), id)
+    if err != nil {
+        if err == repository.ErrCinemaNotFound {
+            return apperr.NotFound("cinema not found")
+        }
+        return apperr.Internal("database error")
+    }
+    return c.JSON(http.StatusOK, newPublicCinema(cin))
+}
+
 // GetPublicHallsByCinema lists halls of a cinema for unauthenticated users. It validates
 // the cinema exists, then returns only non-sensitive fields.
 func (h *PublicHandler) GetPublicHallsByCinema(c echo.Context) error {
     ctx := c.Request().Context()
     id, err := strconv.ParseUint(c.Param("id"), 10, 64)
     if err != nil {
-        return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid id"})
+        return apperr.BadRequest("invalid id")
     }
     // ensure cinema exists
     if _, err := h.CinemaRepo.GetByID(ctx, id); err != nil {
         if err == repository.ErrCinemaNotFound {
-            return c.JSON(http.StatusNotFound, echo.Map{"error": "cinema not found"})
+            return apperr.NotFound("cinema not found")
         }
-        return c.JSON(http.StatusInternalServerError, echo.Map{"error": "database error"})
+        return apperr.Internal("database error")
     }
     halls, err := h.HallRepo.ListByCinema(ctx, id)
     if err != nil {
-        return c.JSON(http.StatusInternalServerError, echo.Map{"error": "database error"})
+        return apperr.Internal("database error")
     }
     out := make([]PublicHall, 0, len(halls))
     for _, hall := range halls {
@@ -140,42 +254,38 @@ func (h *PublicHandler) GetPublicHallsByCinema(c echo.Context) error {
 }
 
 // GetPublicShowsByHall lists shows in a hall for unauthenticated users. It ensures the hall
-// exists, then returns each show's ID, title and start time as a time.Time.
+// exists, then returns each show's ID, title, status and times, with the start time
+// also in the cinema's time zone.  Only scheduled shows are listed unless the
+// query parameter "all" is true, which adds cancelled and finished ones.
 func (h *PublicHandler) GetPublicShowsByHall(c echo.Context) error {
     ctx := c.Request().Context()
     hallID, err := strconv.ParseUint(c.Param("id"), 10, 64)
     if err != nil {
-        return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid id"})
+        return apperr.BadRequest("invalid id")
     }
     // ensure hall exists
-    if _, err := h.HallRepo.GetByID(ctx, hallID); err != nil {
+    hall, err := h.HallRepo.GetByID(ctx, hallID)
+    if err != nil {
         if err == repository.ErrHallNotFound {
-            return c.JSON(http.StatusNotFound, echo.Map{"error": "hall not found"})
+            return apperr.NotFound("hall not found")
         }
-        return c.JSON(http.StatusInternalServerError, echo.Map{"error": "database error"})
+        return apperr.Internal("database error")
     }
-    shows, err := h.ShowRepo.ListByHall(ctx, hallID)
+    all := false
+    if v := strings.TrimSpace(c.QueryParam("all")); v != "" {
+        if all, err = strconv.ParseBool(v); err != nil {
+            return apperr.BadRequest("all must be true or false")
+        }
+    }
+    loc := loadTimezone(hall.Timezone)
+    shows, err := h.ShowRepo.ListByHall(ctx, hallID, all)
     if err != nil {
-        return c.JSON(http.StatusInternalServerError, echo.Map{"error": "database error"})
+        return apperr.Internal("database error")
     }
     out := make([]PublicShow, 0, len(shows))
     for _, s := range shows {
-        var startPtr, endPtr *string
-        // parse and format start time if present
-        if ts := strings.TrimSpace(s.StartsAt); ts != "" && ts != "0001-01-01 00:00:00" {
-            if t, parseErr := time.Parse("2006-01-02 15:04:05", ts); parseErr == nil {
-                iso := t.UTC().Format(time.RFC3339)
-                startPtr = &iso
-            }
-        }
-        // parse and format end time if present
-        if te := strings.TrimSpace(s.EndsAt); te != "" && te != "0001-01-01 00:00:00" {
-            if et, parseErr := time.Parse("2006-01-02 15:04:05", te); parseErr == nil {
-                iso := et.UTC().Format(time.RFC3339)
-                endPtr = &iso
-            }
-        }
-        out = append(out, PublicShow{ID: s.ID, Title: s.Title, StartTime: startPtr, EndTime: endPtr})
+        out = append(out, PublicShow{ID: s.ID, Title: s.Title, Status: s.Status, StartTime: utcTime(s.StartsAt), EndTime: utcTime(s.EndsAt),
+            LocalStartTime: localTime(s.StartsAt, loc), Timezone: loc.String()})
     }
     return c.JSON(http.StatusOK, echo.Map{"items": out})
 }
@@ -186,42 +296,38 @@ func (h *PublicHandler) GetPublicShow(c echo.Context) error {
     ctx := c.Request().Context()
     showID, err := strconv.ParseUint(c.Param("id"), 10, 64)
     if err != nil {
-        return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid id"})
+        return apperr.BadRequest("invalid id")
     }
     s, err := h.ShowRepo.GetByID(ctx, showID)
     if err != nil {
         if err == repository.ErrShowNotFound {
-            return c.JSON(http.StatusNotFound, echo.Map{"error": "show not found"})
-        }
-        return c.JSON(http.StatusInternalServerError, echo.Map{"error": "database error"})
-    }
-    // parse start and end times; assign nil pointers if invalid or zero
-    var startPtr, endPtr *string
-    if ts := strings.TrimSpace(s.StartsAt); ts != "" && ts != "0001-01-01 00:00:00" {
-        if t, parseErr := time.Parse("2006-01-02 15:04:05", ts); parseErr == nil {
-            iso := t.UTC().Format(time.RFC3339)
-            startPtr = &iso
+            return apperr.NotFound("show not found")
         }
+        return apperr.Internal("database error")
     }
-    if te := strings.TrimSpace(s.EndsAt); te != "" && te != "0001-01-01 00:00:00" {
-        if et, parseErr := time.Parse("2006-01-02 15:04:05", te); parseErr == nil {
-            iso := et.UTC().Format(time.RFC3339)
-            endPtr = &iso
-        }
+    resp := PublicShowDetail{ID: s.ID, Title: s.Title, StartTime: utcTime(s.StartsAt), EndTime: utcTime(s.EndsAt)}
+    if sc, err := h.ShowRepo.Content(ctx, showID); err == nil {
+        resp.Rating, resp.Language, resp.Format, resp.MinAge = sc.Rating, sc.Language, sc.Format, sc.MinAge
     }
-    resp := PublicShowDetail{ID: s.ID, Title: s.Title, StartTime: startPtr, EndTime: endPtr}
-    // load hall to get hall name and cinema ID
+    // load hall to get hall name, cinema ID and time zone
+    loc := time.UTC
+    resp.Currency = money.Default()
     if hall, err := h.HallRepo.GetByID(ctx, s.HallID); err == nil {
+        loc = loadTimezone(hall.Timezone)
+        resp.Currency = hall.Currency
         resp.Hall = &struct {
             ID   uint64 `json:"id"`
             Name string `json:"name"`
         }{ID: hall.ID, Name: hall.Name}
         if hall.CinemaID != nil {
             if cin, err2 := h.CinemaRepo.GetByID(ctx, *hall.CinemaID); err2 == nil {
-                resp.Cinema = &PublicCinema{ID: cin.ID, Name: cin.Name}
+                pc := newPublicCinema(cin)
+                resp.Cinema = &pc
             }
         }
     }
+    resp.LocalStartTime = localTime(s.StartsAt, loc)
+    resp.Timezone = loc.String()
     return c.JSON(http.StatusOK, resp)
 }
 
@@ -232,25 +338,31 @@ func (h *PublicHandler) GetPublicShow(c echo.Context) error {
 // flag (true or false).  If SeatRepo is nil, an internal server error
 // is returned.  This endpoint does not require authentication and is
 // intended for customers to view seat arrangements before selecting seats.
+// The hall's rendering metadata is included: the screen edge, the
+// sections and, on every row, its section, offset and spacing.
 func (h *PublicHandler) GetPublicHallLayout(c echo.Context) error {
     if h.SeatRepo == nil {
-        return c.JSON(http.StatusInternalServerError, echo.Map{"error": "seat repository not configured"})
+        return apperr.Internal("seat repository not configured")
     }
     ctx := c.Request().Context()
     hallID, err := strconv.ParseUint(c.Param("id"), 10, 64)
     if err != nil || hallID == 0 {
-        return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid id"})
+        return apperr.BadRequest("invalid id")
     }
     // ensure hall exists
     if _, err := h.HallRepo.GetByID(ctx, hallID); err != nil {
         if err == repository.ErrHallNotFound {
-            return c.JSON(http.StatusNotFound, echo.Map{"error": "hall not found"})
+            return apperr.NotFound("hall not found")
         }
-        return c.JSON(http.StatusInternalServerError, echo.Map{"error": "database error"})
+        return apperr.Internal("database error")
+    }
+    rendering, err := h.HallRepo.GetRendering(ctx, hallID)
+    if err != nil {
+        return apperr.Internal("database error")
     }
     seats, err := h.SeatRepo.GetByHall(ctx, hallID)
     if err != nil {
-        return c.JSON(http.StatusInternalServerError, echo.Map{"error": "database error"})
+        return apperr.Internal("database error")
     }
     // optional active filter
     if v := strings.ToLower(strings.TrimSpace(c.QueryParam("active"))); v == "true" || v == "1" || v == "false" || v == "0" {
@@ -289,13 +401,17 @@ func (h *PublicHandler) GetPublicHallLayout(c echo.Context) error {
     type rowOut struct {
         RowLabel string   `json:"row_label"`
         Numbers  []uint32 `json:"numbers"`
+        Section  string   `json:"section,omitempty"`
+        Offset   float64  `json:"offset"`
+        Spacing  uint8    `json:"spacing"`
     }
     rowsOut := make([]rowOut, 0, len(rowOrder))
     pretty := make([]string, 0, len(rowOrder))
     for _, lbl := range rowOrder {
         nums := rowsMap[lbl]
         sort.Slice(nums, func(i, j int) bool { return nums[i] < nums[j] })
-        rowsOut = append(rowsOut, rowOut{RowLabel: lbl, Numbers: nums})
+        section, adj := rendering.Row(lbl)
+        rowsOut = append(rowsOut, rowOut{RowLabel: lbl, Numbers: nums, Section: section, Offset: adj.Offset, Spacing: adj.Spacing})
         var b strings.Builder
         b.WriteString(lbl)
         b.WriteString(": ")
@@ -313,6 +429,8 @@ func (h *PublicHandler) GetPublicHallLayout(c echo.Context) error {
         "order":    rowOrder,
         "rows":     rowsOut,
         "pretty":   pretty,
+        "screen":   rendering.Screen,
+        "sections": rendering.Sections,
     })
 }
 
@@ -321,64 +439,257 @@ func (h *PublicHandler) GetPublicHallLayout(c echo.Context) error {
 // considered RESERVED when its show_seats.status is RESERVED.  It is
 // considered HELD if there exists a non-expired seat_hold for it (held by
 // any user).  Otherwise it is FREE.  The response contains an array of
-// objects with seat_id, row_label, seat_number and status.
+// objects with seat_id, row_label, seat_number and status, plus the
+// section of the seat's row, and the hall's rendering metadata under
+// layout.  When the request carries a valid bearer token every seat also
+// has held_by_me, true for the caller's own active holds.
 func (h *PublicHandler) GetPublicShowSeats(c echo.Context) error {
+    return h.showSeats(c, false)
+}
+
+// GetPublicShowSeatsAccessibility handles GET
+// /v1/shows/:id/seats/accessibility.  It returns the same seat map as
+// GetPublicShowSeats with each seat additionally annotated with its
+// seat_type and accessibility attributes (wheelchair space, companion
+// seat, transfer seat, step-free access) so seat pickers can offer and
+// filter accessible seating.
+func (h *PublicHandler) GetPublicShowSeatsAccessibility(c echo.Context) error {
+    if h.AccessibilityRepo == nil {
+        return apperr.NotImplemented("accessibility overlay is not available")
+    }
+    return h.showSeats(c, true)
+}
+
+// showSeats implements the seat map endpoints; withAccessibility adds the
+// accessibility overlay.
+func (h *PublicHandler) showSeats(c echo.Context, withAccessibility bool) error {
     if h.ShowSeatRepo == nil || h.SeatRepo == nil {
-        return c.JSON(http.StatusInternalServerError, echo.Map{"error": "seat repositories not configured"})
+        return apperr.Internal("seat repositories not configured")
     }
     ctx := c.Request().Context()
     showID, err := strconv.ParseUint(c.Param("id"), 10, 64)
     if err != nil || showID == 0 {
-        return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid id"})
-    }
-    // ensure show exists
-    if _, err := h.ShowRepo.GetByID(ctx, showID); err != nil {
-        if err == repository.ErrShowNotFound {
-            return c.JSON(http.StatusNotFound, echo.Map{"error": "show not found"})
+        return apperr.BadRequest("invalid id")
+    }
+    // A signed-in caller (see middleware.OptionalJWT) learns which of the
+    // held seats are theirs.
+    userID, err := getUserID(c)
+    signedIn := err == nil
+    // Polling clients send back the ETag of the map they have; when no
+    // seat or hold of the show changed since, a single aggregate query
+    // answers them 304.  The accessibility overlay also depends on seat
+    // attributes, so that map is only checked against a hash of its body
+    // (see middleware.ConditionalGET).
+    if !withAccessibility {
+        v, err := h.ShowSeatRepo.SeatMapVersion(ctx, showID)
+        if err != nil {
+            return apperr.Internal("database error")
         }
-        return c.JSON(http.StatusInternalServerError, echo.Map{"error": "database error"})
-    }
-    // Before fetching seat status, expire any holds that have passed
-    // their expiration.  This ensures that seats with expired holds
-    // become available (FREE) and are reported correctly to clients.
-    if h.SeatHoldRepo != nil {
-        // Run expiration cleanup in a short transaction.  Use the show seat
-        // repository's DB connection to begin the transaction.
-        tx, txErr := h.ShowSeatRepo.DB().BeginTx(ctx, nil)
-        if txErr == nil {
-            // If any holds have expired, they will be deleted and returned
-            // as seat IDs.  We then update those show_seats back to FREE.
-            if expired, expErr := h.SeatHoldRepo.ExpireHoldsTx(ctx, tx, showID); expErr == nil {
-                if len(expired) > 0 {
-                    _ = h.ShowSeatRepo.BulkUpdateStatusTx(ctx, tx, showID, expired, "FREE")
-                }
-                // Commit regardless of whether expired were found to avoid leaving an open transaction
-                _ = tx.Commit()
-            } else {
-                // If cleanup failed, roll back and ignore the error for seat listing
-                _ = tx.Rollback()
+        if v.Seats > 0 {
+            etag := v.ETag(showID)
+            hdr := c.Response().Header()
+            if signedIn {
+                etag = strings.TrimSuffix(etag, `"`) + "-u" + strconv.FormatUint(userID, 10) + `"`
+                hdr.Add(echo.HeaderVary, echo.HeaderAuthorization)
+            }
+            hdr.Set("ETag", etag)
+            hdr.Set(echo.HeaderLastModified, v.LastModified.Format(http.TimeFormat))
+            if middleware.NotModified(c, etag, v.LastModified) {
+                return c.NoContent(http.StatusNotModified)
             }
         }
     }
+    // One query computes every seat's status and holder; expired holds
+    // count as FREE, so nothing is cleaned up on this read path.  The
+    // hall's rendering metadata comes with a second query, which also
+    // tells a missing show from a show without seats.
     seats, err := h.ShowSeatRepo.ListWithStatus(ctx, showID)
     if err != nil {
-        return c.JSON(http.StatusInternalServerError, echo.Map{"error": "database error"})
+        return apperr.Internal("database error")
+    }
+    rendering, err := h.HallRepo.RenderingByShow(ctx, showID)
+    if err != nil {
+        if err == repository.ErrShowNotFound {
+            return apperr.NotFound("show not found")
+        }
+        return apperr.Internal("database error")
+    }
+    var access map[uint64]repository.SeatAccessibility
+    if withAccessibility {
+        access, err = h.AccessibilityRepo.ListByShow(ctx, showID)
+        if err != nil {
+            return apperr.Internal("database error")
+        }
     }
     // build response items
     type seatOut struct {
-        SeatID     uint64 `json:"seat_id"`
-        RowLabel   string `json:"row_label"`
-        SeatNumber uint32 `json:"seat_number"`
-        Status     string `json:"status"`
+        SeatID        uint64                        `json:"seat_id"`
+        RowLabel      string                        `json:"row_label"`
+        SeatNumber    uint32                        `json:"seat_number"`
+        Status        string                        `json:"status"`
+        HeldByMe      *bool                         `json:"held_by_me,omitempty"`
+        Section       string                        `json:"section,omitempty"`
+        SeatType      string                        `json:"seat_type,omitempty"`
+        Accessibility *repository.SeatAccessibility `json:"accessibility,omitempty"`
     }
     items := make([]seatOut, 0, len(seats))
     for _, s := range seats {
-        items = append(items, seatOut{SeatID: s.SeatID, RowLabel: s.RowLabel, SeatNumber: s.SeatNumber, Status: s.Status})
+        out := seatOut{SeatID: s.SeatID, RowLabel: s.RowLabel, SeatNumber: s.SeatNumber, Status: s.Status}
+        out.Section, _ = rendering.Row(s.RowLabel)
+        if signedIn {
+            held := s.HeldBy != nil && *s.HeldBy == userID
+            out.HeldByMe = &held
+        }
+        if withAccessibility {
+            a := access[s.SeatID]
+            a.SeatID = s.SeatID
+            out.SeatType = s.SeatType
+            out.Accessibility = &a
+        }
+        items = append(items, out)
     }
     return c.JSON(http.StatusOK, echo.Map{
         "show_id": showID,
         "count":   len(items),
         "items":   items,
+        "layout":  rendering,
+    })
+}
+
+// SeatCounts is the number of seats of a show in each status.
+type SeatCounts struct {
+    Total    int `json:"total"`
+    Free     int `json:"free"`
+    Held     int `json:"held"`
+    Reserved int `json:"reserved"`
+    Blocked  int `json:"blocked"`
+}
+
+// add counts n seats in status.
+func (s *SeatCounts) add(status string, n int) {
+    s.Total += n
+    switch status {
+    case "FREE":
+        s.Free += n
+    case "HELD":
+        s.Held += n
+    case "RESERVED":
+        s.Reserved += n
+    case "BLOCKED":
+        s.Blocked += n
+    }
+}
+
+// GetPublicShowAvailability handles GET /v1/shows/:id/availability for
+// unauthenticated users.  It returns how many of the show's seats are
+// free, held, reserved or blocked, overall and per seat_type, for
+// listings that need counts rather than the full seat map.  Statuses are
+// computed as in GetPublicShowSeats.
+func (h *PublicHandler) GetPublicShowAvailability(c echo.Context) error {
+    if h.ShowSeatRepo == nil {
+        return apperr.Internal("seat repositories not configured")
+    }
+    ctx := c.Request().Context()
+    showID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+    if err != nil || showID == 0 {
+        return apperr.BadRequest("invalid id")
+    }
+    if _, err := h.ShowRepo.GetByID(ctx, showID); err != nil {
+        if err == repository.ErrShowNotFound {
+            return apperr.NotFound("show not found")
+        }
+        return apperr.Internal("database error")
+    }
+    counts, err := h.ShowSeatRepo.Availability(ctx, showID)
+    if err != nil {
+        return apperr.Internal("database error")
+    }
+    var total SeatCounts
+    byType := make(map[string]*SeatCounts)
+    for _, a := range counts {
+        total.add(a.Status, a.Seats)
+        if byType[a.SeatType] == nil {
+            byType[a.SeatType] = &SeatCounts{}
+        }
+        byType[a.SeatType].add(a.Status, a.Seats)
+    }
+    return c.JSON(http.StatusOK, echo.Map{
+        "show_id":      showID,
+        "seats":        total,
+        "by_seat_type": byType,
+    })
+}
+
+// maxAvailabilityBatch is how many shows one availability batch may ask
+// for.
+const maxAvailabilityBatch = 100
+
+type availabilityBatchReq struct {
+    ShowIDs []uint64 `json:"show_ids" validate:"required,max=100"`
+}
+
+// showAvailability is one show of an availability batch.
+type showAvailability struct {
+    ShowID     uint64                 `json:"show_id"`
+    Seats      SeatCounts             `json:"seats"`
+    BySeatType map[string]*SeatCounts `json:"by_seat_type"`
+}
+
+// GetPublicShowsAvailability handles POST /v1/shows/availability:batch.
+// It returns the counts of GetPublicShowAvailability for up to
+// maxAvailabilityBatch shows from a single grouped query, so a listing
+// can badge all its shows in one call.  Items follow the order of
+// show_ids, without duplicates; shows that do not exist (or have no
+// seats) are listed under missing instead.
+func (h *PublicHandler) GetPublicShowsAvailability(c echo.Context) error {
+    if h.ShowSeatRepo == nil {
+        return apperr.Internal("seat repositories not configured")
+    }
+    var body availabilityBatchReq
+    if err := bindValid(c, &body); err != nil {
+        return err
+    }
+    ids := make([]uint64, 0, len(body.ShowIDs))
+    seen := make(map[uint64]bool, len(body.ShowIDs))
+    for _, id := range body.ShowIDs {
+        if id == 0 {
+            return fieldError("show_ids", "must not contain 0")
+        }
+        if !seen[id] {
+            seen[id] = true
+            ids = append(ids, id)
+        }
+    }
+    counts, err := h.ShowSeatRepo.AvailabilityForShows(c.Request().Context(), ids)
+    if err != nil {
+        return apperr.Internal("database error")
+    }
+    byShow := make(map[uint64]*showAvailability, len(ids))
+    for _, a := range counts {
+        s := byShow[a.ShowID]
+        if s == nil {
+            s = &showAvailability{ShowID: a.ShowID, BySeatType: map[string]*SeatCounts{}}
+            byShow[a.ShowID] = s
+        }
+        s.Seats.add(a.Status, a.Seats)
+        if s.BySeatType[a.SeatType] == nil {
+            s.BySeatType[a.SeatType] = &SeatCounts{}
+        }
+        s.BySeatType[a.SeatType].add(a.Status, a.Seats)
+    }
+    items := make([]*showAvailability, 0, len(byShow))
+    missing := []uint64{}
+    for _, id := range ids {
+        if s := byShow[id]; s != nil {
+            items = append(items, s)
+        } else {
+            missing = append(missing, id)
+        }
+    }
+    return c.JSON(http.StatusOK, echo.Map{
+        "count":   len(items),
+        "items":   items,
+        "missing": missing,
     })
 }
 
@@ -392,25 +703,25 @@ func (h *PublicHandler) GetPublicShowSeats(c echo.Context) error {
 func (h *PublicHandler) GetPublicHallSeats(c echo.Context) error {
     // Ensure the seat repository is configured; without it we cannot list seats.
     if h.SeatRepo == nil {
-        return c.JSON(http.StatusInternalServerError, echo.Map{"error": "seat repository not configured"})
+        return apperr.Internal("seat repository not configured")
     }
     ctx := c.Request().Context()
     hallID, err := strconv.ParseUint(c.Param("id"), 10, 64)
     if err != nil || hallID == 0 {
-        return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid id"})
+        return apperr.BadRequest("invalid id")
     }
     // Ensure the hall exists before querying its seats.  We do not expose
     // internal errors to clients but return 404 if the hall is not found.
     if _, err := h.HallRepo.GetByID(ctx, hallID); err != nil {
         if err == repository.ErrHallNotFound {
-            return c.JSON(http.StatusNotFound, echo.Map{"error": "hall not found"})
+            return apperr.NotFound("hall not found")
         }
-        return c.JSON(http.StatusInternalServerError, echo.Map{"error": "database error"})
+        return apperr.Internal("database error")
     }
     // Fetch all seats for this hall ordered by row and number.
     seats, err := h.SeatRepo.GetByHall(ctx, hallID)
     if err != nil {
-        return c.JSON(http.StatusInternalServerError, echo.Map{"error": "database error"})
+        return apperr.Internal("database error")
     }
     // Optionally filter by the "active" query parameter.  Accepts true/false or 1/0.
     if v := strings.ToLower(strings.TrimSpace(c.QueryParam("active"))); v == "true" || v == "1" || v == "false" || v == "0" {
@@ -0,0 +1,47 @@
+package handler
+
+import (
+    "bytes"
+    "io"
+    "net/http"
+
+    "github.com/iliyamo/cinema-seat-reservation/internal/apperr"
+    "github.com/labstack/echo/v4"
+)
+
+// maxGraphQLBytes bounds the body of POST /v1/graphql.
+const maxGraphQLBytes = 64 << 10
+
+// GraphQL handles GET and POST /v1/graphql, the read-only GraphQL view
+// of the browse endpoints (see package graphql).  POST takes a JSON body
+// with query, operationName and variables, or the bare query with
+// Content-Type application/graphql; GET takes the same as query
+// parameters, with variables JSON-encoded.  Executed queries answer 200
+// with data and any errors.
+func (h *PublicHandler) GraphQL(c echo.Context) error {
+    if h.Browse == nil {
+        return apperr.NotImplemented("GraphQL is not enabled")
+    }
+    r := c.Request()
+    if r.Method == http.MethodPost {
+        body, err := io.ReadAll(io.LimitReader(r.Body, maxGraphQLBytes+1))
+        if err != nil {
+            return apperr.BadRequest("failed to read body")
+        }
+        if len(body) > maxGraphQLBytes {
+            return apperr.New(http.StatusRequestEntityTooLarge, apperr.CodeBadRequest, "body too large")
+        }
+        r.Body = io.NopCloser(bytes.NewReader(body))
+    }
+    h.Browse.ServeHTTP(c.Response(), r)
+    return nil
+}
+
+// GraphQLSchema handles GET /v1/graphql/schema and returns the schema
+// served by /v1/graphql in SDL, for client code generators.
+func (h *PublicHandler) GraphQLSchema(c echo.Context) error {
+    if h.Browse == nil {
+        return apperr.NotImplemented("GraphQL is not enabled")
+    }
+    return c.String(http.StatusOK, h.Browse.SDL())
+}
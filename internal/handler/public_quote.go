@@ -0,0 +1,142 @@
+package handler
+
+// This file implements seat quotes: the price of a seat selection before
+// anything is held, so seat pickers can show the total as seats are
+// chosen.
+
+import (
+    "fmt"
+    "net/http"
+    "strconv"
+    "strings"
+
+    "github.com/iliyamo/cinema-seat-reservation/internal/apperr"
+    "github.com/iliyamo/cinema-seat-reservation/internal/repository"
+    "github.com/labstack/echo/v4"
+)
+
+// maxQuoteSeats is how many seats one quote may price.
+const maxQuoteSeats = 100
+
+// quoteReq is the body of POST /v1/shows/:id/quote.  Seats are given by
+// ID, by row label and number, or both.
+type quoteReq struct {
+    SeatIDs []uint64       `json:"seat_ids" validate:"max=100"`
+    Seats   []quoteSeatRef `json:"seats" validate:"max=100"`
+}
+
+// quoteSeatRef names a seat by its position in the hall.
+type quoteSeatRef struct {
+    Row    string `json:"row" validate:"required,max=10"`
+    Number uint32 `json:"number" validate:"required"`
+}
+
+// quotedSeat is one seat of a quote response.
+type quotedSeat struct {
+    SeatID     uint64 `json:"seat_id"`
+    RowLabel   string `json:"row_label"`
+    SeatNumber uint32 `json:"seat_number"`
+    SeatType   string `json:"seat_type"`
+    PriceCents uint32 `json:"price_cents"`
+}
+
+// QuoteSeats handles POST /v1/shows/:id/quote.  It prices a seat
+// selection of a scheduled show at the current seat prices through
+// service.BookingService.Quote and responds with the per-seat prices,
+// the cinema's booking fee and tax and the total.  Nothing is held and
+// seat availability is not checked; the price is only guaranteed once
+// the seats are held.  Seats that are not in the show's hall yield 422.
+func (h *PublicHandler) QuoteSeats(c echo.Context) error {
+    if h.Booking == nil || h.SeatRepo == nil {
+        return apperr.NotImplemented("quotes are not available")
+    }
+    ctx := c.Request().Context()
+    showID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+    if err != nil || showID == 0 {
+        return apperr.BadRequest("invalid show id")
+    }
+    var body quoteReq
+    if err := bindValid(c, &body); err != nil {
+        return err
+    }
+    if len(body.SeatIDs)+len(body.Seats) == 0 {
+        return fieldError("seat_ids", "at least one seat is required")
+    }
+    show, err := h.ShowRepo.GetByID(ctx, showID)
+    if err == repository.ErrShowNotFound {
+        return apperr.NotFound("show not found")
+    }
+    if err != nil {
+        return apperr.Internal("database error")
+    }
+    hallSeats, err := h.SeatRepo.GetByHall(ctx, show.HallID)
+    if err != nil {
+        return apperr.Internal("database error")
+    }
+    byID := make(map[uint64]repository.Seat, len(hallSeats))
+    byPos := make(map[string]repository.Seat, len(hallSeats))
+    for _, s := range hallSeats {
+        byID[s.ID] = s
+        byPos[seatPosition(s.RowLabel, s.SeatNumber)] = s
+    }
+    // Resolve both forms to hall seats, in request order and without
+    // duplicates.
+    seats := make([]repository.Seat, 0, len(body.SeatIDs)+len(body.Seats))
+    seen := make(map[uint64]bool, cap(seats))
+    add := func(s repository.Seat) {
+        if !seen[s.ID] {
+            seen[s.ID] = true
+            seats = append(seats, s)
+        }
+    }
+    for _, id := range body.SeatIDs {
+        s, ok := byID[id]
+        if !ok {
+            return fieldError("seat_ids", fmt.Sprintf("seat %d is not in the show's hall", id))
+        }
+        add(s)
+    }
+    for _, ref := range body.Seats {
+        s, ok := byPos[seatPosition(ref.Row, ref.Number)]
+        if !ok {
+            return fieldError("seats", fmt.Sprintf("seat %s%d is not in the show's hall", strings.ToUpper(strings.TrimSpace(ref.Row)), ref.Number))
+        }
+        add(s)
+    }
+    if len(seats) > maxQuoteSeats {
+        return fieldError("seats", fmt.Sprintf("at most %d seats can be quoted at once", maxQuoteSeats))
+    }
+    seatIDs := make([]uint64, 0, len(seats))
+    for _, s := range seats {
+        seatIDs = append(seatIDs, s.ID)
+    }
+    q, err := h.Booking.Quote(ctx, show, seatIDs)
+    if err != nil {
+        return txError(c, bookingError(err))
+    }
+    items := make([]quotedSeat, 0, len(seats))
+    for _, s := range seats {
+        items = append(items, quotedSeat{
+            SeatID:     s.ID,
+            RowLabel:   s.RowLabel,
+            SeatNumber: s.SeatNumber,
+            SeatType:   s.SeatType,
+            PriceCents: q.Prices[s.ID],
+        })
+    }
+    return c.JSON(http.StatusOK, echo.Map{
+        "show_id":        showID,
+        "seats":          items,
+        "subtotal_cents": q.SubtotalCents,
+        "fees_cents":     q.FeesCents,
+        "tax_cents":      q.TaxCents,
+        "total_cents":    q.TotalCents,
+        "currency":       q.Currency,
+    })
+}
+
+// seatPosition keys a seat by row label and number; labels compare
+// case-insensitively.
+func seatPosition(row string, number uint32) string {
+    return strings.ToUpper(strings.TrimSpace(row)) + "#" + strconv.FormatUint(uint64(number), 10)
+}
@@ -0,0 +1,161 @@
+package handler
+
+// This file implements the public search endpoint.  It lets guests find
+// shows across all cinemas by title, cinema name, day and city instead of
+// drilling down cinema -> hall -> show, and the listing of every cinema's
+// shows by date.
+
+import (
+    "net/http"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/iliyamo/cinema-seat-reservation/internal/apperr"
+    "github.com/iliyamo/cinema-seat-reservation/internal/repository"
+    "github.com/labstack/echo/v4"
+)
+
+const (
+    searchDefaultPageSize = 20  // shows per page when page_size is omitted
+    searchMaxPageSize     = 100 // upper bound for page_size
+    searchCinemaLimit     = 10  // cinemas returned alongside the show page
+    listingMaxDays        = 31  // longest from..to range of GET /v1/shows
+)
+
+// Search handles GET /v1/search?q=&date=&city=&page=&page_size=.  At least
+// one of q, date (YYYY-MM-DD, UTC) or city must be supplied.  The response
+// combines a paginated list of matching scheduled shows (with hall and
+// cinema info) and, when q is present, the cinemas whose name matches.
+func (h *PublicHandler) Search(c echo.Context) error {
+    if h.SearchRepo == nil {
+        return apperr.NotImplemented("search is not available")
+    }
+    q := strings.TrimSpace(c.QueryParam("q"))
+    city := strings.TrimSpace(c.QueryParam("city"))
+    dateStr := strings.TrimSpace(c.QueryParam("date"))
+    if q == "" && city == "" && dateStr == "" {
+        return apperr.BadRequest("at least one of q, date or city is required")
+    }
+    params := repository.SearchParams{Query: q, City: city}
+    if dateStr != "" {
+        day, err := time.Parse("2006-01-02", dateStr)
+        if err != nil {
+            return apperr.BadRequest("date must be YYYY-MM-DD")
+        }
+        params.Day = day
+    }
+    page, pageSize, err := pageParams(c)
+    if err != nil {
+        return err
+    }
+    params.Limit = pageSize
+    params.Offset = (page - 1) * pageSize
+
+    ctx := c.Request().Context()
+    shows, total, err := h.SearchRepo.SearchShows(ctx, params)
+    if err != nil {
+        return apperr.Internal("database error")
+    }
+    cinemas := make([]repository.CinemaSearchResult, 0)
+    if q != "" {
+        cinemas, err = h.SearchRepo.SearchCinemas(ctx, q, city, searchCinemaLimit)
+        if err != nil {
+            return apperr.Internal("database error")
+        }
+    }
+    return c.JSON(http.StatusOK, echo.Map{
+        "shows":     shows,
+        "cinemas":   cinemas,
+        "page":      page,
+        "page_size": pageSize,
+        "total":     total,
+    })
+}
+
+// pageParams parses the page (default 1) and page_size (default 20, at
+// most 100) query parameters.
+func pageParams(c echo.Context) (page, pageSize int, err error) {
+    page, pageSize = 1, searchDefaultPageSize
+    if s := c.QueryParam("page"); s != "" {
+        n, err := strconv.Atoi(s)
+        if err != nil || n < 1 {
+            return 0, 0, apperr.BadRequest("invalid page")
+        }
+        page = n
+    }
+    if s := c.QueryParam("page_size"); s != "" {
+        n, err := strconv.Atoi(s)
+        if err != nil || n < 1 || n > searchMaxPageSize {
+            return 0, 0, apperr.BadRequest("page_size must be between 1 and 100")
+        }
+        pageSize = n
+    }
+    return page, pageSize, nil
+}
+
+// ListShows handles GET /v1/shows?date=&from=&to=&cinema_id=&city=&page=&page_size=.
+// It lists the scheduled shows of every cinema starting on one UTC day
+// (date, YYYY-MM-DD, default today) or on each day from from through to
+// (at most 31 days), with hall and cinema names, ordered by start time.
+// cinema_id and city narrow the listing to one cinema or city.
+func (h *PublicHandler) ListShows(c echo.Context) error {
+    if h.SearchRepo == nil {
+        return apperr.NotImplemented("show listing is not available")
+    }
+    params := repository.SearchParams{
+        Day:  time.Now().UTC().Truncate(24 * time.Hour),
+        City: strings.TrimSpace(c.QueryParam("city")),
+    }
+    dateStr := strings.TrimSpace(c.QueryParam("date"))
+    fromStr := strings.TrimSpace(c.QueryParam("from"))
+    toStr := strings.TrimSpace(c.QueryParam("to"))
+    var err error
+    switch {
+    case dateStr != "" && (fromStr != "" || toStr != ""):
+        return apperr.BadRequest("date cannot be combined with from and to")
+    case dateStr != "":
+        if params.Day, err = parseDateParam("date", dateStr); err != nil {
+            return err
+        }
+    case fromStr != "" || toStr != "":
+        if fromStr == "" || toStr == "" {
+            return apperr.BadRequest("from and to must be given together")
+        }
+        if params.Day, err = parseDateParam("from", fromStr); err != nil {
+            return err
+        }
+        if params.Until, err = parseDateParam("to", toStr); err != nil {
+            return err
+        }
+        if params.Until.Before(params.Day) {
+            return apperr.BadRequest("to must not be before from")
+        }
+        if params.Until.Sub(params.Day) >= listingMaxDays*24*time.Hour {
+            return apperr.BadRequest("the range may span at most 31 days")
+        }
+    }
+    if s := c.QueryParam("cinema_id"); s != "" {
+        id, err := strconv.ParseUint(s, 10, 64)
+        if err != nil || id == 0 {
+            return apperr.BadRequest("invalid cinema_id")
+        }
+        params.CinemaID = id
+    }
+    page, pageSize, err := pageParams(c)
+    if err != nil {
+        return err
+    }
+    params.Limit = pageSize
+    params.Offset = (page - 1) * pageSize
+    shows, total, err := h.SearchRepo.SearchShows(c.Request().Context(), params)
+    if err != nil {
+        return apperr.Internal("database error")
+    }
+    return c.JSON(http.StatusOK, echo.Map{
+        "items":     shows,
+        "page":      page,
+        "page_size": pageSize,
+        "total":     total,
+    })
+}
@@ -0,0 +1,85 @@
+package handler
+
+// This file converts show times between UTC, in which they are stored, and
+// the local time of the show's cinema.  Owners may send show times with an
+// explicit offset or as a wall-clock time that is read in the cinema's time
+// zone; responses carry the UTC time together with local_start_time.
+//
+// Repository models hold time.Time values, which encoding/json renders as
+// RFC3339.  Handlers that build time strings themselves use utcTime and
+// localTime so that every response uses the same format.
+
+import (
+    "time"
+
+    "github.com/iliyamo/cinema-seat-reservation/internal/repository"
+)
+
+// localLayout is a date and time without offset, read in the cinema's time
+// zone.
+const localLayout = "2006-01-02T15:04:05"
+
+// timestampHint is appended to errors about unparseable show times.
+const timestampHint = "must be an RFC3339 timestamp or a local time (e.g. 2025-08-09T10:55:13+02:00 or 2025-08-09T10:55:13)"
+
+// validTimezone reports whether tz names an IANA time zone such as
+// "Europe/Berlin" or "UTC".
+func validTimezone(tz string) bool {
+    if tz == "" || tz == "Local" {
+        return false
+    }
+    _, err := time.LoadLocation(tz)
+    return err == nil
+}
+
+// loadTimezone returns the location named tz, or UTC when tz is empty or
+// unknown.
+func loadTimezone(tz string) *time.Location {
+    if loc, err := time.LoadLocation(tz); err == nil && tz != "" && tz != "Local" {
+        return loc
+    }
+    return time.UTC
+}
+
+// parseShowTime parses a show time sent by an owner.  RFC3339 timestamps
+// carry their own offset; times without one are local to loc.  The result
+// is in UTC, truncated to the second like the DATETIME columns.
+func parseShowTime(value string, loc *time.Location) (time.Time, error) {
+    t, err := time.Parse(time.RFC3339, value)
+    if err != nil {
+        if t, err = time.ParseInLocation(localLayout, value, loc); err != nil {
+            return time.Time{}, err
+        }
+    }
+    return t.UTC().Truncate(time.Second), nil
+}
+
+// utcTime formats t as RFC3339 in UTC, or returns nil for the zero time.
+func utcTime(t time.Time) *string {
+    return localTime(t, time.UTC)
+}
+
+// localTime formats t as RFC3339 with the offset of loc, or returns nil
+// for the zero time.
+func localTime(t time.Time, loc *time.Location) *string {
+    if t.IsZero() {
+        return nil
+    }
+    s := t.In(loc).Format(time.RFC3339)
+    return &s
+}
+
+// localizeShow sets the time zone, local start time and currency of a
+// show that takes place in loc and is priced in currency.
+func localizeShow(loc *time.Location, currency string, s *repository.Show) {
+    s.Timezone = loc.String()
+    s.LocalStartTime = localTime(s.StartsAt, loc)
+    s.Currency = currency
+}
+
+// localizeShows calls localizeShow for each of shows.
+func localizeShows(loc *time.Location, currency string, shows []repository.Show) {
+    for i := range shows {
+        localizeShow(loc, currency, &shows[i])
+    }
+}
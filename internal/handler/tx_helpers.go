@@ -0,0 +1,39 @@
+package handler
+
+// This file adapts db.WithTx to Echo handlers.  A transaction closure
+// cannot write the HTTP response directly because it may run more than
+// once; instead it returns an *apperr.Error (or failTx for database
+// errors) and the handler returns txError's result after the transaction
+// has finished.
+
+import (
+    "errors"
+    "net/http"
+
+    "github.com/iliyamo/cinema-seat-reservation/internal/apperr"
+    "github.com/iliyamo/cinema-seat-reservation/internal/db"
+    "github.com/labstack/echo/v4"
+)
+
+// failTx aborts a transaction because of a database error.  The cause is
+// kept so db.WithTx can retry deadlocks and lock timeouts; others produce
+// a 500 with msg.
+func failTx(msg string, err error) error {
+    return apperr.Internal(msg).Wrap(err)
+}
+
+// txError converts the error returned by db.WithTx into the handler's
+// result.  Deadlocks and lock wait timeouts that persisted through every
+// retry yield 503 with Retry-After so clients can try again instead of
+// seeing an opaque 500.
+func txError(c echo.Context, err error) error {
+    if db.IsTransient(err) {
+        c.Response().Header().Set("Retry-After", "1")
+        return apperr.New(http.StatusServiceUnavailable, apperr.CodeBusy, "the booking system is busy, please retry").Wrap(err)
+    }
+    var ae *apperr.Error
+    if errors.As(err, &ae) {
+        return ae
+    }
+    return apperr.Internal("database transaction failed").Wrap(err)
+}
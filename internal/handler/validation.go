@@ -0,0 +1,57 @@
+package handler
+
+import (
+    "encoding/json"
+    "errors"
+    "reflect"
+
+    "github.com/iliyamo/cinema-seat-reservation/internal/apperr"
+    "github.com/iliyamo/cinema-seat-reservation/internal/validate"
+    "github.com/labstack/echo/v4"
+)
+
+// bindValid binds the request body into dst and validates it against its
+// `validate` tags (see package validate).  A body that is not valid JSON
+// yields 400; a field of the wrong JSON type or any failed rule yields 422
+// with every offending field listed in details.fields.
+func bindValid(c echo.Context, dst interface{}) error {
+    if err := c.Bind(dst); err != nil {
+        var te *json.UnmarshalTypeError
+        if errors.As(err, &te) && te.Field != "" {
+            return apperr.Validation(validate.Errors{{Field: te.Field, Message: "must be " + jsonKind(te.Type)}})
+        }
+        return apperr.BadRequest("invalid request body")
+    }
+    if errs := validate.Struct(dst); len(errs) > 0 {
+        return apperr.Validation(errs)
+    }
+    return nil
+}
+
+// jsonKind names the JSON type that decodes into t.
+func jsonKind(t reflect.Type) string {
+    for t.Kind() == reflect.Ptr {
+        t = t.Elem()
+    }
+    switch t.Kind() {
+    case reflect.String:
+        return "a string"
+    case reflect.Bool:
+        return "a boolean"
+    case reflect.Slice, reflect.Array:
+        return "an array"
+    case reflect.Struct, reflect.Map:
+        return "an object"
+    case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+        return "a non-negative integer"
+    case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+        return "an integer"
+    }
+    return "a number"
+}
+
+// fieldError returns a 422 for a single field, for checks that struct tags
+// cannot express such as comparing two fields.
+func fieldError(field, message string) error {
+    return apperr.Validation(validate.Errors{{Field: field, Message: message}})
+}
@@ -0,0 +1,93 @@
+package metrics
+
+// Booking-domain and transport metrics.  Label values are kept to small
+// fixed sets so the number of series stays bounded.
+var (
+	// HoldsCreated counts seats placed on hold.
+	HoldsCreated = NewCounter("cinema_holds_created_total",
+		"Seats placed on hold.")
+	// HoldsExpired counts holds removed because they expired, by where the
+	// expiry was noticed (request or sweeper).
+	HoldsExpired = NewCounter("cinema_holds_expired_total",
+		"Seat holds removed after expiring.", "source")
+	// HoldSweepsSkipped counts hold expiry sweeps skipped because another
+	// instance was sweeping.
+	HoldSweepsSkipped = NewCounter("cinema_hold_sweeps_skipped_total",
+		"Hold expiry sweeps skipped because another instance held the sweep lock.")
+	// SeatsRepaired counts show_seats rows whose status was corrected to
+	// match the show's holds and reservations, by source (owner or job).
+	SeatsRepaired = NewCounter("cinema_show_seats_repaired_total",
+		"Show seat statuses corrected by reconciliation.", "source")
+	// WaitlistOffers counts seat holds offered to waitlisted customers.
+	WaitlistOffers = NewCounter("cinema_waitlist_offers_total",
+		"Seat holds offered to customers on a show's waitlist.")
+	// ReservationsConfirmed counts confirmed reservations by channel
+	// (single, bundle, checkout, cart or grpc).
+	ReservationsConfirmed = NewCounter("cinema_reservations_confirmed_total",
+		"Reservations confirmed.", "channel")
+	// ReservationsCancelled counts cancellations by who cancelled
+	// (customer or owner), or show for reservations of a cancelled show.
+	ReservationsCancelled = NewCounter("cinema_reservations_cancelled_total",
+		"Reservations cancelled.", "actor")
+	// ReservationsExpired counts PENDING reservations dropped because
+	// they were not paid within the payment window.
+	ReservationsExpired = NewCounter("cinema_reservations_expired_total",
+		"PENDING reservations expired after the payment window.")
+	// SeatAttendance counts reserved seats checked in or marked as no-show,
+	// by status (checked_in or no_show).
+	SeatAttendance = NewCounter("cinema_seat_attendance_total",
+		"Reserved seats checked in or marked as no-show.", "status")
+	// SeatConflicts counts requests rejected because a seat was already
+	// held or reserved, by operation (hold, confirm or bundle).
+	SeatConflicts = NewCounter("cinema_seat_conflicts_total",
+		"Requests rejected because seats were unavailable.", "op")
+	// Notifications counts notification delivery attempts by outcome
+	// (sent, retry or failed).
+	Notifications = NewCounter("cinema_notifications_total",
+		"Notification delivery attempts.", "outcome")
+	// BookingEvents counts booking event publication attempts by outcome
+	// (published, retry or failed).
+	BookingEvents = NewCounter("cinema_booking_events_total",
+		"Booking event publication attempts.", "outcome")
+	// WebhookDeliveries counts webhook delivery attempts by outcome
+	// (delivered, retry or failed).
+	WebhookDeliveries = NewCounter("cinema_webhook_deliveries_total",
+		"Webhook delivery attempts.", "outcome")
+	// TxDuration observes booking transaction durations, including
+	// retries, by outcome (commit or rollback).
+	TxDuration = NewHistogram("cinema_db_tx_duration_seconds",
+		"Duration of booking database transactions, including retries.", DefaultBuckets, "outcome")
+	// TxRetries counts transaction attempts retried after a deadlock or
+	// lock wait timeout.
+	TxRetries = NewCounter("cinema_db_tx_retries_total",
+		"Transaction attempts retried after a deadlock or lock wait timeout.")
+	// QuotaRejections counts requests rejected by the per-account quota.
+	QuotaRejections = NewCounter("cinema_quota_rejections_total",
+		"Requests rejected with 429 by the per-account quota.")
+	// RateLimitRejections counts requests rejected by the rate limiter,
+	// by class (public, auth, customer or owner).
+	RateLimitRejections = NewCounter("cinema_rate_limit_rejections_total",
+		"Requests rejected with 429 by the rate limiter.", "class")
+	// BotCheckRejections counts requests refused by the bot check, by
+	// reason (missing or failed).
+	BotCheckRejections = NewCounter("cinema_bot_check_rejections_total",
+		"Requests refused with 403 by the bot check.", "reason")
+	// LoginLockouts counts accounts and client addresses locked out after
+	// repeated failed logins, by scope (ACCOUNT or IP).
+	LoginLockouts = NewCounter("cinema_login_lockouts_total",
+		"Login lockouts after repeated failures.", "scope")
+	// HTTPRequests counts handled requests by method, route pattern and
+	// status code.
+	HTTPRequests = NewCounter("cinema_http_requests_total",
+		"HTTP requests handled.", "method", "route", "status")
+	// HTTPDuration observes request latency by method and route pattern.
+	HTTPDuration = NewHistogram("cinema_http_request_duration_seconds",
+		"HTTP request latency.", DefaultBuckets, "method", "route")
+	// SlowQueries counts database statements slower than DB_SLOW_QUERY_MS
+	// by repository method (or SQL verb outside the repositories).
+	SlowQueries = NewCounter("cinema_db_slow_queries_total",
+		"Database statements slower than the slow query threshold.", "query")
+	// GRPCRequests counts handled gRPC calls by method and status code.
+	GRPCRequests = NewCounter("cinema_grpc_requests_total",
+		"gRPC calls handled.", "method", "code")
+)
@@ -0,0 +1,64 @@
+package middleware
+
+import (
+    "errors"
+    "log"
+    "net/http"
+    "strings"
+
+    "github.com/iliyamo/cinema-seat-reservation/internal/apperr"
+    "github.com/iliyamo/cinema-seat-reservation/internal/metrics"
+    "github.com/iliyamo/cinema-seat-reservation/internal/service"
+    "github.com/iliyamo/cinema-seat-reservation/internal/utils"
+    "github.com/labstack/echo/v4"
+)
+
+// Bot check headers.  Clients send the solved challenge in
+// BotTokenHeader, or the pass they were given in BotPassHeader; a
+// verified token is answered with a new pass in BotPassHeader.
+const (
+    BotTokenHeader = "X-Bot-Token"
+    BotPassHeader  = "X-Bot-Pass"
+)
+
+// BotCheck returns a route middleware that refuses anonymous requests
+// which carry neither a valid bot pass nor a token the guard verifies,
+// with 403 BOT_CHECK_REQUIRED.  Requests with a valid Bearer access
+// token skip the check.  When the provider cannot be reached the request
+// is allowed and the error logged, so an outage of the CAPTCHA service
+// does not lock everyone out.  Passes are bound to the client address
+// from c.RealIP, which trusts forwarding headers only as ClientIP allows,
+// so a pass cannot be replayed from elsewhere by claiming its address.
+// A nil guard disables the check.
+func BotCheck(guard *service.BotGuard, keys *utils.KeySet) echo.MiddlewareFunc {
+    return func(next echo.HandlerFunc) echo.HandlerFunc {
+        if guard == nil {
+            return next
+        }
+        return func(c echo.Context) error {
+            req := c.Request()
+            if _, _, ok := bearerSubject(req.Header.Get("Authorization"), keys); ok {
+                return next(c)
+            }
+            ip := c.RealIP()
+            if pass := req.Header.Get(BotPassHeader); pass != "" && guard.CheckPass(pass, ip) {
+                return next(c)
+            }
+            token := strings.TrimSpace(req.Header.Get(BotTokenHeader))
+            if token == "" {
+                metrics.BotCheckRejections.Inc("missing")
+                return apperr.New(http.StatusForbidden, apperr.CodeBotCheck, "bot check required; see GET /v1/auth/bot-check")
+            }
+            if err := guard.Verifier.Verify(req.Context(), token, ip); err != nil {
+                if errors.Is(err, service.ErrBotCheckFailed) {
+                    metrics.BotCheckRejections.Inc("failed")
+                    return apperr.New(http.StatusForbidden, apperr.CodeBotCheck, "bot check failed")
+                }
+                log.Printf("bot check: verify: %v", err)
+                return next(c)
+            }
+            c.Response().Header().Set(BotPassHeader, guard.IssuePass(ip))
+            return next(c)
+        }
+    }
+}
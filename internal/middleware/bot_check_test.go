@@ -0,0 +1,61 @@
+package middleware
+
+import (
+    "context"
+    "net/http"
+    "net/http/httptest"
+    "testing"
+    "time"
+
+    "github.com/labstack/echo/v4"
+
+    "github.com/iliyamo/cinema-seat-reservation/internal/apperr"
+    "github.com/iliyamo/cinema-seat-reservation/internal/service"
+)
+
+// acceptAll is a BotVerifier that accepts every token.
+type acceptAll struct{}
+
+func (acceptAll) Verify(context.Context, string, string) error { return nil }
+
+// botCheckGet sends GET /v1/shows/1/seats from peer with X-Forwarded-For
+// set to forwardedFor and the given bot check headers.
+func botCheckGet(e *echo.Echo, peer, forwardedFor string, hdr map[string]string) *httptest.ResponseRecorder {
+    req := httptest.NewRequest(http.MethodGet, "/v1/shows/1/seats", nil)
+    req.RemoteAddr = peer + ":40000"
+    req.Header.Set(echo.HeaderXForwardedFor, forwardedFor)
+    req.Header.Set(echo.HeaderXRealIP, forwardedFor)
+    for k, v := range hdr {
+        req.Header.Set(k, v)
+    }
+    rec := httptest.NewRecorder()
+    e.ServeHTTP(rec, req)
+    return rec
+}
+
+func TestBotCheckPassBoundToPeerAddress(t *testing.T) {
+    guard := &service.BotGuard{Verifier: acceptAll{}, PassKey: []byte("test-key"), PassTTL: time.Minute}
+    e := echo.New()
+    e.HTTPErrorHandler = apperr.Handler
+    e.IPExtractor = ClientIP(nil)
+    e.GET("/v1/shows/:id/seats", func(c echo.Context) error { return c.NoContent(http.StatusOK) }, BotCheck(guard, nil))
+
+    // a pass solved elsewhere cannot be replayed by claiming its address
+    pass := guard.IssuePass("203.0.113.7")
+    if rec := botCheckGet(e, "192.0.2.10", "203.0.113.7", map[string]string{BotPassHeader: pass}); rec.Code != http.StatusForbidden {
+        t.Fatalf("replayed pass with spoofed X-Forwarded-For: status %d, want 403", rec.Code)
+    }
+
+    // a verified token earns a pass for the peer, not the claimed address
+    rec := botCheckGet(e, "192.0.2.10", "203.0.113.7", map[string]string{BotTokenHeader: "solved"})
+    if rec.Code != http.StatusOK {
+        t.Fatalf("verified token: status %d, want 200", rec.Code)
+    }
+    issued := rec.Header().Get(BotPassHeader)
+    if !guard.CheckPass(issued, "192.0.2.10") || guard.CheckPass(issued, "203.0.113.7") {
+        t.Fatal("pass was not issued to the peer address")
+    }
+    if rec := botCheckGet(e, "192.0.2.10", "198.51.100.4", map[string]string{BotPassHeader: issued}); rec.Code != http.StatusOK {
+        t.Fatalf("own pass: status %d, want 200", rec.Code)
+    }
+}
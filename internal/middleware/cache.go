@@ -0,0 +1,88 @@
+package middleware
+
+import (
+    "bytes"
+    "net/http"
+    "strconv"
+    "strings"
+
+    "github.com/iliyamo/cinema-seat-reservation/internal/cache"
+    "github.com/labstack/echo/v4"
+)
+
+// CacheInvalidation returns a global middleware that defers the cache
+// invalidations of a request (see cache.Defer) until its handler has
+// returned, so they apply after the handler's transactions committed.
+func CacheInvalidation() echo.MiddlewareFunc {
+    return func(next echo.HandlerFunc) echo.HandlerFunc {
+        return func(c echo.Context) error {
+            ctx, flush := cache.Defer(c.Request().Context())
+            c.SetRequest(c.Request().WithContext(ctx))
+            defer flush()
+            return next(c)
+        }
+    }
+}
+
+// ResponseCache returns a route middleware that serves GET responses from
+// store.  Entries are keyed by the request URI; responses of routes under
+// /v1/shows/:id are tied to that show so seat changes drop them.  Only
+// 200 responses are cached.  Responses carry X-Cache: HIT or MISS.
+// Requests authenticated by an earlier OptionalJWT get a response for
+// that user and bypass the cache.
+func ResponseCache(store *cache.Store) echo.MiddlewareFunc {
+    return func(next echo.HandlerFunc) echo.HandlerFunc {
+        return func(c echo.Context) error {
+            req := c.Request()
+            if req.Method != http.MethodGet || c.Get("user_id") != nil {
+                return next(c)
+            }
+            key := req.URL.RequestURI()
+            res := c.Response()
+            if e, ok := store.Get(key); ok {
+                res.Header().Set("X-Cache", "HIT")
+                if e.ETag != "" {
+                    res.Header().Set("ETag", e.ETag)
+                }
+                if e.LastModified != "" {
+                    res.Header().Set(echo.HeaderLastModified, e.LastModified)
+                }
+                return c.Blob(e.Status, e.ContentType, e.Body)
+            }
+            res.Header().Set("X-Cache", "MISS")
+            rec := &recordingWriter{ResponseWriter: res.Writer}
+            res.Writer = rec
+            err := next(c)
+            res.Writer = rec.ResponseWriter
+            if err != nil || res.Status != http.StatusOK {
+                return err
+            }
+            var showID uint64
+            if strings.HasPrefix(c.Path(), "/v1/shows/:id") {
+                showID, _ = strconv.ParseUint(c.Param("id"), 10, 64)
+            }
+            store.Set(key, cache.Entry{
+                Status:       res.Status,
+                ContentType:  res.Header().Get(echo.HeaderContentType),
+                ETag:         res.Header().Get("ETag"),
+                LastModified: res.Header().Get(echo.HeaderLastModified),
+                Body:         rec.body.Bytes(),
+            }, showID)
+            return nil
+        }
+    }
+}
+
+// recordingWriter passes a response through while keeping a copy of its
+// body.
+type recordingWriter struct {
+    http.ResponseWriter
+    body bytes.Buffer
+}
+
+func (w *recordingWriter) Write(b []byte) (int, error) {
+    w.body.Write(b)
+    return w.ResponseWriter.Write(b)
+}
+
+func (w *recordingWriter) Unwrap() http.ResponseWriter { return w.ResponseWriter }
@@ -0,0 +1,25 @@
+package middleware
+
+import (
+    "net"
+
+    "github.com/labstack/echo/v4"
+)
+
+// ClientIP returns the echo.IPExtractor that c.RealIP uses, and with it
+// the login lockouts, rate limits, bot check and audit log.  Without
+// trusted proxies the client is the connecting peer and forwarding
+// headers are ignored, so a client cannot pick its own address.  With
+// them, X-Forwarded-For is read from the right, skipping the trusted
+// proxies, and the first other address is the client; requests that did
+// not come through a trusted proxy use the peer address.
+func ClientIP(trusted []*net.IPNet) echo.IPExtractor {
+    if len(trusted) == 0 {
+        return echo.ExtractIPDirect()
+    }
+    opts := []echo.TrustOption{echo.TrustLoopback(false), echo.TrustLinkLocal(false), echo.TrustPrivateNet(false)}
+    for _, n := range trusted {
+        opts = append(opts, echo.TrustIPRange(n))
+    }
+    return echo.ExtractIPFromXFFHeader(opts...)
+}
@@ -0,0 +1,85 @@
+package middleware
+
+import (
+    "crypto/sha256"
+    "encoding/hex"
+    "net/http"
+    "strings"
+    "time"
+
+    "github.com/labstack/echo/v4"
+)
+
+// ConditionalGET returns a route middleware that answers conditional GET
+// requests.  Every 200 response gets a weak ETag: the one the handler set
+// (seat maps derive theirs from the show's seat versions), or else a hash
+// of the body.  When If-None-Match lists that ETag, or If-Modified-Since
+// is not before the handler's Last-Modified and If-None-Match is absent,
+// the body is dropped and 304 Not Modified is sent instead.  Handlers may
+// also answer 304 themselves before building a response.  Place it
+// before ResponseCache so cached responses are covered.
+func ConditionalGET() echo.MiddlewareFunc {
+    return func(next echo.HandlerFunc) echo.HandlerFunc {
+        return func(c echo.Context) error {
+            req := c.Request()
+            if req.Method != http.MethodGet {
+                return next(c)
+            }
+            res := c.Response()
+            buf := &bufferedWriter{ResponseWriter: res.Writer}
+            res.Writer = buf
+            err := next(c)
+            res.Writer = buf.ResponseWriter
+            if buf.status == 0 {
+                return err // nothing written; the error handler responds
+            }
+            w := buf.ResponseWriter
+            if buf.status == http.StatusOK {
+                etag := res.Header().Get("ETag")
+                if etag == "" {
+                    sum := sha256.Sum256(buf.body.Bytes())
+                    etag = `W/"` + hex.EncodeToString(sum[:16]) + `"`
+                    res.Header().Set("ETag", etag)
+                }
+                if notModified(req, etag, res.Header().Get(echo.HeaderLastModified)) {
+                    res.Header().Del(echo.HeaderContentType)
+                    res.Header().Del(echo.HeaderContentLength)
+                    w.WriteHeader(http.StatusNotModified)
+                    return err
+                }
+            }
+            w.WriteHeader(buf.status)
+            _, _ = w.Write(buf.body.Bytes())
+            return err
+        }
+    }
+}
+
+// NotModified reports whether a GET with the given validators can be
+// answered 304 (see ConditionalGET).  Handlers use it to skip building
+// a response whose ETag they can compute cheaply.
+func NotModified(c echo.Context, etag string, lastModified time.Time) bool {
+    lm := ""
+    if !lastModified.IsZero() {
+        lm = lastModified.UTC().Format(http.TimeFormat)
+    }
+    return notModified(c.Request(), etag, lm)
+}
+
+func notModified(req *http.Request, etag, lastModified string) bool {
+    if inm := req.Header.Get("If-None-Match"); inm != "" {
+        for _, t := range strings.Split(inm, ",") {
+            t = strings.TrimSpace(t)
+            if t == "*" || strings.TrimPrefix(t, "W/") == strings.TrimPrefix(etag, "W/") {
+                return true
+            }
+        }
+        return false
+    }
+    ims, err := http.ParseTime(req.Header.Get(echo.HeaderIfModifiedSince))
+    if err != nil || lastModified == "" {
+        return false
+    }
+    lm, err := http.ParseTime(lastModified)
+    return err == nil && !lm.After(ims)
+}
@@ -0,0 +1,204 @@
+package middleware
+
+import (
+    "bufio"
+    "bytes"
+    "encoding/json"
+    "mime"
+    "net"
+    "net/http"
+    "strconv"
+    "strings"
+    "unicode"
+
+    "github.com/labstack/echo/v4"
+)
+
+// JSONFormat returns a global middleware that lets clients choose how JSON
+// responses are serialised without a breaking change for existing ones.
+// Two options are recognised, either as query parameters or as parameters
+// of the Accept header (query wins):
+//
+//     ?json_case=camel        Accept: application/json; case=camel
+//     ?error_format=legacy    Accept: application/json; errors=legacy
+//
+// json_case=camel rewrites every object key from snake_case to camelCase
+// (seat_ids becomes seatIds).  error_format=legacy renders error envelopes
+// in the pre-envelope shape {"error": message, "code": ..., <details>}.
+// The defaults, snake_case keys and {code, message, details} errors,
+// leave responses untouched and are served without buffering.
+func JSONFormat() echo.MiddlewareFunc {
+    return func(next echo.HandlerFunc) echo.HandlerFunc {
+        return func(c echo.Context) error {
+            camel, legacy := jsonFormatOptions(c.Request())
+            c.Response().Header().Add("Vary", "Accept")
+            if !camel && !legacy {
+                return next(c)
+            }
+            res := c.Response()
+            orig := res.Writer
+            buf := &bufferedWriter{ResponseWriter: orig, status: http.StatusOK}
+            res.Writer = buf
+            // Errors are rendered here rather than after this middleware
+            // returns so that error bodies are rewritten as well.
+            if err := next(c); err != nil {
+                c.Error(err)
+            }
+            res.Writer = orig
+            body := buf.body.Bytes()
+            if buf.hijacked {
+                return nil
+            }
+            if isJSON(orig.Header().Get(echo.HeaderContentType)) && len(body) > 0 {
+                if out, ok := rewriteJSON(body, camel, legacy && buf.status >= 400); ok {
+                    body = out
+                }
+            }
+            orig.Header().Del(echo.HeaderContentLength)
+            if len(body) > 0 {
+                orig.Header().Set(echo.HeaderContentLength, strconv.Itoa(len(body)))
+            }
+            orig.WriteHeader(buf.status)
+            _, err := orig.Write(body)
+            return err
+        }
+    }
+}
+
+// jsonFormatOptions reads the case and error format options.
+func jsonFormatOptions(r *http.Request) (camel, legacy bool) {
+    var caseOpt, errOpt string
+    for _, part := range strings.Split(r.Header.Get(echo.HeaderAccept), ",") {
+        mt, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+        if err != nil || (mt != echo.MIMEApplicationJSON && mt != "*/*") {
+            continue
+        }
+        if v, ok := params["case"]; ok {
+            caseOpt = v
+        }
+        if v, ok := params["errors"]; ok {
+            errOpt = v
+        }
+    }
+    q := r.URL.Query()
+    if v := q.Get("json_case"); v != "" {
+        caseOpt = v
+    }
+    if v := q.Get("error_format"); v != "" {
+        errOpt = v
+    }
+    return strings.EqualFold(caseOpt, "camel"), strings.EqualFold(errOpt, "legacy")
+}
+
+func isJSON(contentType string) bool {
+    mt, _, err := mime.ParseMediaType(contentType)
+    return err == nil && (mt == echo.MIMEApplicationJSON || strings.HasSuffix(mt, "+json"))
+}
+
+// rewriteJSON applies the requested transformations to a JSON document.
+// ok is false when body is not valid JSON, in which case it is sent as is.
+func rewriteJSON(body []byte, camel, legacyError bool) ([]byte, bool) {
+    dec := json.NewDecoder(bytes.NewReader(body))
+    dec.UseNumber()
+    var v interface{}
+    if err := dec.Decode(&v); err != nil {
+        return nil, false
+    }
+    if legacyError {
+        v = legacyErrorBody(v)
+    }
+    if camel {
+        v = camelKeys(v)
+    }
+    var out bytes.Buffer
+    enc := json.NewEncoder(&out)
+    enc.SetEscapeHTML(false)
+    if err := enc.Encode(v); err != nil {
+        return nil, false
+    }
+    return out.Bytes(), true
+}
+
+// legacyErrorBody converts {code, message, details} into the original
+// {"error": message} shape, keeping code and flattening object details
+// into the top level as the handlers used to.
+func legacyErrorBody(v interface{}) interface{} {
+    m, ok := v.(map[string]interface{})
+    if !ok {
+        return v
+    }
+    msg, hasMsg := m["message"]
+    code, hasCode := m["code"]
+    if !hasMsg || !hasCode {
+        return v
+    }
+    out := map[string]interface{}{}
+    if details, ok := m["details"].(map[string]interface{}); ok {
+        for k, d := range details {
+            out[k] = d
+        }
+    }
+    out["error"] = msg
+    out["code"] = code
+    return out
+}
+
+// camelKeys recursively rewrites object keys to camelCase.
+func camelKeys(v interface{}) interface{} {
+    switch t := v.(type) {
+    case map[string]interface{}:
+        out := make(map[string]interface{}, len(t))
+        for k, val := range t {
+            out[snakeToCamel(k)] = camelKeys(val)
+        }
+        return out
+    case []interface{}:
+        for i := range t {
+            t[i] = camelKeys(t[i])
+        }
+        return t
+    }
+    return v
+}
+
+// snakeToCamel converts seat_ids to seatIds.  Keys without underscores are
+// returned unchanged.
+func snakeToCamel(s string) string {
+    if !strings.Contains(s, "_") {
+        return s
+    }
+    var b strings.Builder
+    upper := false
+    for i, r := range s {
+        if r == '_' && i > 0 {
+            upper = true
+            continue
+        }
+        if upper {
+            r = unicode.ToUpper(r)
+            upper = false
+        }
+        b.WriteRune(r)
+    }
+    return b.String()
+}
+
+// bufferedWriter captures the status and body written by the handler.
+type bufferedWriter struct {
+    http.ResponseWriter
+    status   int
+    body     bytes.Buffer
+    hijacked bool
+}
+
+func (w *bufferedWriter) WriteHeader(status int) { w.status = status }
+
+func (w *bufferedWriter) Write(b []byte) (int, error) { return w.body.Write(b) }
+
+// Flush is a no-op: the response is written once the handler returns.
+func (w *bufferedWriter) Flush() {}
+
+func (w *bufferedWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+    w.hijacked = true
+    return http.NewResponseController(w.ResponseWriter).Hijack()
+}
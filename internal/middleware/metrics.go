@@ -0,0 +1,34 @@
+package middleware
+
+import (
+    "strconv"
+    "time"
+
+    "github.com/iliyamo/cinema-seat-reservation/internal/metrics"
+    "github.com/labstack/echo/v4"
+)
+
+// Metrics returns a global middleware that records request counts and
+// latency per route.  Requests are labelled with the route pattern
+// (/v1/shows/:id) rather than the raw path so the number of series stays
+// bounded; requests that match no route are labelled "unmatched".  It
+// should be registered first so the latency covers the other middleware.
+func Metrics() echo.MiddlewareFunc {
+    return func(next echo.HandlerFunc) echo.HandlerFunc {
+        return func(c echo.Context) error {
+            start := time.Now()
+            // Render errors here so the recorded status is the one sent.
+            if err := next(c); err != nil {
+                c.Error(err)
+            }
+            route := c.Path()
+            if route == "" || route == "/*" {
+                route = "unmatched"
+            }
+            method := c.Request().Method
+            metrics.HTTPRequests.Inc(method, route, strconv.Itoa(c.Response().Status))
+            metrics.HTTPDuration.Observe(time.Since(start).Seconds(), method, route)
+            return nil
+        }
+    }
+}
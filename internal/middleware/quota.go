@@ -0,0 +1,99 @@
+package middleware
+
+import (
+    "log"
+    "net/http"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/golang-jwt/jwt/v5"
+    "github.com/iliyamo/cinema-seat-reservation/internal/apperr"
+    "github.com/iliyamo/cinema-seat-reservation/internal/metrics"
+    "github.com/iliyamo/cinema-seat-reservation/internal/service"
+    "github.com/iliyamo/cinema-seat-reservation/internal/utils"
+    "github.com/labstack/echo/v4"
+)
+
+// Quota returns a global middleware that enforces per-account request
+// quotas.  It identifies the account from a valid Bearer access token;
+// requests without one (public browse, login) pass through untouched and
+// remain subject only to the route's own authentication.  ADMIN accounts
+// are never limited.  Quota headers are set on every counted response and
+// exceeding a limit yields 429 with Retry-After.  If the tracker cannot
+// load the account's quota the request is allowed rather than failing
+// closed.
+func Quota(tracker *service.QuotaTracker, keys *utils.KeySet) echo.MiddlewareFunc {
+    return func(next echo.HandlerFunc) echo.HandlerFunc {
+        return func(c echo.Context) error {
+            userID, role, ok := bearerSubject(c.Request().Header.Get("Authorization"), keys)
+            if !ok || role == "ADMIN" {
+                return next(c)
+            }
+            st, err := tracker.Allow(c.Request().Context(), userID)
+            if err != nil {
+                log.Printf("quota check failed for user %d: %v", userID, err)
+                return next(c)
+            }
+            if !st.Limited {
+                return next(c)
+            }
+            hdr := c.Response().Header()
+            if st.DailyLimit != nil {
+                hdr.Set("X-Quota-Daily-Limit", strconv.FormatUint(uint64(*st.DailyLimit), 10))
+                hdr.Set("X-Quota-Daily-Remaining", strconv.FormatUint(remaining(*st.DailyLimit, st.DailyUsed), 10))
+            }
+            if st.MonthlyLimit != nil {
+                hdr.Set("X-Quota-Monthly-Limit", strconv.FormatUint(uint64(*st.MonthlyLimit), 10))
+                hdr.Set("X-Quota-Monthly-Remaining", strconv.FormatUint(remaining(*st.MonthlyLimit, st.MonthlyUsed), 10))
+            }
+            if !st.Allowed {
+                retry := int64(time.Until(st.ResetAt)/time.Second) + 1
+                hdr.Set("X-Quota-Reset", st.ResetAt.Format(time.RFC3339))
+                hdr.Set("Retry-After", strconv.FormatInt(retry, 10))
+                metrics.QuotaRejections.Inc()
+                return apperr.New(http.StatusTooManyRequests, apperr.CodeQuotaExceeded, "request quota exceeded")
+            }
+            return next(c)
+        }
+    }
+}
+
+// remaining returns limit-used, floored at zero.
+func remaining(limit uint32, used uint64) uint64 {
+    if used >= uint64(limit) {
+        return 0
+    }
+    return uint64(limit) - used
+}
+
+// bearerSubject extracts the numeric subject and role from a valid Bearer
+// token signed by a key in keys.  ok is false for missing, malformed or
+// invalid tokens.
+func bearerSubject(auth string, keys *utils.KeySet) (userID uint64, role string, ok bool) {
+    if !strings.HasPrefix(auth, "Bearer ") {
+        return 0, "", false
+    }
+    tok, err := keys.Parse(strings.TrimPrefix(auth, "Bearer "))
+    if err != nil || !tok.Valid {
+        return 0, "", false
+    }
+    claims, ok := tok.Claims.(jwt.MapClaims)
+    if !ok {
+        return 0, "", false
+    }
+    switch v := claims["sub"].(type) {
+    case string:
+        n, err := strconv.ParseUint(v, 10, 64)
+        if err != nil {
+            return 0, "", false
+        }
+        userID = n
+    case float64:
+        userID = uint64(v)
+    default:
+        return 0, "", false
+    }
+    role, _ = claims["role"].(string)
+    return userID, role, userID != 0
+}
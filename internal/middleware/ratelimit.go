@@ -0,0 +1,96 @@
+package middleware
+
+import (
+    "net/http"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/iliyamo/cinema-seat-reservation/internal/apperr"
+    "github.com/iliyamo/cinema-seat-reservation/internal/metrics"
+    "github.com/iliyamo/cinema-seat-reservation/internal/service"
+    "github.com/iliyamo/cinema-seat-reservation/internal/utils"
+    "github.com/labstack/echo/v4"
+)
+
+// RateLimit returns a global middleware that applies the limiter's token
+// buckets by route class (see RateClass).  Authenticated requests are
+// counted per account and anonymous ones per client address (c.RealIP,
+// which trusts forwarding headers only as ClientIP allows); ADMIN
+// accounts, requests made with an API key (see APIKeyAuth, which
+// applies the key's own budget) and routes outside /v1 (health,
+// readiness, metrics) are not limited.  Limited responses carry X-RateLimit-Limit and
+// X-RateLimit-Remaining, and an exhausted bucket yields 429 with
+// Retry-After.
+func RateLimit(limiter *service.RateLimiter, keys *utils.KeySet) echo.MiddlewareFunc {
+    return func(next echo.HandlerFunc) echo.HandlerFunc {
+        return func(c echo.Context) error {
+            req := c.Request()
+            if !strings.HasPrefix(c.Path(), "/v1/") || viaAPIKey(c) {
+                return next(c)
+            }
+            userID, role, ok := bearerSubject(req.Header.Get("Authorization"), keys)
+            if ok && role == "ADMIN" {
+                return next(c)
+            }
+            key := "ip:" + c.RealIP()
+            if ok {
+                key = "user:" + strconv.FormatUint(userID, 10)
+            }
+            class := RateClass(req.Method, c.Path(), role)
+            st := limiter.Allow(class, key)
+            if st.Limit == 0 {
+                return next(c)
+            }
+            hdr := c.Response().Header()
+            hdr.Set("X-RateLimit-Limit", strconv.Itoa(st.Limit))
+            hdr.Set("X-RateLimit-Remaining", strconv.Itoa(st.Remaining))
+            if !st.Allowed {
+                hdr.Set("Retry-After", strconv.FormatInt(int64(st.RetryAfter/time.Second)+1, 10))
+                metrics.RateLimitRejections.Inc(class)
+                return apperr.New(http.StatusTooManyRequests, apperr.CodeRateLimited, "too many requests")
+            }
+            return next(c)
+        }
+    }
+}
+
+// IPRateLimit returns a global middleware with one token bucket per
+// client address (class service.RateClassIP, keyed on c.RealIP as set
+// up by ClientIP) for every /v1 request, signed in or not.  It complements RateLimit, which counts signed-in
+// clients per account: many accounts or anonymous clients behind one
+// address share this budget.  ADMIN accounts are not limited.
+func IPRateLimit(limiter *service.RateLimiter, keys *utils.KeySet) echo.MiddlewareFunc {
+    return func(next echo.HandlerFunc) echo.HandlerFunc {
+        return func(c echo.Context) error {
+            if !strings.HasPrefix(c.Path(), "/v1/") {
+                return next(c)
+            }
+            if _, role, ok := bearerSubject(c.Request().Header.Get("Authorization"), keys); ok && role == "ADMIN" {
+                return next(c)
+            }
+            st := limiter.Allow(service.RateClassIP, c.RealIP())
+            if st.Allowed {
+                return next(c)
+            }
+            c.Response().Header().Set("Retry-After", strconv.FormatInt(int64(st.RetryAfter/time.Second)+1, 10))
+            metrics.RateLimitRejections.Inc(service.RateClassIP)
+            return apperr.New(http.StatusTooManyRequests, apperr.CodeRateLimited, "too many requests from this address")
+        }
+    }
+}
+
+// RateClass returns the rate limit class of a request to the route
+// pattern path by a client with role (empty when anonymous): the auth
+// endpoints, reads, and writes by owners and staff or by everyone else.
+func RateClass(method, path, role string) string {
+    switch {
+    case strings.HasPrefix(path, "/v1/auth/") || path == "/v1/logout":
+        return service.RateClassAuth
+    case method == http.MethodGet || method == http.MethodHead:
+        return service.RateClassPublic
+    case role == "OWNER" || role == "STAFF":
+        return service.RateClassOwner
+    }
+    return service.RateClassCustomer
+}
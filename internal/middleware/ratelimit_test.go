@@ -0,0 +1,61 @@
+package middleware
+
+import (
+    "fmt"
+    "net/http"
+    "net/http/httptest"
+    "testing"
+
+    "github.com/labstack/echo/v4"
+
+    "github.com/iliyamo/cinema-seat-reservation/internal/apperr"
+    "github.com/iliyamo/cinema-seat-reservation/internal/service"
+)
+
+// limitedServer serves GET /v1/shows behind mw with the client address
+// taken from the connecting peer, as without TRUSTED_PROXIES.
+func limitedServer(mw echo.MiddlewareFunc) *echo.Echo {
+    e := echo.New()
+    e.HTTPErrorHandler = apperr.Handler
+    e.IPExtractor = ClientIP(nil)
+    e.Use(mw)
+    e.GET("/v1/shows", func(c echo.Context) error { return c.NoContent(http.StatusOK) })
+    return e
+}
+
+// spoofedGets sends n requests from one peer, each claiming a different
+// address in X-Forwarded-For and X-Real-IP, and returns their statuses.
+func spoofedGets(e *echo.Echo, n int) []int {
+    codes := make([]int, n)
+    for i := range codes {
+        req := httptest.NewRequest(http.MethodGet, "/v1/shows", nil)
+        req.RemoteAddr = "192.0.2.10:40000"
+        spoof := fmt.Sprintf("203.0.113.%d", i+1)
+        req.Header.Set(echo.HeaderXForwardedFor, spoof)
+        req.Header.Set(echo.HeaderXRealIP, spoof)
+        rec := httptest.NewRecorder()
+        e.ServeHTTP(rec, req)
+        codes[i] = rec.Code
+    }
+    return codes
+}
+
+func TestIPRateLimitIgnoresSpoofedForwardedFor(t *testing.T) {
+    limiter := service.NewRateLimiter(map[string]service.RateLimitPolicy{
+        service.RateClassIP: {Burst: 2, PerMinute: 1},
+    })
+    codes := spoofedGets(limitedServer(IPRateLimit(limiter, nil)), 3)
+    if codes[0] != http.StatusOK || codes[1] != http.StatusOK || codes[2] != http.StatusTooManyRequests {
+        t.Fatalf("statuses %v, want [200 200 429]", codes)
+    }
+}
+
+func TestRateLimitIgnoresSpoofedForwardedFor(t *testing.T) {
+    limiter := service.NewRateLimiter(map[string]service.RateLimitPolicy{
+        service.RateClassPublic: {Burst: 2, PerMinute: 1},
+    })
+    codes := spoofedGets(limitedServer(RateLimit(limiter, nil)), 3)
+    if codes[0] != http.StatusOK || codes[1] != http.StatusOK || codes[2] != http.StatusTooManyRequests {
+        t.Fatalf("statuses %v, want [200 200 429]", codes)
+    }
+}
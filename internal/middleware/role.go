@@ -1,7 +1,7 @@
 package middleware // middleware provides shared request processing for handlers
 
 import (
-    "net/http" // http package defines standard HTTP status codes
+    "github.com/iliyamo/cinema-seat-reservation/internal/apperr" // apperr builds error responses
     "github.com/labstack/echo/v4" // echo provides middleware chaining and context
 )
 
@@ -28,7 +28,7 @@ func RequireRole(roles ...string) echo.MiddlewareFunc {
             role, ok := v.(string)
             if !ok || !allowed[role] {
                 // If role is missing or not allowed, return 403
-                return c.JSON(http.StatusForbidden, map[string]string{"error": "forbidden"})
+                return apperr.Forbidden("forbidden")
             }
             // Otherwise call the next handler in the chain
             return next(c)
@@ -0,0 +1,38 @@
+package middleware
+
+import (
+    "github.com/iliyamo/cinema-seat-reservation/internal/apperr"
+    "github.com/iliyamo/cinema-seat-reservation/internal/permissions"
+    "github.com/labstack/echo/v4"
+)
+
+// RequireScope returns a middleware that allows the request only when the
+// access token grants every one of scopes; otherwise it responds 403.  It
+// must run after JWTAuth, which stores the token's scopes in the context.
+func RequireScope(scopes ...permissions.Scope) echo.MiddlewareFunc {
+    return func(next echo.HandlerFunc) echo.HandlerFunc {
+        return func(c echo.Context) error {
+            granted, _ := c.Get("scopes").(permissions.Set)
+            if !granted.HasAll(scopes...) {
+                return apperr.Forbidden("missing required scope")
+            }
+            return next(c)
+        }
+    }
+}
+
+// RequireAnyScope is like RequireScope but allows the request when the
+// access token grants at least one of scopes.
+func RequireAnyScope(scopes ...permissions.Scope) echo.MiddlewareFunc {
+    return func(next echo.HandlerFunc) echo.HandlerFunc {
+        return func(c echo.Context) error {
+            granted, _ := c.Get("scopes").(permissions.Set)
+            for _, sc := range scopes {
+                if granted[sc] {
+                    return next(c)
+                }
+            }
+            return apperr.Forbidden("missing required scope")
+        }
+    }
+}
@@ -0,0 +1,50 @@
+package middleware
+
+import (
+    "context"
+    "errors"
+    "time"
+
+    "github.com/iliyamo/cinema-seat-reservation/internal/apperr"
+    "github.com/labstack/echo/v4"
+)
+
+// TimeoutConfig configures Timeout.  Routes overrides Default for single
+// routes, keyed by method and route pattern ("POST /v1/shows/:id/hold");
+// a zero duration, there or as Default, leaves the request without a
+// deadline.
+type TimeoutConfig struct {
+    Default time.Duration
+    Routes  map[string]time.Duration
+}
+
+// Timeout returns a global middleware that puts a deadline on the request
+// context.  The handler is not interrupted: the deadline reaches the
+// database through the context passed to BeginTx and the queries, so a
+// transaction waiting on a stuck lock is cancelled instead of holding the
+// connection.  When the handler fails with a server error after the
+// deadline passed, the error is replaced with 504 TIMEOUT, since handlers
+// usually report database failures as a generic 500.  Register it after
+// Tracing, whose root span then records the 504.
+func Timeout(cfg TimeoutConfig) echo.MiddlewareFunc {
+    return func(next echo.HandlerFunc) echo.HandlerFunc {
+        return func(c echo.Context) error {
+            req := c.Request()
+            d := cfg.Default
+            if rd, ok := cfg.Routes[req.Method+" "+c.Path()]; ok {
+                d = rd
+            }
+            if d <= 0 {
+                return next(c)
+            }
+            ctx, cancel := context.WithTimeout(req.Context(), d)
+            defer cancel()
+            c.SetRequest(req.WithContext(ctx))
+            err := next(c)
+            if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) && apperr.From(err).Status >= 500 {
+                return apperr.Timeout("the request took too long and was abandoned").Wrap(err)
+            }
+            return err
+        }
+    }
+}
@@ -0,0 +1,33 @@
+package middleware
+
+import (
+    "github.com/labstack/echo/v4"
+    "go.opentelemetry.io/contrib/instrumentation/github.com/labstack/echo/otelecho"
+    "go.opentelemetry.io/otel/trace"
+)
+
+// Tracing returns a global middleware that starts the root span of each
+// request with otelecho, named after the route pattern
+// ("POST /v1/shows/:id/hold") and tagged with the HTTP semantic
+// conventions.  An incoming W3C traceparent header continues the caller's
+// trace.  The span's context is placed on the request so db.WithTx and
+// repository queries add child spans, and the ID of a sampled trace is
+// returned in X-Trace-Id so a slow booking can be looked up from a client
+// report.  service is the server name recorded on the spans.  Register it
+// right after Metrics.
+func Tracing(service string) echo.MiddlewareFunc {
+    return func(next echo.HandlerFunc) echo.HandlerFunc {
+        traced := otelecho.Middleware(service)(func(c echo.Context) error {
+            if sc := trace.SpanContextFromContext(c.Request().Context()); sc.IsSampled() {
+                c.Response().Header().Set("X-Trace-Id", sc.TraceID().String())
+            }
+            return next(c)
+        })
+        return func(c echo.Context) error {
+            // otelecho renders errors itself so the recorded status is
+            // the one sent; returning them would render them twice.
+            _ = traced(c)
+            return nil
+        }
+    }
+}
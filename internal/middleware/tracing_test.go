@@ -0,0 +1,66 @@
+package middleware
+
+import (
+    "net/http"
+    "net/http/httptest"
+    "testing"
+
+    "github.com/labstack/echo/v4"
+    "go.opentelemetry.io/otel"
+    "go.opentelemetry.io/otel/propagation"
+    sdktrace "go.opentelemetry.io/otel/sdk/trace"
+    "go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+    "github.com/iliyamo/cinema-seat-reservation/internal/apperr"
+)
+
+func TestTracingContinuesTraceAndRecordsStatus(t *testing.T) {
+    exp := tracetest.NewInMemoryExporter()
+    prevTP, prevProp := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+    otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exp)))
+    otel.SetTextMapPropagator(propagation.TraceContext{})
+    t.Cleanup(func() {
+        otel.SetTracerProvider(prevTP)
+        otel.SetTextMapPropagator(prevProp)
+    })
+
+    e := echo.New()
+    handled := 0 // the error must reach the error handler once
+    e.HTTPErrorHandler = func(err error, c echo.Context) {
+        handled++
+        apperr.Handler(err, c)
+    }
+    e.Use(Tracing("cinema-test"))
+    e.POST("/v1/shows/:id/hold", func(c echo.Context) error {
+        return apperr.Conflict(apperr.CodeSeatUnavailable, "seats already held")
+    })
+
+    req := httptest.NewRequest(http.MethodPost, "/v1/shows/7/hold", nil)
+    req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+    rec := httptest.NewRecorder()
+    e.ServeHTTP(rec, req)
+
+    if rec.Code != http.StatusConflict || handled != 1 {
+        t.Fatalf("status %d, error handled %d times; want 409, once", rec.Code, handled)
+    }
+    if got := rec.Header().Get("X-Trace-Id"); got != "4bf92f3577b34da6a3ce929d0e0e4736" {
+        t.Fatalf("X-Trace-Id = %q", got)
+    }
+    spans := exp.GetSpans()
+    if len(spans) != 1 {
+        t.Fatalf("%d spans, want 1", len(spans))
+    }
+    s := spans[0]
+    if s.Name != "POST /v1/shows/:id/hold" || s.Parent.SpanID().String() != "00f067aa0ba902b7" {
+        t.Fatalf("span %q with parent %s", s.Name, s.Parent.SpanID())
+    }
+    status := int64(0)
+    for _, a := range s.Attributes {
+        if a.Key == "http.response.status_code" {
+            status = a.Value.AsInt64()
+        }
+    }
+    if status != http.StatusConflict {
+        t.Fatalf("http.response.status_code = %d", status)
+    }
+}
@@ -55,12 +55,18 @@ type Role struct {
 //  TokenHash – SHA‑256 hex digest of the token value.
 //  ExpiresAt – expiration timestamp of the token.
 //  RevokedAt – when the token was revoked (null if still active).
+//  UserAgent – client User-Agent at issuance (nullable).
+//  IP        – client IP address at issuance (nullable).
+//  LastUsedAt – last time the token was used (nullable).
 //  CreatedAt – timestamp of creation.
 type RefreshToken struct {
-    ID        uint64     // refresh_tokens.id
-    UserID    uint64     // refresh_tokens.user_id
-    TokenHash string     // refresh_tokens.token_hash
-    ExpiresAt time.Time  // refresh_tokens.expires_at
-    RevokedAt *time.Time // refresh_tokens.revoked_at (nullable)
-    CreatedAt time.Time  // refresh_tokens.created_at
+    ID         uint64     // refresh_tokens.id
+    UserID     uint64     // refresh_tokens.user_id
+    TokenHash  string     // refresh_tokens.token_hash
+    ExpiresAt  time.Time  // refresh_tokens.expires_at
+    RevokedAt  *time.Time // refresh_tokens.revoked_at (nullable)
+    UserAgent  *string    // refresh_tokens.user_agent (nullable)
+    IP         *string    // refresh_tokens.ip (nullable)
+    LastUsedAt *time.Time // refresh_tokens.last_used_at (nullable)
+    CreatedAt  time.Time  // refresh_tokens.created_at
 }
\ No newline at end of file
@@ -0,0 +1,99 @@
+// Package money handles the currencies amounts are kept in.  Amounts are
+// stored everywhere as integer counts of the currency's minor unit (the
+// *_cents columns); the currency is an ISO 4217 code kept beside them.
+// A cinema's currency applies to its shows; cinemas created before
+// currencies were recorded use the configured default.
+package money
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+)
+
+// ErrInvalidCurrency is returned for strings that are not ISO 4217
+// alphabetic codes.
+var ErrInvalidCurrency = errors.New("currency must be a three-letter ISO 4217 code such as USD or EUR")
+
+var defaultCode atomic.Value // string
+
+func init() { defaultCode.Store("USD") }
+
+// Default returns the currency of amounts that have none recorded.
+func Default() string { return defaultCode.Load().(string) }
+
+// SetDefault sets the currency returned by Default.  It is called once at
+// startup from the CURRENCY setting.
+func SetDefault(code string) error {
+	c, err := Normalize(code)
+	if err != nil {
+		return err
+	}
+	defaultCode.Store(c)
+	return nil
+}
+
+// Normalize trims and upper-cases code and checks that it has the shape
+// of an ISO 4217 alphabetic code.  The code list itself is not checked,
+// so new currencies need no release.
+func Normalize(code string) (string, error) {
+	c := strings.ToUpper(strings.TrimSpace(code))
+	if len(c) != 3 {
+		return "", ErrInvalidCurrency
+	}
+	for i := 0; i < len(c); i++ {
+		if c[i] < 'A' || c[i] > 'Z' {
+			return "", ErrInvalidCurrency
+		}
+	}
+	return c, nil
+}
+
+// Or returns code, or Default when code is empty.
+func Or(code string) string {
+	if code == "" {
+		return Default()
+	}
+	return code
+}
+
+// minorDigits lists the currencies whose minor unit is not a hundredth.
+var minorDigits = map[string]int{
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0, "KRW": 0,
+	"PYG": 0, "RWF": 0, "UGX": 0, "VND": 0, "VUV": 0, "XAF": 0, "XOF": 0, "XPF": 0,
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+}
+
+// MinorDigits returns the number of decimal places of the currency's
+// minor unit: 2 for most currencies, 0 for JPY, 3 for KWD.
+func MinorDigits(code string) int {
+	if d, ok := minorDigits[code]; ok {
+		return d
+	}
+	return 2
+}
+
+// Decimal formats an amount in minor units as a plain decimal number,
+// such as 12.50 for 1250 USD or 1250 for 1250 JPY.
+func Decimal(minor int64, code string) string {
+	sign := ""
+	if minor < 0 {
+		sign, minor = "-", -minor
+	}
+	d := MinorDigits(code)
+	if d == 0 {
+		return fmt.Sprintf("%s%d", sign, minor)
+	}
+	unit := int64(1)
+	for i := 0; i < d; i++ {
+		unit *= 10
+	}
+	return fmt.Sprintf("%s%d.%0*d", sign, minor/unit, d, minor%unit)
+}
+
+// Format formats an amount in minor units for people, such as
+// "12.50 USD".
+func Format(minor int64, code string) string {
+	return Decimal(minor, code) + " " + code
+}
@@ -0,0 +1,351 @@
+// Package openapi builds an OpenAPI 3 document from the routes registered
+// on an Echo instance and the Go types that handlers bind and return.
+//
+// Every registered route appears in the document automatically, so the
+// path list cannot drift from the router.  Request and response schemas
+// are generated by reflection from the DTO types named in a Doc: field
+// names come from json tags and constraints from validate tags (see
+// package validate), so a DTO change is reflected without editing the
+// spec by hand.
+package openapi
+
+import (
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Document is the root of an OpenAPI 3.0 document.
+type Document struct {
+	OpenAPI    string                          `json:"openapi"`
+	Info       Info                            `json:"info"`
+	Paths      map[string]map[string]Operation `json:"paths"`
+	Components Components                      `json:"components"`
+}
+
+// Info describes the API.
+type Info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+// Components holds reusable schemas and security schemes.
+type Components struct {
+	Schemas         map[string]*Schema        `json:"schemas"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes"`
+}
+
+// SecurityScheme describes an authentication method.
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+}
+
+// Operation describes a single method on a path.
+type Operation struct {
+	OperationID string                `json:"operationId"`
+	Summary     string                `json:"summary,omitempty"`
+	Tags        []string              `json:"tags,omitempty"`
+	Parameters  []Parameter           `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]Response   `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+}
+
+// Parameter is a path or query parameter.
+type Parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required"`
+	Schema   *Schema `json:"schema"`
+}
+
+// RequestBody describes a JSON request body.
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+// Response describes one response.
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+// MediaType wraps a schema for a content type.
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Schema is the subset of JSON Schema used by OpenAPI 3.0.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+}
+
+// Doc annotates a route.  Request and Response are example values of the
+// DTO types (typically zero values such as registerReq{}); nil means no
+// body.  Status defaults to 200.
+type Doc struct {
+	Summary  string
+	Tag      string
+	Auth     bool
+	Request  interface{}
+	Response interface{}
+	Status   int
+	Query    []string
+}
+
+// Build assembles the document.  doc is called for every route and
+// returns its annotation; ok=false skips the route.  errorType is the
+// value rendered for error responses.
+func Build(info Info, routes []*echo.Route, doc func(r *echo.Route) (Doc, bool), errorType interface{}) *Document {
+	g := &generator{schemas: map[string]*Schema{}}
+	d := &Document{
+		OpenAPI: "3.0.3",
+		Info:    info,
+		Paths:   map[string]map[string]Operation{},
+		Components: Components{
+			Schemas: g.schemas,
+			SecuritySchemes: map[string]SecurityScheme{
+				"bearerAuth": {Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+			},
+		},
+	}
+	errSchema := g.schema(reflect.TypeOf(errorType))
+	sorted := append([]*echo.Route(nil), routes...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Path != sorted[j].Path {
+			return sorted[i].Path < sorted[j].Path
+		}
+		return sorted[i].Method < sorted[j].Method
+	})
+	for _, r := range sorted {
+		if r.Method == echo.RouteNotFound || !strings.HasPrefix(r.Path, "/") {
+			continue
+		}
+		a, ok := doc(r)
+		if !ok {
+			continue
+		}
+		path, params := convertPath(r.Path)
+		for _, q := range a.Query {
+			params = append(params, Parameter{Name: q, In: "query", Schema: &Schema{Type: "string"}})
+		}
+		op := Operation{
+			OperationID: strings.ToLower(r.Method) + strings.NewReplacer("/", "_", "{", "", "}", "", "-", "_").Replace(path),
+			Summary:     a.Summary,
+			Parameters:  params,
+			Responses:   map[string]Response{},
+		}
+		if a.Tag != "" {
+			op.Tags = []string{a.Tag}
+		}
+		if a.Auth {
+			op.Security = []map[string][]string{{"bearerAuth": {}}}
+		}
+		if a.Request != nil {
+			op.RequestBody = &RequestBody{Required: true, Content: jsonContent(g.schema(reflect.TypeOf(a.Request)))}
+		}
+		status := a.Status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		resp := Response{Description: http.StatusText(status)}
+		if a.Response != nil {
+			resp.Content = jsonContent(g.schema(reflect.TypeOf(a.Response)))
+		}
+		op.Responses[strconv.Itoa(status)] = resp
+		op.Responses["default"] = Response{Description: "Error", Content: jsonContent(errSchema)}
+		if d.Paths[path] == nil {
+			d.Paths[path] = map[string]Operation{}
+		}
+		d.Paths[path][strings.ToLower(r.Method)] = op
+	}
+	return d
+}
+
+func jsonContent(s *Schema) map[string]MediaType {
+	return map[string]MediaType{echo.MIMEApplicationJSON: {Schema: s}}
+}
+
+// convertPath turns /v1/shows/:id into /v1/shows/{id} and lists the path
+// parameters.  Escaped colons (\:) are literal.
+func convertPath(p string) (string, []Parameter) {
+	var params []Parameter
+	parts := strings.Split(p, "/")
+	for i, seg := range parts {
+		if strings.HasPrefix(seg, ":") {
+			name := seg[1:]
+			parts[i] = "{" + name + "}"
+			params = append(params, Parameter{Name: name, In: "path", Required: true, Schema: &Schema{Type: "string"}})
+		} else {
+			parts[i] = strings.ReplaceAll(seg, `\:`, ":")
+		}
+	}
+	return strings.Join(parts, "/"), params
+}
+
+// generator turns Go types into schemas, registering named struct types
+// under components.schemas.
+type generator struct {
+	schemas map[string]*Schema
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func (g *generator) schema(t reflect.Type) *Schema {
+	if t == nil {
+		return &Schema{}
+	}
+	switch t.Kind() {
+	case reflect.Ptr:
+		s := g.schema(t.Elem())
+		if s.Ref != "" {
+			return s
+		}
+		cp := *s
+		cp.Nullable = true
+		return &cp
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		zero := 0.0
+		return &Schema{Type: "integer", Minimum: &zero}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: g.schema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.schema(t.Elem())}
+	case reflect.Interface:
+		return &Schema{}
+	case reflect.Struct:
+		if t == timeType {
+			return &Schema{Type: "string", Format: "date-time"}
+		}
+		if t.Name() == "" {
+			return g.object(t)
+		}
+		name := schemaName(t)
+		if _, ok := g.schemas[name]; !ok {
+			g.schemas[name] = &Schema{} // placeholder for recursive types
+			g.schemas[name] = g.object(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + name}
+	}
+	return &Schema{}
+}
+
+// schemaName exports unexported DTO names: registerReq becomes RegisterReq.
+func schemaName(t reflect.Type) string {
+	n := t.Name()
+	return strings.ToUpper(n[:1]) + n[1:]
+}
+
+func (g *generator) object(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		tag := strings.Split(f.Tag.Get("json"), ",")
+		name := tag[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			if f.Anonymous && f.Type.Kind() == reflect.Struct {
+				embedded := g.object(f.Type)
+				for k, v := range embedded.Properties {
+					s.Properties[k] = v
+				}
+				s.Required = append(s.Required, embedded.Required...)
+				continue
+			}
+			name = f.Name
+		}
+		fs := g.schema(f.Type)
+		if rules := f.Tag.Get("validate"); rules != "" {
+			if applyRules(fs, rules) {
+				s.Required = append(s.Required, name)
+			}
+		}
+		s.Properties[name] = fs
+	}
+	return s
+}
+
+// applyRules copies validate constraints onto s (unless it is a $ref) and
+// reports whether the field is required.
+func applyRules(s *Schema, rules string) (required bool) {
+	for _, r := range strings.Split(rules, ",") {
+		name, arg, _ := strings.Cut(strings.TrimSpace(r), "=")
+		if name == "required" {
+			required = true
+			continue
+		}
+		if s.Ref != "" {
+			continue
+		}
+		n, _ := strconv.ParseFloat(arg, 64)
+		switch name {
+		case "min", "max":
+			switch s.Type {
+			case "string":
+				v := int(n)
+				if name == "min" {
+					s.MinLength = &v
+				} else {
+					s.MaxLength = &v
+				}
+			case "array":
+				v := int(n)
+				if name == "min" {
+					s.MinItems = &v
+				} else {
+					s.MaxItems = &v
+				}
+			default:
+				if name == "min" {
+					s.Minimum = &n
+				} else {
+					s.Maximum = &n
+				}
+			}
+		case "email":
+			s.Format = "email"
+		case "rfc3339":
+			s.Format = "date-time"
+		case "oneof":
+			s.Enum = strings.Fields(arg)
+		}
+	}
+	return required
+}
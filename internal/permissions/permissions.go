@@ -0,0 +1,94 @@
+// Package permissions defines the scopes that authorize API operations and
+// the scopes granted to each role.  Access tokens carry the scopes of the
+// user's role in the space-separated "scope" claim, and routes require
+// scopes (middleware.RequireScope) rather than roles, so adding a role
+// only means adding an entry to the grants below.
+package permissions
+
+import (
+	"sort"
+	"strings"
+)
+
+// Scope is a single permission, written as resource:action.
+type Scope string
+
+const (
+	AccountManage     Scope = "account:manage"     // view the own account and sessions
+	BookingRead       Scope = "booking:read"       // view the own reservations and checkouts
+	BookingWrite      Scope = "booking:write"      // hold seats, book, check out, cancel own bookings
+	HoldWrite         Scope = "hold:write"         // hold, extend and release seats only (guest sessions)
+	CinemaWrite       Scope = "cinema:write"       // manage cinemas, halls, seats and calendars
+	HallWrite         Scope = "hall:write"         // edit existing halls and their seats
+	ShowWrite         Scope = "show:write"         // schedule, edit and delete shows
+	PromoWrite        Scope = "promo:write"        // manage promo codes and bundles
+	ReservationRead   Scope = "reservation:read"   // view reservations on managed shows
+	ReservationCancel Scope = "reservation:cancel" // cancel reservations on managed shows
+	CheckIn           Scope = "checkin:write"      // check customers in to managed shows
+	RefundManage      Scope = "refund:manage"      // approve, deny and settle refunds on managed shows
+	ReportsRead       Scope = "reports:read"       // sales and occupancy reports
+	AuditRead         Scope = "audit:read"         // audit log of the own cinemas
+	StaffManage       Scope = "staff:manage"       // invite and remove staff members
+	APIKeyManage      Scope = "apikey:manage"      // create and revoke owner API keys
+	WebhookManage     Scope = "webhook:manage"     // subscribe URLs to booking events
+	AdminOps          Scope = "admin:ops"          // operational endpoints under /v1/admin
+)
+
+// grants maps each role to the scopes it is given.
+var grants = map[string][]Scope{
+	"CUSTOMER": {AccountManage, BookingRead, BookingWrite},
+	"OWNER": {AccountManage, CinemaWrite, HallWrite, ShowWrite, PromoWrite,
+		ReservationRead, ReservationCancel, CheckIn, RefundManage, ReportsRead, AuditRead, StaffManage, APIKeyManage,
+		WebhookManage},
+	"STAFF": {AccountManage, HallWrite, ShowWrite, ReservationRead, ReservationCancel, CheckIn},
+	"ADMIN": {AccountManage, AdminOps},
+	// Guests hold seats before signing up; booking them needs an account.
+	"GUEST": {HoldWrite},
+}
+
+// ForRole returns the scopes granted to role, or nil for an unknown role.
+func ForRole(role string) []Scope {
+	return append([]Scope(nil), grants[role]...)
+}
+
+// Set is a set of scopes.
+type Set map[Scope]bool
+
+// NewSet builds a set from scopes.
+func NewSet(scopes ...Scope) Set {
+	s := make(Set, len(scopes))
+	for _, sc := range scopes {
+		s[sc] = true
+	}
+	return s
+}
+
+// Parse builds a set from a space-separated "scope" claim.
+func Parse(claim string) Set {
+	s := Set{}
+	for _, f := range strings.Fields(claim) {
+		s[Scope(f)] = true
+	}
+	return s
+}
+
+// HasAll reports whether every scope in want is in s.
+func (s Set) HasAll(want ...Scope) bool {
+	for _, w := range want {
+		if !s[w] {
+			return false
+		}
+	}
+	return true
+}
+
+// Claim formats scopes as a space-separated "scope" claim, sorted so
+// tokens for the same role are identical.
+func Claim(scopes []Scope) string {
+	out := make([]string, len(scopes))
+	for i, sc := range scopes {
+		out[i] = string(sc)
+	}
+	sort.Strings(out)
+	return strings.Join(out, " ")
+}
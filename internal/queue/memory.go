@@ -0,0 +1,74 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+)
+
+// Memory is an in-process backend.  Each topic has a buffer of Capacity
+// messages shared by its consumers, and every message goes to one of
+// them; Publish waits while the buffer is full.  A message whose handler
+// fails is logged and dropped.
+type Memory struct {
+	Capacity int
+
+	mu     sync.Mutex
+	topics map[string]chan []byte
+}
+
+// NewMemory constructs a Memory backend buffering 1024 messages per topic.
+func NewMemory() *Memory {
+	return &Memory{Capacity: 1024, topics: make(map[string]chan []byte)}
+}
+
+func (m *Memory) topic(name string) chan []byte {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ch, ok := m.topics[name]
+	if !ok {
+		ch = make(chan []byte, m.Capacity)
+		m.topics[name] = ch
+	}
+	return ch
+}
+
+// Ping implements Pinger.  It fails while a topic's buffer is full, which
+// means its consumers are not keeping up and Publish is blocking.
+func (m *Memory) Ping(context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for name, ch := range m.topics {
+		if len(ch) == cap(ch) {
+			return fmt.Errorf("queue: %s buffer full (%d messages)", name, cap(ch))
+		}
+	}
+	return nil
+}
+
+// Publish implements Publisher.
+func (m *Memory) Publish(ctx context.Context, topic string, body []byte) error {
+	msg := append([]byte(nil), body...)
+	select {
+	case m.topic(topic) <- msg:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Consume implements Consumer.
+func (m *Memory) Consume(ctx context.Context, topic string, h Handler) error {
+	ch := m.topic(topic)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case body := <-ch:
+			if err := h(ctx, body); err != nil {
+				log.Printf("queue: %s handler failed: %v", topic, err)
+			}
+		}
+	}
+}
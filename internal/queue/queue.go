@@ -0,0 +1,66 @@
+// Package queue abstracts the message broker behind Publisher and
+// Consumer so producers and consumers do not depend on one backend.  The
+// backend is chosen with QUEUE_BACKEND:
+//
+//   - "file" appends every message as one line to <QUEUE_FILE_DIR>/<topic>.jsonl.
+//     It only publishes; consumers read or ship the files themselves.
+//   - "memory" delivers messages to consumers in the same process.  It
+//     keeps nothing across restarts and is meant for development and
+//     tests.
+//
+// Client libraries for network brokers such as RabbitMQ, Kafka or NATS are
+// not among the module's dependencies; a backend for one implements
+// Publisher and Consumer and is added to Open.
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Publisher sends messages to a topic.  Publish returns once the backend
+// has accepted the message; an error means it may not have been sent.
+type Publisher interface {
+	Publish(ctx context.Context, topic string, body []byte) error
+}
+
+// Handler processes one message.  An error is logged by the consumer;
+// whether the message is delivered again depends on the backend.
+type Handler func(ctx context.Context, body []byte) error
+
+// Consumer delivers the messages of a topic to a handler.  Consume
+// blocks until ctx is cancelled.
+type Consumer interface {
+	Consume(ctx context.Context, topic string, h Handler) error
+}
+
+// Pinger is implemented by backends that can tell whether they currently
+// accept messages.  The server registers it as the "queue" check of
+// /readyz.
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// Config selects and configures a backend.
+type Config struct {
+	Backend string // "file" or "memory"
+	FileDir string // directory of the file backend
+}
+
+// Open returns the publisher and consumer of the configured backend.  The
+// consumer is nil for backends that only publish.
+func Open(cfg Config) (Publisher, Consumer, error) {
+	switch cfg.Backend {
+	case "file":
+		if cfg.FileDir == "" {
+			return nil, nil, errors.New("queue: file backend needs a directory")
+		}
+		return &File{Dir: cfg.FileDir}, nil, nil
+	case "memory":
+		m := NewMemory()
+		return m, m, nil
+	default:
+		return nil, nil, fmt.Errorf("queue: unknown backend %q", cfg.Backend)
+	}
+}
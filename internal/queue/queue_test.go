@@ -0,0 +1,37 @@
+package queue
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func TestFilePing(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	if err := (&File{Dir: dir}).Ping(ctx); err != nil {
+		t.Fatalf("writable directory: %v", err)
+	}
+	if err := (&File{Dir: filepath.Join(dir, "missing")}).Ping(ctx); err == nil {
+		t.Fatal("missing directory: want an error")
+	}
+	entries, _ := filepath.Glob(filepath.Join(dir, "*"))
+	if len(entries) != 0 {
+		t.Fatalf("ping left files behind: %v", entries)
+	}
+}
+
+func TestMemoryPingFullBuffer(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory()
+	m.Capacity = 1
+	if err := m.Ping(ctx); err != nil {
+		t.Fatalf("no topics: %v", err)
+	}
+	if err := m.Publish(ctx, "events", []byte("{}")); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Ping(ctx); err == nil {
+		t.Fatal("full buffer: want an error")
+	}
+}
@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// APIKey mirrors a row of the api_keys table.  The key itself is never
+// stored; Prefix is its first characters so owners can tell keys apart.
+// RatePerMin is nil when the key uses the default request budget.
+type APIKey struct {
+	ID         uint64     `json:"id"`
+	OwnerID    uint64     `json:"owner_id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     string     `json:"scopes"`
+	RatePerMin *uint32    `json:"rate_limit_per_minute"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// ErrAPIKeyNotFound is returned by GetActiveByHash for unknown or revoked
+// keys.
+var ErrAPIKeyNotFound = errors.New("api key not found")
+
+// APIKeyRepo persists api_keys.
+type APIKeyRepo struct {
+	db *sql.DB
+}
+
+// NewAPIKeyRepo returns a new APIKeyRepo bound to the given database.
+func NewAPIKeyRepo(db *sql.DB) *APIKeyRepo { return &APIKeyRepo{db: db} }
+
+const apiKeyColumns = `id, owner_id, name, prefix, scopes, rate_per_min, last_used_at, revoked_at, created_at`
+
+func scanAPIKey(s interface{ Scan(...any) error }) (*APIKey, error) {
+	var (
+		k        APIKey
+		rate     sql.NullInt64
+		lastUsed sql.NullTime
+		revoked  sql.NullTime
+	)
+	if err := s.Scan(&k.ID, &k.OwnerID, &k.Name, &k.Prefix, &k.Scopes, &rate, &lastUsed, &revoked, &k.CreatedAt); err != nil {
+		return nil, err
+	}
+	if rate.Valid {
+		v := uint32(rate.Int64)
+		k.RatePerMin = &v
+	}
+	if lastUsed.Valid {
+		t := lastUsed.Time.UTC()
+		k.LastUsedAt = &t
+	}
+	if revoked.Valid {
+		t := revoked.Time.UTC()
+		k.RevokedAt = &t
+	}
+	k.CreatedAt = k.CreatedAt.UTC()
+	return &k, nil
+}
+
+// Create inserts k with the SHA-256 hash of its key and sets its ID.
+func (r *APIKeyRepo) Create(ctx context.Context, k *APIKey, keyHash string) error {
+	res, err := r.db.ExecContext(ctx,
+		`INSERT INTO api_keys (owner_id, name, prefix, key_hash, scopes, rate_per_min) VALUES (?, ?, ?, ?, ?, ?)`,
+		k.OwnerID, k.Name, k.Prefix, keyHash, k.Scopes, k.RatePerMin)
+	if err != nil {
+		return err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	k.ID = uint64(id)
+	k.CreatedAt = time.Now().UTC()
+	return nil
+}
+
+// ListByOwner returns an owner's keys, revoked ones included, newest
+// first.
+func (r *APIKeyRepo) ListByOwner(ctx context.Context, ownerID uint64) ([]APIKey, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+apiKeyColumns+` FROM api_keys WHERE owner_id = ? ORDER BY id DESC`, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []APIKey{}
+	for rows.Next() {
+		k, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *k)
+	}
+	return out, rows.Err()
+}
+
+// GetActiveByHash returns the unrevoked key with the given hash, or
+// ErrAPIKeyNotFound.
+func (r *APIKeyRepo) GetActiveByHash(ctx context.Context, keyHash string) (*APIKey, error) {
+	k, err := scanAPIKey(r.db.QueryRowContext(ctx,
+		`SELECT `+apiKeyColumns+` FROM api_keys WHERE key_hash = ? AND revoked_at IS NULL`, keyHash))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrAPIKeyNotFound
+	}
+	return k, err
+}
+
+// TouchLastUsed records that a key was used.  The row is written at most
+// once a minute per key, so busy integrations do not turn every request
+// into a write.
+func (r *APIKeyRepo) TouchLastUsed(ctx context.Context, id uint64) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE api_keys SET last_used_at = CURRENT_TIMESTAMP
+		 WHERE id = ? AND (last_used_at IS NULL OR last_used_at < CURRENT_TIMESTAMP - INTERVAL 1 MINUTE)`, id)
+	return err
+}
+
+// Revoke revokes one of an owner's keys and returns it.  It returns
+// sql.ErrNoRows when the owner has no such key; revoking a revoked key
+// leaves its revocation time unchanged.
+func (r *APIKeyRepo) Revoke(ctx context.Context, ownerID, id uint64) (*APIKey, error) {
+	if _, err := r.db.ExecContext(ctx,
+		`UPDATE api_keys SET revoked_at = CURRENT_TIMESTAMP WHERE id = ? AND owner_id = ? AND revoked_at IS NULL`,
+		id, ownerID); err != nil {
+		return nil, err
+	}
+	return scanAPIKey(r.db.QueryRowContext(ctx,
+		`SELECT `+apiKeyColumns+` FROM api_keys WHERE id = ? AND owner_id = ?`, id, ownerID))
+}
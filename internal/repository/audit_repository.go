@@ -0,0 +1,156 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// AuditEvent is one row of the audit log.  Before and After hold JSON
+// snapshots of the entity around a mutation; either is empty for creates
+// and deletes.  OwnerID is the owner of the affected resource, when any.
+type AuditEvent struct {
+	ID         uint64          `json:"id"`
+	ActorID    *uint64         `json:"actor_id"`
+	OwnerID    *uint64         `json:"owner_id,omitempty"`
+	Action     string          `json:"action"`
+	EntityType string          `json:"entity_type"`
+	EntityID   string          `json:"entity_id,omitempty"`
+	IP         string          `json:"ip,omitempty"`
+	Details    json.RawMessage `json:"details,omitempty"`
+	Before     json.RawMessage `json:"before,omitempty"`
+	After      json.RawMessage `json:"after,omitempty"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+// AuditFilter selects audit events for List.  Zero fields are ignored.
+// Results are ordered newest first; BeforeID pages backwards from a
+// previously returned ID.
+type AuditFilter struct {
+	ActorID    uint64
+	OwnerID    uint64
+	Action     string // exact match, or a prefix when it ends in "*"
+	EntityType string
+	EntityID   string
+	From       time.Time
+	To         time.Time
+	BeforeID   uint64
+	Limit      int
+}
+
+// AuditRepo appends to and reads the audit_log table.
+type AuditRepo struct {
+	db *sql.DB
+}
+
+// NewAuditRepo returns a new AuditRepo bound to the given database.
+func NewAuditRepo(db *sql.DB) *AuditRepo { return &AuditRepo{db: db} }
+
+// Record appends e to the audit log.  details, when non-nil, is marshalled
+// to JSON and stored in place of e.Details.
+func (r *AuditRepo) Record(ctx context.Context, e *AuditEvent, details interface{}) error {
+	raw := e.Details
+	if details != nil {
+		b, err := json.Marshal(details)
+		if err != nil {
+			return err
+		}
+		raw = b
+	}
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO audit_log (actor_id, owner_id, action, entity_type, entity_id, ip, details, before_state, after_state)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		e.ActorID, e.OwnerID, e.Action, e.EntityType, nullString(e.EntityID), nullString(e.IP),
+		jsonArg(raw), jsonArg(e.Before), jsonArg(e.After))
+	return err
+}
+
+// jsonArg maps an empty JSON value to NULL.
+func jsonArg(raw json.RawMessage) interface{} {
+	if len(raw) == 0 {
+		return nil
+	}
+	return string(raw)
+}
+
+// List returns the events matching f, newest first.  Limit defaults to 50
+// and is capped at 500.
+func (r *AuditRepo) List(ctx context.Context, f AuditFilter) ([]AuditEvent, error) {
+	var (
+		where []string
+		args  []interface{}
+	)
+	if f.ActorID != 0 {
+		where, args = append(where, "actor_id = ?"), append(args, f.ActorID)
+	}
+	if f.OwnerID != 0 {
+		where, args = append(where, "owner_id = ?"), append(args, f.OwnerID)
+	}
+	if f.Action != "" {
+		if strings.HasSuffix(f.Action, "*") {
+			where, args = append(where, "action LIKE ?"), append(args, likePrefix(strings.TrimSuffix(f.Action, "*")))
+		} else {
+			where, args = append(where, "action = ?"), append(args, f.Action)
+		}
+	}
+	if f.EntityType != "" {
+		where, args = append(where, "entity_type = ?"), append(args, f.EntityType)
+	}
+	if f.EntityID != "" {
+		where, args = append(where, "entity_id = ?"), append(args, f.EntityID)
+	}
+	if !f.From.IsZero() {
+		where, args = append(where, "created_at >= ?"), append(args, f.From.UTC())
+	}
+	if !f.To.IsZero() {
+		where, args = append(where, "created_at < ?"), append(args, f.To.UTC())
+	}
+	if f.BeforeID != 0 {
+		where, args = append(where, "id < ?"), append(args, f.BeforeID)
+	}
+	limit := f.Limit
+	if limit <= 0 {
+		limit = 50
+	} else if limit > 500 {
+		limit = 500
+	}
+	q := `SELECT id, actor_id, owner_id, action, entity_type, entity_id, ip, details, before_state, after_state, created_at
+	      FROM audit_log`
+	if len(where) > 0 {
+		q += " WHERE " + strings.Join(where, " AND ")
+	}
+	q += " ORDER BY id DESC LIMIT ?"
+	args = append(args, limit)
+	rows, err := r.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []AuditEvent{}
+	for rows.Next() {
+		var (
+			e                      AuditEvent
+			actor, owner           sql.NullInt64
+			entityID, ip           sql.NullString
+			details, before, after []byte
+		)
+		if err := rows.Scan(&e.ID, &actor, &owner, &e.Action, &e.EntityType, &entityID, &ip,
+			&details, &before, &after, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		if actor.Valid {
+			v := uint64(actor.Int64)
+			e.ActorID = &v
+		}
+		if owner.Valid {
+			v := uint64(owner.Int64)
+			e.OwnerID = &v
+		}
+		e.EntityID, e.IP = entityID.String, ip.String
+		e.Details, e.Before, e.After = details, before, after
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
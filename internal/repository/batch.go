@@ -0,0 +1,176 @@
+package repository
+
+// Batched lookups for the GraphQL browse API (package graphql): each
+// method loads the rows for many parents in one query, so a nested query
+// costs one round trip per level instead of one per object.
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/iliyamo/cinema-seat-reservation/internal/money"
+)
+
+// idArgs returns the placeholders and arguments of an IN list of ids.
+func idArgs(ids []uint64) (string, []interface{}) {
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	return strings.TrimSuffix(strings.Repeat("?,", len(ids)), ","), args
+}
+
+// GetByIDs returns the cinemas with the given ids in id order; unknown
+// ids are skipped.
+func (r *CinemaRepo) GetByIDs(ctx context.Context, ids []uint64) ([]*Cinema, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	in, args := idArgs(ids)
+	rows, err := r.db.QueryContext(ctx, "SELECT "+cinemaColumns+" FROM cinemas WHERE id IN ("+in+") ORDER BY id", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []*Cinema
+	for rows.Next() {
+		c, err := scanCinema(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
+
+// hallColumns lists the columns scanned by scanHall; the hall is aliased h.
+const hallColumns = `h.id, h.owner_id, h.cinema_id, h.name, h.description, h.seat_rows, h.seat_cols, h.is_active, ` + hallTimezone + `, ` + hallCurrency + `, h.created_at, h.updated_at`
+
+// scanHall scans a row selected with hallColumns.
+func scanHall(row interface{ Scan(...any) error }) (*Hall, error) {
+	h := new(Hall)
+	if err := row.Scan(&h.ID, &h.OwnerID, &h.CinemaID, &h.Name, &h.Description,
+		&h.SeatRows, &h.SeatCols, &h.IsActive, &h.Timezone, &h.Currency, &h.CreatedAt, &h.UpdatedAt); err != nil {
+		return nil, err
+	}
+	h.Currency = money.Or(h.Currency)
+	return h, nil
+}
+
+// listHalls returns the halls selected by where, ordered by id.
+func (r *HallRepo) listHalls(ctx context.Context, where string, args []interface{}) ([]*Hall, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT "+hallColumns+" FROM halls h WHERE "+where+" ORDER BY h.id", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []*Hall
+	for rows.Next() {
+		h, err := scanHall(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, h)
+	}
+	return out, rows.Err()
+}
+
+// GetByIDs returns the halls with the given ids in id order; unknown ids
+// are skipped.
+func (r *HallRepo) GetByIDs(ctx context.Context, ids []uint64) ([]*Hall, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	in, args := idArgs(ids)
+	return r.listHalls(ctx, "h.id IN ("+in+")", args)
+}
+
+// ListByCinemas returns the halls of the given cinemas, regardless of
+// owner, in id order.
+func (r *HallRepo) ListByCinemas(ctx context.Context, cinemaIDs []uint64) ([]*Hall, error) {
+	if len(cinemaIDs) == 0 {
+		return nil, nil
+	}
+	in, args := idArgs(cinemaIDs)
+	return r.listHalls(ctx, "h.cinema_id IN ("+in+")", args)
+}
+
+// ListByHalls returns the shows of the given halls, regardless of owner,
+// ordered by hall and start time.  Only SCHEDULED shows are returned
+// unless all is set; non-nil from and to keep shows starting at or after
+// from and before to.
+func (r *ShowRepo) ListByHalls(ctx context.Context, hallIDs []uint64, from, to *time.Time, all bool) ([]Show, error) {
+	if len(hallIDs) == 0 {
+		return nil, nil
+	}
+	in, args := idArgs(hallIDs)
+	q := `SELECT id, hall_id, title, starts_at, ends_at, base_price_cents, status, hold_ttl_sec, created_at, updated_at
+	      FROM shows WHERE hall_id IN (` + in + `)`
+	if !all {
+		q += " AND status = 'SCHEDULED'"
+	}
+	if from != nil {
+		q += " AND starts_at >= ?"
+		args = append(args, from.UTC())
+	}
+	if to != nil {
+		q += " AND starts_at < ?"
+		args = append(args, to.UTC())
+	}
+	q += " ORDER BY hall_id, starts_at, id"
+	rows, err := r.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Show
+	for rows.Next() {
+		var s Show
+		if err := rows.Scan(&s.ID, &s.HallID, &s.Title, &s.StartsAt, &s.EndsAt,
+			&s.BasePriceCents, &s.Status, &s.HoldTTLSec, &s.CreatedAt, &s.UpdatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, s)
+	}
+	return out, rows.Err()
+}
+
+// ShowAvailabilityCount is an AvailabilityCount of one of several shows.
+type ShowAvailabilityCount struct {
+	ShowID uint64
+	AvailabilityCount
+}
+
+// AvailabilityForShows is Availability for several shows in one query.
+func (r *ShowSeatRepo) AvailabilityForShows(ctx context.Context, showIDs []uint64) ([]ShowAvailabilityCount, error) {
+	if len(showIDs) == 0 {
+		return nil, nil
+	}
+	in, args := idArgs(showIDs)
+	q := `SELECT ss.show_id, s.seat_type,
+	             CASE WHEN ss.status IN ('RESERVED','BLOCKED') THEN ss.status
+	                  WHEN EXISTS (SELECT 1 FROM seat_holds sh
+	                               WHERE sh.show_id = ss.show_id AND sh.seat_id = ss.seat_id
+	                                 AND sh.expires_at > UTC_TIMESTAMP()) THEN 'HELD'
+	                  ELSE 'FREE' END AS computed,
+	             COUNT(*)
+	      FROM show_seats ss
+	      JOIN seats s ON s.id = ss.seat_id
+	      WHERE ss.show_id IN (` + in + `)
+	      GROUP BY ss.show_id, s.seat_type, computed`
+	rows, err := r.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []ShowAvailabilityCount
+	for rows.Next() {
+		var a ShowAvailabilityCount
+		if err := rows.Scan(&a.ShowID, &a.SeatType, &a.Status, &a.Seats); err != nil {
+			return nil, err
+		}
+		out = append(out, a)
+	}
+	return out, rows.Err()
+}
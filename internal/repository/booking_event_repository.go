@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+)
+
+// Booking event kinds.
+const (
+	EventReservationConfirmed = "reservation.confirmed"
+	EventReservationCancelled = "reservation.cancelled"
+	// EventReservationExpired is recorded when a PENDING reservation is
+	// dropped because it was not paid in time.
+	EventReservationExpired = "reservation.expired"
+)
+
+// Booking event states.  PENDING events wait for the event relay; a failed
+// publication is retried until the relay gives up and marks the event
+// FAILED.
+const (
+	BookingEventPending   = "PENDING"
+	BookingEventPublished = "PUBLISHED"
+	BookingEventFailed    = "FAILED"
+)
+
+// BookingEvent is an entry of the booking event outbox.  The reservation
+// fields are copied when the event is recorded.
+type BookingEvent struct {
+	ID               uint64    `json:"id"`
+	Kind             string    `json:"kind"`
+	ReservationID    uint64    `json:"reservation_id"`
+	ShowID           uint64    `json:"show_id"`
+	UserID           uint64    `json:"user_id"`
+	TotalAmountCents uint32    `json:"total_amount_cents"`
+	OccurredAt       time.Time `json:"occurred_at"`
+	Attempts         uint32    `json:"-"` // failed publications so far
+}
+
+// BookingEventRepo persists the booking event outbox.
+type BookingEventRepo struct {
+	db *sql.DB
+}
+
+// NewBookingEventRepo returns a new BookingEventRepo bound to the given database.
+func NewBookingEventRepo(db *sql.DB) *BookingEventRepo { return &BookingEventRepo{db: db} }
+
+// DB returns the underlying database handle.
+func (r *BookingEventRepo) DB() *sql.DB { return r.db }
+
+// AppendTx records an event of the given kind for each reservation inside
+// tx, copying the reservations' current show, user and total.  The events
+// are only published when tx commits.  Reservations that do not exist are
+// skipped, so callers cancelling by deletion must append first.
+func (r *BookingEventRepo) AppendTx(ctx context.Context, tx *sql.Tx, kind string, reservationIDs ...uint64) error {
+	if len(reservationIDs) == 0 {
+		return nil
+	}
+	args := make([]interface{}, 0, len(reservationIDs)+1)
+	args = append(args, kind)
+	for _, id := range reservationIDs {
+		args = append(args, id)
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(reservationIDs)), ",")
+	_, err := tx.ExecContext(ctx,
+		`INSERT INTO booking_events (kind, reservation_id, show_id, user_id, total_amount_cents, next_attempt_at)
+		 SELECT ?, id, show_id, user_id, total_amount_cents, UTC_TIMESTAMP()
+		 FROM reservations WHERE id IN (`+placeholders+`) ORDER BY id`, args...)
+	return err
+}
+
+// Due returns up to limit PENDING events whose next attempt is due, oldest
+// first.
+func (r *BookingEventRepo) Due(ctx context.Context, limit int) ([]BookingEvent, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT id, kind, reservation_id, show_id, user_id, total_amount_cents, created_at, attempts
+		 FROM booking_events
+		 WHERE status = 'PENDING' AND next_attempt_at <= UTC_TIMESTAMP()
+		 ORDER BY id LIMIT ?`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []BookingEvent
+	for rows.Next() {
+		var e BookingEvent
+		if err := rows.Scan(&e.ID, &e.Kind, &e.ReservationID, &e.ShowID, &e.UserID, &e.TotalAmountCents, &e.OccurredAt, &e.Attempts); err != nil {
+			return nil, err
+		}
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
+
+// MarkPublished records a successful publication.
+func (r *BookingEventRepo) MarkPublished(ctx context.Context, id uint64) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE booking_events SET status = 'PUBLISHED', published_at = UTC_TIMESTAMP(), last_error = NULL WHERE id = ?`, id)
+	return err
+}
+
+// MarkFailed records a failed publication.  The event is retried at
+// retryAt, or marked FAILED for good when retryAt is nil.
+func (r *BookingEventRepo) MarkFailed(ctx context.Context, id uint64, cause string, retryAt *time.Time) error {
+	if len(cause) > 255 {
+		cause = cause[:255]
+	}
+	status := BookingEventFailed
+	var next interface{}
+	if retryAt != nil {
+		status, next = BookingEventPending, retryAt.UTC()
+	}
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE booking_events
+		 SET status = ?, attempts = attempts + 1, last_error = ?, next_attempt_at = COALESCE(?, next_attempt_at)
+		 WHERE id = ?`, status, cause, next, id)
+	return err
+}
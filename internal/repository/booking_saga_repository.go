@@ -0,0 +1,218 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/iliyamo/cinema-seat-reservation/internal/money"
+)
+
+// Booking saga states; see migration 0021 for their meaning.
+const (
+	SagaReserved     = "RESERVED"
+	SagaCharging     = "CHARGING"
+	SagaPaid         = "PAID"
+	SagaConfirmed    = "CONFIRMED"
+	SagaCompensating = "COMPENSATING"
+	SagaCompensated  = "COMPENSATED"
+)
+
+// BookingSaga mirrors a row of the booking_sagas table.
+type BookingSaga struct {
+	ID            uint64    `json:"id"`
+	UserID        uint64    `json:"user_id"`
+	ShowID        uint64    `json:"show_id"`
+	ReservationID *uint64   `json:"reservation_id"`
+	State         string    `json:"state"`
+	AmountCents   uint32    `json:"amount_cents"`
+	Currency      string    `json:"currency"`
+	PaymentRef    *string   `json:"payment_ref,omitempty"`
+	LastError     *string   `json:"last_error,omitempty"`
+	DeadlineAt    time.Time `json:"deadline_at"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// Terminal reports whether the saga has finished.
+func (s *BookingSaga) Terminal() bool {
+	return s.State == SagaConfirmed || s.State == SagaCompensated
+}
+
+// ErrSagaNotFound is returned when a saga does not exist (or belongs to
+// another user).
+var ErrSagaNotFound = errors.New("booking saga not found")
+
+// ErrSagaStateChanged is returned by Transition when the saga is no longer
+// in the expected state because another process advanced it.
+var ErrSagaStateChanged = errors.New("booking saga state changed")
+
+// BookingSagaRepo provides persistence for booking sagas.
+type BookingSagaRepo struct {
+	db *sql.DB
+}
+
+// NewBookingSagaRepo returns a new BookingSagaRepo bound to the given
+// database.
+func NewBookingSagaRepo(db *sql.DB) *BookingSagaRepo { return &BookingSagaRepo{db: db} }
+
+// DB exposes the underlying handle for callers that run transactions.
+func (r *BookingSagaRepo) DB() *sql.DB { return r.db }
+
+const sagaColumns = `id, user_id, show_id, reservation_id, state, amount_cents, currency, payment_ref, last_error, deadline_at, created_at, updated_at`
+
+func scanSaga(row interface{ Scan(...interface{}) error }) (*BookingSaga, error) {
+	var s BookingSaga
+	var resID sql.NullInt64
+	var currency, ref, lastErr sql.NullString
+	if err := row.Scan(&s.ID, &s.UserID, &s.ShowID, &resID, &s.State, &s.AmountCents,
+		&currency, &ref, &lastErr, &s.DeadlineAt, &s.CreatedAt, &s.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if resID.Valid {
+		id := uint64(resID.Int64)
+		s.ReservationID = &id
+	}
+	s.Currency = money.Or(currency.String)
+	if ref.Valid {
+		s.PaymentRef = &ref.String
+	}
+	if lastErr.Valid {
+		s.LastError = &lastErr.String
+	}
+	return &s, nil
+}
+
+// CreateTx inserts a saga and populates its ID.
+func (r *BookingSagaRepo) CreateTx(ctx context.Context, tx *sql.Tx, s *BookingSaga) error {
+	const q = `INSERT INTO booking_sagas (user_id, show_id, reservation_id, state, amount_cents, currency, deadline_at)
+               VALUES (?, ?, ?, ?, ?, ?, ?)`
+	var resID interface{}
+	if s.ReservationID != nil {
+		resID = *s.ReservationID
+	}
+	res, err := tx.ExecContext(ctx, q, s.UserID, s.ShowID, resID, s.State, s.AmountCents, money.Or(s.Currency), nullableUTC(&s.DeadlineAt))
+	if err != nil {
+		return err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	s.ID = uint64(id)
+	return nil
+}
+
+// GetByID returns a saga or ErrSagaNotFound.
+func (r *BookingSagaRepo) GetByID(ctx context.Context, id uint64) (*BookingSaga, error) {
+	s, err := scanSaga(r.db.QueryRowContext(ctx, `SELECT `+sagaColumns+` FROM booking_sagas WHERE id = ?`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrSagaNotFound
+	}
+	return s, err
+}
+
+// GetConfirmedByReservation returns the CONFIRMED saga that paid for a
+// reservation, or ErrSagaNotFound when the reservation was not paid
+// through checkout.
+func (r *BookingSagaRepo) GetConfirmedByReservation(ctx context.Context, reservationID uint64) (*BookingSaga, error) {
+	s, err := scanSaga(r.db.QueryRowContext(ctx,
+		`SELECT `+sagaColumns+` FROM booking_sagas WHERE reservation_id = ? AND state = 'CONFIRMED' ORDER BY id DESC LIMIT 1`, reservationID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrSagaNotFound
+	}
+	return s, err
+}
+
+// GetByIDForUser returns a saga owned by userID or ErrSagaNotFound.
+func (r *BookingSagaRepo) GetByIDForUser(ctx context.Context, id, userID uint64) (*BookingSaga, error) {
+	s, err := scanSaga(r.db.QueryRowContext(ctx, `SELECT `+sagaColumns+` FROM booking_sagas WHERE id = ? AND user_id = ?`, id, userID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrSagaNotFound
+	}
+	return s, err
+}
+
+// ListStale returns up to limit unfinished sagas whose deadline has passed,
+// oldest first.
+func (r *BookingSagaRepo) ListStale(ctx context.Context, now time.Time, limit int) ([]*BookingSaga, error) {
+	const q = `SELECT ` + sagaColumns + ` FROM booking_sagas
+               WHERE state NOT IN ('CONFIRMED','COMPENSATED') AND deadline_at <= ?
+               ORDER BY deadline_at
+               LIMIT ?`
+	rows, err := r.db.QueryContext(ctx, q, nullableUTC(&now), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []*BookingSaga
+	for rows.Next() {
+		s, err := scanSaga(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, s)
+	}
+	return out, rows.Err()
+}
+
+// Claim pushes the deadline of a stale saga to deadline so that only one
+// recovery worker handles it.  It returns ErrSagaStateChanged when the
+// saga changed state or was claimed in the meantime.
+func (r *BookingSagaRepo) Claim(ctx context.Context, s *BookingSaga, now, deadline time.Time) error {
+	const q = `UPDATE booking_sagas SET deadline_at = ?
+               WHERE id = ? AND state = ? AND deadline_at <= ?`
+	res, err := r.db.ExecContext(ctx, q, nullableUTC(&deadline), s.ID, s.State, nullableUTC(&now))
+	if err != nil {
+		return err
+	}
+	if err := sagaAffected(res); err != nil {
+		return err
+	}
+	s.DeadlineAt = deadline
+	return nil
+}
+
+// Transition moves a saga from state from to state to, recording the
+// payment reference (when non-nil) and lastErr (cleared when empty).  It
+// returns ErrSagaStateChanged when the saga is no longer in state from.
+func (r *BookingSagaRepo) Transition(ctx context.Context, id uint64, from, to string, paymentRef *string, lastErr string) error {
+	return transitionSaga(ctx, r.db, id, from, to, paymentRef, lastErr)
+}
+
+// TransitionTx is Transition inside tx.
+func (r *BookingSagaRepo) TransitionTx(ctx context.Context, tx *sql.Tx, id uint64, from, to string, paymentRef *string, lastErr string) error {
+	return transitionSaga(ctx, tx, id, from, to, paymentRef, lastErr)
+}
+
+func transitionSaga(ctx context.Context, q interface {
+	ExecContext(context.Context, string, ...interface{}) (sql.Result, error)
+}, id uint64, from, to string, paymentRef *string, lastErr string) error {
+	const stmt = `UPDATE booking_sagas
+                  SET state = ?, payment_ref = COALESCE(?, payment_ref), last_error = NULLIF(?, '')
+                  WHERE id = ? AND state = ?`
+	var ref interface{}
+	if paymentRef != nil {
+		ref = *paymentRef
+	}
+	if len(lastErr) > 255 {
+		lastErr = lastErr[:255]
+	}
+	res, err := q.ExecContext(ctx, stmt, to, ref, lastErr, id, from)
+	if err != nil {
+		return err
+	}
+	return sagaAffected(res)
+}
+
+func sagaAffected(res sql.Result) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrSagaStateChanged
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+)
+
+// Bundle is an owner-defined package of shows sold together.  Each show in
+// the bundle is reserved as its own reservation (linked by bundle_id) with
+// DiscountPercent applied to its seat prices.
+type Bundle struct {
+	ID              uint64    `json:"id"`
+	OwnerID         uint64    `json:"owner_id"`
+	Name            string    `json:"name"`
+	DiscountPercent uint8     `json:"discount_percent"`
+	IsActive        bool      `json:"is_active"`
+	ShowIDs         []uint64  `json:"show_ids"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// ErrBundleNotFound is returned when a bundle does not exist.
+var ErrBundleNotFound = errors.New("bundle not found")
+
+// BundleRepo provides persistence for bundles and their shows.
+type BundleRepo struct {
+	db *sql.DB
+}
+
+// NewBundleRepo returns a new BundleRepo bound to the given database.
+func NewBundleRepo(db *sql.DB) *BundleRepo { return &BundleRepo{db: db} }
+
+// CountOwnedShows returns how many of the given show IDs are scheduled in
+// halls owned by ownerID.  Callers compare the result with len(showIDs)
+// to verify ownership of every show.
+func (r *BundleRepo) CountOwnedShows(ctx context.Context, ownerID uint64, showIDs []uint64) (int, error) {
+	if len(showIDs) == 0 {
+		return 0, nil
+	}
+	placeholders := make([]string, 0, len(showIDs))
+	args := make([]interface{}, 0, len(showIDs)+1)
+	args = append(args, ownerID)
+	for _, id := range showIDs {
+		placeholders = append(placeholders, "?")
+		args = append(args, id)
+	}
+	q := `SELECT COUNT(*) FROM shows s JOIN halls h ON h.id = s.hall_id
+          WHERE h.owner_id = ? AND s.id IN (` + strings.Join(placeholders, ",") + `)`
+	var n int
+	err := r.db.QueryRowContext(ctx, q, args...).Scan(&n)
+	return n, err
+}
+
+// Create inserts a bundle together with its show list in a single
+// transaction and populates the ID and CreatedAt fields.
+func (r *BundleRepo) Create(ctx context.Context, b *Bundle) (err error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+			return
+		}
+		err = tx.Commit()
+	}()
+	res, err := tx.ExecContext(ctx,
+		`INSERT INTO bundles (owner_id, name, discount_percent, is_active) VALUES (?, ?, ?, ?)`,
+		b.OwnerID, b.Name, b.DiscountPercent, b.IsActive)
+	if err != nil {
+		return err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	b.ID = uint64(id)
+	query := `INSERT INTO bundle_shows (bundle_id, show_id) VALUES `
+	args := make([]interface{}, 0, len(b.ShowIDs)*2)
+	for i, sid := range b.ShowIDs {
+		if i > 0 {
+			query += ","
+		}
+		query += "(?, ?)"
+		args = append(args, b.ID, sid)
+	}
+	if _, err = tx.ExecContext(ctx, query, args...); err != nil {
+		return err
+	}
+	err = tx.QueryRowContext(ctx, `SELECT created_at FROM bundles WHERE id = ?`, b.ID).Scan(&b.CreatedAt)
+	return err
+}
+
+// GetByID returns a bundle with its show IDs.  ErrBundleNotFound is
+// returned when no bundle has the given ID.
+func (r *BundleRepo) GetByID(ctx context.Context, id uint64) (*Bundle, error) {
+	var b Bundle
+	err := r.db.QueryRowContext(ctx,
+		`SELECT id, owner_id, name, discount_percent, is_active, created_at FROM bundles WHERE id = ?`, id,
+	).Scan(&b.ID, &b.OwnerID, &b.Name, &b.DiscountPercent, &b.IsActive, &b.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrBundleNotFound
+		}
+		return nil, err
+	}
+	if err := r.loadShowIDs(ctx, []*Bundle{&b}); err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+// ListByOwner returns all bundles created by the owner, newest first.
+func (r *BundleRepo) ListByOwner(ctx context.Context, ownerID uint64) ([]Bundle, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT id, owner_id, name, discount_percent, is_active, created_at
+         FROM bundles WHERE owner_id = ? ORDER BY id DESC`, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := make([]Bundle, 0)
+	for rows.Next() {
+		var b Bundle
+		if err := rows.Scan(&b.ID, &b.OwnerID, &b.Name, &b.DiscountPercent, &b.IsActive, &b.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, b)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	ptrs := make([]*Bundle, 0, len(out))
+	for i := range out {
+		ptrs = append(ptrs, &out[i])
+	}
+	if err := r.loadShowIDs(ctx, ptrs); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// loadShowIDs populates ShowIDs for the given bundles in one query.
+func (r *BundleRepo) loadShowIDs(ctx context.Context, bundles []*Bundle) error {
+	if len(bundles) == 0 {
+		return nil
+	}
+	index := make(map[uint64]*Bundle, len(bundles))
+	placeholders := make([]string, 0, len(bundles))
+	args := make([]interface{}, 0, len(bundles))
+	for _, b := range bundles {
+		b.ShowIDs = []uint64{}
+		index[b.ID] = b
+		placeholders = append(placeholders, "?")
+		args = append(args, b.ID)
+	}
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT bundle_id, show_id FROM bundle_shows WHERE bundle_id IN (`+strings.Join(placeholders, ",")+`) ORDER BY bundle_id, show_id`,
+		args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var bid, sid uint64
+		if err := rows.Scan(&bid, &sid); err != nil {
+			return err
+		}
+		if b, ok := index[bid]; ok {
+			b.ShowIDs = append(b.ShowIDs, sid)
+		}
+	}
+	return rows.Err()
+}
@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// Calendar date kinds.
+const (
+	CalendarBlackout = "BLACKOUT" // no shows may be scheduled on the date
+	CalendarSpecial  = "SPECIAL"  // seat prices are scaled by PriceMultiplierPct
+)
+
+// DateLayout is the format of calendar dates (YYYY-MM-DD, UTC).
+const DateLayout = "2006-01-02"
+
+// CalendarDate mirrors a row of the cinema_calendar table.
+type CalendarDate struct {
+	ID                 uint64 `json:"id"`
+	CinemaID           uint64 `json:"cinema_id"`
+	Date               string `json:"date"` // YYYY-MM-DD
+	Kind               string `json:"kind"` // BLACKOUT or SPECIAL
+	PriceMultiplierPct uint32 `json:"price_multiplier_pct"`
+	Label              string `json:"label"`
+}
+
+// ErrCalendarDateNotFound is returned when a cinema has no calendar entry
+// for a date.
+var ErrCalendarDateNotFound = errors.New("calendar date not found")
+
+// CalendarRepo provides persistence for per-cinema blackout and special
+// dates.
+type CalendarRepo struct {
+	db *sql.DB
+}
+
+// NewCalendarRepo returns a new CalendarRepo bound to the given database.
+func NewCalendarRepo(db *sql.DB) *CalendarRepo { return &CalendarRepo{db: db} }
+
+// ListRange returns the entries of a cinema between from and to
+// (inclusive, compared by calendar day in the location of from and to)
+// ordered by date.
+func (r *CalendarRepo) ListRange(ctx context.Context, cinemaID uint64, from, to time.Time) ([]CalendarDate, error) {
+	return listCalendar(ctx, r.db, cinemaID, from, to)
+}
+
+// ListRangeTx is ListRange inside tx.
+func (r *CalendarRepo) ListRangeTx(ctx context.Context, tx *sql.Tx, cinemaID uint64, from, to time.Time) ([]CalendarDate, error) {
+	return listCalendar(ctx, tx, cinemaID, from, to)
+}
+
+func listCalendar(ctx context.Context, q holdQueryer, cinemaID uint64, from, to time.Time) ([]CalendarDate, error) {
+	const query = `SELECT id, cinema_id, date, kind, price_multiplier_pct, label
+                   FROM cinema_calendar
+                   WHERE cinema_id = ? AND date BETWEEN ? AND ?
+                   ORDER BY date`
+	rows, err := q.QueryContext(ctx, query, cinemaID, from.Format(DateLayout), to.Format(DateLayout))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := make([]CalendarDate, 0)
+	for rows.Next() {
+		var d CalendarDate
+		var day time.Time
+		if err := rows.Scan(&d.ID, &d.CinemaID, &day, &d.Kind, &d.PriceMultiplierPct, &d.Label); err != nil {
+			return nil, err
+		}
+		d.Date = day.Format(DateLayout)
+		out = append(out, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Upsert creates the entry for d.CinemaID and d.Date or replaces its kind,
+// multiplier and label, then populates d.ID.
+func (r *CalendarRepo) Upsert(ctx context.Context, d *CalendarDate) error {
+	const q = `INSERT INTO cinema_calendar (cinema_id, date, kind, price_multiplier_pct, label)
+               VALUES (?, ?, ?, ?, ?)
+               ON DUPLICATE KEY UPDATE id = LAST_INSERT_ID(id), kind = VALUES(kind),
+                 price_multiplier_pct = VALUES(price_multiplier_pct), label = VALUES(label)`
+	res, err := r.db.ExecContext(ctx, q, d.CinemaID, d.Date, d.Kind, d.PriceMultiplierPct, d.Label)
+	if err != nil {
+		return err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	d.ID = uint64(id)
+	return nil
+}
+
+// Delete removes a cinema's entry for date (YYYY-MM-DD).  It returns
+// ErrCalendarDateNotFound when there is none.
+func (r *CalendarRepo) Delete(ctx context.Context, cinemaID uint64, date string) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM cinema_calendar WHERE cinema_id = ? AND date = ?`, cinemaID, date)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrCalendarDateNotFound
+	}
+	return nil
+}
+
+// ScheduledShowsOn returns the IDs of SCHEDULED shows in the cinema's
+// halls that run at any time during day, taken in the cinema's time zone.
+func (r *CalendarRepo) ScheduledShowsOn(ctx context.Context, cinemaID uint64, day time.Time) ([]uint64, error) {
+	const q = `SELECT sh.id
+               FROM shows sh
+               JOIN halls h ON h.id = sh.hall_id
+               WHERE h.cinema_id = ? AND sh.status = 'SCHEDULED'
+                 AND sh.starts_at < ? AND sh.ends_at > ?
+               ORDER BY sh.id`
+	var tz string
+	if err := r.db.QueryRowContext(ctx, `SELECT timezone FROM cinemas WHERE id = ?`, cinemaID).Scan(&tz); err != nil {
+		return nil, err
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		loc = time.UTC
+	}
+	start := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, loc)
+	rows, err := r.db.QueryContext(ctx, q, cinemaID,
+		start.AddDate(0, 0, 1).UTC().Format("2006-01-02 15:04:05"), start.UTC().Format("2006-01-02 15:04:05"))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	ids := make([]uint64, 0)
+	for rows.Next() {
+		var id uint64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// ApplyPriceMultiplier scales cents by pct percent, rounding to the
+// nearest cent.
+func ApplyPriceMultiplier(cents, pct uint32) uint32 {
+	return uint32((uint64(cents)*uint64(pct) + 50) / 100)
+}
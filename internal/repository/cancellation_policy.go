@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// CancellationPolicy is how long before a show starts its reservations
+// may still be cancelled.  The show's cutoff overrides its cinema's; when
+// neither is set cancelling is allowed until the show starts.
+type CancellationPolicy struct {
+	ShowID                 uint64  `json:"show_id"`
+	CutoffMinutes          *uint32 `json:"cutoff_minutes"`           // the show's own cutoff
+	CinemaCutoffMinutes    *uint32 `json:"cinema_cutoff_minutes"`    // the cinema's default
+	EffectiveCutoffMinutes uint32  `json:"effective_cutoff_minutes"` // the cutoff that applies
+}
+
+// CancellationPolicy returns the cancellation cutoffs of a show.  It
+// returns ErrShowNotFound if there is no such show.
+func (r *ShowRepo) CancellationPolicy(ctx context.Context, id uint64) (*CancellationPolicy, error) {
+	p := &CancellationPolicy{ShowID: id}
+	err := r.db.QueryRowContext(ctx,
+		`SELECT sh.cancel_cutoff_min, c.cancel_cutoff_min
+		 FROM shows sh
+		 JOIN halls h ON h.id = sh.hall_id
+		 LEFT JOIN cinemas c ON c.id = h.cinema_id
+		 WHERE sh.id = ?`, id).Scan(&p.CutoffMinutes, &p.CinemaCutoffMinutes)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrShowNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case p.CutoffMinutes != nil:
+		p.EffectiveCutoffMinutes = *p.CutoffMinutes
+	case p.CinemaCutoffMinutes != nil:
+		p.EffectiveCutoffMinutes = *p.CinemaCutoffMinutes
+	}
+	return p, nil
+}
+
+// CancelCutoffTx returns the cancellation cutoff that applies to a show:
+// its own, else its cinema's, else zero.
+func (r *ShowRepo) CancelCutoffTx(ctx context.Context, tx *sql.Tx, id uint64) (time.Duration, error) {
+	var minutes uint32
+	err := tx.QueryRowContext(ctx,
+		`SELECT COALESCE(sh.cancel_cutoff_min, c.cancel_cutoff_min, 0)
+		 FROM shows sh
+		 JOIN halls h ON h.id = sh.hall_id
+		 LEFT JOIN cinemas c ON c.id = h.cinema_id
+		 WHERE sh.id = ?`, id).Scan(&minutes)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrShowNotFound
+	}
+	return time.Duration(minutes) * time.Minute, err
+}
+
+// SetCancelCutoff sets a show's cancellation cutoff in minutes; nil falls
+// back to the cinema's.
+func (r *ShowRepo) SetCancelCutoff(ctx context.Context, id uint64, minutes *uint32) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE shows SET cancel_cutoff_min = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`, minutes, id)
+	return err
+}
+
+// CancelCutoff returns a cinema's default cancellation cutoff in minutes,
+// nil when it has none.  It returns ErrCinemaNotFound unless the cinema
+// belongs to ownerID.
+func (r *CinemaRepo) CancelCutoff(ctx context.Context, id, ownerID uint64) (*uint32, error) {
+	var minutes *uint32
+	err := r.db.QueryRowContext(ctx,
+		`SELECT cancel_cutoff_min FROM cinemas WHERE id = ? AND owner_id = ?`, id, ownerID).Scan(&minutes)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrCinemaNotFound
+	}
+	return minutes, err
+}
+
+// SetCancelCutoff sets the default cancellation cutoff of a cinema's
+// shows in minutes; nil removes it.  It returns ErrCinemaNotFound unless
+// the cinema belongs to ownerID.
+func (r *CinemaRepo) SetCancelCutoff(ctx context.Context, id, ownerID uint64, minutes *uint32) error {
+	if _, err := r.CancelCutoff(ctx, id, ownerID); err != nil {
+		return err
+	}
+	// RowsAffected is 0 when nothing changed, so existence is checked above.
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE cinemas SET cancel_cutoff_min = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ? AND owner_id = ?`, minutes, id, ownerID)
+	return err
+}
@@ -0,0 +1,171 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/iliyamo/cinema-seat-reservation/internal/cache"
+)
+
+// Weekdays are the day names of OpeningHours, Monday first.
+var Weekdays = []string{"MON", "TUE", "WED", "THU", "FRI", "SAT", "SUN"}
+
+// OpeningHours is one opening period of a cinema on a weekday.  Opens
+// and Closes are local times as HH:MM; a Closes before Opens means the
+// cinema closes after midnight.  A day may have several periods and days
+// without any are closed.
+type OpeningHours struct {
+	Day    string `json:"day"`
+	Opens  string `json:"opens"`
+	Closes string `json:"closes"`
+}
+
+// CinemaProfile is the public profile of a cinema shown by front-ends:
+// the URL of its logo, a plain-text description, a phone number and its
+// opening hours.  Nil fields are not set.
+type CinemaProfile struct {
+	LogoURL      *string        `json:"logo_url"`
+	Description  *string        `json:"description"`
+	Phone        *string        `json:"phone"`
+	OpeningHours []OpeningHours `json:"opening_hours"`
+}
+
+// Errors returned by CinemaProfile.Normalize.
+var (
+	ErrInvalidLogoURL      = errors.New("invalid logo url")
+	ErrInvalidPhone        = errors.New("invalid phone number")
+	ErrInvalidOpeningHours = errors.New("invalid opening hours")
+)
+
+var (
+	// markupTag matches HTML tags, which descriptions must not carry.
+	markupTag = regexp.MustCompile(`<[^>]*>`)
+	// phonePattern is an optional + followed by digits and the usual
+	// separators.
+	phonePattern = regexp.MustCompile(`^\+?[0-9][0-9 ()./-]*$`)
+)
+
+// Profile returns the profile fields of c.
+func (c *Cinema) Profile() *CinemaProfile {
+	return &CinemaProfile{LogoURL: c.LogoURL, Description: c.Description, Phone: c.Phone, OpeningHours: c.OpeningHours}
+}
+
+// Normalize sanitizes the profile so it is safe to show as is: blank
+// fields become nil, the description loses HTML tags and control
+// characters other than line breaks and tabs, the phone number's spaces
+// are collapsed, and opening hours are upper-cased, formatted as HH:MM
+// and sorted by day and opening time.  The logo must be an absolute http
+// or https URL without credentials (ErrInvalidLogoURL), the phone number
+// digits with an optional leading + and separators (ErrInvalidPhone), and
+// opening hours must name weekdays, have valid distinct times and not
+// repeat (ErrInvalidOpeningHours).
+func (p *CinemaProfile) Normalize() error {
+	p.LogoURL = trimmedOrNil(p.LogoURL)
+	if p.LogoURL != nil {
+		u, err := url.Parse(*p.LogoURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.User != nil {
+			return ErrInvalidLogoURL
+		}
+	}
+	if p.Description != nil {
+		d := markupTag.ReplaceAllString(*p.Description, "")
+		d = strings.Map(func(r rune) rune {
+			if unicode.IsControl(r) && r != '\n' && r != '\t' {
+				return -1
+			}
+			return r
+		}, strings.ReplaceAll(d, "\r\n", "\n"))
+		p.Description = trimmedOrNil(&d)
+	}
+	if p.Phone = trimmedOrNil(p.Phone); p.Phone != nil {
+		ph := strings.Join(strings.Fields(*p.Phone), " ")
+		digits := 0
+		for _, r := range ph {
+			if r >= '0' && r <= '9' {
+				digits++
+			}
+		}
+		if !phonePattern.MatchString(ph) || digits < 3 || digits > 15 {
+			return ErrInvalidPhone
+		}
+		p.Phone = &ph
+	}
+	day := make(map[string]int, len(Weekdays))
+	for i, d := range Weekdays {
+		day[d] = i
+	}
+	seen := make(map[OpeningHours]bool, len(p.OpeningHours))
+	for i := range p.OpeningHours {
+		h := &p.OpeningHours[i]
+		h.Day = strings.ToUpper(strings.TrimSpace(h.Day))
+		if _, ok := day[h.Day]; !ok {
+			return ErrInvalidOpeningHours
+		}
+		opens, err1 := time.Parse("15:04", strings.TrimSpace(h.Opens))
+		closes, err2 := time.Parse("15:04", strings.TrimSpace(h.Closes))
+		if err1 != nil || err2 != nil || opens.Equal(closes) {
+			return ErrInvalidOpeningHours
+		}
+		h.Opens, h.Closes = opens.Format("15:04"), closes.Format("15:04")
+		if seen[*h] {
+			return ErrInvalidOpeningHours
+		}
+		seen[*h] = true
+	}
+	sort.SliceStable(p.OpeningHours, func(i, j int) bool {
+		a, b := p.OpeningHours[i], p.OpeningHours[j]
+		if a.Day != b.Day {
+			return day[a.Day] < day[b.Day]
+		}
+		return a.Opens < b.Opens
+	})
+	if len(p.OpeningHours) == 0 {
+		p.OpeningHours = nil
+	}
+	return nil
+}
+
+// trimmedOrNil returns s without surrounding space, or nil when that
+// leaves nothing.
+func trimmedOrNil(s *string) *string {
+	if s == nil {
+		return nil
+	}
+	t := strings.TrimSpace(*s)
+	if t == "" {
+		return nil
+	}
+	return &t
+}
+
+// SetProfile replaces the profile of a cinema owned by ownerID with p,
+// which should be normalized.  It returns ErrCinemaNotFound when the
+// owner has no such cinema.
+func (r *CinemaRepo) SetProfile(ctx context.Context, id, ownerID uint64, p *CinemaProfile) error {
+	if _, err := r.GetByIDAndOwner(ctx, id, ownerID); err != nil {
+		return err
+	}
+	var hours []byte
+	if len(p.OpeningHours) > 0 {
+		var err error
+		if hours, err = json.Marshal(p.OpeningHours); err != nil {
+			return err
+		}
+	}
+	// RowsAffected is 0 when nothing changed, so existence is checked above.
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE cinemas SET logo_url = ?, description = ?, phone = ?, opening_hours = ?, updated_at = CURRENT_TIMESTAMP
+		 WHERE id = ? AND owner_id = ?`, p.LogoURL, p.Description, p.Phone, jsonArg(hours), id, ownerID)
+	if err != nil {
+		return err
+	}
+	cache.CatalogChanged(ctx)
+	return nil
+}
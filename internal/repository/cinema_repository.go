@@ -5,25 +5,70 @@
 package repository
 
 import (
-	"context"      // context allows passing deadlines and cancellation signals to DB operations
-	"database/sql" // sql provides generic database operations and drivers
-	"errors"       // errors is used to define custom error values
+	"context"       // context allows passing deadlines and cancellation signals to DB operations
+	"database/sql"  // sql provides generic database operations and drivers
+	"encoding/json" // json decodes opening hours
+	"errors"        // errors is used to define custom error values
+	"math"          // math computes distances for the near filter
+	"sort"          // sort orders cinemas by distance
+	"time"          // time holds row timestamps
+
+	"github.com/iliyamo/cinema-seat-reservation/internal/cache"
+	"github.com/iliyamo/cinema-seat-reservation/internal/money"
 )
 
 // Cinema represents a cinema entity persisted in the database. Each cinema belongs to a single owner
 // and may contain multiple halls. The ID field is the primary key and is auto-incremented by the DB.
 // Note: OwnerID, CreatedAt and UpdatedAt should not be exposed via public API responses.
 type Cinema struct {
-	ID        uint64 // ID is the unique identifier of the cinema
-	OwnerID   uint64 // OwnerID references the users.id of the cinema owner
-	Name      string // Name is the human-friendly name of the cinema
-	CreatedAt string // CreatedAt stores when the row was created (timestamp in DB timezone)
-	UpdatedAt string // UpdatedAt stores when the row was last updated
+	ID           uint64         // ID is the unique identifier of the cinema
+	OwnerID      uint64         // OwnerID references the users.id of the cinema owner
+	Name         string         // Name is the human-friendly name of the cinema
+	Timezone     string         // Timezone is the IANA time zone show times are local to, e.g. "Europe/Berlin"
+	Currency     string         // Currency is the ISO 4217 code of the prices of the cinema's shows, e.g. "EUR"
+	City         *string        // City is used by the public city filters; nil when not set
+	Address      *string        // Address is the street address shown to guests
+	Latitude     *float64       // Latitude and Longitude locate the cinema (WGS84 degrees); both or neither are set
+	Longitude    *float64
+	LogoURL      *string        // LogoURL, Description and Phone are the public profile; see CinemaProfile
+	Description  *string
+	Phone        *string
+	OpeningHours []OpeningHours // OpeningHours are the weekly opening times; nil when not set
+	CreatedAt    time.Time      // CreatedAt stores when the row was created
+	UpdatedAt    time.Time      // UpdatedAt stores when the row was last updated
 }
 
 // ErrCinemaNotFound is returned when a cinema cannot be found in the DB.
 var ErrCinemaNotFound = errors.New("cinema not found")
 
+// cinemaColumns lists the columns scanned by scanCinema.
+const cinemaColumns = "id, owner_id, name, timezone, currency, city, address, latitude, longitude, logo_url, description, phone, opening_hours, created_at, updated_at"
+
+// scanCinema scans a row selected with cinemaColumns.
+func scanCinema(row interface{ Scan(...any) error }) (*Cinema, error) {
+	var c Cinema
+	var currency, city, address, logo, desc, phone sql.NullString
+	var lat, lng sql.NullFloat64
+	var hours []byte
+	if err := row.Scan(&c.ID, &c.OwnerID, &c.Name, &c.Timezone, &currency, &city, &address, &lat, &lng,
+		&logo, &desc, &phone, &hours, &c.CreatedAt, &c.UpdatedAt); err != nil {
+		return nil, err
+	}
+	c.Currency = money.Or(currency.String)
+	c.City = nullStringPtr(city)
+	c.Address = nullStringPtr(address)
+	if lat.Valid && lng.Valid {
+		c.Latitude, c.Longitude = &lat.Float64, &lng.Float64
+	}
+	c.LogoURL, c.Description, c.Phone = nullStringPtr(logo), nullStringPtr(desc), nullStringPtr(phone)
+	if len(hours) > 0 {
+		if err := json.Unmarshal(hours, &c.OpeningHours); err != nil {
+			return nil, err
+		}
+	}
+	return &c, nil
+}
+
 // CinemaRepo encapsulates all database queries related to cinemas.  It
 // depends on a sql.DB connection which should be configured elsewhere.
 type CinemaRepo struct {
@@ -37,13 +82,18 @@ func NewCinemaRepo(db *sql.DB) *CinemaRepo {
 	return &CinemaRepo{db: db}
 }
 
-// Create inserts a new cinema into the database.  On success the cinema's
+// Create inserts a new cinema into the database.  An empty Timezone is
+// stored as "UTC" and an empty Currency as the default currency.  On success the cinema's
 // ID field will be populated with the auto‑generated value.  After the
 // insert, a SELECT is executed to populate the CreatedAt and UpdatedAt
 // fields so that callers receive a fully populated record.
 func (r *CinemaRepo) Create(ctx context.Context, c *Cinema) error {
-	const qInsert = "INSERT INTO cinemas (owner_id, name) VALUES (?, ?)"
-	res, err := r.db.ExecContext(ctx, qInsert, c.OwnerID, c.Name)
+	if c.Timezone == "" {
+		c.Timezone = "UTC"
+	}
+	c.Currency = money.Or(c.Currency)
+	const qInsert = "INSERT INTO cinemas (owner_id, name, timezone, currency, city, address, latitude, longitude) VALUES (?, ?, ?, ?, ?, ?, ?, ?)"
+	res, err := r.db.ExecContext(ctx, qInsert, c.OwnerID, c.Name, c.Timezone, c.Currency, c.City, c.Address, c.Latitude, c.Longitude)
 	if err != nil {
 		return err // propagate DB errors to the caller
 	}
@@ -52,12 +102,14 @@ func (r *CinemaRepo) Create(ctx context.Context, c *Cinema) error {
 		return err
 	}
 	c.ID = uint64(id)
+	cache.CatalogChanged(ctx)
 
     // Perform a follow‑up SELECT to populate default timestamp fields (created_at, updated_at).
-    const qSelect = "SELECT owner_id, name, created_at, updated_at FROM cinemas WHERE id = ?"
-    if err := r.db.QueryRowContext(ctx, qSelect, c.ID).Scan(&c.OwnerID, &c.Name, &c.CreatedAt, &c.UpdatedAt); err != nil {
+    got, err := scanCinema(r.db.QueryRowContext(ctx, "SELECT "+cinemaColumns+" FROM cinemas WHERE id = ?", c.ID))
+    if err != nil {
         return err
     }
+    *c = *got
     return nil
 }
 
@@ -65,37 +117,33 @@ func (r *CinemaRepo) Create(ctx context.Context, c *Cinema) error {
 // ErrCinemaNotFound if no row is found.  Callers can use this method
 // when they don't need to enforce ownership in the repository layer.
 func (r *CinemaRepo) GetByID(ctx context.Context, id uint64) (*Cinema, error) {
-	const q = "SELECT id, owner_id, name, created_at, updated_at FROM cinemas WHERE id = ?"
-	var c Cinema
-	if err := r.db.QueryRowContext(ctx, q, id).Scan(&c.ID, &c.OwnerID, &c.Name, &c.CreatedAt, &c.UpdatedAt); err != nil {
+	c, err := scanCinema(r.db.QueryRowContext(ctx, "SELECT "+cinemaColumns+" FROM cinemas WHERE id = ?", id))
+	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, ErrCinemaNotFound
 		}
 		return nil, err
 	}
-	return &c, nil
+	return c, nil
 }
 
 // GetByIDAndOwner fetches a cinema by id but only if it belongs to the
 // specified owner.  If the cinema doesn't exist or is owned by someone
 // else, ErrCinemaNotFound is returned.
 func (r *CinemaRepo) GetByIDAndOwner(ctx context.Context, id, ownerID uint64) (*Cinema, error) {
-	const q = "SELECT id, owner_id, name, created_at, updated_at FROM cinemas WHERE id = ? AND owner_id = ?"
-	var c Cinema
-	if err := r.db.QueryRowContext(ctx, q, id, ownerID).Scan(&c.ID, &c.OwnerID, &c.Name, &c.CreatedAt, &c.UpdatedAt); err != nil {
+	c, err := scanCinema(r.db.QueryRowContext(ctx, "SELECT "+cinemaColumns+" FROM cinemas WHERE id = ? AND owner_id = ?", id, ownerID))
+	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, ErrCinemaNotFound
 		}
 		return nil, err
 	}
-	return &c, nil
+	return c, nil
 }
 
 // ListByOwner returns all cinemas for a specific owner ordered by id.
 func (r *CinemaRepo) ListByOwner(ctx context.Context, ownerID uint64) ([]*Cinema, error) {
-	const q = `SELECT id, owner_id, name, created_at, updated_at
-	           FROM cinemas WHERE owner_id = ? ORDER BY id`
-	rows, err := r.db.QueryContext(ctx, q, ownerID)
+	rows, err := r.db.QueryContext(ctx, "SELECT "+cinemaColumns+" FROM cinemas WHERE owner_id = ? ORDER BY id", ownerID)
 	if err != nil {
 		return nil, err
 	}
@@ -103,8 +151,8 @@ func (r *CinemaRepo) ListByOwner(ctx context.Context, ownerID uint64) ([]*Cinema
 
 	var out []*Cinema
 	for rows.Next() {
-		c := new(Cinema)
-		if err := rows.Scan(&c.ID, &c.OwnerID, &c.Name, &c.CreatedAt, &c.UpdatedAt); err != nil {
+		c, err := scanCinema(rows)
+		if err != nil {
 			return nil, err
 		}
 		out = append(out, c)
@@ -115,43 +163,127 @@ func (r *CinemaRepo) ListByOwner(ctx context.Context, ownerID uint64) ([]*Cinema
 	return out, nil
 }
 
-// UpdateName updates the cinema name if it belongs to the provided owner.
-// It returns sql.ErrNoRows when no row is affected (not found / not owned).
-func (r *CinemaRepo) UpdateName(ctx context.Context, id, ownerID uint64, name string) error {
+// Update sets the cinema name, time zone and currency if it belongs to
+// the provided owner.  It returns sql.ErrNoRows when no row is affected (not found /
+// not owned).
+func (r *CinemaRepo) Update(ctx context.Context, id, ownerID uint64, name, timezone, currency string) error {
 	const q = `UPDATE cinemas
-	           SET name = ?, updated_at = CURRENT_TIMESTAMP
+	           SET name = ?, timezone = ?, currency = ?, updated_at = CURRENT_TIMESTAMP
 	           WHERE id = ? AND owner_id = ?`
-	res, err := r.db.ExecContext(ctx, q, name, id, ownerID)
+	res, err := r.db.ExecContext(ctx, q, name, timezone, currency, id, ownerID)
 	if err != nil {
 		return err
 	}
 	if n, _ := res.RowsAffected(); n == 0 {
 		return sql.ErrNoRows
 	}
+	cache.CatalogChanged(ctx)
 	return nil
 }
 
-// ListAll returns all cinemas regardless of owner. It is used for public browsing
-// endpoints to present available cinemas to unauthenticated users. Only ID and
-// Name fields are selected to avoid exposing sensitive owner or timestamp fields.
-func (r *CinemaRepo) ListAll(ctx context.Context) ([]*Cinema, error) {
-    const q = `SELECT id, name FROM cinemas ORDER BY id`
-    rows, err := r.db.QueryContext(ctx, q)
+// UpdateLocation replaces the city, address and coordinates of a cinema
+// that belongs to the provided owner; nil values clear them.  It returns
+// sql.ErrNoRows when the cinema is not found or not owned.
+func (r *CinemaRepo) UpdateLocation(ctx context.Context, id, ownerID uint64, city, address *string, lat, lng *float64) error {
+	var n int
+	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM cinemas WHERE id = ? AND owner_id = ?`, id, ownerID).Scan(&n)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+	// RowsAffected is 0 when nothing changed, so existence is checked above.
+	const q = `UPDATE cinemas
+	           SET city = ?, address = ?, latitude = ?, longitude = ?, updated_at = CURRENT_TIMESTAMP
+	           WHERE id = ? AND owner_id = ?`
+	if _, err := r.db.ExecContext(ctx, q, city, address, lat, lng, id, ownerID); err != nil {
+		return err
+	}
+	cache.CatalogChanged(ctx)
+	return nil
+}
+
+// CinemaFilter narrows ListAll.  City, when non-empty, matches the city
+// exactly.  When Near is set, only cinemas with coordinates within
+// RadiusKm of it are returned, nearest first.
+type CinemaFilter struct {
+	City     string
+	Near     *GeoPoint
+	RadiusKm float64
+}
+
+// GeoPoint is a WGS84 position in degrees.
+type GeoPoint struct {
+	Lat, Lng float64
+}
+
+// earthRadiusKm is the mean radius of the Earth.
+const earthRadiusKm = 6371.0
+
+// DistanceKm returns the great-circle distance between p and q.
+func (p GeoPoint) DistanceKm(q GeoPoint) float64 {
+	rad := math.Pi / 180
+	dLat := (q.Lat - p.Lat) * rad
+	dLng := (q.Lng - p.Lng) * rad
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(p.Lat*rad)*math.Cos(q.Lat*rad)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusKm * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+// ListAll returns the cinemas matching f, regardless of owner, ordered by
+// id or, with f.Near, by distance.  It is used by the public browse
+// endpoints; callers must not expose the owner or timestamps.  The
+// distance filter first selects the bounding box around f.Near in SQL
+// (using idx_cinemas_geo) and then drops the corners by exact distance.
+func (r *CinemaRepo) ListAll(ctx context.Context, f CinemaFilter) ([]*Cinema, error) {
+    q := "SELECT " + cinemaColumns + " FROM cinemas WHERE 1=1"
+    var args []interface{}
+    if f.City != "" {
+        q += " AND city = ?"
+        args = append(args, f.City)
+    }
+    if f.Near != nil {
+        dLat := f.RadiusKm / (earthRadiusKm * math.Pi / 180)
+        q += " AND latitude BETWEEN ? AND ?"
+        args = append(args, f.Near.Lat-dLat, f.Near.Lat+dLat)
+        // Near the poles or across the antimeridian the longitude range
+        // wraps; the box then spans every longitude.
+        if cos := math.Cos(f.Near.Lat * math.Pi / 180); f.Near.Lat+dLat < 90 && f.Near.Lat-dLat > -90 && cos > 0 {
+            dLng := dLat / cos
+            if f.Near.Lng-dLng >= -180 && f.Near.Lng+dLng <= 180 {
+                q += " AND longitude BETWEEN ? AND ?"
+                args = append(args, f.Near.Lng-dLng, f.Near.Lng+dLng)
+            }
+        }
+        q += " AND longitude IS NOT NULL"
+    }
+    q += " ORDER BY id"
+    rows, err := r.db.QueryContext(ctx, q, args...)
     if err != nil {
         return nil, err
     }
     defer rows.Close()
     var out []*Cinema
     for rows.Next() {
-        c := &Cinema{}
-        if err := rows.Scan(&c.ID, &c.Name); err != nil {
+        c, err := scanCinema(rows)
+        if err != nil {
             return nil, err
         }
+        if f.Near != nil && (c.Latitude == nil || f.Near.DistanceKm(GeoPoint{*c.Latitude, *c.Longitude}) > f.RadiusKm) {
+            continue
+        }
         out = append(out, c)
     }
     if err := rows.Err(); err != nil {
         return nil, err
     }
+    if f.Near != nil {
+        sort.SliceStable(out, func(i, j int) bool {
+            return f.Near.DistanceKm(GeoPoint{*out[i].Latitude, *out[i].Longitude}) <
+                f.Near.DistanceKm(GeoPoint{*out[j].Latitude, *out[j].Longitude})
+        })
+    }
     return out, nil
 }
 
@@ -234,5 +366,6 @@ func (r *CinemaRepo) DeleteByIDAndOwner(ctx context.Context, id, ownerID uint64)
     if _, err = tx.ExecContext(ctx, `DELETE FROM cinemas WHERE id = ?`, id); err != nil {
         return err
     }
+    cache.CatalogChanged(ctx)
     return nil
 }
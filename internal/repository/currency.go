@@ -0,0 +1,29 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/iliyamo/cinema-seat-reservation/internal/money"
+)
+
+// CurrencyTx returns the currency of a show's prices, which is the
+// currency of the show's cinema (money.Default when it has none).  It
+// returns ErrShowNotFound if there is no such show.
+func (r *ShowRepo) CurrencyTx(ctx context.Context, tx *sql.Tx, id uint64) (string, error) {
+	var currency sql.NullString
+	err := tx.QueryRowContext(ctx,
+		`SELECT c.currency
+		 FROM shows s
+		 JOIN halls h ON h.id = s.hall_id
+		 LEFT JOIN cinemas c ON c.id = h.cinema_id
+		 WHERE s.id = ?`, id).Scan(&currency)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrShowNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	return money.Or(currency.String), nil
+}
@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// LongTransaction describes an InnoDB transaction that has been open for
+// longer than the requested threshold (information_schema.innodb_trx).
+type LongTransaction struct {
+	TrxID        string    `json:"trx_id"`
+	State        string    `json:"state"`
+	StartedAt    time.Time `json:"started_at"`
+	AgeSeconds   int64     `json:"age_seconds"`
+	ThreadID     uint64    `json:"thread_id"`
+	Query        *string   `json:"query,omitempty"`
+	RowsLocked   uint64    `json:"rows_locked"`
+	TablesLocked uint64    `json:"tables_locked"`
+}
+
+// LockWait describes one transaction waiting on a lock held by another
+// (sys.innodb_lock_waits, available on MySQL 5.7+ and 8.0).
+type LockWait struct {
+	WaitAgeSeconds int64   `json:"wait_age_seconds"`
+	LockedTable    string  `json:"locked_table"`
+	LockedIndex    *string `json:"locked_index,omitempty"`
+	WaitingTrxID   string  `json:"waiting_trx_id"`
+	WaitingPID     uint64  `json:"waiting_pid"`
+	WaitingQuery   *string `json:"waiting_query,omitempty"`
+	BlockingTrxID  string  `json:"blocking_trx_id"`
+	BlockingPID    uint64  `json:"blocking_pid"`
+	BlockingQuery  *string `json:"blocking_query,omitempty"`
+}
+
+// ShowHoldCount is the number of active (unexpired) seat holds on a show.
+type ShowHoldCount struct {
+	ShowID      uint64 `json:"show_id"`
+	Title       string `json:"title"`
+	ActiveHolds int    `json:"active_holds"`
+}
+
+// DiagnosticsRepo runs read-only operational queries used by the admin
+// diagnostics endpoint.  The connecting user needs the PROCESS privilege
+// to see other sessions' transactions.
+type DiagnosticsRepo struct {
+	db *sql.DB
+}
+
+// NewDiagnosticsRepo returns a new DiagnosticsRepo bound to the given database.
+func NewDiagnosticsRepo(db *sql.DB) *DiagnosticsRepo { return &DiagnosticsRepo{db: db} }
+
+func nullStringPtr(ns sql.NullString) *string {
+	if !ns.Valid {
+		return nil
+	}
+	s := ns.String
+	return &s
+}
+
+// nullTimePtr returns a pointer to the time in nt, in UTC, or nil when it
+// is NULL.
+func nullTimePtr(nt sql.NullTime) *time.Time {
+	if !nt.Valid {
+		return nil
+	}
+	t := nt.Time.UTC()
+	return &t
+}
+
+// LongTransactions returns InnoDB transactions open for at least minAge,
+// oldest first.
+func (r *DiagnosticsRepo) LongTransactions(ctx context.Context, minAge time.Duration) ([]LongTransaction, error) {
+	const q = `SELECT trx_id, trx_state, trx_started, TIMESTAMPDIFF(SECOND, trx_started, NOW()),
+                      trx_mysql_thread_id, trx_query, trx_rows_locked, trx_tables_locked
+               FROM information_schema.innodb_trx
+               WHERE TIMESTAMPDIFF(SECOND, trx_started, NOW()) >= ?
+               ORDER BY trx_started`
+	rows, err := r.db.QueryContext(ctx, q, int64(minAge/time.Second))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := make([]LongTransaction, 0)
+	for rows.Next() {
+		var t LongTransaction
+		var query sql.NullString
+		if err := rows.Scan(&t.TrxID, &t.State, &t.StartedAt, &t.AgeSeconds, &t.ThreadID, &query, &t.RowsLocked, &t.TablesLocked); err != nil {
+			return nil, err
+		}
+		t.Query = nullStringPtr(query)
+		out = append(out, t)
+	}
+	return out, rows.Err()
+}
+
+// LockWaits returns current lock waits, longest wait first.
+func (r *DiagnosticsRepo) LockWaits(ctx context.Context) ([]LockWait, error) {
+	const q = `SELECT wait_age_secs, locked_table, locked_index,
+                      waiting_trx_id, waiting_pid, waiting_query,
+                      blocking_trx_id, blocking_pid, blocking_query
+               FROM sys.innodb_lock_waits
+               ORDER BY wait_age_secs DESC`
+	rows, err := r.db.QueryContext(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := make([]LockWait, 0)
+	for rows.Next() {
+		var w LockWait
+		var idx, wq, bq sql.NullString
+		if err := rows.Scan(&w.WaitAgeSeconds, &w.LockedTable, &idx, &w.WaitingTrxID, &w.WaitingPID, &wq,
+			&w.BlockingTrxID, &w.BlockingPID, &bq); err != nil {
+			return nil, err
+		}
+		w.LockedIndex = nullStringPtr(idx)
+		w.WaitingQuery = nullStringPtr(wq)
+		w.BlockingQuery = nullStringPtr(bq)
+		out = append(out, w)
+	}
+	return out, rows.Err()
+}
+
+// ActiveHoldCounts returns the shows with the most active seat holds.
+func (r *DiagnosticsRepo) ActiveHoldCounts(ctx context.Context, limit int) ([]ShowHoldCount, error) {
+	const q = `SELECT sh.show_id, s.title, COUNT(*) AS active
+               FROM seat_holds sh
+               JOIN shows s ON s.id = sh.show_id
+               WHERE sh.expires_at > UTC_TIMESTAMP()
+               GROUP BY sh.show_id, s.title
+               ORDER BY active DESC, sh.show_id
+               LIMIT ?`
+	rows, err := r.db.QueryContext(ctx, q, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := make([]ShowHoldCount, 0)
+	for rows.Next() {
+		var c ShowHoldCount
+		if err := rows.Scan(&c.ShowID, &c.Title, &c.ActiveHolds); err != nil {
+			return nil, err
+		}
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
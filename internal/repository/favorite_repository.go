@@ -0,0 +1,181 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+)
+
+// Favorite is a cinema or movie a customer follows.  Exactly one of
+// CinemaID and MovieTitle is set; CinemaName is filled in by ListByUser
+// for cinemas.
+type Favorite struct {
+	ID         uint64    `json:"id"`
+	UserID     uint64    `json:"-"`
+	CinemaID   *uint64   `json:"cinema_id,omitempty"`
+	CinemaName string    `json:"cinema_name,omitempty"`
+	MovieTitle *string   `json:"movie_title,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// NewShow is a scheduled show whose followers have not been notified yet,
+// as returned by UnannouncedShows.
+type NewShow struct {
+	ID         uint64
+	Title      string
+	StartsAt   time.Time
+	Status     string
+	CinemaID   uint64
+	CinemaName string
+	HallName   string
+}
+
+var (
+	// ErrFavoriteExists is returned by Create when the user already
+	// follows the cinema or movie.
+	ErrFavoriteExists = errors.New("already a favourite")
+	// ErrFavoriteNotFound is returned by Delete when the user has no
+	// favourite with the id.
+	ErrFavoriteNotFound = errors.New("favourite not found")
+)
+
+// FavoriteRepo persists favorites and finds the followers of new shows.
+type FavoriteRepo struct {
+	db *sql.DB
+}
+
+// NewFavoriteRepo returns a new FavoriteRepo bound to the given database.
+func NewFavoriteRepo(db *sql.DB) *FavoriteRepo { return &FavoriteRepo{db: db} }
+
+// DB exposes the underlying sql.DB for transactions spanning repositories.
+func (r *FavoriteRepo) DB() *sql.DB { return r.db }
+
+// Create stores f and sets its ID and CreatedAt.  Following the same
+// cinema or movie twice yields ErrFavoriteExists.
+func (r *FavoriteRepo) Create(ctx context.Context, f *Favorite) error {
+	res, err := r.db.ExecContext(ctx,
+		`INSERT INTO favorites (user_id, cinema_id, movie_title) VALUES (?, ?, ?)`,
+		f.UserID, f.CinemaID, f.MovieTitle)
+	if err != nil {
+		if strings.Contains(err.Error(), "1062") {
+			return ErrFavoriteExists
+		}
+		return err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	f.ID = uint64(id)
+	f.CreatedAt = time.Now().UTC().Truncate(time.Second)
+	return nil
+}
+
+// ListByUser returns the user's favourites, newest first.
+func (r *FavoriteRepo) ListByUser(ctx context.Context, userID uint64) ([]Favorite, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT f.id, f.user_id, f.cinema_id, COALESCE(c.name, ''), f.movie_title, f.created_at
+		 FROM favorites f
+		 LEFT JOIN cinemas c ON c.id = f.cinema_id
+		 WHERE f.user_id = ?
+		 ORDER BY f.id DESC`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []Favorite{}
+	for rows.Next() {
+		var (
+			f      Favorite
+			cinema sql.NullInt64
+			title  sql.NullString
+		)
+		if err := rows.Scan(&f.ID, &f.UserID, &cinema, &f.CinemaName, &title, &f.CreatedAt); err != nil {
+			return nil, err
+		}
+		if cinema.Valid {
+			id := uint64(cinema.Int64)
+			f.CinemaID = &id
+		}
+		if title.Valid {
+			f.MovieTitle = &title.String
+		}
+		out = append(out, f)
+	}
+	return out, rows.Err()
+}
+
+// Delete removes one of the user's favourites.
+func (r *FavoriteRepo) Delete(ctx context.Context, id, userID uint64) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM favorites WHERE id = ? AND user_id = ?`, id, userID)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrFavoriteNotFound
+	}
+	return nil
+}
+
+// UnannouncedShows returns up to limit shows whose followers have not
+// been notified, oldest first.
+func (r *FavoriteRepo) UnannouncedShows(ctx context.Context, limit int) ([]NewShow, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT s.id, s.title, s.starts_at, s.status, c.id, c.name, h.name
+		 FROM shows s
+		 JOIN halls h ON h.id = s.hall_id
+		 JOIN cinemas c ON c.id = h.cinema_id
+		 WHERE s.followers_notified_at IS NULL
+		 ORDER BY s.id LIMIT ?`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []NewShow
+	for rows.Next() {
+		var s NewShow
+		if err := rows.Scan(&s.ID, &s.Title, &s.StartsAt, &s.Status, &s.CinemaID, &s.CinemaName, &s.HallName); err != nil {
+			return nil, err
+		}
+		out = append(out, s)
+	}
+	return out, rows.Err()
+}
+
+// MarkAnnouncedTx records inside tx that the followers of a show were
+// notified.  It reports false when another worker got there first, in
+// which case the caller must not notify anyone.
+func (r *FavoriteRepo) MarkAnnouncedTx(ctx context.Context, tx *sql.Tx, showID uint64) (bool, error) {
+	res, err := tx.ExecContext(ctx,
+		`UPDATE shows SET followers_notified_at = UTC_TIMESTAMP() WHERE id = ? AND followers_notified_at IS NULL`, showID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// FollowersTx returns the users following the cinema or the movie title,
+// each once.
+func (r *FavoriteRepo) FollowersTx(ctx context.Context, tx *sql.Tx, cinemaID uint64, title string) ([]uint64, error) {
+	rows, err := tx.QueryContext(ctx,
+		`SELECT DISTINCT user_id FROM favorites WHERE cinema_id = ? OR movie_title = ? ORDER BY user_id`,
+		cinemaID, title)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []uint64
+	for rows.Next() {
+		var id uint64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		out = append(out, id)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+// Booking fee types of a cinema.
+const (
+	FeePercent = "PERCENT" // FeeValue basis points of the discounted seat prices
+	FeeFlat    = "FLAT"    // FeeValue cents per ticket
+)
+
+// CinemaFees is what a cinema charges on top of its seat prices: an
+// optional booking fee and a tax rate.  FeeType is nil when no fee is
+// charged.  TaxRateBP is in basis points (1900 = 19%) and applies to the
+// discounted seat prices plus the fee.
+type CinemaFees struct {
+	FeeType   *string `json:"fee_type"`
+	FeeValue  uint32  `json:"fee_value"`
+	TaxRateBP uint32  `json:"tax_rate_bp"`
+}
+
+// Charges returns the booking fee and tax of tickets seats whose prices
+// add up to amount after discounts.  Percentages are rounded half up to
+// the cent.
+func (f *CinemaFees) Charges(tickets int, amount uint32) (fees, tax uint32) {
+	if f == nil {
+		return 0, 0
+	}
+	if f.FeeType != nil {
+		switch *f.FeeType {
+		case FeePercent:
+			fees = basisPoints(amount, f.FeeValue)
+		case FeeFlat:
+			fees = uint32(tickets) * f.FeeValue
+		}
+	}
+	tax = basisPoints(amount+fees, f.TaxRateBP)
+	return fees, tax
+}
+
+// basisPoints returns bp basis points of amount, rounded half up.
+func basisPoints(amount, bp uint32) uint32 {
+	return uint32((uint64(amount)*uint64(bp) + 5000) / 10000)
+}
+
+// FeesTx returns the fees of a show's cinema; shows of halls without a
+// cinema charge none.  It returns ErrShowNotFound if there is no such
+// show.
+func (r *ShowRepo) FeesTx(ctx context.Context, tx *sql.Tx, id uint64) (*CinemaFees, error) {
+	var (
+		f       CinemaFees
+		feeType sql.NullString
+		value   sql.NullInt64
+		tax     sql.NullInt64
+	)
+	err := tx.QueryRowContext(ctx,
+		`SELECT c.fee_type, c.fee_value, c.tax_rate_bp
+		 FROM shows s
+		 JOIN halls h ON h.id = s.hall_id
+		 LEFT JOIN cinemas c ON c.id = h.cinema_id
+		 WHERE s.id = ?`, id).Scan(&feeType, &value, &tax)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrShowNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if feeType.Valid {
+		f.FeeType = &feeType.String
+	}
+	f.FeeValue, f.TaxRateBP = uint32(value.Int64), uint32(tax.Int64)
+	return &f, nil
+}
+
+// Fees returns the fees of a cinema owned by ownerID, or
+// ErrCinemaNotFound.
+func (r *CinemaRepo) Fees(ctx context.Context, id, ownerID uint64) (*CinemaFees, error) {
+	var (
+		f       CinemaFees
+		feeType sql.NullString
+	)
+	err := r.db.QueryRowContext(ctx,
+		`SELECT fee_type, fee_value, tax_rate_bp FROM cinemas WHERE id = ? AND owner_id = ?`, id, ownerID).
+		Scan(&feeType, &f.FeeValue, &f.TaxRateBP)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrCinemaNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if feeType.Valid {
+		f.FeeType = &feeType.String
+	}
+	return &f, nil
+}
+
+// SetFees replaces the fees of a cinema owned by ownerID.  It returns
+// ErrCinemaNotFound when the owner has no such cinema.
+func (r *CinemaRepo) SetFees(ctx context.Context, id, ownerID uint64, f *CinemaFees) error {
+	if _, err := r.Fees(ctx, id, ownerID); err != nil {
+		return err
+	}
+	// RowsAffected is 0 when nothing changed, so existence is checked above.
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE cinemas SET fee_type = ?, fee_value = ?, tax_rate_bp = ?, updated_at = CURRENT_TIMESTAMP
+		 WHERE id = ? AND owner_id = ?`, f.FeeType, f.FeeValue, f.TaxRateBP, id, ownerID)
+	return err
+}
@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/iliyamo/cinema-seat-reservation/internal/utils"
+)
+
+// guestRoleID is the roles row of guest users (see 0051_guest_sessions).
+const guestRoleID = 5
+
+// CreateGuest inserts a GUEST user for an anonymous guest session and
+// returns its ID.  The user gets a random placeholder email under the
+// reserved .invalid domain and a password hash nothing verifies against,
+// so it can never log in.
+func (r *UserRepo) CreateGuest(ctx context.Context) (uint64, error) {
+	secret, err := utils.NewSecret()
+	if err != nil {
+		return 0, err
+	}
+	res, err := r.DB.ExecContext(ctx,
+		"INSERT INTO users (email, password_hash, role_id) VALUES (?,?,?)",
+		"guest-"+secret[:24]+"@guest.invalid", "!", guestRoleID)
+	if err != nil {
+		return 0, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	return uint64(id), nil
+}
+
+// AdoptGuest moves every hold of the guest user guestID to userID and
+// deletes the guest, in one transaction.  It returns how many of the
+// moved holds were still active; expired ones move too so the hold
+// expiry sweeper still frees their seats.  A guestID that is not a guest
+// user moves nothing.
+func (r *UserRepo) AdoptGuest(ctx context.Context, guestID, userID uint64) (active int64, err error) {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+	var isGuest bool
+	err = tx.QueryRowContext(ctx,
+		`SELECT 1 FROM users WHERE id = ? AND role_id = ? FOR UPDATE`, guestID, guestRoleID).Scan(&isGuest)
+	if err == sql.ErrNoRows {
+		return 0, tx.Rollback()
+	}
+	if err != nil {
+		return 0, err
+	}
+	res, err := tx.ExecContext(ctx,
+		`UPDATE seat_holds SET user_id = ? WHERE user_id = ? AND expires_at > UTC_TIMESTAMP()`, userID, guestID)
+	if err != nil {
+		return 0, err
+	}
+	active, err = res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if _, err = tx.ExecContext(ctx, `UPDATE seat_holds SET user_id = ? WHERE user_id = ?`, userID, guestID); err != nil {
+		return 0, err
+	}
+	if _, err = tx.ExecContext(ctx, `DELETE FROM users WHERE id = ?`, guestID); err != nil {
+		return 0, err
+	}
+	if err = tx.Commit(); err != nil {
+		return 0, err
+	}
+	return active, nil
+}
+
+// DeleteStaleGuests deletes guest users created more than olderThanSec
+// seconds ago that hold no seats, and returns how many were deleted.
+func (r *UserRepo) DeleteStaleGuests(ctx context.Context, olderThanSec int) (int64, error) {
+	res, err := r.DB.ExecContext(ctx,
+		`DELETE FROM users
+		 WHERE role_id = ? AND created_at < NOW() - INTERVAL ? SECOND
+		   AND NOT EXISTS (SELECT 1 FROM seat_holds h WHERE h.user_id = users.id)`,
+		guestRoleID, olderThanSec)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/iliyamo/cinema-seat-reservation/internal/cache"
+)
+
+// CloneTx creates dst as a copy of hall srcID inside tx: the new hall
+// takes dst's owner, cinema, name and description and the source's
+// dimensions, custom layout and rendering metadata, and gets a copy of every seat with its
+// type, active flag and accessibility attributes.  Retired seats and
+// shows are not copied.
+// It sets dst.ID and returns the number of seats created, or
+// ErrHallNotFound when the source hall does not exist.
+func (r *HallRepo) CloneTx(ctx context.Context, tx *sql.Tx, srcID uint64, dst *Hall) (int, error) {
+	res, err := tx.ExecContext(ctx,
+		`INSERT INTO halls (owner_id, cinema_id, name, description, seat_rows, seat_cols, layout, rendering)
+		 SELECT ?, ?, ?, ?, seat_rows, seat_cols, layout, rendering FROM halls WHERE id = ?`,
+		dst.OwnerID, dst.CinemaID, dst.Name, dst.Description, srcID)
+	if err != nil {
+		return 0, err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return 0, err
+	} else if n == 0 {
+		return 0, ErrHallNotFound
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	dst.ID = uint64(id)
+	res, err = tx.ExecContext(ctx,
+		`INSERT INTO seats (hall_id, row_label, seat_number, seat_type, is_active)
+		 SELECT ?, row_label, seat_number, seat_type, is_active FROM seats WHERE hall_id = ? AND valid_to IS NULL ORDER BY id`,
+		dst.ID, srcID)
+	if err != nil {
+		return 0, err
+	}
+	seats, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	// Seats are matched by position, which is unique within a hall.
+	if _, err := tx.ExecContext(ctx,
+		`INSERT INTO seat_accessibility (seat_id, wheelchair_space, companion_seat, transfer_seat, step_free_access)
+		 SELECT ns.id, sa.wheelchair_space, sa.companion_seat, sa.transfer_seat, sa.step_free_access
+		 FROM seat_accessibility sa
+		 JOIN seats os ON os.id = sa.seat_id
+		 JOIN seats ns ON ns.hall_id = ? AND ns.row_label = os.row_label AND ns.seat_number = os.seat_number
+		 WHERE os.hall_id = ? AND os.valid_to IS NULL`, dst.ID, srcID); err != nil {
+		return 0, err
+	}
+	cache.CatalogChanged(ctx)
+	return int(seats), nil
+}
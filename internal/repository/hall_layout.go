@@ -0,0 +1,178 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/iliyamo/cinema-seat-reservation/internal/cache"
+)
+
+// HallLayout is a custom seat layout.  Each row lists the seat numbers
+// that exist in it; numbers that are skipped are gaps or aisles.  Rows
+// are kept in display order and their labels are unique.
+type HallLayout struct {
+	Rows []HallLayoutRow `json:"rows"`
+}
+
+// HallLayoutRow is one row of a HallLayout.  Seats is ascending.
+type HallLayoutRow struct {
+	Label string   `json:"label"`
+	Seats []uint32 `json:"seats"`
+}
+
+// ErrInvalidLayout is returned by HallLayout.Normalize for layouts
+// without seats, with duplicate rows or with duplicate seats.
+var ErrInvalidLayout = errors.New("invalid hall layout")
+
+// Normalize upper-cases labels, sorts the seats of every row and drops
+// empty rows.  It returns ErrInvalidLayout when a label or seat repeats,
+// a seat number is 0 or no seat is left.
+func (l *HallLayout) Normalize() error {
+	seen := make(map[string]bool, len(l.Rows))
+	rows := l.Rows[:0]
+	for _, row := range l.Rows {
+		row.Label = strings.ToUpper(row.Label)
+		if row.Label == "" || seen[row.Label] {
+			return ErrInvalidLayout
+		}
+		seen[row.Label] = true
+		if len(row.Seats) == 0 {
+			continue
+		}
+		sort.Slice(row.Seats, func(i, j int) bool { return row.Seats[i] < row.Seats[j] })
+		for i, n := range row.Seats {
+			if n == 0 || (i > 0 && row.Seats[i-1] == n) {
+				return ErrInvalidLayout
+			}
+		}
+		rows = append(rows, row)
+	}
+	if len(rows) == 0 {
+		return ErrInvalidLayout
+	}
+	l.Rows = rows
+	return nil
+}
+
+// Dims returns the number of rows and the highest seat number, which are
+// stored as the hall's seat_rows and seat_cols.
+func (l *HallLayout) Dims() (rows, cols uint32) {
+	for _, row := range l.Rows {
+		if n := row.Seats[len(row.Seats)-1]; n > cols {
+			cols = n
+		}
+	}
+	return uint32(len(l.Rows)), cols
+}
+
+// Seats returns a STANDARD seat of hallID for every seat of the layout.
+func (l *HallLayout) Seats(hallID uint64) []Seat {
+	var out []Seat
+	for _, row := range l.Rows {
+		for _, n := range row.Seats {
+			out = append(out, Seat{HallID: hallID, RowLabel: row.Label, SeatNumber: n, SeatType: "STANDARD"})
+		}
+	}
+	return out
+}
+
+// LayoutFromSeats describes existing seats as a layout, keeping the row
+// order of prev where a row still exists and appending new rows in label
+// order.  prev may be nil.
+func LayoutFromSeats(seats []Seat, prev *HallLayout) *HallLayout {
+	byRow := make(map[string][]uint32)
+	for _, s := range seats {
+		lbl := strings.ToUpper(s.RowLabel)
+		byRow[lbl] = append(byRow[lbl], s.SeatNumber)
+	}
+	out := &HallLayout{}
+	if prev != nil {
+		for _, row := range prev.Rows {
+			if nums, ok := byRow[row.Label]; ok {
+				out.Rows = append(out.Rows, HallLayoutRow{Label: row.Label, Seats: nums})
+				delete(byRow, row.Label)
+			}
+		}
+	}
+	labels := make([]string, 0, len(byRow))
+	for lbl := range byRow {
+		labels = append(labels, lbl)
+	}
+	// Shorter labels first so that Z sorts before AA.
+	sort.Slice(labels, func(i, j int) bool {
+		if len(labels[i]) != len(labels[j]) {
+			return len(labels[i]) < len(labels[j])
+		}
+		return labels[i] < labels[j]
+	})
+	for _, lbl := range labels {
+		out.Rows = append(out.Rows, HallLayoutRow{Label: lbl, Seats: byRow[lbl]})
+	}
+	for _, row := range out.Rows {
+		sort.Slice(row.Seats, func(i, j int) bool { return row.Seats[i] < row.Seats[j] })
+	}
+	return out
+}
+
+// GetLayout returns the custom layout of a hall, or nil when the hall
+// uses the dense seat_rows x seat_cols grid.  It returns ErrHallNotFound
+// when the hall does not exist.
+func (r *HallRepo) GetLayout(ctx context.Context, hallID uint64) (*HallLayout, error) {
+	var raw []byte
+	err := r.db.QueryRowContext(ctx, `SELECT layout FROM halls WHERE id = ?`, hallID).Scan(&raw)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrHallNotFound
+		}
+		return nil, err
+	}
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	var l HallLayout
+	if err := json.Unmarshal(raw, &l); err != nil {
+		return nil, err
+	}
+	return &l, nil
+}
+
+// SetLayoutTx stores a hall's layout together with the seat_rows and
+// seat_cols it implies.  A nil layout only clears the stored layout and
+// leaves the dimensions alone.
+func (r *HallRepo) SetLayoutTx(ctx context.Context, tx *sql.Tx, hallID uint64, l *HallLayout) error {
+	return setLayout(ctx, tx, hallID, l)
+}
+
+// SetLayout is SetLayoutTx outside a transaction.
+func (r *HallRepo) SetLayout(ctx context.Context, hallID uint64, l *HallLayout) error {
+	return setLayout(ctx, r.db, hallID, l)
+}
+
+func setLayout(ctx context.Context, q interface {
+	ExecContext(context.Context, string, ...interface{}) (sql.Result, error)
+}, hallID uint64, l *HallLayout) error {
+	if l == nil {
+		if _, err := q.ExecContext(ctx, `UPDATE halls SET layout = NULL WHERE id = ?`, hallID); err != nil {
+			return err
+		}
+		cache.CatalogChanged(ctx)
+		return nil
+	}
+	raw, err := json.Marshal(l)
+	if err != nil {
+		return err
+	}
+	rows, cols := l.Dims()
+	_, err = q.ExecContext(ctx,
+		`UPDATE halls SET layout = ?, seat_rows = ?, seat_cols = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`,
+		jsonArg(raw), rows, cols, hallID)
+	if err != nil {
+		return err
+	}
+	cache.CatalogChanged(ctx)
+	return nil
+}
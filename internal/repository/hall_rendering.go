@@ -0,0 +1,172 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/iliyamo/cinema-seat-reservation/internal/cache"
+)
+
+// DefaultScreen is the edge of the seat map the screen is at when a hall
+// has no rendering metadata.
+const DefaultScreen = "top"
+
+// HallRendering is what a seat picker needs besides the seats to draw a
+// hall: the edge of the map the screen is at (top, bottom, left or
+// right), named sections made of rows, and per-row adjustments.  Rows are
+// referred to by label; labels that no longer exist in the hall are
+// ignored.
+type HallRendering struct {
+	Screen   string             `json:"screen"`
+	Sections []HallSection      `json:"sections"`
+	Rows     []HallRowRendering `json:"rows"`
+}
+
+// HallSection is a named group of rows, such as "Stalls" or "Balcony".
+type HallSection struct {
+	Name string   `json:"name"`
+	Rows []string `json:"rows"`
+}
+
+// HallRowRendering adjusts how one row is drawn.  Offset shifts the row
+// sideways, in seat widths (0.5 staggers it); Spacing is the number of
+// empty rows drawn before it, for example for a cross aisle.
+type HallRowRendering struct {
+	Label   string  `json:"label"`
+	Offset  float64 `json:"offset"`
+	Spacing uint8   `json:"spacing"`
+}
+
+// ErrInvalidRendering is returned by HallRendering.Normalize for
+// sections or rows that repeat.
+var ErrInvalidRendering = errors.New("invalid hall rendering")
+
+// Normalize upper-cases row labels and fills in the default screen.  It
+// returns ErrInvalidRendering when a section name repeats, a row belongs
+// to more than one section or a row is adjusted twice.
+func (r *HallRendering) Normalize() error {
+	if r.Screen == "" {
+		r.Screen = DefaultScreen
+	}
+	r.Screen = strings.ToLower(r.Screen)
+	names := make(map[string]bool, len(r.Sections))
+	inSection := make(map[string]bool)
+	for i := range r.Sections {
+		s := &r.Sections[i]
+		s.Name = strings.TrimSpace(s.Name)
+		key := strings.ToLower(s.Name)
+		if s.Name == "" || names[key] {
+			return ErrInvalidRendering
+		}
+		names[key] = true
+		for j, lbl := range s.Rows {
+			lbl = strings.ToUpper(strings.TrimSpace(lbl))
+			if lbl == "" || inSection[lbl] {
+				return ErrInvalidRendering
+			}
+			inSection[lbl] = true
+			s.Rows[j] = lbl
+		}
+	}
+	adjusted := make(map[string]bool, len(r.Rows))
+	for i := range r.Rows {
+		row := &r.Rows[i]
+		row.Label = strings.ToUpper(strings.TrimSpace(row.Label))
+		if row.Label == "" || adjusted[row.Label] {
+			return ErrInvalidRendering
+		}
+		adjusted[row.Label] = true
+	}
+	if r.Sections == nil {
+		r.Sections = []HallSection{}
+	}
+	if r.Rows == nil {
+		r.Rows = []HallRowRendering{}
+	}
+	return nil
+}
+
+// Row returns the section of the row with the given label ("" when it is
+// in none) and its adjustments.
+func (r *HallRendering) Row(label string) (section string, adj HallRowRendering) {
+	label = strings.ToUpper(label)
+	for _, s := range r.Sections {
+		for _, lbl := range s.Rows {
+			if lbl == label {
+				section = s.Name
+			}
+		}
+	}
+	adj.Label = label
+	for _, row := range r.Rows {
+		if row.Label == label {
+			adj = row
+		}
+	}
+	return section, adj
+}
+
+// decodeRendering parses a stored rendering; NULL gives the default.
+func decodeRendering(raw []byte) (*HallRendering, error) {
+	r := &HallRendering{}
+	if len(raw) > 0 {
+		if err := json.Unmarshal(raw, r); err != nil {
+			return nil, err
+		}
+	}
+	// Stored renderings were normalized when saved; this only fills in
+	// the defaults.
+	_ = r.Normalize()
+	return r, nil
+}
+
+// GetRendering returns the rendering metadata of a hall, or the default
+// when none was set.  It returns ErrHallNotFound when the hall does not
+// exist.
+func (r *HallRepo) GetRendering(ctx context.Context, hallID uint64) (*HallRendering, error) {
+	var raw []byte
+	err := r.db.QueryRowContext(ctx, `SELECT rendering FROM halls WHERE id = ?`, hallID).Scan(&raw)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrHallNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return decodeRendering(raw)
+}
+
+// RenderingByShow returns the rendering metadata of a show's hall.  It
+// returns ErrShowNotFound when the show does not exist.
+func (r *HallRepo) RenderingByShow(ctx context.Context, showID uint64) (*HallRendering, error) {
+	var raw []byte
+	err := r.db.QueryRowContext(ctx,
+		`SELECT h.rendering FROM shows s JOIN halls h ON h.id = s.hall_id WHERE s.id = ?`, showID).Scan(&raw)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrShowNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return decodeRendering(raw)
+}
+
+// SetRendering stores the rendering metadata of a hall; nil clears it.
+func (r *HallRepo) SetRendering(ctx context.Context, hallID uint64, rd *HallRendering) error {
+	var raw []byte
+	if rd != nil {
+		var err error
+		if raw, err = json.Marshal(rd); err != nil {
+			return err
+		}
+	}
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE halls SET rendering = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`, jsonArg(raw), hallID)
+	if err != nil {
+		return err
+	}
+	cache.CatalogChanged(ctx)
+	return nil
+}
@@ -8,6 +8,10 @@ import (
 	"context"      // context is used to manage deadlines and cancellation
 	"database/sql" // sql provides DB primitives
 	"errors"       // errors package allows sentinel error definitions
+	"time"         // time holds row timestamps
+
+	"github.com/iliyamo/cinema-seat-reservation/internal/cache"
+	"github.com/iliyamo/cinema-seat-reservation/internal/money"
 )
 
 // Hall represents a screening hall within a cinema. Each hall belongs to
@@ -21,14 +25,26 @@ type Hall struct {
 	SeatRows    sql.NullInt32  // SeatRows indicates how many seating rows exist; nullable
 	SeatCols    sql.NullInt32  // SeatCols indicates how many seats per row; nullable
 	IsActive    bool           // IsActive flag indicates if the hall is currently in use
-	CreatedAt   string         // CreatedAt stores creation timestamp
-	UpdatedAt   string         // UpdatedAt stores last update timestamp
+	Timezone    string         // Timezone is the IANA time zone of the hall's cinema; "UTC" for halls without a cinema
+	Currency    string         // Currency is the ISO 4217 code of the hall's cinema; the default currency for halls without one
+	CreatedAt   time.Time      // CreatedAt stores creation timestamp
+	UpdatedAt   time.Time      // UpdatedAt stores last update timestamp
 }
 
+// hallTimezone selects the time zone of hall h from its cinema.
+const hallTimezone = `COALESCE((SELECT c.timezone FROM cinemas c WHERE c.id = h.cinema_id), 'UTC')`
+
+// hallCurrency selects the currency of hall h from its cinema; empty when
+// the cinema has none recorded, which readers map to money.Default.
+const hallCurrency = `COALESCE((SELECT c.currency FROM cinemas c WHERE c.id = h.cinema_id), '')`
+
 // ErrHallNotFound is returned when a hall lookup fails.
 var ErrHallNotFound = errors.New("hall not found")
 // ErrHallConflict is returned when another hall with identical attributes exists.
 var ErrHallConflict = errors.New("hall already exists with identical attributes")
+// ErrHallInUse is returned by DeleteByIDAndOwner while shows of the hall
+// that have not ended have open (PENDING or CONFIRMED) reservations.
+var ErrHallInUse = errors.New("hall has upcoming shows with reservations")
 
 // HallRepo provides methods to create and retrieve halls.  It embeds a
 // database handle to perform queries and commands.
@@ -59,13 +75,15 @@ func (r *HallRepo) Create(ctx context.Context, h *Hall) error {
 		return err
 	}
 	h.ID = uint64(id)
+	cache.CatalogChanged(ctx)
 
     // Perform a follow‑up SELECT to populate computed fields (is_active, created_at, updated_at).
-    const qSelect = `SELECT id, owner_id, cinema_id, name, description, seat_rows, seat_cols, is_active, created_at, updated_at
-                     FROM halls WHERE id = ?`
-    if err := r.db.QueryRowContext(ctx, qSelect, h.ID).Scan(&h.ID, &h.OwnerID, &h.CinemaID, &h.Name, &h.Description, &h.SeatRows, &h.SeatCols, &h.IsActive, &h.CreatedAt, &h.UpdatedAt); err != nil {
+    const qSelect = `SELECT id, owner_id, cinema_id, name, description, seat_rows, seat_cols, is_active, ` + hallTimezone + `, ` + hallCurrency + `, created_at, updated_at
+                     FROM halls h WHERE id = ?`
+    if err := r.db.QueryRowContext(ctx, qSelect, h.ID).Scan(&h.ID, &h.OwnerID, &h.CinemaID, &h.Name, &h.Description, &h.SeatRows, &h.SeatCols, &h.IsActive, &h.Timezone, &h.Currency, &h.CreatedAt, &h.UpdatedAt); err != nil {
         return err
     }
+    h.Currency = money.Or(h.Currency)
     return nil
 }
 
@@ -73,40 +91,44 @@ func (r *HallRepo) Create(ctx context.Context, h *Hall) error {
 // ErrHallNotFound when no row is found.  Rows and Cols may come back
 // NULL and are represented using sql.NullInt32.
 func (r *HallRepo) GetByID(ctx context.Context, id uint64) (*Hall, error) {
-	const q = `SELECT id, owner_id, cinema_id, name, description, seat_rows, seat_cols, is_active, created_at, updated_at FROM halls WHERE id = ?`
+	const q = `SELECT id, owner_id, cinema_id, name, description, seat_rows, seat_cols, is_active, ` + hallTimezone + `, ` + hallCurrency + `, created_at, updated_at FROM halls h WHERE id = ?`
 	var h Hall
 	// Perform the query and scan results into the hall struct fields.
-	err := r.db.QueryRowContext(ctx, q, id).Scan(&h.ID, &h.OwnerID, &h.CinemaID, &h.Name, &h.Description, &h.SeatRows, &h.SeatCols, &h.IsActive, &h.CreatedAt, &h.UpdatedAt)
+	err := r.db.QueryRowContext(ctx, q, id).Scan(&h.ID, &h.OwnerID, &h.CinemaID, &h.Name, &h.Description, &h.SeatRows, &h.SeatCols, &h.IsActive, &h.Timezone, &h.Currency, &h.CreatedAt, &h.UpdatedAt)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, ErrHallNotFound
 		}
 		return nil, err
 	}
+	h.Currency = money.Or(h.Currency)
 	return &h, nil
 }
 
 // GetByIDAndOwner retrieves a hall but only if it belongs to the given
-// owner.  This helper is used to enforce resource ownership.  If no
-// matching hall is found, ErrHallNotFound is returned.
+// owner or the owner has made that user staff for the hall (see
+// StaffRepo).  This helper is used to enforce resource ownership; the
+// returned hall's OwnerID is always the actual owner.  If no matching
+// hall is found, ErrHallNotFound is returned.
 func (r *HallRepo) GetByIDAndOwner(ctx context.Context, id, ownerID uint64) (*Hall, error) {
-	const q = `SELECT id, owner_id, cinema_id, name, description, seat_rows, seat_cols, is_active, created_at, updated_at FROM halls WHERE id = ? AND owner_id = ?`
+	const q = `SELECT id, owner_id, cinema_id, name, description, seat_rows, seat_cols, is_active, ` + hallTimezone + `, ` + hallCurrency + `, created_at, updated_at FROM halls h WHERE id = ? AND ` + managedHall
 	var h Hall
-	err := r.db.QueryRowContext(ctx, q, id, ownerID).Scan(&h.ID, &h.OwnerID, &h.CinemaID, &h.Name, &h.Description, &h.SeatRows, &h.SeatCols, &h.IsActive, &h.CreatedAt, &h.UpdatedAt)
+	err := r.db.QueryRowContext(ctx, q, id, ownerID, ownerID).Scan(&h.ID, &h.OwnerID, &h.CinemaID, &h.Name, &h.Description, &h.SeatRows, &h.SeatCols, &h.IsActive, &h.Timezone, &h.Currency, &h.CreatedAt, &h.UpdatedAt)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, ErrHallNotFound
 		}
 		return nil, err
 	}
+	h.Currency = money.Or(h.Currency)
 	return &h, nil
 }
 
 // ListByCinemaAndOwner returns all halls inside a cinema for the owner.
 // Useful for GET /v1/cinemas/:cinema_id/halls.
 func (r *HallRepo) ListByCinemaAndOwner(ctx context.Context, cinemaID, ownerID uint64) ([]*Hall, error) {
-	const q = `SELECT id, owner_id, cinema_id, name, description, seat_rows, seat_cols, is_active, created_at, updated_at
-               FROM halls
+	const q = `SELECT id, owner_id, cinema_id, name, description, seat_rows, seat_cols, is_active, ` + hallTimezone + `, ` + hallCurrency + `, created_at, updated_at
+               FROM halls h
                WHERE cinema_id = ? AND owner_id = ?
                ORDER BY id`
 	rows, err := r.db.QueryContext(ctx, q, cinemaID, ownerID)
@@ -118,9 +140,10 @@ func (r *HallRepo) ListByCinemaAndOwner(ctx context.Context, cinemaID, ownerID u
 	var out []*Hall
 	for rows.Next() {
 		h := new(Hall)
-		if err := rows.Scan(&h.ID, &h.OwnerID, &h.CinemaID, &h.Name, &h.Description, &h.SeatRows, &h.SeatCols, &h.IsActive, &h.CreatedAt, &h.UpdatedAt); err != nil {
+		if err := rows.Scan(&h.ID, &h.OwnerID, &h.CinemaID, &h.Name, &h.Description, &h.SeatRows, &h.SeatCols, &h.IsActive, &h.Timezone, &h.Currency, &h.CreatedAt, &h.UpdatedAt); err != nil {
 			return nil, err
 		}
+		h.Currency = money.Or(h.Currency)
 		out = append(out, h)
 	}
 	if err := rows.Err(); err != nil {
@@ -132,8 +155,8 @@ func (r *HallRepo) ListByCinemaAndOwner(ctx context.Context, cinemaID, ownerID u
 // ListByCinema returns all halls inside a cinema regardless of owner. It is used
 // by public browse endpoints to show available halls to unauthenticated users.
 func (r *HallRepo) ListByCinema(ctx context.Context, cinemaID uint64) ([]*Hall, error) {
-    const q = `SELECT id, owner_id, cinema_id, name, description, seat_rows, seat_cols, is_active, created_at, updated_at
-               FROM halls
+    const q = `SELECT id, owner_id, cinema_id, name, description, seat_rows, seat_cols, is_active, ` + hallTimezone + `, ` + hallCurrency + `, created_at, updated_at
+               FROM halls h
                WHERE cinema_id = ?
                ORDER BY id`
     rows, err := r.db.QueryContext(ctx, q, cinemaID)
@@ -145,9 +168,10 @@ func (r *HallRepo) ListByCinema(ctx context.Context, cinemaID uint64) ([]*Hall,
     for rows.Next() {
         h := new(Hall)
         if err := rows.Scan(&h.ID, &h.OwnerID, &h.CinemaID, &h.Name, &h.Description,
-            &h.SeatRows, &h.SeatCols, &h.IsActive, &h.CreatedAt, &h.UpdatedAt); err != nil {
+            &h.SeatRows, &h.SeatCols, &h.IsActive, &h.Timezone, &h.Currency, &h.CreatedAt, &h.UpdatedAt); err != nil {
             return nil, err
         }
+        h.Currency = money.Or(h.Currency)
         out = append(out, h)
     }
     if err := rows.Err(); err != nil {
@@ -179,6 +203,7 @@ func (r *HallRepo) UpdateByIDAndOwner(ctx context.Context, h *Hall) error {
     if n, _ := res.RowsAffected(); n == 0 {
         return sql.ErrNoRows
     }
+    cache.CatalogChanged(ctx)
     return nil
 }
 
@@ -251,8 +276,11 @@ func (r *HallRepo) ExistsExact(
 // DeleteByIDAndOwner removes a hall and all dependent records including seats,
 // shows, show seats, reservations and reservation seats. The hall must belong
 // to the provided owner; otherwise ErrForbidden is returned. If the hall
-// does not exist, sql.ErrNoRows is returned. The deletion is executed within
-// a single transaction to ensure data consistency.
+// does not exist, sql.ErrNoRows is returned. While a show of the hall that
+// has not ended has reservations, nothing is deleted and ErrHallInUse is
+// returned; the seats of those shows are locked first, so a booking cannot
+// slip in between the check and the deletion. The deletion is executed
+// within a single transaction to ensure data consistency.
 func (r *HallRepo) DeleteByIDAndOwner(ctx context.Context, id, ownerID uint64) error {
     tx, err := r.db.BeginTx(ctx, nil)
     if err != nil {
@@ -275,7 +303,35 @@ func (r *HallRepo) DeleteByIDAndOwner(ctx context.Context, id, ownerID uint64) e
         return err
     }
     if dbOwnerID != ownerID {
-        return ErrForbidden
+        err = ErrForbidden
+        return err
+    }
+    // Lock the seats of the hall's shows that have not ended, as bookings
+    // do, then refuse if any of those shows has open (PENDING or
+    // CONFIRMED) reservations; cancelled ones do not hold the hall.
+    now := time.Now().UTC()
+    locked, err := tx.QueryContext(ctx,
+        `SELECT ss.id FROM show_seats ss
+         JOIN shows sh ON sh.id = ss.show_id
+         WHERE sh.hall_id = ? AND sh.ends_at > ?
+         FOR UPDATE`, id, now)
+    if err != nil {
+        return err
+    }
+    if err = locked.Close(); err != nil {
+        return err
+    }
+    var reserved int
+    err = tx.QueryRowContext(ctx,
+        `SELECT COUNT(*) FROM reservations r
+         JOIN shows sh ON sh.id = r.show_id
+         WHERE sh.hall_id = ? AND sh.ends_at > ? AND r.status IN ('PENDING', 'CONFIRMED')`, id, now).Scan(&reserved)
+    if err != nil {
+        return err
+    }
+    if reserved > 0 {
+        err = ErrHallInUse
+        return err
     }
     // Remove reservation seats for shows in this hall
     // Delete depends on reservation_seats referencing reservations and shows; we must remove these first.
@@ -314,5 +370,6 @@ func (r *HallRepo) DeleteByIDAndOwner(ctx context.Context, id, ownerID uint64) e
     if _, err = tx.ExecContext(ctx, `DELETE FROM halls WHERE id = ?`, id); err != nil {
         return err
     }
+    cache.CatalogChanged(ctx)
     return nil
 }
@@ -0,0 +1,354 @@
+//go:build integration
+
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/iliyamo/cinema-seat-reservation/internal/repository"
+	"github.com/iliyamo/cinema-seat-reservation/internal/testdb"
+)
+
+func TestMain(m *testing.M) { testdb.Main(m) }
+
+// hold creates holds of userID on seatIDs of show expiring in five
+// minutes and marks the seats HELD.
+func hold(t *testing.T, show *testdb.Show, userID uint64, seatIDs ...uint64) []repository.SeatHoldRecord {
+	t.Helper()
+	ctx := context.Background()
+	holds, err := repository.GenerateHoldRecords(userID, show.ID, seatIDs, time.Now().UTC().Add(5*time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = testdb.Tx(t, func(tx *sql.Tx) error {
+		if err := repository.NewSeatHoldRepo(testdb.DB).CreateMultipleTx(ctx, tx, holds); err != nil {
+			return err
+		}
+		return repository.NewShowSeatRepo(testdb.DB).BulkUpdateStatusTx(ctx, tx, show.ID, seatIDs, "HELD")
+	})
+	if err != nil {
+		t.Fatalf("hold seats: %v", err)
+	}
+	return holds
+}
+
+func TestIntegrationLockSeatsTx(t *testing.T) {
+	ctx := context.Background()
+	show := testdb.NewShow(t, 3, 1)
+	customer := show.Customers[0]
+	repo := repository.NewShowSeatRepo(testdb.DB)
+	hold(t, show, customer, show.SeatIDs[1])
+
+	var locked map[uint64]repository.LockedSeat
+	err := testdb.Tx(t, func(tx *sql.Tx) error {
+		var err error
+		locked, err = repo.LockSeatsTx(ctx, tx, show.ID, append([]uint64{999999}, show.SeatIDs...))
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(locked) != 3 {
+		t.Fatalf("locked %d seats, want 3 (the unknown seat is left out)", len(locked))
+	}
+	for i, sid := range show.SeatIDs {
+		ls := locked[sid]
+		if ls.PriceCents != 1000 {
+			t.Fatalf("seat %d price %d, want 1000", sid, ls.PriceCents)
+		}
+		if i == 1 {
+			if ls.Status != "HELD" || ls.HeldBy == nil || *ls.HeldBy != customer {
+				t.Fatalf("held seat: %+v", ls)
+			}
+			continue
+		}
+		if ls.Status != "FREE" || ls.HeldBy != nil {
+			t.Fatalf("free seat: %+v", ls)
+		}
+	}
+}
+
+func TestIntegrationLockSeatsTxBlocksOtherTransactions(t *testing.T) {
+	ctx := context.Background()
+	show := testdb.NewShow(t, 2, 0)
+	repo := repository.NewShowSeatRepo(testdb.DB)
+
+	first, err := testdb.DB.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer first.Rollback()
+	if _, err := repo.LockSeatsTx(ctx, first, show.ID, show.SeatIDs); err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error, 1)
+	go func() {
+		tx, err := testdb.DB.BeginTx(ctx, nil)
+		if err != nil {
+			done <- err
+			return
+		}
+		defer tx.Rollback()
+		_, err = repo.LockSeatsTx(ctx, tx, show.ID, show.SeatIDs[1:])
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		t.Fatalf("second transaction locked the seats while the first held them (err %v)", err)
+	case <-time.After(300 * time.Millisecond):
+	}
+	if err := first.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("second transaction after the first committed: %v", err)
+	}
+}
+
+func TestIntegrationShowSeatStatus(t *testing.T) {
+	ctx := context.Background()
+	show := testdb.NewShow(t, 2, 1)
+	customer := show.Customers[0]
+	repo := repository.NewShowSeatRepo(testdb.DB)
+	hold(t, show, customer, show.SeatIDs[0])
+
+	seats, err := repo.ListWithStatus(ctx, show.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	status := make(map[uint64]repository.SeatWithStatus, len(seats))
+	for _, s := range seats {
+		status[s.SeatID] = s
+	}
+	if s := status[show.SeatIDs[0]]; s.Status != "HELD" || s.HeldBy == nil || *s.HeldBy != customer {
+		t.Fatalf("held seat: %+v", s)
+	}
+	if s := status[show.SeatIDs[1]]; s.Status != "FREE" {
+		t.Fatalf("free seat: %+v", s)
+	}
+
+	err = testdb.Tx(t, func(tx *sql.Tx) error {
+		return repo.BulkUpdateStatusTx(ctx, tx, show.ID, show.SeatIDs, "RESERVED")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = testdb.Tx(t, func(tx *sql.Tx) error {
+		prices, err := repo.GetPricesBySeatIDsTx(ctx, tx, show.ID, show.SeatIDs)
+		if err == nil && (len(prices) != 2 || prices[show.SeatIDs[0]] != 1000) {
+			t.Fatalf("prices %v", prices)
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var reserved int
+	if err := testdb.DB.QueryRow(`SELECT COUNT(*) FROM show_seats WHERE show_id = ? AND status = 'RESERVED'`, show.ID).Scan(&reserved); err != nil {
+		t.Fatal(err)
+	}
+	if reserved != 2 {
+		t.Fatalf("%d seats RESERVED, want 2", reserved)
+	}
+}
+
+func TestIntegrationSeatHolds(t *testing.T) {
+	ctx := context.Background()
+	show := testdb.NewShow(t, 3, 2)
+	customer, other := show.Customers[0], show.Customers[1]
+	repo := repository.NewSeatHoldRepo(testdb.DB)
+	hold(t, show, customer, show.SeatIDs[:2]...)
+	hold(t, show, other, show.SeatIDs[2])
+
+	active, err := repo.ActiveHoldsByUserAndShow(ctx, customer, show.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(active) != 2 {
+		t.Fatalf("%d active holds, want 2", len(active))
+	}
+
+	var extended bool
+	var holds []repository.SeatHoldRecord
+	err = testdb.Tx(t, func(tx *sql.Tx) error {
+		var err error
+		holds, extended, err = repo.ExtendByUserAndShowTx(ctx, tx, customer, show.ID, time.Minute, time.Hour)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !extended || len(holds) != 2 || !holds[0].ExpiresAt.After(active[0].ExpiresAt) {
+		t.Fatalf("extension: extended %v, holds %+v", extended, holds)
+	}
+
+	show.ExpireHolds(t, customer)
+	var expired []uint64
+	err = testdb.Tx(t, func(tx *sql.Tx) error {
+		var err error
+		expired, err = repo.ExpireHoldsTx(ctx, tx, show.ID)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(expired) != 2 {
+		t.Fatalf("expired seats %v, want the customer's two", expired)
+	}
+	if active, _ := repo.ActiveHoldsByUserAndShow(ctx, customer, show.ID); len(active) != 0 {
+		t.Fatalf("%d holds still active after expiry", len(active))
+	}
+	if active, _ := repo.ActiveHoldsByUserAndShow(ctx, other, show.ID); len(active) != 1 {
+		t.Fatalf("other customer's hold: %d active, want 1", len(active))
+	}
+
+	var deleted []uint64
+	err = testdb.Tx(t, func(tx *sql.Tx) error {
+		var err error
+		deleted, err = repo.DeleteByUserAndShowTx(ctx, tx, other, show.ID)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deleted) != 1 || deleted[0] != show.SeatIDs[2] {
+		t.Fatalf("deleted holds on seats %v, want [%d]", deleted, show.SeatIDs[2])
+	}
+}
+
+// reserve books seatIDs of show for userID as a reservation with the
+// given status and total.
+func reserve(t *testing.T, show *testdb.Show, userID uint64, status string, total uint32, seatIDs ...uint64) uint64 {
+	t.Helper()
+	ctx := context.Background()
+	repo := repository.NewReservationRepo(testdb.DB)
+	rec := &repository.ReservationRecord{UserID: userID, ShowID: show.ID, Status: status, SubtotalCents: total, TotalAmountCents: total, Currency: show.Currency}
+	err := testdb.Tx(t, func(tx *sql.Tx) error {
+		if err := repo.CreateTx(ctx, tx, rec); err != nil {
+			return err
+		}
+		seats := make([]repository.ReservationSeatRecord, 0, len(seatIDs))
+		for _, sid := range seatIDs {
+			seats = append(seats, repository.ReservationSeatRecord{ReservationID: rec.ID, ShowID: show.ID, SeatID: sid, PriceCents: total / uint32(len(seatIDs))})
+		}
+		if err := repo.CreateSeatsBulkTx(ctx, tx, seats); err != nil {
+			return err
+		}
+		return repository.NewShowSeatRepo(testdb.DB).BulkUpdateStatusTx(ctx, tx, show.ID, seatIDs, "RESERVED")
+	})
+	if err != nil {
+		t.Fatalf("reserve seats: %v", err)
+	}
+	return rec.ID
+}
+
+// reservationState returns the status of a reservation, "" once it is
+// deleted, and the number of its reservation_seats rows.
+func reservationState(t *testing.T, id uint64) (string, int) {
+	t.Helper()
+	var status string
+	var seats int
+	err := testdb.DB.QueryRow(`SELECT status FROM reservations WHERE id = ?`, id).Scan(&status)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		t.Fatal(err)
+	}
+	if err := testdb.DB.QueryRow(`SELECT COUNT(*) FROM reservation_seats WHERE reservation_id = ?`, id).Scan(&seats); err != nil {
+		t.Fatal(err)
+	}
+	return status, seats
+}
+
+func cancel(t *testing.T, id uint64) error {
+	t.Helper()
+	return testdb.Tx(t, func(tx *sql.Tx) error {
+		return repository.NewReservationRepo(testdb.DB).CancelTx(context.Background(), tx, id)
+	})
+}
+
+func TestIntegrationCancelTxKeepsPaidReservations(t *testing.T) {
+	show := testdb.NewShow(t, 4, 1)
+	customer := show.Customers[0]
+
+	paid := reserve(t, show, customer, "CONFIRMED", 2000, show.SeatIDs[:2]...)
+	err := testdb.Tx(t, func(tx *sql.Tx) error {
+		return repository.NewReservationRepo(testdb.DB).SetPaymentRefTx(context.Background(), tx, paid, "pay_1")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cancel(t, paid); !errors.Is(err, repository.ErrReservationPaid) {
+		t.Fatalf("paid reservation: err = %v, want ErrReservationPaid", err)
+	}
+	if status, seats := reservationState(t, paid); status != "CONFIRMED" || seats != 2 {
+		t.Fatalf("paid reservation after cancel: status %q, %d seats", status, seats)
+	}
+
+	checkout := reserve(t, show, customer, "CONFIRMED", 1000, show.SeatIDs[2])
+	_, err = testdb.DB.Exec(`INSERT INTO booking_sagas (user_id, show_id, reservation_id, state, amount_cents, deadline_at)
+		VALUES (?, ?, ?, 'CONFIRMED', 1000, UTC_TIMESTAMP())`, customer, show.ID, checkout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cancel(t, checkout); !errors.Is(err, repository.ErrReservationPaid) {
+		t.Fatalf("reservation paid at checkout: err = %v, want ErrReservationPaid", err)
+	}
+
+	// /confirm takes no payment: a total alone does not make it paid
+	confirmed := reserve(t, show, customer, "CONFIRMED", 1000, show.SeatIDs[3])
+	if err := cancel(t, confirmed); err != nil {
+		t.Fatalf("unpaid confirmed reservation: %v", err)
+	}
+	if status, seats := reservationState(t, confirmed); status != "CANCELLED" || seats != 0 {
+		t.Fatalf("unpaid confirmed reservation after cancel: status %q, %d seats", status, seats)
+	}
+}
+
+func TestIntegrationCancelTx(t *testing.T) {
+	ctx := context.Background()
+	show := testdb.NewShow(t, 2, 2)
+	customer, other := show.Customers[0], show.Customers[1]
+	repo := repository.NewReservationRepo(testdb.DB)
+
+	free := reserve(t, show, customer, "CONFIRMED", 0, show.SeatIDs[0])
+	err := testdb.Tx(t, func(tx *sql.Tx) error {
+		_, _, seats, err := repo.GetInfoForUserTx(ctx, tx, free, customer)
+		if err == nil && len(seats) != 1 {
+			t.Fatalf("reservation seats %v", seats)
+		}
+		if _, _, _, err := repo.GetInfoForUserTx(ctx, tx, free, other); !errors.Is(err, repository.ErrForbidden) {
+			t.Fatalf("other customer: err = %v, want ErrForbidden", err)
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cancel(t, free); err != nil {
+		t.Fatal(err)
+	}
+	if status, seats := reservationState(t, free); status != "CANCELLED" || seats != 0 {
+		t.Fatalf("free reservation after cancel: status %q, %d seats", status, seats)
+	}
+	if err := cancel(t, free); !errors.Is(err, repository.ErrReservationCancelled) {
+		t.Fatalf("second cancel: err = %v, want ErrReservationCancelled", err)
+	}
+
+	pending := reserve(t, show, customer, "PENDING", 1000, show.SeatIDs[1])
+	if err := cancel(t, pending); err != nil {
+		t.Fatal(err)
+	}
+	if status, seats := reservationState(t, pending); status != "" || seats != 0 {
+		t.Fatalf("pending reservation after cancel: status %q, %d seats", status, seats)
+	}
+	err = testdb.Tx(t, func(tx *sql.Tx) error {
+		_, _, _, err := repo.GetInfoForUserTx(ctx, tx, pending, customer)
+		return err
+	})
+	if !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("deleted reservation: err = %v, want sql.ErrNoRows", err)
+	}
+}
@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// Login throttle scopes.
+const (
+	ThrottleAccount = "ACCOUNT"
+	ThrottleIP      = "IP"
+)
+
+// LoginThrottle is the failed-login state of one account or client address.
+type LoginThrottle struct {
+	Scope       string
+	Subject     string
+	Failures    uint32
+	Lockouts    uint32
+	LockedUntil *time.Time
+}
+
+// LoginThrottleRepo persists failed login counters and lockouts.
+type LoginThrottleRepo struct {
+	db *sql.DB
+}
+
+// NewLoginThrottleRepo returns a new LoginThrottleRepo bound to the given database.
+func NewLoginThrottleRepo(db *sql.DB) *LoginThrottleRepo { return &LoginThrottleRepo{db: db} }
+
+// Get returns the state for scope/subject, or nil when there is none.
+func (r *LoginThrottleRepo) Get(ctx context.Context, scope, subject string) (*LoginThrottle, error) {
+	t := LoginThrottle{Scope: scope, Subject: subject}
+	var until sql.NullTime
+	err := r.db.QueryRowContext(ctx,
+		`SELECT failures, lockouts, locked_until FROM login_throttles WHERE scope = ? AND subject = ?`,
+		scope, subject).Scan(&t.Failures, &t.Lockouts, &until)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if until.Valid {
+		u := until.Time
+		t.LockedUntil = &u
+	}
+	return &t, nil
+}
+
+// RecordFailure counts a failed login and returns the updated state.  The
+// failure count restarts when the previous failure is older than window,
+// and the lockout count (which escalates lockout durations) restarts when
+// it is older than decay.
+func (r *LoginThrottleRepo) RecordFailure(ctx context.Context, scope, subject string, window, decay time.Duration) (*LoginThrottle, error) {
+	// MySQL applies ON DUPLICATE KEY assignments left to right, so the
+	// counters must be computed before last_failure_at is overwritten.
+	_, err := r.db.ExecContext(ctx, `
+        INSERT INTO login_throttles (scope, subject, failures, last_failure_at)
+        VALUES (?, ?, 1, UTC_TIMESTAMP())
+        ON DUPLICATE KEY UPDATE
+            lockouts = IF(last_failure_at < UTC_TIMESTAMP() - INTERVAL ? SECOND, 0, lockouts),
+            failures = IF(last_failure_at < UTC_TIMESTAMP() - INTERVAL ? SECOND, 1, failures + 1),
+            last_failure_at = UTC_TIMESTAMP()`,
+		scope, subject, int64(decay/time.Second), int64(window/time.Second))
+	if err != nil {
+		return nil, err
+	}
+	return r.Get(ctx, scope, subject)
+}
+
+// Lock locks scope/subject until the given time if it still has at least
+// threshold failures, resetting the failure count and incrementing the
+// lockout count.  The condition makes concurrent failures lock only once;
+// locked reports whether this call applied the lock.
+func (r *LoginThrottleRepo) Lock(ctx context.Context, scope, subject string, threshold uint32, until time.Time) (locked bool, err error) {
+	res, err := r.db.ExecContext(ctx, `
+        UPDATE login_throttles
+        SET locked_until = ?, lockouts = lockouts + 1, failures = 0
+        WHERE scope = ? AND subject = ? AND failures >= ?`,
+		until.UTC(), scope, subject, threshold)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// Clear removes the state for scope/subject after a successful login.
+func (r *LoginThrottleRepo) Clear(ctx context.Context, scope, subject string) error {
+	_, err := r.db.ExecContext(ctx,
+		`DELETE FROM login_throttles WHERE scope = ? AND subject = ?`, scope, subject)
+	return err
+}
@@ -0,0 +1,79 @@
+package mock
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+)
+
+// Driver is a test double for the database behind a *sql.DB.  Transactions
+// always begin, commit and roll back; statements are answered by QueryFn
+// and ExecFn, which receive the SQL text and its arguments.  A nil QueryFn
+// returns no rows and a nil ExecFn affects no rows, so a Driver with no
+// functions set serves services that only reach the database through
+// other doubles.
+type Driver struct {
+	QueryFn func(query string, args []driver.NamedValue) (columns []string, rows [][]driver.Value, err error)
+	ExecFn  func(query string, args []driver.NamedValue) (driver.Result, error)
+}
+
+// DB returns a *sql.DB backed by d.
+func (d *Driver) DB() *sql.DB { return sql.OpenDB(d.Connector()) }
+
+// Connector returns a connector to d, for wrapping before sql.OpenDB.
+func (d *Driver) Connector() driver.Connector { return connector{d} }
+
+type connector struct{ d *Driver }
+
+func (c connector) Connect(context.Context) (driver.Conn, error) { return conn{c.d}, nil }
+func (c connector) Driver() driver.Driver                        { return c }
+func (c connector) Open(string) (driver.Conn, error)             { return conn{c.d}, nil }
+
+type conn struct{ d *Driver }
+
+func (c conn) Prepare(string) (driver.Stmt, error) { return nil, driver.ErrSkip }
+func (c conn) Close() error                        { return nil }
+func (c conn) Begin() (driver.Tx, error)           { return tx{}, nil }
+
+func (c conn) BeginTx(context.Context, driver.TxOptions) (driver.Tx, error) { return tx{}, nil }
+
+func (c conn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if c.d.QueryFn == nil {
+		return &rows{}, nil
+	}
+	cols, vals, err := c.d.QueryFn(query, args)
+	if err != nil {
+		return nil, err
+	}
+	return &rows{cols: cols, vals: vals}, nil
+}
+
+func (c conn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if c.d.ExecFn == nil {
+		return driver.RowsAffected(0), nil
+	}
+	return c.d.ExecFn(query, args)
+}
+
+type tx struct{}
+
+func (tx) Commit() error   { return nil }
+func (tx) Rollback() error { return nil }
+
+type rows struct {
+	cols []string
+	vals [][]driver.Value
+}
+
+func (r *rows) Columns() []string { return r.cols }
+func (r *rows) Close() error      { return nil }
+
+func (r *rows) Next(dest []driver.Value) error {
+	if len(r.vals) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.vals[0])
+	r.vals = r.vals[1:]
+	return nil
+}
@@ -0,0 +1,300 @@
+// Package mock provides hand-written test doubles for the store
+// interfaces of package repository.  Each double has one function field
+// per method; a method whose field is nil returns zero values, or the
+// not-found error of the SQL repository for single-row lookups, so tests
+// only set the behaviour they care about.  The doubles record nothing
+// themselves; tests capture arguments in their closures.
+package mock
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/iliyamo/cinema-seat-reservation/internal/repository"
+)
+
+// ShowStore is a test double for repository.ShowStore.  Conn is returned
+// by DB.
+type ShowStore struct {
+	Conn               *sql.DB
+	GetByIDFn          func(ctx context.Context, id uint64) (*repository.Show, error)
+	StatusForShareTxFn func(ctx context.Context, tx *sql.Tx, id uint64) (string, error)
+	StatusTxFn         func(ctx context.Context, tx *sql.Tx, id uint64) (string, error)
+	CancelCutoffTxFn   func(ctx context.Context, tx *sql.Tx, id uint64) (time.Duration, error)
+	AgeRestrictionTxFn func(ctx context.Context, tx *sql.Tx, id uint64) (uint8, time.Time, error)
+	CurrencyTxFn       func(ctx context.Context, tx *sql.Tx, id uint64) (string, error)
+	FeesTxFn           func(ctx context.Context, tx *sql.Tx, id uint64) (*repository.CinemaFees, error)
+}
+
+func (m *ShowStore) DB() *sql.DB { return m.Conn }
+
+func (m *ShowStore) GetByID(ctx context.Context, id uint64) (*repository.Show, error) {
+	if m.GetByIDFn == nil {
+		return nil, repository.ErrShowNotFound
+	}
+	return m.GetByIDFn(ctx, id)
+}
+
+func (m *ShowStore) StatusForShareTx(ctx context.Context, tx *sql.Tx, id uint64) (string, error) {
+	if m.StatusForShareTxFn == nil {
+		return "", nil
+	}
+	return m.StatusForShareTxFn(ctx, tx, id)
+}
+
+func (m *ShowStore) StatusTx(ctx context.Context, tx *sql.Tx, id uint64) (string, error) {
+	if m.StatusTxFn == nil {
+		return "", nil
+	}
+	return m.StatusTxFn(ctx, tx, id)
+}
+
+func (m *ShowStore) CancelCutoffTx(ctx context.Context, tx *sql.Tx, id uint64) (time.Duration, error) {
+	if m.CancelCutoffTxFn == nil {
+		return 0, nil
+	}
+	return m.CancelCutoffTxFn(ctx, tx, id)
+}
+
+func (m *ShowStore) AgeRestrictionTx(ctx context.Context, tx *sql.Tx, id uint64) (uint8, time.Time, error) {
+	if m.AgeRestrictionTxFn == nil {
+		return 0, time.Time{}, nil
+	}
+	return m.AgeRestrictionTxFn(ctx, tx, id)
+}
+
+func (m *ShowStore) CurrencyTx(ctx context.Context, tx *sql.Tx, id uint64) (string, error) {
+	if m.CurrencyTxFn == nil {
+		return "", nil
+	}
+	return m.CurrencyTxFn(ctx, tx, id)
+}
+
+func (m *ShowStore) FeesTx(ctx context.Context, tx *sql.Tx, id uint64) (*repository.CinemaFees, error) {
+	if m.FeesTxFn == nil {
+		return &repository.CinemaFees{}, nil
+	}
+	return m.FeesTxFn(ctx, tx, id)
+}
+
+// SeatStore is a test double for repository.SeatStore.
+type SeatStore struct {
+	GetByHallFn func(ctx context.Context, hallID uint64) ([]repository.Seat, error)
+}
+
+func (m *SeatStore) GetByHall(ctx context.Context, hallID uint64) ([]repository.Seat, error) {
+	if m.GetByHallFn == nil {
+		return nil, nil
+	}
+	return m.GetByHallFn(ctx, hallID)
+}
+
+// HallStore is a test double for repository.HallStore.
+type HallStore struct {
+	GetByIDFn func(ctx context.Context, id uint64) (*repository.Hall, error)
+}
+
+func (m *HallStore) GetByID(ctx context.Context, id uint64) (*repository.Hall, error) {
+	if m.GetByIDFn == nil {
+		return nil, repository.ErrHallNotFound
+	}
+	return m.GetByIDFn(ctx, id)
+}
+
+// ShowSeatStore is a test double for repository.ShowSeatStore.
+type ShowSeatStore struct {
+	ListWithStatusFn       func(ctx context.Context, showID uint64) ([]repository.SeatWithStatus, error)
+	LockSeatsTxFn          func(ctx context.Context, tx *sql.Tx, showID uint64, seatIDs []uint64) (map[uint64]repository.LockedSeat, error)
+	SeatsTxFn              func(ctx context.Context, tx *sql.Tx, showID uint64, seatIDs []uint64) (map[uint64]repository.LockedSeat, error)
+	BulkUpdateStatusTxFn   func(ctx context.Context, tx *sql.Tx, showID uint64, seatIDs []uint64, status string) error
+	GetPricesBySeatIDsTxFn func(ctx context.Context, tx *sql.Tx, showID uint64, seatIDs []uint64) (map[uint64]uint32, error)
+}
+
+func (m *ShowSeatStore) ListWithStatus(ctx context.Context, showID uint64) ([]repository.SeatWithStatus, error) {
+	if m.ListWithStatusFn == nil {
+		return nil, nil
+	}
+	return m.ListWithStatusFn(ctx, showID)
+}
+
+func (m *ShowSeatStore) LockSeatsTx(ctx context.Context, tx *sql.Tx, showID uint64, seatIDs []uint64) (map[uint64]repository.LockedSeat, error) {
+	if m.LockSeatsTxFn == nil {
+		return nil, nil
+	}
+	return m.LockSeatsTxFn(ctx, tx, showID, seatIDs)
+}
+
+func (m *ShowSeatStore) SeatsTx(ctx context.Context, tx *sql.Tx, showID uint64, seatIDs []uint64) (map[uint64]repository.LockedSeat, error) {
+	if m.SeatsTxFn == nil {
+		return nil, nil
+	}
+	return m.SeatsTxFn(ctx, tx, showID, seatIDs)
+}
+
+func (m *ShowSeatStore) BulkUpdateStatusTx(ctx context.Context, tx *sql.Tx, showID uint64, seatIDs []uint64, status string) error {
+	if m.BulkUpdateStatusTxFn == nil {
+		return nil
+	}
+	return m.BulkUpdateStatusTxFn(ctx, tx, showID, seatIDs, status)
+}
+
+func (m *ShowSeatStore) GetPricesBySeatIDsTx(ctx context.Context, tx *sql.Tx, showID uint64, seatIDs []uint64) (map[uint64]uint32, error) {
+	if m.GetPricesBySeatIDsTxFn == nil {
+		return nil, nil
+	}
+	return m.GetPricesBySeatIDsTxFn(ctx, tx, showID, seatIDs)
+}
+
+// SeatHoldStore is a test double for repository.SeatHoldStore.
+type SeatHoldStore struct {
+	ExpireHoldsTxFn              func(ctx context.Context, tx *sql.Tx, showID uint64) ([]uint64, error)
+	CreateMultipleTxFn           func(ctx context.Context, tx *sql.Tx, holds []repository.SeatHoldRecord) error
+	DeleteByUserAndShowTxFn      func(ctx context.Context, tx *sql.Tx, userID, showID uint64) ([]uint64, error)
+	DeleteByIDsTxFn              func(ctx context.Context, tx *sql.Tx, ids []uint64) error
+	ActiveHoldsByUserAndShowTxFn func(ctx context.Context, tx *sql.Tx, userID, showID uint64) ([]repository.SeatHoldRecord, error)
+	ActiveHoldsByUserAndShowFn   func(ctx context.Context, userID, showID uint64) ([]repository.SeatHoldRecord, error)
+	ActiveHoldsByUserFn          func(ctx context.Context, userID uint64) ([]repository.SeatHoldRecord, error)
+	LockActiveHoldsByUserTxFn    func(ctx context.Context, tx *sql.Tx, userID uint64) ([]repository.SeatHoldRecord, error)
+	ExtendByUserAndShowTxFn      func(ctx context.Context, tx *sql.Tx, userID, showID uint64, increment, maxTotal time.Duration) ([]repository.SeatHoldRecord, bool, error)
+}
+
+func (m *SeatHoldStore) ExpireHoldsTx(ctx context.Context, tx *sql.Tx, showID uint64) ([]uint64, error) {
+	if m.ExpireHoldsTxFn == nil {
+		return nil, nil
+	}
+	return m.ExpireHoldsTxFn(ctx, tx, showID)
+}
+
+func (m *SeatHoldStore) CreateMultipleTx(ctx context.Context, tx *sql.Tx, holds []repository.SeatHoldRecord) error {
+	if m.CreateMultipleTxFn == nil {
+		return nil
+	}
+	return m.CreateMultipleTxFn(ctx, tx, holds)
+}
+
+func (m *SeatHoldStore) DeleteByUserAndShowTx(ctx context.Context, tx *sql.Tx, userID, showID uint64) ([]uint64, error) {
+	if m.DeleteByUserAndShowTxFn == nil {
+		return nil, nil
+	}
+	return m.DeleteByUserAndShowTxFn(ctx, tx, userID, showID)
+}
+
+func (m *SeatHoldStore) DeleteByIDsTx(ctx context.Context, tx *sql.Tx, ids []uint64) error {
+	if m.DeleteByIDsTxFn == nil {
+		return nil
+	}
+	return m.DeleteByIDsTxFn(ctx, tx, ids)
+}
+
+func (m *SeatHoldStore) ActiveHoldsByUserAndShowTx(ctx context.Context, tx *sql.Tx, userID, showID uint64) ([]repository.SeatHoldRecord, error) {
+	if m.ActiveHoldsByUserAndShowTxFn == nil {
+		return nil, nil
+	}
+	return m.ActiveHoldsByUserAndShowTxFn(ctx, tx, userID, showID)
+}
+
+func (m *SeatHoldStore) ActiveHoldsByUserAndShow(ctx context.Context, userID, showID uint64) ([]repository.SeatHoldRecord, error) {
+	if m.ActiveHoldsByUserAndShowFn == nil {
+		return nil, nil
+	}
+	return m.ActiveHoldsByUserAndShowFn(ctx, userID, showID)
+}
+
+func (m *SeatHoldStore) ActiveHoldsByUser(ctx context.Context, userID uint64) ([]repository.SeatHoldRecord, error) {
+	if m.ActiveHoldsByUserFn == nil {
+		return nil, nil
+	}
+	return m.ActiveHoldsByUserFn(ctx, userID)
+}
+
+func (m *SeatHoldStore) LockActiveHoldsByUserTx(ctx context.Context, tx *sql.Tx, userID uint64) ([]repository.SeatHoldRecord, error) {
+	if m.LockActiveHoldsByUserTxFn == nil {
+		return nil, nil
+	}
+	return m.LockActiveHoldsByUserTxFn(ctx, tx, userID)
+}
+
+func (m *SeatHoldStore) ExtendByUserAndShowTx(ctx context.Context, tx *sql.Tx, userID, showID uint64, increment, maxTotal time.Duration) ([]repository.SeatHoldRecord, bool, error) {
+	if m.ExtendByUserAndShowTxFn == nil {
+		return nil, false, nil
+	}
+	return m.ExtendByUserAndShowTxFn(ctx, tx, userID, showID, increment, maxTotal)
+}
+
+// ReservationStore is a test double for repository.ReservationStore.
+type ReservationStore struct {
+	CreateTxFn          func(ctx context.Context, tx *sql.Tx, res *repository.ReservationRecord) error
+	CreateSeatsBulkTxFn func(ctx context.Context, tx *sql.Tx, seats []repository.ReservationSeatRecord) error
+	GetByIDForUserFn    func(ctx context.Context, reservationID, userID uint64) (*repository.ReservationDetail, error)
+	IsChargedFn         func(ctx context.Context, reservationID uint64) (bool, error)
+	GetInfoForUserTxFn  func(ctx context.Context, tx *sql.Tx, reservationID, userID uint64) (uint64, time.Time, []uint64, error)
+	ListByUserFn        func(ctx context.Context, userID uint64) ([]repository.ReservationDetail, error)
+	SetPaymentRefTxFn   func(ctx context.Context, tx *sql.Tx, reservationID uint64, paymentRef string) error
+	CancelTxFn          func(ctx context.Context, tx *sql.Tx, reservationID uint64) error
+}
+
+func (m *ReservationStore) CreateTx(ctx context.Context, tx *sql.Tx, res *repository.ReservationRecord) error {
+	if m.CreateTxFn == nil {
+		return nil
+	}
+	return m.CreateTxFn(ctx, tx, res)
+}
+
+func (m *ReservationStore) CreateSeatsBulkTx(ctx context.Context, tx *sql.Tx, seats []repository.ReservationSeatRecord) error {
+	if m.CreateSeatsBulkTxFn == nil {
+		return nil
+	}
+	return m.CreateSeatsBulkTxFn(ctx, tx, seats)
+}
+
+func (m *ReservationStore) GetByIDForUser(ctx context.Context, reservationID, userID uint64) (*repository.ReservationDetail, error) {
+	if m.GetByIDForUserFn == nil {
+		return nil, sql.ErrNoRows
+	}
+	return m.GetByIDForUserFn(ctx, reservationID, userID)
+}
+
+func (m *ReservationStore) IsCharged(ctx context.Context, reservationID uint64) (bool, error) {
+	if m.IsChargedFn == nil {
+		return false, nil
+	}
+	return m.IsChargedFn(ctx, reservationID)
+}
+
+func (m *ReservationStore) GetInfoForUserTx(ctx context.Context, tx *sql.Tx, reservationID, userID uint64) (uint64, time.Time, []uint64, error) {
+	if m.GetInfoForUserTxFn == nil {
+		return 0, time.Time{}, nil, sql.ErrNoRows
+	}
+	return m.GetInfoForUserTxFn(ctx, tx, reservationID, userID)
+}
+
+func (m *ReservationStore) ListByUser(ctx context.Context, userID uint64) ([]repository.ReservationDetail, error) {
+	if m.ListByUserFn == nil {
+		return nil, nil
+	}
+	return m.ListByUserFn(ctx, userID)
+}
+
+func (m *ReservationStore) SetPaymentRefTx(ctx context.Context, tx *sql.Tx, reservationID uint64, paymentRef string) error {
+	if m.SetPaymentRefTxFn == nil {
+		return nil
+	}
+	return m.SetPaymentRefTxFn(ctx, tx, reservationID, paymentRef)
+}
+
+func (m *ReservationStore) CancelTx(ctx context.Context, tx *sql.Tx, reservationID uint64) error {
+	if m.CancelTxFn == nil {
+		return nil
+	}
+	return m.CancelTxFn(ctx, tx, reservationID)
+}
+
+var (
+	_ repository.ShowStore        = (*ShowStore)(nil)
+	_ repository.SeatStore        = (*SeatStore)(nil)
+	_ repository.HallStore        = (*HallStore)(nil)
+	_ repository.ShowSeatStore    = (*ShowSeatStore)(nil)
+	_ repository.SeatHoldStore    = (*SeatHoldStore)(nil)
+	_ repository.ReservationStore = (*ReservationStore)(nil)
+)
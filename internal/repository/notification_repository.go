@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// Notification states.  PENDING notifications wait for the notification
+// worker; a failed delivery is retried until the worker gives up and marks
+// the notification FAILED.
+const (
+	NotificationPending = "PENDING"
+	NotificationSent    = "SENT"
+	NotificationFailed  = "FAILED"
+)
+
+// Notification is a message queued for a user.  Kind names the event that
+// caused it, such as "show.cancelled".
+type Notification struct {
+	ID            uint64
+	UserID        uint64
+	ReservationID *uint64 // reservation the message is about, if any
+	Kind          string
+	Subject       string
+	Body          string
+	Attempts      uint32 // failed deliveries so far
+	Email         string // recipient address; only set by Due
+}
+
+// NotificationRepo persists the notifications queue.
+type NotificationRepo struct {
+	db *sql.DB
+}
+
+// NewNotificationRepo returns a new NotificationRepo bound to the given database.
+func NewNotificationRepo(db *sql.DB) *NotificationRepo { return &NotificationRepo{db: db} }
+
+// EnqueueTx queues n for immediate delivery inside tx, so the message is
+// only sent when the change it reports commits.  It sets n.ID.
+func (r *NotificationRepo) EnqueueTx(ctx context.Context, tx *sql.Tx, n *Notification) error {
+	res, err := tx.ExecContext(ctx,
+		`INSERT INTO notifications (user_id, reservation_id, kind, subject, body, next_attempt_at)
+		 VALUES (?, ?, ?, ?, ?, UTC_TIMESTAMP())`,
+		n.UserID, n.ReservationID, n.Kind, n.Subject, n.Body)
+	if err != nil {
+		return err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	n.ID = uint64(id)
+	return nil
+}
+
+// Due returns up to limit PENDING notifications whose next attempt is due,
+// oldest first, with the recipient's email address.
+func (r *NotificationRepo) Due(ctx context.Context, limit int) ([]Notification, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT n.id, n.user_id, n.reservation_id, n.kind, n.subject, n.body, n.attempts, u.email
+		 FROM notifications n
+		 JOIN users u ON u.id = n.user_id
+		 WHERE n.status = 'PENDING' AND n.next_attempt_at <= UTC_TIMESTAMP()
+		 ORDER BY n.id LIMIT ?`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Notification
+	for rows.Next() {
+		var n Notification
+		var resID sql.NullInt64
+		if err := rows.Scan(&n.ID, &n.UserID, &resID, &n.Kind, &n.Subject, &n.Body, &n.Attempts, &n.Email); err != nil {
+			return nil, err
+		}
+		if resID.Valid {
+			id := uint64(resID.Int64)
+			n.ReservationID = &id
+		}
+		out = append(out, n)
+	}
+	return out, rows.Err()
+}
+
+// MarkSent records a successful delivery.
+func (r *NotificationRepo) MarkSent(ctx context.Context, id uint64) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE notifications SET status = 'SENT', sent_at = UTC_TIMESTAMP(), last_error = NULL WHERE id = ?`, id)
+	return err
+}
+
+// MarkFailed records a failed delivery.  The notification is retried at
+// retryAt, or marked FAILED for good when retryAt is nil.
+func (r *NotificationRepo) MarkFailed(ctx context.Context, id uint64, cause string, retryAt *time.Time) error {
+	if len(cause) > 255 {
+		cause = cause[:255]
+	}
+	status := NotificationFailed
+	var next interface{}
+	if retryAt != nil {
+		status, next = NotificationPending, retryAt.UTC()
+	}
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE notifications
+		 SET status = ?, attempts = attempts + 1, last_error = ?, next_attempt_at = COALESCE(?, next_attempt_at)
+		 WHERE id = ?`, status, cause, next, id)
+	return err
+}
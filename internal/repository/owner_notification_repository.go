@@ -0,0 +1,156 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// OwnerNotificationSettings are the notification preferences of an owner.
+// DigestHour is the hour of the day, in Timezone, from which the daily
+// digest of the previous day is sent.
+type OwnerNotificationSettings struct {
+	UserID       uint64     `json:"-"`
+	DailyDigest  bool       `json:"daily_digest"`
+	DigestHour   int        `json:"digest_hour"`
+	Timezone     string     `json:"timezone"`
+	LastDigestOn *string    `json:"last_digest_on"` // YYYY-MM-DD of the last day summarised
+	UpdatedAt    *time.Time `json:"updated_at"`     // nil until the settings are first saved
+}
+
+// DefaultOwnerNotificationSettings are the settings of an owner who never
+// saved any.
+func DefaultOwnerNotificationSettings(userID uint64) *OwnerNotificationSettings {
+	return &OwnerNotificationSettings{UserID: userID, DigestHour: 8, Timezone: "UTC"}
+}
+
+// DigestShow is one show of an owner's daily digest: the reservations
+// made for it during the day that are still booked.
+type DigestShow struct {
+	ShowID       uint64
+	Title        string
+	StartsAt     time.Time
+	CinemaName   string
+	HallName     string
+	Reservations int
+	Seats        int
+	RevenueCents uint64
+}
+
+// OwnerNotificationRepo persists owner notification preferences and
+// gathers the data of their digests.
+type OwnerNotificationRepo struct {
+	db *sql.DB
+}
+
+// NewOwnerNotificationRepo returns a new OwnerNotificationRepo bound to the given database.
+func NewOwnerNotificationRepo(db *sql.DB) *OwnerNotificationRepo {
+	return &OwnerNotificationRepo{db: db}
+}
+
+// DB returns the underlying database handle.
+func (r *OwnerNotificationRepo) DB() *sql.DB { return r.db }
+
+const ownerNotificationColumns = `user_id, daily_digest, digest_hour, timezone, DATE_FORMAT(last_digest_on, '%Y-%m-%d'), updated_at`
+
+func scanOwnerNotificationSettings(row interface{ Scan(...interface{}) error }) (*OwnerNotificationSettings, error) {
+	s := &OwnerNotificationSettings{}
+	var updated time.Time
+	if err := row.Scan(&s.UserID, &s.DailyDigest, &s.DigestHour, &s.Timezone, &s.LastDigestOn, &updated); err != nil {
+		return nil, err
+	}
+	s.UpdatedAt = &updated
+	return s, nil
+}
+
+// Get returns the owner's settings, or the defaults when none were saved.
+func (r *OwnerNotificationRepo) Get(ctx context.Context, userID uint64) (*OwnerNotificationSettings, error) {
+	s, err := scanOwnerNotificationSettings(r.db.QueryRowContext(ctx,
+		`SELECT `+ownerNotificationColumns+` FROM owner_notification_settings WHERE user_id = ?`, userID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return DefaultOwnerNotificationSettings(userID), nil
+	}
+	return s, err
+}
+
+// Save stores s, creating the owner's row on first use.  last_digest_on
+// is kept.
+func (r *OwnerNotificationRepo) Save(ctx context.Context, s *OwnerNotificationSettings) error {
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO owner_notification_settings (user_id, daily_digest, digest_hour, timezone)
+		 VALUES (?, ?, ?, ?)
+		 ON DUPLICATE KEY UPDATE daily_digest = VALUES(daily_digest), digest_hour = VALUES(digest_hour),
+		                         timezone = VALUES(timezone), updated_at = CURRENT_TIMESTAMP`,
+		s.UserID, s.DailyDigest, s.DigestHour, s.Timezone)
+	return err
+}
+
+// DigestSubscribers returns the settings of every owner with the daily
+// digest enabled.
+func (r *OwnerNotificationRepo) DigestSubscribers(ctx context.Context) ([]OwnerNotificationSettings, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+ownerNotificationColumns+` FROM owner_notification_settings WHERE daily_digest = TRUE ORDER BY user_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []OwnerNotificationSettings
+	for rows.Next() {
+		s, err := scanOwnerNotificationSettings(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *s)
+	}
+	return out, rows.Err()
+}
+
+// ClaimDigestTx records inside tx that the digest of day (YYYY-MM-DD) is
+// being sent to the owner.  It reports false when that day or a later one
+// was already claimed, so a digest is sent once even with several
+// replicas running.
+func (r *OwnerNotificationRepo) ClaimDigestTx(ctx context.Context, tx *sql.Tx, userID uint64, day string) (bool, error) {
+	res, err := tx.ExecContext(ctx,
+		`UPDATE owner_notification_settings SET last_digest_on = ?
+		 WHERE user_id = ? AND daily_digest = TRUE AND (last_digest_on IS NULL OR last_digest_on < ?)`,
+		day, userID, day)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// DigestTx returns, per show of the owner's halls, the reservations
+// created in [from, to) that are still CONFIRMED or awaiting a refund,
+// ordered by show start.  Cancelled reservations are deleted and so not
+// counted.
+func (r *OwnerNotificationRepo) DigestTx(ctx context.Context, tx *sql.Tx, ownerID uint64, from, to time.Time) ([]DigestShow, error) {
+	rows, err := tx.QueryContext(ctx,
+		`SELECT show_id, title, starts_at, cinema_name, hall_name, COUNT(*), SUM(seats), SUM(total_amount_cents)
+		 FROM (SELECT r.show_id, s.title, s.starts_at, COALESCE(c.name, '') AS cinema_name, h.name AS hall_name,
+		              r.total_amount_cents,
+		              (SELECT COUNT(*) FROM reservation_seats rs WHERE rs.reservation_id = r.id) AS seats
+		       FROM reservations r
+		       JOIN shows s ON s.id = r.show_id
+		       JOIN halls h ON h.id = s.hall_id
+		       LEFT JOIN cinemas c ON c.id = h.cinema_id
+		       WHERE h.owner_id = ? AND r.created_at >= ? AND r.created_at < ?
+		         AND r.status IN ('CONFIRMED', 'REFUND_PENDING')) d
+		 GROUP BY show_id, title, starts_at, cinema_name, hall_name
+		 ORDER BY starts_at, show_id`, ownerID, from.UTC(), to.UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []DigestShow
+	for rows.Next() {
+		var d DigestShow
+		if err := rows.Scan(&d.ShowID, &d.Title, &d.StartsAt, &d.CinemaName, &d.HallName, &d.Reservations, &d.Seats, &d.RevenueCents); err != nil {
+			return nil, err
+		}
+		out = append(out, d)
+	}
+	return out, rows.Err()
+}
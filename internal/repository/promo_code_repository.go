@@ -0,0 +1,196 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// PromoCode mirrors a row of the promo_codes table.  Codes are created by
+// owners and may be redeemed by customers when confirming held seats for
+// a show in one of the owner's halls.  DiscountValue is a percentage
+// (0-100) when DiscountType is PERCENT and an amount in cents when it is
+// FIXED.  Nil pointers mean "no limit" for the validity window and usage cap.
+type PromoCode struct {
+	ID            uint64     `json:"id"`
+	OwnerID       uint64     `json:"owner_id"`
+	Code          string     `json:"code"`
+	DiscountType  string     `json:"discount_type"`
+	DiscountValue uint32     `json:"discount_value"`
+	ValidFrom     *time.Time `json:"valid_from,omitempty"`
+	ValidUntil    *time.Time `json:"valid_until,omitempty"`
+	MaxUses       *uint32    `json:"max_uses,omitempty"`
+	UsedCount     uint32     `json:"used_count"`
+	IsActive      bool       `json:"is_active"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// ErrPromoCodeNotFound is returned when a promo code does not exist or is
+// not redeemable for the requested show (belongs to another owner).
+var ErrPromoCodeNotFound = errors.New("promo code not found")
+
+// ErrPromoCodeInvalid is returned by Validate when a promo code exists but
+// is inactive, outside its validity window or has reached its usage limit.
+var ErrPromoCodeInvalid = errors.New("promo code not valid")
+
+// PromoCodeRepo provides persistence for promo codes.
+type PromoCodeRepo struct {
+	db *sql.DB
+}
+
+// NewPromoCodeRepo returns a new PromoCodeRepo bound to the given database.
+func NewPromoCodeRepo(db *sql.DB) *PromoCodeRepo { return &PromoCodeRepo{db: db} }
+
+const promoCodeColumns = `id, owner_id, code, discount_type, discount_value, valid_from, valid_until, max_uses, used_count, is_active, created_at`
+
+// scanPromoCode reads a promo_codes row selected with promoCodeColumns.
+func scanPromoCode(row interface{ Scan(...interface{}) error }) (*PromoCode, error) {
+	var p PromoCode
+	var from, until sql.NullTime
+	var maxUses sql.NullInt64
+	if err := row.Scan(&p.ID, &p.OwnerID, &p.Code, &p.DiscountType, &p.DiscountValue,
+		&from, &until, &maxUses, &p.UsedCount, &p.IsActive, &p.CreatedAt); err != nil {
+		return nil, err
+	}
+	if from.Valid {
+		t := from.Time.UTC()
+		p.ValidFrom = &t
+	}
+	if until.Valid {
+		t := until.Time.UTC()
+		p.ValidUntil = &t
+	}
+	if maxUses.Valid {
+		m := uint32(maxUses.Int64)
+		p.MaxUses = &m
+	}
+	return &p, nil
+}
+
+// nullableUTC converts an optional time into a value suitable for a
+// DATETIME column, formatting it in UTC.
+func nullableUTC(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+	return t.UTC().Format("2006-01-02 15:04:05")
+}
+
+// Create inserts a new promo code and populates its ID and CreatedAt.
+// Duplicate codes surface as MySQL error 1062 to the caller.
+func (r *PromoCodeRepo) Create(ctx context.Context, p *PromoCode) error {
+	const q = `INSERT INTO promo_codes (owner_id, code, discount_type, discount_value, valid_from, valid_until, max_uses, is_active)
+               VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
+	var maxUses interface{}
+	if p.MaxUses != nil {
+		maxUses = *p.MaxUses
+	}
+	res, err := r.db.ExecContext(ctx, q, p.OwnerID, p.Code, p.DiscountType, p.DiscountValue,
+		nullableUTC(p.ValidFrom), nullableUTC(p.ValidUntil), maxUses, p.IsActive)
+	if err != nil {
+		return err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	p.ID = uint64(id)
+	return r.db.QueryRowContext(ctx, `SELECT created_at FROM promo_codes WHERE id = ?`, p.ID).Scan(&p.CreatedAt)
+}
+
+// ListByOwner returns all promo codes created by the owner, newest first.
+func (r *PromoCodeRepo) ListByOwner(ctx context.Context, ownerID uint64) ([]PromoCode, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+promoCodeColumns+` FROM promo_codes WHERE owner_id = ? ORDER BY id DESC`, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := make([]PromoCode, 0)
+	for rows.Next() {
+		p, err := scanPromoCode(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *p)
+	}
+	return out, rows.Err()
+}
+
+// GetForShowForUpdateTx loads the promo code with the given code and locks
+// its row for the remainder of the transaction so that concurrent
+// confirmations cannot exceed max_uses.  The code must belong to the
+// owner of the hall in which the show is scheduled; otherwise
+// ErrPromoCodeNotFound is returned.
+func (r *PromoCodeRepo) GetForShowForUpdateTx(ctx context.Context, tx *sql.Tx, code string, showID uint64) (*PromoCode, error) {
+	return getPromoForShowTx(ctx, tx, code, showID, true)
+}
+
+// GetForShowTx is GetForShowForUpdateTx without the lock, for read-only
+// transactions.
+func (r *PromoCodeRepo) GetForShowTx(ctx context.Context, tx *sql.Tx, code string, showID uint64) (*PromoCode, error) {
+	return getPromoForShowTx(ctx, tx, code, showID, false)
+}
+
+func getPromoForShowTx(ctx context.Context, tx *sql.Tx, code string, showID uint64, lock bool) (*PromoCode, error) {
+	q := `SELECT p.id, p.owner_id, p.code, p.discount_type, p.discount_value, p.valid_from, p.valid_until,
+                      p.max_uses, p.used_count, p.is_active, p.created_at
+               FROM promo_codes p
+               JOIN halls h ON h.owner_id = p.owner_id
+               JOIN shows s ON s.hall_id = h.id
+               WHERE p.code = ? AND s.id = ?`
+	if lock {
+		q += ` FOR UPDATE`
+	}
+	p, err := scanPromoCode(tx.QueryRowContext(ctx, q, code, showID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrPromoCodeNotFound
+		}
+		return nil, err
+	}
+	return p, nil
+}
+
+// IncrementUsageTx records a redemption of the promo code within the
+// caller's transaction.
+func (r *PromoCodeRepo) IncrementUsageTx(ctx context.Context, tx *sql.Tx, id uint64) error {
+	_, err := tx.ExecContext(ctx, `UPDATE promo_codes SET used_count = used_count + 1 WHERE id = ?`, id)
+	return err
+}
+
+// Validate reports ErrPromoCodeInvalid when the code cannot be redeemed at
+// the given time: it is inactive, outside its validity window or has
+// reached max_uses.
+func (p *PromoCode) Validate(now time.Time) error {
+	if !p.IsActive {
+		return ErrPromoCodeInvalid
+	}
+	if p.ValidFrom != nil && now.Before(*p.ValidFrom) {
+		return ErrPromoCodeInvalid
+	}
+	if p.ValidUntil != nil && !now.Before(*p.ValidUntil) {
+		return ErrPromoCodeInvalid
+	}
+	if p.MaxUses != nil && p.UsedCount >= *p.MaxUses {
+		return ErrPromoCodeInvalid
+	}
+	return nil
+}
+
+// DiscountFor computes the discount in cents for the given total.  The
+// discount never exceeds the total so the charged amount cannot go negative.
+func (p *PromoCode) DiscountFor(total uint32) uint32 {
+	var d uint64
+	switch p.DiscountType {
+	case "PERCENT":
+		d = uint64(total) * uint64(p.DiscountValue) / 100
+	case "FIXED":
+		d = uint64(p.DiscountValue)
+	}
+	if d > uint64(total) {
+		d = uint64(total)
+	}
+	return uint32(d)
+}
@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// Usage periods stored in account_usage.period.
+const (
+	QuotaPeriodDay   = "DAY"
+	QuotaPeriodMonth = "MONTH"
+)
+
+// AccountQuota limits the number of authenticated requests an account may
+// make per UTC day and calendar month.  A nil limit means unlimited.
+type AccountQuota struct {
+	UserID       uint64    `json:"user_id"`
+	DailyLimit   *uint32   `json:"daily_limit"`
+	MonthlyLimit *uint32   `json:"monthly_limit"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// QuotaRepo provides persistence for account quotas and usage counters.
+type QuotaRepo struct {
+	db *sql.DB
+}
+
+// NewQuotaRepo returns a new QuotaRepo bound to the given database.
+func NewQuotaRepo(db *sql.DB) *QuotaRepo { return &QuotaRepo{db: db} }
+
+// Get returns the quota configured for userID, or nil when the account
+// has no quota row (and is therefore unlimited).
+func (r *QuotaRepo) Get(ctx context.Context, userID uint64) (*AccountQuota, error) {
+	var q AccountQuota
+	var daily, monthly sql.NullInt64
+	err := r.db.QueryRowContext(ctx,
+		`SELECT user_id, daily_limit, monthly_limit, updated_at FROM account_quotas WHERE user_id = ?`, userID,
+	).Scan(&q.UserID, &daily, &monthly, &q.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	q.DailyLimit = nullUint32(daily)
+	q.MonthlyLimit = nullUint32(monthly)
+	return &q, nil
+}
+
+// Upsert creates or replaces the quota for q.UserID.
+func (r *QuotaRepo) Upsert(ctx context.Context, q *AccountQuota) error {
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO account_quotas (user_id, daily_limit, monthly_limit) VALUES (?, ?, ?)
+         ON DUPLICATE KEY UPDATE daily_limit = VALUES(daily_limit), monthly_limit = VALUES(monthly_limit)`,
+		q.UserID, q.DailyLimit, q.MonthlyLimit)
+	return err
+}
+
+// Delete removes the quota for userID, making the account unlimited.
+// Recorded usage is kept.  It reports whether a quota existed.
+func (r *QuotaRepo) Delete(ctx context.Context, userID uint64) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM account_quotas WHERE user_id = ?`, userID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// Usage returns the persisted request count of userID for the period
+// starting at periodStart.  Missing rows count as zero.
+func (r *QuotaRepo) Usage(ctx context.Context, userID uint64, period string, periodStart time.Time) (uint64, error) {
+	var n uint64
+	err := r.db.QueryRowContext(ctx,
+		`SELECT request_count FROM account_usage WHERE user_id = ? AND period = ? AND period_start = ?`,
+		userID, period, periodStart.Format("2006-01-02"),
+	).Scan(&n)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	return n, err
+}
+
+// AddUsage adds delta to the persisted request count of userID for the
+// period starting at periodStart, creating the row when needed.
+func (r *QuotaRepo) AddUsage(ctx context.Context, userID uint64, period string, periodStart time.Time, delta uint64) error {
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO account_usage (user_id, period, period_start, request_count) VALUES (?, ?, ?, ?)
+         ON DUPLICATE KEY UPDATE request_count = request_count + VALUES(request_count)`,
+		userID, period, periodStart.Format("2006-01-02"), delta)
+	return err
+}
+
+// nullUint32 converts a nullable integer column to *uint32.
+func nullUint32(v sql.NullInt64) *uint32 {
+	if !v.Valid {
+		return nil
+	}
+	n := uint32(v.Int64)
+	return &n
+}
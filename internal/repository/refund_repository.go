@@ -0,0 +1,247 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+)
+
+// Refund states.  A customer's request is REQUESTED until the owner
+// APPROVES or REJECTS it; approved refunds become PROCESSED once the money
+// was returned, through the payment gateway or by the owner.
+const (
+	RefundRequested = "REQUESTED"
+	RefundApproved  = "APPROVED"
+	RefundProcessed = "PROCESSED"
+	RefundRejected  = "REJECTED"
+)
+
+// Refund is the refund of one reservation.  AmountCents is the
+// reservation's total; Note is the owner's comment on the decision and
+// PaymentRef the reference of the returned payment, when known.
+type Refund struct {
+	ID            uint64     `json:"id"`
+	ReservationID uint64     `json:"reservation_id"`
+	UserID        uint64     `json:"user_id"`
+	ShowID        uint64     `json:"show_id"`
+	AmountCents   uint32     `json:"amount_cents"`
+	Status        string     `json:"status"`
+	Reason        *string    `json:"reason"`
+	Note          *string    `json:"note"`
+	DecidedBy     *uint64    `json:"decided_by"`
+	PaymentRef    *string    `json:"payment_ref"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+	ProcessedAt   *time.Time `json:"processed_at"`
+	OwnerID       uint64     `json:"-"` // owner of the show's hall; only set by GetForOwner
+}
+
+var (
+	// ErrRefundNotFound is returned when a refund does not exist.
+	ErrRefundNotFound = errors.New("refund not found")
+	// ErrRefundExists is returned by CreateTx when the reservation already
+	// has a refund.
+	ErrRefundExists = errors.New("refund already exists")
+	// ErrRefundStateChanged is returned by TransitionTx when the refund is
+	// no longer in the expected state.
+	ErrRefundStateChanged = errors.New("refund state changed")
+)
+
+// RefundRepo persists refunds.
+type RefundRepo struct {
+	db *sql.DB
+}
+
+// NewRefundRepo returns a new RefundRepo bound to the given database.
+func NewRefundRepo(db *sql.DB) *RefundRepo { return &RefundRepo{db: db} }
+
+// DB exposes the underlying sql.DB for transactions spanning repositories.
+func (r *RefundRepo) DB() *sql.DB { return r.db }
+
+const refundColumns = `rf.id, rf.reservation_id, rf.user_id, r.show_id, rf.amount_cents, rf.status, rf.reason,
+    rf.note, rf.decided_by, rf.payment_ref, rf.created_at, rf.updated_at, rf.processed_at`
+
+func scanRefund(row interface{ Scan(...interface{}) error }, extra ...interface{}) (*Refund, error) {
+	var f Refund
+	var reason, note, ref sql.NullString
+	var decidedBy sql.NullInt64
+	var processedAt sql.NullTime
+	dest := []interface{}{&f.ID, &f.ReservationID, &f.UserID, &f.ShowID, &f.AmountCents, &f.Status, &reason,
+		&note, &decidedBy, &ref, &f.CreatedAt, &f.UpdatedAt, &processedAt}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return nil, err
+	}
+	f.Reason = nullStringPtr(reason)
+	f.Note = nullStringPtr(note)
+	f.PaymentRef = nullStringPtr(ref)
+	if decidedBy.Valid {
+		id := uint64(decidedBy.Int64)
+		f.DecidedBy = &id
+	}
+	if processedAt.Valid {
+		t := processedAt.Time
+		f.ProcessedAt = &t
+	}
+	return &f, nil
+}
+
+// CreateTx inserts a REQUESTED refund for f.ReservationID and sets its ID.
+// A second refund for the same reservation yields ErrRefundExists.
+func (r *RefundRepo) CreateTx(ctx context.Context, tx *sql.Tx, f *Refund) error {
+	res, err := tx.ExecContext(ctx,
+		`INSERT INTO refunds (reservation_id, user_id, amount_cents, reason) VALUES (?, ?, ?, ?)`,
+		f.ReservationID, f.UserID, f.AmountCents, f.Reason)
+	if err != nil {
+		if strings.Contains(err.Error(), "1062") {
+			return ErrRefundExists
+		}
+		return err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	f.ID = uint64(id)
+	f.Status = RefundRequested
+	return nil
+}
+
+// ApproveForShowTx approves a refund for every REFUND_PENDING reservation
+// of a cancelled show: missing refunds are created APPROVED and requested
+// or rejected ones are approved.  decidedBy is the user who cancelled the
+// show.
+func (r *RefundRepo) ApproveForShowTx(ctx context.Context, tx *sql.Tx, showID, decidedBy uint64) error {
+	_, err := tx.ExecContext(ctx,
+		`INSERT INTO refunds (reservation_id, user_id, amount_cents, status, note, decided_by)
+		 SELECT res.id, res.user_id, res.total_amount_cents, 'APPROVED', 'show cancelled', ?
+		 FROM reservations res WHERE res.show_id = ? AND res.status = 'REFUND_PENDING'
+		 ON DUPLICATE KEY UPDATE
+		   note = IF(refunds.status IN ('REQUESTED', 'REJECTED'), VALUES(note), refunds.note),
+		   decided_by = IF(refunds.status IN ('REQUESTED', 'REJECTED'), VALUES(decided_by), refunds.decided_by),
+		   status = IF(refunds.status IN ('REQUESTED', 'REJECTED'), 'APPROVED', refunds.status)`,
+		decidedBy, showID)
+	return err
+}
+
+// GetForOwner returns a refund of a reservation on a hall the caller owns
+// or is staff for.  It returns ErrRefundNotFound when the refund does not
+// exist and ErrForbidden when the caller may not manage the hall.
+func (r *RefundRepo) GetForOwner(ctx context.Context, id, ownerID uint64) (*Refund, error) {
+	var ownerOfHall uint64
+	var allowed bool
+	f, err := scanRefund(r.db.QueryRowContext(ctx,
+		`SELECT `+refundColumns+`, h.owner_id, `+managedHall+`
+		 FROM refunds rf
+		 JOIN reservations r ON r.id = rf.reservation_id
+		 JOIN shows s ON s.id = r.show_id
+		 JOIN halls h ON h.id = s.hall_id
+		 WHERE rf.id = ?`, ownerID, ownerID, id), &ownerOfHall, &allowed)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrRefundNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, ErrForbidden
+	}
+	f.OwnerID = ownerOfHall
+	return f, nil
+}
+
+// GetByReservationForUser returns the refund of a reservation belonging to
+// userID, or ErrRefundNotFound.
+func (r *RefundRepo) GetByReservationForUser(ctx context.Context, reservationID, userID uint64) (*Refund, error) {
+	f, err := scanRefund(r.db.QueryRowContext(ctx,
+		`SELECT `+refundColumns+`
+		 FROM refunds rf
+		 JOIN reservations r ON r.id = rf.reservation_id
+		 WHERE rf.reservation_id = ? AND rf.user_id = ?`, reservationID, userID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrRefundNotFound
+	}
+	return f, err
+}
+
+// ListForOwner returns the refunds of reservations on halls the caller
+// owns or is staff for, newest first, optionally filtered by status.
+func (r *RefundRepo) ListForOwner(ctx context.Context, ownerID uint64, status string) ([]Refund, error) {
+	q := `SELECT ` + refundColumns + `
+	      FROM refunds rf
+	      JOIN reservations r ON r.id = rf.reservation_id
+	      JOIN shows s ON s.id = r.show_id
+	      JOIN halls h ON h.id = s.hall_id
+	      WHERE ` + managedHall
+	args := []interface{}{ownerID, ownerID}
+	if status != "" {
+		q += ` AND rf.status = ?`
+		args = append(args, status)
+	}
+	rows, err := r.db.QueryContext(ctx, q+` ORDER BY rf.id DESC`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []Refund{}
+	for rows.Next() {
+		f, err := scanRefund(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *f)
+	}
+	return out, rows.Err()
+}
+
+// ListApprovedCharged returns up to limit APPROVED refunds whose
+// reservation was paid through a confirmed checkout, oldest first.  These
+// can be refunded through the payment gateway.
+func (r *RefundRepo) ListApprovedCharged(ctx context.Context, limit int) ([]Refund, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+refundColumns+`
+		 FROM refunds rf
+		 JOIN reservations r ON r.id = rf.reservation_id
+		 WHERE rf.status = 'APPROVED'
+		   AND EXISTS (SELECT 1 FROM booking_sagas b WHERE b.reservation_id = rf.reservation_id AND b.state = 'CONFIRMED')
+		 ORDER BY rf.id LIMIT ?`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Refund
+	for rows.Next() {
+		f, err := scanRefund(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *f)
+	}
+	return out, rows.Err()
+}
+
+// TransitionTx moves a refund from one state to another.  decidedBy and
+// note are recorded when non-nil, paymentRef likewise, and processed_at is
+// set when the refund becomes PROCESSED.  It returns ErrRefundStateChanged
+// when the refund is not in state from.
+func (r *RefundRepo) TransitionTx(ctx context.Context, tx *sql.Tx, id uint64, from, to string, decidedBy *uint64, note, paymentRef *string) error {
+	res, err := tx.ExecContext(ctx,
+		`UPDATE refunds
+		 SET status = ?, decided_by = COALESCE(?, decided_by), note = COALESCE(?, note),
+		     payment_ref = COALESCE(?, payment_ref),
+		     processed_at = IF(? = 'PROCESSED', UTC_TIMESTAMP(), processed_at)
+		 WHERE id = ? AND status = ?`,
+		to, decidedBy, note, paymentRef, to, id, from)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrRefundStateChanged
+	}
+	return nil
+}
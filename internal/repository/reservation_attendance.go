@@ -0,0 +1,189 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math"
+	"strings"
+	"time"
+)
+
+// Attendance statuses of reserved seats.  Seats are BOOKED until they
+// are checked in; seats of confirmed reservations that were not checked
+// in by the end of their show become NO_SHOW (see MarkNoShows).
+const (
+	SeatBooked    = "BOOKED"
+	SeatCheckedIn = "CHECKED_IN"
+	SeatNoShow    = "NO_SHOW"
+)
+
+var (
+	// ErrReservationNotConfirmed is returned by CheckInTx for reservations
+	// that are not CONFIRMED, such as unpaid or cancelled ones.
+	ErrReservationNotConfirmed = errors.New("reservation is not confirmed")
+	// ErrCheckInClosed is returned by CheckInTx once the show has ended.
+	ErrCheckInClosed = errors.New("check-in is closed")
+	// ErrSeatNotInReservation is returned by CheckInTx for seats the
+	// reservation does not include.
+	ErrSeatNotInReservation = errors.New("seat is not part of the reservation")
+	// ErrAlreadyCheckedIn is returned by CheckInTx when none of the seats
+	// is still BOOKED.
+	ErrAlreadyCheckedIn = errors.New("seats are already checked in")
+)
+
+// CheckInTx marks seats of a CONFIRMED reservation on a hall ownerID
+// manages as CHECKED_IN at now; with no seatIDs every seat still BOOKED
+// is checked in.  Seats already checked in are skipped.  It returns the
+// number of seats checked in, or sql.ErrNoRows when the reservation does
+// not exist, ErrForbidden, ErrReservationNotConfirmed, ErrCheckInClosed
+// after the show's end, ErrSeatNotInReservation or ErrAlreadyCheckedIn.
+// The reservation row is locked so concurrent check-ins serialize.
+func (r *ReservationRepo) CheckInTx(ctx context.Context, tx *sql.Tx, reservationID, ownerID uint64, seatIDs []uint64, now time.Time) (int, error) {
+	var (
+		status  string
+		endsAt  time.Time
+		allowed bool
+	)
+	err := tx.QueryRowContext(ctx,
+		`SELECT r.status, s.ends_at, `+managedHall+`
+		 FROM reservations r
+		 JOIN shows s ON s.id = r.show_id
+		 JOIN halls h ON h.id = s.hall_id
+		 WHERE r.id = ?
+		 FOR UPDATE`, ownerID, ownerID, reservationID).Scan(&status, &endsAt, &allowed)
+	if err != nil {
+		return 0, err
+	}
+	if !allowed {
+		return 0, ErrForbidden
+	}
+	if status != "CONFIRMED" {
+		return 0, ErrReservationNotConfirmed
+	}
+	if !endsAt.After(now) {
+		return 0, ErrCheckInClosed
+	}
+	rows, err := tx.QueryContext(ctx,
+		`SELECT seat_id, status FROM reservation_seats WHERE reservation_id = ? FOR UPDATE`, reservationID)
+	if err != nil {
+		return 0, err
+	}
+	seats := map[uint64]string{}
+	for rows.Next() {
+		var id uint64
+		var st string
+		if err := rows.Scan(&id, &st); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		seats[id] = st
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	if len(seatIDs) == 0 {
+		for id := range seats {
+			seatIDs = append(seatIDs, id)
+		}
+	}
+	var (
+		args         = []interface{}{now.UTC(), reservationID}
+		placeholders []string
+	)
+	for _, id := range seatIDs {
+		st, ok := seats[id]
+		if !ok {
+			return 0, ErrSeatNotInReservation
+		}
+		if st == SeatBooked {
+			args = append(args, id)
+			placeholders = append(placeholders, "?")
+		}
+	}
+	if len(placeholders) == 0 {
+		return 0, ErrAlreadyCheckedIn
+	}
+	res, err := tx.ExecContext(ctx,
+		`UPDATE reservation_seats SET status = 'CHECKED_IN', checked_in_at = ?
+		 WHERE reservation_id = ? AND status = 'BOOKED' AND seat_id IN (`+strings.Join(placeholders, ",")+`)`, args...)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
+
+// MarkNoShows marks the BOOKED seats of CONFIRMED reservations whose show
+// ended at or before now as NO_SHOW and returns how many it marked.
+// Running it again changes nothing, so replicas may run it side by side.
+func (r *ReservationRepo) MarkNoShows(ctx context.Context, now time.Time) (int64, error) {
+	res, err := r.db.ExecContext(ctx,
+		`UPDATE reservation_seats rs
+		 JOIN reservations r ON r.id = rs.reservation_id
+		 JOIN shows s ON s.id = rs.show_id
+		 SET rs.status = 'NO_SHOW'
+		 WHERE rs.status = 'BOOKED' AND r.status = 'CONFIRMED' AND s.ends_at <= ?`, now.UTC())
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// ShowAttendance counts the seats of a show's CONFIRMED reservations by
+// attendance status.  CheckInRate and NoShowRate are shares of Seats,
+// rounded to three decimals; both are 0 for a show without seats.
+type ShowAttendance struct {
+	ShowID      uint64  `json:"show_id"`
+	Seats       int     `json:"seats"`
+	Booked      int     `json:"booked"`
+	CheckedIn   int     `json:"checked_in"`
+	NoShow      int     `json:"no_show"`
+	CheckInRate float64 `json:"check_in_rate"`
+	NoShowRate  float64 `json:"no_show_rate"`
+}
+
+// AttendanceByShow returns the attendance of a show the caller manages.
+// It returns sql.ErrNoRows when the show does not exist and ErrForbidden
+// when the caller may not manage its hall.
+func (r *ReservationRepo) AttendanceByShow(ctx context.Context, showID, ownerID uint64) (*ShowAttendance, error) {
+	if err := r.checkShowManaged(ctx, showID, ownerID); err != nil {
+		return nil, err
+	}
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT rs.status, COUNT(*)
+		 FROM reservation_seats rs
+		 JOIN reservations r ON r.id = rs.reservation_id
+		 WHERE rs.show_id = ? AND r.status = 'CONFIRMED'
+		 GROUP BY rs.status`, showID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	a := &ShowAttendance{ShowID: showID}
+	for rows.Next() {
+		var st string
+		var n int
+		if err := rows.Scan(&st, &n); err != nil {
+			return nil, err
+		}
+		switch st {
+		case SeatBooked:
+			a.Booked = n
+		case SeatCheckedIn:
+			a.CheckedIn = n
+		case SeatNoShow:
+			a.NoShow = n
+		}
+		a.Seats += n
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if a.Seats > 0 {
+		a.CheckInRate = math.Round(float64(a.CheckedIn)/float64(a.Seats)*1000) / 1000
+		a.NoShowRate = math.Round(float64(a.NoShow)/float64(a.Seats)*1000) / 1000
+	}
+	return a, nil
+}
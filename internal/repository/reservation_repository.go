@@ -4,8 +4,11 @@ package repository
 import (
     "context"
     "database/sql"
+    "errors"
     "strings"
     "time"
+
+    "github.com/iliyamo/cinema-seat-reservation/internal/money"
 )
 
 // ReservationRepo provides CRUD operations for reservations and their seats.
@@ -15,6 +18,12 @@ import (
 // stored in UTC.
 type ReservationRepo struct {
     db *sql.DB
+    // Events, when set, records confirmations and cancellations in the
+    // booking event outbox inside the transaction that makes them.
+    Events *BookingEventRepo
+    // Webhooks, when set, queues the owners' webhook deliveries for the
+    // same changes in the same transaction.
+    Webhooks *WebhookRepo
 }
 
 // NOTE: This file has been modified to fix several issues related to
@@ -39,7 +48,14 @@ type ReservationRecord struct {
     UserID           uint64
     ShowID           uint64
     Status           string
-    TotalAmountCents uint32
+    SubtotalCents    uint32  // sum of the seat prices
+    TotalAmountCents uint32  // SubtotalCents - DiscountCents + FeesCents + TaxCents
+    Currency         string  // ISO 4217 code of the amounts; empty for reservations made before currencies were recorded
+    PromoCodeID      *uint64 // promo code redeemed on this reservation, if any
+    DiscountCents    uint32  // amount subtracted from the seat total (promo code or bundle)
+    FeesCents        uint32  // booking fee of the cinema
+    TaxCents         uint32  // tax on the discounted seat prices and the fee
+    BundleID         *uint64 // bundle purchase this reservation belongs to, if any
     PaymentRef       *string
     CreatedAt        time.Time
     UpdatedAt        time.Time
@@ -61,8 +77,19 @@ type ReservationSeatRecord struct {
 // rollback the transaction.  Status should be a valid enumeration
 // ('PENDING','CONFIRMED','CANCELLED').
 func (r *ReservationRepo) CreateTx(ctx context.Context, tx *sql.Tx, res *ReservationRecord) error {
-    const q = `INSERT INTO reservations (user_id, show_id, status, total_amount_cents) VALUES (?, ?, ?, ?)`
-    result, err := tx.ExecContext(ctx, q, res.UserID, res.ShowID, res.Status, res.TotalAmountCents)
+    const q = `INSERT INTO reservations (user_id, show_id, status, subtotal_cents, total_amount_cents, currency, promo_code_id, discount_cents, fees_cents, tax_cents, bundle_id)
+               VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+    var currency, promoID, bundleID interface{}
+    if res.Currency != "" {
+        currency = res.Currency
+    }
+    if res.PromoCodeID != nil {
+        promoID = *res.PromoCodeID
+    }
+    if res.BundleID != nil {
+        bundleID = *res.BundleID
+    }
+    result, err := tx.ExecContext(ctx, q, res.UserID, res.ShowID, res.Status, res.SubtotalCents, res.TotalAmountCents, currency, promoID, res.DiscountCents, res.FeesCents, res.TaxCents, bundleID)
     if err != nil {
         return err
     }
@@ -85,9 +112,26 @@ func (r *ReservationRepo) CreateTx(ctx context.Context, tx *sql.Tx, res *Reserva
         pr := paymentRef.String
         res.PaymentRef = &pr
     }
+    if res.Status == "CONFIRMED" {
+        return r.recordEventTx(ctx, tx, EventReservationConfirmed, res.ID)
+    }
     return nil
 }
 
+// recordEventTx appends a booking event for the reservations when Events
+// is set and queues the matching webhook deliveries when Webhooks is.
+func (r *ReservationRepo) recordEventTx(ctx context.Context, tx *sql.Tx, kind string, reservationIDs ...uint64) error {
+    if r.Webhooks != nil {
+        if err := r.Webhooks.EnqueueBookingTx(ctx, tx, kind, reservationIDs...); err != nil {
+            return err
+        }
+    }
+    if r.Events == nil {
+        return nil
+    }
+    return r.Events.AppendTx(ctx, tx, kind, reservationIDs...)
+}
+
 // CreateSeatsBulkTx inserts multiple reservation_seats rows in a single
 // statement.  It associates each seat with the same reservation.  The
 // caller must supply the reservation ID in each record.  The insertion
@@ -114,17 +158,23 @@ func (r *ReservationRepo) CreateSeatsBulkTx(ctx context.Context, tx *sql.Tx, sea
 // hall and cinema information and the seats reserved.  It is returned by
 // ListByUser for display to customers.
 type ReservationDetail struct {
-    ID               uint64   `json:"id"`
-    ShowID           uint64   `json:"show_id"`
-    Status           string   `json:"status"`
-    TotalAmountCents uint32   `json:"total_amount_cents"`
-    ShowTitle        string   `json:"show_title"`
-    StartTime        *string  `json:"start_time"`
-    EndTime          *string  `json:"end_time"`
-    HallID           uint64   `json:"hall_id"`
-    HallName         string   `json:"hall_name"`
-    CinemaID         *uint64  `json:"cinema_id,omitempty"`
-    CinemaName       *string  `json:"cinema_name,omitempty"`
+    ID               uint64     `json:"id"`
+    ShowID           uint64     `json:"show_id"`
+    Status           string     `json:"status"`
+    SubtotalCents    uint32     `json:"subtotal_cents"`
+    DiscountCents    uint32     `json:"discount_cents"`
+    FeesCents        uint32     `json:"fees_cents"`
+    TaxCents         uint32     `json:"tax_cents"`
+    TotalAmountCents uint32     `json:"total_amount_cents"`
+    Currency         string     `json:"currency"`
+    ShowTitle        string     `json:"show_title"`
+    StartTime        *time.Time `json:"start_time"`
+    EndTime          *time.Time `json:"end_time"`
+    HallID           uint64     `json:"hall_id"`
+    HallName         string     `json:"hall_name"`
+    CinemaID         *uint64    `json:"cinema_id,omitempty"`
+    CinemaName       *string    `json:"cinema_name,omitempty"`
+    CreatedAt        time.Time  `json:"created_at"`
     Seats            []struct {
         SeatID     uint64 `json:"seat_id"`
         RowLabel   string `json:"row_label"`
@@ -139,24 +189,36 @@ type ReservationDetail struct {
 // owner‑specific endpoints to expose the reservation's customer and payment
 // details alongside show, hall, cinema and seat information.
 type OwnerReservationDetail struct {
-    ID               uint64   `json:"id"`
-    UserID           uint64   `json:"user_id"`
-    ShowID           uint64   `json:"show_id"`
-    Status           string   `json:"status"`
-    TotalAmountCents uint32   `json:"total_amount_cents"`
-    PaymentRef       *string  `json:"payment_ref,omitempty"`
-    ShowTitle        string   `json:"show_title"`
-    StartTime        *string  `json:"start_time"`
-    EndTime          *string  `json:"end_time"`
-    HallID           uint64   `json:"hall_id"`
-    HallName         string   `json:"hall_name"`
-    CinemaID         *uint64  `json:"cinema_id,omitempty"`
-    CinemaName       *string  `json:"cinema_name,omitempty"`
-    Seats            []struct {
-        SeatID     uint64 `json:"seat_id"`
-        RowLabel   string `json:"row_label"`
-        SeatNumber uint32 `json:"seat_number"`
-    } `json:"seats"`
+    ID               uint64     `json:"id"`
+    UserID           uint64     `json:"user_id"`
+    ShowID           uint64     `json:"show_id"`
+    Status           string     `json:"status"`
+    SubtotalCents    uint32     `json:"subtotal_cents"`
+    DiscountCents    uint32     `json:"discount_cents"`
+    FeesCents        uint32     `json:"fees_cents"`
+    TaxCents         uint32     `json:"tax_cents"`
+    TotalAmountCents uint32     `json:"total_amount_cents"`
+    Currency         string     `json:"currency"`
+    PaymentRef       *string    `json:"payment_ref,omitempty"`
+    ShowTitle        string     `json:"show_title"`
+    StartTime        *time.Time `json:"start_time"`
+    EndTime          *time.Time `json:"end_time"`
+    HallID           uint64     `json:"hall_id"`
+    HallName         string     `json:"hall_name"`
+    CinemaID         *uint64    `json:"cinema_id,omitempty"`
+    CinemaName       *string    `json:"cinema_name,omitempty"`
+    Seats            []OwnerReservationSeat `json:"seats"`
+}
+
+// OwnerReservationSeat is a seat of an OwnerReservationDetail with its
+// attendance: BOOKED, CHECKED_IN (with the time of the check-in) or
+// NO_SHOW.
+type OwnerReservationSeat struct {
+    SeatID      uint64     `json:"seat_id"`
+    RowLabel    string     `json:"row_label"`
+    SeatNumber  uint32     `json:"seat_number"`
+    Status      string     `json:"status"`
+    CheckedInAt *time.Time `json:"checked_in_at"`
 }
 
 // GetByIDForUser returns a single reservation for the given user.  It
@@ -167,9 +229,9 @@ func (r *ReservationRepo) GetByIDForUser(ctx context.Context, reservationID, use
     // Query reservation and related show/hall/cinema information.  Restrict
     // to the requested reservation ID and the calling user to enforce
     // ownership.
-    const q = `SELECT r.id, r.show_id, r.status, r.total_amount_cents,
+    const q = `SELECT r.id, r.show_id, r.status, r.subtotal_cents, r.discount_cents, r.fees_cents, r.tax_cents, r.total_amount_cents, COALESCE(r.currency, c.currency, ''),
                       s.title, s.starts_at, s.ends_at,
-                      h.id, h.name, c.id, c.name
+                      h.id, h.name, c.id, c.name, r.created_at
                FROM reservations r
                JOIN shows s ON s.id = r.show_id
                JOIN halls h ON h.id = s.hall_id
@@ -185,23 +247,17 @@ func (r *ReservationRepo) GetByIDForUser(ctx context.Context, reservationID, use
     var startTime, endTime sql.NullTime
     // Execute the query; if no row is returned the error is sql.ErrNoRows
     err := r.db.QueryRowContext(ctx, q, reservationID, userID).Scan(
-        &det.ID, &det.ShowID, &det.Status, &det.TotalAmountCents,
+        &det.ID, &det.ShowID, &det.Status, &det.SubtotalCents, &det.DiscountCents, &det.FeesCents, &det.TaxCents, &det.TotalAmountCents, &det.Currency,
         &det.ShowTitle, &startTime, &endTime,
-        &hallID, &hallName, &cinemaID, &cinemaName,
+        &hallID, &hallName, &cinemaID, &cinemaName, &det.CreatedAt,
     )
     if err != nil {
         return nil, err
     }
-    // Convert start and end times to RFC3339 in UTC.  When the database
-    // value is NULL (sql.NullTime.Valid == false), leave the JSON fields unset.
-    if startTime.Valid {
-        iso := startTime.Time.UTC().Format(time.RFC3339)
-        det.StartTime = &iso
-    }
-    if endTime.Valid {
-        iso := endTime.Time.UTC().Format(time.RFC3339)
-        det.EndTime = &iso
-    }
+    det.CreatedAt = det.CreatedAt.UTC()
+    det.Currency = money.Or(det.Currency)
+    det.StartTime = nullTimePtr(startTime)
+    det.EndTime = nullTimePtr(endTime)
     det.HallID = hallID
     det.HallName = hallName
     if cinemaID.Valid {
@@ -258,23 +314,24 @@ func (r *ReservationRepo) GetByIDForUser(ctx context.Context, reservationID, use
 // does not exist.
 func (r *ReservationRepo) GetByIDForOwner(ctx context.Context, reservationID, ownerID uint64) (*OwnerReservationDetail, error) {
     // First check existence and ownership.  Join through shows and halls to
-    // obtain the owner_id.  If no row is returned, the reservation does
-    // not exist.  If the owner_id does not match, return ErrForbidden.
-    const checkQ = `SELECT h.owner_id
+    // evaluate whether the caller owns (or is staff for) the hall.  If no
+    // row is returned, the reservation does not exist.  If the caller may
+    // not manage the hall, return ErrForbidden.
+    const checkQ = `SELECT ` + managedHall + `
                     FROM reservations r
                     JOIN shows s ON s.id = r.show_id
                     JOIN halls h ON h.id = s.hall_id
                     WHERE r.id = ?`
-    var actualOwnerID uint64
-    err := r.db.QueryRowContext(ctx, checkQ, reservationID).Scan(&actualOwnerID)
+    var allowed bool
+    err := r.db.QueryRowContext(ctx, checkQ, ownerID, ownerID, reservationID).Scan(&allowed)
     if err != nil {
         return nil, err
     }
-    if actualOwnerID != ownerID {
+    if !allowed {
         return nil, ErrForbidden
     }
     // Fetch the reservation details including the user ID and payment ref
-    const q = `SELECT r.id, r.user_id, r.show_id, r.status, r.total_amount_cents, r.payment_ref,
+    const q = `SELECT r.id, r.user_id, r.show_id, r.status, r.subtotal_cents, r.discount_cents, r.fees_cents, r.tax_cents, r.total_amount_cents, COALESCE(r.currency, c.currency, ''), r.payment_ref,
                       s.title, s.starts_at, s.ends_at,
                       h.id, h.name, c.id, c.name
                FROM reservations r
@@ -291,7 +348,7 @@ func (r *ReservationRepo) GetByIDForOwner(ctx context.Context, reservationID, ow
     // Scan start and end times as sql.NullTime to avoid manual parsing
     var startTime, endTime sql.NullTime
     if err := r.db.QueryRowContext(ctx, q, reservationID).Scan(
-        &det.ID, &det.UserID, &det.ShowID, &det.Status, &det.TotalAmountCents, &payRef,
+        &det.ID, &det.UserID, &det.ShowID, &det.Status, &det.SubtotalCents, &det.DiscountCents, &det.FeesCents, &det.TaxCents, &det.TotalAmountCents, &det.Currency, &payRef,
         &det.ShowTitle, &startTime, &endTime,
         &hallID, &hallName, &cinemaID, &cinemaName,
     ); err != nil {
@@ -301,15 +358,9 @@ func (r *ReservationRepo) GetByIDForOwner(ctx context.Context, reservationID, ow
         ref := payRef.String
         det.PaymentRef = &ref
     }
-    // Convert times to RFC3339 in UTC.  NULL values result in nil JSON fields.
-    if startTime.Valid {
-        iso := startTime.Time.UTC().Format(time.RFC3339)
-        det.StartTime = &iso
-    }
-    if endTime.Valid {
-        iso := endTime.Time.UTC().Format(time.RFC3339)
-        det.EndTime = &iso
-    }
+    det.Currency = money.Or(det.Currency)
+    det.StartTime = nullTimePtr(startTime)
+    det.EndTime = nullTimePtr(endTime)
     det.HallID = hallID
     det.HallName = hallName
     if cinemaID.Valid {
@@ -320,13 +371,9 @@ func (r *ReservationRepo) GetByIDForOwner(ctx context.Context, reservationID, ow
         cn := cinemaName.String
         det.CinemaName = &cn
     }
-    det.Seats = []struct {
-        SeatID     uint64 `json:"seat_id"`
-        RowLabel   string `json:"row_label"`
-        SeatNumber uint32 `json:"seat_number"`
-    }{}
+    det.Seats = []OwnerReservationSeat{}
     // Fetch seats booked under this reservation
-    const seatQ = `SELECT rs.seat_id, se.row_label, se.seat_number
+    const seatQ = `SELECT rs.seat_id, se.row_label, se.seat_number, rs.status, rs.checked_in_at
                    FROM reservation_seats rs
                    JOIN seats se ON se.id = rs.seat_id
                    WHERE rs.reservation_id = ?
@@ -337,17 +384,13 @@ func (r *ReservationRepo) GetByIDForOwner(ctx context.Context, reservationID, ow
     }
     defer rows.Close()
     for rows.Next() {
-        var sid uint64
-        var rowLabel string
-        var seatNum uint32
-        if err := rows.Scan(&sid, &rowLabel, &seatNum); err != nil {
+        var seat OwnerReservationSeat
+        var checkedIn sql.NullTime
+        if err := rows.Scan(&seat.SeatID, &seat.RowLabel, &seat.SeatNumber, &seat.Status, &checkedIn); err != nil {
             return nil, err
         }
-        det.Seats = append(det.Seats, struct {
-            SeatID     uint64 `json:"seat_id"`
-            RowLabel   string `json:"row_label"`
-            SeatNumber uint32 `json:"seat_number"`
-        }{SeatID: sid, RowLabel: rowLabel, SeatNumber: seatNum})
+        seat.CheckedInAt = nullTimePtr(checkedIn)
+        det.Seats = append(det.Seats, seat)
     }
     if err := rows.Err(); err != nil {
         return nil, err
@@ -360,24 +403,11 @@ func (r *ReservationRepo) GetByIDForOwner(ctx context.Context, reservationID, ow
 // the owner before returning the list; otherwise ErrForbidden is
 // returned.  Reservations are ordered by creation time descending.
 func (r *ReservationRepo) ListByShowForOwner(ctx context.Context, showID, ownerID uint64) ([]OwnerReservationDetail, error) {
-    // Verify that the show is owned by the caller.  Join through halls to
-    // obtain the owner_id.  If no row is returned then the show does
-    // not exist (sql.ErrNoRows).  If the owner does not match, return
-    // ErrForbidden.
-    const checkQ = `SELECT h.owner_id
-                    FROM shows s
-                    JOIN halls h ON h.id = s.hall_id
-                    WHERE s.id = ?`
-    var actualOwnerID uint64
-    err := r.db.QueryRowContext(ctx, checkQ, showID).Scan(&actualOwnerID)
-    if err != nil {
+    if err := r.checkShowManaged(ctx, showID, ownerID); err != nil {
         return nil, err
     }
-    if actualOwnerID != ownerID {
-        return nil, ErrForbidden
-    }
     // Fetch reservations for the show with user and payment info
-    const q = `SELECT r.id, r.user_id, r.show_id, r.status, r.total_amount_cents, r.payment_ref,
+    const q = `SELECT r.id, r.user_id, r.show_id, r.status, r.subtotal_cents, r.discount_cents, r.fees_cents, r.tax_cents, r.total_amount_cents, COALESCE(r.currency, c.currency, ''), r.payment_ref,
                       s.title, s.starts_at, s.ends_at,
                       h.id, h.name, c.id, c.name,
                       r.created_at
@@ -405,7 +435,7 @@ func (r *ReservationRepo) ListByShowForOwner(ctx context.Context, showID, ownerI
         var startTime, endTime sql.NullTime
         var createdAt time.Time
         if err := rows.Scan(
-            &d.ID, &d.UserID, &d.ShowID, &d.Status, &d.TotalAmountCents, &payRef,
+            &d.ID, &d.UserID, &d.ShowID, &d.Status, &d.SubtotalCents, &d.DiscountCents, &d.FeesCents, &d.TaxCents, &d.TotalAmountCents, &d.Currency, &payRef,
             &d.ShowTitle, &startTime, &endTime,
             &hallID, &hallName, &cinemaID, &cinemaName,
             &createdAt,
@@ -416,15 +446,9 @@ func (r *ReservationRepo) ListByShowForOwner(ctx context.Context, showID, ownerI
             ref := payRef.String
             d.PaymentRef = &ref
         }
-        // Convert start and end times to RFC3339 in UTC if present
-        if startTime.Valid {
-            iso := startTime.Time.UTC().Format(time.RFC3339)
-            d.StartTime = &iso
-        }
-        if endTime.Valid {
-            iso := endTime.Time.UTC().Format(time.RFC3339)
-            d.EndTime = &iso
-        }
+        d.Currency = money.Or(d.Currency)
+        d.StartTime = nullTimePtr(startTime)
+        d.EndTime = nullTimePtr(endTime)
         d.HallID = hallID
         d.HallName = hallName
         if cinemaID.Valid {
@@ -435,11 +459,7 @@ func (r *ReservationRepo) ListByShowForOwner(ctx context.Context, showID, ownerI
             cn := cinemaName.String
             d.CinemaName = &cn
         }
-        d.Seats = []struct {
-            SeatID     uint64 `json:"seat_id"`
-            RowLabel   string `json:"row_label"`
-            SeatNumber uint32 `json:"seat_number"`
-        }{}
+        d.Seats = []OwnerReservationSeat{}
         index[d.ID] = len(details)
         details = append(details, d)
     }
@@ -456,7 +476,7 @@ func (r *ReservationRepo) ListByShowForOwner(ctx context.Context, showID, ownerI
         ids = append(ids, d.ID)
         placeholders = append(placeholders, "?")
     }
-    seatQuery := `SELECT rs.reservation_id, rs.seat_id, se.row_label, se.seat_number
+    seatQuery := `SELECT rs.reservation_id, rs.seat_id, se.row_label, se.seat_number, rs.status, rs.checked_in_at
                   FROM reservation_seats rs
                   JOIN seats se ON se.id = rs.seat_id
                   WHERE rs.reservation_id IN (` + strings.Join(placeholders, ",") + `)
@@ -468,21 +488,17 @@ func (r *ReservationRepo) ListByShowForOwner(ctx context.Context, showID, ownerI
     defer srows.Close()
     for srows.Next() {
         var rid uint64
-        var sid uint64
-        var rowLabel string
-        var seatNum uint32
-        if err := srows.Scan(&rid, &sid, &rowLabel, &seatNum); err != nil {
+        var seat OwnerReservationSeat
+        var checkedIn sql.NullTime
+        if err := srows.Scan(&rid, &seat.SeatID, &seat.RowLabel, &seat.SeatNumber, &seat.Status, &checkedIn); err != nil {
             return nil, err
         }
         idx, ok := index[rid]
         if !ok {
             continue
         }
-        details[idx].Seats = append(details[idx].Seats, struct {
-            SeatID     uint64 `json:"seat_id"`
-            RowLabel   string `json:"row_label"`
-            SeatNumber uint32 `json:"seat_number"`
-        }{SeatID: sid, RowLabel: rowLabel, SeatNumber: seatNum})
+        seat.CheckedInAt = nullTimePtr(checkedIn)
+        details[idx].Seats = append(details[idx].Seats, seat)
     }
     if err := srows.Err(); err != nil {
         return nil, err
@@ -490,14 +506,100 @@ func (r *ReservationRepo) ListByShowForOwner(ctx context.Context, showID, ownerI
     return details, nil
 }
 
+// checkShowManaged verifies that the show is owned (or managed as staff)
+// by the caller.  It returns sql.ErrNoRows when the show does not exist
+// and ErrForbidden when the caller may not manage its hall.
+func (r *ReservationRepo) checkShowManaged(ctx context.Context, showID, ownerID uint64) error {
+    const checkQ = `SELECT ` + managedHall + `
+                    FROM shows s
+                    JOIN halls h ON h.id = s.hall_id
+                    WHERE s.id = ?`
+    var allowed bool
+    if err := r.db.QueryRowContext(ctx, checkQ, ownerID, ownerID, showID).Scan(&allowed); err != nil {
+        return err
+    }
+    if !allowed {
+        return ErrForbidden
+    }
+    return nil
+}
+
+// ReservationExportRow is one reservation of a show export.  Seats lists
+// the booked seats as row label and number (e.g. "A12"), space separated.
+type ReservationExportRow struct {
+    ID               uint64
+    CustomerEmail    string
+    Seats            string
+    SeatCount        int
+    SubtotalCents    uint32
+    DiscountCents    uint32
+    FeesCents        uint32
+    TaxCents         uint32
+    TotalAmountCents uint32
+    Currency         string
+    Status           string
+    PaymentRef       string
+    CreatedAt        time.Time
+    UpdatedAt        time.Time
+}
+
+// ExportByShowForOwner streams the reservations of a show to fn, oldest
+// first, after the same ownership check as ListByShowForOwner.  Rows are
+// read from a single query and handed over one at a time so large shows
+// are never held in memory; an error from fn stops the export and is
+// returned.
+func (r *ReservationRepo) ExportByShowForOwner(ctx context.Context, showID, ownerID uint64, fn func(*ReservationExportRow) error) error {
+    if err := r.checkShowManaged(ctx, showID, ownerID); err != nil {
+        return err
+    }
+    const q = `SELECT r.id, u.email,
+                      COALESCE(GROUP_CONCAT(CONCAT(se.row_label, se.seat_number) ORDER BY se.row_label, se.seat_number SEPARATOR ' '), ''),
+                      COUNT(rs.seat_id), r.subtotal_cents, r.discount_cents, r.fees_cents, r.tax_cents, r.total_amount_cents,
+                      COALESCE(r.currency, (SELECT c.currency FROM shows s
+                                            JOIN halls h ON h.id = s.hall_id
+                                            JOIN cinemas c ON c.id = h.cinema_id
+                                            WHERE s.id = r.show_id), ''),
+                      r.status, r.payment_ref, r.created_at, r.updated_at
+               FROM reservations r
+               JOIN users u ON u.id = r.user_id
+               LEFT JOIN reservation_seats rs ON rs.reservation_id = r.id
+               LEFT JOIN seats se ON se.id = rs.seat_id
+               WHERE r.show_id = ?
+               GROUP BY r.id
+               ORDER BY r.id`
+    rows, err := r.db.QueryContext(ctx, q, showID)
+    if err != nil {
+        return err
+    }
+    defer rows.Close()
+    for rows.Next() {
+        var (
+            row    ReservationExportRow
+            payRef sql.NullString
+        )
+        if err := rows.Scan(&row.ID, &row.CustomerEmail, &row.Seats, &row.SeatCount,
+            &row.SubtotalCents, &row.DiscountCents, &row.FeesCents, &row.TaxCents, &row.TotalAmountCents,
+            &row.Currency, &row.Status, &payRef, &row.CreatedAt, &row.UpdatedAt); err != nil {
+            return err
+        }
+        row.Currency = money.Or(row.Currency)
+        row.PaymentRef = payRef.String
+        if err := fn(&row); err != nil {
+            return err
+        }
+    }
+    return rows.Err()
+}
+
 // GetInfoForOwnerTx returns the show ID, show start time and list of seat IDs for a
 // reservation, validating ownership within a transaction.  It ensures
-// that the reservation exists and that the caller owns the hall.  If
-// the reservation does not exist, sql.ErrNoRows is returned.  If the
-// owner does not own the hall, ErrForbidden is returned.  The
-// returned time is in UTC.
+// that the reservation exists and that the caller owns the hall or is
+// staff for it.  If the reservation does not exist, sql.ErrNoRows is
+// returned.  If the caller may not manage the hall, ErrForbidden is returned,
+// and ErrReservationCancelled when the reservation was already cancelled.
+// The returned time is in UTC.
 func (r *ReservationRepo) GetInfoForOwnerTx(ctx context.Context, tx *sql.Tx, reservationID, ownerID uint64) (uint64, time.Time, []uint64, error) {
-    const q = `SELECT r.show_id, s.starts_at, h.owner_id
+    const q = `SELECT r.show_id, s.starts_at, r.status, ` + managedHall + `
                FROM reservations r
                JOIN shows s ON s.id = r.show_id
                JOIN halls h ON h.id = s.hall_id
@@ -507,14 +609,18 @@ func (r *ReservationRepo) GetInfoForOwnerTx(ctx context.Context, tx *sql.Tx, res
     // avoid parsing errors.  The shows.starts_at column is defined as
     // DATETIME NOT NULL, so this will always return a valid time.
     var startTime time.Time
-    var actualOwnerID uint64
-    err := tx.QueryRowContext(ctx, q, reservationID).Scan(&showID, &startTime, &actualOwnerID)
+    var status string
+    var allowed bool
+    err := tx.QueryRowContext(ctx, q, ownerID, ownerID, reservationID).Scan(&showID, &startTime, &status, &allowed)
     if err != nil {
         return 0, time.Time{}, nil, err
     }
-    if actualOwnerID != ownerID {
+    if !allowed {
         return 0, time.Time{}, nil, ErrForbidden
     }
+    if !reservationOpen(status) {
+        return 0, time.Time{}, nil, ErrReservationCancelled
+    }
     // No parsing necessary; startTime already contains the correct value
     // Fetch seat IDs
     const seatQ = `SELECT seat_id FROM reservation_seats WHERE reservation_id = ?`
@@ -540,10 +646,11 @@ func (r *ReservationRepo) GetInfoForOwnerTx(ctx context.Context, tx *sql.Tx, res
 // GetInfoForUserTx returns the show ID, show start time and seat IDs for a
 // reservation within a transaction, validating that the reservation
 // belongs to the specified user.  It returns sql.ErrNoRows when the
-// reservation does not exist and ErrForbidden when the reservation
-// belongs to a different user.  The returned time is in UTC.
+// reservation does not exist, ErrForbidden when the reservation belongs
+// to a different user and ErrReservationCancelled when it was already
+// cancelled.  The returned time is in UTC.
 func (r *ReservationRepo) GetInfoForUserTx(ctx context.Context, tx *sql.Tx, reservationID, userID uint64) (uint64, time.Time, []uint64, error) {
-    const q = `SELECT r.show_id, s.starts_at, r.user_id
+    const q = `SELECT r.show_id, s.starts_at, r.user_id, r.status
                FROM reservations r
                JOIN shows s ON s.id = r.show_id
                WHERE r.id = ?`
@@ -551,13 +658,17 @@ func (r *ReservationRepo) GetInfoForUserTx(ctx context.Context, tx *sql.Tx, rese
     // Scan the show's start time directly as a time.Time to avoid parsing errors.
     var startTime time.Time
     var actualUserID uint64
-    err := tx.QueryRowContext(ctx, q, reservationID).Scan(&showID, &startTime, &actualUserID)
+    var status string
+    err := tx.QueryRowContext(ctx, q, reservationID).Scan(&showID, &startTime, &actualUserID, &status)
     if err != nil {
         return 0, time.Time{}, nil, err
     }
     if actualUserID != userID {
         return 0, time.Time{}, nil, ErrForbidden
     }
+    if !reservationOpen(status) {
+        return 0, time.Time{}, nil, ErrReservationCancelled
+    }
     // No parsing necessary; startTime already contains the correct value
     const seatQ = `SELECT seat_id FROM reservation_seats WHERE reservation_id = ?`
     rows, err := tx.QueryContext(ctx, seatQ, reservationID)
@@ -586,7 +697,7 @@ func (r *ReservationRepo) GetInfoForUserTx(ctx context.Context, tx *sql.Tx, rese
 // slice is returned.
 func (r *ReservationRepo) ListByUser(ctx context.Context, userID uint64) ([]ReservationDetail, error) {
     // First fetch high-level reservation info and related show/hall/cinema details
-    const q = `SELECT r.id, r.show_id, r.status, r.total_amount_cents,
+    const q = `SELECT r.id, r.show_id, r.status, r.subtotal_cents, r.discount_cents, r.fees_cents, r.tax_cents, r.total_amount_cents, COALESCE(r.currency, c.currency, ''),
                       s.title, s.starts_at, s.ends_at,
                       h.id, h.name, c.id, c.name,
                       r.created_at
@@ -613,24 +724,18 @@ func (r *ReservationRepo) ListByUser(ctx context.Context, userID uint64) ([]Rese
         var cinemaName sql.NullString
         // Scan start and end times as sql.NullTime to avoid parsing errors
         var startTime, endTime sql.NullTime
-        var createdAt time.Time
         if err := rows.Scan(
-            &d.ID, &d.ShowID, &d.Status, &d.TotalAmountCents,
+            &d.ID, &d.ShowID, &d.Status, &d.SubtotalCents, &d.DiscountCents, &d.FeesCents, &d.TaxCents, &d.TotalAmountCents, &d.Currency,
             &d.ShowTitle, &startTime, &endTime,
             &hallID, &hallName, &cinemaID, &cinemaName,
-            &createdAt,
+            &d.CreatedAt,
         ); err != nil {
             return nil, err
         }
-        // Convert times from DB to RFC3339 in UTC.  Leave unset when NULL.
-        if startTime.Valid {
-            iso := startTime.Time.UTC().Format(time.RFC3339)
-            d.StartTime = &iso
-        }
-        if endTime.Valid {
-            iso := endTime.Time.UTC().Format(time.RFC3339)
-            d.EndTime = &iso
-        }
+        d.CreatedAt = d.CreatedAt.UTC()
+        d.Currency = money.Or(d.Currency)
+        d.StartTime = nullTimePtr(startTime)
+        d.EndTime = nullTimePtr(endTime)
         d.HallID = hallID
         d.HallName = hallName
         if cinemaID.Valid {
@@ -696,4 +801,325 @@ func (r *ReservationRepo) ListByUser(ctx context.Context, userID uint64) ([]Rese
         return nil, err
     }
     return details, nil
-}
\ No newline at end of file
+}
+
+// ErrReservationNotPending is returned by ConfirmPendingTx and
+// ReleasePendingTx when the reservation no longer exists or is not
+// PENDING.
+var ErrReservationNotPending = errors.New("reservation is not pending")
+
+// ErrReservationCancelled is returned by GetInfoForOwnerTx and
+// GetInfoForUserTx for reservations that are CANCELLED or REFUND_PENDING,
+// for example because their show was cancelled.
+var ErrReservationCancelled = errors.New("reservation is cancelled")
+
+// ErrReservationPaid is returned by CancelTx for reservations that were
+// paid for or have a refund; they are cancelled through a refund instead.
+var ErrReservationPaid = errors.New("reservation is paid")
+
+// chargedSQL is true for a row of reservations that was paid for: it has
+// a payment reference or its booking saga charged it.  A non-zero total
+// alone does not count; /confirm books without taking a payment.
+const chargedSQL = `(reservations.payment_ref IS NOT NULL
+    OR EXISTS(SELECT 1 FROM booking_sagas g WHERE g.reservation_id = reservations.id AND g.state = 'CONFIRMED'))`
+
+// paidSQL is chargedSQL or a refund exists for the reservation.
+const paidSQL = `(` + chargedSQL + `
+    OR EXISTS(SELECT 1 FROM refunds f WHERE f.reservation_id = reservations.id))`
+
+// reservationOpen reports whether a reservation in the given status still
+// holds its seats.
+func reservationOpen(status string) bool {
+    return status == "PENDING" || status == "CONFIRMED"
+}
+
+// CancelledReservation is a reservation moved out of PENDING or CONFIRMED
+// by CancelByShowTx.  Status is the new status.
+type CancelledReservation struct {
+    ID               uint64
+    UserID           uint64
+    Status           string // CANCELLED or REFUND_PENDING
+    TotalAmountCents uint32
+    PaymentRef       *string
+}
+
+// CancelByShowTx cancels every PENDING or CONFIRMED reservation of a show.
+// Paid CONFIRMED reservations (with a payment reference, a confirmed
+// booking saga or a refund) become REFUND_PENDING so the refund can be processed later; all others become
+// CANCELLED.  The reservations and their reservation_seats rows are kept
+// for the customers' history.  The rows are locked first, so a booking
+// saga confirming one of them concurrently fails with
+// ErrReservationNotPending and voids its charge.
+func (r *ReservationRepo) CancelByShowTx(ctx context.Context, tx *sql.Tx, showID uint64) ([]CancelledReservation, error) {
+    rows, err := tx.QueryContext(ctx,
+        `SELECT id, user_id, status, total_amount_cents, payment_ref, `+paidSQL+` FROM reservations
+         WHERE show_id = ? AND status IN ('PENDING', 'CONFIRMED') ORDER BY id FOR UPDATE`, showID)
+    if err != nil {
+        return nil, err
+    }
+    var out []CancelledReservation
+    for rows.Next() {
+        var cr CancelledReservation
+        var ref sql.NullString
+        var paid bool
+        if err := rows.Scan(&cr.ID, &cr.UserID, &cr.Status, &cr.TotalAmountCents, &ref, &paid); err != nil {
+            rows.Close()
+            return nil, err
+        }
+        if ref.Valid {
+            cr.PaymentRef = &ref.String
+        }
+        if cr.Status == "CONFIRMED" && paid {
+            cr.Status = "REFUND_PENDING"
+        } else {
+            cr.Status = "CANCELLED"
+        }
+        out = append(out, cr)
+    }
+    rows.Close()
+    if err := rows.Err(); err != nil {
+        return nil, err
+    }
+    if len(out) == 0 {
+        return out, nil
+    }
+    const q = `UPDATE reservations
+               SET status = IF(status = 'CONFIRMED' AND ` + paidSQL + `, 'REFUND_PENDING', 'CANCELLED')
+               WHERE show_id = ? AND status IN ('PENDING', 'CONFIRMED')`
+    if _, err := tx.ExecContext(ctx, q, showID); err != nil {
+        return nil, err
+    }
+    ids := make([]uint64, len(out))
+    for i, cr := range out {
+        ids[i] = cr.ID
+    }
+    if err := r.recordEventTx(ctx, tx, EventReservationCancelled, ids...); err != nil {
+        return nil, err
+    }
+    return out, nil
+}
+
+// ReleaseForRefundTx moves a CONFIRMED reservation to REFUND_PENDING once
+// its refund was approved and releases its seats: the reservation_seats
+// rows are deleted so the seats can be booked again, and the show and
+// seat IDs are returned so the caller can set them FREE.  It returns
+// sql.ErrNoRows when the reservation does not exist and
+// ErrReservationCancelled when it is not CONFIRMED.
+func (r *ReservationRepo) ReleaseForRefundTx(ctx context.Context, tx *sql.Tx, reservationID uint64) (uint64, []uint64, error) {
+    var showID uint64
+    var status string
+    err := tx.QueryRowContext(ctx, `SELECT show_id, status FROM reservations WHERE id = ? FOR UPDATE`, reservationID).Scan(&showID, &status)
+    if err != nil {
+        return 0, nil, err
+    }
+    if status != "CONFIRMED" {
+        return 0, nil, ErrReservationCancelled
+    }
+    rows, err := tx.QueryContext(ctx, `SELECT seat_id FROM reservation_seats WHERE reservation_id = ? ORDER BY seat_id`, reservationID)
+    if err != nil {
+        return 0, nil, err
+    }
+    var seatIDs []uint64
+    for rows.Next() {
+        var sid uint64
+        if err := rows.Scan(&sid); err != nil {
+            rows.Close()
+            return 0, nil, err
+        }
+        seatIDs = append(seatIDs, sid)
+    }
+    rows.Close()
+    if err := rows.Err(); err != nil {
+        return 0, nil, err
+    }
+    if _, err := tx.ExecContext(ctx, `DELETE FROM reservation_seats WHERE reservation_id = ?`, reservationID); err != nil {
+        return 0, nil, err
+    }
+    if _, err := tx.ExecContext(ctx, `UPDATE reservations SET status = 'REFUND_PENDING' WHERE id = ?`, reservationID); err != nil {
+        return 0, nil, err
+    }
+    if err := r.recordEventTx(ctx, tx, EventReservationCancelled, reservationID); err != nil {
+        return 0, nil, err
+    }
+    return showID, seatIDs, nil
+}
+
+// FinishRefundTx marks a REFUND_PENDING reservation CANCELLED after its
+// refund was processed.  Reservations in other states are left alone.
+func (r *ReservationRepo) FinishRefundTx(ctx context.Context, tx *sql.Tx, reservationID uint64) error {
+    _, err := tx.ExecContext(ctx, `UPDATE reservations SET status = 'CANCELLED' WHERE id = ? AND status = 'REFUND_PENDING'`, reservationID)
+    return err
+}
+
+// IsCharged reports whether a reservation was paid for: it has a payment
+// reference or a confirmed booking saga.  Only charged reservations are
+// refunded; the others are cancelled.
+func (r *ReservationRepo) IsCharged(ctx context.Context, reservationID uint64) (bool, error) {
+    var charged bool
+    err := r.db.QueryRowContext(ctx,
+        `SELECT `+chargedSQL+` FROM reservations WHERE id = ?`, reservationID).Scan(&charged)
+    return charged, err
+}
+
+// SetPaymentRefTx records the payment reference of a reservation.
+func (r *ReservationRepo) SetPaymentRefTx(ctx context.Context, tx *sql.Tx, reservationID uint64, paymentRef string) error {
+    _, err := tx.ExecContext(ctx, `UPDATE reservations SET payment_ref = ? WHERE id = ?`, paymentRef, reservationID)
+    return err
+}
+
+// CancelTx cancels an open reservation that was not paid for.  An
+// unpaid PENDING reservation is deleted and its promo code redemption
+// returned; its reservation_seats rows are removed by the foreign key
+// cascade.  An unpaid CONFIRMED reservation becomes CANCELLED and is kept
+// for the customer's history, while its reservation_seats rows are
+// deleted so the seats can be booked again.  Reservations with a payment
+// reference, a confirmed booking saga or a refund are left alone and fail
+// with ErrReservationPaid; they are cancelled by refunding them.  The row is locked first.  It returns
+// sql.ErrNoRows when the reservation does not exist and
+// ErrReservationCancelled when it is no longer open.
+func (r *ReservationRepo) CancelTx(ctx context.Context, tx *sql.Tx, reservationID uint64) error {
+    var status string
+    var paid bool
+    err := tx.QueryRowContext(ctx,
+        `SELECT status, `+paidSQL+` FROM reservations WHERE id = ? FOR UPDATE`, reservationID).Scan(&status, &paid)
+    if err != nil {
+        return err
+    }
+    if !reservationOpen(status) {
+        return ErrReservationCancelled
+    }
+    if paid {
+        return ErrReservationPaid
+    }
+    if status == "PENDING" {
+        if err := r.recordEventTx(ctx, tx, EventReservationCancelled, reservationID); err != nil {
+            return err
+        }
+        return r.deletePendingTx(ctx, tx, reservationID)
+    }
+    if _, err := tx.ExecContext(ctx, `DELETE FROM reservation_seats WHERE reservation_id = ?`, reservationID); err != nil {
+        return err
+    }
+    if _, err := tx.ExecContext(ctx, `UPDATE reservations SET status = 'CANCELLED' WHERE id = ?`, reservationID); err != nil {
+        return err
+    }
+    return r.recordEventTx(ctx, tx, EventReservationCancelled, reservationID)
+}
+
+// ConfirmPendingTx marks a PENDING reservation CONFIRMED and records the
+// payment reference.
+func (r *ReservationRepo) ConfirmPendingTx(ctx context.Context, tx *sql.Tx, reservationID uint64, paymentRef *string) error {
+    const q = `UPDATE reservations SET status = 'CONFIRMED', payment_ref = ? WHERE id = ? AND status = 'PENDING'`
+    var ref interface{}
+    if paymentRef != nil {
+        ref = *paymentRef
+    }
+    res, err := tx.ExecContext(ctx, q, ref, reservationID)
+    if err != nil {
+        return err
+    }
+    n, err := res.RowsAffected()
+    if err != nil {
+        return err
+    }
+    if n == 0 {
+        return ErrReservationNotPending
+    }
+    return r.recordEventTx(ctx, tx, EventReservationConfirmed, reservationID)
+}
+
+// ReleasePendingTx deletes a PENDING reservation (its reservation_seats
+// cascade) and returns its show and seat IDs so the caller can free the
+// seats.  The promo code redemption the reservation counted, if any, is
+// returned in the same transaction.  The reservation row is locked first
+// so a concurrent confirmation cannot slip in between.
+func (r *ReservationRepo) ReleasePendingTx(ctx context.Context, tx *sql.Tx, reservationID uint64) (uint64, []uint64, error) {
+    return r.releasePendingTx(ctx, tx, reservationID, "")
+}
+
+// ExpirePendingTx is ReleasePendingTx for a reservation whose payment
+// window passed; it also records an EventReservationExpired booking
+// event.
+func (r *ReservationRepo) ExpirePendingTx(ctx context.Context, tx *sql.Tx, reservationID uint64) (uint64, []uint64, error) {
+    return r.releasePendingTx(ctx, tx, reservationID, EventReservationExpired)
+}
+
+// StalePending returns up to limit PENDING reservations created before
+// cutoff, oldest first.  Reservations of an unfinished booking saga are
+// left to the saga's recovery, which may already have charged them.
+func (r *ReservationRepo) StalePending(ctx context.Context, cutoff time.Time, limit int) ([]uint64, error) {
+    rows, err := r.db.QueryContext(ctx,
+        `SELECT r.id FROM reservations r
+         WHERE r.status = 'PENDING' AND r.created_at < ?
+           AND NOT EXISTS (SELECT 1 FROM booking_sagas g
+                           WHERE g.reservation_id = r.id AND g.state NOT IN ('CONFIRMED', 'COMPENSATED'))
+         ORDER BY r.created_at, r.id
+         LIMIT ?`, cutoff, limit)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+    var ids []uint64
+    for rows.Next() {
+        var id uint64
+        if err := rows.Scan(&id); err != nil {
+            return nil, err
+        }
+        ids = append(ids, id)
+    }
+    return ids, rows.Err()
+}
+
+// releasePendingTx implements ReleasePendingTx, appending a booking event
+// of the given kind before the row is deleted unless kind is empty.
+func (r *ReservationRepo) releasePendingTx(ctx context.Context, tx *sql.Tx, reservationID uint64, kind string) (uint64, []uint64, error) {
+    var showID uint64
+    var status string
+    err := tx.QueryRowContext(ctx, `SELECT show_id, status FROM reservations WHERE id = ? FOR UPDATE`, reservationID).Scan(&showID, &status)
+    if errors.Is(err, sql.ErrNoRows) || (err == nil && status != "PENDING") {
+        return 0, nil, ErrReservationNotPending
+    }
+    if err != nil {
+        return 0, nil, err
+    }
+    rows, err := tx.QueryContext(ctx, `SELECT seat_id FROM reservation_seats WHERE reservation_id = ? ORDER BY seat_id`, reservationID)
+    if err != nil {
+        return 0, nil, err
+    }
+    var seatIDs []uint64
+    for rows.Next() {
+        var sid uint64
+        if err := rows.Scan(&sid); err != nil {
+            rows.Close()
+            return 0, nil, err
+        }
+        seatIDs = append(seatIDs, sid)
+    }
+    rows.Close()
+    if err := rows.Err(); err != nil {
+        return 0, nil, err
+    }
+    if kind != "" {
+        if err := r.recordEventTx(ctx, tx, kind, reservationID); err != nil {
+            return 0, nil, err
+        }
+    }
+    if err := r.deletePendingTx(ctx, tx, reservationID); err != nil {
+        return 0, nil, err
+    }
+    return showID, seatIDs, nil
+}
+
+// deletePendingTx deletes a locked PENDING reservation and takes back the
+// promo code redemption counted when it was created: an unpaid
+// reservation never redeemed its code.
+func (r *ReservationRepo) deletePendingTx(ctx context.Context, tx *sql.Tx, reservationID uint64) error {
+    if _, err := tx.ExecContext(ctx,
+        `UPDATE promo_codes p JOIN reservations r ON r.promo_code_id = p.id
+         SET p.used_count = p.used_count - 1
+         WHERE r.id = ? AND p.used_count > 0`, reservationID); err != nil {
+        return err
+    }
+    _, err := tx.ExecContext(ctx, `DELETE FROM reservations WHERE id = ?`, reservationID)
+    return err
+}
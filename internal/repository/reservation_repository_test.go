@@ -0,0 +1,138 @@
+package repository_test
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/iliyamo/cinema-seat-reservation/internal/repository"
+	"github.com/iliyamo/cinema-seat-reservation/internal/repository/mock"
+)
+
+// cancelTx runs ReservationRepo.CancelTx against a reservation row with
+// the given status that the database reports as paid or not, and returns
+// the statements it executed and its error.
+func cancelTx(t *testing.T, status string, paid bool) ([]string, error) {
+	t.Helper()
+	var execs []string
+	d := &mock.Driver{
+		QueryFn: func(query string, _ []driver.NamedValue) ([]string, [][]driver.Value, error) {
+			if !strings.Contains(query, "FOR UPDATE") {
+				t.Fatalf("unexpected query %q", query)
+			}
+			// paid means a payment reference, a confirmed saga or a refund
+			for _, want := range []string{"payment_ref IS NOT NULL", "FROM booking_sagas", "state = 'CONFIRMED'", "FROM refunds"} {
+				if !strings.Contains(query, want) {
+					t.Fatalf("query %q does not check %q", query, want)
+				}
+			}
+			return []string{"status", "paid"}, [][]driver.Value{{status, paid}}, nil
+		},
+		ExecFn: func(query string, _ []driver.NamedValue) (driver.Result, error) {
+			execs = append(execs, strings.Join(strings.Fields(query), " "))
+			return driver.RowsAffected(1), nil
+		},
+	}
+	conn := d.DB()
+	defer conn.Close()
+	ctx := context.Background()
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+	err = repository.NewReservationRepo(conn).CancelTx(ctx, tx, 7)
+	return execs, err
+}
+
+func TestCancelTxKeepsPaidReservations(t *testing.T) {
+	for _, status := range []string{"CONFIRMED", "PENDING"} {
+		t.Run(status, func(t *testing.T) {
+			execs, err := cancelTx(t, status, true)
+			if !errors.Is(err, repository.ErrReservationPaid) {
+				t.Fatalf("err = %v, want ErrReservationPaid", err)
+			}
+			if len(execs) != 0 {
+				t.Fatalf("paid reservation was modified: %q", execs)
+			}
+		})
+	}
+}
+
+func TestCancelTxDeletesUnpaidPending(t *testing.T) {
+	execs, err := cancelTx(t, "PENDING", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(execs) != 2 || !strings.HasPrefix(execs[0], "UPDATE promo_codes") || execs[1] != "DELETE FROM reservations WHERE id = ?" {
+		t.Fatalf("execs = %q", execs)
+	}
+}
+
+// An unpaid CONFIRMED reservation is cancelled whatever its total:
+// /confirm books without taking a payment.
+func TestCancelTxCancelsUnpaidConfirmed(t *testing.T) {
+	execs, err := cancelTx(t, "CONFIRMED", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"DELETE FROM reservation_seats WHERE reservation_id = ?",
+		"UPDATE reservations SET status = 'CANCELLED' WHERE id = ?",
+	}
+	if strings.Join(execs, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("execs = %q, want %q", execs, want)
+	}
+}
+
+func TestCancelTxRejectsClosed(t *testing.T) {
+	for _, status := range []string{"CANCELLED", "REFUND_PENDING"} {
+		execs, err := cancelTx(t, status, true)
+		if !errors.Is(err, repository.ErrReservationCancelled) {
+			t.Fatalf("%s: err = %v, want ErrReservationCancelled", status, err)
+		}
+		if len(execs) != 0 {
+			t.Fatalf("%s: execs = %q", status, execs)
+		}
+	}
+}
+
+func TestReleasePendingTxReturnsPromoRedemption(t *testing.T) {
+	var execs []string
+	d := &mock.Driver{
+		QueryFn: func(query string, _ []driver.NamedValue) ([]string, [][]driver.Value, error) {
+			if strings.Contains(query, "FOR UPDATE") {
+				return []string{"show_id", "status"}, [][]driver.Value{{int64(3), "PENDING"}}, nil
+			}
+			return []string{"seat_id"}, [][]driver.Value{{int64(11)}, {int64(12)}}, nil
+		},
+		ExecFn: func(query string, _ []driver.NamedValue) (driver.Result, error) {
+			execs = append(execs, strings.Join(strings.Fields(query), " "))
+			return driver.RowsAffected(1), nil
+		},
+	}
+	conn := d.DB()
+	defer conn.Close()
+	ctx := context.Background()
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+	showID, seatIDs, err := repository.NewReservationRepo(conn).ReleasePendingTx(ctx, tx, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if showID != 3 || len(seatIDs) != 2 {
+		t.Fatalf("released show %d seats %v", showID, seatIDs)
+	}
+	want := []string{
+		"UPDATE promo_codes p JOIN reservations r ON r.promo_code_id = p.id SET p.used_count = p.used_count - 1 WHERE r.id = ? AND p.used_count > 0",
+		"DELETE FROM reservations WHERE id = ?",
+	}
+	if strings.Join(execs, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("execs = %q, want %q", execs, want)
+	}
+}
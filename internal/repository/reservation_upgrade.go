@@ -0,0 +1,190 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/iliyamo/cinema-seat-reservation/internal/money"
+)
+
+// UpgradedSeat is one seat swapped by a reservation upgrade, with the
+// price paid for the old seat and the price of the new one.
+type UpgradedSeat struct {
+	FromSeatID     uint64 `json:"from_seat_id"`
+	ToSeatID       uint64 `json:"to_seat_id"`
+	FromPriceCents uint32 `json:"from_price_cents"`
+	ToPriceCents   uint32 `json:"to_price_cents"`
+}
+
+// ReservationUpgrade mirrors a row of the reservation_upgrades table.
+// DeltaCents is the amount added to the reservation's total: the seat
+// price difference plus FeesCents and TaxCents charged on it.  PaymentRef
+// is set when it was charged through the payment gateway.
+type ReservationUpgrade struct {
+	ID               uint64         `json:"id"`
+	ReservationID    uint64         `json:"reservation_id"`
+	UserID           uint64         `json:"user_id"`
+	ShowID           uint64         `json:"show_id"`
+	Seats            []UpgradedSeat `json:"seats"`
+	DeltaCents       uint32         `json:"price_difference_cents"`
+	FeesCents        uint32         `json:"fees_cents"`
+	TaxCents         uint32         `json:"tax_cents"`
+	TotalAmountCents uint32         `json:"total_amount_cents"` // the reservation's total after the upgrade
+	Currency         string         `json:"currency"`
+	PaymentRef       *string        `json:"payment_ref,omitempty"`
+	CreatedAt        time.Time      `json:"created_at"`
+}
+
+// UpgradableReservation is a reservation locked by LockForUpgradeTx.
+// SeatPrices maps its seats to the price paid for each; Fees are the
+// current fees of the show's cinema.
+type UpgradableReservation struct {
+	ID               uint64
+	UserID           uint64
+	ShowID           uint64
+	Status           string
+	StartsAt         time.Time
+	TotalAmountCents uint32
+	Currency         string
+	Fees             CinemaFees
+	SeatPrices       map[uint64]uint32
+}
+
+// LockForUpgradeTx locks a reservation row and returns it with its show's
+// start time, currency, cinema fees and seats.  It returns sql.ErrNoRows when the
+// reservation does not exist.
+func (r *ReservationRepo) LockForUpgradeTx(ctx context.Context, tx *sql.Tx, reservationID uint64) (*UpgradableReservation, error) {
+	const q = `SELECT r.id, r.user_id, r.show_id, r.status, s.starts_at, r.total_amount_cents,
+	                  COALESCE(r.currency, c.currency, ''), c.fee_type, COALESCE(c.fee_value, 0), COALESCE(c.tax_rate_bp, 0)
+	           FROM reservations r
+	           JOIN shows s ON s.id = r.show_id
+	           JOIN halls h ON h.id = s.hall_id
+	           LEFT JOIN cinemas c ON c.id = h.cinema_id
+	           WHERE r.id = ?
+	           FOR UPDATE`
+	var u UpgradableReservation
+	var feeType sql.NullString
+	if err := tx.QueryRowContext(ctx, q, reservationID).Scan(&u.ID, &u.UserID, &u.ShowID, &u.Status,
+		&u.StartsAt, &u.TotalAmountCents, &u.Currency, &feeType, &u.Fees.FeeValue, &u.Fees.TaxRateBP); err != nil {
+		return nil, err
+	}
+	if feeType.Valid {
+		u.Fees.FeeType = &feeType.String
+	}
+	u.StartsAt = u.StartsAt.UTC()
+	u.Currency = money.Or(u.Currency)
+	rows, err := tx.QueryContext(ctx, `SELECT seat_id, price_cents FROM reservation_seats WHERE reservation_id = ?`, reservationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	u.SeatPrices = make(map[uint64]uint32)
+	for rows.Next() {
+		var sid uint64
+		var price uint32
+		if err := rows.Scan(&sid, &price); err != nil {
+			return nil, err
+		}
+		u.SeatPrices[sid] = price
+	}
+	return &u, rows.Err()
+}
+
+// SeatTypesTx returns the seat_type of the given seats keyed by seat ID.
+// Seats that do not exist are absent from the map.
+func (r *ReservationRepo) SeatTypesTx(ctx context.Context, tx *sql.Tx, seatIDs []uint64) (map[uint64]string, error) {
+	result := make(map[uint64]string, len(seatIDs))
+	if len(seatIDs) == 0 {
+		return result, nil
+	}
+	placeholders := make([]string, len(seatIDs))
+	args := make([]interface{}, len(seatIDs))
+	for i, id := range seatIDs {
+		placeholders[i] = "?"
+		args[i] = id
+	}
+	rows, err := tx.QueryContext(ctx, `SELECT id, seat_type FROM seats WHERE id IN (`+strings.Join(placeholders, ",")+`)`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id uint64
+		var typ string
+		if err := rows.Scan(&id, &typ); err != nil {
+			return nil, err
+		}
+		result[id] = typ
+	}
+	return result, rows.Err()
+}
+
+// ApplyUpgradeTx moves the reservation's seats listed in u.Seats to their
+// new seats and prices, adds u.DeltaCents to the reservation's total (and
+// its parts to the subtotal, fees and tax) and
+// records the upgrade, populating u.ID.  The caller locks the reservation
+// and the seats and updates show_seats.
+func (r *ReservationRepo) ApplyUpgradeTx(ctx context.Context, tx *sql.Tx, u *ReservationUpgrade) error {
+	for _, s := range u.Seats {
+		if _, err := tx.ExecContext(ctx,
+			`UPDATE reservation_seats SET seat_id = ?, price_cents = ? WHERE reservation_id = ? AND seat_id = ?`,
+			s.ToSeatID, s.ToPriceCents, u.ReservationID, s.FromSeatID); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.ExecContext(ctx,
+		`UPDATE reservations
+		 SET subtotal_cents = subtotal_cents + ?, fees_cents = fees_cents + ?, tax_cents = tax_cents + ?,
+		     total_amount_cents = total_amount_cents + ?
+		 WHERE id = ?`,
+		u.DeltaCents-u.FeesCents-u.TaxCents, u.FeesCents, u.TaxCents, u.DeltaCents, u.ReservationID); err != nil {
+		return err
+	}
+	seats, err := json.Marshal(u.Seats)
+	if err != nil {
+		return err
+	}
+	res, err := tx.ExecContext(ctx,
+		`INSERT INTO reservation_upgrades (reservation_id, user_id, seats, delta_cents, currency) VALUES (?, ?, ?, ?, ?)`,
+		u.ReservationID, u.UserID, seats, u.DeltaCents, u.Currency)
+	if err != nil {
+		return err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	u.ID = uint64(id)
+	u.CreatedAt = time.Now().UTC()
+	return nil
+}
+
+// SetUpgradePaymentRefTx records the payment reference of the charge for
+// an upgrade's price difference.
+func (r *ReservationRepo) SetUpgradePaymentRefTx(ctx context.Context, tx *sql.Tx, upgradeID uint64, paymentRef string) error {
+	_, err := tx.ExecContext(ctx, `UPDATE reservation_upgrades SET payment_ref = ? WHERE id = ?`, paymentRef, upgradeID)
+	return err
+}
+
+// ChargedUpgrades returns the IDs of a reservation's upgrades whose price
+// difference was charged through the payment gateway.
+func (r *ReservationRepo) ChargedUpgrades(ctx context.Context, reservationID uint64) ([]uint64, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT id FROM reservation_upgrades WHERE reservation_id = ? AND payment_ref IS NOT NULL ORDER BY id`, reservationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var ids []uint64
+	for rows.Next() {
+		var id uint64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+// SchedulingWindow is how far ahead a cinema's shows may be scheduled:
+// at least MinLeadMinutes from now and at most MaxHorizonDays ahead.
+// Nil fields fall back to the server's defaults.
+type SchedulingWindow struct {
+	MinLeadMinutes *uint32 `json:"min_lead_minutes"`
+	MaxHorizonDays *uint32 `json:"max_horizon_days"`
+}
+
+// SchedulingWindowByHall returns the scheduling window of a hall's
+// cinema; halls without a cinema get an empty window.  It returns
+// ErrHallNotFound if there is no such hall.
+func (r *ShowRepo) SchedulingWindowByHall(ctx context.Context, hallID uint64) (*SchedulingWindow, error) {
+	var w SchedulingWindow
+	err := r.db.QueryRowContext(ctx,
+		`SELECT c.min_lead_min, c.max_horizon_days
+		 FROM halls h
+		 LEFT JOIN cinemas c ON c.id = h.cinema_id
+		 WHERE h.id = ?`, hallID).Scan(&w.MinLeadMinutes, &w.MaxHorizonDays)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrHallNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+// SchedulingWindow returns the scheduling window of a cinema owned by
+// ownerID, or ErrCinemaNotFound.
+func (r *CinemaRepo) SchedulingWindow(ctx context.Context, id, ownerID uint64) (*SchedulingWindow, error) {
+	var w SchedulingWindow
+	err := r.db.QueryRowContext(ctx,
+		`SELECT min_lead_min, max_horizon_days FROM cinemas WHERE id = ? AND owner_id = ?`, id, ownerID).
+		Scan(&w.MinLeadMinutes, &w.MaxHorizonDays)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrCinemaNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+// SetSchedulingWindow replaces the scheduling window of a cinema owned by
+// ownerID.  It returns ErrCinemaNotFound when the owner has no such
+// cinema.  Shows already scheduled are not affected.
+func (r *CinemaRepo) SetSchedulingWindow(ctx context.Context, id, ownerID uint64, w *SchedulingWindow) error {
+	if _, err := r.SchedulingWindow(ctx, id, ownerID); err != nil {
+		return err
+	}
+	// RowsAffected is 0 when nothing changed, so existence is checked above.
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE cinemas SET min_lead_min = ?, max_horizon_days = ?, updated_at = CURRENT_TIMESTAMP
+		 WHERE id = ? AND owner_id = ?`, w.MinLeadMinutes, w.MaxHorizonDays, id, ownerID)
+	return err
+}
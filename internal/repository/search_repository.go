@@ -0,0 +1,184 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+)
+
+// SearchParams narrows a public search.  Query matches show titles and
+// cinema names (case-insensitive substring).  Day, when non-zero, limits
+// shows to those starting on that UTC calendar day; Until, when also
+// non-zero, extends that to every UTC day from Day through Until.  City,
+// when non-empty, limits results to cinemas in that city and CinemaID,
+// when non-zero, to one cinema.  Limit/Offset paginate shows.
+type SearchParams struct {
+	Query    string
+	Day      time.Time
+	Until    time.Time
+	City     string
+	CinemaID uint64
+	Limit    int
+	Offset   int
+}
+
+// ShowSearchResult is a show hit with the hall and cinema it belongs to.
+// Times are in UTC; LocalStartTime is the start time in the cinema's time
+// zone.
+type ShowSearchResult struct {
+	ShowID         uint64    `json:"show_id"`
+	Title          string    `json:"title"`
+	StartTime      time.Time `json:"start_time"`
+	EndTime        time.Time `json:"end_time"`
+	LocalStartTime time.Time `json:"local_start_time"`
+	Timezone       string    `json:"timezone"`
+	HallID         uint64    `json:"hall_id"`
+	HallName       string    `json:"hall_name"`
+	CinemaID       *uint64   `json:"cinema_id,omitempty"`
+	CinemaName     *string   `json:"cinema_name,omitempty"`
+	City           *string   `json:"city,omitempty"`
+	Rating         *string   `json:"rating"`
+	Language       *string   `json:"language"`
+	Format         *string   `json:"format"`
+	MinAge         uint8     `json:"min_age"`
+}
+
+// CinemaSearchResult is a cinema whose name matched the query.
+type CinemaSearchResult struct {
+	ID   uint64  `json:"id"`
+	Name string  `json:"name"`
+	City *string `json:"city,omitempty"`
+}
+
+// SearchRepo runs the LIKE-based queries behind the public search endpoint.
+type SearchRepo struct {
+	db *sql.DB
+}
+
+// NewSearchRepo returns a new SearchRepo bound to the given database.
+func NewSearchRepo(db *sql.DB) *SearchRepo { return &SearchRepo{db: db} }
+
+// likeEscaper escapes LIKE wildcards.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// likePattern escapes LIKE wildcards in s and wraps it for a substring match.
+func likePattern(s string) string {
+	return "%" + likeEscaper.Replace(s) + "%"
+}
+
+// likePrefix escapes LIKE wildcards in s for a prefix match.
+func likePrefix(s string) string {
+	return likeEscaper.Replace(s) + "%"
+}
+
+// SearchShows returns scheduled shows matching p together with the total
+// number of matches (ignoring Limit/Offset).  A show matches the query
+// when its title or its cinema's name contains the query text.  Results
+// are ordered by start time.
+func (r *SearchRepo) SearchShows(ctx context.Context, p SearchParams) ([]ShowSearchResult, int, error) {
+	where := []string{"s.status = 'SCHEDULED'"}
+	args := []interface{}{}
+	if p.Query != "" {
+		pat := likePattern(p.Query)
+		where = append(where, "(s.title LIKE ? OR c.name LIKE ?)")
+		args = append(args, pat, pat)
+	}
+	if !p.Day.IsZero() {
+		start := p.Day.UTC().Truncate(24 * time.Hour)
+		end := start
+		if !p.Until.IsZero() {
+			end = p.Until.UTC().Truncate(24 * time.Hour)
+		}
+		where = append(where, "s.starts_at >= ? AND s.starts_at < ?")
+		args = append(args, start.Format("2006-01-02 15:04:05"), end.Add(24*time.Hour).Format("2006-01-02 15:04:05"))
+	}
+	if p.City != "" {
+		where = append(where, "c.city = ?")
+		args = append(args, p.City)
+	}
+	if p.CinemaID != 0 {
+		where = append(where, "c.id = ?")
+		args = append(args, p.CinemaID)
+	}
+	from := ` FROM shows s
+              JOIN halls h ON h.id = s.hall_id
+              LEFT JOIN cinemas c ON c.id = h.cinema_id
+              WHERE ` + strings.Join(where, " AND ")
+	var total int
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*)`+from, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+	q := `SELECT s.id, s.title, s.starts_at, s.ends_at, h.id, h.name, c.id, c.name, c.city, COALESCE(c.timezone, 'UTC'),
+	             s.rating, s.language, s.format, s.min_age` + from +
+		` ORDER BY s.starts_at, s.id LIMIT ? OFFSET ?`
+	rows, err := r.db.QueryContext(ctx, q, append(args, p.Limit, p.Offset)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+	out := make([]ShowSearchResult, 0)
+	for rows.Next() {
+		var res ShowSearchResult
+		var cinemaID sql.NullInt64
+		var cinemaName, city sql.NullString
+		if err := rows.Scan(&res.ShowID, &res.Title, &res.StartTime, &res.EndTime, &res.HallID, &res.HallName,
+			&cinemaID, &cinemaName, &city, &res.Timezone, &res.Rating, &res.Language, &res.Format, &res.MinAge); err != nil {
+			return nil, 0, err
+		}
+		loc, err := time.LoadLocation(res.Timezone)
+		if err != nil {
+			loc = time.UTC
+		}
+		res.LocalStartTime = res.StartTime.In(loc)
+		if cinemaID.Valid {
+			id := uint64(cinemaID.Int64)
+			res.CinemaID = &id
+		}
+		if cinemaName.Valid {
+			n := cinemaName.String
+			res.CinemaName = &n
+		}
+		if city.Valid {
+			cty := city.String
+			res.City = &cty
+		}
+		out = append(out, res)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	return out, total, nil
+}
+
+// SearchCinemas returns up to limit cinemas whose name contains query,
+// optionally restricted to a city.
+func (r *SearchRepo) SearchCinemas(ctx context.Context, query, city string, limit int) ([]CinemaSearchResult, error) {
+	q := `SELECT id, name, city FROM cinemas WHERE name LIKE ?`
+	args := []interface{}{likePattern(query)}
+	if city != "" {
+		q += ` AND city = ?`
+		args = append(args, city)
+	}
+	q += ` ORDER BY name, id LIMIT ?`
+	args = append(args, limit)
+	rows, err := r.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := make([]CinemaSearchResult, 0)
+	for rows.Next() {
+		var res CinemaSearchResult
+		var city sql.NullString
+		if err := rows.Scan(&res.ID, &res.Name, &city); err != nil {
+			return nil, err
+		}
+		if city.Valid {
+			cty := city.String
+			res.City = &cty
+		}
+		out = append(out, res)
+	}
+	return out, rows.Err()
+}
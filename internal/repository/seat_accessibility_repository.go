@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/iliyamo/cinema-seat-reservation/internal/cache"
+)
+
+// SeatAccessibility holds the accessibility attributes of a seat.  A seat
+// without a seat_accessibility row has all attributes false.
+type SeatAccessibility struct {
+	SeatID          uint64 `json:"seat_id"`
+	WheelchairSpace bool   `json:"wheelchair_space"`
+	CompanionSeat   bool   `json:"companion_seat"`
+	TransferSeat    bool   `json:"transfer_seat"`
+	StepFreeAccess  bool   `json:"step_free_access"`
+}
+
+// SeatAccessibilityRepo provides persistence for seat accessibility
+// attributes.
+type SeatAccessibilityRepo struct {
+	db *sql.DB
+}
+
+// NewSeatAccessibilityRepo returns a new SeatAccessibilityRepo bound to the
+// given database.
+func NewSeatAccessibilityRepo(db *sql.DB) *SeatAccessibilityRepo {
+	return &SeatAccessibilityRepo{db: db}
+}
+
+// ListByShow returns the accessibility attributes of the seats of a show,
+// keyed by seat ID.  Seats without attributes are absent from the map.
+func (r *SeatAccessibilityRepo) ListByShow(ctx context.Context, showID uint64) (map[uint64]SeatAccessibility, error) {
+	const q = `SELECT sa.seat_id, sa.wheelchair_space, sa.companion_seat, sa.transfer_seat, sa.step_free_access
+               FROM seat_accessibility sa
+               JOIN show_seats ss ON ss.seat_id = sa.seat_id
+               WHERE ss.show_id = ?`
+	rows, err := r.db.QueryContext(ctx, q, showID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := make(map[uint64]SeatAccessibility)
+	for rows.Next() {
+		var a SeatAccessibility
+		if err := rows.Scan(&a.SeatID, &a.WheelchairSpace, &a.CompanionSeat, &a.TransferSeat, &a.StepFreeAccess); err != nil {
+			return nil, err
+		}
+		out[a.SeatID] = a
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// UpsertBulk creates or replaces the attributes of the given seats in a
+// single statement.  Callers must verify seat ownership beforehand.
+func (r *SeatAccessibilityRepo) UpsertBulk(ctx context.Context, items []SeatAccessibility) error {
+	if len(items) == 0 {
+		return nil
+	}
+	query := `INSERT INTO seat_accessibility (seat_id, wheelchair_space, companion_seat, transfer_seat, step_free_access) VALUES `
+	args := make([]interface{}, 0, len(items)*5)
+	for i, a := range items {
+		if i > 0 {
+			query += ","
+		}
+		query += "(?, ?, ?, ?, ?)"
+		args = append(args, a.SeatID, a.WheelchairSpace, a.CompanionSeat, a.TransferSeat, a.StepFreeAccess)
+	}
+	query += ` ON DUPLICATE KEY UPDATE wheelchair_space = VALUES(wheelchair_space), companion_seat = VALUES(companion_seat),
+               transfer_seat = VALUES(transfer_seat), step_free_access = VALUES(step_free_access)`
+	_, err := r.db.ExecContext(ctx, query, args...)
+	if err == nil {
+		cache.CatalogChanged(ctx)
+	}
+	return err
+}
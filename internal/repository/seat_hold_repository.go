@@ -5,20 +5,26 @@ import (
 	"crypto/rand"
 	"database/sql"
 	"encoding/hex"
+	"errors"
+	"strings"
 	"time"
+
+	"github.com/iliyamo/cinema-seat-reservation/internal/cache"
 )
 
 // SeatHoldRecord represents the persistence model for a seat hold.  It is
 // used internally by the repository layer when creating and querying holds.
 // The exported model.SeatHold should be used for business logic.
 type SeatHoldRecord struct {
-	ID        uint64    // primary key of the seat_holds row
-	UserID    uint64    // user who holds the seat; must be non-zero for authenticated holds
-	ShowID    uint64    // show to which this seat belongs
-	SeatID    uint64    // seat being held
-	HoldToken string    // opaque token returned to the client for correlation
-	ExpiresAt time.Time // expiration timestamp
-	CreatedAt time.Time // creation timestamp
+	ID         uint64    // primary key of the seat_holds row
+	UserID     uint64    // user who holds the seat; must be non-zero for authenticated holds
+	ShowID     uint64    // show to which this seat belongs
+	SeatID     uint64    // seat being held
+	HoldToken  string    // opaque token returned to the client for correlation
+	PriceCents *uint32   // price quoted at hold time; nil for holds created before prices were captured
+	Currency   *string   // currency PriceCents is in; nil when no price was captured or for older holds
+	ExpiresAt  time.Time // expiration timestamp
+	CreatedAt  time.Time // creation timestamp
 }
 
 // SeatHoldRepo provides data access to the seat_holds table.  It is
@@ -35,28 +41,37 @@ func NewSeatHoldRepo(db *sql.DB) *SeatHoldRepo { return &SeatHoldRepo{db: db} }
 // ExpireHoldsTx removes all seat holds for a given show that have expired and
 // returns the seat IDs whose holds were removed.  A hold is considered
 // expired when its expires_at timestamp is less than or equal to the current
-// UTC time.  The caller must supply an existing transaction and is
+// UTC time.  Because expires_at is fixed when the hold is created from the
+// effective TTL (per-show hold_ttl_sec or the configured default), no TTL
+// lookup is needed here.  The caller must supply an existing transaction and is
 // responsible for committing or rolling back the transaction.  After
 // calling ExpireHoldsTx, callers should update the corresponding
 // show_seats.status values back to "FREE" for the returned seat IDs.
 //
+// The expired rows are locked with FOR UPDATE and deleted by ID, so when
+// several requests or instances expire the same show at once the later
+// ones wait for the first to commit and then find nothing left to expire;
+// each hold is released exactly once.
+//
 // When there are no expired holds, it returns an empty slice and nil error.
 func (r *SeatHoldRepo) ExpireHoldsTx(ctx context.Context, tx *sql.Tx, showID uint64) ([]uint64, error) {
-	// Query all seat IDs with expired holds for this show.
+	// Lock all expired holds for this show.
 	rows, err := tx.QueryContext(ctx,
-		`SELECT seat_id FROM seat_holds WHERE show_id = ? AND expires_at <= UTC_TIMESTAMP()`,
+		`SELECT id, seat_id FROM seat_holds WHERE show_id = ? AND expires_at <= UTC_TIMESTAMP() FOR UPDATE`,
 		showID,
 	)
 	if err != nil {
 		return nil, err
 	}
+	var ids []interface{}
 	var expiredSeatIDs []uint64
 	for rows.Next() {
-		var sid uint64
-		if scanErr := rows.Scan(&sid); scanErr != nil {
+		var id, sid uint64
+		if scanErr := rows.Scan(&id, &sid); scanErr != nil {
 			rows.Close()
 			return nil, scanErr
 		}
+		ids = append(ids, id)
 		expiredSeatIDs = append(expiredSeatIDs, sid)
 	}
 	if err = rows.Close(); err != nil {
@@ -65,15 +80,53 @@ func (r *SeatHoldRepo) ExpireHoldsTx(ctx context.Context, tx *sql.Tx, showID uin
 	if len(expiredSeatIDs) == 0 {
 		return []uint64{}, nil
 	}
-	// Delete expired holds.
-	_, err = tx.ExecContext(ctx,
-		`DELETE FROM seat_holds WHERE show_id = ? AND expires_at <= UTC_TIMESTAMP()`,
-		showID,
+	// Delete exactly the holds that were locked.
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(ids)), ",")
+	if _, err = tx.ExecContext(ctx, `DELETE FROM seat_holds WHERE id IN (`+placeholders+`)`, ids...); err != nil {
+		return nil, err
+	}
+	cache.ShowChanged(ctx, showID)
+	return expiredSeatIDs, nil
+}
+
+// ExpireAllHoldsTx removes every expired seat hold regardless of show and
+// returns the released seat IDs grouped by show ID.  The expired rows are
+// locked with FOR UPDATE before deletion so that a concurrent
+// ExpireHoldsTx for the same show waits instead of racing.  Callers must
+// update show_seats back to "FREE" for the returned seats within the same
+// transaction.
+func (r *SeatHoldRepo) ExpireAllHoldsTx(ctx context.Context, tx *sql.Tx) (map[uint64][]uint64, error) {
+	rows, err := tx.QueryContext(ctx,
+		`SELECT id, show_id, seat_id FROM seat_holds WHERE expires_at <= UTC_TIMESTAMP() FOR UPDATE`,
 	)
 	if err != nil {
 		return nil, err
 	}
-	return expiredSeatIDs, nil
+	released := make(map[uint64][]uint64)
+	var ids []interface{}
+	for rows.Next() {
+		var id, showID, seatID uint64
+		if scanErr := rows.Scan(&id, &showID, &seatID); scanErr != nil {
+			rows.Close()
+			return nil, scanErr
+		}
+		ids = append(ids, id)
+		released[showID] = append(released[showID], seatID)
+	}
+	if err = rows.Close(); err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return released, nil
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(ids)), ",")
+	if _, err = tx.ExecContext(ctx, `DELETE FROM seat_holds WHERE id IN (`+placeholders+`)`, ids...); err != nil {
+		return nil, err
+	}
+	for showID := range released {
+		cache.ShowChanged(ctx, showID)
+	}
+	return released, nil
 }
 
 // randomToken generates a random hexadecimal string of length n*2 bytes.
@@ -91,23 +144,35 @@ func randomToken(n int) (string, error) {
 
 // CreateMultipleTx inserts multiple seat_holds within the provided
 // transaction.  Each hold must specify ShowID, SeatID, UserID, HoldToken
-// and ExpiresAt.  The CreatedAt column is automatically set by the
+// and ExpiresAt; PriceCents and Currency are optional.  The CreatedAt column is automatically set by the
 // database.  The caller is responsible for committing or rolling back
 // the transaction.  Passing an empty slice has no effect and returns nil.
 func (r *SeatHoldRepo) CreateMultipleTx(ctx context.Context, tx *sql.Tx, holds []SeatHoldRecord) error {
 	if len(holds) == 0 {
 		return nil
 	}
-	query := `INSERT INTO seat_holds (user_id, show_id, seat_id, hold_token, expires_at) VALUES `
-	args := make([]interface{}, 0, len(holds)*5)
+	query := `INSERT INTO seat_holds (user_id, show_id, seat_id, hold_token, price_cents, currency, expires_at) VALUES `
+	args := make([]interface{}, 0, len(holds)*7)
 	for i, h := range holds {
 		if i > 0 {
 			query += ","
 		}
-		query += "(?, ?, ?, ?, ?)"
-		args = append(args, h.UserID, h.ShowID, h.SeatID, h.HoldToken, h.ExpiresAt.UTC().Format("2006-01-02 15:04:05"))
+		query += "(?, ?, ?, ?, ?, ?, ?)"
+		var price, currency interface{}
+		if h.PriceCents != nil {
+			price = *h.PriceCents
+		}
+		if h.Currency != nil {
+			currency = *h.Currency
+		}
+		args = append(args, h.UserID, h.ShowID, h.SeatID, h.HoldToken, price, currency, h.ExpiresAt.UTC().Format("2006-01-02 15:04:05"))
 	}
 	_, err := tx.ExecContext(ctx, query, args...)
+	if err == nil {
+		for _, h := range holds {
+			cache.ShowChanged(ctx, h.ShowID)
+		}
+	}
 	return err
 }
 
@@ -137,21 +202,98 @@ func (r *SeatHoldRepo) DeleteByUserAndShowTx(ctx context.Context, tx *sql.Tx, us
 	if _, err = tx.ExecContext(ctx, `DELETE FROM seat_holds WHERE user_id = ? AND show_id = ?`, userID, showID); err != nil {
 		return nil, err
 	}
+	cache.ShowChanged(ctx, showID)
 	return seatIDs, nil
 }
 
+// DeleteByShowTx removes every hold of a show, expired or not, inside tx
+// and returns how many were removed.  It is used when the show is
+// cancelled; callers free the seats themselves.
+func (r *SeatHoldRepo) DeleteByShowTx(ctx context.Context, tx *sql.Tx, showID uint64) (int64, error) {
+	res, err := tx.ExecContext(ctx, `DELETE FROM seat_holds WHERE show_id = ?`, showID)
+	if err != nil {
+		return 0, err
+	}
+	cache.ShowChanged(ctx, showID)
+	return res.RowsAffected()
+}
+
+// DeleteByIDsTx removes the given seat_holds rows inside tx.  It does not
+// know their shows; callers that release seats this way also update
+// show_seats, which invalidates cached seat maps.
+func (r *SeatHoldRepo) DeleteByIDsTx(ctx context.Context, tx *sql.Tx, ids []uint64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	placeholders := make([]string, len(ids))
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		placeholders[i] = "?"
+		args[i] = id
+	}
+	_, err := tx.ExecContext(ctx, `DELETE FROM seat_holds WHERE id IN (`+strings.Join(placeholders, ",")+`)`, args...)
+	return err
+}
+
 // ActiveHoldsByUserAndShowTx retrieves all non-expired seat holds for a
 // particular user and show.  The returned slice contains complete hold
 // records.  Use this when confirming a reservation to ensure the seats
 // are still held and have not expired.  The query is executed within
 // the provided transaction to support locking if desired via SELECT ... FOR UPDATE.
 func (r *SeatHoldRepo) ActiveHoldsByUserAndShowTx(ctx context.Context, tx *sql.Tx, userID, showID uint64) ([]SeatHoldRecord, error) {
-	const q = `SELECT id, user_id, show_id, seat_id, hold_token, expires_at, created_at
+	return activeHoldsByUserAndShow(ctx, tx, userID, showID)
+}
+
+// ActiveHoldsByUserAndShow is the non-transactional variant of
+// ActiveHoldsByUserAndShowTx for read-only callers such as the checkout
+// session endpoint.
+func (r *SeatHoldRepo) ActiveHoldsByUserAndShow(ctx context.Context, userID, showID uint64) ([]SeatHoldRecord, error) {
+	return activeHoldsByUserAndShow(ctx, r.db, userID, showID)
+}
+
+// ActiveHoldsByUser returns all of a user's non-expired holds across
+// shows, ordered by show and seat.
+func (r *SeatHoldRepo) ActiveHoldsByUser(ctx context.Context, userID uint64) ([]SeatHoldRecord, error) {
+	return queryHolds(ctx, r.db, `SELECT id, user_id, show_id, seat_id, hold_token, price_cents, currency, expires_at, created_at
+               FROM seat_holds
+               WHERE user_id = ? AND expires_at > UTC_TIMESTAMP()
+               ORDER BY show_id, seat_id`, userID)
+}
+
+// LockActiveHoldsByUserTx locks the user's row and returns all of the
+// user's non-expired holds across shows, ordered by show and seat.  The
+// lock is held until tx ends, so concurrent hold requests of one user are
+// serialised and a limit checked against the result cannot be exceeded
+// by racing requests.
+func (r *SeatHoldRepo) LockActiveHoldsByUserTx(ctx context.Context, tx *sql.Tx, userID uint64) ([]SeatHoldRecord, error) {
+	var id uint64
+	if err := tx.QueryRowContext(ctx, `SELECT id FROM users WHERE id = ? FOR UPDATE`, userID).Scan(&id); err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	return queryHolds(ctx, tx, `SELECT id, user_id, show_id, seat_id, hold_token, price_cents, currency, expires_at, created_at
                FROM seat_holds
-               WHERE user_id = ? AND show_id = ? AND expires_at > UTC_TIMESTAMP()`
+               WHERE user_id = ? AND expires_at > UTC_TIMESTAMP()
+               ORDER BY show_id, seat_id`, userID)
+}
+
+// holdQueryer is satisfied by both *sql.DB and *sql.Tx.
+type holdQueryer interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+func activeHoldsByUserAndShow(ctx context.Context, q holdQueryer, userID, showID uint64) ([]SeatHoldRecord, error) {
+	const sel = `SELECT id, user_id, show_id, seat_id, hold_token, price_cents, currency, expires_at, created_at
+               FROM seat_holds
+               WHERE user_id = ? AND show_id = ? AND expires_at > UTC_TIMESTAMP()
+               ORDER BY seat_id`
 	// Note: not using FOR UPDATE here; callers can append "FOR UPDATE" if
 	// locking is required.  Some DBs disallow FOR UPDATE with DISTINCT or JOIN.
-	rows, err := tx.QueryContext(ctx, q, userID, showID)
+	return queryHolds(ctx, q, sel, userID, showID)
+}
+
+// queryHolds scans complete hold records selected by query.
+func queryHolds(ctx context.Context, q holdQueryer, query string, args ...interface{}) ([]SeatHoldRecord, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -159,9 +301,16 @@ func (r *SeatHoldRepo) ActiveHoldsByUserAndShowTx(ctx context.Context, tx *sql.T
 	var holds []SeatHoldRecord
 	for rows.Next() {
 		var h SeatHoldRecord
-		if err := rows.Scan(&h.ID, &h.UserID, &h.ShowID, &h.SeatID, &h.HoldToken, &h.ExpiresAt, &h.CreatedAt); err != nil {
+		var price sql.NullInt64
+		var currency sql.NullString
+		if err := rows.Scan(&h.ID, &h.UserID, &h.ShowID, &h.SeatID, &h.HoldToken, &price, &currency, &h.ExpiresAt, &h.CreatedAt); err != nil {
 			return nil, err
 		}
+		if price.Valid {
+			p := uint32(price.Int64)
+			h.PriceCents = &p
+		}
+		h.Currency = nullStringPtr(currency)
 		holds = append(holds, h)
 	}
 	if err := rows.Err(); err != nil {
@@ -170,6 +319,54 @@ func (r *SeatHoldRepo) ActiveHoldsByUserAndShowTx(ctx context.Context, tx *sql.T
 	return holds, nil
 }
 
+// ExtendByUserAndShowTx pushes the expiry of the user's active holds for a
+// show forward by increment, capping each hold at maxTotal after its
+// creation time.  The holds are locked with FOR UPDATE so the extension
+// cannot race with expiry cleanup.  It returns the holds with their new
+// expiry and whether at least one of them was actually extended (false
+// when every hold has already reached its cap).
+func (r *SeatHoldRepo) ExtendByUserAndShowTx(ctx context.Context, tx *sql.Tx, userID, showID uint64, increment, maxTotal time.Duration) ([]SeatHoldRecord, bool, error) {
+	rows, err := tx.QueryContext(ctx,
+		`SELECT id, seat_id, expires_at, created_at FROM seat_holds
+         WHERE user_id = ? AND show_id = ? AND expires_at > UTC_TIMESTAMP()
+         ORDER BY id FOR UPDATE`,
+		userID, showID,
+	)
+	if err != nil {
+		return nil, false, err
+	}
+	var holds []SeatHoldRecord
+	for rows.Next() {
+		h := SeatHoldRecord{UserID: userID, ShowID: showID}
+		if scanErr := rows.Scan(&h.ID, &h.SeatID, &h.ExpiresAt, &h.CreatedAt); scanErr != nil {
+			rows.Close()
+			return nil, false, scanErr
+		}
+		holds = append(holds, h)
+	}
+	if err = rows.Close(); err != nil {
+		return nil, false, err
+	}
+	extended := false
+	for i := range holds {
+		h := &holds[i]
+		newExp := h.ExpiresAt.Add(increment)
+		if limit := h.CreatedAt.Add(maxTotal); newExp.After(limit) {
+			newExp = limit
+		}
+		if !newExp.After(h.ExpiresAt) {
+			continue
+		}
+		if _, err = tx.ExecContext(ctx, `UPDATE seat_holds SET expires_at = ? WHERE id = ?`,
+			newExp.UTC().Format("2006-01-02 15:04:05"), h.ID); err != nil {
+			return nil, false, err
+		}
+		h.ExpiresAt = newExp
+		extended = true
+	}
+	return holds, extended, nil
+}
+
 // GenerateHoldRecords builds seat hold records for the given user, show and
 // seat IDs.  A new random token is generated for each seat.  The
 // expiration is set to the provided timestamp.  This helper can be used
@@ -191,3 +388,82 @@ func GenerateHoldRecords(userID, showID uint64, seatIDs []uint64, expiresAt time
 	}
 	return holds, nil
 }
+
+// ErrHoldNotFound is returned by ReleaseByIDTx when the show has no hold
+// with the given ID.
+var ErrHoldNotFound = errors.New("hold not found")
+
+// ShowHold is an active hold of a show as owners see it: the held seat
+// and the user holding it.  UserID and Email are nil for holds whose user
+// was deleted.
+type ShowHold struct {
+	ID         uint64    `json:"hold_id"`
+	UserID     *uint64   `json:"user_id"`
+	Email      *string   `json:"email"`
+	SeatID     uint64    `json:"seat_id"`
+	RowLabel   string    `json:"row_label"`
+	SeatNumber uint32    `json:"seat_number"`
+	PriceCents *uint32   `json:"price_cents"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// ActiveHoldsByShow returns the unexpired holds of a show, ordered by
+// user and seat.
+func (r *SeatHoldRepo) ActiveHoldsByShow(ctx context.Context, showID uint64) ([]ShowHold, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT h.id, h.user_id, u.email, h.seat_id, s.row_label, s.seat_number, h.price_cents, h.expires_at, h.created_at
+		 FROM seat_holds h
+		 JOIN seats s ON s.id = h.seat_id
+		 LEFT JOIN users u ON u.id = h.user_id
+		 WHERE h.show_id = ? AND h.expires_at > UTC_TIMESTAMP()
+		 ORDER BY h.user_id, s.row_label, s.seat_number`, showID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	holds := []ShowHold{}
+	for rows.Next() {
+		var (
+			h      ShowHold
+			userID sql.NullInt64
+			email  sql.NullString
+			price  sql.NullInt64
+		)
+		if err := rows.Scan(&h.ID, &userID, &email, &h.SeatID, &h.RowLabel, &h.SeatNumber, &price, &h.ExpiresAt, &h.CreatedAt); err != nil {
+			return nil, err
+		}
+		if userID.Valid {
+			id := uint64(userID.Int64)
+			h.UserID = &id
+		}
+		h.Email = nullStringPtr(email)
+		if price.Valid {
+			p := uint32(price.Int64)
+			h.PriceCents = &p
+		}
+		holds = append(holds, h)
+	}
+	return holds, rows.Err()
+}
+
+// ReleaseByIDTx locks and deletes one hold of a show inside tx, expired
+// or not, and returns it.  It returns ErrHoldNotFound when the show has
+// no such hold.  Callers free the seat themselves.
+func (r *SeatHoldRepo) ReleaseByIDTx(ctx context.Context, tx *sql.Tx, showID, holdID uint64) (*SeatHoldRecord, error) {
+	holds, err := queryHolds(ctx, tx, `SELECT id, COALESCE(user_id, 0), show_id, seat_id, hold_token, price_cents, currency, expires_at, created_at
+               FROM seat_holds
+               WHERE id = ? AND show_id = ?
+               FOR UPDATE`, holdID, showID)
+	if err != nil {
+		return nil, err
+	}
+	if len(holds) == 0 {
+		return nil, ErrHoldNotFound
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM seat_holds WHERE id = ?`, holdID); err != nil {
+		return nil, err
+	}
+	cache.ShowChanged(ctx, showID)
+	return &holds[0], nil
+}
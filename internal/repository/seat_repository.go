@@ -4,6 +4,10 @@ import (
 	"context"      // context allows query cancellation and timeouts
 	"database/sql" // sql provides DB primitives
 	"errors"       // errors for sentinel definitions
+	"strings"      // strings builds the IN list of bulk updates
+	"time"         // time holds row timestamps
+
+	"github.com/iliyamo/cinema-seat-reservation/internal/cache"
 )
 
 // Seat represents a physical seat within a hall. RowLabel and
@@ -15,8 +19,8 @@ type Seat struct {
 	SeatNumber uint32 // position in the row (1-based)
 	SeatType   string // STANDARD | VIP | ACCESSIBLE
 	IsActive   bool   // soft availability flag (not reservation)
-	CreatedAt  string
-	UpdatedAt  string
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
 }
 
 // ErrSeatNotFound is returned when a seat lookup yields no rows.
@@ -45,6 +49,7 @@ func (r *SeatRepo) Create(ctx context.Context, s *Seat) error {
 		return err
 	}
 	s.ID = uint64(id)
+	cache.CatalogChanged(ctx)
 	return nil
 }
 
@@ -63,14 +68,19 @@ func (r *SeatRepo) CreateBulk(ctx context.Context, seats []Seat) error {
 		args = append(args, seat.HallID, seat.RowLabel, seat.SeatNumber, seat.SeatType)
 	}
 	_, err := r.db.ExecContext(ctx, query, args...)
+	if err == nil {
+		cache.CatalogChanged(ctx)
+	}
 	return err
 }
 
-// GetByHall retrieves all seats of a hall ordered by row_label then seat_number.
+// GetByHall retrieves the current seats of a hall ordered by row_label
+// then seat_number.  Seats retired by an archived grid rebuild are left
+// out.
 func (r *SeatRepo) GetByHall(ctx context.Context, hallID uint64) ([]Seat, error) {
 	const q = `SELECT id, hall_id, row_label, seat_number, seat_type, is_active, created_at, updated_at
 	           FROM seats
-	           WHERE hall_id = ?
+	           WHERE hall_id = ? AND valid_to IS NULL
 	           ORDER BY row_label, seat_number`
 	rows, err := r.db.QueryContext(ctx, q, hallID)
 	if err != nil {
@@ -111,12 +121,13 @@ func (r *SeatRepo) GetByID(ctx context.Context, id uint64) (*Seat, error) {
 	return &s, nil
 }
 
-// GetByIDAndOwner retrieves a seat by its id while enforcing ownership via halls.
+// GetByIDAndOwner retrieves a current seat by its id while enforcing
+// ownership via halls; retired seats are not found.
 func (r *SeatRepo) GetByIDAndOwner(ctx context.Context, id, ownerID uint64) (*Seat, error) {
 	const q = `SELECT s.id, s.hall_id, s.row_label, s.seat_number, s.seat_type, s.is_active, s.created_at, s.updated_at
 	           FROM seats s
 	           JOIN halls h ON h.id = s.hall_id
-	           WHERE s.id = ? AND h.owner_id = ?`
+	           WHERE s.id = ? AND h.owner_id = ? AND s.valid_to IS NULL`
 	var s Seat
 	err := r.db.QueryRowContext(ctx, q, id, ownerID).
 		Scan(&s.ID, &s.HallID, &s.RowLabel, &s.SeatNumber, &s.SeatType, &s.IsActive, &s.CreatedAt, &s.UpdatedAt)
@@ -135,7 +146,7 @@ func (r *SeatRepo) UpdateByIDAndOwner(ctx context.Context, id, ownerID uint64, r
 	const q = `UPDATE seats s
 	           JOIN halls h ON h.id = s.hall_id
 	           SET s.row_label = ?, s.seat_number = ?, s.is_active = ?, s.updated_at = CURRENT_TIMESTAMP
-	           WHERE s.id = ? AND h.owner_id = ?`
+	           WHERE s.id = ? AND h.owner_id = ? AND s.valid_to IS NULL`
 	res, err := r.db.ExecContext(ctx, q, rowLabel, seatNumber, isActive, id, ownerID)
 	if err != nil {
 		return err
@@ -143,6 +154,7 @@ func (r *SeatRepo) UpdateByIDAndOwner(ctx context.Context, id, ownerID uint64, r
 	if n, _ := res.RowsAffected(); n == 0 {
 		return sql.ErrNoRows
 	}
+	cache.CatalogChanged(ctx)
 	return nil
 }
 
@@ -152,7 +164,7 @@ func (r *SeatRepo) UpdateWithTypeByIDAndOwner(ctx context.Context, id, ownerID u
 	const q = `UPDATE seats s
 	           JOIN halls h ON h.id = s.hall_id
 	           SET s.row_label = ?, s.seat_number = ?, s.seat_type = ?, s.is_active = ?, s.updated_at = CURRENT_TIMESTAMP
-	           WHERE s.id = ? AND h.owner_id = ?`
+	           WHERE s.id = ? AND h.owner_id = ? AND s.valid_to IS NULL`
 	res, err := r.db.ExecContext(ctx, q, rowLabel, seatNumber, seatType, isActive, id, ownerID)
 	if err != nil {
 		return err
@@ -160,6 +172,7 @@ func (r *SeatRepo) UpdateWithTypeByIDAndOwner(ctx context.Context, id, ownerID u
 	if n, _ := res.RowsAffected(); n == 0 {
 		return sql.ErrNoRows
 	}
+	cache.CatalogChanged(ctx)
 	return nil
 }
 
@@ -180,6 +193,7 @@ func (r *SeatRepo) DeleteByIDAndOwner(ctx context.Context, id, ownerID uint64) e
 	if n, _ := res.RowsAffected(); n == 0 {
 		return sql.ErrNoRows
 	}
+	cache.CatalogChanged(ctx)
 	return nil
 }
 
@@ -191,5 +205,43 @@ func (r *SeatRepo) DeleteByHall(ctx context.Context, hallID uint64) error {
     // Construct a simple delete statement to remove all seat rows for the hall.
     const q = `DELETE FROM seats WHERE hall_id = ?` // query deleting seats by hall
     _, err := r.db.ExecContext(ctx, q, hallID)      // execute deletion
+    if err == nil {
+        cache.CatalogChanged(ctx)
+    }
     return err                                      // return any error encountered
 }
+
+// UpdateBulkTx sets the seat type and/or active flag of the seats ids of
+// hall hallID inside tx; a nil seatType or isActive leaves that column
+// alone.  It returns the number of seats that actually changed.
+func (r *SeatRepo) UpdateBulkTx(ctx context.Context, tx *sql.Tx, hallID uint64, ids []uint64, seatType *string, isActive *bool) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	var sb strings.Builder
+	sb.WriteString(`UPDATE seats SET seat_type = COALESCE(?, seat_type), is_active = COALESCE(?, is_active), updated_at = CURRENT_TIMESTAMP
+	                WHERE hall_id = ? AND valid_to IS NULL AND id IN (`)
+	args := make([]interface{}, 0, len(ids)+3)
+	args = append(args, seatType, isActive, hallID)
+	for i, id := range ids {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteByte('?')
+		args = append(args, id)
+	}
+	sb.WriteString(`) AND (seat_type <> COALESCE(?, seat_type) OR is_active <> COALESCE(?, is_active))`)
+	args = append(args, seatType, isActive)
+	res, err := tx.ExecContext(ctx, sb.String(), args...)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if n > 0 {
+		cache.CatalogChanged(ctx)
+	}
+	return n, nil
+}
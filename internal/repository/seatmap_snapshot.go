@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+// Reasons a seat map snapshot was taken.
+const (
+	SnapshotCreated     = "created"      // the show was scheduled
+	SnapshotHallRebuilt = "hall_rebuilt" // the hall's seat grid or layout was rebuilt
+	SnapshotHallChanged = "hall_changed" // the show moved to another hall
+)
+
+// SnapshotSeat is one seat of a seat map snapshot.
+type SnapshotSeat struct {
+	SeatID     uint64 `json:"seat_id"`
+	RowLabel   string `json:"row_label"`
+	SeatNumber uint32 `json:"seat_number"`
+	SeatType   string `json:"seat_type"`
+	Status     string `json:"status"`
+	PriceCents uint32 `json:"price_cents"`
+}
+
+// SeatMapSnapshot is an immutable copy of a show's seat map.
+type SeatMapSnapshot struct {
+	ShowID    uint64         `json:"show_id"`
+	Version   int            `json:"version"`
+	Reason    string         `json:"reason"`
+	SeatCount int            `json:"seat_count"`
+	CreatedAt time.Time      `json:"created_at"`
+	Seats     []SnapshotSeat `json:"seats"`
+}
+
+// SnapshotTx records the current show seats of showID as the show's next
+// seat map snapshot inside tx, so it commits together with the change
+// that produced the seat map.
+func (r *ShowSeatRepo) SnapshotTx(ctx context.Context, tx *sql.Tx, showID uint64, reason string) error {
+	rows, err := tx.QueryContext(ctx,
+		`SELECT ss.seat_id, s.row_label, s.seat_number, s.seat_type, ss.status, ss.price_cents
+		 FROM show_seats ss
+		 JOIN seats s ON s.id = ss.seat_id
+		 WHERE ss.show_id = ?
+		 ORDER BY s.row_label, s.seat_number`, showID)
+	if err != nil {
+		return err
+	}
+	seats := make([]SnapshotSeat, 0)
+	for rows.Next() {
+		var s SnapshotSeat
+		if err := rows.Scan(&s.SeatID, &s.RowLabel, &s.SeatNumber, &s.SeatType, &s.Status, &s.PriceCents); err != nil {
+			rows.Close()
+			return err
+		}
+		seats = append(seats, s)
+	}
+	if err := rows.Close(); err != nil {
+		return err
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	raw, err := json.Marshal(seats)
+	if err != nil {
+		return err
+	}
+	// The show row was created or updated earlier in tx, so concurrent
+	// snapshots of the same show are serialised by its lock.
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO show_seatmap_snapshots (show_id, version, reason, seat_count, seats)
+		 SELECT ?, COALESCE(MAX(version), 0) + 1, ?, ?, ? FROM show_seatmap_snapshots WHERE show_id = ?`,
+		showID, reason, len(seats), jsonArg(raw), showID)
+	return err
+}
+
+// ListSnapshots returns the seat map snapshots of a show, oldest first.
+// version > 0 selects a single version.
+func (r *ShowSeatRepo) ListSnapshots(ctx context.Context, showID uint64, version int) ([]SeatMapSnapshot, error) {
+	q := `SELECT show_id, version, reason, seat_count, created_at, seats FROM show_seatmap_snapshots WHERE show_id = ?`
+	args := []interface{}{showID}
+	if version > 0 {
+		q += ` AND version = ?`
+		args = append(args, version)
+	}
+	rows, err := r.db.QueryContext(ctx, q+` ORDER BY version`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := make([]SeatMapSnapshot, 0)
+	for rows.Next() {
+		var s SeatMapSnapshot
+		var raw []byte
+		if err := rows.Scan(&s.ShowID, &s.Version, &s.Reason, &s.SeatCount, &s.CreatedAt, &raw); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(raw, &s.Seats); err != nil {
+			return nil, err
+		}
+		out = append(out, s)
+	}
+	return out, rows.Err()
+}
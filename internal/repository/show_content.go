@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/iliyamo/cinema-seat-reservation/internal/cache"
+)
+
+// ShowContent is the content metadata of a show.  MinAge is the age a
+// customer must have reached when the show starts; zero means the show is
+// not age-restricted.
+type ShowContent struct {
+	ShowID   uint64  `json:"show_id"`
+	Rating   *string `json:"rating"`   // for example PG-13 or 18+
+	Language *string `json:"language"` // spoken language as a BCP 47 tag, for example en or fa-IR
+	Format   *string `json:"format"`   // 2D, 3D or IMAX
+	MinAge   uint8   `json:"min_age"`
+}
+
+// Content returns the content metadata of a show.  It returns
+// ErrShowNotFound if there is no such show.
+func (r *ShowRepo) Content(ctx context.Context, id uint64) (*ShowContent, error) {
+	sc := &ShowContent{ShowID: id}
+	err := r.db.QueryRowContext(ctx,
+		`SELECT rating, language, format, min_age FROM shows WHERE id = ?`, id).
+		Scan(&sc.Rating, &sc.Language, &sc.Format, &sc.MinAge)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrShowNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return sc, nil
+}
+
+// SetContent replaces the content metadata of the show sc.ShowID.  The
+// fields appear in show details and search results, so a successful
+// update drops the whole response cache.
+func (r *ShowRepo) SetContent(ctx context.Context, sc *ShowContent) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE shows SET rating = ?, language = ?, format = ?, min_age = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`,
+		sc.Rating, sc.Language, sc.Format, sc.MinAge, sc.ShowID)
+	if err != nil {
+		return err
+	}
+	cache.CatalogChanged(ctx)
+	return nil
+}
+
+// AgeRestrictionTx returns the minimum age of a show's audience and the
+// show's start.
+func (r *ShowRepo) AgeRestrictionTx(ctx context.Context, tx *sql.Tx, id uint64) (uint8, time.Time, error) {
+	var minAge uint8
+	var startsAt time.Time
+	err := tx.QueryRowContext(ctx, `SELECT min_age, starts_at FROM shows WHERE id = ?`, id).Scan(&minAge, &startsAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, time.Time{}, ErrShowNotFound
+	}
+	return minAge, startsAt, err
+}
+
+// DateOfBirth returns the user's date of birth, nil when it was never
+// given.  It returns sql.ErrNoRows when no such user exists.
+func (r *UserRepo) DateOfBirth(ctx context.Context, id uint64) (*time.Time, error) {
+	var dob sql.NullTime
+	if err := r.DB.QueryRowContext(ctx, `SELECT date_of_birth FROM users WHERE id = ?`, id).Scan(&dob); err != nil {
+		return nil, err
+	}
+	if !dob.Valid {
+		return nil, nil
+	}
+	return &dob.Time, nil
+}
+
+// SetDateOfBirth records the user's date of birth; nil removes it.
+func (r *UserRepo) SetDateOfBirth(ctx context.Context, id uint64, dob *time.Time) error {
+	var v interface{}
+	if dob != nil {
+		v = dob.Format("2006-01-02")
+	}
+	_, err := r.DB.ExecContext(ctx, `UPDATE users SET date_of_birth = ? WHERE id = ?`, v, id)
+	return err
+}
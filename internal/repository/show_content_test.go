@@ -0,0 +1,50 @@
+package repository_test
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/iliyamo/cinema-seat-reservation/internal/cache"
+	"github.com/iliyamo/cinema-seat-reservation/internal/repository"
+	"github.com/iliyamo/cinema-seat-reservation/internal/repository/mock"
+)
+
+// TestSetContentDropsCachedResponses checks that a content update drops
+// the cached show details and search results, and that a failed update
+// leaves them.
+func TestSetContentDropsCachedResponses(t *testing.T) {
+	store := cache.New(time.Minute)
+	cache.Enable(store)
+	defer cache.Enable(nil)
+
+	for _, tc := range []struct {
+		name    string
+		execErr error
+		cached  bool
+	}{
+		{"updated", nil, false},
+		{"failed", errors.New("connection lost"), true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			store.Set("/v1/shows/3", cache.Entry{}, 3)
+			store.Set("/v1/search?q=dune", cache.Entry{}, 0)
+			d := &mock.Driver{ExecFn: func(string, []driver.NamedValue) (driver.Result, error) {
+				return driver.RowsAffected(1), tc.execErr
+			}}
+			conn := d.DB()
+			defer conn.Close()
+			err := repository.NewShowRepo(conn).SetContent(context.Background(), &repository.ShowContent{ShowID: 3, MinAge: 16})
+			if !errors.Is(err, tc.execErr) {
+				t.Fatalf("err = %v, want %v", err, tc.execErr)
+			}
+			for _, key := range []string{"/v1/shows/3", "/v1/search?q=dune"} {
+				if _, ok := store.Get(key); ok != tc.cached {
+					t.Errorf("%s cached = %v, want %v", key, ok, tc.cached)
+				}
+			}
+		})
+	}
+}
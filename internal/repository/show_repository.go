@@ -8,22 +8,33 @@ import (
 	"context"      // context for controlling query lifetime
 	"database/sql" // sql provides DB abstraction
 	"errors"       // errors for sentinel definitions
+	"time"         // time holds the schedule and row timestamps
+
+	"github.com/iliyamo/cinema-seat-reservation/internal/cache"
 )
 
 // Show represents a scheduled screening of a movie in a particular hall.
 // StartsAt and EndsAt define the schedule; BasePriceCents is the default
-// price for seats unless overridden per seat.
-// NOTE: Time strings are stored in DB format "2006-01-02 15:04:05" (UTC).
+// price for seats unless overridden per seat.  Times are in UTC.
 type Show struct {
-	ID             uint64 // ID is the primary key of the show
-	HallID         uint64 // HallID references the hall where the show occurs
-	Title          string // Title is the name of the movie or event
-	StartsAt       string // StartsAt is the DB timestamp when the show begins ("YYYY-MM-DD HH:MM:SS" UTC)
-	EndsAt         string // EndsAt is the DB timestamp when the show ends   ("YYYY-MM-DD HH:MM:SS" UTC)
-	BasePriceCents uint32 // BasePriceCents is the base price for a seat in cents
-	Status         string // Status is the state of the show (SCHEDULED, CANCELLED, FINISHED)
-	CreatedAt      string // CreatedAt records row creation time
-	UpdatedAt      string // UpdatedAt records last update time
+	ID             uint64    // ID is the primary key of the show
+	HallID         uint64    // HallID references the hall where the show occurs
+	Title          string    // Title is the name of the movie or event
+	StartsAt       time.Time // StartsAt is when the show begins
+	EndsAt         time.Time // EndsAt is when the show ends
+	BasePriceCents uint32    // BasePriceCents is the base price for a seat in cents
+	Status         string    // Status is the state of the show (SCHEDULED, CANCELLED, FINISHED)
+	HoldTTLSec     *uint32   // HoldTTLSec overrides the default seat hold duration for this show; nil uses the default
+	CreatedAt      time.Time // CreatedAt records row creation time
+	UpdatedAt      time.Time // UpdatedAt records last update time
+
+	// Timezone and LocalStartTime present the start time in the time zone
+	// of the show's cinema, and Currency is the cinema's currency, which
+	// BasePriceCents is in.  They are not stored; handlers set them on
+	// responses.
+	Timezone       string  `json:"timezone,omitempty"`
+	LocalStartTime *string `json:"local_start_time,omitempty"`
+	Currency       string  `json:"currency,omitempty"`
 }
 
 // ErrShowNotFound indicates that a show was not located in the DB.
@@ -48,13 +59,13 @@ func (r *ShowRepo) DB() *sql.DB {
 // the repository's DB handle.  It behaves like Create but does not
 // commit the transaction.  The caller must commit or roll back the
 // transaction.  On success, the generated ID and DB-default fields
-// (status, created_at, updated_at) are populated on the given Show.
+// (status, hold_ttl_sec, created_at, updated_at) are populated on the given Show.
 func (r *ShowRepo) CreateTx(ctx context.Context, tx *sql.Tx, s *Show) error {
-    const q = `INSERT INTO shows (hall_id, title, starts_at, ends_at, base_price_cents) VALUES (?, ?, ?, ?, ?)`
+    const q = `INSERT INTO shows (hall_id, title, starts_at, ends_at, base_price_cents, hold_ttl_sec) VALUES (?, ?, ?, ?, ?, ?)`
     // Execute the insert using the provided transaction. Do not use
     // r.db here to ensure the operation participates in the caller's
     // transaction.
-    res, err := tx.ExecContext(ctx, q, s.HallID, s.Title, s.StartsAt, s.EndsAt, s.BasePriceCents)
+    res, err := tx.ExecContext(ctx, q, s.HallID, s.Title, s.StartsAt, s.EndsAt, s.BasePriceCents, s.HoldTTLSec)
     if err != nil {
         return err
     }
@@ -64,8 +75,9 @@ func (r *ShowRepo) CreateTx(ctx context.Context, tx *sql.Tx, s *Show) error {
         return err
     }
     s.ID = uint64(id)
+    cache.CatalogChanged(ctx)
     // Query the inserted row to obtain default fields such as status and timestamps.
-    const sel = `SELECT id, hall_id, title, starts_at, ends_at, base_price_cents, status, created_at, updated_at
+    const sel = `SELECT id, hall_id, title, starts_at, ends_at, base_price_cents, status, hold_ttl_sec, created_at, updated_at
                  FROM shows WHERE id = ?`
     return tx.QueryRowContext(ctx, sel, s.ID).Scan(
         &s.ID,
@@ -75,6 +87,7 @@ func (r *ShowRepo) CreateTx(ctx context.Context, tx *sql.Tx, s *Show) error {
         &s.EndsAt,
         &s.BasePriceCents,
         &s.Status,
+        &s.HoldTTLSec,
         &s.CreatedAt,
         &s.UpdatedAt,
     )
@@ -91,8 +104,8 @@ func NewShowRepo(db *sql.DB) *ShowRepo {
 // supplied; if zero the DB default of 0 will be used.  Status is
 // implicitly SCHEDULED by the DB.
 func (r *ShowRepo) Create(ctx context.Context, s *Show) error {
-	const q = `INSERT INTO shows (hall_id, title, starts_at, ends_at, base_price_cents) VALUES (?, ?, ?, ?, ?)` // SQL insert for shows
-	res, err := r.db.ExecContext(ctx, q, s.HallID, s.Title, s.StartsAt, s.EndsAt, s.BasePriceCents)             // execute insertion
+	const q = `INSERT INTO shows (hall_id, title, starts_at, ends_at, base_price_cents, hold_ttl_sec) VALUES (?, ?, ?, ?, ?, ?)` // SQL insert for shows
+	res, err := r.db.ExecContext(ctx, q, s.HallID, s.Title, s.StartsAt, s.EndsAt, s.BasePriceCents, s.HoldTTLSec)             // execute insertion
 	if err != nil {                                                                                             // check execution error
 		return err // propagate the error
 	}
@@ -101,23 +114,24 @@ func (r *ShowRepo) Create(ctx context.Context, s *Show) error {
 		return err // propagate error
 	}
 	s.ID = uint64(id) // assign the generated ID to the show model
-	// Fetch the freshly inserted row to populate default fields (status, created_at, updated_at)
-	const sel = `SELECT id, hall_id, title, starts_at, ends_at, base_price_cents, status, created_at, updated_at FROM shows WHERE id = ?` // select query
+	// Fetch the freshly inserted row to populate default fields (status, hold_ttl_sec, created_at, updated_at)
+	const sel = `SELECT id, hall_id, title, starts_at, ends_at, base_price_cents, status, hold_ttl_sec, created_at, updated_at FROM shows WHERE id = ?` // select query
 	err = r.db.QueryRowContext(ctx, sel, s.ID).Scan(                                                                                      // scan the selected row into the struct
-		&s.ID, &s.HallID, &s.Title, &s.StartsAt, &s.EndsAt, &s.BasePriceCents, &s.Status, &s.CreatedAt, &s.UpdatedAt,
+		&s.ID, &s.HallID, &s.Title, &s.StartsAt, &s.EndsAt, &s.BasePriceCents, &s.Status, &s.HoldTTLSec, &s.CreatedAt, &s.UpdatedAt,
 	)
 	if err != nil { // check scanning error
 		return err // propagate error
 	}
+	cache.CatalogChanged(ctx)
 	return nil // return nil on success
 }
 
 // GetByID retrieves a show by its ID.  It returns ErrShowNotFound if
 // there is no matching row.
 func (r *ShowRepo) GetByID(ctx context.Context, id uint64) (*Show, error) {
-	const q = `SELECT id, hall_id, title, starts_at, ends_at, base_price_cents, status, created_at, updated_at FROM shows WHERE id = ?`
+	const q = `SELECT id, hall_id, title, starts_at, ends_at, base_price_cents, status, hold_ttl_sec, created_at, updated_at FROM shows WHERE id = ?`
 	var s Show
-	err := r.db.QueryRowContext(ctx, q, id).Scan(&s.ID, &s.HallID, &s.Title, &s.StartsAt, &s.EndsAt, &s.BasePriceCents, &s.Status, &s.CreatedAt, &s.UpdatedAt)
+	err := r.db.QueryRowContext(ctx, q, id).Scan(&s.ID, &s.HallID, &s.Title, &s.StartsAt, &s.EndsAt, &s.BasePriceCents, &s.Status, &s.HoldTTLSec, &s.CreatedAt, &s.UpdatedAt)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, ErrShowNotFound
@@ -127,18 +141,80 @@ func (r *ShowRepo) GetByID(ctx context.Context, id uint64) (*Show, error) {
 	return &s, nil
 }
 
+// StatusForShareTx returns the status of a show and keeps a shared lock on
+// its row until tx ends, so CancelTx cannot commit between the caller's
+// status check and its writes.  It returns ErrShowNotFound if there is no
+// matching row.
+func (r *ShowRepo) StatusForShareTx(ctx context.Context, tx *sql.Tx, id uint64) (string, error) {
+	var status string
+	err := tx.QueryRowContext(ctx, `SELECT status FROM shows WHERE id = ? LOCK IN SHARE MODE`, id).Scan(&status)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrShowNotFound
+	}
+	return status, err
+}
+
+// StatusTx returns the status of a show as seen by tx, without locking
+// it, for read-only transactions.  It returns ErrShowNotFound if there is
+// no matching row.
+func (r *ShowRepo) StatusTx(ctx context.Context, tx *sql.Tx, id uint64) (string, error) {
+	var status string
+	err := tx.QueryRowContext(ctx, `SELECT status FROM shows WHERE id = ?`, id).Scan(&status)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrShowNotFound
+	}
+	return status, err
+}
+
+// FinishEnded marks the SCHEDULED shows that ended at or before now as
+// FINISHED and returns how many it marked.  Running it again changes
+// nothing, so replicas may run it side by side.
+func (r *ShowRepo) FinishEnded(ctx context.Context, now time.Time) (int64, error) {
+	res, err := r.db.ExecContext(ctx,
+		`UPDATE shows SET status = 'FINISHED', updated_at = CURRENT_TIMESTAMP WHERE status = 'SCHEDULED' AND ends_at <= ?`, now.UTC())
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if n > 0 {
+		cache.CatalogChanged(ctx)
+	}
+	return n, nil
+}
+
+// CancelTx marks a SCHEDULED show CANCELLED.  It returns ErrConflict when
+// the show no longer exists or is not SCHEDULED.
+func (r *ShowRepo) CancelTx(ctx context.Context, tx *sql.Tx, id uint64) error {
+	res, err := tx.ExecContext(ctx,
+		`UPDATE shows SET status = 'CANCELLED', updated_at = CURRENT_TIMESTAMP WHERE id = ? AND status = 'SCHEDULED'`, id)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrConflict
+	}
+	cache.CatalogChanged(ctx)
+	return nil
+}
+
 // ListByHallAndOwner returns all shows for a given hall that belong to the specified owner.
-// The owner constraint is enforced via the halls table.  Results are ordered by start
+// The owner constraint is enforced via the halls table and also admits the
+// owner's staff for the hall.  Results are ordered by start
 // time ascending.  When no shows exist it returns an empty slice and nil error.
 func (r *ShowRepo) ListByHallAndOwner(ctx context.Context, hallID, ownerID uint64) ([]Show, error) {
 	// Select shows joined with halls to check owner_id on halls.  Only select shows for
 	// the requested hall and owner.
-	const q = `SELECT s.id, s.hall_id, s.title, s.starts_at, s.ends_at, s.base_price_cents, s.status, s.created_at, s.updated_at
+	const q = `SELECT s.id, s.hall_id, s.title, s.starts_at, s.ends_at, s.base_price_cents, s.status, s.hold_ttl_sec, s.created_at, s.updated_at
                FROM shows s
                JOIN halls h ON h.id = s.hall_id
-               WHERE s.hall_id = ? AND h.owner_id = ?
+               WHERE s.hall_id = ? AND ` + managedHall + `
                ORDER BY s.starts_at ASC`
-	rows, err := r.db.QueryContext(ctx, q, hallID, ownerID)
+	rows, err := r.db.QueryContext(ctx, q, hallID, ownerID, ownerID)
 	if err != nil {
 		return nil, err
 	}
@@ -147,7 +223,7 @@ func (r *ShowRepo) ListByHallAndOwner(ctx context.Context, hallID, ownerID uint6
 	for rows.Next() {
 		var s Show
 		if err := rows.Scan(
-			&s.ID, &s.HallID, &s.Title, &s.StartsAt, &s.EndsAt, &s.BasePriceCents, &s.Status, &s.CreatedAt, &s.UpdatedAt,
+			&s.ID, &s.HallID, &s.Title, &s.StartsAt, &s.EndsAt, &s.BasePriceCents, &s.Status, &s.HoldTTLSec, &s.CreatedAt, &s.UpdatedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -159,14 +235,18 @@ func (r *ShowRepo) ListByHallAndOwner(ctx context.Context, hallID, ownerID uint6
 	return result, nil
 }
 
-// ListByHall returns all shows for a given hall regardless of owner. It is used by
-// public browse endpoints to display available shows to unauthenticated users. Shows
-// are ordered by their start time ascending.
-func (r *ShowRepo) ListByHall(ctx context.Context, hallID uint64) ([]Show, error) {
-    const q = `SELECT s.id, s.hall_id, s.title, s.starts_at, s.ends_at, s.base_price_cents, s.status, s.created_at, s.updated_at
-               FROM shows s
-               WHERE s.hall_id = ?
-               ORDER BY s.starts_at ASC`
+// ListByHall returns the shows of a given hall regardless of owner. It is used by
+// public browse endpoints to display available shows to unauthenticated users, so
+// only SCHEDULED shows are returned unless all is set. Shows are ordered by their
+// start time ascending.
+func (r *ShowRepo) ListByHall(ctx context.Context, hallID uint64, all bool) ([]Show, error) {
+    q := `SELECT s.id, s.hall_id, s.title, s.starts_at, s.ends_at, s.base_price_cents, s.status, s.hold_ttl_sec, s.created_at, s.updated_at
+          FROM shows s
+          WHERE s.hall_id = ?`
+    if !all {
+        q += " AND s.status = 'SCHEDULED'"
+    }
+    q += " ORDER BY s.starts_at ASC"
     rows, err := r.db.QueryContext(ctx, q, hallID)
     if err != nil {
         return nil, err
@@ -177,7 +257,7 @@ func (r *ShowRepo) ListByHall(ctx context.Context, hallID uint64) ([]Show, error
         var s Show
         if err := rows.Scan(
             &s.ID, &s.HallID, &s.Title, &s.StartsAt, &s.EndsAt,
-            &s.BasePriceCents, &s.Status, &s.CreatedAt, &s.UpdatedAt,
+            &s.BasePriceCents, &s.Status, &s.HoldTTLSec, &s.CreatedAt, &s.UpdatedAt,
         ); err != nil {
             return nil, err
         }
@@ -191,12 +271,11 @@ func (r *ShowRepo) ListByHall(ctx context.Context, hallID uint64) ([]Show, error
 
 // FindOverlapping finds all shows in the specified hall whose scheduled time overlaps
 // the provided interval [start, end).  A show overlaps when it starts before the
-// proposed end and ends after the proposed start.  Time strings must use the same
-// format as stored in the database ("2006-01-02 15:04:05").  It returns an empty
+// proposed end and ends after the proposed start.  It returns an empty
 // slice when no overlaps are found.
-func (r *ShowRepo) FindOverlapping(ctx context.Context, hallID uint64, start, end string) ([]Show, error) {
+func (r *ShowRepo) FindOverlapping(ctx context.Context, hallID uint64, start, end time.Time) ([]Show, error) {
 	// Use a predicate that selects shows where NOT (existing ends before new starts OR existing starts after new ends).
-	const q = `SELECT id, hall_id, title, starts_at, ends_at, base_price_cents, status, created_at, updated_at
+	const q = `SELECT id, hall_id, title, starts_at, ends_at, base_price_cents, status, hold_ttl_sec, created_at, updated_at
                FROM shows
                WHERE hall_id = ? AND NOT (ends_at <= ? OR starts_at >= ?)`
 	rows, err := r.db.QueryContext(ctx, q, hallID, start, end)
@@ -208,7 +287,7 @@ func (r *ShowRepo) FindOverlapping(ctx context.Context, hallID uint64, start, en
 	for rows.Next() {
 		var s Show
 		if err := rows.Scan(
-			&s.ID, &s.HallID, &s.Title, &s.StartsAt, &s.EndsAt, &s.BasePriceCents, &s.Status, &s.CreatedAt, &s.UpdatedAt,
+			&s.ID, &s.HallID, &s.Title, &s.StartsAt, &s.EndsAt, &s.BasePriceCents, &s.Status, &s.HoldTTLSec, &s.CreatedAt, &s.UpdatedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -222,8 +301,8 @@ func (r *ShowRepo) FindOverlapping(ctx context.Context, hallID uint64, start, en
 
 // FindOverlappingExcluding is similar to FindOverlapping but excludes the show with the given ID
 // from the overlap check.  This is used during updates to allow a show to overlap with itself.
-func (r *ShowRepo) FindOverlappingExcluding(ctx context.Context, hallID, excludeID uint64, start, end string) ([]Show, error) {
-	const q = `SELECT id, hall_id, title, starts_at, ends_at, base_price_cents, status, created_at, updated_at
+func (r *ShowRepo) FindOverlappingExcluding(ctx context.Context, hallID, excludeID uint64, start, end time.Time) ([]Show, error) {
+	const q = `SELECT id, hall_id, title, starts_at, ends_at, base_price_cents, status, hold_ttl_sec, created_at, updated_at
                FROM shows
                WHERE hall_id = ? AND id <> ? AND NOT (ends_at <= ? OR starts_at >= ?)`
 	rows, err := r.db.QueryContext(ctx, q, hallID, excludeID, start, end)
@@ -235,7 +314,7 @@ func (r *ShowRepo) FindOverlappingExcluding(ctx context.Context, hallID, exclude
 	for rows.Next() {
 		var s Show
 		if err := rows.Scan(
-			&s.ID, &s.HallID, &s.Title, &s.StartsAt, &s.EndsAt, &s.BasePriceCents, &s.Status, &s.CreatedAt, &s.UpdatedAt,
+			&s.ID, &s.HallID, &s.Title, &s.StartsAt, &s.EndsAt, &s.BasePriceCents, &s.Status, &s.HoldTTLSec, &s.CreatedAt, &s.UpdatedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -247,26 +326,28 @@ func (r *ShowRepo) FindOverlappingExcluding(ctx context.Context, hallID, exclude
 	return overlaps, nil
 }
 
-// UpdateByIDAndOwner updates a show's attributes if it belongs to a hall owned by the given owner.
+// UpdateByIDAndOwner updates a show's attributes if it belongs to a hall owned by the given owner
+// (or managed by them as staff).
 // It only performs the UPDATE when there is at least one differing field;
 // otherwise it returns ErrNoChange. When the row/ownership doesn't match,
 // it returns sql.ErrNoRows.
 func (r *ShowRepo) UpdateByIDAndOwner(ctx context.Context, s *Show, ownerID uint64) error {
 	const q = `UPDATE shows sh
                JOIN halls h ON h.id = sh.hall_id
-               SET sh.title = ?, sh.starts_at = ?, sh.ends_at = ?, sh.base_price_cents = ?, sh.status = ?, sh.updated_at = CURRENT_TIMESTAMP
-               WHERE sh.id = ? AND h.owner_id = ?
-                 AND (sh.title <> ? OR sh.starts_at <> ? OR sh.ends_at <> ? OR sh.base_price_cents <> ? OR sh.status <> ?)`
+               SET sh.title = ?, sh.starts_at = ?, sh.ends_at = ?, sh.base_price_cents = ?, sh.status = ?, sh.hold_ttl_sec = ?, sh.updated_at = CURRENT_TIMESTAMP
+               WHERE sh.id = ? AND ` + managedHall + `
+                 AND (sh.title <> ? OR sh.starts_at <> ? OR sh.ends_at <> ? OR sh.base_price_cents <> ? OR sh.status <> ? OR NOT (sh.hold_ttl_sec <=> ?))`
 
 	res, err := r.db.ExecContext(ctx, q,
-		s.Title, s.StartsAt, s.EndsAt, s.BasePriceCents, s.Status, // SET
-		s.ID, ownerID, // WHERE (record + owner)
-		s.Title, s.StartsAt, s.EndsAt, s.BasePriceCents, s.Status, // only if at least one field differs
+		s.Title, s.StartsAt, s.EndsAt, s.BasePriceCents, s.Status, s.HoldTTLSec, // SET
+		s.ID, ownerID, ownerID, // WHERE (record + owner or staff)
+		s.Title, s.StartsAt, s.EndsAt, s.BasePriceCents, s.Status, s.HoldTTLSec, // only if at least one field differs
 	)
 	if err != nil {
 		return err
 	}
 	if n, _ := res.RowsAffected(); n > 0 {
+		cache.CatalogChanged(ctx)
 		return nil
 	}
 
@@ -274,10 +355,10 @@ func (r *ShowRepo) UpdateByIDAndOwner(ctx context.Context, s *Show, ownerID uint
 	const qExists = `SELECT 1
                      FROM shows sh
                      JOIN halls h ON h.id = sh.hall_id
-                     WHERE sh.id = ? AND h.owner_id = ?
+                     WHERE sh.id = ? AND ` + managedHall + `
                      LIMIT 1`
 	var one int
-	if err := r.db.QueryRowContext(ctx, qExists, s.ID, ownerID).Scan(&one); err != nil {
+	if err := r.db.QueryRowContext(ctx, qExists, s.ID, ownerID, ownerID).Scan(&one); err != nil {
 		if err == sql.ErrNoRows {
 			return sql.ErrNoRows // record doesn't exist or belongs to another owner
 		}
@@ -287,7 +368,7 @@ func (r *ShowRepo) UpdateByIDAndOwner(ctx context.Context, s *Show, ownerID uint
 }
 
 // DeleteByIDAndOwner removes a show and all of its dependent records provided the
-// show belongs to a hall owned by the given owner. The deletion occurs within
+// show belongs to a hall owned (or managed as staff) by the given user. The deletion occurs within
 // a transaction to ensure that no partial cleanup occurs. If the show does
 // not exist, ErrShowNotFound is returned. If it is owned by another user,
 // ErrForbidden is returned. If any reservations exist for the show, the
@@ -305,18 +386,18 @@ func (r *ShowRepo) DeleteByIDAndOwner(ctx context.Context, id, ownerID uint64) e
             _ = tx.Commit()
         }
     }()
-    // Verify show exists and belongs to the specified owner
-    var dbOwnerID uint64
+    // Verify show exists and belongs to (or is managed by) the specified user
+    var allowed bool
     err = tx.QueryRowContext(ctx,
-        `SELECT h.owner_id FROM shows sh JOIN halls h ON h.id = sh.hall_id WHERE sh.id = ?`, id,
-    ).Scan(&dbOwnerID)
+        `SELECT `+managedHall+` FROM shows sh JOIN halls h ON h.id = sh.hall_id WHERE sh.id = ?`, ownerID, ownerID, id,
+    ).Scan(&allowed)
     if err != nil {
         if errors.Is(err, sql.ErrNoRows) {
             return ErrShowNotFound
         }
         return err
     }
-    if dbOwnerID != ownerID {
+    if !allowed {
         return ErrForbidden
     }
     // Check for existing reservations referencing this show
@@ -339,5 +420,6 @@ func (r *ShowRepo) DeleteByIDAndOwner(ctx context.Context, id, ownerID uint64) e
     if _, err = tx.ExecContext(ctx, `DELETE FROM shows WHERE id = ?`, id); err != nil {
         return err
     }
+    cache.CatalogChanged(ctx)
     return nil
 }
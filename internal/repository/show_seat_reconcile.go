@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+)
+
+// SeatRepair describes one show_seats row whose status ReconcileTx changed.
+type SeatRepair struct {
+	SeatID uint64 `json:"seat_id"`
+	Was    string `json:"was"`
+	Now    string `json:"now"`
+	Reason string `json:"reason"`
+}
+
+// ReconcileTx compares the stored status of every seat of a show with the
+// bookings behind it and repairs the rows that disagree.  A seat of a
+// PENDING or CONFIRMED reservation must be RESERVED; otherwise a seat with
+// an unexpired hold must be HELD; otherwise it is FREE, unless an owner
+// blocked it.  An owner's BLOCKED status only gives way to a reservation or
+// hold, which the owner endpoints never let exist together.
+//
+// The show's seats are locked in seat_id order, the order LockSeatsTx
+// uses, so a concurrent booking either commits before the check or waits
+// for it.  It returns the repairs made and the number of seats checked.
+func (r *ShowSeatRepo) ReconcileTx(ctx context.Context, tx *sql.Tx, showID uint64) ([]SeatRepair, int, error) {
+	const q = `SELECT ss.seat_id, ss.status,
+	                  EXISTS (SELECT 1 FROM reservation_seats rs
+	                          JOIN reservations r ON r.id = rs.reservation_id
+	                          WHERE rs.show_id = ss.show_id AND rs.seat_id = ss.seat_id
+	                            AND r.status IN ('PENDING', 'CONFIRMED')) AS reserved,
+	                  EXISTS (SELECT 1 FROM seat_holds sh
+	                          WHERE sh.show_id = ss.show_id AND sh.seat_id = ss.seat_id
+	                            AND sh.expires_at > UTC_TIMESTAMP()) AS held
+	           FROM show_seats ss
+	           WHERE ss.show_id = ?
+	           ORDER BY ss.seat_id
+	           FOR UPDATE`
+	rows, err := tx.QueryContext(ctx, q, showID)
+	if err != nil {
+		return nil, 0, err
+	}
+	var repairs []SeatRepair
+	checked := 0
+	for rows.Next() {
+		var seatID uint64
+		var status string
+		var reserved, held bool
+		if err := rows.Scan(&seatID, &status, &reserved, &held); err != nil {
+			rows.Close()
+			return nil, 0, err
+		}
+		checked++
+		want, reason := status, ""
+		switch {
+		case reserved:
+			want, reason = "RESERVED", "active reservation"
+		case held:
+			want, reason = "HELD", "active hold"
+		case status == "RESERVED":
+			want, reason = "FREE", "no active reservation"
+		case status == "HELD":
+			want, reason = "FREE", "no active hold"
+		}
+		if want != status {
+			repairs = append(repairs, SeatRepair{SeatID: seatID, Was: status, Now: want, Reason: reason})
+		}
+	}
+	if err := rows.Close(); err != nil {
+		return nil, 0, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	byStatus := make(map[string][]uint64)
+	for _, rp := range repairs {
+		byStatus[rp.Now] = append(byStatus[rp.Now], rp.SeatID)
+	}
+	for status, seatIDs := range byStatus {
+		if err := r.BulkUpdateStatusTx(ctx, tx, showID, seatIDs, status); err != nil {
+			return nil, 0, err
+		}
+	}
+	return repairs, checked, nil
+}
+
+// ReconcileCandidates returns up to limit IDs, above afterID and in
+// ascending order, of SCHEDULED shows that have not ended.  Finished and
+// cancelled shows no longer sell seats and are left alone.
+func (r *ShowSeatRepo) ReconcileCandidates(ctx context.Context, afterID uint64, limit int) ([]uint64, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT id FROM shows WHERE id > ? AND status = 'SCHEDULED' AND ends_at > UTC_TIMESTAMP() ORDER BY id LIMIT ?`,
+		afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var ids []uint64
+	for rows.Next() {
+		var id uint64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
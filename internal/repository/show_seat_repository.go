@@ -3,7 +3,11 @@ package repository // repository for show seat persistence
 import (
     "context"       // context for managing deadlines
     "database/sql"   // sql provides DB interfaces
+    "fmt"           // fmt formats entity tags
     "strings"       // strings for building dynamic queries
+    "time"          // time holds row timestamps
+
+    "github.com/iliyamo/cinema-seat-reservation/internal/cache"
 )
 
 // ShowSeat represents the availability and pricing of a specific seat
@@ -12,11 +16,11 @@ type ShowSeat struct {
     ID         uint64 // ID is the primary key of the show_seat row
     ShowID     uint64 // ShowID references the show
     SeatID     uint64 // SeatID references the seat
-    Status     string // Status is one of FREE, HELD, RESERVED
+    Status     string // Status is one of FREE, HELD, RESERVED, BLOCKED
     PriceCents uint32 // PriceCents is the price for this seat
     Version    uint32 // Version is used for optimistic locking (not enforced here)
-    CreatedAt  string // CreatedAt records when the row was inserted
-    UpdatedAt  string // UpdatedAt records last modification
+    CreatedAt  time.Time // CreatedAt records when the row was inserted
+    UpdatedAt  time.Time // UpdatedAt records last modification
 }
 
 // ShowSeatRepo encapsulates database operations for show_seats.
@@ -36,23 +40,26 @@ func (r *ShowSeatRepo) DB() *sql.DB { return r.db }
 // RESERVED) and the price for the seat.  Clients can use this to
 // construct a view of the auditorium with availability information.
 type SeatWithStatus struct {
-    SeatID     uint64 // seat_id
-    RowLabel   string // seat row label
-    SeatNumber uint32 // seat number within the row
-    Status     string // computed status: FREE, HELD, RESERVED
-    PriceCents uint32 // price in cents for this seat (from show_seats)
+    SeatID     uint64  // seat_id
+    RowLabel   string  // seat row label
+    SeatNumber uint32  // seat number within the row
+    SeatType   string  // STANDARD | VIP | ACCESSIBLE
+    Status     string  // computed status: FREE, HELD, RESERVED, BLOCKED
+    PriceCents uint32  // price in cents for this seat (from show_seats)
+    HeldBy     *uint64 // user holding the seat when Status is HELD; nil otherwise and for guest holds
 }
 
 // ListWithStatus returns all seats for a show along with their availability
-// status.  A seat is considered RESERVED when the show_seats.status is
-// RESERVED.  It is considered HELD when there exists a non-expired
+// status.  A seat is considered RESERVED or BLOCKED when the
+// show_seats.status says so.  It is considered HELD when there exists a non-expired
 // entry in seat_holds for the same show and seat; otherwise it is
-// considered FREE.  The computed status does not automatically clear
-// expired holds; callers should ensure expired holds are purged or use
-// this computed status to treat expired holds as FREE.
+// considered FREE.  Expired holds are ignored rather than purged, so
+// the whole map costs one query and no write; the hold expiry worker
+// deletes them in the background.  The join is covered by the
+// (show_id, seat_id, ...) indexes of show_seats and seat_holds.
 func (r *ShowSeatRepo) ListWithStatus(ctx context.Context, showID uint64) ([]SeatWithStatus, error) {
-    const q = `SELECT s.id, s.row_label, s.seat_number, ss.status, ss.price_cents,
-                      sh.id AS hold_id
+    const q = `SELECT s.id, s.row_label, s.seat_number, s.seat_type, ss.status, ss.price_cents,
+                      sh.id AS hold_id, sh.user_id
                FROM seats s
                JOIN show_seats ss ON ss.seat_id = s.id AND ss.show_id = ?
                LEFT JOIN seat_holds sh ON sh.show_id = ss.show_id AND sh.seat_id = ss.seat_id AND sh.expires_at > UTC_TIMESTAMP()
@@ -67,26 +74,35 @@ func (r *ShowSeatRepo) ListWithStatus(ctx context.Context, showID uint64) ([]Sea
         var id uint64
         var rowLabel string
         var seatNum uint32
+        var seatType string
         var seatStatus string
         var price uint32
         var holdID sql.NullInt64
-        if err := rows.Scan(&id, &rowLabel, &seatNum, &seatStatus, &price, &holdID); err != nil {
+        var holder sql.NullInt64
+        if err := rows.Scan(&id, &rowLabel, &seatNum, &seatType, &seatStatus, &price, &holdID, &holder); err != nil {
             return nil, err
         }
-        // compute final status: RESERVED has highest priority; then HELD (when hold exists);
-        // otherwise FREE.
+        // compute final status: RESERVED and BLOCKED have highest priority;
+        // then HELD (when hold exists); otherwise FREE.
         status := "FREE"
-        if seatStatus == "RESERVED" {
-            status = "RESERVED"
+        var heldBy *uint64
+        if seatStatus == "RESERVED" || seatStatus == "BLOCKED" {
+            status = seatStatus
         } else if holdID.Valid {
             status = "HELD"
+            if holder.Valid {
+                u := uint64(holder.Int64)
+                heldBy = &u
+            }
         }
         result = append(result, SeatWithStatus{
             SeatID:     id,
             RowLabel:   rowLabel,
             SeatNumber: seatNum,
+            SeatType:   seatType,
             Status:     status,
             PriceCents: price,
+            HeldBy:     heldBy,
         })
     }
     if err := rows.Err(); err != nil {
@@ -95,16 +111,127 @@ func (r *ShowSeatRepo) ListWithStatus(ctx context.Context, showID uint64) ([]Sea
     return result, nil
 }
 
-// FilterHoldableSeatsTx returns the subset of seatIDs that can be placed on hold
-// for the specified show.  A seat is holdable when its show_seats.status is
-// not RESERVED and there is no active seat_hold for it (expired holds do
-// not block).  The query is executed within the provided transaction.
-// The returned slice preserves the order of the input seatIDs.
-func (r *ShowSeatRepo) FilterHoldableSeatsTx(ctx context.Context, tx *sql.Tx, showID uint64, seatIDs []uint64) ([]uint64, error) {
+// SeatMapVersion summarises everything the seat map of a show depends
+// on, so clients polling an unchanged map can be answered 304 without
+// building it.  Every seat status change bumps a show seat's version and
+// updated_at; holds are created and deleted in seat_holds, and one that
+// expires changes the map at its expiry even before it is swept.  The
+// hall's rendering metadata is part of the map, so its checksum is too.
+type SeatMapVersion struct {
+    Seats        int       // show seats
+    VersionSum   uint64    // sum of the show seats' versions
+    ActiveHolds  int       // unexpired holds
+    LastHoldID   uint64    // newest hold, active or not
+    LastModified time.Time // latest seat update, hold creation or passed hold expiry
+    Rendering    uint32    // CRC32 of the hall's rendering metadata, 0 when none
+}
+
+// ETag formats v as a weak entity tag.
+func (v SeatMapVersion) ETag(showID uint64) string {
+    return fmt.Sprintf(`W/"seats-%d-%d-%d-%d-%d-%d-%x"`, showID, v.Seats, v.VersionSum, v.ActiveHolds, v.LastHoldID, v.LastModified.Unix(), v.Rendering)
+}
+
+// SeatMapVersion returns the version of a show's seat map in one query
+// over the (show_id, ...) indexes of show_seats and seat_holds.
+func (r *ShowSeatRepo) SeatMapVersion(ctx context.Context, showID uint64) (SeatMapVersion, error) {
+    const q = `SELECT COUNT(*), COALESCE(SUM(ss.version), 0), MAX(ss.updated_at),
+                      (SELECT COUNT(*) FROM seat_holds sh WHERE sh.show_id = ? AND sh.expires_at > UTC_TIMESTAMP()),
+                      (SELECT COALESCE(MAX(sh.id), 0) FROM seat_holds sh WHERE sh.show_id = ?),
+                      (SELECT MAX(CASE WHEN sh.expires_at > UTC_TIMESTAMP() THEN sh.created_at ELSE sh.expires_at END)
+                       FROM seat_holds sh WHERE sh.show_id = ?),
+                      COALESCE((SELECT CRC32(h.rendering) FROM shows s JOIN halls h ON h.id = s.hall_id WHERE s.id = ?), 0)
+               FROM show_seats ss WHERE ss.show_id = ?`
+    var (
+        v       SeatMapVersion
+        seatsAt sql.NullTime
+        holdsAt sql.NullTime
+    )
+    err := r.db.QueryRowContext(ctx, q, showID, showID, showID, showID, showID).Scan(
+        &v.Seats, &v.VersionSum, &seatsAt, &v.ActiveHolds, &v.LastHoldID, &holdsAt, &v.Rendering)
+    if err != nil {
+        return v, err
+    }
+    if seatsAt.Valid {
+        v.LastModified = seatsAt.Time.UTC()
+    }
+    if holdsAt.Valid && holdsAt.Time.After(v.LastModified) {
+        v.LastModified = holdsAt.Time.UTC()
+    }
+    v.LastModified = v.LastModified.Truncate(time.Second)
+    return v, nil
+}
+
+// AvailabilityCount is the number of seats of one type in one computed
+// status (as in ListWithStatus) for a show.
+type AvailabilityCount struct {
+    SeatType string
+    Status   string
+    Seats    int
+}
+
+// Availability counts a show's seats by seat type and computed status
+// in one aggregate query.  Expired holds count as FREE, like in
+// ListWithStatus.
+func (r *ShowSeatRepo) Availability(ctx context.Context, showID uint64) ([]AvailabilityCount, error) {
+    const q = `SELECT s.seat_type,
+                      CASE WHEN ss.status IN ('RESERVED','BLOCKED') THEN ss.status
+                           WHEN EXISTS (SELECT 1 FROM seat_holds sh
+                                        WHERE sh.show_id = ss.show_id AND sh.seat_id = ss.seat_id
+                                          AND sh.expires_at > UTC_TIMESTAMP()) THEN 'HELD'
+                           ELSE 'FREE' END AS computed,
+                      COUNT(*)
+               FROM show_seats ss
+               JOIN seats s ON s.id = ss.seat_id
+               WHERE ss.show_id = ?
+               GROUP BY s.seat_type, computed`
+    rows, err := r.db.QueryContext(ctx, q, showID)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+    var out []AvailabilityCount
+    for rows.Next() {
+        var a AvailabilityCount
+        if err := rows.Scan(&a.SeatType, &a.Status, &a.Seats); err != nil {
+            return nil, err
+        }
+        out = append(out, a)
+    }
+    return out, rows.Err()
+}
+
+// LockedSeat is the state of one show seat read under a row lock by
+// LockSeatsTx.  HeldBy is the user owning an active (unexpired) hold on
+// the seat, or nil when the seat is not actively held.
+type LockedSeat struct {
+    SeatID     uint64
+    Status     string
+    PriceCents uint32
+    HeldBy     *uint64
+}
+
+// LockSeatsTx locks the show_seats rows of the given seats with a single
+// SELECT ... FOR UPDATE and returns their status, price and active hold
+// owner keyed by seat ID.  Rows are read in ascending seat_id order so
+// concurrent transactions always acquire locks in the same order, which
+// avoids deadlocks between overlapping selections.  Seats that do not
+// exist for the show are absent from the map.  seat_holds has at most one
+// row per show seat (uk_active_hold), so the join yields one row per seat.
+func (r *ShowSeatRepo) LockSeatsTx(ctx context.Context, tx *sql.Tx, showID uint64, seatIDs []uint64) (map[uint64]LockedSeat, error) {
+    return seatsTx(ctx, tx, showID, seatIDs, true)
+}
+
+// SeatsTx is LockSeatsTx without the locks, for read-only transactions
+// that only check whether seats could be held or reserved.
+func (r *ShowSeatRepo) SeatsTx(ctx context.Context, tx *sql.Tx, showID uint64, seatIDs []uint64) (map[uint64]LockedSeat, error) {
+    return seatsTx(ctx, tx, showID, seatIDs, false)
+}
+
+func seatsTx(ctx context.Context, tx *sql.Tx, showID uint64, seatIDs []uint64, lock bool) (map[uint64]LockedSeat, error) {
+    result := make(map[uint64]LockedSeat, len(seatIDs))
     if len(seatIDs) == 0 {
-        return []uint64{}, nil
+        return result, nil
     }
-    // Build IN clause placeholders
     placeholders := make([]string, 0, len(seatIDs))
     args := make([]interface{}, 0, len(seatIDs)+1)
     args = append(args, showID)
@@ -112,41 +239,35 @@ func (r *ShowSeatRepo) FilterHoldableSeatsTx(ctx context.Context, tx *sql.Tx, sh
         placeholders = append(placeholders, "?")
         args = append(args, id)
     }
-    // This query selects seat IDs that are holdable.  A seat is holdable if
-    // it is not reserved and has no active hold.  We use a LEFT JOIN on
-    // seat_holds with an expiration check to find active holds and exclude
-    // them.  We also compare show_seats.status != 'RESERVED'.
-    query := `SELECT ss.seat_id
+    query := `SELECT ss.seat_id, ss.status, ss.price_cents, sh.user_id
               FROM show_seats ss
               LEFT JOIN seat_holds sh ON sh.show_id = ss.show_id AND sh.seat_id = ss.seat_id AND sh.expires_at > UTC_TIMESTAMP()
               WHERE ss.show_id = ? AND ss.seat_id IN (` + strings.Join(placeholders, ",") + `)
-                AND ss.status <> 'RESERVED'
-                AND sh.id IS NULL`
+              ORDER BY ss.seat_id`
+    if lock {
+        query += ` FOR UPDATE`
+    }
     rows, err := tx.QueryContext(ctx, query, args...)
     if err != nil {
         return nil, err
     }
     defer rows.Close()
-    // Build a set for constant time lookup
-    allowed := make(map[uint64]struct{})
     for rows.Next() {
-        var sid uint64
-        if err := rows.Scan(&sid); err != nil {
+        var ls LockedSeat
+        var holder sql.NullInt64
+        if err := rows.Scan(&ls.SeatID, &ls.Status, &ls.PriceCents, &holder); err != nil {
             return nil, err
         }
-        allowed[sid] = struct{}{}
+        if holder.Valid {
+            u := uint64(holder.Int64)
+            ls.HeldBy = &u
+        }
+        result[ls.SeatID] = ls
     }
     if err := rows.Err(); err != nil {
         return nil, err
     }
-    // Preserve input order
-    out := make([]uint64, 0, len(allowed))
-    for _, sid := range seatIDs {
-        if _, ok := allowed[sid]; ok {
-            out = append(out, sid)
-        }
-    }
-    return out, nil
+    return result, nil
 }
 
 // BulkUpdateStatusTx updates the status of the specified seats for a show.
@@ -170,6 +291,23 @@ func (r *ShowSeatRepo) BulkUpdateStatusTx(ctx context.Context, tx *sql.Tx, showI
               SET status = ?, version = version + 1, updated_at = CURRENT_TIMESTAMP
               WHERE show_id = ? AND seat_id IN (` + strings.Join(placeholders, ",") + `)`
     _, err := tx.ExecContext(ctx, query, args...)
+    if err == nil {
+        cache.ShowChanged(ctx, showID)
+    }
+    return err
+}
+
+// FreeShowTx sets every HELD or RESERVED seat of a show back to FREE
+// inside tx; BLOCKED seats stay blocked.  It is used when the show is
+// cancelled, after its holds and reservations were released.
+func (r *ShowSeatRepo) FreeShowTx(ctx context.Context, tx *sql.Tx, showID uint64) error {
+    const q = `UPDATE show_seats
+               SET status = 'FREE', version = version + 1, updated_at = CURRENT_TIMESTAMP
+               WHERE show_id = ? AND status IN ('HELD', 'RESERVED')`
+    _, err := tx.ExecContext(ctx, q, showID)
+    if err == nil {
+        cache.ShowChanged(ctx, showID)
+    }
     return err
 }
 
@@ -239,6 +377,9 @@ func (r *ShowSeatRepo) CreateBulk(ctx context.Context, seats []ShowSeat) error {
         args = append(args, ss.ShowID, ss.SeatID, ss.Status, ss.PriceCents, ss.Version)
     }
     _, err := r.db.ExecContext(ctx, query, args...)
+    if err == nil {
+        seatsChanged(ctx, seats)
+    }
     return err
 }
 
@@ -263,5 +404,44 @@ func (r *ShowSeatRepo) CreateBulkTx(ctx context.Context, tx *sql.Tx, seats []Sho
     }
     // Execute the bulk insert within the provided transaction context.
     _, err := tx.ExecContext(ctx, query, args...)
+    if err == nil {
+        seatsChanged(ctx, seats)
+    }
     return err
-}
\ No newline at end of file
+}
+
+// seatsChanged invalidates the cached seat maps of the shows of seats.
+func seatsChanged(ctx context.Context, seats []ShowSeat) {
+    seen := make(map[uint64]bool)
+    for _, ss := range seats {
+        if !seen[ss.ShowID] {
+            seen[ss.ShowID] = true
+            cache.ShowChanged(ctx, ss.ShowID)
+        }
+    }
+}
+// UpdatePricesTx sets the price of the given seats of a show inside tx.
+// Callers lock the seats first (LockSeatsTx) and leave out RESERVED ones,
+// whose price was already charged.  It returns the number of seats whose
+// price changed.
+func (r *ShowSeatRepo) UpdatePricesTx(ctx context.Context, tx *sql.Tx, showID uint64, seatIDs []uint64, priceCents uint32) (int64, error) {
+    if len(seatIDs) == 0 {
+        return 0, nil
+    }
+    placeholders := make([]string, 0, len(seatIDs))
+    args := make([]interface{}, 0, len(seatIDs)+3)
+    args = append(args, priceCents, showID, priceCents)
+    for _, id := range seatIDs {
+        placeholders = append(placeholders, "?")
+        args = append(args, id)
+    }
+    query := `UPDATE show_seats
+              SET price_cents = ?, version = version + 1, updated_at = CURRENT_TIMESTAMP
+              WHERE show_id = ? AND price_cents <> ? AND seat_id IN (` + strings.Join(placeholders, ",") + `)`
+    res, err := tx.ExecContext(ctx, query, args...)
+    if err != nil {
+        return 0, err
+    }
+    cache.ShowChanged(ctx, showID)
+    return res.RowsAffected()
+}
@@ -0,0 +1,62 @@
+package repository_test
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/iliyamo/cinema-seat-reservation/internal/repository"
+	"github.com/iliyamo/cinema-seat-reservation/internal/repository/mock"
+)
+
+// BenchmarkLockSeatsTx measures the locking read behind every hold,
+// reservation and seat change: building the SELECT ... FOR UPDATE for n
+// seats and scanning one row per seat, half of them actively held.  The
+// database is a mock.Driver, so the numbers cover the repository's own
+// work, not MySQL's.
+func BenchmarkLockSeatsTx(b *testing.B) {
+	for _, n := range []int{1, 10, 100} {
+		b.Run(fmt.Sprintf("seats=%d", n), func(b *testing.B) {
+			seatIDs := make([]uint64, n)
+			rows := make([][]driver.Value, n)
+			for i := range seatIDs {
+				seatIDs[i] = uint64(i + 1)
+				var holder driver.Value
+				if i%2 == 1 {
+					holder = int64(42)
+				}
+				rows[i] = []driver.Value{int64(i + 1), "FREE", int64(1200), holder}
+			}
+			d := &mock.Driver{
+				QueryFn: func(query string, args []driver.NamedValue) ([]string, [][]driver.Value, error) {
+					if !strings.HasSuffix(query, "FOR UPDATE") || len(args) != n+1 {
+						b.Fatalf("unexpected query %q with %d args", query, len(args))
+					}
+					return []string{"seat_id", "status", "price_cents", "user_id"}, rows, nil
+				},
+			}
+			conn := d.DB()
+			defer conn.Close()
+			repo := repository.NewShowSeatRepo(conn)
+			ctx := context.Background()
+			tx, err := conn.BeginTx(ctx, nil)
+			if err != nil {
+				b.Fatal(err)
+			}
+			defer tx.Rollback()
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				locked, err := repo.LockSeatsTx(ctx, tx, 7, seatIDs)
+				if err != nil {
+					b.Fatal(err)
+				}
+				if len(locked) != n {
+					b.Fatalf("locked %d seats, want %d", len(locked), n)
+				}
+			}
+		})
+	}
+}
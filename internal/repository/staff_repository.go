@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+)
+
+// managedHall is a SQL condition on a halls row aliased h that holds when
+// a user owns the hall or is staff for it.  The user ID is bound twice.
+// Ownership checks in HallRepo, ShowRepo and ReservationRepo use it so
+// staff can act on the halls they were granted.
+const managedHall = `(h.owner_id = ? OR EXISTS (SELECT 1 FROM staff_memberships sm
+    WHERE sm.staff_user_id = ? AND sm.cinema_id = h.cinema_id AND (sm.hall_id IS NULL OR sm.hall_id = h.id)))`
+
+// StaffMembership grants a staff user management of one hall, or of every
+// hall of a cinema when HallID is nil.
+type StaffMembership struct {
+	ID          uint64    `json:"id"`
+	OwnerID     uint64    `json:"owner_id"`
+	StaffUserID uint64    `json:"staff_user_id"`
+	StaffEmail  string    `json:"staff_email"`
+	CinemaID    uint64    `json:"cinema_id"`
+	HallID      *uint64   `json:"hall_id"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// ErrStaffExists is returned by Create when the user already has the same
+// membership.
+var ErrStaffExists = errors.New("staff membership already exists")
+
+// StaffRepo persists staff_memberships.
+type StaffRepo struct {
+	db *sql.DB
+}
+
+// NewStaffRepo returns a new StaffRepo bound to the given database.
+func NewStaffRepo(db *sql.DB) *StaffRepo { return &StaffRepo{db: db} }
+
+// Create inserts m and sets its ID.  A duplicate grant yields
+// ErrStaffExists.
+func (r *StaffRepo) Create(ctx context.Context, m *StaffMembership) error {
+	res, err := r.db.ExecContext(ctx,
+		`INSERT INTO staff_memberships (owner_id, staff_user_id, cinema_id, hall_id) VALUES (?, ?, ?, ?)`,
+		m.OwnerID, m.StaffUserID, m.CinemaID, m.HallID)
+	if err != nil {
+		if strings.Contains(err.Error(), "1062") {
+			return ErrStaffExists
+		}
+		return err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	m.ID = uint64(id)
+	return nil
+}
+
+// ListByOwner returns the memberships an owner has granted, newest first.
+func (r *StaffRepo) ListByOwner(ctx context.Context, ownerID uint64) ([]StaffMembership, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT m.id, m.owner_id, m.staff_user_id, u.email, m.cinema_id, m.hall_id, m.created_at
+		FROM staff_memberships m
+		JOIN users u ON u.id = m.staff_user_id
+		WHERE m.owner_id = ?
+		ORDER BY m.id DESC`, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []StaffMembership{}
+	for rows.Next() {
+		var (
+			m      StaffMembership
+			hallID sql.NullInt64
+		)
+		if err := rows.Scan(&m.ID, &m.OwnerID, &m.StaffUserID, &m.StaffEmail, &m.CinemaID, &hallID, &m.CreatedAt); err != nil {
+			return nil, err
+		}
+		if hallID.Valid {
+			v := uint64(hallID.Int64)
+			m.HallID = &v
+		}
+		out = append(out, m)
+	}
+	return out, rows.Err()
+}
+
+// Delete removes one of an owner's memberships and returns the staff
+// user it belonged to.  It returns sql.ErrNoRows when no such membership
+// was granted by the owner.
+func (r *StaffRepo) Delete(ctx context.Context, ownerID, id uint64) (uint64, error) {
+	var staffID uint64
+	if err := r.db.QueryRowContext(ctx,
+		`SELECT staff_user_id FROM staff_memberships WHERE id = ? AND owner_id = ?`, id, ownerID,
+	).Scan(&staffID); err != nil {
+		return 0, err
+	}
+	res, err := r.db.ExecContext(ctx, `DELETE FROM staff_memberships WHERE id = ? AND owner_id = ?`, id, ownerID)
+	if err != nil {
+		return 0, err
+	}
+	return staffID, requireAffected(res)
+}
+
+// CountForStaff returns how many memberships a staff user still holds.
+func (r *StaffRepo) CountForStaff(ctx context.Context, staffUserID uint64) (int, error) {
+	var n int
+	err := r.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM staff_memberships WHERE staff_user_id = ?`, staffUserID).Scan(&n)
+	return n, err
+}
@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// RoleCount is the number of users with a role.
+type RoleCount struct {
+	Role  string `json:"role"`
+	Users int    `json:"users"`
+}
+
+// DailyBookings summarises the reservations created on one UTC day.
+// Revenue counts confirmed reservations only; pending, cancelled and
+// refund-pending reservations are counted but bring no revenue.
+type DailyBookings struct {
+	Date         string `json:"date"` // YYYY-MM-DD
+	Reservations int    `json:"reservations"`
+	Confirmed    int    `json:"confirmed"`
+	Cancelled    int    `json:"cancelled"`
+	RevenueCents uint64 `json:"revenue_cents"`
+}
+
+// StatsRepo runs the platform-wide aggregate queries behind the admin
+// statistics endpoint.
+type StatsRepo struct {
+	db *sql.DB
+}
+
+// NewStatsRepo returns a new StatsRepo bound to the given database.
+func NewStatsRepo(db *sql.DB) *StatsRepo { return &StatsRepo{db: db} }
+
+// UsersByRole returns the number of users per role, including roles
+// without users.
+func (r *StatsRepo) UsersByRole(ctx context.Context) ([]RoleCount, error) {
+	const q = `SELECT r.name, COUNT(u.id)
+               FROM roles r
+               LEFT JOIN users u ON u.role_id = r.id
+               GROUP BY r.id, r.name
+               ORDER BY r.id`
+	rows, err := r.db.QueryContext(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := make([]RoleCount, 0)
+	for rows.Next() {
+		var c RoleCount
+		if err := rows.Scan(&c.Role, &c.Users); err != nil {
+			return nil, err
+		}
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
+
+// CinemaCount returns the number of cinemas.
+func (r *StatsRepo) CinemaCount(ctx context.Context) (int, error) {
+	var n int
+	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM cinemas`).Scan(&n)
+	return n, err
+}
+
+// ScheduledShows returns the number of SCHEDULED shows starting in
+// [from, to).
+func (r *StatsRepo) ScheduledShows(ctx context.Context, from, to time.Time) (int, error) {
+	var n int
+	err := r.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM shows WHERE status = 'SCHEDULED' AND starts_at >= ? AND starts_at < ?`,
+		from.UTC(), to.UTC()).Scan(&n)
+	return n, err
+}
+
+// DailyBookings returns one entry per UTC day from from through to
+// (inclusive), with zeros for days without reservations.
+func (r *StatsRepo) DailyBookings(ctx context.Context, from, to time.Time) ([]DailyBookings, error) {
+	from = from.UTC().Truncate(24 * time.Hour)
+	to = to.UTC().Truncate(24 * time.Hour)
+	const q = `SELECT DATE(created_at) AS day,
+                      COUNT(*),
+                      COALESCE(SUM(status = 'CONFIRMED'), 0),
+                      COALESCE(SUM(status = 'CANCELLED'), 0),
+                      COALESCE(SUM(CASE WHEN status = 'CONFIRMED' THEN total_amount_cents ELSE 0 END), 0)
+               FROM reservations
+               WHERE created_at >= ? AND created_at < ?
+               GROUP BY day`
+	rows, err := r.db.QueryContext(ctx, q, from, to.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	byDay := make(map[string]DailyBookings)
+	for rows.Next() {
+		var (
+			day time.Time
+			d   DailyBookings
+		)
+		if err := rows.Scan(&day, &d.Reservations, &d.Confirmed, &d.Cancelled, &d.RevenueCents); err != nil {
+			return nil, err
+		}
+		d.Date = day.Format(DateLayout)
+		byDay[d.Date] = d
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	out := make([]DailyBookings, 0, int(to.Sub(from)/(24*time.Hour))+1)
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		key := day.Format(DateLayout)
+		d, ok := byDay[key]
+		if !ok {
+			d.Date = key
+		}
+		out = append(out, d)
+	}
+	return out, nil
+}
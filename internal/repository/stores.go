@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// The interfaces below describe the repository methods the customer
+// handlers depend on.  The SQL repositories in this package implement
+// them; handlers accept the interfaces so they can be exercised with the
+// in-memory doubles in package repository/mock instead of MySQL.
+
+// ShowStore reads shows.
+type ShowStore interface {
+	DB() *sql.DB
+	GetByID(ctx context.Context, id uint64) (*Show, error)
+	StatusForShareTx(ctx context.Context, tx *sql.Tx, id uint64) (string, error)
+	StatusTx(ctx context.Context, tx *sql.Tx, id uint64) (string, error)
+	CancelCutoffTx(ctx context.Context, tx *sql.Tx, id uint64) (time.Duration, error)
+	AgeRestrictionTx(ctx context.Context, tx *sql.Tx, id uint64) (uint8, time.Time, error)
+	CurrencyTx(ctx context.Context, tx *sql.Tx, id uint64) (string, error)
+	FeesTx(ctx context.Context, tx *sql.Tx, id uint64) (*CinemaFees, error)
+}
+
+// SeatStore reads the seats of a hall.
+type SeatStore interface {
+	GetByHall(ctx context.Context, hallID uint64) ([]Seat, error)
+}
+
+// HallStore reads halls.
+type HallStore interface {
+	GetByID(ctx context.Context, id uint64) (*Hall, error)
+}
+
+// ShowSeatStore reads and updates the per-show seat state.
+type ShowSeatStore interface {
+	ListWithStatus(ctx context.Context, showID uint64) ([]SeatWithStatus, error)
+	LockSeatsTx(ctx context.Context, tx *sql.Tx, showID uint64, seatIDs []uint64) (map[uint64]LockedSeat, error)
+	SeatsTx(ctx context.Context, tx *sql.Tx, showID uint64, seatIDs []uint64) (map[uint64]LockedSeat, error)
+	BulkUpdateStatusTx(ctx context.Context, tx *sql.Tx, showID uint64, seatIDs []uint64, status string) error
+	GetPricesBySeatIDsTx(ctx context.Context, tx *sql.Tx, showID uint64, seatIDs []uint64) (map[uint64]uint32, error)
+}
+
+// SeatHoldStore manages seat holds.
+type SeatHoldStore interface {
+	ExpireHoldsTx(ctx context.Context, tx *sql.Tx, showID uint64) ([]uint64, error)
+	CreateMultipleTx(ctx context.Context, tx *sql.Tx, holds []SeatHoldRecord) error
+	DeleteByUserAndShowTx(ctx context.Context, tx *sql.Tx, userID, showID uint64) ([]uint64, error)
+	DeleteByIDsTx(ctx context.Context, tx *sql.Tx, ids []uint64) error
+	ActiveHoldsByUserAndShowTx(ctx context.Context, tx *sql.Tx, userID, showID uint64) ([]SeatHoldRecord, error)
+	ActiveHoldsByUserAndShow(ctx context.Context, userID, showID uint64) ([]SeatHoldRecord, error)
+	ActiveHoldsByUser(ctx context.Context, userID uint64) ([]SeatHoldRecord, error)
+	LockActiveHoldsByUserTx(ctx context.Context, tx *sql.Tx, userID uint64) ([]SeatHoldRecord, error)
+	ExtendByUserAndShowTx(ctx context.Context, tx *sql.Tx, userID, showID uint64, increment, maxTotal time.Duration) ([]SeatHoldRecord, bool, error)
+}
+
+// ReservationStore creates, reads and cancels a customer's reservations.
+type ReservationStore interface {
+	CreateTx(ctx context.Context, tx *sql.Tx, res *ReservationRecord) error
+	CreateSeatsBulkTx(ctx context.Context, tx *sql.Tx, seats []ReservationSeatRecord) error
+	GetByIDForUser(ctx context.Context, reservationID, userID uint64) (*ReservationDetail, error)
+	IsCharged(ctx context.Context, reservationID uint64) (bool, error)
+	GetInfoForUserTx(ctx context.Context, tx *sql.Tx, reservationID, userID uint64) (uint64, time.Time, []uint64, error)
+	ListByUser(ctx context.Context, userID uint64) ([]ReservationDetail, error)
+	SetPaymentRefTx(ctx context.Context, tx *sql.Tx, reservationID uint64, paymentRef string) error
+	CancelTx(ctx context.Context, tx *sql.Tx, reservationID uint64) error
+}
+
+var (
+	_ ShowStore        = (*ShowRepo)(nil)
+	_ SeatStore        = (*SeatRepo)(nil)
+	_ HallStore        = (*HallRepo)(nil)
+	_ ShowSeatStore    = (*ShowSeatRepo)(nil)
+	_ SeatHoldStore    = (*SeatHoldRepo)(nil)
+	_ ReservationStore = (*ReservationRepo)(nil)
+)
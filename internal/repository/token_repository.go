@@ -15,15 +15,38 @@ type TokenRepo struct{ DB *sql.DB }
 // NewTokenRepo constructs a new TokenRepo given a database handle.
 func NewTokenRepo(db *sql.DB) *TokenRepo { return &TokenRepo{DB: db} }
 
-// StoreRefresh inserts a row containing the hashed refresh token, the user ID
-// and the expiry time.  It returns any error from the underlying Exec call.
-func (r *TokenRepo) StoreRefresh(ctx context.Context, userID uint64, tokenHash string, exp time.Time) error {
+// SessionMeta describes the client a refresh token was issued to.  Empty
+// fields are stored as NULL.
+type SessionMeta struct {
+    UserAgent string
+    IP        string
+}
+
+// Session is an active refresh token as listed by GET /v1/me/sessions.
+type Session struct {
+    ID         uint64     `json:"id"`
+    UserAgent  *string    `json:"user_agent"`
+    IP         *string    `json:"ip"`
+    CreatedAt  time.Time  `json:"created_at"`
+    LastUsedAt *time.Time `json:"last_used_at"`
+    ExpiresAt  time.Time  `json:"expires_at"`
+}
+
+// StoreRefresh inserts a row containing the hashed refresh token, the user ID,
+// the expiry time and the client metadata.  It returns any error from the
+// underlying Exec call.
+func (r *TokenRepo) StoreRefresh(ctx context.Context, userID uint64, tokenHash string, exp time.Time, meta SessionMeta) error {
     _, err := r.DB.ExecContext(ctx,
-        "INSERT INTO refresh_tokens (user_id, token_hash, expires_at) VALUES (?,?,?)",
-        userID, tokenHash, exp)
+        "INSERT INTO refresh_tokens (user_id, token_hash, expires_at, user_agent, ip) VALUES (?,?,?,?,?)",
+        userID, tokenHash, exp, nullString(meta.UserAgent), nullString(meta.IP))
     return err
 }
 
+// nullString maps "" to NULL.
+func nullString(s string) sql.NullString {
+    return sql.NullString{String: s, Valid: s != ""}
+}
+
 // ValidateRefresh checks that a given token hash exists, is not revoked and
 // has not expired.  It returns the associated user ID or sql.ErrNoRows if
 // validation fails.  Clients should treat sql.ErrNoRows as a generic
@@ -64,6 +87,60 @@ func (r *TokenRepo) RevokeByHash(ctx context.Context, tokenHash string) error {
     return err
 }
 
+// TouchRefresh records that a refresh token was just used.
+func (r *TokenRepo) TouchRefresh(ctx context.Context, tokenHash string) error {
+    _, err := r.DB.ExecContext(ctx,
+        "UPDATE refresh_tokens SET last_used_at=UTC_TIMESTAMP() WHERE token_hash=?",
+        tokenHash)
+    return err
+}
+
+// ListSessions returns a user's unrevoked, unexpired refresh tokens, most
+// recently used first.
+func (r *TokenRepo) ListSessions(ctx context.Context, userID uint64) ([]Session, error) {
+    rows, err := r.DB.QueryContext(ctx, `
+        SELECT id, user_agent, ip, created_at, last_used_at, expires_at
+        FROM refresh_tokens
+        WHERE user_id=? AND revoked_at IS NULL AND expires_at > UTC_TIMESTAMP()
+        ORDER BY COALESCE(last_used_at, created_at) DESC, id DESC`, userID)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+    out := []Session{}
+    for rows.Next() {
+        var (
+            s        Session
+            ua, ip   sql.NullString
+            lastUsed sql.NullTime
+        )
+        if err := rows.Scan(&s.ID, &ua, &ip, &s.CreatedAt, &lastUsed, &s.ExpiresAt); err != nil {
+            return nil, err
+        }
+        s.UserAgent = nullStringPtr(ua)
+        s.IP = nullStringPtr(ip)
+        if lastUsed.Valid {
+            t := lastUsed.Time
+            s.LastUsedAt = &t
+        }
+        out = append(out, s)
+    }
+    return out, rows.Err()
+}
+
+// RevokeSession revokes one of a user's refresh tokens by ID.  It returns
+// sql.ErrNoRows when the token does not exist, belongs to another user or
+// is already revoked.
+func (r *TokenRepo) RevokeSession(ctx context.Context, userID, id uint64) error {
+    res, err := r.DB.ExecContext(ctx,
+        "UPDATE refresh_tokens SET revoked_at=NOW() WHERE id=? AND user_id=? AND revoked_at IS NULL",
+        id, userID)
+    if err != nil {
+        return err
+    }
+    return requireAffected(res)
+}
+
 // RevokeAllForUser revokes all active refresh tokens for a given user by
 // setting revoked_at on all rows where revoked_at is NULL.  Returns any
 // execution error.
@@ -73,3 +150,47 @@ func (r *TokenRepo) RevokeAllForUser(ctx context.Context, userID uint64) error {
         userID)
     return err
 }
+
+// StorePasswordReset records a hashed password reset token for a user.
+func (r *TokenRepo) StorePasswordReset(ctx context.Context, userID uint64, tokenHash string, exp time.Time) error {
+    _, err := r.DB.ExecContext(ctx,
+        "INSERT INTO password_resets (user_id, token_hash, expires_at) VALUES (?,?,?)",
+        userID, tokenHash, exp.UTC())
+    return err
+}
+
+// CountPasswordResetsSince returns how many reset tokens were issued to a
+// user since the given time; used to throttle reset emails.
+func (r *TokenRepo) CountPasswordResetsSince(ctx context.Context, userID uint64, since time.Time) (int, error) {
+    var n int
+    err := r.DB.QueryRowContext(ctx,
+        "SELECT COUNT(*) FROM password_resets WHERE user_id=? AND created_at >= ?",
+        userID, since.UTC()).Scan(&n)
+    return n, err
+}
+
+// ConsumePasswordReset redeems a reset token: it must exist, be unused and
+// unexpired.  The token is marked used by a single conditional UPDATE, so
+// concurrent redemptions cannot both succeed, and the user's other
+// outstanding reset tokens are invalidated.  It returns the user ID or
+// sql.ErrNoRows when the token is not redeemable.
+func (r *TokenRepo) ConsumePasswordReset(ctx context.Context, tokenHash string) (uint64, error) {
+    res, err := r.DB.ExecContext(ctx,
+        "UPDATE password_resets SET used_at=UTC_TIMESTAMP() WHERE token_hash=? AND used_at IS NULL AND expires_at > UTC_TIMESTAMP()",
+        tokenHash)
+    if err != nil {
+        return 0, err
+    }
+    if err := requireAffected(res); err != nil {
+        return 0, err
+    }
+    var userID uint64
+    if err := r.DB.QueryRowContext(ctx,
+        "SELECT user_id FROM password_resets WHERE token_hash=?", tokenHash).Scan(&userID); err != nil {
+        return 0, err
+    }
+    _, err = r.DB.ExecContext(ctx,
+        "UPDATE password_resets SET used_at=UTC_TIMESTAMP() WHERE user_id=? AND used_at IS NULL",
+        userID)
+    return userID, err
+}
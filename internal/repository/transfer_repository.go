@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// ReservationTransfer is a transfer code issued for a reservation.  The
+// code itself is only returned when it is issued.  ShowTitle and
+// StartsAt are only set by ClaimTx.
+type ReservationTransfer struct {
+	ID            uint64     `json:"id"`
+	ReservationID uint64     `json:"reservation_id"`
+	FromUserID    uint64     `json:"from_user_id"`
+	ExpiresAt     time.Time  `json:"expires_at"`
+	ClaimedBy     *uint64    `json:"claimed_by"`
+	ClaimedAt     *time.Time `json:"claimed_at"`
+	ShowTitle     string     `json:"-"`
+	StartsAt      time.Time  `json:"-"`
+}
+
+var (
+	// ErrTransferNotFound is returned by ClaimTx when no unclaimed,
+	// unexpired transfer has the code.
+	ErrTransferNotFound = errors.New("transfer code not found")
+	// ErrTransferNotClaimable is returned by ClaimTx when the reservation
+	// changed since the code was issued: it was cancelled, has an open
+	// refund, or no longer belongs to the issuer.
+	ErrTransferNotClaimable = errors.New("reservation can no longer be transferred")
+	// ErrTransferToSelf is returned by ClaimTx when the issuer claims
+	// their own code.
+	ErrTransferToSelf = errors.New("cannot claim own transfer")
+)
+
+// TransferRepo persists reservation transfer codes and moves reservations
+// between users.
+type TransferRepo struct {
+	db *sql.DB
+}
+
+// NewTransferRepo returns a new TransferRepo bound to the given database.
+func NewTransferRepo(db *sql.DB) *TransferRepo { return &TransferRepo{db: db} }
+
+// DB exposes the underlying sql.DB for transactions spanning repositories.
+func (r *TransferRepo) DB() *sql.DB { return r.db }
+
+// CreateTx stores the hash of a new transfer code for t.ReservationID and
+// drops the reservation's earlier unclaimed codes, so only the newest
+// one can be claimed.  It sets t.ID.
+func (r *TransferRepo) CreateTx(ctx context.Context, tx *sql.Tx, t *ReservationTransfer, codeHash string) error {
+	if _, err := tx.ExecContext(ctx,
+		`DELETE FROM reservation_transfers WHERE reservation_id = ? AND claimed_at IS NULL`, t.ReservationID); err != nil {
+		return err
+	}
+	res, err := tx.ExecContext(ctx,
+		`INSERT INTO reservation_transfers (reservation_id, from_user_id, code_hash, expires_at) VALUES (?, ?, ?, ?)`,
+		t.ReservationID, t.FromUserID, codeHash, t.ExpiresAt.UTC())
+	if err != nil {
+		return err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	t.ID = uint64(id)
+	return nil
+}
+
+// ClaimTx redeems the code with hash codeHash for userID: the
+// reservation becomes userID's and the code is marked claimed, both
+// inside tx.  The reservation must still belong to the issuer, be
+// CONFIRMED and have no REQUESTED or APPROVED refund, and its show must
+// not have started; otherwise ErrTransferNotClaimable is returned.
+func (r *TransferRepo) ClaimTx(ctx context.Context, tx *sql.Tx, codeHash string, userID uint64) (*ReservationTransfer, error) {
+	var t ReservationTransfer
+	err := tx.QueryRowContext(ctx,
+		`SELECT t.id, t.reservation_id, t.from_user_id, t.expires_at, s.title, s.starts_at
+		 FROM reservation_transfers t
+		 JOIN reservations r ON r.id = t.reservation_id
+		 JOIN shows s ON s.id = r.show_id
+		 WHERE t.code_hash = ? AND t.claimed_at IS NULL AND t.expires_at > UTC_TIMESTAMP()
+		 FOR UPDATE`, codeHash).Scan(&t.ID, &t.ReservationID, &t.FromUserID, &t.ExpiresAt, &t.ShowTitle, &t.StartsAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrTransferNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if t.FromUserID == userID {
+		return nil, ErrTransferToSelf
+	}
+	res, err := tx.ExecContext(ctx,
+		`UPDATE reservations r
+		 JOIN shows s ON s.id = r.show_id
+		 SET r.user_id = ?
+		 WHERE r.id = ? AND r.user_id = ? AND r.status = 'CONFIRMED' AND s.starts_at > UTC_TIMESTAMP()
+		   AND NOT EXISTS (SELECT 1 FROM refunds rf
+		                   WHERE rf.reservation_id = r.id AND rf.status IN ('REQUESTED','APPROVED'))`,
+		userID, t.ReservationID, t.FromUserID)
+	if err != nil {
+		return nil, err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return nil, err
+	} else if n == 0 {
+		return nil, ErrTransferNotClaimable
+	}
+	now := time.Now().UTC().Truncate(time.Second)
+	if _, err := tx.ExecContext(ctx,
+		`UPDATE reservation_transfers SET claimed_by = ?, claimed_at = ? WHERE id = ?`, userID, now, t.ID); err != nil {
+		return nil, err
+	}
+	t.ClaimedBy, t.ClaimedAt = &userID, &now
+	return &t, nil
+}
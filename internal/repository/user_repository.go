@@ -105,3 +105,65 @@ func (r *UserRepo) GetByID(ctx context.Context, id uint64) (model.User, error) {
 		id).Scan(&u.ID, &u.Email, &u.PasswordHash, &u.IsActive, &u.CreatedAt, &u.UpdatedAt, &u.RoleID, &u.Role)
 	return u, err
 }
+
+// ErrUnknownRole is returned by SetRole for a role name that has no row in
+// the roles table.
+var ErrUnknownRole = errors.New("unknown role")
+
+// UpdatePassword replaces the password of the given user with a bcrypt hash
+// of password computed at the supplied cost.  It returns sql.ErrNoRows when
+// no such user exists.
+func (r *UserRepo) UpdatePassword(ctx context.Context, id uint64, password string, cost int) error {
+	hash, err := utils.HashPassword(password, cost)
+	if err != nil {
+		return err
+	}
+	res, err := r.DB.ExecContext(ctx, "UPDATE users SET password_hash = ? WHERE id = ?", hash, id)
+	if err != nil {
+		return err
+	}
+	return requireAffected(res)
+}
+
+// SetRole changes the role of the given user.  role is one of CUSTOMER,
+// OWNER, ADMIN or STAFF (case-insensitive); anything else yields
+// ErrUnknownRole.
+// It returns sql.ErrNoRows when no such user exists.
+func (r *UserRepo) SetRole(ctx context.Context, id uint64, role string) error {
+	var roleID uint8
+	switch strings.ToUpper(strings.TrimSpace(role)) {
+	case "CUSTOMER":
+		roleID = 1
+	case "OWNER":
+		roleID = 2
+	case "ADMIN":
+		roleID = 3
+	case "STAFF":
+		roleID = 4
+	default:
+		return ErrUnknownRole
+	}
+	// Select first so that setting the role a user already has is not
+	// reported as a missing user (RowsAffected is 0 for unchanged rows).
+	var current uint8
+	if err := r.DB.QueryRowContext(ctx, "SELECT role_id FROM users WHERE id = ?", id).Scan(&current); err != nil {
+		return err
+	}
+	if current == roleID {
+		return nil
+	}
+	_, err := r.DB.ExecContext(ctx, "UPDATE users SET role_id = ? WHERE id = ?", roleID, id)
+	return err
+}
+
+// requireAffected converts an update that matched no rows into sql.ErrNoRows.
+func requireAffected(res sql.Result) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
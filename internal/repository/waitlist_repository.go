@@ -0,0 +1,216 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+)
+
+// Waitlist entry states.  WAITING entries queue in id order; the head is
+// OFFERED a seat hold when enough seats come free.  An offer ends as
+// FULFILLED (the held seats were confirmed), DECLINED or EXPIRED; a
+// WAITING entry the user withdraws becomes CANCELLED.
+const (
+	WaitlistWaiting   = "WAITING"
+	WaitlistOffered   = "OFFERED"
+	WaitlistFulfilled = "FULFILLED"
+	WaitlistDeclined  = "DECLINED"
+	WaitlistExpired   = "EXPIRED"
+	WaitlistCancelled = "CANCELLED"
+)
+
+// WaitlistEntry is one user's place in a show's waitlist.  Position is the
+// 1-based place in the queue and is only set for WAITING entries.
+type WaitlistEntry struct {
+	ID             uint64     `json:"id"`
+	ShowID         uint64     `json:"show_id"`
+	UserID         uint64     `json:"user_id"`
+	SeatCount      int        `json:"seat_count"`
+	Adjacent       bool       `json:"adjacent"`
+	Status         string     `json:"status"`
+	Position       *int       `json:"position"`
+	OfferExpiresAt *time.Time `json:"offer_expires_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// ErrWaitlistExists is returned by Create when the user already has an
+// open (WAITING or OFFERED) entry for the show.
+var ErrWaitlistExists = errors.New("already on the waitlist")
+
+// WaitlistRepo persists waitlist_entries.
+type WaitlistRepo struct {
+	db *sql.DB
+}
+
+// NewWaitlistRepo returns a new WaitlistRepo bound to the given database.
+func NewWaitlistRepo(db *sql.DB) *WaitlistRepo { return &WaitlistRepo{db: db} }
+
+// DB exposes the underlying sql.DB for transactions spanning repositories.
+func (r *WaitlistRepo) DB() *sql.DB { return r.db }
+
+const waitlistColumns = `w.id, w.show_id, w.user_id, w.seat_count, w.adjacent, w.status, w.offer_expires_at, w.created_at`
+
+// waitlistPosition selects the queue position of a WAITING entry aliased w
+// (NULL for other states).
+const waitlistPosition = `IF(w.status = 'WAITING', (SELECT COUNT(*) FROM waitlist_entries q
+    WHERE q.show_id = w.show_id AND q.status = 'WAITING' AND q.id <= w.id), NULL)`
+
+func scanWaitlistEntry(row interface{ Scan(...interface{}) error }, withPosition bool) (*WaitlistEntry, error) {
+	var (
+		e       WaitlistEntry
+		expires sql.NullTime
+		pos     sql.NullInt64
+	)
+	dest := []interface{}{&e.ID, &e.ShowID, &e.UserID, &e.SeatCount, &e.Adjacent, &e.Status, &expires, &e.CreatedAt}
+	if withPosition {
+		dest = append(dest, &pos)
+	}
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+	if expires.Valid {
+		t := expires.Time
+		e.OfferExpiresAt = &t
+	}
+	if pos.Valid {
+		p := int(pos.Int64)
+		e.Position = &p
+	}
+	return &e, nil
+}
+
+// Create appends e to the waitlist of its show and sets its ID, status and
+// position.  A second open entry for the same user and show yields
+// ErrWaitlistExists.
+func (r *WaitlistRepo) Create(ctx context.Context, e *WaitlistEntry) error {
+	res, err := r.db.ExecContext(ctx,
+		`INSERT INTO waitlist_entries (show_id, user_id, seat_count, adjacent) VALUES (?, ?, ?, ?)`,
+		e.ShowID, e.UserID, e.SeatCount, e.Adjacent)
+	if err != nil {
+		if strings.Contains(err.Error(), "1062") {
+			return ErrWaitlistExists
+		}
+		return err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	created, err := scanWaitlistEntry(r.db.QueryRowContext(ctx,
+		`SELECT `+waitlistColumns+`, `+waitlistPosition+` FROM waitlist_entries w WHERE w.id = ?`, id), true)
+	if err != nil {
+		return err
+	}
+	*e = *created
+	return nil
+}
+
+// ListByUser returns a user's waitlist entries, newest first.
+func (r *WaitlistRepo) ListByUser(ctx context.Context, userID uint64) ([]WaitlistEntry, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+waitlistColumns+`, `+waitlistPosition+` FROM waitlist_entries w WHERE w.user_id = ? ORDER BY w.id DESC`,
+		userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []WaitlistEntry{}
+	for rows.Next() {
+		e, err := scanWaitlistEntry(rows, true)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *e)
+	}
+	return out, rows.Err()
+}
+
+// GetForUserTx locks and returns one of a user's entries.  It returns
+// sql.ErrNoRows when the entry does not exist or belongs to someone else.
+func (r *WaitlistRepo) GetForUserTx(ctx context.Context, tx *sql.Tx, id, userID uint64) (*WaitlistEntry, error) {
+	return scanWaitlistEntry(tx.QueryRowContext(ctx,
+		`SELECT `+waitlistColumns+` FROM waitlist_entries w WHERE w.id = ? AND w.user_id = ? FOR UPDATE`,
+		id, userID), false)
+}
+
+// HeadTx locks and returns the oldest WAITING entry of a show, or
+// sql.ErrNoRows when nobody is waiting.
+func (r *WaitlistRepo) HeadTx(ctx context.Context, tx *sql.Tx, showID uint64) (*WaitlistEntry, error) {
+	return scanWaitlistEntry(tx.QueryRowContext(ctx,
+		`SELECT `+waitlistColumns+` FROM waitlist_entries w
+		 WHERE w.show_id = ? AND w.status = 'WAITING' ORDER BY w.id LIMIT 1 FOR UPDATE`,
+		showID), false)
+}
+
+// SetStatusTx moves an entry to status, recording offerExpiresAt for
+// offers.
+func (r *WaitlistRepo) SetStatusTx(ctx context.Context, tx *sql.Tx, id uint64, status string, offerExpiresAt *time.Time) error {
+	var expires sql.NullTime
+	if offerExpiresAt != nil {
+		expires = sql.NullTime{Time: offerExpiresAt.UTC(), Valid: true}
+	}
+	_, err := tx.ExecContext(ctx,
+		`UPDATE waitlist_entries SET status = ?, offer_expires_at = ? WHERE id = ?`,
+		status, expires, id)
+	return err
+}
+
+// FulfillTx marks a user's open offer for a show FULFILLED.  It is called
+// when the user confirms seats and does nothing when there is no offer.
+func (r *WaitlistRepo) FulfillTx(ctx context.Context, tx *sql.Tx, userID, showID uint64) error {
+	_, err := tx.ExecContext(ctx,
+		`UPDATE waitlist_entries SET status = 'FULFILLED' WHERE user_id = ? AND show_id = ? AND status = 'OFFERED'`,
+		userID, showID)
+	return err
+}
+
+// CloseWaitingTx expires every WAITING entry of a show; used once the
+// show can no longer be booked.
+func (r *WaitlistRepo) CloseWaitingTx(ctx context.Context, tx *sql.Tx, showID uint64) error {
+	_, err := tx.ExecContext(ctx,
+		`UPDATE waitlist_entries SET status = 'EXPIRED' WHERE show_id = ? AND status = 'WAITING'`,
+		showID)
+	return err
+}
+
+// CloseShowTx expires every WAITING and OFFERED entry of a show; used
+// when the show is cancelled and its offered holds are dropped.
+func (r *WaitlistRepo) CloseShowTx(ctx context.Context, tx *sql.Tx, showID uint64) error {
+	_, err := tx.ExecContext(ctx,
+		`UPDATE waitlist_entries SET status = 'EXPIRED' WHERE show_id = ? AND status IN ('WAITING', 'OFFERED')`,
+		showID)
+	return err
+}
+
+// ExpireOffers marks OFFERED entries whose offer has lapsed as EXPIRED and
+// returns how many were changed.  The held seats themselves are released
+// by the normal hold expiry.
+func (r *WaitlistRepo) ExpireOffers(ctx context.Context) (int64, error) {
+	res, err := r.db.ExecContext(ctx,
+		`UPDATE waitlist_entries SET status = 'EXPIRED' WHERE status = 'OFFERED' AND offer_expires_at <= UTC_TIMESTAMP()`)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// ShowsWaiting returns the IDs of shows with at least one WAITING entry.
+func (r *WaitlistRepo) ShowsWaiting(ctx context.Context) ([]uint64, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT DISTINCT show_id FROM waitlist_entries WHERE status = 'WAITING' ORDER BY show_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []uint64
+	for rows.Next() {
+		var id uint64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		out = append(out, id)
+	}
+	return out, rows.Err()
+}
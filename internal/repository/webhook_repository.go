@@ -0,0 +1,396 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/iliyamo/cinema-seat-reservation/internal/money"
+)
+
+// Webhook events owners can subscribe to.
+const (
+	WebhookReservationCreated   = "reservation.created"
+	WebhookReservationCancelled = "reservation.cancelled"
+	WebhookShowCancelled        = "show.cancelled"
+)
+
+// WebhookEvents lists every webhook event, in documentation order.
+var WebhookEvents = []string{WebhookReservationCreated, WebhookReservationCancelled, WebhookShowCancelled}
+
+// webhookForBooking maps booking event kinds to the webhook event they
+// trigger.  Expiries of unpaid reservations are not sent: the partner
+// never saw the reservation as created.
+var webhookForBooking = map[string]string{
+	EventReservationConfirmed: WebhookReservationCreated,
+	EventReservationCancelled: WebhookReservationCancelled,
+}
+
+// Webhook delivery states.  PENDING deliveries wait for the dispatcher; a
+// failed delivery is retried until the dispatcher gives up and marks it
+// FAILED.
+const (
+	WebhookDeliveryPending   = "PENDING"
+	WebhookDeliveryDelivered = "DELIVERED"
+	WebhookDeliveryFailed    = "FAILED"
+)
+
+// WebhookSubscription mirrors a row of the webhook_subscriptions table.
+// Secret is only shown when the subscription is created.
+type WebhookSubscription struct {
+	ID        uint64    `json:"id"`
+	OwnerID   uint64    `json:"owner_id"`
+	URL       string    `json:"url"`
+	Secret    string    `json:"-"`
+	Events    []string  `json:"events"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// WebhookDelivery is an entry of the webhook delivery queue and log.  Data
+// is the event payload as it was when the event happened.
+type WebhookDelivery struct {
+	ID             uint64          `json:"id"`
+	SubscriptionID uint64          `json:"subscription_id"`
+	Event          string          `json:"event"`
+	Data           json.RawMessage `json:"data"`
+	Status         string          `json:"status"`
+	Attempts       uint32          `json:"attempts"`
+	LastStatusCode *int            `json:"last_status_code"`
+	LastError      *string         `json:"last_error"`
+	NextAttemptAt  *time.Time      `json:"next_attempt_at"` // nil once delivered or failed
+	CreatedAt      time.Time       `json:"created_at"`
+	DeliveredAt    *time.Time      `json:"delivered_at"`
+	// URL and Secret are those of the subscription, loaded by Due.
+	URL    string `json:"-"`
+	Secret string `json:"-"`
+}
+
+// WebhookReservationData is the payload of reservation events.
+type WebhookReservationData struct {
+	ReservationID    uint64    `json:"reservation_id"`
+	ShowID           uint64    `json:"show_id"`
+	Status           string    `json:"status"`
+	TotalAmountCents uint32    `json:"total_amount_cents"`
+	Currency         string    `json:"currency"`
+	OccurredAt       time.Time `json:"occurred_at"`
+}
+
+// WebhookShowData is the payload of show events.
+type WebhookShowData struct {
+	ShowID     uint64    `json:"show_id"`
+	HallID     uint64    `json:"hall_id"`
+	Title      string    `json:"title"`
+	StartsAt   time.Time `json:"starts_at"`
+	Status     string    `json:"status"`
+	Reason     string    `json:"reason,omitempty"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+// WebhookRepo persists webhook subscriptions and deliveries.
+type WebhookRepo struct {
+	db *sql.DB
+}
+
+// NewWebhookRepo returns a new WebhookRepo bound to the given database.
+func NewWebhookRepo(db *sql.DB) *WebhookRepo { return &WebhookRepo{db: db} }
+
+// DB returns the underlying database handle.
+func (r *WebhookRepo) DB() *sql.DB { return r.db }
+
+// Create inserts s and sets its ID.
+func (r *WebhookRepo) Create(ctx context.Context, s *WebhookSubscription) error {
+	res, err := r.db.ExecContext(ctx,
+		`INSERT INTO webhook_subscriptions (owner_id, url, secret, events) VALUES (?, ?, ?, ?)`,
+		s.OwnerID, s.URL, s.Secret, strings.Join(s.Events, " "))
+	if err != nil {
+		return err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	s.ID = uint64(id)
+	s.CreatedAt = time.Now().UTC()
+	return nil
+}
+
+// ListByOwner returns an owner's subscriptions, newest first.
+func (r *WebhookRepo) ListByOwner(ctx context.Context, ownerID uint64) ([]WebhookSubscription, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT id, owner_id, url, secret, events, created_at FROM webhook_subscriptions WHERE owner_id = ? ORDER BY id DESC`, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []WebhookSubscription{}
+	for rows.Next() {
+		var (
+			s      WebhookSubscription
+			events string
+		)
+		if err := rows.Scan(&s.ID, &s.OwnerID, &s.URL, &s.Secret, &events, &s.CreatedAt); err != nil {
+			return nil, err
+		}
+		s.Events = strings.Fields(events)
+		s.CreatedAt = s.CreatedAt.UTC()
+		out = append(out, s)
+	}
+	return out, rows.Err()
+}
+
+// Delete removes one of an owner's subscriptions together with its
+// deliveries.  It returns sql.ErrNoRows when the owner has no such
+// subscription.
+func (r *WebhookRepo) Delete(ctx context.Context, ownerID, id uint64) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM webhook_subscriptions WHERE id = ? AND owner_id = ?`, id, ownerID)
+	if err != nil {
+		return err
+	}
+	return requireAffected(res)
+}
+
+// OwnsSubscription reports whether the owner has the subscription.
+func (r *WebhookRepo) OwnsSubscription(ctx context.Context, ownerID, id uint64) (bool, error) {
+	var n int
+	err := r.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM webhook_subscriptions WHERE id = ? AND owner_id = ?`, id, ownerID).Scan(&n)
+	return n > 0, err
+}
+
+// enqueueTx queues a delivery of event with data for each of the owner's
+// subscriptions to it.
+func (r *WebhookRepo) enqueueTx(ctx context.Context, tx *sql.Tx, ownerID uint64, event string, data interface{}) error {
+	rows, err := tx.QueryContext(ctx, `SELECT id, events FROM webhook_subscriptions WHERE owner_id = ? ORDER BY id`, ownerID)
+	if err != nil {
+		return err
+	}
+	var subs []uint64
+	for rows.Next() {
+		var (
+			id     uint64
+			events string
+		)
+		if err := rows.Scan(&id, &events); err != nil {
+			rows.Close()
+			return err
+		}
+		for _, ev := range strings.Fields(events) {
+			if ev == event {
+				subs = append(subs, id)
+				break
+			}
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil || len(subs) == 0 {
+		return err
+	}
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	for _, id := range subs {
+		if _, err := tx.ExecContext(ctx,
+			`INSERT INTO webhook_deliveries (subscription_id, event, payload, next_attempt_at) VALUES (?, ?, ?, UTC_TIMESTAMP())`,
+			id, event, payload); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// EnqueueBookingTx queues the webhook deliveries triggered by a booking
+// event of the given kind for each reservation inside tx, with the
+// reservations' current state.  Kinds without a webhook event are
+// ignored.  As with BookingEventRepo.AppendTx, reservations that do not
+// exist are skipped.
+func (r *WebhookRepo) EnqueueBookingTx(ctx context.Context, tx *sql.Tx, kind string, reservationIDs ...uint64) error {
+	event, ok := webhookForBooking[kind]
+	if !ok || len(reservationIDs) == 0 {
+		return nil
+	}
+	args := make([]interface{}, len(reservationIDs))
+	for i, id := range reservationIDs {
+		args[i] = id
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(reservationIDs)), ",")
+	rows, err := tx.QueryContext(ctx,
+		`SELECT r.id, r.show_id, r.status, r.total_amount_cents, COALESCE(r.currency, c.currency, ''), h.owner_id
+		 FROM reservations r
+		 JOIN shows s ON s.id = r.show_id
+		 JOIN halls h ON h.id = s.hall_id
+		 LEFT JOIN cinemas c ON c.id = h.cinema_id
+		 WHERE r.id IN (`+placeholders+`) ORDER BY r.id`, args...)
+	if err != nil {
+		return err
+	}
+	type owned struct {
+		owner uint64
+		data  WebhookReservationData
+	}
+	var items []owned
+	now := time.Now().UTC()
+	for rows.Next() {
+		var it owned
+		d := &it.data
+		if err := rows.Scan(&d.ReservationID, &d.ShowID, &d.Status, &d.TotalAmountCents, &d.Currency, &it.owner); err != nil {
+			rows.Close()
+			return err
+		}
+		d.Currency = money.Or(d.Currency)
+		d.OccurredAt = now
+		items = append(items, it)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for _, it := range items {
+		if err := r.enqueueTx(ctx, tx, it.owner, event, it.data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// EnqueueShowCancelledTx queues show.cancelled deliveries for a show
+// inside tx.  reason is the owner's optional explanation.
+func (r *WebhookRepo) EnqueueShowCancelledTx(ctx context.Context, tx *sql.Tx, showID uint64, reason string) error {
+	d := WebhookShowData{ShowID: showID, Status: "CANCELLED", Reason: reason, OccurredAt: time.Now().UTC()}
+	var ownerID uint64
+	if err := tx.QueryRowContext(ctx,
+		`SELECT s.hall_id, s.title, s.starts_at, h.owner_id FROM shows s JOIN halls h ON h.id = s.hall_id WHERE s.id = ?`,
+		showID).Scan(&d.HallID, &d.Title, &d.StartsAt, &ownerID); err != nil {
+		return err
+	}
+	d.StartsAt = d.StartsAt.UTC()
+	return r.enqueueTx(ctx, tx, ownerID, WebhookShowCancelled, d)
+}
+
+const webhookDeliveryColumns = `d.id, d.subscription_id, d.event, d.payload, d.status, d.attempts, d.last_status_code,
+	d.last_error, d.next_attempt_at, d.created_at, d.delivered_at`
+
+func scanWebhookDelivery(row interface{ Scan(...any) error }, extra ...any) (*WebhookDelivery, error) {
+	var (
+		d         WebhookDelivery
+		code      sql.NullInt64
+		lastErr   sql.NullString
+		next      sql.NullTime
+		delivered sql.NullTime
+		payload   []byte
+	)
+	dest := []any{&d.ID, &d.SubscriptionID, &d.Event, &payload, &d.Status, &d.Attempts, &code,
+		&lastErr, &next, &d.CreatedAt, &delivered}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return nil, err
+	}
+	d.Data = json.RawMessage(payload)
+	if code.Valid {
+		v := int(code.Int64)
+		d.LastStatusCode = &v
+	}
+	if lastErr.Valid {
+		d.LastError = &lastErr.String
+	}
+	if next.Valid && d.Status == WebhookDeliveryPending {
+		t := next.Time.UTC()
+		d.NextAttemptAt = &t
+	}
+	if delivered.Valid {
+		t := delivered.Time.UTC()
+		d.DeliveredAt = &t
+	}
+	d.CreatedAt = d.CreatedAt.UTC()
+	return &d, nil
+}
+
+// Due returns up to limit PENDING deliveries whose next attempt is due,
+// oldest first, with their subscription's URL and secret.
+func (r *WebhookRepo) Due(ctx context.Context, limit int) ([]WebhookDelivery, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+webhookDeliveryColumns+`, s.url, s.secret
+		 FROM webhook_deliveries d
+		 JOIN webhook_subscriptions s ON s.id = d.subscription_id
+		 WHERE d.status = 'PENDING' AND d.next_attempt_at <= UTC_TIMESTAMP()
+		 ORDER BY d.id LIMIT ?`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []WebhookDelivery
+	for rows.Next() {
+		var url, secret string
+		d, err := scanWebhookDelivery(rows, &url, &secret)
+		if err != nil {
+			return nil, err
+		}
+		d.URL, d.Secret = url, secret
+		out = append(out, *d)
+	}
+	return out, rows.Err()
+}
+
+// ListDeliveries returns up to limit deliveries of a subscription, newest
+// first, optionally only those with the given status and those older than
+// beforeID (0 for none).
+func (r *WebhookRepo) ListDeliveries(ctx context.Context, subscriptionID uint64, status string, beforeID uint64, limit int) ([]WebhookDelivery, error) {
+	q := `SELECT ` + webhookDeliveryColumns + ` FROM webhook_deliveries d WHERE d.subscription_id = ?`
+	args := []interface{}{subscriptionID}
+	if status != "" {
+		q += ` AND d.status = ?`
+		args = append(args, status)
+	}
+	if beforeID > 0 {
+		q += ` AND d.id < ?`
+		args = append(args, beforeID)
+	}
+	q += ` ORDER BY d.id DESC LIMIT ?`
+	args = append(args, limit)
+	rows, err := r.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []WebhookDelivery{}
+	for rows.Next() {
+		d, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *d)
+	}
+	return out, rows.Err()
+}
+
+// MarkDelivered records a successful delivery and the response status.
+func (r *WebhookRepo) MarkDelivered(ctx context.Context, id uint64, statusCode int) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE webhook_deliveries
+		 SET status = 'DELIVERED', attempts = attempts + 1, last_status_code = ?, last_error = NULL, delivered_at = UTC_TIMESTAMP()
+		 WHERE id = ?`, statusCode, id)
+	return err
+}
+
+// MarkFailed records a failed delivery and the response status (0 when
+// there was no response).  The delivery is retried at retryAt, or marked
+// FAILED for good when retryAt is nil.
+func (r *WebhookRepo) MarkFailed(ctx context.Context, id uint64, statusCode int, cause string, retryAt *time.Time) error {
+	if len(cause) > 255 {
+		cause = cause[:255]
+	}
+	status := WebhookDeliveryFailed
+	var next, code interface{}
+	if retryAt != nil {
+		status, next = WebhookDeliveryPending, retryAt.UTC()
+	}
+	if statusCode > 0 {
+		code = statusCode
+	}
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE webhook_deliveries
+		 SET status = ?, attempts = attempts + 1, last_status_code = ?, last_error = ?, next_attempt_at = COALESCE(?, next_attempt_at)
+		 WHERE id = ?`, status, code, cause, next, id)
+	return err
+}
@@ -0,0 +1,33 @@
+package router
+
+// This file registers admin-only operational routes.
+
+import (
+    "github.com/iliyamo/cinema-seat-reservation/internal/handler"
+    "github.com/iliyamo/cinema-seat-reservation/internal/middleware"
+    "github.com/iliyamo/cinema-seat-reservation/internal/permissions"
+    "github.com/iliyamo/cinema-seat-reservation/internal/utils"
+    "github.com/labstack/echo/v4"
+)
+
+// RegisterAdmin registers routes under /v1/admin.  All routes require a
+// JWT token with the admin:ops scope.
+func RegisterAdmin(e *echo.Echo, h *handler.AdminHandler, keys *utils.KeySet) {
+    g := e.Group(
+        "/v1/admin",
+        middleware.JWTAuth(keys),
+        middleware.RequireScope(permissions.AdminOps),
+    )
+    // Long transactions, lock waits and active holds per show
+    g.GET("/diagnostics", h.GetDiagnostics)
+    // Per-account request quotas
+    g.GET("/quotas/:user_id", h.GetQuota)
+    g.PUT("/quotas/:user_id", h.PutQuota)
+    g.DELETE("/quotas/:user_id", h.DeleteQuota)
+    // Access token signing keys (IDs only)
+    g.GET("/jwt-keys", h.GetJWTKeys)
+    // Effective configuration with secrets redacted
+    g.GET("/config", h.GetConfig)
+    // Platform-wide totals and reservations/revenue per day
+    g.GET("/stats", h.GetStats)
+}
@@ -0,0 +1,19 @@
+package router
+
+// This file registers the audit log query routes.
+
+import (
+    "github.com/iliyamo/cinema-seat-reservation/internal/handler"
+    "github.com/iliyamo/cinema-seat-reservation/internal/middleware"
+    "github.com/iliyamo/cinema-seat-reservation/internal/permissions"
+    "github.com/iliyamo/cinema-seat-reservation/internal/utils"
+    "github.com/labstack/echo/v4"
+)
+
+// RegisterAudit registers GET /v1/admin/audit (admin:ops scope, whole log)
+// and GET /v1/owner/audit (audit:read scope, the caller's resources).
+func RegisterAudit(e *echo.Echo, h *handler.AuditHandler, keys *utils.KeySet) {
+    auth := middleware.JWTAuth(keys)
+    e.GET("/v1/admin/audit", h.ListAudit, auth, middleware.RequireScope(permissions.AdminOps))
+    e.GET("/v1/owner/audit", h.ListOwnerAudit, auth, middleware.RequireScope(permissions.AuditRead))
+}
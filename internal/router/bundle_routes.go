@@ -0,0 +1,35 @@
+package router
+
+// This file registers routes for multi-show bundles.  Owners manage
+// bundles under /v1/owner, guests can view a bundle and customers can
+// purchase one.
+
+import (
+    "github.com/iliyamo/cinema-seat-reservation/internal/handler"
+    "github.com/iliyamo/cinema-seat-reservation/internal/middleware"
+    "github.com/iliyamo/cinema-seat-reservation/internal/permissions"
+    "github.com/iliyamo/cinema-seat-reservation/internal/utils"
+    "github.com/labstack/echo/v4"
+)
+
+// RegisterBundles registers owner, public and customer bundle endpoints.
+func RegisterBundles(e *echo.Echo, h *handler.BundleHandler, keys *utils.KeySet) {
+    // Public: view an active bundle
+    e.GET("/v1/bundles/:id", h.GetBundle)
+
+    owner := e.Group(
+        "/v1/owner",
+        middleware.JWTAuth(keys),
+        middleware.RequireScope(permissions.PromoWrite),
+    )
+    owner.POST("/bundles", h.CreateBundle)
+    owner.GET("/bundles", h.ListOwnerBundles)
+
+    customer := e.Group(
+        "/v1",
+        middleware.JWTAuth(keys),
+        middleware.RequireScope(permissions.BookingWrite),
+    )
+    // Reserve seats in every show of the bundle atomically
+    customer.POST("/bundles/:id/checkout", h.CheckoutBundle)
+}
@@ -3,32 +3,74 @@ package router
 import (
 	"github.com/iliyamo/cinema-seat-reservation/internal/handler"
 	"github.com/iliyamo/cinema-seat-reservation/internal/middleware"
+	"github.com/iliyamo/cinema-seat-reservation/internal/permissions"
+	"github.com/iliyamo/cinema-seat-reservation/internal/utils"
 	"github.com/labstack/echo/v4"
 )
 
-// RegisterCustomer registers customer-scoped endpoints under /v1.  All routes
-// require a valid JWT and the CUSTOMER role.  Customers can view seat
+// RegisterCustomer registers customer endpoints under /v1.  All routes
+// require a valid JWT; reads need the booking:read scope and changes the
+// booking:write scope.  Holding, extending and releasing seats also
+// accept the hold:write scope of guest sessions.  Customers can view seat
 // status for shows, place holds on seats, release holds, confirm
 // reservations and view their own reservations.
-func RegisterCustomer(e *echo.Echo, h *handler.CustomerHandler, jwtSecret string) {
+func RegisterCustomer(e *echo.Echo, h *handler.CustomerHandler, keys *utils.KeySet) {
 	g := e.Group(
 		"/v1",
-		middleware.JWTAuth(jwtSecret),
-		middleware.RequireRole("CUSTOMER"),
+		middleware.JWTAuth(keys),
 	)
+	read := middleware.RequireScope(permissions.BookingRead)
+	write := middleware.RequireScope(permissions.BookingWrite)
+	hold := middleware.RequireAnyScope(permissions.BookingWrite, permissions.HoldWrite)
 	// Note: GET /v1/shows/:id/seats, GET /v1/halls/:id/seats/layout and
 	// GET /v1/halls/:id/seats are registered on the public router so that
 	// guests can view seat availability and hall seat lists.  Customer-specific
 	// endpoints begin here.
-	g.POST("/shows/:id/hold", h.HoldSeats)
-	g.DELETE("/shows/:id/hold", h.ReleaseHolds)
-	g.POST("/shows/:id/confirm", h.ConfirmSeats)
-	g.GET("/my-reservations", h.ListReservations)
+	g.POST("/shows/:id/hold", h.HoldSeats, hold)
+	g.DELETE("/shows/:id/hold", h.ReleaseHolds, hold)
+	g.POST("/shows/:id/hold/extend", h.ExtendHolds, hold)
+	// Group booking: hold N server-chosen (by default adjacent) seats
+	g.POST("/shows/:id/hold/auto", h.AutoHoldSeats, hold)
+	g.POST("/shows/:id/confirm", h.ConfirmSeats, write)
+	g.POST("/shows/:id/reserve", h.ConfirmSeats, write)
+	// Waitlist for sold-out shows; freed seats are offered as holds
+	g.POST("/shows/:id/waitlist", h.JoinWaitlist, write)
+	g.GET("/waitlist", h.ListWaitlist, read)
+	g.POST("/waitlist/:id/decline", h.DeclineWaitlist, write)
+	// Paid checkout through the booking saga; the saga can be polled when
+	// the payment outcome was not known at response time.
+	g.POST("/shows/:id/checkout", h.Checkout, write)
+	g.GET("/booking-sagas/:id", h.GetBookingSaga, read)
+	// Resumable checkout: returns the caller's active holds for a show so
+	// checkout can continue on another device.
+	g.GET("/checkout-session/:show_id", h.GetCheckoutSession, read)
+	// Multi-show cart: every active hold of the caller, checked out in
+	// one all-or-nothing transaction
+	g.GET("/cart", h.GetCart, read)
+	g.POST("/cart/checkout", h.CheckoutCart, write)
+	g.GET("/my-reservations", h.ListReservations, read)
+	g.GET("/my-reservations/export", h.ExportReservations, read)
 
 	// Reservation detail and deletion endpoints for customers.  These
 	// endpoints allow a customer to view or cancel a reservation
-	// belonging to themselves.  They are protected by the CUSTOMER
-	// role and validated within the handler.
-	g.GET("/reservations/:id", h.GetReservation)
-	g.DELETE("/reservations/:id", h.DeleteReservation)
+	// belonging to themselves.  They are protected by the booking
+	// scopes and ownership is validated within the handler.
+	g.GET("/reservations/:id", h.GetReservation, read)
+	g.GET("/reservations/:id/calendar.ics", h.ReservationCalendar, read)
+	g.DELETE("/reservations/:id", h.DeleteReservation, write)
+	// refunds: request one for a paid reservation and follow its state
+	g.POST("/reservations/:id/refund-request", h.RequestRefund, write)
+	g.GET("/reservations/:id/refund", h.GetRefund, read)
+	// transfers: hand a reservation to another customer with a one-time code
+	g.POST("/reservations/:id/transfer", h.TransferReservation, write)
+	g.POST("/reservations/claim", h.ClaimReservation, write)
+	// upgrades: swap STANDARD seats of a reservation for free VIP seats
+	g.POST("/reservations/:id/upgrade", h.UpgradeReservation, write)
+	// favourites: followed cinemas and movies, notified of new shows
+	g.POST("/me/favorites", h.AddFavorite, write)
+	g.GET("/me/favorites", h.ListFavorites, read)
+	g.DELETE("/me/favorites/:id", h.DeleteFavorite, write)
+	// date of birth, checked when confirming age-restricted shows
+	g.GET("/me/date-of-birth", h.GetDateOfBirth, read)
+	g.PUT("/me/date-of-birth", h.SetDateOfBirth, write)
 }
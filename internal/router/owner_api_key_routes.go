@@ -0,0 +1,28 @@
+package router
+
+// This file registers owner-specific routes for managing API keys.
+
+import (
+    "github.com/iliyamo/cinema-seat-reservation/internal/handler"
+    "github.com/iliyamo/cinema-seat-reservation/internal/middleware"
+    "github.com/iliyamo/cinema-seat-reservation/internal/permissions"
+    "github.com/iliyamo/cinema-seat-reservation/internal/utils"
+    "github.com/labstack/echo/v4"
+)
+
+// RegisterOwnerAPIKeys registers API key management routes under
+// /v1/owner.  All routes require a JWT token with the apikey:manage
+// scope, which API keys themselves are never granted.
+func RegisterOwnerAPIKeys(e *echo.Echo, h *handler.OwnerAPIKeyHandler, keys *utils.KeySet) {
+    g := e.Group(
+        "/v1/owner",
+        middleware.JWTAuth(keys),
+        middleware.RequireScope(permissions.APIKeyManage),
+    )
+    // Issue a key; the key itself is only in this response
+    g.POST("/api-keys", h.CreateAPIKey)
+    // List the owner's keys
+    g.GET("/api-keys", h.ListAPIKeys)
+    // Revoke a key
+    g.DELETE("/api-keys/:id", h.RevokeAPIKey)
+}
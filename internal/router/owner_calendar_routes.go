@@ -0,0 +1,28 @@
+package router
+
+// This file registers owner-specific routes for managing cinema calendars.
+
+import (
+    "github.com/iliyamo/cinema-seat-reservation/internal/handler"
+    "github.com/iliyamo/cinema-seat-reservation/internal/middleware"
+    "github.com/iliyamo/cinema-seat-reservation/internal/permissions"
+    "github.com/iliyamo/cinema-seat-reservation/internal/utils"
+    "github.com/labstack/echo/v4"
+)
+
+// RegisterOwnerCalendar registers blackout and special date management
+// routes under /v1/owner.  All routes require a JWT token with the
+// cinema:write scope.
+func RegisterOwnerCalendar(e *echo.Echo, h *handler.OwnerCalendarHandler, keys *utils.KeySet) {
+    g := e.Group(
+        "/v1/owner",
+        middleware.JWTAuth(keys),
+        middleware.RequireScope(permissions.CinemaWrite),
+    )
+    // List a cinema's calendar entries in a date range
+    g.GET("/cinemas/:id/calendar", h.ListCalendar)
+    // Create or replace the entry for one date
+    g.PUT("/cinemas/:id/calendar/:date", h.PutCalendarDate)
+    // Remove the entry for one date
+    g.DELETE("/cinemas/:id/calendar/:date", h.DeleteCalendarDate)
+}
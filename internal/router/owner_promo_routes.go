@@ -0,0 +1,25 @@
+package router
+
+// This file registers owner-specific routes for managing promo codes.
+
+import (
+    "github.com/iliyamo/cinema-seat-reservation/internal/handler"
+    "github.com/iliyamo/cinema-seat-reservation/internal/middleware"
+    "github.com/iliyamo/cinema-seat-reservation/internal/permissions"
+    "github.com/iliyamo/cinema-seat-reservation/internal/utils"
+    "github.com/labstack/echo/v4"
+)
+
+// RegisterOwnerPromos registers promo code management routes under
+// /v1/owner.  All routes require a JWT token with the promo:write scope.
+func RegisterOwnerPromos(e *echo.Echo, h *handler.OwnerPromoHandler, keys *utils.KeySet) {
+    g := e.Group(
+        "/v1/owner",
+        middleware.JWTAuth(keys),
+        middleware.RequireScope(permissions.PromoWrite),
+    )
+    // Create a promo code redeemable on the owner's shows
+    g.POST("/promo-codes", h.CreatePromoCode)
+    // List the owner's promo codes with usage counts
+    g.GET("/promo-codes", h.ListPromoCodes)
+}
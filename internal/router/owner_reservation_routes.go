@@ -8,24 +8,39 @@ package router
 import (
     "github.com/iliyamo/cinema-seat-reservation/internal/handler"
     "github.com/iliyamo/cinema-seat-reservation/internal/middleware"
+    "github.com/iliyamo/cinema-seat-reservation/internal/permissions"
+    "github.com/iliyamo/cinema-seat-reservation/internal/utils"
     "github.com/labstack/echo/v4"
 )
 
 // RegisterOwnerReservations registers routes that allow owners to manage
 // reservations.  All routes are mounted under /v1 and require a
-// JWT token as well as the OWNER role.  The provided handler
+// JWT token; viewing needs the reservation:read scope, cancelling the
+// reservation:cancel scope, checking in checkin:write, attendance
+// reports:read and refund management refund:manage.  The provided handler
 // supplies the business logic for listing, retrieving and deleting
 // reservations.
-func RegisterOwnerReservations(e *echo.Echo, h *handler.OwnerReservationHandler, jwtSecret string) {
+func RegisterOwnerReservations(e *echo.Echo, h *handler.OwnerReservationHandler, keys *utils.KeySet) {
     g := e.Group(
         "/v1",
-        middleware.JWTAuth(jwtSecret),
-        middleware.RequireRole("OWNER"),
+        middleware.JWTAuth(keys),
     )
+    read := middleware.RequireScope(permissions.ReservationRead)
     // List all reservations for a specific show
-    g.GET("/shows/:id/reservations", h.ListShowReservations)
+    g.GET("/shows/:id/reservations", h.ListShowReservations, read)
+    // Download all reservations of a show as CSV
+    g.GET("/shows/:id/reservations/export", h.ExportShowReservations, read)
     // Retrieve a single reservation (owner perspective)
-    g.GET("/owner/reservations/:id", h.GetOwnerReservation)
+    g.GET("/owner/reservations/:id", h.GetOwnerReservation, read)
     // Cancel a reservation before the show starts (owner override)
-    g.DELETE("/owner/reservations/:id", h.DeleteOwnerReservation)
+    g.DELETE("/owner/reservations/:id", h.DeleteOwnerReservation, middleware.RequireScope(permissions.ReservationCancel))
+    // Check customers in at the door, and see who came
+    g.POST("/owner/reservations/:id/check-in", h.CheckInReservation, middleware.RequireScope(permissions.CheckIn))
+    g.GET("/owner/shows/:id/attendance", h.GetShowAttendance, middleware.RequireScope(permissions.ReportsRead))
+    // Decide on customers' refund requests and settle approved refunds
+    refunds := middleware.RequireScope(permissions.RefundManage)
+    g.GET("/owner/refunds", h.ListRefunds, refunds)
+    g.POST("/owner/refunds/:id/approve", h.ApproveRefund, refunds)
+    g.POST("/owner/refunds/:id/deny", h.DenyRefund, refunds)
+    g.POST("/owner/refunds/:id/process", h.ProcessRefund, refunds)
 }
\ No newline at end of file
@@ -2,51 +2,108 @@ package router // router defines how HTTP routes are registered for the API
 
 import (
 	"github.com/iliyamo/cinema-seat-reservation/internal/handler"    // owner handlers
-	"github.com/iliyamo/cinema-seat-reservation/internal/middleware" // JWT + role middlewares
+	"github.com/iliyamo/cinema-seat-reservation/internal/middleware"
+	"github.com/iliyamo/cinema-seat-reservation/internal/permissions" // JWT + role middlewares
+	"github.com/iliyamo/cinema-seat-reservation/internal/utils"      // JWT key set
 	"github.com/labstack/echo/v4"
 )
 
-// RegisterOwner registers OWNER-scoped endpoints under /v1.
-// All routes require a valid JWT and OWNER role.
-func RegisterOwner(e *echo.Echo, o *handler.OwnerHandler, jwtSecret string) {
+// RegisterOwner registers owner management endpoints under /v1.  All
+// routes require a valid JWT; cinemas, halls and seats additionally need
+// the cinema:write scope, except that editing an existing hall needs
+// hall:write (granted to staff as well), and shows the show:write scope.
+// Seat map snapshots and notification settings need reports:read.
+func RegisterOwner(e *echo.Echo, o *handler.OwnerHandler, keys *utils.KeySet) {
 	// Attach middlewares at group construction time for clarity.
 	g := e.Group(
 		"/v1",
-		middleware.JWTAuth(jwtSecret),
-		middleware.RequireRole("OWNER"),
+		middleware.JWTAuth(keys),
 	)
+	cinemaWrite := middleware.RequireScope(permissions.CinemaWrite)
+	hallWrite := middleware.RequireScope(permissions.HallWrite)
+	showWrite := middleware.RequireScope(permissions.ShowWrite)
 
 	// ---- Cinemas ----
-	g.POST("/cinemas", o.CreateCinema)
+	g.POST("/cinemas", o.CreateCinema, cinemaWrite)
 	// NOTE: Listing cinemas is handled by the public browse API.  Owner‑scoped
 	// list endpoints have been removed to avoid route conflicts with the
 	// public /v1/cinemas handler.
 	// g.GET("/cinemas", o.ListCinemas)
-	g.PUT("/cinemas/:id", o.UpdateCinema)
-	g.PATCH("/cinemas/:id", o.UpdateCinema) // allow partial/semantic updates via PATCH as well
-	g.DELETE("/cinemas/:id", o.DeleteCinema)
+	g.PUT("/cinemas/:id", o.UpdateCinema, cinemaWrite)
+	g.PATCH("/cinemas/:id", o.UpdateCinema, cinemaWrite) // allow partial/semantic updates via PATCH as well
+	g.PUT("/cinemas/:id/location", o.UpdateCinemaLocation, cinemaWrite)
+	// public profile: logo, description, phone and opening hours
+	g.GET("/cinemas/:id/profile", o.GetCinemaProfile, cinemaWrite)
+	g.PUT("/cinemas/:id/profile", o.UpdateCinemaProfile, cinemaWrite)
+	// how long before a show customers may still cancel, for every show
+	g.PUT("/cinemas/:id/cancellation-policy", o.UpdateCinemaCancellationPolicy, cinemaWrite)
+	// how soon and how far ahead shows may be scheduled
+	g.GET("/cinemas/:id/scheduling-window", o.GetCinemaSchedulingWindow, cinemaWrite)
+	g.PUT("/cinemas/:id/scheduling-window", o.UpdateCinemaSchedulingWindow, cinemaWrite)
+	// booking fee and tax charged on top of seat prices
+	g.GET("/cinemas/:id/fees", o.GetCinemaFees, cinemaWrite)
+	g.PUT("/cinemas/:id/fees", o.UpdateCinemaFees, cinemaWrite)
+	g.DELETE("/cinemas/:id", o.DeleteCinema, cinemaWrite)
 
 	// ---- Halls ----
-	g.POST("/halls", o.CreateHall)
-	g.PUT("/halls/:id", o.UpdateHall)
-	g.PATCH("/halls/:id", o.UpdateHall)
+	g.POST("/halls", o.CreateHall, cinemaWrite)
+	g.PUT("/halls/:id", o.UpdateHall, hallWrite)
+	g.PATCH("/halls/:id", o.UpdateHall, hallWrite)
 	// NOTE: Listing halls by cinema is provided by the public API (GET /v1/cinemas/:id/halls).
 	// g.GET("/cinemas/:cinema_id/halls", o.ListHallsInCinema)
-	g.DELETE("/halls/:id", o.DeleteHall)
+	g.DELETE("/halls/:id", o.DeleteHall, cinemaWrite)
+	// copy a hall's seats, seat types and layout into a new hall
+	g.POST("/halls/:id/clone", o.CloneHall, cinemaWrite)
+	// custom seat layouts with gaps and aisles
+	g.GET("/halls/:id/layout", o.GetHallLayout, hallWrite)
+	g.PUT("/halls/:id/layout", o.PutHallLayout, hallWrite)
+	// seat map rendering metadata: screen edge, sections, row offsets
+	g.GET("/halls/:id/layout/rendering", o.GetHallRendering, hallWrite)
+	g.PUT("/halls/:id/layout/rendering", o.PutHallRendering, hallWrite)
 
 	// ---- Seats ----
-	g.POST("/seats", o.CreateSeat)
-	g.PUT("/seats/:id", o.UpdateSeat)   // returns 200 with updated seat in handler
-	g.PATCH("/seats/:id", o.UpdateSeat) // alias for clients that use PATCH
-	g.DELETE("/seats/:id", o.DeleteSeat)
+	g.POST("/seats", o.CreateSeat, cinemaWrite)
+	g.PUT("/seats/:id", o.UpdateSeat, cinemaWrite)   // returns 200 with updated seat in handler
+	g.PATCH("/seats/:id", o.UpdateSeat, cinemaWrite) // alias for clients that use PATCH
+	g.DELETE("/seats/:id", o.DeleteSeat, cinemaWrite)
+	// change the type or active flag of many seats of a hall at once
+	g.PATCH("/halls/:id/seats/bulk", o.UpdateSeatsBulk, cinemaWrite)
+	// bulk-set accessibility attributes for seats of a hall
+	g.PUT("/halls/:id/seats/accessibility", o.UpdateSeatAccessibility, hallWrite)
 
 	// ---- Shows ----
-	g.POST("/shows", o.CreateShow)
+	g.POST("/shows", o.CreateShow, showWrite)
+	g.POST("/shows/:id/duplicate", o.DuplicateShow, showWrite)
+	// block or release individual seats of one show
+	g.POST("/shows/:id/seats/block", o.BlockShowSeats, showWrite)
+	g.POST("/shows/:id/seats/unblock", o.UnblockShowSeats, showWrite)
+	// override seat prices of one show
+	g.PATCH("/shows/:id/seats/prices", o.UpdateShowSeatPrices, showWrite)
+	// cancel a show, voiding its reservations and notifying customers
+	g.POST("/shows/:id/cancel", o.CancelShow, showWrite)
+	// override the cinema's cancellation cutoff for one show
+	g.GET("/shows/:id/cancellation-policy", o.GetShowCancellationPolicy, showWrite)
+	g.PUT("/shows/:id/cancellation-policy", o.UpdateShowCancellationPolicy, showWrite)
+	// rating, language, format and minimum age of a show
+	g.GET("/shows/:id/content", o.GetShowContent, showWrite)
+	g.PUT("/shows/:id/content", o.UpdateShowContent, showWrite)
+	// bulk-schedule shows from a CSV upload; reports the outcome per row
+	g.POST("/owner/shows/import", o.ImportShows, showWrite)
+	// seat maps a show was scheduled and rebuilt with, for reconciliation
+	g.GET("/owner/shows/:id/seatmap-snapshot", o.GetSeatMapSnapshots, middleware.RequireScope(permissions.ReportsRead))
+	// repair seat statuses that disagree with the show's holds and reservations
+	g.POST("/owner/shows/:id/reconcile", o.ReconcileShow, showWrite)
+	// inspect a show's active holds and force-release stuck ones
+	g.GET("/owner/shows/:id/holds", o.ListShowHolds, middleware.RequireScope(permissions.ReservationRead))
+	g.DELETE("/owner/shows/:id/holds/:hold_id", o.ReleaseShowHold, showWrite)
+	// daily bookings digest and other notification preferences
+	g.GET("/owner/notification-settings", o.GetNotificationSettings, middleware.RequireScope(permissions.ReportsRead))
+	g.PATCH("/owner/notification-settings", o.UpdateNotificationSettings, middleware.RequireScope(permissions.ReportsRead))
 	// allow full/partial updates to show properties
-	g.PUT("/shows/:id", o.UpdateShow)
-	g.PATCH("/shows/:id", o.UpdateShow)
+	g.PUT("/shows/:id", o.UpdateShow, showWrite)
+	g.PATCH("/shows/:id", o.UpdateShow, showWrite)
 	// NOTE: Listing shows in a hall is handled by the public API at /v1/halls/:id/shows.
 	// g.GET("/halls/:hall_id/shows", o.ListShowsInHall)
-	g.DELETE("/shows/:id", o.DeleteShow)
+	g.DELETE("/shows/:id", o.DeleteShow, showWrite)
 
 }
@@ -0,0 +1,27 @@
+package router
+
+// This file registers owner-specific routes for managing staff.
+
+import (
+    "github.com/iliyamo/cinema-seat-reservation/internal/handler"
+    "github.com/iliyamo/cinema-seat-reservation/internal/middleware"
+    "github.com/iliyamo/cinema-seat-reservation/internal/permissions"
+    "github.com/iliyamo/cinema-seat-reservation/internal/utils"
+    "github.com/labstack/echo/v4"
+)
+
+// RegisterOwnerStaff registers staff management routes under /v1/owner.
+// All routes require a JWT token with the staff:manage scope.
+func RegisterOwnerStaff(e *echo.Echo, h *handler.OwnerStaffHandler, keys *utils.KeySet) {
+    g := e.Group(
+        "/v1/owner",
+        middleware.JWTAuth(keys),
+        middleware.RequireScope(permissions.StaffManage),
+    )
+    // Grant a user management of a cinema or one of its halls
+    g.POST("/staff", h.InviteStaff)
+    // List granted staff memberships
+    g.GET("/staff", h.ListStaff)
+    // Revoke a staff membership
+    g.DELETE("/staff/:id", h.RemoveStaff)
+}
@@ -0,0 +1,30 @@
+package router
+
+// This file registers owner-specific routes for managing webhooks.
+
+import (
+    "github.com/iliyamo/cinema-seat-reservation/internal/handler"
+    "github.com/iliyamo/cinema-seat-reservation/internal/middleware"
+    "github.com/iliyamo/cinema-seat-reservation/internal/permissions"
+    "github.com/iliyamo/cinema-seat-reservation/internal/utils"
+    "github.com/labstack/echo/v4"
+)
+
+// RegisterOwnerWebhooks registers webhook subscription routes under
+// /v1/owner.  All routes require a JWT token or API key with the
+// webhook:manage scope.
+func RegisterOwnerWebhooks(e *echo.Echo, h *handler.OwnerWebhookHandler, keys *utils.KeySet) {
+    g := e.Group(
+        "/v1/owner",
+        middleware.JWTAuth(keys),
+        middleware.RequireScope(permissions.WebhookManage),
+    )
+    // Subscribe a URL to booking events; the secret is only in this response
+    g.POST("/webhooks", h.CreateWebhook)
+    // List the owner's subscriptions
+    g.GET("/webhooks", h.ListWebhooks)
+    // Remove a subscription and its delivery log
+    g.DELETE("/webhooks/:id", h.DeleteWebhook)
+    // Delivery log of a subscription
+    g.GET("/webhooks/:id/deliveries", h.ListWebhookDeliveries)
+}
@@ -4,7 +4,10 @@ import (
 	"github.com/labstack/echo/v4" // import the Echo web framework to handle routing
 
 	"github.com/iliyamo/cinema-seat-reservation/internal/handler"    // import the handlers that implement business logic
-	"github.com/iliyamo/cinema-seat-reservation/internal/middleware" // import middleware for JWT authentication and role enforcement
+	"github.com/iliyamo/cinema-seat-reservation/internal/middleware"  // import middleware for JWT authentication and scope enforcement
+	"github.com/iliyamo/cinema-seat-reservation/internal/permissions" // scopes required by protected routes
+	"github.com/iliyamo/cinema-seat-reservation/internal/service"     // bot check for scraped public routes
+	"github.com/iliyamo/cinema-seat-reservation/internal/utils"      // key set for signing and verifying JWTs
 )
 
 // RegisterRoutes registers non-authenticated routes on the provided Echo instance.
@@ -16,23 +19,41 @@ func RegisterRoutes(e *echo.Echo) {
 	// endpoint can be used by load balancers or monitoring systems to verify
 	// that the service is up and running.
 	e.GET("/healthz", handler.Health)
+	// Prometheus metrics for scraping.
+	e.GET("/metrics", handler.Metrics)
+	// The OpenAPI document and a Swagger UI page describing every route.
+	e.GET("/v1/openapi.json", handler.OpenAPI)
+	e.GET("/v1/docs", handler.SwaggerUI)
+}
+
+// RegisterReadiness exposes GET /readyz, which probes the dependencies
+// registered on h and answers 503 when a required one is down.
+func RegisterReadiness(e *echo.Echo, h *handler.ReadinessHandler) {
+	e.GET("/readyz", h.Ready)
 }
 
 // RegisterAuth registers all authentication-related routes and their middleware.
 // The provided AuthHandler implements the logic for each endpoint, and the
-// jwtSecret is used to sign and verify JWT tokens for protected routes.
+// key set is used to verify JWT tokens for protected routes.
 // RegisterAuth registers all authentication‑related routes and applies the
 // necessary middleware.  Unauthenticated operations live under /v1/auth,
 // while protected endpoints live under /v1.
-func RegisterAuth(e *echo.Echo, a *handler.AuthHandler, jwtSecret string) {
+func RegisterAuth(e *echo.Echo, a *handler.AuthHandler, keys *utils.KeySet) {
 	// Create a route group under the /v1/auth prefix for operations that do
 	// not require an existing session (register, login, refresh).  Each of
 	// these handlers is responsible for generating or exchanging tokens.
 	g := e.Group("/v1/auth")
+	// Registration and login require a passed bot check when one is
+	// configured; clients fetch the challenge from /v1/auth/bot-check.
+	bot := middleware.BotCheck(a.BotCheck, keys)
+	g.GET("/bot-check", a.BotCheckChallenge)
 	// Register a POST endpoint to handle user registration at /v1/auth/register.
-	g.POST("/register", a.Register)
+	g.POST("/register", a.Register, bot)
 	// Register a POST endpoint to handle user login at /v1/auth/login.
-	g.POST("/login", a.Login)
+	g.POST("/login", a.Login, bot)
+	// Anonymous guest session that may hold seats; register or login
+	// with its token to keep the holds.
+	g.POST("/guest", a.GuestSession, bot)
     // Register a POST endpoint to refresh access tokens at /v1/auth/refresh. This rotates the refresh token.
     g.POST("/refresh", a.Refresh)
     // Register a POST endpoint to issue a new access token without rotating the refresh token.
@@ -43,20 +64,24 @@ func RegisterAuth(e *echo.Echo, a *handler.AuthHandler, jwtSecret string) {
 	// invalidate that token.  If the token is valid, a 204 response is
 	// returned; otherwise 400/401/500 are possible depending on the error.
 	g.POST("/logout", a.Logout)
+	// Password reset: request an emailed single-use link, then redeem it.
+	g.POST("/forgot-password", a.ForgotPassword)
+	g.POST("/reset-password", a.ResetPassword)
 
 	// Create another group for routes that require a valid access token.  All
 	// handlers registered on this group will execute the JWTAuth middleware
 	// before being invoked.  Protected endpoints live under /v1.
 	auth := e.Group("/v1")
-	// Apply the JWTAuth middleware to the protected group using the provided secret.
-	auth.Use(middleware.JWTAuth(jwtSecret))
-	// Apply the RequireRole middleware for any authenticated endpoint.  At
-	// this stage of the project we accept both OWNER and CUSTOMER roles on
-	// protected endpoints.  The middleware will reject requests with
-	// missing or unknown roles.
-	auth.Use(middleware.RequireRole("OWNER", "CUSTOMER"))
+	// Apply the JWTAuth middleware to the protected group using the provided key set.
+	auth.Use(middleware.JWTAuth(keys))
+	// Account endpoints require the account:manage scope, which every
+	// role is granted.
+	auth.Use(middleware.RequireScope(permissions.AccountManage))
 	// Register a GET endpoint at /v1/me that returns the authenticated user's information.
 	auth.GET("/me", a.Me)
+	// Signed-in devices: list active refresh tokens and revoke one of them.
+	auth.GET("/me/sessions", a.ListSessions)
+	auth.DELETE("/me/sessions/:id", a.RevokeSession)
 
 	// Additionally map POST /v1/logout to the same handler.  This route lives
 	// at the top level (outside of the protected group) so it does not
@@ -69,29 +94,63 @@ func RegisterAuth(e *echo.Echo, a *handler.AuthHandler, jwtSecret string) {
 
 // RegisterPublic registers unauthenticated browse endpoints on the provided Echo instance.
 // The provided PublicHandler exposes handlers that return sanitized data for cinemas,
-// halls and shows. These routes do not apply any JWT or role middleware and are
-// intended for guest users.
-func RegisterPublic(e *echo.Echo, p *handler.PublicHandler) {
+// halls and shows. These routes do not require a JWT and are intended for
+// guest users; the seat maps optionally accept one (verified with keys)
+// to mark the caller's own holds.  mw is applied to every route, e.g. the
+// response cache.  The seat maps and availability counts, which
+// scrapers poll most, also require a passed bot check (nil bot disables
+// it); it runs before the cache so cached responses are covered too.
+func RegisterPublic(e *echo.Echo, p *handler.PublicHandler, keys *utils.KeySet, bot *service.BotGuard, mw ...echo.MiddlewareFunc) {
+    botMW := middleware.BotCheck(bot, keys)
+    hotMW := append([]echo.MiddlewareFunc{botMW}, mw...)
+    // Seat maps identify a signed-in caller before the response cache,
+    // which then leaves the per-user response uncached.
+    seatMW := append([]echo.MiddlewareFunc{middleware.OptionalJWT(keys)}, hotMW...)
     // Expose list of all cinemas
-    e.GET("/v1/cinemas", p.GetPublicCinemas)
+    e.GET("/v1/cinemas", p.GetPublicCinemas, mw...)
+    // One cinema with its profile: logo, description, phone, opening hours
+    e.GET("/v1/cinemas/:id", p.GetPublicCinema, mw...)
     // List halls of a specific cinema
-    e.GET("/v1/cinemas/:id/halls", p.GetPublicHallsByCinema)
+    e.GET("/v1/cinemas/:id/halls", p.GetPublicHallsByCinema, mw...)
     // List shows of a specific hall
-    e.GET("/v1/halls/:id/shows", p.GetPublicShowsByHall)
+    e.GET("/v1/halls/:id/shows", p.GetPublicShowsByHall, mw...)
     // Show details by show id
-    e.GET("/v1/shows/:id", p.GetPublicShow)
+    e.GET("/v1/shows/:id", p.GetPublicShow, mw...)
     // Publicly view the seating layout of a hall (rows and columns of seats)
     // This endpoint returns the raw seat grid grouped by row.  Authentication is not required so that
     // guests can preview a hall before selecting seats.
-    e.GET("/v1/halls/:id/seats/layout", p.GetPublicHallLayout)
+    e.GET("/v1/halls/:id/seats/layout", p.GetPublicHallLayout, mw...)
     // Publicly view seat availability for a specific show.  Seat status is derived from show seats and active holds.
-    // Status values can be FREE, HELD or RESERVED.
-    e.GET("/v1/shows/:id/seats", p.GetPublicShowSeats)
+    // Status values can be FREE, HELD or RESERVED; with a bearer token each seat also
+    // reports held_by_me.
+    e.GET("/v1/shows/:id/seats", p.GetPublicShowSeats, seatMW...)
+    // Same seat map annotated with seat type and accessibility attributes.
+    e.GET("/v1/shows/:id/seats/accessibility", p.GetPublicShowSeatsAccessibility, seatMW...)
+    // Seat counts per status, overall and per seat type, without the seat map.
+    e.GET("/v1/shows/:id/availability", p.GetPublicShowAvailability, hotMW...)
+    // The same counts for many shows at once.  The colon is escaped so
+    // Echo does not read ":batch" as a path parameter; POST responses are
+    // not cached.
+    e.POST("/v1/shows/availability\\:batch", p.GetPublicShowsAvailability, botMW)
+    // Price a seat selection before holding it; not cached.
+    e.POST("/v1/shows/:id/quote", p.QuoteSeats, botMW)
 
     // Publicly view the list of all seats in a hall (flat list).  This route returns
     // a simple array of seats with row labels, numbers, types and active flags.  No
     // authentication is required so that guests can inspect a hall's seats before
     // choosing a show.  Use the optional ?active=true|false query parameter to
     // filter by a seat's is_active flag.
-    e.GET("/v1/halls/:id/seats", p.GetPublicHallSeats)
+    e.GET("/v1/halls/:id/seats", p.GetPublicHallSeats, mw...)
+
+    // Search scheduled shows by title, cinema name, day and city with
+    // page/page_size pagination.
+    e.GET("/v1/search", p.Search, mw...)
+    // Scheduled shows of every cinema on a day or range of days, with
+    // optional cinema_id and city filters.
+    e.GET("/v1/shows", p.ListShows, mw...)
+    // GraphQL over the same data, availability included.  Its responses
+    // are not cached as the cache cannot tell which shows they contain.
+    e.GET("/v1/graphql", p.GraphQL, botMW)
+    e.POST("/v1/graphql", p.GraphQL, botMW)
+    e.GET("/v1/graphql/schema", p.GraphQLSchema, mw...)
 }
@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/iliyamo/cinema-seat-reservation/internal/permissions"
+	"github.com/iliyamo/cinema-seat-reservation/internal/repository"
+	"github.com/iliyamo/cinema-seat-reservation/internal/utils"
+)
+
+// apiKeyPrefix starts every API key so leaked keys are easy to recognise
+// in logs and by secret scanners.
+const apiKeyPrefix = "csr_"
+
+// apiKeyShownChars is how many leading characters of a key are kept as
+// its prefix.
+const apiKeyShownChars = 12
+
+// APIKeyScopes are the scopes an owner API key may be granted: the
+// owner's scopes except managing the account, staff and API keys, which
+// stay with the signed-in owner.
+var APIKeyScopes = []permissions.Scope{
+	permissions.CinemaWrite, permissions.HallWrite, permissions.ShowWrite, permissions.PromoWrite,
+	permissions.ReservationRead, permissions.ReservationCancel, permissions.RefundManage,
+	permissions.ReportsRead, permissions.AuditRead, permissions.WebhookManage,
+}
+
+// ErrInvalidAPIKey is returned by APIKeyService.Authenticate for keys
+// that are malformed, unknown or revoked.
+var ErrInvalidAPIKey = errors.New("invalid api key")
+
+// InvalidScopeError is returned by APIKeyService.Create for scopes an API
+// key cannot be granted.
+type InvalidScopeError struct {
+	Scope string
+}
+
+func (e *InvalidScopeError) Error() string {
+	return fmt.Sprintf("scope %q cannot be granted to an API key", e.Scope)
+}
+
+// APIKeyService issues and checks long-lived API keys that let an owner's
+// servers call the owner API without signing in.  Keys are random, shown
+// once at creation and stored as their SHA-256 hash.  Each key has its own
+// request budget: RatePerMin from the key, or DefaultPerMin.
+type APIKeyService struct {
+	Repo          *repository.APIKeyRepo
+	DefaultPerMin int
+}
+
+// NewAPIKeyService constructs an APIKeyService.  repo must be non-nil.
+func NewAPIKeyService(repo *repository.APIKeyRepo, defaultPerMin int) *APIKeyService {
+	if repo == nil {
+		panic("nil repository passed to NewAPIKeyService")
+	}
+	return &APIKeyService{Repo: repo, DefaultPerMin: defaultPerMin}
+}
+
+// Create issues a key for ownerID with the given scopes, all of
+// APIKeyScopes when empty, and returns it with the raw key, which cannot
+// be recovered later.  ratePerMin optionally overrides the default
+// budget.
+func (s *APIKeyService) Create(ctx context.Context, ownerID uint64, name string, scopes []string, ratePerMin *uint32) (*repository.APIKey, string, error) {
+	granted := APIKeyScopes
+	if len(scopes) > 0 {
+		allowed := permissions.NewSet(APIKeyScopes...)
+		granted = make([]permissions.Scope, 0, len(scopes))
+		for _, sc := range scopes {
+			if !allowed[permissions.Scope(sc)] {
+				return nil, "", &InvalidScopeError{Scope: sc}
+			}
+			granted = append(granted, permissions.Scope(sc))
+		}
+	}
+	secret, err := utils.NewSecret()
+	if err != nil {
+		return nil, "", err
+	}
+	raw := apiKeyPrefix + secret
+	k := &repository.APIKey{
+		OwnerID:    ownerID,
+		Name:       strings.TrimSpace(name),
+		Prefix:     raw[:apiKeyShownChars],
+		Scopes:     permissions.Claim(granted),
+		RatePerMin: ratePerMin,
+	}
+	if err := s.Repo.Create(ctx, k, utils.HashRefreshRaw(raw)); err != nil {
+		return nil, "", err
+	}
+	return k, raw, nil
+}
+
+// Authenticate returns the active key matching raw, or ErrInvalidAPIKey.
+// It records the key's use.
+func (s *APIKeyService) Authenticate(ctx context.Context, raw string) (*repository.APIKey, error) {
+	if !strings.HasPrefix(raw, apiKeyPrefix) {
+		return nil, ErrInvalidAPIKey
+	}
+	k, err := s.Repo.GetActiveByHash(ctx, utils.HashRefreshRaw(raw))
+	if errors.Is(err, repository.ErrAPIKeyNotFound) {
+		return nil, ErrInvalidAPIKey
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := s.Repo.TouchLastUsed(ctx, k.ID); err != nil {
+		log.Printf("api key %d: recording use failed: %v", k.ID, err)
+	}
+	return k, nil
+}
+
+// Policy returns the rate limit policy of k.  A key may send its whole
+// per-minute budget at once.
+func (s *APIKeyService) Policy(k *repository.APIKey) RateLimitPolicy {
+	perMin := s.DefaultPerMin
+	if k.RatePerMin != nil {
+		perMin = int(*k.RatePerMin)
+	}
+	return RateLimitPolicy{Burst: perMin, PerMinute: perMin}
+}
@@ -0,0 +1,967 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/iliyamo/cinema-seat-reservation/internal/db"
+	"github.com/iliyamo/cinema-seat-reservation/internal/metrics"
+	"github.com/iliyamo/cinema-seat-reservation/internal/repository"
+)
+
+var (
+	// ErrNoActiveHolds is returned when the customer has no active holds
+	// on the show to confirm or extend.
+	ErrNoActiveHolds = errors.New("no active holds for this show")
+	// ErrHoldExtensionsDisabled is returned by ExtendHolds when no
+	// extension increment is configured.
+	ErrHoldExtensionsDisabled = errors.New("hold extensions are disabled")
+	// ErrHoldLimitReached is returned by ExtendHolds when the holds have
+	// already reached the maximum hold lifetime.
+	ErrHoldLimitReached = errors.New("maximum hold duration reached")
+	// ErrPromoCodesUnsupported is returned when a promo code is redeemed
+	// but no promo code repository is configured.
+	ErrPromoCodesUnsupported = errors.New("promo codes are not supported")
+	// ErrPromoCodeInvalid is returned when a promo code exists but is
+	// expired, not yet valid or used up.
+	ErrPromoCodeInvalid = errors.New("promo code is not valid")
+	// ErrShowStarted is returned by CancelReservation when the show of
+	// the reservation has already started.
+	ErrShowStarted = errors.New("show already started")
+)
+
+// CancelCutoffError is returned by CancelReservation when the show starts
+// within its cancellation cutoff.
+type CancelCutoffError struct {
+	Cutoff   time.Duration
+	Deadline time.Time // the last moment the reservation could be cancelled
+}
+
+func (e *CancelCutoffError) Error() string {
+	return fmt.Sprintf("reservations can only be cancelled until %d minutes before the show", int(e.Cutoff/time.Minute))
+}
+
+// HoldQuotaError is returned by Hold when the customer already holds seats
+// on MaxHeldShows other shows.  Active lists those holds.
+type HoldQuotaError struct {
+	Limit  int
+	Active []repository.SeatHoldRecord
+}
+
+func (e *HoldQuotaError) Error() string {
+	return fmt.Sprintf("seats can be held on at most %d shows at once", e.Limit)
+}
+
+// AgeRestrictedError is returned when a show has a minimum age and the
+// customer gave no date of birth (NeedDateOfBirth) or is younger than
+// MinAge when the show starts.
+type AgeRestrictedError struct {
+	MinAge          int
+	NeedDateOfBirth bool
+}
+
+func (e *AgeRestrictedError) Error() string {
+	if e.NeedDateOfBirth {
+		return fmt.Sprintf("the show is restricted to ages %d and up; a date of birth is required", e.MinAge)
+	}
+	return fmt.Sprintf("the show is restricted to ages %d and up", e.MinAge)
+}
+
+// CurrencyMismatchError is returned when amounts in different currencies
+// would be combined: a hold quoted in Quoted is confirmed after the
+// show's cinema switched to Current, or one checkout spans shows priced in
+// different currencies.
+type CurrencyMismatchError struct {
+	Quoted  string
+	Current string
+}
+
+func (e *CurrencyMismatchError) Error() string {
+	return fmt.Sprintf("prices in %s cannot be combined with prices in %s", e.Quoted, e.Current)
+}
+
+// SeatsNotHeldError reports held seats that can no longer be confirmed
+// because they are not HELD by the customer any more.
+type SeatsNotHeldError struct {
+	ShowID  uint64
+	SeatIDs []uint64
+}
+
+func (e *SeatsNotHeldError) Error() string {
+	return fmt.Sprintf("seats no longer held for show %d", e.ShowID)
+}
+
+// HoldsNotActiveError reports hold tokens that name no active hold of the
+// customer on the show.
+type HoldsNotActiveError struct {
+	Tokens []string
+}
+
+func (e *HoldsNotActiveError) Error() string {
+	return "some holds are no longer active"
+}
+
+// PriceChange is a seat whose price changed between hold and confirmation.
+type PriceChange struct {
+	SeatID            uint64 `json:"seat_id"`
+	QuotedPriceCents  uint32 `json:"quoted_price_cents"`
+	CurrentPriceCents uint32 `json:"current_price_cents"`
+}
+
+// PriceChangedError is returned when seat prices changed since the seats
+// were held and the customer did not accept the new prices.
+type PriceChangedError struct {
+	Changes           []PriceChange
+	QuotedTotalCents  uint32
+	CurrentTotalCents uint32
+}
+
+func (e *PriceChangedError) Error() string {
+	return "seat prices changed since the seats were held"
+}
+
+// HoldResult describes the seats held by one hold request.
+type HoldResult struct {
+	ExpiresAt        time.Time
+	SeatIDs          []uint64                    // seats held by this request
+	Holds            []repository.SeatHoldRecord // holds created by this request
+	Prices           map[uint64]uint32           // quoted price per held seat
+	QuotedTotalCents uint32
+	Currency         string                      // currency of Prices and QuotedTotalCents
+	Active           []repository.SeatHoldRecord // every active hold of the customer on the show
+	Unavailable      []uint64                    // requested seats a partial hold skipped
+}
+
+// ReserveRequest asks to turn a customer's holds on a show into a
+// reservation.  When HoldTokens is non-empty only those holds are used;
+// otherwise every active hold of the customer on the show is.  Status is
+// the status of the new reservation.
+type ReserveRequest struct {
+	UserID            uint64
+	ShowID            uint64
+	HoldTokens        []string
+	PromoCode         string
+	AcceptPriceChange bool
+	Status            string
+}
+
+// ReservePreview is the reservation a ReserveRequest amounts to: the
+// seats and their current prices, the price changes since the seats were
+// held, the promo code discount, the cinema's fee and tax and the total
+// charged.
+type ReservePreview struct {
+	SeatIDs          []uint64
+	Prices           map[uint64]uint32
+	PriceChanges     []PriceChange
+	QuotedTotalCents uint32 // total at the prices quoted when the seats were held
+	SubtotalCents    uint32 // sum of the current seat prices
+	DiscountCents    uint32
+	FeesCents        uint32
+	TaxCents         uint32
+	TotalAmountCents uint32 // SubtotalCents - DiscountCents + FeesCents + TaxCents
+	Currency         string
+	Holds            []repository.SeatHoldRecord // holds the reservation uses
+	PromoCode        *repository.PromoCode       // nil without a promo code
+}
+
+// BookingService implements the customer booking workflow: holding seats,
+// releasing and extending holds and turning holds into reservations.  It
+// owns the transactions and the rules around them, so the HTTP handlers
+// only bind requests and render results and other entry points can reuse
+// the same workflow.  Errors are the repository sentinels, the error
+// values and types above, ShowNotBookableError and SeatsUnavailableError;
+// anything else is a database error, possibly a transient one.
+type BookingService struct {
+	ShowRepo        repository.ShowStore
+	ShowSeatRepo    repository.ShowSeatStore
+	SeatHoldRepo    repository.SeatHoldStore
+	ReservationRepo repository.ReservationStore
+	PromoCodeRepo   *repository.PromoCodeRepo // optional; enables promo codes on reservations
+	Waitlist        *WaitlistService          // optional; offers released seats and closes fulfilled offers
+	Users           *repository.UserRepo      // optional; enables the age check of age-restricted shows
+
+	// HoldTTL is the default lifetime of a seat hold.  A show's
+	// hold_ttl_sec overrides it; when both are unset five minutes is used.
+	HoldTTL time.Duration
+
+	// HoldExtendBy and HoldMaxTotal configure ExtendHolds.  A zero
+	// HoldExtendBy disables extensions.
+	HoldExtendBy time.Duration
+	HoldMaxTotal time.Duration
+
+	// MaxHeldShows caps the number of shows a customer may hold seats on
+	// at once; adding seats to a show already held is always allowed.
+	// Zero disables the limit.
+	MaxHeldShows int
+}
+
+// NewBookingService constructs a BookingService.  All dependencies must be
+// non-nil.
+func NewBookingService(showRepo repository.ShowStore, showSeatRepo repository.ShowSeatStore, seatHoldRepo repository.SeatHoldStore, reservationRepo repository.ReservationStore) *BookingService {
+	if showRepo == nil || showSeatRepo == nil || seatHoldRepo == nil || reservationRepo == nil {
+		panic("nil repository passed to NewBookingService")
+	}
+	return &BookingService{
+		ShowRepo:        showRepo,
+		ShowSeatRepo:    showSeatRepo,
+		SeatHoldRepo:    seatHoldRepo,
+		ReservationRepo: reservationRepo,
+	}
+}
+
+// HoldTTLFor returns the effective hold duration for a show: the show's
+// own override when set, otherwise HoldTTL, otherwise five minutes.
+func (s *BookingService) HoldTTLFor(show *repository.Show) time.Duration {
+	if show != nil && show.HoldTTLSec != nil && *show.HoldTTLSec > 0 {
+		return time.Duration(*show.HoldTTLSec) * time.Second
+	}
+	if s.HoldTTL > 0 {
+		return s.HoldTTL
+	}
+	return 5 * time.Minute
+}
+
+// Hold places holds on seatIDs of show for userID.  Stale holds are
+// expired first; then the requested show_seats rows are locked and the
+// request fails with SeatsUnavailableError unless every seat is FREE and
+// unheld.  Otherwise the holds are created at the current seat prices,
+// which Reserve later compares against, and the seats become HELD.
+// With partial the hold is best-effort instead: the available seats are
+// held, the others are listed in HoldResult.Unavailable, and
+// SeatsUnavailableError is returned only when none is available.  Shows
+// that are not SCHEDULED yield ShowNotBookableError, and a new show
+// beyond MaxHeldShows yields HoldQuotaError.
+func (s *BookingService) Hold(ctx context.Context, userID uint64, show *repository.Show, seatIDs []uint64, partial bool) (*HoldResult, error) {
+	var res *HoldResult
+	var expired int
+	err := db.WithTx(ctx, s.ShowRepo.DB(), func(tx *sql.Tx) error {
+		var err error
+		res, expired, err = s.holdTx(ctx, tx, userID, show, seatIDs, partial)
+		return err
+	})
+	// Counted once here rather than in holdTx, which runs again when the
+	// transaction is retried after a deadlock.
+	var unavailable *SeatsUnavailableError
+	if errors.As(err, &unavailable) || (err == nil && len(res.Unavailable) > 0) {
+		metrics.SeatConflicts.Inc("hold")
+	}
+	if err != nil {
+		return nil, err
+	}
+	metrics.HoldsExpired.Add(float64(expired), "request")
+	metrics.HoldsCreated.Add(float64(len(res.SeatIDs)))
+	return res, nil
+}
+
+func (s *BookingService) holdTx(ctx context.Context, tx *sql.Tx, userID uint64, show *repository.Show, seatIDs []uint64, partial bool) (*HoldResult, int, error) {
+	showID := show.ID
+	// Lock the show row shared so a concurrent cancellation cannot commit
+	// until these holds do, and refuse shows that are no longer SCHEDULED.
+	status, err := s.ShowRepo.StatusForShareTx(ctx, tx, showID)
+	if err != nil {
+		return nil, 0, err
+	}
+	if status != "SCHEDULED" {
+		return nil, 0, &ShowNotBookableError{ShowID: showID}
+	}
+	if err := s.checkHoldQuotaTx(ctx, tx, userID, showID); err != nil {
+		return nil, 0, err
+	}
+	expired, err := s.expireHoldsTx(ctx, tx, showID)
+	if err != nil {
+		return nil, 0, err
+	}
+	// All requested show_seats rows are locked with one SELECT ... FOR
+	// UPDATE in ascending seat_id order (see LockSeatsTx), joined against
+	// active holds, so the check costs one round trip regardless of
+	// selection size and overlapping requests cannot deadlock on
+	// inconsistent lock order.  The locks are kept until commit, so no
+	// other transaction can take a seat found FREE here.
+	locked, err := s.ShowSeatRepo.LockSeatsTx(ctx, tx, showID, seatIDs)
+	if err != nil {
+		return nil, 0, err
+	}
+	holdable, unavailable, err := holdableSeats(showID, seatIDs, locked, false, partial)
+	if err != nil {
+		return nil, 0, err
+	}
+	expiresAt := time.Now().UTC().Add(s.HoldTTLFor(show))
+	holds, err := repository.GenerateHoldRecords(userID, showID, holdable, expiresAt)
+	if err != nil {
+		return nil, 0, err
+	}
+	// Capture the price quoted for each seat, and its currency, so that a
+	// later confirmation can detect changes made between hold and
+	// confirmation.
+	prices, currency, quotedTotal, err := s.quoteTx(ctx, tx, showID, holdable)
+	if err != nil {
+		return nil, 0, err
+	}
+	for i := range holds {
+		p := prices[holds[i].SeatID]
+		holds[i].PriceCents = &p
+		holds[i].Currency = &currency
+	}
+	if err := s.SeatHoldRepo.CreateMultipleTx(ctx, tx, holds); err != nil {
+		return nil, 0, err
+	}
+	if err := s.ShowSeatRepo.BulkUpdateStatusTx(ctx, tx, showID, holdable, "HELD"); err != nil {
+		return nil, 0, err
+	}
+	// Load every active hold of the user for this show, including holds
+	// from earlier requests, so callers can present the whole checkout
+	// session.
+	active, err := s.SeatHoldRepo.ActiveHoldsByUserAndShowTx(ctx, tx, userID, showID)
+	if err != nil {
+		return nil, 0, err
+	}
+	return &HoldResult{
+		ExpiresAt:        expiresAt,
+		SeatIDs:          holdable,
+		Holds:            holds,
+		Prices:           prices,
+		QuotedTotalCents: quotedTotal,
+		Currency:         currency,
+		Active:           active,
+		Unavailable:      unavailable,
+	}, len(expired), nil
+}
+
+// PreviewHold runs the checks of Hold without holding anything and
+// returns the HoldResult Hold would return, without Holds and with Active
+// listing the customer's current holds on the show.  The seats and prices
+// are read in a read-only transaction without locks, so a later Hold can
+// still fail.  Seats whose hold has expired count as FREE, since Hold
+// expires such holds first.  partial is as for Hold.
+func (s *BookingService) PreviewHold(ctx context.Context, userID uint64, show *repository.Show, seatIDs []uint64, partial bool) (*HoldResult, error) {
+	showID := show.ID
+	if show.Status != "SCHEDULED" {
+		return nil, &ShowNotBookableError{ShowID: showID}
+	}
+	if s.MaxHeldShows > 0 {
+		active, err := s.SeatHoldRepo.ActiveHoldsByUser(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.holdQuotaError(active, showID); err != nil {
+			return nil, err
+		}
+	}
+	var res *HoldResult
+	err := db.ReadOnlyTx(ctx, s.ShowRepo.DB(), func(tx *sql.Tx) error {
+		seats, err := s.ShowSeatRepo.SeatsTx(ctx, tx, showID, seatIDs)
+		if err != nil {
+			return err
+		}
+		holdable, unavailable, err := holdableSeats(showID, seatIDs, seats, true, partial)
+		if err != nil {
+			return err
+		}
+		prices, currency, total, err := s.quoteTx(ctx, tx, showID, holdable)
+		if err != nil {
+			return err
+		}
+		active, err := s.SeatHoldRepo.ActiveHoldsByUserAndShowTx(ctx, tx, userID, showID)
+		if err != nil {
+			return err
+		}
+		res = &HoldResult{
+			ExpiresAt:        time.Now().UTC().Add(s.HoldTTLFor(show)),
+			SeatIDs:          holdable,
+			Prices:           prices,
+			QuotedTotalCents: total,
+			Currency:         currency,
+			Active:           active,
+			Unavailable:      unavailable,
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// Quote is the price of a seat selection at the current seat prices,
+// with the booking fee and tax of the show's cinema.
+type Quote struct {
+	Prices        map[uint64]uint32
+	SubtotalCents uint32
+	FeesCents     uint32
+	TaxCents      uint32
+	TotalCents    uint32
+	Currency      string
+}
+
+// Quote prices seatIDs of show as Hold would, in a read-only transaction
+// and without checking whether the seats are free.  Shows that are not
+// SCHEDULED yield ShowNotBookableError.
+func (s *BookingService) Quote(ctx context.Context, show *repository.Show, seatIDs []uint64) (*Quote, error) {
+	if show.Status != "SCHEDULED" {
+		return nil, &ShowNotBookableError{ShowID: show.ID}
+	}
+	var q *Quote
+	err := db.ReadOnlyTx(ctx, s.ShowRepo.DB(), func(tx *sql.Tx) error {
+		prices, currency, subtotal, err := s.quoteTx(ctx, tx, show.ID, seatIDs)
+		if err != nil {
+			return err
+		}
+		for _, sid := range seatIDs {
+			if _, ok := prices[sid]; !ok {
+				return fmt.Errorf("price not found for seat %d", sid)
+			}
+		}
+		fees, err := s.ShowRepo.FeesTx(ctx, tx, show.ID)
+		if err != nil {
+			return err
+		}
+		q = &Quote{Prices: prices, SubtotalCents: subtotal, Currency: currency}
+		q.FeesCents, q.TaxCents = fees.Charges(len(seatIDs), subtotal)
+		q.TotalCents = subtotal + q.FeesCents + q.TaxCents
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+// holdableSeats splits seatIDs into the seats that exist and are FREE with
+// no unexpired hold by anyone and the unavailable others.  Unless partial,
+// any unavailable seat yields SeatsUnavailableError listing them; with
+// partial only a selection without holdable seats does.  With
+// expiredFree, HELD seats without an unexpired hold count as FREE.
+func holdableSeats(showID uint64, seatIDs []uint64, seats map[uint64]repository.LockedSeat, expiredFree, partial bool) ([]uint64, []uint64, error) {
+	unavailable := make([]uint64, 0)
+	holdable := make([]uint64, 0, len(seatIDs))
+	for _, sid := range seatIDs {
+		ls, ok := seats[sid]
+		free := ok && ls.HeldBy == nil && (ls.Status == "FREE" || (expiredFree && ls.Status == "HELD"))
+		if !free {
+			unavailable = append(unavailable, sid)
+			continue
+		}
+		holdable = append(holdable, sid)
+	}
+	if len(unavailable) > 0 && (!partial || len(holdable) == 0) {
+		return nil, unavailable, &SeatsUnavailableError{ShowID: showID, SeatIDs: unavailable}
+	}
+	return holdable, unavailable, nil
+}
+
+// quoteTx returns the current prices of seatIDs, the show's currency and
+// the total.
+func (s *BookingService) quoteTx(ctx context.Context, tx *sql.Tx, showID uint64, seatIDs []uint64) (map[uint64]uint32, string, uint32, error) {
+	prices, err := s.ShowSeatRepo.GetPricesBySeatIDsTx(ctx, tx, showID, seatIDs)
+	if err != nil {
+		return nil, "", 0, err
+	}
+	currency, err := s.ShowRepo.CurrencyTx(ctx, tx, showID)
+	if err != nil {
+		return nil, "", 0, err
+	}
+	total := uint32(0)
+	for _, sid := range seatIDs {
+		total += prices[sid]
+	}
+	return prices, currency, total, nil
+}
+
+// checkHoldQuotaTx fails with HoldQuotaError when userID holds seats on
+// MaxHeldShows shows other than showID.
+func (s *BookingService) checkHoldQuotaTx(ctx context.Context, tx *sql.Tx, userID, showID uint64) error {
+	if s.MaxHeldShows <= 0 {
+		return nil
+	}
+	active, err := s.SeatHoldRepo.LockActiveHoldsByUserTx(ctx, tx, userID)
+	if err != nil {
+		return err
+	}
+	return s.holdQuotaError(active, showID)
+}
+
+// holdQuotaError returns HoldQuotaError when the active holds span
+// MaxHeldShows shows, none of them showID.
+func (s *BookingService) holdQuotaError(active []repository.SeatHoldRecord, showID uint64) error {
+	shows := make(map[uint64]bool)
+	for _, h := range active {
+		if h.ShowID == showID {
+			return nil
+		}
+		shows[h.ShowID] = true
+	}
+	if len(shows) >= s.MaxHeldShows {
+		return &HoldQuotaError{Limit: s.MaxHeldShows, Active: active}
+	}
+	return nil
+}
+
+// checkAgeTx fails with AgeRestrictedError when the show has a minimum
+// age and userID has no date of birth or is younger than that when the
+// show starts.  It does nothing without Users.
+func (s *BookingService) checkAgeTx(ctx context.Context, tx *sql.Tx, userID, showID uint64) error {
+	if s.Users == nil {
+		return nil
+	}
+	minAge, startsAt, err := s.ShowRepo.AgeRestrictionTx(ctx, tx, showID)
+	if err != nil || minAge == 0 {
+		return err
+	}
+	dob, err := s.Users.DateOfBirth(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if dob == nil {
+		return &AgeRestrictedError{MinAge: int(minAge), NeedDateOfBirth: true}
+	}
+	if ageOn(*dob, startsAt) < int(minAge) {
+		return &AgeRestrictedError{MinAge: int(minAge)}
+	}
+	return nil
+}
+
+// ageOn returns the age in whole years of someone born on dob at t.
+func ageOn(dob, t time.Time) int {
+	t = t.UTC()
+	age := t.Year() - dob.Year()
+	if t.Month() < dob.Month() || (t.Month() == dob.Month() && t.Day() < dob.Day()) {
+		age--
+	}
+	return age
+}
+
+// ReleaseHolds releases every hold of userID on a show, returns the seats
+// to FREE and offers them to the show's waitlist.  It returns the number
+// of seats released.
+func (s *BookingService) ReleaseHolds(ctx context.Context, userID, showID uint64) (int, error) {
+	var released int
+	err := db.WithTx(ctx, s.ShowRepo.DB(), func(tx *sql.Tx) error {
+		seatIDs, err := s.SeatHoldRepo.DeleteByUserAndShowTx(ctx, tx, userID, showID)
+		if err != nil {
+			return err
+		}
+		if len(seatIDs) > 0 {
+			if err := s.ShowSeatRepo.BulkUpdateStatusTx(ctx, tx, showID, seatIDs, "FREE"); err != nil {
+				return err
+			}
+		}
+		released = len(seatIDs)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	if released > 0 && s.Waitlist != nil {
+		s.Waitlist.Notify(showID)
+	}
+	return released, nil
+}
+
+// ExtendHolds pushes the expiry of all of userID's active holds on a show
+// forward by HoldExtendBy, never beyond HoldMaxTotal after the hold was
+// placed, and returns the extended holds.  It fails with
+// ErrHoldExtensionsDisabled, ErrNoActiveHolds or ErrHoldLimitReached.
+func (s *BookingService) ExtendHolds(ctx context.Context, userID, showID uint64) ([]repository.SeatHoldRecord, error) {
+	if s.HoldExtendBy <= 0 {
+		return nil, ErrHoldExtensionsDisabled
+	}
+	var holds []repository.SeatHoldRecord
+	err := db.WithTx(ctx, s.ShowRepo.DB(), func(tx *sql.Tx) error {
+		var extended bool
+		var err error
+		holds, extended, err = s.SeatHoldRepo.ExtendByUserAndShowTx(ctx, tx, userID, showID, s.HoldExtendBy, s.HoldMaxTotal)
+		if err != nil {
+			return err
+		}
+		if len(holds) == 0 {
+			return ErrNoActiveHolds
+		}
+		if !extended {
+			return ErrHoldLimitReached
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return holds, nil
+}
+
+// Reserve turns the customer's holds into a reservation in its own
+// transaction; see ReserveTx.
+func (s *BookingService) Reserve(ctx context.Context, req ReserveRequest) (*repository.ReservationRecord, error) {
+	var rec *repository.ReservationRecord
+	var expired int
+	err := db.WithTx(ctx, s.ShowRepo.DB(), func(tx *sql.Tx) error {
+		var err error
+		rec, expired, err = s.ReserveTx(ctx, tx, req)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	metrics.HoldsExpired.Add(float64(expired), "request")
+	return rec, nil
+}
+
+// ReserveTx turns the customer's active holds on a show into a reservation
+// inside tx.  When req.HoldTokens is non-empty only those holds are used
+// and tokens that are not active holds of the customer yield
+// HoldsNotActiveError; the customer's other holds are left untouched.  It
+// expires stale holds, refuses shows that are no longer SCHEDULED
+// (ShowNotBookableError), refuses customers too young for an age-restricted
+// show (AgeRestrictedError), locks and validates the held seats
+// (SeatsNotHeldError), refuses holds quoted in another currency than the
+// show's (CurrencyMismatchError) and changed prices unless
+// req.AcceptPriceChange (PriceChangedError), redeems req.PromoCode,
+// creates the reservation in the show's currency, with the cinema's
+// booking fee and tax, and its seats, marks the seats RESERVED and deletes the holds.  It returns
+// the reservation and the number of holds expired along the way, and is
+// used directly by callers that combine a reservation with other work in
+// one transaction, such as cart and paid checkouts.
+func (s *BookingService) ReserveTx(ctx context.Context, tx *sql.Tx, req ReserveRequest) (*repository.ReservationRecord, int, error) {
+	if req.PromoCode != "" && s.PromoCodeRepo == nil {
+		return nil, 0, ErrPromoCodesUnsupported
+	}
+	userID, showID := req.UserID, req.ShowID
+	expired, err := s.expireHoldsTx(ctx, tx, showID)
+	if err != nil {
+		return nil, 0, err
+	}
+	pv, err := s.checkReserveTx(ctx, tx, req, false)
+	if err != nil {
+		return nil, 0, err
+	}
+	var promoID *uint64
+	if pv.PromoCode != nil {
+		if err := s.PromoCodeRepo.IncrementUsageTx(ctx, tx, pv.PromoCode.ID); err != nil {
+			return nil, 0, err
+		}
+		promoID = &pv.PromoCode.ID
+	}
+	seatIDs := pv.SeatIDs
+	rec := &repository.ReservationRecord{
+		UserID:           userID,
+		ShowID:           showID,
+		Status:           req.Status,
+		SubtotalCents:    pv.SubtotalCents,
+		TotalAmountCents: pv.TotalAmountCents,
+		Currency:         pv.Currency,
+		PromoCodeID:      promoID,
+		DiscountCents:    pv.DiscountCents,
+		FeesCents:        pv.FeesCents,
+		TaxCents:         pv.TaxCents,
+	}
+	if err := s.ReservationRepo.CreateTx(ctx, tx, rec); err != nil {
+		return nil, 0, err
+	}
+	seats := make([]repository.ReservationSeatRecord, 0, len(seatIDs))
+	for _, sid := range seatIDs {
+		seats = append(seats, repository.ReservationSeatRecord{
+			ReservationID: rec.ID,
+			ShowID:        showID,
+			SeatID:        sid,
+			PriceCents:    pv.Prices[sid],
+		})
+	}
+	if err := s.ReservationRepo.CreateSeatsBulkTx(ctx, tx, seats); err != nil {
+		return nil, 0, err
+	}
+	if err := s.ShowSeatRepo.BulkUpdateStatusTx(ctx, tx, showID, seatIDs, "RESERVED"); err != nil {
+		return nil, 0, err
+	}
+	// Without hold tokens every hold of the user on this show was used.
+	if len(req.HoldTokens) > 0 {
+		holdIDs := make([]uint64, 0, len(pv.Holds))
+		for _, hld := range pv.Holds {
+			holdIDs = append(holdIDs, hld.ID)
+		}
+		if err := s.SeatHoldRepo.DeleteByIDsTx(ctx, tx, holdIDs); err != nil {
+			return nil, 0, err
+		}
+	} else if _, err := s.SeatHoldRepo.DeleteByUserAndShowTx(ctx, tx, userID, showID); err != nil {
+		return nil, 0, err
+	}
+	// Seats offered from the waitlist are now booked; close the offer.
+	if s.Waitlist != nil {
+		if err := s.Waitlist.Repo.FulfillTx(ctx, tx, userID, showID); err != nil {
+			return nil, 0, err
+		}
+	}
+	return rec, len(expired), nil
+}
+
+// PreviewReserve runs the checks of Reserve without reserving anything
+// and returns what Reserve would do.  Everything is read in a read-only
+// transaction without locks and the promo code is not redeemed, so a
+// later Reserve can still fail.  Holds that have expired are ignored, as
+// Reserve expires them first.
+func (s *BookingService) PreviewReserve(ctx context.Context, req ReserveRequest) (*ReservePreview, error) {
+	if req.PromoCode != "" && s.PromoCodeRepo == nil {
+		return nil, ErrPromoCodesUnsupported
+	}
+	var pv *ReservePreview
+	err := db.ReadOnlyTx(ctx, s.ShowRepo.DB(), func(tx *sql.Tx) error {
+		var err error
+		pv, err = s.checkReserveTx(ctx, tx, req, true)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return pv, nil
+}
+
+// checkReserveTx validates req for ReserveTx and PreviewReserve and
+// returns the reservation it amounts to.  Unless dryRun, the held seats
+// and the promo code are locked for the rest of tx.
+func (s *BookingService) checkReserveTx(ctx context.Context, tx *sql.Tx, req ReserveRequest, dryRun bool) (*ReservePreview, error) {
+	userID, showID := req.UserID, req.ShowID
+	holds, err := s.SeatHoldRepo.ActiveHoldsByUserAndShowTx(ctx, tx, userID, showID)
+	if err != nil {
+		return nil, err
+	}
+	if len(req.HoldTokens) > 0 {
+		var missing []string
+		if holds, missing = holdsByToken(holds, req.HoldTokens); len(missing) > 0 {
+			return nil, &HoldsNotActiveError{Tokens: missing}
+		}
+	}
+	if len(holds) == 0 {
+		return nil, ErrNoActiveHolds
+	}
+	// The show may have been cancelled or have finished since the seats
+	// were held.  As in holdTx, the shared lock keeps a cancellation from
+	// committing before the reservation does.
+	var status string
+	if dryRun {
+		status, err = s.ShowRepo.StatusTx(ctx, tx, showID)
+	} else {
+		status, err = s.ShowRepo.StatusForShareTx(ctx, tx, showID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if status != "SCHEDULED" {
+		return nil, &ShowNotBookableError{ShowID: showID}
+	}
+	if err := s.checkAgeTx(ctx, tx, userID, showID); err != nil {
+		return nil, err
+	}
+	seatIDs := make([]uint64, 0, len(holds))
+	for _, hld := range holds {
+		seatIDs = append(seatIDs, hld.SeatID)
+	}
+	// Lock the held seats so that no concurrent confirmation can reserve
+	// them twice; each must still be HELD with an active hold of this user.
+	var seats map[uint64]repository.LockedSeat
+	if dryRun {
+		seats, err = s.ShowSeatRepo.SeatsTx(ctx, tx, showID, seatIDs)
+	} else {
+		seats, err = s.ShowSeatRepo.LockSeatsTx(ctx, tx, showID, seatIDs)
+	}
+	if err != nil {
+		return nil, err
+	}
+	unavailable := make([]uint64, 0)
+	for _, sid := range seatIDs {
+		ls, ok := seats[sid]
+		if !ok || ls.Status != "HELD" || ls.HeldBy == nil || *ls.HeldBy != userID {
+			unavailable = append(unavailable, sid)
+		}
+	}
+	if len(unavailable) > 0 {
+		if !dryRun {
+			metrics.SeatConflicts.Inc("confirm")
+		}
+		return nil, &SeatsNotHeldError{ShowID: showID, SeatIDs: unavailable}
+	}
+	// Prices are read after locking so the total is consistent.
+	prices, err := s.ShowSeatRepo.GetPricesBySeatIDsTx(ctx, tx, showID, seatIDs)
+	if err != nil {
+		return nil, err
+	}
+	total := uint32(0)
+	for _, sid := range seatIDs {
+		p, ok := prices[sid]
+		if !ok {
+			return nil, fmt.Errorf("price not found for seat %d", sid)
+		}
+		total += p
+	}
+	// A price quoted in another currency cannot be compared, let alone
+	// accepted; the customer has to hold the seats again.
+	currency, err := s.ShowRepo.CurrencyTx(ctx, tx, showID)
+	if err != nil {
+		return nil, err
+	}
+	for _, hld := range holds {
+		if hld.Currency != nil && *hld.Currency != currency {
+			return nil, &CurrencyMismatchError{Quoted: *hld.Currency, Current: currency}
+		}
+	}
+	// If the owner changed prices since the seats were held, refuse to
+	// charge the new amount unless the customer acknowledged it.
+	changes := make([]PriceChange, 0)
+	quotedTotal := uint32(0)
+	for _, hld := range holds {
+		if hld.PriceCents == nil {
+			// legacy hold without a captured price; nothing to compare
+			quotedTotal += prices[hld.SeatID]
+			continue
+		}
+		quotedTotal += *hld.PriceCents
+		if *hld.PriceCents != prices[hld.SeatID] {
+			changes = append(changes, PriceChange{
+				SeatID:            hld.SeatID,
+				QuotedPriceCents:  *hld.PriceCents,
+				CurrentPriceCents: prices[hld.SeatID],
+			})
+		}
+	}
+	if len(changes) > 0 && !req.AcceptPriceChange {
+		return nil, &PriceChangedError{Changes: changes, QuotedTotalCents: quotedTotal, CurrentTotalCents: total}
+	}
+	pv := &ReservePreview{
+		SeatIDs:          seatIDs,
+		Prices:           prices,
+		PriceChanges:     changes,
+		QuotedTotalCents: quotedTotal,
+		SubtotalCents:    total,
+		Currency:         currency,
+		Holds:            holds,
+	}
+	// The promo_codes row stays locked for the rest of the transaction so
+	// concurrent confirmations cannot push used_count past max_uses.
+	if req.PromoCode != "" {
+		var promo *repository.PromoCode
+		if dryRun {
+			promo, err = s.PromoCodeRepo.GetForShowTx(ctx, tx, req.PromoCode, showID)
+		} else {
+			promo, err = s.PromoCodeRepo.GetForShowForUpdateTx(ctx, tx, req.PromoCode, showID)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if err := promo.Validate(time.Now().UTC()); err != nil {
+			return nil, ErrPromoCodeInvalid
+		}
+		pv.DiscountCents = promo.DiscountFor(total)
+		total -= pv.DiscountCents
+		pv.PromoCode = promo
+	}
+	fees, err := s.ShowRepo.FeesTx(ctx, tx, showID)
+	if err != nil {
+		return nil, err
+	}
+	pv.FeesCents, pv.TaxCents = fees.Charges(len(seatIDs), total)
+	pv.TotalAmountCents = total + pv.FeesCents + pv.TaxCents
+	return pv, nil
+}
+
+// SeatMap returns the seats of a show with their current status.  Expired
+// holds are released first so their seats are FREE again; that cleanup is
+// best effort, since the status already ignores expired holds.
+func (s *BookingService) SeatMap(ctx context.Context, showID uint64) ([]repository.SeatWithStatus, error) {
+	var expired []uint64
+	err := db.WithTx(ctx, s.ShowRepo.DB(), func(tx *sql.Tx) error {
+		var err error
+		expired, err = s.expireHoldsTx(ctx, tx, showID)
+		return err
+	})
+	if err == nil {
+		metrics.HoldsExpired.Add(float64(len(expired)), "request")
+	}
+	return s.ShowSeatRepo.ListWithStatus(ctx, showID)
+}
+
+// CancelReservation cancels reservation resID of userID before its show
+// starts: the reservation is cancelled as ReservationRepo.CancelTx does,
+// its seats return to FREE and are offered to the show's waitlist.  It
+// fails with sql.ErrNoRows, repository.ErrForbidden or
+// repository.ErrReservationCancelled as ReservationRepo.GetInfoForUserTx
+// does, with repository.ErrReservationPaid for paid reservations, which
+// are cancelled by requesting a refund, with ErrShowStarted and, when the
+// show's cancellation cutoff has passed, with *CancelCutoffError.
+func (s *BookingService) CancelReservation(ctx context.Context, userID, resID uint64) error {
+	var showID uint64
+	err := db.WithTx(ctx, s.ShowRepo.DB(), func(tx *sql.Tx) error {
+		var startTime time.Time
+		var seatIDs []uint64
+		var err error
+		showID, startTime, seatIDs, err = s.ReservationRepo.GetInfoForUserTx(ctx, tx, resID, userID)
+		if err != nil {
+			return err
+		}
+		now := time.Now().UTC()
+		if !startTime.After(now) {
+			return ErrShowStarted
+		}
+		cutoff, err := s.ShowRepo.CancelCutoffTx(ctx, tx, showID)
+		if err != nil {
+			return err
+		}
+		if deadline := startTime.Add(-cutoff); cutoff > 0 && !now.Before(deadline) {
+			return &CancelCutoffError{Cutoff: cutoff, Deadline: deadline}
+		}
+		if err := s.ReservationRepo.CancelTx(ctx, tx, resID); err != nil {
+			return err
+		}
+		if len(seatIDs) > 0 {
+			if err := s.ShowSeatRepo.BulkUpdateStatusTx(ctx, tx, showID, seatIDs, "FREE"); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	metrics.ReservationsCancelled.Inc("customer")
+	if s.Waitlist != nil {
+		s.Waitlist.Notify(showID)
+	}
+	return nil
+}
+
+// expireHoldsTx deletes the expired holds of a show and frees their seats.
+// It returns the freed seat IDs.
+func (s *BookingService) expireHoldsTx(ctx context.Context, tx *sql.Tx, showID uint64) ([]uint64, error) {
+	expired, err := s.SeatHoldRepo.ExpireHoldsTx(ctx, tx, showID)
+	if err != nil {
+		return nil, err
+	}
+	if len(expired) > 0 {
+		if err := s.ShowSeatRepo.BulkUpdateStatusTx(ctx, tx, showID, expired, "FREE"); err != nil {
+			return nil, err
+		}
+	}
+	return expired, nil
+}
+
+// holdsByToken returns the holds whose tokens are listed and the tokens
+// that match none of them.  Duplicate tokens are counted once.
+func holdsByToken(holds []repository.SeatHoldRecord, tokens []string) ([]repository.SeatHoldRecord, []string) {
+	byToken := make(map[string]repository.SeatHoldRecord, len(holds))
+	for _, hld := range holds {
+		byToken[hld.HoldToken] = hld
+	}
+	selected := make([]repository.SeatHoldRecord, 0, len(tokens))
+	var missing []string
+	seen := make(map[string]bool, len(tokens))
+	for _, t := range tokens {
+		if seen[t] {
+			continue
+		}
+		seen[t] = true
+		if hld, ok := byToken[t]; ok {
+			selected = append(selected, hld)
+		} else {
+			missing = append(missing, t)
+		}
+	}
+	return selected, missing
+}
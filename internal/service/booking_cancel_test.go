@@ -0,0 +1,43 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/iliyamo/cinema-seat-reservation/internal/repository"
+	"github.com/iliyamo/cinema-seat-reservation/internal/repository/mock"
+)
+
+func TestCancelReservationPaidKeepsSeats(t *testing.T) {
+	conn := (&mock.Driver{}).DB()
+	defer conn.Close()
+	freed := false
+	s := NewBookingService(
+		&mock.ShowStore{Conn: conn},
+		&mock.ShowSeatStore{
+			BulkUpdateStatusTxFn: func(context.Context, *sql.Tx, uint64, []uint64, string) error {
+				freed = true
+				return nil
+			},
+		},
+		&mock.SeatHoldStore{},
+		&mock.ReservationStore{
+			GetInfoForUserTxFn: func(context.Context, *sql.Tx, uint64, uint64) (uint64, time.Time, []uint64, error) {
+				return 3, time.Now().Add(24 * time.Hour), []uint64{11, 12}, nil
+			},
+			CancelTxFn: func(context.Context, *sql.Tx, uint64) error {
+				return repository.ErrReservationPaid
+			},
+		},
+	)
+	err := s.CancelReservation(context.Background(), 1, 7)
+	if !errors.Is(err, repository.ErrReservationPaid) {
+		t.Fatalf("err = %v, want ErrReservationPaid", err)
+	}
+	if freed {
+		t.Fatal("seats of a paid reservation were freed")
+	}
+}
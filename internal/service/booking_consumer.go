@@ -0,0 +1,50 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/iliyamo/cinema-seat-reservation/internal/queue"
+	"github.com/iliyamo/cinema-seat-reservation/internal/repository"
+)
+
+// StartBookingConsumer consumes BookingEventsTopic from c in the
+// background until ctx is cancelled, logging one line per event.  It is
+// the place to hang further reactions to bookings; since delivery is at
+// least once, those must tolerate seeing an event twice.
+//
+// The returned function is a readiness check: it fails once the consumer
+// has stopped.
+func StartBookingConsumer(ctx context.Context, c queue.Consumer) func(context.Context) error {
+	stopped := make(chan struct{})
+	var stopErr error
+	go func() {
+		defer close(stopped)
+		stopErr = c.Consume(ctx, BookingEventsTopic, func(_ context.Context, body []byte) error {
+			var e repository.BookingEvent
+			if err := json.Unmarshal(body, &e); err != nil {
+				return err
+			}
+			log.Printf("booking event %d: %s reservation=%d show=%d user=%d total_cents=%d",
+				e.ID, e.Kind, e.ReservationID, e.ShowID, e.UserID, e.TotalAmountCents)
+			return nil
+		})
+		if stopErr != nil {
+			log.Printf("booking consumer stopped: %v", stopErr)
+		}
+	}()
+	return func(context.Context) error {
+		select {
+		case <-stopped:
+			if stopErr != nil {
+				return fmt.Errorf("stopped: %v", stopErr)
+			}
+			return errors.New("stopped")
+		default:
+			return nil
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/iliyamo/cinema-seat-reservation/internal/repository"
+)
+
+func TestHoldableSeats(t *testing.T) {
+	holder := uint64(9)
+	// 1 and 2 are free, 3 is reserved, 4 is held by another customer, 5
+	// is HELD with an expired hold and 6 does not exist
+	seats := map[uint64]repository.LockedSeat{
+		1: {SeatID: 1, Status: "FREE"},
+		2: {SeatID: 2, Status: "FREE"},
+		3: {SeatID: 3, Status: "RESERVED"},
+		4: {SeatID: 4, Status: "HELD", HeldBy: &holder},
+		5: {SeatID: 5, Status: "HELD"},
+	}
+	cases := []struct {
+		name        string
+		seatIDs     []uint64
+		expiredFree bool
+		partial     bool
+		holdable    []uint64
+		unavailable []uint64 // listed by the error when err
+		err         bool
+	}{
+		{"strict, all free", []uint64{1, 2}, false, false, []uint64{1, 2}, []uint64{}, false},
+		{"strict, mixed", []uint64{1, 3, 2, 4}, false, false, nil, []uint64{3, 4}, true},
+		{"strict, missing seat", []uint64{1, 6}, false, false, nil, []uint64{6}, true},
+		{"partial, all free", []uint64{1, 2}, false, true, []uint64{1, 2}, []uint64{}, false},
+		{"partial, mixed", []uint64{1, 3, 2, 4, 6}, false, true, []uint64{1, 2}, []uint64{3, 4, 6}, false},
+		{"partial, none available", []uint64{3, 4, 6}, false, true, nil, []uint64{3, 4, 6}, true},
+		{"expired hold taken", []uint64{1, 5}, false, true, []uint64{1}, []uint64{5}, false},
+		{"expired hold free", []uint64{1, 5}, true, false, []uint64{1, 5}, []uint64{}, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			holdable, unavailable, err := holdableSeats(3, tc.seatIDs, seats, tc.expiredFree, tc.partial)
+			if !reflect.DeepEqual(holdable, tc.holdable) {
+				t.Errorf("holdable = %v, want %v", holdable, tc.holdable)
+			}
+			if !reflect.DeepEqual(unavailable, tc.unavailable) {
+				t.Errorf("unavailable = %v, want %v", unavailable, tc.unavailable)
+			}
+			var unavailableErr *SeatsUnavailableError
+			if !tc.err {
+				if err != nil {
+					t.Fatalf("err = %v, want nil", err)
+				}
+				return
+			}
+			if !errors.As(err, &unavailableErr) {
+				t.Fatalf("err = %v, want SeatsUnavailableError", err)
+			}
+			if unavailableErr.ShowID != 3 || !reflect.DeepEqual(unavailableErr.SeatIDs, tc.unavailable) {
+				t.Errorf("error lists show %d seats %v, want show 3 seats %v", unavailableErr.ShowID, unavailableErr.SeatIDs, tc.unavailable)
+			}
+		})
+	}
+}
@@ -0,0 +1,266 @@
+//go:build integration
+
+package service_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/iliyamo/cinema-seat-reservation/internal/repository"
+	"github.com/iliyamo/cinema-seat-reservation/internal/service"
+	"github.com/iliyamo/cinema-seat-reservation/internal/testdb"
+)
+
+func TestMain(m *testing.M) { testdb.Main(m) }
+
+// clients is the number of customers racing in every test.
+const clients = 20
+
+// race runs fn(0) .. fn(n-1) in their own goroutines, released together
+// so their transactions overlap, and waits for all of them.
+func race(n int, fn func(i int)) {
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			fn(i)
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+}
+
+func newBooking() *service.BookingService {
+	db := testdb.DB
+	return service.NewBookingService(repository.NewShowRepo(db), repository.NewShowSeatRepo(db),
+		repository.NewSeatHoldRepo(db), repository.NewReservationRepo(db))
+}
+
+// holdRace has every customer in customers hold seats at once, checks
+// that exactly one of them won and the others got
+// SeatsUnavailableError, and returns the winner.
+func holdRace(t *testing.T, b *service.BookingService, show *testdb.Show, customers, seats []uint64) uint64 {
+	t.Helper()
+	var mu sync.Mutex
+	var winners []uint64
+	var unexpected []error
+	race(len(customers), func(i int) {
+		_, err := b.Hold(context.Background(), customers[i], show.Show, seats, false)
+		mu.Lock()
+		defer mu.Unlock()
+		var unavailable *service.SeatsUnavailableError
+		switch {
+		case err == nil:
+			winners = append(winners, customers[i])
+		case !errors.As(err, &unavailable):
+			unexpected = append(unexpected, err)
+		}
+	})
+	if len(unexpected) > 0 {
+		t.Fatalf("hold failed unexpectedly: %v", unexpected[0])
+	}
+	if len(winners) != 1 {
+		t.Fatalf("%d holds succeeded, want 1", len(winners))
+	}
+	expectSeats(t, show, seats, "HELD", winners[0], 0)
+	return winners[0]
+}
+
+// others returns the customers of show except userID.
+func others(show *testdb.Show, userID uint64) []uint64 {
+	out := make([]uint64, 0, len(show.Customers)-1)
+	for _, id := range show.Customers {
+		if id != userID {
+			out = append(out, id)
+		}
+	}
+	return out
+}
+
+// expectSeats checks the stored state of seats: every show seat has
+// status, every seat has an active hold of holder (none when holder is
+// 0) and the seats make up the given number of live reservations.
+func expectSeats(t *testing.T, show *testdb.Show, seats []uint64, status string, holder uint64, reservations int) {
+	t.Helper()
+	in := strings.TrimSuffix(strings.Repeat("?,", len(seats)), ",")
+	args := []interface{}{show.ID}
+	for _, id := range seats {
+		args = append(args, id)
+	}
+	var wrongStatus, holds, booked, live int
+	holders := make(map[uint64]bool)
+	err := testdb.DB.QueryRow(
+		`SELECT COUNT(*) FROM show_seats WHERE show_id = ? AND seat_id IN (`+in+`) AND status <> ?`,
+		append(args, status)...).Scan(&wrongStatus)
+	if err != nil {
+		t.Fatalf("check seats: %v", err)
+	}
+	rows, err := testdb.DB.Query(
+		`SELECT user_id FROM seat_holds WHERE show_id = ? AND seat_id IN (`+in+`) AND expires_at > UTC_TIMESTAMP()`,
+		args...)
+	if err != nil {
+		t.Fatalf("check holds: %v", err)
+	}
+	for rows.Next() {
+		var id uint64
+		if err := rows.Scan(&id); err != nil {
+			t.Fatalf("check holds: %v", err)
+		}
+		holds++
+		holders[id] = true
+	}
+	rows.Close()
+	err = testdb.DB.QueryRow(
+		`SELECT COUNT(*), COUNT(DISTINCT rs.reservation_id) FROM reservation_seats rs
+		 JOIN reservations rv ON rv.id = rs.reservation_id
+		 WHERE rs.show_id = ? AND rs.seat_id IN (`+in+`) AND rv.status IN ('PENDING','CONFIRMED')`,
+		args...).Scan(&booked, &live)
+	if err != nil {
+		t.Fatalf("check reservations: %v", err)
+	}
+	switch {
+	case wrongStatus > 0:
+		t.Fatalf("%d seats are not %s", wrongStatus, status)
+	case holder == 0 && holds > 0:
+		t.Fatalf("%d seats are still held", holds)
+	case holder != 0 && (holds != len(seats) || len(holders) != 1 || !holders[holder]):
+		t.Fatalf("%d active holds by users %v, want %d by %d", holds, holders, len(seats), holder)
+	case live != reservations || (reservations > 0 && booked != len(seats)) || (reservations == 0 && booked > 0):
+		t.Fatalf("%d seats in %d live reservations, want %d reservations", booked, live, reservations)
+	}
+}
+
+func TestHoldRaceHasOneWinner(t *testing.T) {
+	show := testdb.NewShow(t, 2, clients)
+	holdRace(t, newBooking(), show, show.Customers, show.SeatIDs)
+}
+
+// TestConfirmRace has the holder confirm clients times at once while
+// every other customer tries to hold the seats: one reservation is made
+// and every hold fails.
+func TestConfirmRace(t *testing.T) {
+	ctx := context.Background()
+	b := newBooking()
+	show := testdb.NewShow(t, 2, clients)
+	winner := holdRace(t, b, show, show.Customers, show.SeatIDs)
+	rivals := others(show, winner)
+
+	var mu sync.Mutex
+	var confirmed, held int
+	var unexpected []error
+	race(clients+len(rivals), func(i int) {
+		if i < clients {
+			_, err := b.Reserve(ctx, service.ReserveRequest{UserID: winner, ShowID: show.ID, Status: "CONFIRMED"})
+			mu.Lock()
+			defer mu.Unlock()
+			var notHeld *service.SeatsNotHeldError
+			switch {
+			case err == nil:
+				confirmed++
+			case !errors.Is(err, service.ErrNoActiveHolds) && !errors.As(err, &notHeld):
+				unexpected = append(unexpected, err)
+			}
+			return
+		}
+		_, err := b.Hold(ctx, rivals[i-clients], show.Show, show.SeatIDs, false)
+		mu.Lock()
+		defer mu.Unlock()
+		var unavailable *service.SeatsUnavailableError
+		switch {
+		case err == nil:
+			held++
+		case !errors.As(err, &unavailable):
+			unexpected = append(unexpected, err)
+		}
+	})
+	if len(unexpected) > 0 {
+		t.Fatalf("request failed unexpectedly: %v", unexpected[0])
+	}
+	if confirmed != 1 || held != 0 {
+		t.Fatalf("%d confirms and %d holds succeeded, want 1 and 0", confirmed, held)
+	}
+	expectSeats(t, show, show.SeatIDs, "RESERVED", 0, 1)
+}
+
+// TestHoldsExpire checks that an expired hold stops blocking its seats:
+// the sweeper frees them and another customer can hold them.
+func TestHoldsExpire(t *testing.T) {
+	ctx := context.Background()
+	b := newBooking()
+	show := testdb.NewShow(t, 2, 2)
+	holder := holdRace(t, b, show, show.Customers[:1], show.SeatIDs)
+	show.ExpireHolds(t, holder)
+
+	sweeper := service.NewHoldExpiryWorker(repository.NewSeatHoldRepo(testdb.DB), repository.NewShowSeatRepo(testdb.DB), 0)
+	if err := sweeper.Sweep(ctx); err != nil {
+		t.Fatalf("sweep: %v", err)
+	}
+	expectSeats(t, show, show.SeatIDs, "FREE", 0, 0)
+	holdRace(t, b, show, show.Customers[1:], show.SeatIDs)
+}
+
+// TestConfirmAfterExpiryRejected has a customer confirm an expired hold
+// alone: it fails with ErrNoActiveHolds and the seats can be held again.
+func TestConfirmAfterExpiryRejected(t *testing.T) {
+	ctx := context.Background()
+	b := newBooking()
+	show := testdb.NewShow(t, 2, 2)
+	holder := holdRace(t, b, show, show.Customers[:1], show.SeatIDs)
+	show.ExpireHolds(t, holder)
+
+	_, err := b.Reserve(ctx, service.ReserveRequest{UserID: holder, ShowID: show.ID, Status: "CONFIRMED"})
+	if !errors.Is(err, service.ErrNoActiveHolds) {
+		t.Fatalf("confirm of an expired hold: got %v, want %v", err, service.ErrNoActiveHolds)
+	}
+	expectSeats(t, show, show.SeatIDs, "HELD", 0, 0)
+	holdRace(t, b, show, show.Customers[1:], show.SeatIDs)
+}
+
+// TestConfirmAfterExpiryRace has a customer confirm an expired hold while
+// every other customer tries to hold the seats: the confirm fails and
+// exactly one other customer gets the seats.
+func TestConfirmAfterExpiryRace(t *testing.T) {
+	ctx := context.Background()
+	b := newBooking()
+	show := testdb.NewShow(t, 2, clients)
+	holder := holdRace(t, b, show, show.Customers, show.SeatIDs)
+	show.ExpireHolds(t, holder)
+
+	var confirmErr error
+	var winners []uint64
+	var unexpected []error
+	var mu sync.Mutex
+	rivals := others(show, holder)
+	race(1+len(rivals), func(i int) {
+		if i == 0 {
+			_, confirmErr = b.Reserve(ctx, service.ReserveRequest{UserID: holder, ShowID: show.ID, Status: "CONFIRMED"})
+			return
+		}
+		_, err := b.Hold(ctx, rivals[i-1], show.Show, show.SeatIDs, false)
+		mu.Lock()
+		defer mu.Unlock()
+		var unavailable *service.SeatsUnavailableError
+		switch {
+		case err == nil:
+			winners = append(winners, rivals[i-1])
+		case !errors.As(err, &unavailable):
+			unexpected = append(unexpected, err)
+		}
+	})
+	if confirmErr == nil {
+		t.Fatal("confirm of an expired hold succeeded")
+	}
+	if len(unexpected) > 0 {
+		t.Fatalf("hold failed unexpectedly: %v", unexpected[0])
+	}
+	if len(winners) != 1 {
+		t.Fatalf("%d holds succeeded, want 1", len(winners))
+	}
+	expectSeats(t, show, show.SeatIDs, "HELD", winners[0], 0)
+}
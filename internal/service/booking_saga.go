@@ -0,0 +1,274 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/iliyamo/cinema-seat-reservation/internal/db"
+	"github.com/iliyamo/cinema-seat-reservation/internal/metrics"
+	"github.com/iliyamo/cinema-seat-reservation/internal/repository"
+)
+
+// ReserveFunc performs the first step of a booking saga inside tx: it turns
+// the customer's holds into a PENDING reservation whose seats are RESERVED
+// and returns the reservation ID and the amount to charge with its
+// currency.
+type ReserveFunc func(tx *sql.Tx) (reservationID uint64, amountCents uint32, currency string, err error)
+
+// BookingSagaService runs the hold -> pending -> payment -> confirm flow as
+// a saga whose state is persisted in booking_sagas.  Every step is a
+// compare-and-set state transition, so a request and the recovery worker
+// can never both perform the same step, and the payment provider is
+// called with the idempotency key saga-<id>.  When a step fails for good
+// (payment declined, reservation gone) the saga compensates: the charge is
+// voided, the reservation removed and its seats freed.  Sagas left
+// unfinished by a crash are picked up by Run once their deadline passes:
+// sagas that were never charged are compensated, sagas whose charge
+// reached the provider are confirmed.
+type BookingSagaService struct {
+	SagaRepo        *repository.BookingSagaRepo
+	ReservationRepo *repository.ReservationRepo
+	ShowSeatRepo    *repository.ShowSeatRepo
+	Payments        PaymentGateway
+	// Timeout is how long a saga may stay unfinished before the recovery
+	// worker takes over.  It must comfortably exceed a request's duration.
+	Timeout time.Duration
+	// RecoveryInterval is the period of Run.
+	RecoveryInterval time.Duration
+	// Heartbeat is updated after every recovery pass for GET /readyz.
+	Heartbeat Heartbeat
+}
+
+// NewBookingSagaService constructs a BookingSagaService.
+func NewBookingSagaService(sagaRepo *repository.BookingSagaRepo, resRepo *repository.ReservationRepo, showSeatRepo *repository.ShowSeatRepo, payments PaymentGateway, timeout time.Duration) *BookingSagaService {
+	if sagaRepo == nil || resRepo == nil || showSeatRepo == nil || payments == nil {
+		panic("nil dependency passed to NewBookingSagaService")
+	}
+	return &BookingSagaService{
+		SagaRepo:         sagaRepo,
+		ReservationRepo:  resRepo,
+		ShowSeatRepo:     showSeatRepo,
+		Payments:         payments,
+		Timeout:          timeout,
+		RecoveryInterval: 30 * time.Second,
+	}
+}
+
+func sagaKey(id uint64) string { return "saga-" + strconv.FormatUint(id, 10) }
+
+// Start runs reserve and the saga row insert in one transaction and then
+// drives the saga to a terminal state.  When reserve fails the saga is
+// nil and err is reserve's error.  A non-nil saga with a non-nil error is
+// unfinished (for example the payment outcome is unknown); the recovery
+// worker completes it after the deadline.
+func (s *BookingSagaService) Start(ctx context.Context, userID, showID uint64, reserve ReserveFunc) (*repository.BookingSaga, error) {
+	sg := &repository.BookingSaga{UserID: userID, ShowID: showID, State: repository.SagaReserved}
+	err := db.WithTx(ctx, s.SagaRepo.DB(), func(tx *sql.Tx) error {
+		resID, amount, currency, err := reserve(tx)
+		if err != nil {
+			return err
+		}
+		sg.ReservationID = &resID
+		sg.AmountCents = amount
+		sg.Currency = currency
+		sg.DeadlineAt = time.Now().UTC().Add(s.Timeout)
+		return s.SagaRepo.CreateTx(ctx, tx, sg)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return sg, s.advance(ctx, sg, false)
+}
+
+// Run recovers stale sagas every RecoveryInterval until ctx is cancelled.
+func (s *BookingSagaService) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.RecoveryInterval)
+	defer ticker.Stop()
+	s.Heartbeat.Beat(nil)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := s.Recover(ctx)
+			if err != nil {
+				log.Printf("booking saga recovery failed: %v", err)
+			}
+			s.Heartbeat.Beat(err)
+		}
+	}
+}
+
+// Recover finishes or compensates unfinished sagas whose deadline has
+// passed.  Each saga is claimed by pushing its deadline forward, so
+// concurrent workers skip it; a saga that fails again is retried after
+// another Timeout.
+func (s *BookingSagaService) Recover(ctx context.Context) error {
+	now := time.Now().UTC()
+	stale, err := s.SagaRepo.ListStale(ctx, now, 100)
+	if err != nil {
+		return err
+	}
+	for _, sg := range stale {
+		if err := s.SagaRepo.Claim(ctx, sg, now, now.Add(s.Timeout)); err != nil {
+			if errors.Is(err, repository.ErrSagaStateChanged) {
+				continue
+			}
+			return err
+		}
+		if err := s.advance(ctx, sg, true); err != nil {
+			log.Printf("booking saga %d: recovery stopped in state %s: %v", sg.ID, sg.State, err)
+		}
+	}
+	return nil
+}
+
+// advance performs steps until sg is terminal.  recovering selects the
+// recovery worker's behaviour for sagas interrupted before or during
+// payment.
+func (s *BookingSagaService) advance(ctx context.Context, sg *repository.BookingSaga, recovering bool) error {
+	for !sg.Terminal() {
+		var err error
+		switch sg.State {
+		case repository.SagaReserved:
+			switch {
+			case recovering:
+				// The customer is no longer waiting for this checkout;
+				// do not charge them in the background.
+				err = s.move(ctx, sg, repository.SagaCompensating, nil, "checkout interrupted before payment")
+			case sg.AmountCents == 0:
+				err = s.move(ctx, sg, repository.SagaPaid, nil, "")
+			default:
+				err = s.move(ctx, sg, repository.SagaCharging, nil, "")
+			}
+		case repository.SagaCharging:
+			err = s.charge(ctx, sg, recovering)
+		case repository.SagaPaid:
+			err = s.confirm(ctx, sg)
+		case repository.SagaCompensating:
+			err = s.compensate(ctx, sg)
+		default:
+			return fmt.Errorf("booking saga %d: unknown state %q", sg.ID, sg.State)
+		}
+		if errors.Is(err, repository.ErrSagaStateChanged) {
+			// Another process advanced the saga; continue from its state.
+			fresh, gerr := s.SagaRepo.GetByID(ctx, sg.ID)
+			if gerr != nil {
+				return gerr
+			}
+			*sg = *fresh
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// charge captures the payment.  During recovery the provider is asked
+// whether the interrupted charge went through instead of charging again.
+func (s *BookingSagaService) charge(ctx context.Context, sg *repository.BookingSaga, recovering bool) error {
+	key := sagaKey(sg.ID)
+	var ref string
+	if recovering {
+		r, charged, err := s.Payments.Lookup(ctx, key)
+		if err != nil {
+			return err
+		}
+		if !charged {
+			return s.move(ctx, sg, repository.SagaCompensating, nil, "checkout interrupted during payment")
+		}
+		ref = r
+	} else {
+		r, err := s.Payments.Charge(ctx, key, sg.AmountCents, sg.Currency)
+		if errors.Is(err, ErrPaymentDeclined) {
+			return s.move(ctx, sg, repository.SagaCompensating, nil, ErrPaymentDeclined.Error())
+		}
+		if err != nil {
+			return err
+		}
+		ref = r
+	}
+	return s.move(ctx, sg, repository.SagaPaid, &ref, "")
+}
+
+// confirm marks the reservation CONFIRMED.  If it has disappeared in the
+// meantime the saga compensates, refunding the customer.
+func (s *BookingSagaService) confirm(ctx context.Context, sg *repository.BookingSaga) error {
+	err := db.WithTx(ctx, s.SagaRepo.DB(), func(tx *sql.Tx) error {
+		if err := s.SagaRepo.TransitionTx(ctx, tx, sg.ID, repository.SagaPaid, repository.SagaConfirmed, nil, ""); err != nil {
+			return err
+		}
+		if sg.ReservationID == nil {
+			return repository.ErrReservationNotPending
+		}
+		return s.ReservationRepo.ConfirmPendingTx(ctx, tx, *sg.ReservationID, sg.PaymentRef)
+	})
+	if errors.Is(err, repository.ErrReservationNotPending) {
+		return s.move(ctx, sg, repository.SagaCompensating, nil, "reservation is no longer pending")
+	}
+	if err != nil {
+		return err
+	}
+	sg.State = repository.SagaConfirmed
+	sg.LastError = nil
+	metrics.ReservationsConfirmed.Inc("checkout")
+	return nil
+}
+
+// compensate voids the charge (if any), removes the PENDING reservation,
+// returning its promo code redemption, and frees its seats, all in one
+// transaction with the saga's move to COMPENSATED.
+func (s *BookingSagaService) compensate(ctx context.Context, sg *repository.BookingSaga) error {
+	if err := s.Payments.Void(ctx, sagaKey(sg.ID)); err != nil {
+		return err
+	}
+	reason := ""
+	if sg.LastError != nil {
+		reason = *sg.LastError
+	}
+	err := db.WithTx(ctx, s.SagaRepo.DB(), func(tx *sql.Tx) error {
+		if err := s.SagaRepo.TransitionTx(ctx, tx, sg.ID, repository.SagaCompensating, repository.SagaCompensated, nil, reason); err != nil {
+			return err
+		}
+		if sg.ReservationID == nil {
+			return nil
+		}
+		showID, seatIDs, err := s.ReservationRepo.ReleasePendingTx(ctx, tx, *sg.ReservationID)
+		if errors.Is(err, repository.ErrReservationNotPending) {
+			return nil // already removed
+		}
+		if err != nil {
+			return err
+		}
+		return s.ShowSeatRepo.BulkUpdateStatusTx(ctx, tx, showID, seatIDs, "FREE")
+	})
+	if err != nil {
+		return err
+	}
+	sg.State = repository.SagaCompensated
+	sg.ReservationID = nil
+	return nil
+}
+
+// move transitions sg to state to and mirrors the change on sg.
+func (s *BookingSagaService) move(ctx context.Context, sg *repository.BookingSaga, to string, ref *string, reason string) error {
+	if err := s.SagaRepo.Transition(ctx, sg.ID, sg.State, to, ref, reason); err != nil {
+		return err
+	}
+	sg.State = to
+	if ref != nil {
+		sg.PaymentRef = ref
+	}
+	sg.LastError = nil
+	if reason != "" {
+		sg.LastError = &reason
+	}
+	return nil
+}
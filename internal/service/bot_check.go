@@ -0,0 +1,246 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/bits"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Bot check providers.
+const (
+	BotCheckPoW       = "pow"       // proof of work, see ProofOfWork
+	BotCheckRecaptcha = "recaptcha" // Google reCAPTCHA
+	BotCheckHCaptcha  = "hcaptcha"  // hCaptcha
+	BotCheckTurnstile = "turnstile" // Cloudflare Turnstile
+)
+
+// captchaVerifyURLs are the siteverify endpoints of the CAPTCHA providers.
+var captchaVerifyURLs = map[string]string{
+	BotCheckRecaptcha: "https://www.google.com/recaptcha/api/siteverify",
+	BotCheckHCaptcha:  "https://api.hcaptcha.com/siteverify",
+	BotCheckTurnstile: "https://challenges.cloudflare.com/turnstile/v0/siteverify",
+}
+
+// ErrBotCheckFailed is returned when a bot check token is missing,
+// malformed, expired, already used or rejected by the provider.
+var ErrBotCheckFailed = errors.New("bot check failed")
+
+// BotVerifier verifies the token a client obtained by solving a
+// challenge.  remoteIP is the client's address.
+type BotVerifier interface {
+	Verify(ctx context.Context, token, remoteIP string) error
+}
+
+// CaptchaVerifier checks CAPTCHA responses with the provider's siteverify
+// endpoint.  reCAPTCHA, hCaptcha and Turnstile share the protocol: a
+// form POST of secret, response and remoteip answered with
+// {"success": bool}.
+type CaptchaVerifier struct {
+	URL    string
+	Secret string
+	Client *http.Client // nil means a client with a 5 second timeout
+}
+
+// NewCaptchaVerifier returns a verifier for provider (recaptcha,
+// hcaptcha or turnstile).  verifyURL overrides the provider's siteverify
+// endpoint when not empty.
+func NewCaptchaVerifier(provider, secret, verifyURL string) (*CaptchaVerifier, error) {
+	if verifyURL == "" {
+		verifyURL = captchaVerifyURLs[provider]
+	}
+	if verifyURL == "" {
+		return nil, fmt.Errorf("unknown captcha provider %q", provider)
+	}
+	if secret == "" {
+		return nil, errors.New("captcha secret is required")
+	}
+	return &CaptchaVerifier{URL: verifyURL, Secret: secret}, nil
+}
+
+// Verify implements BotVerifier.  Errors reaching the provider are
+// returned as they are, so callers can tell them from ErrBotCheckFailed.
+func (v *CaptchaVerifier) Verify(ctx context.Context, token, remoteIP string) error {
+	if token == "" {
+		return ErrBotCheckFailed
+	}
+	form := url.Values{"secret": {v.Secret}, "response": {token}}
+	if remoteIP != "" {
+		form.Set("remoteip", remoteIP)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, v.URL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	client := v.Client
+	if client == nil {
+		client = &http.Client{Timeout: 5 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("captcha verify: status %d", resp.StatusCode)
+	}
+	var out struct {
+		Success bool `json:"success"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return fmt.Errorf("captcha verify: %w", err)
+	}
+	if !out.Success {
+		return ErrBotCheckFailed
+	}
+	return nil
+}
+
+// PoWChallenge is a proof-of-work puzzle handed to a client.  The client
+// finds a nonce such that SHA-256(challenge + ":" + nonce) starts with
+// Difficulty zero bits and sends "challenge:nonce" as its token.
+type PoWChallenge struct {
+	Challenge  string    `json:"challenge"`
+	Difficulty int       `json:"difficulty"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// ProofOfWork issues and verifies stateless proof-of-work challenges.  A
+// challenge is a random nonce and expiry signed with Key, so any replica
+// sharing the key can verify it; solved challenges are remembered until
+// they expire so each can be used once per process.
+type ProofOfWork struct {
+	Key        []byte
+	Difficulty int           // leading zero bits required
+	TTL        time.Duration // how long a challenge can be solved
+
+	mu   sync.Mutex
+	used map[string]time.Time
+}
+
+// NewProofOfWork returns a ProofOfWork signing with key.
+func NewProofOfWork(key []byte, difficulty int, ttl time.Duration) *ProofOfWork {
+	return &ProofOfWork{Key: key, Difficulty: difficulty, TTL: ttl, used: make(map[string]time.Time)}
+}
+
+// Challenge issues a new challenge.
+func (p *ProofOfWork) Challenge() (PoWChallenge, error) {
+	var nonce [16]byte
+	if _, err := rand.Read(nonce[:]); err != nil {
+		return PoWChallenge{}, err
+	}
+	exp := time.Now().Add(p.TTL).UTC().Truncate(time.Second)
+	body := hex.EncodeToString(nonce[:]) + "." + strconv.FormatInt(exp.Unix(), 10) + "." + strconv.Itoa(p.Difficulty)
+	return PoWChallenge{Challenge: body + "." + botCheckMAC(p.Key, body), Difficulty: p.Difficulty, ExpiresAt: exp}, nil
+}
+
+// Verify implements BotVerifier for tokens of the form "challenge:nonce".
+func (p *ProofOfWork) Verify(_ context.Context, token, _ string) error {
+	challenge, _, ok := strings.Cut(token, ":")
+	if !ok {
+		return ErrBotCheckFailed
+	}
+	parts := strings.Split(challenge, ".")
+	if len(parts) != 4 {
+		return ErrBotCheckFailed
+	}
+	body := strings.Join(parts[:3], ".")
+	if !hmac.Equal([]byte(parts[3]), []byte(botCheckMAC(p.Key, body))) {
+		return ErrBotCheckFailed
+	}
+	expUnix, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return ErrBotCheckFailed
+	}
+	exp := time.Unix(expUnix, 0)
+	now := time.Now()
+	if !exp.After(now) {
+		return ErrBotCheckFailed
+	}
+	difficulty, err := strconv.Atoi(parts[2])
+	if err != nil || difficulty < p.Difficulty {
+		return ErrBotCheckFailed
+	}
+	sum := sha256.Sum256([]byte(token))
+	if leadingZeroBits(sum[:]) < difficulty {
+		return ErrBotCheckFailed
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for k, e := range p.used {
+		if !e.After(now) {
+			delete(p.used, k)
+		}
+	}
+	if _, seen := p.used[challenge]; seen {
+		return ErrBotCheckFailed
+	}
+	p.used[challenge] = exp
+	return nil
+}
+
+// leadingZeroBits counts the zero bits at the start of b.
+func leadingZeroBits(b []byte) int {
+	n := 0
+	for _, x := range b {
+		if x != 0 {
+			return n + bits.LeadingZeros8(x)
+		}
+		n += 8
+	}
+	return n
+}
+
+// botCheckMAC returns the base64url HMAC-SHA256 of s under key.
+func botCheckMAC(key []byte, s string) string {
+	m := hmac.New(sha256.New, key)
+	m.Write([]byte(s))
+	return base64.RawURLEncoding.EncodeToString(m.Sum(nil))
+}
+
+// BotGuard asks anonymous clients of protected endpoints to prove they
+// are human.  A client solves a challenge from Provider (a CAPTCHA, or a
+// proof of work when Provider is "pow") and sends the token; once it is
+// verified the client receives a pass, bound to its address and valid
+// for PassTTL, which it sends instead on later requests.
+type BotGuard struct {
+	Provider string
+	SiteKey  string // public CAPTCHA key handed to clients
+	Verifier BotVerifier
+	PoW      *ProofOfWork // set when Provider is "pow"
+
+	PassKey []byte
+	PassTTL time.Duration
+}
+
+// IssuePass returns a pass for ip that expires after PassTTL.
+func (g *BotGuard) IssuePass(ip string) string {
+	exp := strconv.FormatInt(time.Now().Add(g.PassTTL).Unix(), 10)
+	return exp + "." + botCheckMAC(g.PassKey, exp+"|"+ip)
+}
+
+// CheckPass reports whether pass was issued to ip and has not expired.
+func (g *BotGuard) CheckPass(pass, ip string) bool {
+	exp, mac, ok := strings.Cut(pass, ".")
+	if !ok {
+		return false
+	}
+	expUnix, err := strconv.ParseInt(exp, 10, 64)
+	if err != nil || time.Now().Unix() >= expUnix {
+		return false
+	}
+	return hmac.Equal([]byte(mac), []byte(botCheckMAC(g.PassKey, exp+"|"+ip)))
+}
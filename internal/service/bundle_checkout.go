@@ -0,0 +1,256 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/iliyamo/cinema-seat-reservation/internal/db"
+	"github.com/iliyamo/cinema-seat-reservation/internal/metrics"
+	"github.com/iliyamo/cinema-seat-reservation/internal/money"
+	"github.com/iliyamo/cinema-seat-reservation/internal/repository"
+)
+
+// ErrBundleInactive is returned when checking out a bundle that the owner
+// has deactivated.
+var ErrBundleInactive = errors.New("bundle is not active")
+
+// ErrBundleSelection is returned when the seat selection does not name
+// exactly the shows included in the bundle, or names a show without seats.
+var ErrBundleSelection = errors.New("selection must include seats for every show in the bundle")
+
+// ShowNotBookableError reports a show in the bundle that cannot be booked
+// because it is not SCHEDULED or has already started.
+type ShowNotBookableError struct {
+	ShowID uint64
+}
+
+func (e *ShowNotBookableError) Error() string {
+	return fmt.Sprintf("show %d is not bookable", e.ShowID)
+}
+
+// SeatsUnavailableError reports seats in one show of the bundle that are
+// not FREE or are held by someone.
+type SeatsUnavailableError struct {
+	ShowID  uint64
+	SeatIDs []uint64
+}
+
+func (e *SeatsUnavailableError) Error() string {
+	return fmt.Sprintf("seats unavailable for show %d", e.ShowID)
+}
+
+// BundleReservation summarises one reservation created by a bundle checkout.
+type BundleReservation struct {
+	ReservationID    uint64 `json:"reservation_id"`
+	ShowID           uint64 `json:"show_id"`
+	SubtotalCents    uint32 `json:"subtotal_cents"`
+	DiscountCents    uint32 `json:"discount_cents"`
+	FeesCents        uint32 `json:"fees_cents"`
+	TaxCents         uint32 `json:"tax_cents"`
+	TotalAmountCents uint32 `json:"total_amount_cents"`
+	Currency         string `json:"currency"`
+}
+
+// BundleService coordinates the purchase of a bundle.  A checkout
+// reserves the selected seats in every show of the bundle inside one
+// database transaction, so a failure in any show rolls back the seats
+// already taken in the others.
+type BundleService struct {
+	BundleRepo      *repository.BundleRepo
+	ShowRepo        *repository.ShowRepo
+	ShowSeatRepo    *repository.ShowSeatRepo
+	SeatHoldRepo    *repository.SeatHoldRepo
+	ReservationRepo *repository.ReservationRepo
+}
+
+// NewBundleService constructs a BundleService.  All dependencies must be non-nil.
+func NewBundleService(bundleRepo *repository.BundleRepo, showRepo *repository.ShowRepo, showSeatRepo *repository.ShowSeatRepo, seatHoldRepo *repository.SeatHoldRepo, reservationRepo *repository.ReservationRepo) *BundleService {
+	if bundleRepo == nil || showRepo == nil || showSeatRepo == nil || seatHoldRepo == nil || reservationRepo == nil {
+		panic("nil repository passed to NewBundleService")
+	}
+	return &BundleService{
+		BundleRepo:      bundleRepo,
+		ShowRepo:        showRepo,
+		ShowSeatRepo:    showSeatRepo,
+		SeatHoldRepo:    seatHoldRepo,
+		ReservationRepo: reservationRepo,
+	}
+}
+
+// Checkout reserves the selected seats (show ID -> seat IDs) for every
+// show in the bundle on behalf of userID.  Shows and seats are locked in
+// ascending ID order to avoid deadlocks between concurrent checkouts.
+// On success one CONFIRMED reservation per show is returned; on any
+// failure nothing is persisted.  All shows must be priced in the same
+// currency (CurrencyMismatchError), since the bundle is paid at once.  Deadlocks and lock wait timeouts are
+// retried via db.WithTx.
+func (s *BundleService) Checkout(ctx context.Context, userID, bundleID uint64, selection map[uint64][]uint64) ([]BundleReservation, error) {
+	bundle, err := s.BundleRepo.GetByID(ctx, bundleID)
+	if err != nil {
+		return nil, err
+	}
+	if !bundle.IsActive {
+		return nil, ErrBundleInactive
+	}
+	if len(selection) != len(bundle.ShowIDs) {
+		return nil, ErrBundleSelection
+	}
+	for _, sid := range bundle.ShowIDs {
+		if len(selection[sid]) == 0 {
+			return nil, ErrBundleSelection
+		}
+	}
+	showIDs := append([]uint64(nil), bundle.ShowIDs...)
+	sort.Slice(showIDs, func(i, j int) bool { return showIDs[i] < showIDs[j] })
+
+	var out []BundleReservation
+	var expired int
+	err = db.WithTx(ctx, s.ShowSeatRepo.DB(), func(tx *sql.Tx) error {
+		var err error
+		out, expired, err = s.checkoutTx(ctx, tx, userID, bundle, showIDs, selection)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	metrics.HoldsExpired.Add(float64(expired), "request")
+	return out, nil
+}
+
+// checkoutTx performs one attempt of Checkout inside tx.  It also returns
+// the number of expired holds it cleared.
+func (s *BundleService) checkoutTx(ctx context.Context, tx *sql.Tx, userID uint64, bundle *repository.Bundle, showIDs []uint64, selection map[uint64][]uint64) ([]BundleReservation, int, error) {
+	now := time.Now().UTC()
+	out := make([]BundleReservation, 0, len(showIDs))
+	cleared := 0
+	for _, showID := range showIDs {
+		// The show must still be bookable.
+		var status string
+		var startsAt time.Time
+		var cinemaCurrency sql.NullString
+		if err := tx.QueryRowContext(ctx,
+			`SELECT s.status, s.starts_at, c.currency
+			 FROM shows s
+			 JOIN halls h ON h.id = s.hall_id
+			 LEFT JOIN cinemas c ON c.id = h.cinema_id
+			 WHERE s.id = ?`, showID).Scan(&status, &startsAt, &cinemaCurrency); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil, 0, &ShowNotBookableError{ShowID: showID}
+			}
+			return nil, 0, err
+		}
+		if status != "SCHEDULED" || !startsAt.After(now) {
+			return nil, 0, &ShowNotBookableError{ShowID: showID}
+		}
+		currency := money.Or(cinemaCurrency.String)
+		if len(out) > 0 && out[0].Currency != currency {
+			return nil, 0, &CurrencyMismatchError{Quoted: out[0].Currency, Current: currency}
+		}
+		// Clear stale holds so expired ones do not block the checkout.
+		expired, err := s.SeatHoldRepo.ExpireHoldsTx(ctx, tx, showID)
+		if err != nil {
+			return nil, 0, err
+		}
+		cleared += len(expired)
+		if err := s.ShowSeatRepo.BulkUpdateStatusTx(ctx, tx, showID, expired, "FREE"); err != nil {
+			return nil, 0, err
+		}
+		seatIDs := dedupeSorted(selection[showID])
+		if len(seatIDs) == 0 {
+			return nil, 0, ErrBundleSelection
+		}
+		locked, err := s.ShowSeatRepo.LockSeatsTx(ctx, tx, showID, seatIDs)
+		if err != nil {
+			return nil, 0, err
+		}
+		unavailable := make([]uint64, 0)
+		for _, seatID := range seatIDs {
+			ls, ok := locked[seatID]
+			if !ok || ls.Status != "FREE" || ls.HeldBy != nil {
+				unavailable = append(unavailable, seatID)
+			}
+		}
+		if len(unavailable) > 0 {
+			return nil, 0, &SeatsUnavailableError{ShowID: showID, SeatIDs: unavailable}
+		}
+		prices, err := s.ShowSeatRepo.GetPricesBySeatIDsTx(ctx, tx, showID, seatIDs)
+		if err != nil {
+			return nil, 0, err
+		}
+		subtotal := uint32(0)
+		for _, seatID := range seatIDs {
+			subtotal += prices[seatID]
+		}
+		discount := uint32(uint64(subtotal) * uint64(bundle.DiscountPercent) / 100)
+		// The cinema's fee and tax apply to the discounted price.
+		cinemaFees, err := s.ShowRepo.FeesTx(ctx, tx, showID)
+		if err != nil {
+			return nil, 0, err
+		}
+		fees, tax := cinemaFees.Charges(len(seatIDs), subtotal-discount)
+		bid := bundle.ID
+		rec := &repository.ReservationRecord{
+			UserID:           userID,
+			ShowID:           showID,
+			Status:           "CONFIRMED",
+			SubtotalCents:    subtotal,
+			TotalAmountCents: subtotal - discount + fees + tax,
+			Currency:         currency,
+			DiscountCents:    discount,
+			FeesCents:        fees,
+			TaxCents:         tax,
+			BundleID:         &bid,
+		}
+		if err := s.ReservationRepo.CreateTx(ctx, tx, rec); err != nil {
+			return nil, 0, err
+		}
+		seats := make([]repository.ReservationSeatRecord, 0, len(seatIDs))
+		for _, seatID := range seatIDs {
+			seats = append(seats, repository.ReservationSeatRecord{
+				ReservationID: rec.ID,
+				ShowID:        showID,
+				SeatID:        seatID,
+				PriceCents:    prices[seatID],
+			})
+		}
+		if err := s.ReservationRepo.CreateSeatsBulkTx(ctx, tx, seats); err != nil {
+			return nil, 0, err
+		}
+		if err := s.ShowSeatRepo.BulkUpdateStatusTx(ctx, tx, showID, seatIDs, "RESERVED"); err != nil {
+			return nil, 0, err
+		}
+		out = append(out, BundleReservation{
+			ReservationID:    rec.ID,
+			ShowID:           showID,
+			SubtotalCents:    subtotal,
+			DiscountCents:    discount,
+			FeesCents:        fees,
+			TaxCents:         tax,
+			TotalAmountCents: rec.TotalAmountCents,
+			Currency:         currency,
+		})
+	}
+	return out, cleared, nil
+}
+
+// dedupeSorted returns the non-zero IDs of in, deduplicated and sorted
+// ascending.
+func dedupeSorted(in []uint64) []uint64 {
+	seen := make(map[uint64]struct{}, len(in))
+	out := make([]uint64, 0, len(in))
+	for _, id := range in {
+		if id == 0 {
+			continue
+		}
+		if _, ok := seen[id]; !ok {
+			seen[id] = struct{}{}
+			out = append(out, id)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i] < out[j] })
+	return out
+}
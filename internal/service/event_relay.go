@@ -0,0 +1,112 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/iliyamo/cinema-seat-reservation/internal/db"
+	"github.com/iliyamo/cinema-seat-reservation/internal/metrics"
+	"github.com/iliyamo/cinema-seat-reservation/internal/queue"
+	"github.com/iliyamo/cinema-seat-reservation/internal/repository"
+)
+
+// BookingEventsTopic is the queue topic of booking events.  Messages are
+// repository.BookingEvent values encoded as JSON.
+const BookingEventsTopic = "booking.events"
+
+// EventRelay publishes the booking event outbox to BookingEventsTopic.
+// Reservations record their confirmations and cancellations in
+// booking_events inside the transaction that makes them (see
+// repository.ReservationRepo.Events), so no event is lost when the queue
+// is down and none is published for a change that rolled back.  Delivery is at least once: when the process
+// stops between publishing an event and recording it, the event is
+// published again, so consumers should ignore event IDs they have seen.
+// Events are published in the order they were recorded, except that an
+// event waiting for a retry can be overtaken.  Failed publications are
+// retried with exponential backoff starting at Interval until
+// MaxAttempts is reached.
+//
+// Like the hold expiry sweeper, each pass takes a MySQL named lock
+// (EventRelayLock), so with several replicas only one publishes at a time.
+type EventRelay struct {
+	Repo        *repository.BookingEventRepo
+	Publisher   queue.Publisher
+	Interval    time.Duration
+	BatchSize   int    // events published per pass
+	MaxAttempts uint32 // publications tried before an event is FAILED
+	// Heartbeat is updated after every pass for GET /readyz.
+	Heartbeat Heartbeat
+}
+
+// EventRelayLock is the MySQL named lock held during a relay pass.
+const EventRelayLock = "cinema.event_relay"
+
+// NewEventRelay constructs a relay publishing through publisher at the
+// given interval.
+func NewEventRelay(repo *repository.BookingEventRepo, publisher queue.Publisher, interval time.Duration) *EventRelay {
+	if repo == nil || publisher == nil {
+		panic("nil dependency passed to NewEventRelay")
+	}
+	return &EventRelay{Repo: repo, Publisher: publisher, Interval: interval, BatchSize: 100, MaxAttempts: 10}
+}
+
+// Run publishes events until ctx is cancelled.  Errors are logged and the
+// next tick retries.
+func (w *EventRelay) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.Interval)
+	defer ticker.Stop()
+	w.Heartbeat.Beat(nil)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := w.Sweep(ctx)
+			if err != nil {
+				log.Printf("booking event relay failed: %v", err)
+			}
+			w.Heartbeat.Beat(err)
+		}
+	}
+}
+
+// Sweep publishes one batch of due events.  An event the publisher
+// rejects is rescheduled and ends the pass, since the following events
+// would most likely fail too; only database errors fail the pass.
+func (w *EventRelay) Sweep(ctx context.Context) error {
+	release, ok, err := db.TryLock(ctx, w.Repo.DB(), EventRelayLock)
+	if err != nil || !ok {
+		return err
+	}
+	defer release()
+	due, err := w.Repo.Due(ctx, w.BatchSize)
+	if err != nil {
+		return err
+	}
+	for _, e := range due {
+		body, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		pubErr := w.Publisher.Publish(ctx, BookingEventsTopic, body)
+		if pubErr == nil {
+			metrics.BookingEvents.Inc("published")
+			if err := w.Repo.MarkPublished(ctx, e.ID); err != nil {
+				return err
+			}
+			continue
+		}
+		var retryAt *time.Time
+		outcome := "failed"
+		if attempts := e.Attempts + 1; attempts < w.MaxAttempts {
+			t := time.Now().UTC().Add(w.Interval << attempts)
+			retryAt, outcome = &t, "retry"
+		}
+		log.Printf("booking event %d (%s) not published (%s): %v", e.ID, e.Kind, outcome, pubErr)
+		metrics.BookingEvents.Inc(outcome)
+		return w.Repo.MarkFailed(ctx, e.ID, pubErr.Error(), retryAt)
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Heartbeat records when a background worker last completed a pass and
+// whether it failed, so readiness probes can tell a stuck or failing
+// worker from a healthy one.  The zero value is ready to use.
+type Heartbeat struct {
+	mu      sync.Mutex
+	last    time.Time
+	lastErr error
+}
+
+// Beat records a finished pass; err is its outcome.  Workers also beat
+// once when they start so a probe does not report them before the first
+// tick.
+func (h *Heartbeat) Beat(err error) {
+	h.mu.Lock()
+	h.last = time.Now()
+	h.lastErr = err
+	h.mu.Unlock()
+}
+
+// Check returns an error when the worker never started, has not beaten
+// within maxAge, or its last pass failed.
+func (h *Heartbeat) Check(maxAge time.Duration) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	switch {
+	case h.last.IsZero():
+		return errors.New("not running")
+	case time.Since(h.last) > maxAge:
+		return fmt.Errorf("last pass %s ago", time.Since(h.last).Round(time.Second))
+	case h.lastErr != nil:
+		return fmt.Errorf("last pass failed: %v", h.lastErr)
+	}
+	return nil
+}
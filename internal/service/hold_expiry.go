@@ -0,0 +1,124 @@
+// Package service holds the booking logic shared by the HTTP and gRPC
+// APIs, and the background workers that run beside them, such as the
+// hold expiry sweeper.
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/iliyamo/cinema-seat-reservation/internal/db"
+	"github.com/iliyamo/cinema-seat-reservation/internal/metrics"
+	"github.com/iliyamo/cinema-seat-reservation/internal/repository"
+)
+
+// HoldExpiryWorker periodically releases expired seat holds across all
+// shows.  Handlers already expire holds lazily for the show they touch;
+// the worker makes sure seats return to FREE promptly even when no
+// request arrives for that show, so public seat maps and counters reflect
+// an expired hold within one sweep interval.
+//
+// With several replicas every instance runs a worker, but each sweep first
+// takes the MySQL named lock HoldSweepLock, so only one of them sweeps per
+// tick and the others skip it (counted in cinema_hold_sweeps_skipped_total).
+// After the sweep commits, OnExpired is told which seats of which shows
+// were released, which the server uses to offer them to waitlists, and
+// when Guests is set the sweep also deletes guest sessions that have
+// outlived GuestTTL and hold no seats.
+type HoldExpiryWorker struct {
+	SeatHoldRepo *repository.SeatHoldRepo
+	ShowSeatRepo *repository.ShowSeatRepo
+	Interval     time.Duration
+	// OnExpired, when set, is invoked after each committed sweep with the
+	// seats that were released, keyed by show ID.
+	OnExpired func(showID uint64, seatIDs []uint64)
+	// Guests, when set, has every sweep also delete guest users older
+	// than GuestTTL that no longer hold seats.
+	Guests   *repository.UserRepo
+	GuestTTL time.Duration
+	// Heartbeat is updated after every sweep for GET /readyz.
+	Heartbeat Heartbeat
+}
+
+// HoldSweepLock is the MySQL named lock held during a sweep.
+const HoldSweepLock = "cinema.hold_expiry"
+
+// NewHoldExpiryWorker constructs a worker sweeping at the given interval.
+func NewHoldExpiryWorker(holdRepo *repository.SeatHoldRepo, showSeatRepo *repository.ShowSeatRepo, interval time.Duration) *HoldExpiryWorker {
+	if holdRepo == nil || showSeatRepo == nil {
+		panic("nil repository passed to NewHoldExpiryWorker")
+	}
+	return &HoldExpiryWorker{SeatHoldRepo: holdRepo, ShowSeatRepo: showSeatRepo, Interval: interval}
+}
+
+// Run sweeps until ctx is cancelled.  Errors are logged and the next tick
+// retries; a failed sweep leaves holds for the lazy per-request cleanup.
+func (w *HoldExpiryWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.Interval)
+	defer ticker.Stop()
+	w.Heartbeat.Beat(nil)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := w.Sweep(ctx)
+			if err != nil {
+				log.Printf("hold expiry sweep failed: %v", err)
+			}
+			w.Heartbeat.Beat(err)
+		}
+	}
+}
+
+// Sweep performs a single expiry pass inside one transaction.  It does
+// nothing when another instance holds HoldSweepLock.
+func (w *HoldExpiryWorker) Sweep(ctx context.Context) error {
+	release, ok, err := db.TryLock(ctx, w.ShowSeatRepo.DB(), HoldSweepLock)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		metrics.HoldSweepsSkipped.Inc()
+		return nil
+	}
+	defer release()
+	tx, err := w.ShowSeatRepo.DB().BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			_ = tx.Rollback()
+		}
+	}()
+	released, err := w.SeatHoldRepo.ExpireAllHoldsTx(ctx, tx)
+	if err != nil {
+		return err
+	}
+	for showID, seatIDs := range released {
+		if err := w.ShowSeatRepo.BulkUpdateStatusTx(ctx, tx, showID, seatIDs, "FREE"); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	committed = true
+	for _, seatIDs := range released {
+		metrics.HoldsExpired.Add(float64(len(seatIDs)), "sweeper")
+	}
+	if w.OnExpired != nil {
+		for showID, seatIDs := range released {
+			w.OnExpired(showID, seatIDs)
+		}
+	}
+	if w.Guests != nil && w.GuestTTL > 0 {
+		if _, err := w.Guests.DeleteStaleGuests(ctx, int(w.GuestTTL/time.Second)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
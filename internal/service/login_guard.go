@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/iliyamo/cinema-seat-reservation/internal/metrics"
+	"github.com/iliyamo/cinema-seat-reservation/internal/repository"
+)
+
+// LoginLockedError is returned when a login is refused because the account
+// or the client address is locked out.
+type LoginLockedError struct {
+	Scope      string // repository.ThrottleAccount or repository.ThrottleIP
+	RetryAfter time.Duration
+}
+
+func (e *LoginLockedError) Error() string {
+	return fmt.Sprintf("login locked (%s) for %s", e.Scope, e.RetryAfter)
+}
+
+// LoginGuard throttles password guessing.  Failed logins are counted per
+// account and per client address; reaching MaxAccountFailures (or
+// MaxIPFailures) within FailureWindow locks the subject for LockoutBase,
+// doubling with every further lockout up to LockoutMax.  Escalation resets
+// after a successful login or a day without failures.  Lockouts are written
+// to the audit log.  State lives in MySQL so all replicas share it.
+type LoginGuard struct {
+	Repo  *repository.LoginThrottleRepo
+	Audit *repository.AuditRepo
+
+	MaxAccountFailures uint32
+	MaxIPFailures      uint32
+	FailureWindow      time.Duration
+	LockoutBase        time.Duration
+	LockoutMax         time.Duration
+}
+
+// lockoutDecay is how long after the last failure the escalation resets.
+const lockoutDecay = 24 * time.Hour
+
+// Check returns a *LoginLockedError when email or ip is currently locked.
+// Account lockouts take precedence.  Database errors are logged and the
+// login is allowed, so an outage of the throttle table does not lock
+// everyone out.
+func (g *LoginGuard) Check(ctx context.Context, email, ip string) error {
+	now := time.Now().UTC()
+	for _, s := range g.subjects(email, ip) {
+		t, err := g.Repo.Get(ctx, s.scope, s.subject)
+		if err != nil {
+			log.Printf("login guard: load %s throttle: %v", s.scope, err)
+			continue
+		}
+		if t != nil && t.LockedUntil != nil && t.LockedUntil.After(now) {
+			return &LoginLockedError{Scope: s.scope, RetryAfter: t.LockedUntil.Sub(now)}
+		}
+	}
+	return nil
+}
+
+// Failure records a failed login for email (whose account is userID, or 0
+// when no such account exists) from ip.  It returns a *LoginLockedError
+// when this failure triggered a lockout.
+func (g *LoginGuard) Failure(ctx context.Context, email, ip string, userID uint64) error {
+	var locked error
+	for _, s := range g.subjects(email, ip) {
+		t, err := g.Repo.RecordFailure(ctx, s.scope, s.subject, g.FailureWindow, lockoutDecay)
+		if err != nil {
+			log.Printf("login guard: record %s failure: %v", s.scope, err)
+			continue
+		}
+		if t == nil || s.max == 0 || t.Failures < s.max {
+			continue
+		}
+		d := g.lockoutFor(t.Lockouts)
+		ok, err := g.Repo.Lock(ctx, s.scope, s.subject, s.max, time.Now().Add(d))
+		if err != nil {
+			log.Printf("login guard: lock %s: %v", s.scope, err)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		metrics.LoginLockouts.Inc(s.scope)
+		g.audit(ctx, s, ip, userID, d, t.Lockouts+1)
+		if locked == nil {
+			locked = &LoginLockedError{Scope: s.scope, RetryAfter: d}
+		}
+	}
+	return locked
+}
+
+// Success clears the account's failure state after a successful login.
+// The address state is kept so one valid account cannot be used to reset
+// the counter of an address guessing at others.
+func (g *LoginGuard) Success(ctx context.Context, email string) {
+	if err := g.Repo.Clear(ctx, repository.ThrottleAccount, email); err != nil {
+		log.Printf("login guard: clear account throttle: %v", err)
+	}
+}
+
+// lockoutFor returns the lockout duration after n previous lockouts.
+func (g *LoginGuard) lockoutFor(n uint32) time.Duration {
+	d := g.LockoutBase
+	for i := uint32(0); i < n && d < g.LockoutMax; i++ {
+		d *= 2
+	}
+	if g.LockoutMax > 0 && d > g.LockoutMax {
+		d = g.LockoutMax
+	}
+	return d
+}
+
+type throttleSubject struct {
+	scope, subject string
+	max            uint32
+}
+
+func (g *LoginGuard) subjects(email, ip string) []throttleSubject {
+	out := []throttleSubject{{scope: repository.ThrottleAccount, subject: email, max: g.MaxAccountFailures}}
+	if ip != "" {
+		out = append(out, throttleSubject{scope: repository.ThrottleIP, subject: ip, max: g.MaxIPFailures})
+	}
+	return out
+}
+
+// audit writes a lockout to the audit log.
+func (g *LoginGuard) audit(ctx context.Context, s throttleSubject, ip string, userID uint64, d time.Duration, lockouts uint32) {
+	if g.Audit == nil {
+		return
+	}
+	e := &repository.AuditEvent{Action: "auth.lockout", EntityType: "email", EntityID: s.subject, IP: ip}
+	switch {
+	case s.scope == repository.ThrottleIP:
+		e.EntityType = "ip"
+	case userID != 0:
+		e.EntityType, e.EntityID = "user", fmt.Sprint(userID)
+	}
+	details := map[string]interface{}{
+		"scope":        s.scope,
+		"subject":      s.subject,
+		"duration_sec": int64(d / time.Second),
+		"lockouts":     lockouts,
+	}
+	if err := g.Audit.Record(ctx, e, details); err != nil {
+		log.Printf("login guard: audit lockout: %v", err)
+	}
+}
@@ -0,0 +1,55 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// Mailer sends transactional email such as password reset links.
+type Mailer interface {
+	Send(ctx context.Context, to, subject, body string) error
+}
+
+// LogMailer writes messages to the log instead of sending them.  It is
+// meant for development; never use it where logs are shared, since reset
+// links grant access to accounts.
+type LogMailer struct{}
+
+// Send implements Mailer.
+func (LogMailer) Send(_ context.Context, to, subject, body string) error {
+	log.Printf("mail to %s: %s\n%s", to, subject, body)
+	return nil
+}
+
+// SMTPMailer sends plain-text messages through an SMTP relay.  Username
+// and Password enable PLAIN authentication, which net/smtp only performs
+// over TLS or to localhost.
+type SMTPMailer struct {
+	Addr     string // host:port of the relay
+	From     string // envelope and header sender
+	Username string
+	Password string
+}
+
+// Send implements Mailer.  net/smtp has no context support, so ctx is
+// only checked before connecting.
+func (m *SMTPMailer) Send(ctx context.Context, to, subject, body string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	var auth smtp.Auth
+	if m.Username != "" {
+		host := m.Addr
+		if i := strings.LastIndex(host, ":"); i >= 0 {
+			host = host[:i]
+		}
+		auth = smtp.PlainAuth("", m.Username, m.Password, host)
+	}
+	msg := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\nDate: %s\r\nMIME-Version: 1.0\r\nContent-Type: text/plain; charset=utf-8\r\n\r\n%s",
+		m.From, to, subject, time.Now().Format(time.RFC1123Z), strings.ReplaceAll(body, "\n", "\r\n"))
+	return smtp.SendMail(m.Addr, auth, m.From, []string{to}, []byte(msg))
+}
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/iliyamo/cinema-seat-reservation/internal/metrics"
+	"github.com/iliyamo/cinema-seat-reservation/internal/repository"
+)
+
+// NoShowWorker periodically marks the seats of confirmed reservations
+// that were not checked in by the end of their show as NO_SHOW (see
+// ReservationRepo.MarkNoShows).  A pass is a single idempotent UPDATE, so
+// replicas may run it side by side.
+type NoShowWorker struct {
+	ReservationRepo *repository.ReservationRepo
+	Interval        time.Duration
+	// Heartbeat is updated after every pass for GET /readyz.
+	Heartbeat Heartbeat
+}
+
+// NewNoShowWorker constructs a worker marking no-shows at the given
+// interval.
+func NewNoShowWorker(resRepo *repository.ReservationRepo, interval time.Duration) *NoShowWorker {
+	if resRepo == nil {
+		panic("nil repository passed to NewNoShowWorker")
+	}
+	return &NoShowWorker{ReservationRepo: resRepo, Interval: interval}
+}
+
+// Run marks no-shows until ctx is cancelled.  Errors are logged and the
+// next tick retries.
+func (w *NoShowWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.Interval)
+	defer ticker.Stop()
+	w.Heartbeat.Beat(nil)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := w.Sweep(ctx)
+			if err != nil {
+				log.Printf("no-show marking failed: %v", err)
+			}
+			w.Heartbeat.Beat(err)
+		}
+	}
+}
+
+// Sweep marks the seats of every ended show that were not checked in.
+func (w *NoShowWorker) Sweep(ctx context.Context) error {
+	n, err := w.ReservationRepo.MarkNoShows(ctx, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		metrics.SeatAttendance.Add(float64(n), "no_show")
+	}
+	return nil
+}
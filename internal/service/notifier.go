@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/iliyamo/cinema-seat-reservation/internal/metrics"
+	"github.com/iliyamo/cinema-seat-reservation/internal/repository"
+)
+
+// NotificationWorker delivers queued notifications by email.  Handlers
+// queue notifications inside the transaction of the change they report,
+// so customers only hear about committed changes.  Delivery is at least
+// once: when the process stops between sending a message and recording
+// it, the message is sent again.  Failed deliveries are retried with
+// exponential backoff starting at Interval until MaxAttempts is reached.
+type NotificationWorker struct {
+	Repo        *repository.NotificationRepo
+	Mailer      Mailer
+	Interval    time.Duration
+	BatchSize   int    // notifications delivered per pass
+	MaxAttempts uint32 // deliveries tried before a notification is FAILED
+	// Heartbeat is updated after every pass for GET /readyz.
+	Heartbeat Heartbeat
+}
+
+// NewNotificationWorker constructs a worker delivering through mailer at
+// the given interval.
+func NewNotificationWorker(repo *repository.NotificationRepo, mailer Mailer, interval time.Duration) *NotificationWorker {
+	if repo == nil || mailer == nil {
+		panic("nil dependency passed to NewNotificationWorker")
+	}
+	return &NotificationWorker{Repo: repo, Mailer: mailer, Interval: interval, BatchSize: 100, MaxAttempts: 5}
+}
+
+// Run delivers notifications until ctx is cancelled.  Errors are logged
+// and the next tick retries.
+func (w *NotificationWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.Interval)
+	defer ticker.Stop()
+	w.Heartbeat.Beat(nil)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := w.Sweep(ctx)
+			if err != nil {
+				log.Printf("notification sweep failed: %v", err)
+			}
+			w.Heartbeat.Beat(err)
+		}
+	}
+}
+
+// Sweep delivers one batch of due notifications.  A message the mailer
+// rejects is rescheduled and does not fail the pass; only database
+// errors do.
+func (w *NotificationWorker) Sweep(ctx context.Context) error {
+	due, err := w.Repo.Due(ctx, w.BatchSize)
+	if err != nil {
+		return err
+	}
+	for _, n := range due {
+		sendErr := w.Mailer.Send(ctx, n.Email, n.Subject, n.Body)
+		if sendErr == nil {
+			metrics.Notifications.Inc("sent")
+			if err := w.Repo.MarkSent(ctx, n.ID); err != nil {
+				return err
+			}
+			continue
+		}
+		var retryAt *time.Time
+		outcome := "failed"
+		if attempts := n.Attempts + 1; attempts < w.MaxAttempts {
+			t := time.Now().UTC().Add(w.Interval << attempts)
+			retryAt, outcome = &t, "retry"
+		}
+		log.Printf("notification %d to user %d failed (%s): %v", n.ID, n.UserID, outcome, sendErr)
+		metrics.Notifications.Inc(outcome)
+		if err := w.Repo.MarkFailed(ctx, n.ID, sendErr.Error(), retryAt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/iliyamo/cinema-seat-reservation/internal/db"
+	"github.com/iliyamo/cinema-seat-reservation/internal/repository"
+)
+
+// OwnerDigestWorker emails owners who enabled the daily digest a summary
+// of the previous day's bookings per show.  Days run from midnight to
+// midnight in the owner's time zone; once the owner's clock reaches the
+// chosen hour a pass queues an "owner.digest" notification, which the
+// NotificationWorker then emails.  The day is claimed in the transaction
+// that queues the message, so it is sent once even with several replicas
+// running.  Days without bookings are claimed without a message.
+type OwnerDigestWorker struct {
+	Repo          *repository.OwnerNotificationRepo
+	Notifications *repository.NotificationRepo
+	Interval      time.Duration
+	// Heartbeat is updated after every pass for GET /readyz.
+	Heartbeat Heartbeat
+}
+
+// NewOwnerDigestWorker constructs a digest worker running at the given
+// interval.
+func NewOwnerDigestWorker(repo *repository.OwnerNotificationRepo, notifications *repository.NotificationRepo, interval time.Duration) *OwnerDigestWorker {
+	if repo == nil || notifications == nil {
+		panic("nil dependency passed to NewOwnerDigestWorker")
+	}
+	return &OwnerDigestWorker{Repo: repo, Notifications: notifications, Interval: interval}
+}
+
+// Run sends digests until ctx is cancelled.  Errors are logged and the
+// next tick retries.
+func (w *OwnerDigestWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.Interval)
+	defer ticker.Stop()
+	w.Heartbeat.Beat(nil)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := w.Sweep(ctx, time.Now())
+			if err != nil {
+				log.Printf("owner digest pass failed: %v", err)
+			}
+			w.Heartbeat.Beat(err)
+		}
+	}
+}
+
+// Sweep queues the digests that are due at now.
+func (w *OwnerDigestWorker) Sweep(ctx context.Context, now time.Time) error {
+	owners, err := w.Repo.DigestSubscribers(ctx)
+	if err != nil {
+		return err
+	}
+	for _, s := range owners {
+		loc, err := time.LoadLocation(s.Timezone)
+		if err != nil {
+			loc = time.UTC
+		}
+		local := now.In(loc)
+		if local.Hour() < s.DigestHour {
+			continue
+		}
+		to := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+		from := to.AddDate(0, 0, -1)
+		day := from.Format("2006-01-02")
+		if s.LastDigestOn != nil && *s.LastDigestOn >= day {
+			continue
+		}
+		userID := s.UserID
+		err = db.WithTx(ctx, w.Repo.DB(), func(tx *sql.Tx) error {
+			claimed, err := w.Repo.ClaimDigestTx(ctx, tx, userID, day)
+			if err != nil || !claimed {
+				return err
+			}
+			shows, err := w.Repo.DigestTx(ctx, tx, userID, from, to)
+			if err != nil || len(shows) == 0 {
+				return err
+			}
+			n := ownerDigestNotification(userID, day, loc, shows)
+			return w.Notifications.EnqueueTx(ctx, tx, &n)
+		})
+		if err != nil {
+			return fmt.Errorf("digest for owner %d: %w", userID, err)
+		}
+	}
+	return nil
+}
+
+// ownerDigestNotification returns the digest of day for ownerID, with
+// show times in loc.
+func ownerDigestNotification(ownerID uint64, day string, loc *time.Location, shows []repository.DigestShow) repository.Notification {
+	var b strings.Builder
+	var reservations, seats int
+	var revenue uint64
+	fmt.Fprintf(&b, "Bookings made on %s (%s):\n\n", day, loc)
+	for _, s := range shows {
+		fmt.Fprintf(&b, "%s — %s, %s (%s): %d reservations, %d seats, %s\n",
+			s.StartsAt.In(loc).Format("2006-01-02 15:04"), s.Title, s.CinemaName, s.HallName,
+			s.Reservations, s.Seats, formatCents(s.RevenueCents))
+		reservations += s.Reservations
+		seats += s.Seats
+		revenue += s.RevenueCents
+	}
+	fmt.Fprintf(&b, "\nTotal: %d reservations, %d seats, %s across %d shows.\n",
+		reservations, seats, formatCents(revenue), len(shows))
+	return repository.Notification{
+		UserID:  ownerID,
+		Kind:    "owner.digest",
+		Subject: "Daily bookings summary for " + day,
+		Body:    b.String(),
+	}
+}
+
+// formatCents formats an amount in cents as units with two decimals.
+func formatCents(cents uint64) string {
+	return fmt.Sprintf("%d.%02d", cents/100, cents%100)
+}
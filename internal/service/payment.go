@@ -0,0 +1,76 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrPaymentDeclined is returned by PaymentGateway.Charge when the payment
+// was refused.  Any other error leaves the outcome unknown.
+var ErrPaymentDeclined = errors.New("payment declined")
+
+// PaymentGateway is the payment provider used by BookingSagaService.  Every
+// call is keyed by an idempotency key so a retried or recovered saga never
+// charges twice.
+type PaymentGateway interface {
+	// Charge captures amountCents, in minor units of currency, and
+	// returns the provider's reference.  Repeating a charge with the same
+	// key returns the original result.
+	Charge(ctx context.Context, key string, amountCents uint32, currency string) (ref string, err error)
+	// Lookup reports whether a charge with key was captured.
+	Lookup(ctx context.Context, key string) (ref string, charged bool, err error)
+	// Void cancels or refunds the charge made with key.  Voiding a key
+	// that was never charged is not an error.
+	Void(ctx context.Context, key string) error
+}
+
+// FakePaymentGateway is an in-memory PaymentGateway for development and
+// demos.  It approves every charge up to DeclineOverCents (0 means no
+// limit).  Charges are lost on restart, so recovery treats them as never
+// made.
+type FakePaymentGateway struct {
+	DeclineOverCents uint32
+
+	mu      sync.Mutex
+	charges map[string]string
+	next    int
+}
+
+// NewFakePaymentGateway returns an empty FakePaymentGateway.
+func NewFakePaymentGateway() *FakePaymentGateway {
+	return &FakePaymentGateway{charges: map[string]string{}}
+}
+
+// Charge implements PaymentGateway.
+func (g *FakePaymentGateway) Charge(ctx context.Context, key string, amountCents uint32, currency string) (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if ref, ok := g.charges[key]; ok {
+		return ref, nil
+	}
+	if g.DeclineOverCents > 0 && amountCents > g.DeclineOverCents {
+		return "", ErrPaymentDeclined
+	}
+	g.next++
+	ref := fmt.Sprintf("fake_%d", g.next)
+	g.charges[key] = ref
+	return ref, nil
+}
+
+// Lookup implements PaymentGateway.
+func (g *FakePaymentGateway) Lookup(ctx context.Context, key string) (string, bool, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	ref, ok := g.charges[key]
+	return ref, ok, nil
+}
+
+// Void implements PaymentGateway.
+func (g *FakePaymentGateway) Void(ctx context.Context, key string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	delete(g.charges, key)
+	return nil
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/iliyamo/cinema-seat-reservation/internal/db"
+	"github.com/iliyamo/cinema-seat-reservation/internal/metrics"
+	"github.com/iliyamo/cinema-seat-reservation/internal/repository"
+)
+
+// PendingExpiryWorker drops PENDING reservations that were not paid
+// within Window of their creation: each is deleted, its seats return to
+// FREE and a reservation.expired booking event is recorded in the same
+// transaction.  Reservations of an unfinished booking saga are left to
+// the saga's recovery (see ReservationRepo.StalePending).
+//
+// Every reservation is expired in its own transaction after locking and
+// re-checking its row, so replicas running the worker side by side never
+// expire one twice and a payment confirmed meanwhile wins.
+type PendingExpiryWorker struct {
+	ReservationRepo *repository.ReservationRepo
+	ShowSeatRepo    *repository.ShowSeatRepo
+	Window          time.Duration // how long a reservation may stay PENDING
+	Interval        time.Duration
+	BatchSize       int // reservations expired per pass
+	// OnExpired, when set, is invoked after each expiry with the freed
+	// seats of the reservation's show.
+	OnExpired func(showID uint64, seatIDs []uint64)
+	// Heartbeat is updated after every pass for GET /readyz.
+	Heartbeat Heartbeat
+}
+
+// NewPendingExpiryWorker constructs a worker expiring reservations older
+// than window at the given interval.
+func NewPendingExpiryWorker(resRepo *repository.ReservationRepo, showSeatRepo *repository.ShowSeatRepo, window, interval time.Duration) *PendingExpiryWorker {
+	if resRepo == nil || showSeatRepo == nil {
+		panic("nil repository passed to NewPendingExpiryWorker")
+	}
+	return &PendingExpiryWorker{ReservationRepo: resRepo, ShowSeatRepo: showSeatRepo, Window: window, Interval: interval, BatchSize: 100}
+}
+
+// Run expires reservations until ctx is cancelled.  Errors are logged and
+// the next tick retries.
+func (w *PendingExpiryWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.Interval)
+	defer ticker.Stop()
+	w.Heartbeat.Beat(nil)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := w.Sweep(ctx)
+			if err != nil {
+				log.Printf("pending reservation expiry failed: %v", err)
+			}
+			w.Heartbeat.Beat(err)
+		}
+	}
+}
+
+// Sweep expires one batch of overdue PENDING reservations.
+func (w *PendingExpiryWorker) Sweep(ctx context.Context) error {
+	ids, err := w.ReservationRepo.StalePending(ctx, time.Now().UTC().Add(-w.Window), w.BatchSize)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		var showID uint64
+		var seatIDs []uint64
+		err := db.WithTx(ctx, w.ShowSeatRepo.DB(), func(tx *sql.Tx) error {
+			var err error
+			showID, seatIDs, err = w.ReservationRepo.ExpirePendingTx(ctx, tx, id)
+			if err != nil {
+				return err
+			}
+			if len(seatIDs) == 0 {
+				return nil
+			}
+			return w.ShowSeatRepo.BulkUpdateStatusTx(ctx, tx, showID, seatIDs, "FREE")
+		})
+		if errors.Is(err, repository.ErrReservationNotPending) {
+			continue // confirmed or cancelled since it was listed
+		}
+		if err != nil {
+			return err
+		}
+		metrics.ReservationsExpired.Inc()
+		if w.OnExpired != nil {
+			w.OnExpired(showID, seatIDs)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,202 @@
+package service
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/iliyamo/cinema-seat-reservation/internal/repository"
+)
+
+// QuotaStatus describes an account's position against its quota after a
+// request has been counted.  Limits are nil when the period is unlimited.
+type QuotaStatus struct {
+	Limited      bool
+	Allowed      bool
+	DailyLimit   *uint32
+	DailyUsed    uint64
+	MonthlyLimit *uint32
+	MonthlyUsed  uint64
+	// ResetAt is when the exhausted period rolls over; zero when Allowed.
+	ResetAt time.Time
+}
+
+// usageKey identifies one counter in account_usage.
+type usageKey struct {
+	userID uint64
+	period string
+	start  time.Time
+}
+
+// quotaEntry caches an account's limits and in-memory counters.
+type quotaEntry struct {
+	quota    *repository.AccountQuota
+	loadedAt time.Time
+	day      time.Time
+	month    time.Time
+	dayUsed  uint64
+	monUsed  uint64
+}
+
+// QuotaTracker enforces per-account request quotas.  Counters are kept in
+// memory and the increments are flushed to account_usage every
+// FlushInterval, so the hot path performs no writes.  Limits are cached for
+// LimitsTTL; Invalidate drops the cache after an admin change.  Counts are
+// per process: with several replicas each one enforces the shared total
+// read at load time plus its own increments, so an account may overshoot
+// its quota by at most one flush interval of traffic per replica.
+type QuotaTracker struct {
+	Repo          *repository.QuotaRepo
+	FlushInterval time.Duration
+	LimitsTTL     time.Duration
+	// Heartbeat is updated after every flush for GET /readyz.
+	Heartbeat Heartbeat
+
+	mu      sync.Mutex
+	entries map[uint64]*quotaEntry
+	pending map[usageKey]uint64
+}
+
+// NewQuotaTracker constructs a tracker flushing at the given interval.
+func NewQuotaTracker(repo *repository.QuotaRepo, flushInterval time.Duration) *QuotaTracker {
+	if repo == nil {
+		panic("nil repository passed to NewQuotaTracker")
+	}
+	return &QuotaTracker{
+		Repo:          repo,
+		FlushInterval: flushInterval,
+		LimitsTTL:     time.Minute,
+		entries:       make(map[uint64]*quotaEntry),
+		pending:       make(map[usageKey]uint64),
+	}
+}
+
+// Allow counts one request for userID and reports whether it is within the
+// account's quota.  Rejected requests are not counted.  Accounts without a
+// quota are always allowed and not tracked.
+func (t *QuotaTracker) Allow(ctx context.Context, userID uint64) (QuotaStatus, error) {
+	now := time.Now().UTC()
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	t.mu.Lock()
+	e := t.entries[userID]
+	stale := e == nil || now.Sub(e.loadedAt) > t.LimitsTTL || !e.day.Equal(day)
+	t.mu.Unlock()
+	if stale {
+		loaded, err := t.load(ctx, userID, day, month, now)
+		if err != nil {
+			return QuotaStatus{}, err
+		}
+		t.mu.Lock()
+		t.entries[userID] = loaded
+		e = loaded
+		t.mu.Unlock()
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	st := QuotaStatus{}
+	if e.quota == nil {
+		st.Allowed = true
+		return st, nil
+	}
+	st.Limited = true
+	st.DailyLimit = e.quota.DailyLimit
+	st.MonthlyLimit = e.quota.MonthlyLimit
+	st.DailyUsed = e.dayUsed
+	st.MonthlyUsed = e.monUsed
+	if e.quota.MonthlyLimit != nil && e.monUsed >= uint64(*e.quota.MonthlyLimit) {
+		st.ResetAt = month.AddDate(0, 1, 0)
+		return st, nil
+	}
+	if e.quota.DailyLimit != nil && e.dayUsed >= uint64(*e.quota.DailyLimit) {
+		st.ResetAt = day.AddDate(0, 0, 1)
+		return st, nil
+	}
+	e.dayUsed++
+	e.monUsed++
+	t.pending[usageKey{userID, repository.QuotaPeriodDay, day}]++
+	t.pending[usageKey{userID, repository.QuotaPeriodMonth, month}]++
+	st.Allowed = true
+	st.DailyUsed = e.dayUsed
+	st.MonthlyUsed = e.monUsed
+	return st, nil
+}
+
+// load reads the account's quota and persisted usage and adds increments
+// that have not been flushed yet.
+func (t *QuotaTracker) load(ctx context.Context, userID uint64, day, month, now time.Time) (*quotaEntry, error) {
+	q, err := t.Repo.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	e := &quotaEntry{quota: q, loadedAt: now, day: day, month: month}
+	if q == nil {
+		return e, nil
+	}
+	if e.dayUsed, err = t.Repo.Usage(ctx, userID, repository.QuotaPeriodDay, day); err != nil {
+		return nil, err
+	}
+	if e.monUsed, err = t.Repo.Usage(ctx, userID, repository.QuotaPeriodMonth, month); err != nil {
+		return nil, err
+	}
+	t.mu.Lock()
+	e.dayUsed += t.pending[usageKey{userID, repository.QuotaPeriodDay, day}]
+	e.monUsed += t.pending[usageKey{userID, repository.QuotaPeriodMonth, month}]
+	t.mu.Unlock()
+	return e, nil
+}
+
+// Invalidate drops the cached limits for userID so the next request reads
+// the current quota.
+func (t *QuotaTracker) Invalidate(userID uint64) {
+	t.mu.Lock()
+	delete(t.entries, userID)
+	t.mu.Unlock()
+}
+
+// Run flushes counters until ctx is cancelled, then flushes once more.
+func (t *QuotaTracker) Run(ctx context.Context) {
+	ticker := time.NewTicker(t.FlushInterval)
+	defer ticker.Stop()
+	t.Heartbeat.Beat(nil)
+	for {
+		select {
+		case <-ctx.Done():
+			if err := t.Flush(context.Background()); err != nil {
+				log.Printf("quota flush failed: %v", err)
+			}
+			return
+		case <-ticker.C:
+			err := t.Flush(ctx)
+			if err != nil {
+				log.Printf("quota flush failed: %v", err)
+			}
+			t.Heartbeat.Beat(err)
+		}
+	}
+}
+
+// Flush writes pending increments to account_usage.  Increments that fail
+// to persist are kept and retried on the next flush.
+func (t *QuotaTracker) Flush(ctx context.Context) error {
+	t.mu.Lock()
+	batch := t.pending
+	t.pending = make(map[usageKey]uint64)
+	t.mu.Unlock()
+
+	var firstErr error
+	for k, n := range batch {
+		if err := t.Repo.AddUsage(ctx, k.userID, k.period, k.start, n); err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			t.mu.Lock()
+			t.pending[k] += n
+			t.mu.Unlock()
+		}
+	}
+	return firstErr
+}
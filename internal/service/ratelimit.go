@@ -0,0 +1,125 @@
+package service
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// Rate limit classes.  Each class has its own budget, so a burst of
+// public seat-map reads cannot starve bookings and vice versa.
+const (
+	RateClassPublic   = "public"   // reads (GET and HEAD) of any route
+	RateClassAuth     = "auth"     // login, registration, token refresh and password reset
+	RateClassCustomer = "customer" // writes by customers and anonymous clients
+	RateClassOwner    = "owner"    // writes by owners and staff
+	RateClassIP       = "ip"       // every request from one client address, see middleware.IPRateLimit
+	RateClassAPIKey   = "apikey"   // every request made with one owner API key, see middleware.APIKeyAuth
+)
+
+// RateLimitPolicy is the token bucket of one class: a client may send
+// Burst requests at once and then PerMinute requests per minute.  A zero
+// Burst disables limiting for the class.
+type RateLimitPolicy struct {
+	Burst     int
+	PerMinute int
+}
+
+// RateLimitStatus is the outcome of RateLimiter.Allow.  Limit and
+// Remaining are zero for classes without a policy.
+type RateLimitStatus struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// RetryAfter is how long until the next request would be allowed;
+	// zero when Allowed.
+	RetryAfter time.Duration
+}
+
+// rateKey identifies one client's bucket in one class.
+type rateKey struct {
+	class string
+	key   string
+}
+
+// rateBucket is the state of one bucket.
+type rateBucket struct {
+	tokens float64
+	last   time.Time
+	policy RateLimitPolicy
+}
+
+// RateLimiter enforces per-client token buckets, one bucket per class and
+// client key.  Buckets live in memory and are per process: with several
+// replicas behind a load balancer each enforces the budget separately.
+// Idle buckets are dropped once they have refilled.
+type RateLimiter struct {
+	Policies map[string]RateLimitPolicy
+
+	mu        sync.Mutex
+	buckets   map[rateKey]*rateBucket
+	lastPrune time.Time
+}
+
+// NewRateLimiter constructs a limiter with the given policy per class.
+func NewRateLimiter(policies map[string]RateLimitPolicy) *RateLimiter {
+	return &RateLimiter{Policies: policies, buckets: make(map[rateKey]*rateBucket)}
+}
+
+// Allow takes a token from key's bucket in class and reports whether the
+// request may proceed.  Classes without a policy always allow.
+func (l *RateLimiter) Allow(class, key string) RateLimitStatus {
+	return l.AllowWith(class, key, l.Policies[class])
+}
+
+// AllowWith is Allow with the policy p instead of the class's, for
+// clients with budgets of their own such as API keys.  A zero p always
+// allows.
+func (l *RateLimiter) AllowWith(class, key string, p RateLimitPolicy) RateLimitStatus {
+	if p.Burst <= 0 || p.PerMinute <= 0 {
+		return RateLimitStatus{Allowed: true}
+	}
+	rate := float64(p.PerMinute) / float64(time.Minute)
+	now := time.Now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.prune(now)
+	id := rateKey{class, key}
+	b := l.buckets[id]
+	if b == nil {
+		b = &rateBucket{tokens: float64(p.Burst), last: now}
+		l.buckets[id] = b
+	}
+	b.policy = p
+	b.tokens = math.Min(float64(p.Burst), b.tokens+float64(now.Sub(b.last))*rate)
+	b.last = now
+	st := RateLimitStatus{Limit: p.Burst}
+	if b.tokens < 1 {
+		st.RetryAfter = time.Duration((1 - b.tokens) / rate)
+		return st
+	}
+	b.tokens--
+	st.Allowed = true
+	st.Remaining = int(b.tokens)
+	return st
+}
+
+// prune drops, at most once a minute, the buckets that have been idle
+// long enough to refill completely; a new bucket starts full anyway.
+func (l *RateLimiter) prune(now time.Time) {
+	if now.Sub(l.lastPrune) < time.Minute {
+		return
+	}
+	l.lastPrune = now
+	for id, b := range l.buckets {
+		p := b.policy
+		if p.PerMinute <= 0 {
+			delete(l.buckets, id)
+			continue
+		}
+		full := time.Duration(float64(p.Burst) / float64(p.PerMinute) * float64(time.Minute))
+		if now.Sub(b.last) >= full {
+			delete(l.buckets, id)
+		}
+	}
+}
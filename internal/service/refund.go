@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/iliyamo/cinema-seat-reservation/internal/db"
+	"github.com/iliyamo/cinema-seat-reservation/internal/repository"
+)
+
+// ErrRefundNotCharged is returned by RefundService.Process for refunds of
+// reservations that were not paid through checkout.  The payment gateway
+// knows nothing about them, so the owner settles them by hand.
+var ErrRefundNotCharged = errors.New("reservation was not paid through checkout")
+
+// RefundService returns the money of APPROVED refunds through the payment
+// gateway.  The charge of the checkout that paid for the reservation is
+// voided with the saga's idempotency key, together with the charges of
+// its seat upgrades, so processing a refund twice is harmless; the refund
+// then becomes PROCESSED and its reservation CANCELLED.  Run retries approved refunds whose processing failed.
+type RefundService struct {
+	Repo            *repository.RefundRepo
+	SagaRepo        *repository.BookingSagaRepo
+	ReservationRepo *repository.ReservationRepo
+	Payments        PaymentGateway
+	Interval        time.Duration
+	// Heartbeat is updated after every pass for GET /readyz.
+	Heartbeat Heartbeat
+}
+
+// NewRefundService constructs a RefundService.  All dependencies must be
+// non-nil.
+func NewRefundService(repo *repository.RefundRepo, sagaRepo *repository.BookingSagaRepo, resRepo *repository.ReservationRepo, payments PaymentGateway, interval time.Duration) *RefundService {
+	if repo == nil || sagaRepo == nil || resRepo == nil || payments == nil {
+		panic("nil dependency passed to NewRefundService")
+	}
+	return &RefundService{Repo: repo, SagaRepo: sagaRepo, ReservationRepo: resRepo, Payments: payments, Interval: interval}
+}
+
+// Process refunds an APPROVED refund through the payment gateway.  It
+// returns ErrRefundNotCharged when the reservation has no confirmed
+// checkout and repository.ErrRefundStateChanged when the refund is no
+// longer APPROVED.
+func (s *RefundService) Process(ctx context.Context, f *repository.Refund) error {
+	sg, err := s.SagaRepo.GetConfirmedByReservation(ctx, f.ReservationID)
+	if errors.Is(err, repository.ErrSagaNotFound) {
+		return ErrRefundNotCharged
+	}
+	if err != nil {
+		return err
+	}
+	if err := s.Payments.Void(ctx, sagaKey(sg.ID)); err != nil {
+		return err
+	}
+	// Price differences of seat upgrades were charged separately.
+	upgrades, err := s.ReservationRepo.ChargedUpgrades(ctx, f.ReservationID)
+	if err != nil {
+		return err
+	}
+	for _, id := range upgrades {
+		if err := s.Payments.Void(ctx, upgradeKey(id)); err != nil {
+			return err
+		}
+	}
+	return db.WithTx(ctx, s.Repo.DB(), func(tx *sql.Tx) error {
+		if err := s.Repo.TransitionTx(ctx, tx, f.ID, repository.RefundApproved, repository.RefundProcessed, nil, nil, sg.PaymentRef); err != nil {
+			return err
+		}
+		return s.ReservationRepo.FinishRefundTx(ctx, tx, f.ReservationID)
+	})
+}
+
+// Run processes approved refunds until ctx is cancelled.  Errors are
+// logged and the next tick retries.
+func (s *RefundService) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.Interval)
+	defer ticker.Stop()
+	s.Heartbeat.Beat(nil)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := s.Sweep(ctx)
+			if err != nil {
+				log.Printf("refund sweep failed: %v", err)
+			}
+			s.Heartbeat.Beat(err)
+		}
+	}
+}
+
+// Sweep processes one batch of approved refunds paid through checkout.  A
+// refund that fails is logged and retried on the next pass.
+func (s *RefundService) Sweep(ctx context.Context) error {
+	due, err := s.Repo.ListApprovedCharged(ctx, 100)
+	if err != nil {
+		return err
+	}
+	for i := range due {
+		err := s.Process(ctx, &due[i])
+		if err != nil && !errors.Is(err, repository.ErrRefundStateChanged) {
+			log.Printf("refund %d failed: %v", due[i].ID, err)
+		}
+	}
+	return nil
+}
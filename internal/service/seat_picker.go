@@ -0,0 +1,131 @@
+package service
+
+import (
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/iliyamo/cinema-seat-reservation/internal/repository"
+)
+
+// seatSpot is a candidate seat (or block of seats) with its distance from
+// the middle row and from the middle of its row.
+type seatSpot struct {
+	ids      []uint64
+	row      int
+	first    uint32
+	rowDist  float64
+	seatDist float64
+}
+
+// PickSeats chooses n FREE active seats from a hall layout and the show's
+// seat availability, preferring seats closest to the middle row and then
+// to the middle of their row.  With adjacent the seats form one run of
+// consecutive seat numbers in a row.  It returns nil when no choice is
+// possible.  Group holds and waitlist offers both pick seats with it.
+func PickSeats(layout []repository.Seat, status []repository.SeatWithStatus, n int, adjacent bool) []uint64 {
+	free := make(map[uint64]bool, len(status))
+	for _, s := range status {
+		if s.Status == "FREE" {
+			free[s.SeatID] = true
+		}
+	}
+	rows := make(map[int][]repository.Seat)
+	minRow, maxRow := math.MaxInt, -1
+	for _, s := range layout {
+		idx, ok := rowIndex(s.RowLabel)
+		if !ok || !s.IsActive {
+			continue
+		}
+		rows[idx] = append(rows[idx], s)
+		minRow, maxRow = min(minRow, idx), max(maxRow, idx)
+	}
+	if maxRow < 0 {
+		return nil
+	}
+	midRow := float64(minRow+maxRow) / 2
+	var spots []seatSpot
+	for idx, seats := range rows {
+		sort.Slice(seats, func(i, j int) bool { return seats[i].SeatNumber < seats[j].SeatNumber })
+		midSeat := float64(seats[0].SeatNumber+seats[len(seats)-1].SeatNumber) / 2
+		rowDist := math.Abs(float64(idx) - midRow)
+		if !adjacent {
+			for _, s := range seats {
+				if free[s.ID] {
+					spots = append(spots, seatSpot{ids: []uint64{s.ID}, row: idx, first: s.SeatNumber,
+						rowDist: rowDist, seatDist: math.Abs(float64(s.SeatNumber) - midSeat)})
+				}
+			}
+			continue
+		}
+		// Slide over runs of free seats with consecutive numbers; a taken,
+		// inactive or missing seat ends the run.
+		run := 0
+		for i, s := range seats {
+			switch {
+			case !free[s.ID]:
+				run = 0
+				continue
+			case run > 0 && s.SeatNumber == seats[i-1].SeatNumber+1:
+				run++
+			default:
+				run = 1
+			}
+			if run < n {
+				continue
+			}
+			block := seats[i-n+1 : i+1]
+			ids := make([]uint64, n)
+			for k, b := range block {
+				ids[k] = b.ID
+			}
+			center := float64(block[0].SeatNumber+s.SeatNumber) / 2
+			spots = append(spots, seatSpot{ids: ids, row: idx, first: block[0].SeatNumber,
+				rowDist: rowDist, seatDist: math.Abs(center - midSeat)})
+		}
+	}
+	sort.Slice(spots, func(i, j int) bool {
+		a, b := spots[i], spots[j]
+		if a.rowDist != b.rowDist {
+			return a.rowDist < b.rowDist
+		}
+		if a.seatDist != b.seatDist {
+			return a.seatDist < b.seatDist
+		}
+		if a.row != b.row {
+			return a.row < b.row
+		}
+		return a.first < b.first
+	})
+	if adjacent {
+		if len(spots) == 0 {
+			return nil
+		}
+		return spots[0].ids
+	}
+	if len(spots) < n {
+		return nil
+	}
+	out := make([]uint64, 0, n)
+	for _, sp := range spots[:n] {
+		out = append(out, sp.ids...)
+	}
+	return out
+}
+
+// rowIndex converts a row label (A, B, ..., Z, AA, ...) to its zero-based
+// index, reporting false for labels that are not ASCII letters.
+func rowIndex(label string) (int, bool) {
+	s := strings.ToUpper(strings.TrimSpace(label))
+	if s == "" {
+		return -1, false
+	}
+	n := 0
+	for i := 0; i < len(s); i++ {
+		if s[i] < 'A' || s[i] > 'Z' {
+			return -1, false
+		}
+		n = n*26 + int(s[i]-'A'+1)
+	}
+	return n - 1, true
+}
@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	"github.com/iliyamo/cinema-seat-reservation/internal/db"
+	"github.com/iliyamo/cinema-seat-reservation/internal/metrics"
+	"github.com/iliyamo/cinema-seat-reservation/internal/repository"
+)
+
+// SeatReconcileWorker periodically checks the seat statuses of every
+// upcoming show against its holds and reservations and repairs rows that
+// drifted, for example a seat left RESERVED after its reservation was
+// removed by hand.  The check itself is ShowSeatRepo.ReconcileTx; each
+// show is reconciled in its own transaction so seats of one show are
+// locked only briefly.
+//
+// Like the hold sweep, a pass first takes the MySQL named lock
+// SeatReconcileLock, so only one replica reconciles per tick.
+type SeatReconcileWorker struct {
+	ShowSeatRepo *repository.ShowSeatRepo
+	Interval     time.Duration
+	BatchSize    int // shows listed per query
+	// OnFreed, when set, is invoked with the seats of a show that a pass
+	// set back to FREE.
+	OnFreed func(showID uint64, seatIDs []uint64)
+	// Heartbeat is updated after every pass for GET /readyz.
+	Heartbeat Heartbeat
+}
+
+// SeatReconcileLock is the MySQL named lock held during a reconciliation
+// pass.
+const SeatReconcileLock = "cinema.seat_reconcile"
+
+// NewSeatReconcileWorker constructs a worker reconciling at the given
+// interval.
+func NewSeatReconcileWorker(showSeatRepo *repository.ShowSeatRepo, interval time.Duration) *SeatReconcileWorker {
+	if showSeatRepo == nil {
+		panic("nil repository passed to NewSeatReconcileWorker")
+	}
+	return &SeatReconcileWorker{ShowSeatRepo: showSeatRepo, Interval: interval, BatchSize: 100}
+}
+
+// Run reconciles until ctx is cancelled.  Errors are logged and the next
+// tick retries.
+func (w *SeatReconcileWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.Interval)
+	defer ticker.Stop()
+	w.Heartbeat.Beat(nil)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := w.Sweep(ctx)
+			if err != nil {
+				log.Printf("seat reconciliation failed: %v", err)
+			}
+			w.Heartbeat.Beat(err)
+		}
+	}
+}
+
+// Sweep reconciles every SCHEDULED show that has not ended.  It does
+// nothing when another instance holds SeatReconcileLock.
+func (w *SeatReconcileWorker) Sweep(ctx context.Context) error {
+	release, ok, err := db.TryLock(ctx, w.ShowSeatRepo.DB(), SeatReconcileLock)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return nil
+	}
+	defer release()
+	var after uint64
+	for {
+		ids, err := w.ShowSeatRepo.ReconcileCandidates(ctx, after, w.BatchSize)
+		if err != nil {
+			return err
+		}
+		for _, showID := range ids {
+			if err := w.reconcile(ctx, showID); err != nil {
+				return err
+			}
+		}
+		if len(ids) < w.BatchSize {
+			return nil
+		}
+		after = ids[len(ids)-1]
+	}
+}
+
+// reconcile repairs the seats of one show.
+func (w *SeatReconcileWorker) reconcile(ctx context.Context, showID uint64) error {
+	var repairs []repository.SeatRepair
+	err := db.WithTx(ctx, w.ShowSeatRepo.DB(), func(tx *sql.Tx) error {
+		var err error
+		repairs, _, err = w.ShowSeatRepo.ReconcileTx(ctx, tx, showID)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if len(repairs) == 0 {
+		return nil
+	}
+	metrics.SeatsRepaired.Add(float64(len(repairs)), "job")
+	var freed []uint64
+	for _, rp := range repairs {
+		log.Printf("seat reconciliation: show %d seat %d %s -> %s (%s)", showID, rp.SeatID, rp.Was, rp.Now, rp.Reason)
+		if rp.Now == "FREE" {
+			freed = append(freed, rp.SeatID)
+		}
+	}
+	if len(freed) > 0 && w.OnFreed != nil {
+		w.OnFreed(showID, freed)
+	}
+	return nil
+}
@@ -0,0 +1,232 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/iliyamo/cinema-seat-reservation/internal/db"
+	"github.com/iliyamo/cinema-seat-reservation/internal/repository"
+)
+
+// Seat types an upgrade moves between.
+const (
+	upgradeFromType = "STANDARD"
+	upgradeToType   = "VIP"
+)
+
+// SeatSwap asks for one seat of a reservation to be replaced by another
+// seat of the same show.
+type SeatSwap struct {
+	FromSeatID uint64
+	ToSeatID   uint64
+}
+
+// UpgradeRequest asks for seats of a customer's reservation to be
+// upgraded.
+type UpgradeRequest struct {
+	UserID        uint64
+	ReservationID uint64
+	Seats         []SeatSwap
+}
+
+// UpgradeNotAllowedError is returned when the requested swaps are not an
+// upgrade: a seat is not part of the reservation or not STANDARD, a
+// target is not a VIP seat of the show, or the new seats cost less.
+type UpgradeNotAllowedError struct {
+	SeatIDs []uint64
+	Reason  string
+}
+
+func (e *UpgradeNotAllowedError) Error() string { return e.Reason }
+
+// SeatUpgradeService swaps STANDARD seats of confirmed reservations for
+// FREE VIP seats of the same show.  The seats and the reservation's total
+// change in one transaction.  When the reservation was paid through
+// checkout and Payments is set, the price difference is charged inside
+// that transaction with the idempotency key upgrade-<id>: a declined
+// charge rolls the upgrade back, and a charge whose transaction does not
+// commit is voided.  Reservations confirmed without payment only have
+// their total raised.
+type SeatUpgradeService struct {
+	ReservationRepo *repository.ReservationRepo
+	ShowSeatRepo    *repository.ShowSeatRepo
+	SagaRepo        *repository.BookingSagaRepo // optional; finds the checkout that paid a reservation
+	Payments        PaymentGateway              // optional; charges the price difference
+	// OnFreed, when set, is invoked with the seats given up by an upgrade.
+	OnFreed func(showID uint64, seatIDs []uint64)
+}
+
+// NewSeatUpgradeService constructs a SeatUpgradeService.  Both
+// repositories must be non-nil.
+func NewSeatUpgradeService(resRepo *repository.ReservationRepo, showSeatRepo *repository.ShowSeatRepo) *SeatUpgradeService {
+	if resRepo == nil || showSeatRepo == nil {
+		panic("nil repository passed to NewSeatUpgradeService")
+	}
+	return &SeatUpgradeService{ReservationRepo: resRepo, ShowSeatRepo: showSeatRepo}
+}
+
+func upgradeKey(id uint64) string { return "upgrade-" + strconv.FormatUint(id, 10) }
+
+// Upgrade performs the swaps of req.  Besides UpgradeNotAllowedError it
+// returns sql.ErrNoRows for unknown reservations, repository.ErrForbidden
+// for other customers' reservations, repository.ErrReservationCancelled
+// for reservations that are not CONFIRMED, ErrShowStarted,
+// SeatsUnavailableError for targets that are not FREE and
+// ErrPaymentDeclined.
+func (s *SeatUpgradeService) Upgrade(ctx context.Context, req UpgradeRequest) (*repository.ReservationUpgrade, error) {
+	var up *repository.ReservationUpgrade
+	var charged []string // idempotency keys charged, including by retried attempts
+	err := db.WithTx(ctx, s.ShowSeatRepo.DB(), func(tx *sql.Tx) error {
+		var err error
+		up, err = s.upgradeTx(ctx, tx, req)
+		if err != nil {
+			return err
+		}
+		if up.DeltaCents == 0 || s.Payments == nil || s.SagaRepo == nil {
+			return nil
+		}
+		if _, err := s.SagaRepo.GetConfirmedByReservation(ctx, req.ReservationID); err != nil {
+			if errors.Is(err, repository.ErrSagaNotFound) {
+				return nil
+			}
+			return err
+		}
+		key := upgradeKey(up.ID)
+		charged = append(charged, key)
+		ref, err := s.Payments.Charge(ctx, key, up.DeltaCents, up.Currency)
+		if err != nil {
+			return err
+		}
+		up.PaymentRef = &ref
+		return s.ReservationRepo.SetUpgradePaymentRefTx(ctx, tx, up.ID, ref)
+	})
+	for _, key := range charged {
+		if err == nil && key == upgradeKey(up.ID) {
+			continue
+		}
+		if verr := s.Payments.Void(ctx, key); verr != nil {
+			log.Printf("seat upgrade: voiding charge %s failed: %v", key, verr)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if s.OnFreed != nil {
+		freed := make([]uint64, len(up.Seats))
+		for i, sw := range up.Seats {
+			freed[i] = sw.FromSeatID
+		}
+		s.OnFreed(up.ShowID, freed)
+	}
+	return up, nil
+}
+
+// upgradeTx validates the swaps and applies them inside tx.
+func (s *SeatUpgradeService) upgradeTx(ctx context.Context, tx *sql.Tx, req UpgradeRequest) (*repository.ReservationUpgrade, error) {
+	res, err := s.ReservationRepo.LockForUpgradeTx(ctx, tx, req.ReservationID)
+	if err != nil {
+		return nil, err
+	}
+	if res.UserID != req.UserID {
+		return nil, repository.ErrForbidden
+	}
+	if res.Status != "CONFIRMED" {
+		return nil, repository.ErrReservationCancelled
+	}
+	if !res.StartsAt.After(time.Now().UTC()) {
+		return nil, ErrShowStarted
+	}
+	fromIDs := make([]uint64, 0, len(req.Seats))
+	toIDs := make([]uint64, 0, len(req.Seats))
+	seen := make(map[uint64]bool, 2*len(req.Seats))
+	var notOwned []uint64
+	for _, sw := range req.Seats {
+		if seen[sw.FromSeatID] || seen[sw.ToSeatID] {
+			return nil, &UpgradeNotAllowedError{SeatIDs: []uint64{sw.FromSeatID, sw.ToSeatID}, Reason: "each seat may appear only once"}
+		}
+		seen[sw.FromSeatID], seen[sw.ToSeatID] = true, true
+		if _, ok := res.SeatPrices[sw.FromSeatID]; !ok {
+			notOwned = append(notOwned, sw.FromSeatID)
+		}
+		fromIDs = append(fromIDs, sw.FromSeatID)
+		toIDs = append(toIDs, sw.ToSeatID)
+	}
+	if len(notOwned) > 0 {
+		return nil, &UpgradeNotAllowedError{SeatIDs: notOwned, Reason: "seats are not part of the reservation"}
+	}
+	types, err := s.ReservationRepo.SeatTypesTx(ctx, tx, append(append([]uint64{}, fromIDs...), toIDs...))
+	if err != nil {
+		return nil, err
+	}
+	var wrongFrom, wrongTo []uint64
+	for _, sw := range req.Seats {
+		if types[sw.FromSeatID] != upgradeFromType {
+			wrongFrom = append(wrongFrom, sw.FromSeatID)
+		}
+		if types[sw.ToSeatID] != upgradeToType {
+			wrongTo = append(wrongTo, sw.ToSeatID)
+		}
+	}
+	if len(wrongFrom) > 0 {
+		return nil, &UpgradeNotAllowedError{SeatIDs: wrongFrom, Reason: fmt.Sprintf("only %s seats can be upgraded", upgradeFromType)}
+	}
+	if len(wrongTo) > 0 {
+		return nil, &UpgradeNotAllowedError{SeatIDs: wrongTo, Reason: fmt.Sprintf("seats can only be upgraded to %s seats", upgradeToType)}
+	}
+	// The targets are locked together with the seats given up, in seat_id
+	// order like every other booking path.
+	locked, err := s.ShowSeatRepo.LockSeatsTx(ctx, tx, res.ShowID, append(append([]uint64{}, fromIDs...), toIDs...))
+	if err != nil {
+		return nil, err
+	}
+	var unavailable []uint64
+	for _, id := range toIDs {
+		ls, ok := locked[id]
+		if !ok || ls.Status != "FREE" || ls.HeldBy != nil {
+			unavailable = append(unavailable, id)
+		}
+	}
+	if len(unavailable) > 0 {
+		sort.Slice(unavailable, func(i, j int) bool { return unavailable[i] < unavailable[j] })
+		return nil, &SeatsUnavailableError{ShowID: res.ShowID, SeatIDs: unavailable}
+	}
+	up := &repository.ReservationUpgrade{
+		ReservationID: res.ID,
+		UserID:        res.UserID,
+		ShowID:        res.ShowID,
+		Currency:      res.Currency,
+		Seats:         make([]repository.UpgradedSeat, 0, len(req.Seats)),
+	}
+	var delta int64
+	for _, sw := range req.Seats {
+		from, to := res.SeatPrices[sw.FromSeatID], locked[sw.ToSeatID].PriceCents
+		delta += int64(to) - int64(from)
+		up.Seats = append(up.Seats, repository.UpgradedSeat{
+			FromSeatID: sw.FromSeatID, ToSeatID: sw.ToSeatID, FromPriceCents: from, ToPriceCents: to,
+		})
+	}
+	if delta < 0 {
+		return nil, &UpgradeNotAllowedError{SeatIDs: toIDs, Reason: "the new seats cost less than the current ones"}
+	}
+	// The percentage fee and the tax apply to the difference too; a flat
+	// fee per ticket was already paid for these seats.
+	up.FeesCents, up.TaxCents = res.Fees.Charges(0, uint32(delta))
+	up.DeltaCents = uint32(delta) + up.FeesCents + up.TaxCents
+	up.TotalAmountCents = res.TotalAmountCents + up.DeltaCents
+	if err := s.ReservationRepo.ApplyUpgradeTx(ctx, tx, up); err != nil {
+		return nil, err
+	}
+	if err := s.ShowSeatRepo.BulkUpdateStatusTx(ctx, tx, res.ShowID, toIDs, "RESERVED"); err != nil {
+		return nil, err
+	}
+	if err := s.ShowSeatRepo.BulkUpdateStatusTx(ctx, tx, res.ShowID, fromIDs, "FREE"); err != nil {
+		return nil, err
+	}
+	return up, nil
+}
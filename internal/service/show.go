@@ -0,0 +1,232 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/iliyamo/cinema-seat-reservation/internal/db"
+	"github.com/iliyamo/cinema-seat-reservation/internal/repository"
+)
+
+// BlackoutDateError is returned when a show would touch a blackout date of
+// its cinema's calendar.
+type BlackoutDateError struct {
+	Date  string
+	Label string
+}
+
+func (e *BlackoutDateError) Error() string {
+	return fmt.Sprintf("%s is a blackout date", e.Date)
+}
+
+// ShowOverlapError is returned when a show would overlap other shows in
+// the same hall.
+type ShowOverlapError struct {
+	Overlaps []repository.Show
+}
+
+func (e *ShowOverlapError) Error() string {
+	return "show time overlaps with existing show"
+}
+
+// ScheduleWindowError is returned when a show would start in the past,
+// sooner than the minimum lead time or beyond the scheduling horizon of
+// its cinema.  MinLead and MaxHorizon are the bounds that apply;
+// MaxHorizon is 0 when there is no horizon.
+type ScheduleWindowError struct {
+	MinLead    time.Duration
+	MaxHorizon time.Duration
+	TooFar     bool // the show starts beyond MaxHorizon rather than too soon
+}
+
+func (e *ScheduleWindowError) Error() string {
+	if e.TooFar {
+		return fmt.Sprintf("show starts more than %s ahead", e.MaxHorizon)
+	}
+	return fmt.Sprintf("show starts less than %s from now", e.MinLead)
+}
+
+// ShowSpec is everything about a show except its time: the hall, what is
+// shown and the pricing.  A spec may be scheduled at one or more times.
+type ShowSpec struct {
+	Hall       *repository.Hall
+	Title      string
+	PriceCents uint32
+	HoldTTLSec *uint32
+}
+
+// ShowService owns the scheduling rules for shows: shows must start
+// within their cinema's scheduling window, may not touch a blackout date
+// of their cinema or overlap another show in the hall, and special dates
+// scale the price of their seats.
+type ShowService struct {
+	ShowRepo     *repository.ShowRepo
+	ShowSeatRepo *repository.ShowSeatRepo
+	CalendarRepo *repository.CalendarRepo // optional; enables blackout dates and special-date pricing
+	// MinLead and MaxHorizon are the scheduling window of cinemas that
+	// set none: shows must start at least MinLead from now and at most
+	// MaxHorizon ahead.  A zero MaxHorizon does not limit how far ahead.
+	MinLead    time.Duration
+	MaxHorizon time.Duration
+}
+
+// NewShowService constructs a ShowService.  All dependencies must be
+// non-nil.
+func NewShowService(showRepo *repository.ShowRepo, showSeatRepo *repository.ShowSeatRepo) *ShowService {
+	if showRepo == nil || showSeatRepo == nil {
+		panic("nil repository passed to NewShowService")
+	}
+	return &ShowService{ShowRepo: showRepo, ShowSeatRepo: showSeatRepo}
+}
+
+// CheckSchedule validates a show in hall from start to end against the
+// cinema calendar and the other shows of the hall, ignoring the show
+// excludeID (0 for a new show).  It returns BlackoutDateError or
+// ShowOverlapError when the time is not available, and otherwise the
+// price multiplier, in percent, of the special date the show starts on.
+func (s *ShowService) CheckSchedule(ctx context.Context, hall *repository.Hall, excludeID uint64, start, end time.Time) (uint32, error) {
+	days, err := s.Calendar(ctx, hall, start, end)
+	if err != nil {
+		return 0, fmt.Errorf("load cinema calendar: %w", err)
+	}
+	for _, d := range days {
+		if d.Kind == repository.CalendarBlackout {
+			return 0, &BlackoutDateError{Date: d.Date, Label: d.Label}
+		}
+	}
+	var overlaps []repository.Show
+	if excludeID == 0 {
+		overlaps, err = s.ShowRepo.FindOverlapping(ctx, hall.ID, start, end)
+	} else {
+		overlaps, err = s.ShowRepo.FindOverlappingExcluding(ctx, hall.ID, excludeID, start, end)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("check existing shows: %w", err)
+	}
+	if len(overlaps) > 0 {
+		return 0, &ShowOverlapError{Overlaps: overlaps}
+	}
+	return PriceMultiplierPct(days, start.In(hallLocation(hall))), nil
+}
+
+// Window returns the minimum lead time and the scheduling horizon of a
+// cinema with the window w: its own values, else the service's defaults.
+func (s *ShowService) Window(w *repository.SchedulingWindow) (minLead, maxHorizon time.Duration) {
+	minLead, maxHorizon = s.MinLead, s.MaxHorizon
+	if w == nil {
+		return minLead, maxHorizon
+	}
+	if w.MinLeadMinutes != nil {
+		minLead = time.Duration(*w.MinLeadMinutes) * time.Minute
+	}
+	if w.MaxHorizonDays != nil {
+		maxHorizon = time.Duration(*w.MaxHorizonDays) * 24 * time.Hour
+	}
+	return minLead, maxHorizon
+}
+
+// CheckWindow returns ScheduleWindowError unless a show in hall starting
+// at start is at least the minimum lead time from now and within the
+// scheduling horizon of the hall's cinema.  Shows can never start in the
+// past.
+func (s *ShowService) CheckWindow(ctx context.Context, hall *repository.Hall, start time.Time) error {
+	w, err := s.ShowRepo.SchedulingWindowByHall(ctx, hall.ID)
+	if err != nil {
+		return fmt.Errorf("load scheduling window: %w", err)
+	}
+	minLead, maxHorizon := s.Window(w)
+	now := time.Now()
+	switch {
+	case start.Before(now.Add(minLead)):
+		return &ScheduleWindowError{MinLead: minLead, MaxHorizon: maxHorizon}
+	case maxHorizon > 0 && start.After(now.Add(maxHorizon)):
+		return &ScheduleWindowError{MinLead: minLead, MaxHorizon: maxHorizon, TooFar: true}
+	}
+	return nil
+}
+
+// Schedule creates a show of spec from start to end together with a FREE
+// show seat for each of seats, after checking the time with CheckWindow
+// and CheckSchedule.
+// The seats are priced at the show's base price scaled by the special
+// date the show starts on; the show keeps the base price.  The new seat
+// map is recorded as the show's first snapshot.
+func (s *ShowService) Schedule(ctx context.Context, spec ShowSpec, seats []repository.Seat, start, end time.Time) (*repository.Show, error) {
+	if err := s.CheckWindow(ctx, spec.Hall, start); err != nil {
+		return nil, err
+	}
+	pct, err := s.CheckSchedule(ctx, spec.Hall, 0, start, end)
+	if err != nil {
+		return nil, err
+	}
+	seatPrice := repository.ApplyPriceMultiplier(spec.PriceCents, pct)
+	show := &repository.Show{
+		HallID:         spec.Hall.ID,
+		Title:          spec.Title,
+		StartsAt:       start.UTC(),
+		EndsAt:         end.UTC(),
+		BasePriceCents: spec.PriceCents,
+		HoldTTLSec:     spec.HoldTTLSec,
+	}
+	err = db.WithTx(ctx, s.ShowRepo.DB(), func(tx *sql.Tx) error {
+		if err := s.ShowRepo.CreateTx(ctx, tx, show); err != nil {
+			return fmt.Errorf("create show: %w", err)
+		}
+		ss := make([]repository.ShowSeat, 0, len(seats))
+		for _, seat := range seats {
+			ss = append(ss, repository.ShowSeat{
+				ShowID:     show.ID,
+				SeatID:     seat.ID,
+				Status:     "FREE",
+				PriceCents: seatPrice,
+				Version:    1,
+			})
+		}
+		if err := s.ShowSeatRepo.CreateBulkTx(ctx, tx, ss); err != nil {
+			return fmt.Errorf("create show seats: %w", err)
+		}
+		if err := s.ShowSeatRepo.SnapshotTx(ctx, tx, show.ID, repository.SnapshotCreated); err != nil {
+			return fmt.Errorf("snapshot seat map: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return show, nil
+}
+
+// Calendar loads the calendar entries of the hall's cinema for every local
+// day touched by a show running from start to end.  It returns nil when
+// the calendar is disabled or the hall has no cinema.
+func (s *ShowService) Calendar(ctx context.Context, hall *repository.Hall, start, end time.Time) ([]repository.CalendarDate, error) {
+	if s.CalendarRepo == nil || hall.CinemaID == nil {
+		return nil, nil
+	}
+	loc := hallLocation(hall)
+	// A show ending exactly at midnight does not touch the next day.
+	return s.CalendarRepo.ListRange(ctx, *hall.CinemaID, start.In(loc), end.Add(-time.Second).In(loc))
+}
+
+// PriceMultiplierPct returns the multiplier of the special date on which a
+// show starting at start begins, or 100.  The date is taken in start's
+// location, which should be the cinema's time zone.
+func PriceMultiplierPct(days []repository.CalendarDate, start time.Time) uint32 {
+	day := start.Format(repository.DateLayout)
+	for _, d := range days {
+		if d.Kind == repository.CalendarSpecial && d.Date == day {
+			return d.PriceMultiplierPct
+		}
+	}
+	return 100
+}
+
+// hallLocation returns the time zone of the hall's cinema, or UTC.
+func hallLocation(hall *repository.Hall) *time.Location {
+	if loc, err := time.LoadLocation(hall.Timezone); err == nil && hall.Timezone != "" && hall.Timezone != "Local" {
+		return loc
+	}
+	return time.UTC
+}
@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/iliyamo/cinema-seat-reservation/internal/db"
+	"github.com/iliyamo/cinema-seat-reservation/internal/repository"
+)
+
+// ShowAnnouncer tells customers about new shows of the cinemas and
+// movies they follow.  Every pass picks up the shows scheduled since the
+// last one and queues a "show.new" notification for each follower, which
+// the NotificationWorker then emails.  A show is marked announced in the
+// transaction that queues its notifications, so each follower hears about
+// it once even with several replicas running.  Shows that were cancelled
+// or started before their pass are marked without notifying anyone.
+type ShowAnnouncer struct {
+	Repo          *repository.FavoriteRepo
+	Notifications *repository.NotificationRepo
+	Interval      time.Duration
+	BatchSize     int // shows announced per pass
+	// Heartbeat is updated after every pass for GET /readyz.
+	Heartbeat Heartbeat
+}
+
+// NewShowAnnouncer constructs an announcer running at the given interval.
+func NewShowAnnouncer(repo *repository.FavoriteRepo, notifications *repository.NotificationRepo, interval time.Duration) *ShowAnnouncer {
+	if repo == nil || notifications == nil {
+		panic("nil dependency passed to NewShowAnnouncer")
+	}
+	return &ShowAnnouncer{Repo: repo, Notifications: notifications, Interval: interval, BatchSize: 100}
+}
+
+// Run announces new shows until ctx is cancelled.  Errors are logged and
+// the next tick retries.
+func (a *ShowAnnouncer) Run(ctx context.Context) {
+	ticker := time.NewTicker(a.Interval)
+	defer ticker.Stop()
+	a.Heartbeat.Beat(nil)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := a.Sweep(ctx)
+			if err != nil {
+				log.Printf("show announcement sweep failed: %v", err)
+			}
+			a.Heartbeat.Beat(err)
+		}
+	}
+}
+
+// Sweep announces one batch of new shows.
+func (a *ShowAnnouncer) Sweep(ctx context.Context) error {
+	shows, err := a.Repo.UnannouncedShows(ctx, a.BatchSize)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	for _, s := range shows {
+		s := s
+		err := db.WithTx(ctx, a.Repo.DB(), func(tx *sql.Tx) error {
+			claimed, err := a.Repo.MarkAnnouncedTx(ctx, tx, s.ID)
+			if err != nil || !claimed {
+				return err
+			}
+			if s.Status != "SCHEDULED" || !s.StartsAt.After(now) {
+				return nil
+			}
+			followers, err := a.Repo.FollowersTx(ctx, tx, s.CinemaID, s.Title)
+			if err != nil {
+				return err
+			}
+			for _, userID := range followers {
+				n := newShowNotification(userID, s)
+				if err := a.Notifications.EnqueueTx(ctx, tx, &n); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("announce show %d: %w", s.ID, err)
+		}
+	}
+	return nil
+}
+
+// newShowNotification returns the message telling userID about show s.
+func newShowNotification(userID uint64, s repository.NewShow) repository.Notification {
+	return repository.Notification{
+		UserID:  userID,
+		Kind:    "show.new",
+		Subject: "New show: " + s.Title,
+		Body: fmt.Sprintf("%q is now scheduled at %s (%s) on %s.\n",
+			s.Title, s.CinemaName, s.HallName, s.StartsAt.UTC().Format(time.RFC3339)),
+	}
+}
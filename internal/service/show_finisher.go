@@ -0,0 +1,55 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/iliyamo/cinema-seat-reservation/internal/repository"
+)
+
+// ShowFinishWorker periodically marks SCHEDULED shows whose end time has
+// passed as FINISHED (see ShowRepo.FinishEnded), which takes them out of
+// public listings and refuses further holds and reservations.  A pass is
+// a single idempotent UPDATE, so replicas may run it side by side.
+type ShowFinishWorker struct {
+	ShowRepo *repository.ShowRepo
+	Interval time.Duration
+	// Heartbeat is updated after every pass for GET /readyz.
+	Heartbeat Heartbeat
+}
+
+// NewShowFinishWorker constructs a worker finishing ended shows at the
+// given interval.
+func NewShowFinishWorker(showRepo *repository.ShowRepo, interval time.Duration) *ShowFinishWorker {
+	if showRepo == nil {
+		panic("nil repository passed to NewShowFinishWorker")
+	}
+	return &ShowFinishWorker{ShowRepo: showRepo, Interval: interval}
+}
+
+// Run finishes ended shows until ctx is cancelled.  Errors are logged and
+// the next tick retries.
+func (w *ShowFinishWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.Interval)
+	defer ticker.Stop()
+	w.Heartbeat.Beat(nil)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := w.Sweep(ctx)
+			if err != nil {
+				log.Printf("show finishing failed: %v", err)
+			}
+			w.Heartbeat.Beat(err)
+		}
+	}
+}
+
+// Sweep marks every SCHEDULED show that has ended as FINISHED.
+func (w *ShowFinishWorker) Sweep(ctx context.Context) error {
+	_, err := w.ShowRepo.FinishEnded(ctx, time.Now().UTC())
+	return err
+}
@@ -0,0 +1,211 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/iliyamo/cinema-seat-reservation/internal/db"
+	"github.com/iliyamo/cinema-seat-reservation/internal/metrics"
+	"github.com/iliyamo/cinema-seat-reservation/internal/repository"
+)
+
+// WaitlistService offers seats to customers waiting on sold-out shows.
+// Handlers call Notify after freeing seats (cancellations, released
+// holds); Run consumes those notifications and, on every tick, also
+// expires lapsed offers and retries every show with a queue.  Serving a
+// show offers seats to the head of its queue for as long as enough free
+// seats exist: the seats are held for the waiting user exactly as if they
+// had called POST /v1/shows/:id/hold, with the hold expiring after
+// OfferTTL, and the user confirms them through the normal confirm
+// endpoint.  The queue is strictly first come, first served; a head entry
+// that cannot be satisfied yet blocks smaller requests behind it.
+type WaitlistService struct {
+	Repo         *repository.WaitlistRepo
+	SeatRepo     *repository.SeatRepo
+	ShowSeatRepo *repository.ShowSeatRepo
+	SeatHoldRepo *repository.SeatHoldRepo
+	OfferTTL     time.Duration
+	Interval     time.Duration
+	// Heartbeat is updated after every periodic pass for GET /readyz.
+	Heartbeat Heartbeat
+
+	notify chan uint64
+}
+
+// NewWaitlistService constructs a WaitlistService.  All dependencies must
+// be non-nil.
+func NewWaitlistService(repo *repository.WaitlistRepo, seatRepo *repository.SeatRepo, showSeatRepo *repository.ShowSeatRepo, seatHoldRepo *repository.SeatHoldRepo, offerTTL, interval time.Duration) *WaitlistService {
+	if repo == nil || seatRepo == nil || showSeatRepo == nil || seatHoldRepo == nil {
+		panic("nil repository passed to NewWaitlistService")
+	}
+	return &WaitlistService{
+		Repo:         repo,
+		SeatRepo:     seatRepo,
+		ShowSeatRepo: showSeatRepo,
+		SeatHoldRepo: seatHoldRepo,
+		OfferTTL:     offerTTL,
+		Interval:     interval,
+		notify:       make(chan uint64, 256),
+	}
+}
+
+// Notify asks the worker to serve a show's waitlist because seats may
+// have come free.  It never blocks; when the queue of notifications is
+// full the show is picked up by the next periodic pass instead.
+func (s *WaitlistService) Notify(showID uint64) {
+	select {
+	case s.notify <- showID:
+	default:
+	}
+}
+
+// Run consumes notifications and performs periodic passes until ctx is
+// cancelled.  Errors are logged and retried on the next pass.
+func (s *WaitlistService) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.Interval)
+	defer ticker.Stop()
+	s.Heartbeat.Beat(nil)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case showID := <-s.notify:
+			if err := s.ServeShow(ctx, showID); err != nil {
+				log.Printf("waitlist: serving show %d failed: %v", showID, err)
+			}
+		case <-ticker.C:
+			err := s.Sweep(ctx)
+			if err != nil {
+				log.Printf("waitlist sweep failed: %v", err)
+			}
+			s.Heartbeat.Beat(err)
+		}
+	}
+}
+
+// Sweep expires lapsed offers and serves every show with a queue.
+func (s *WaitlistService) Sweep(ctx context.Context) error {
+	if _, err := s.Repo.ExpireOffers(ctx); err != nil {
+		return err
+	}
+	shows, err := s.Repo.ShowsWaiting(ctx)
+	if err != nil {
+		return err
+	}
+	for _, showID := range shows {
+		if err := s.ServeShow(ctx, showID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ServeShow makes offers to the head of a show's queue until nobody is
+// waiting or the head cannot be satisfied.
+func (s *WaitlistService) ServeShow(ctx context.Context, showID uint64) error {
+	for {
+		offered, err := s.offerHead(ctx, showID)
+		if err != nil || !offered {
+			return err
+		}
+	}
+}
+
+// offerHead offers seats to the oldest WAITING entry of a show inside one
+// transaction and reports whether an offer was made.  When the show can
+// no longer be booked its remaining entries are expired instead.
+func (s *WaitlistService) offerHead(ctx context.Context, showID uint64) (bool, error) {
+	var (
+		offered bool
+		held    int
+		expired int
+	)
+	err := db.WithTx(ctx, s.Repo.DB(), func(tx *sql.Tx) error {
+		offered, held, expired = false, 0, 0
+		head, err := s.Repo.HeadTx(ctx, tx, showID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		var (
+			hallID   uint64
+			status   string
+			startsAt time.Time
+		)
+		if err := tx.QueryRowContext(ctx, `SELECT hall_id, status, starts_at FROM shows WHERE id = ?`, showID).
+			Scan(&hallID, &status, &startsAt); err != nil {
+			return err
+		}
+		if status != "SCHEDULED" || !startsAt.After(time.Now().UTC()) {
+			return s.Repo.CloseWaitingTx(ctx, tx, showID)
+		}
+		// Release lapsed holds first so their seats count as free.
+		released, err := s.SeatHoldRepo.ExpireHoldsTx(ctx, tx, showID)
+		if err != nil {
+			return err
+		}
+		if len(released) > 0 {
+			if err := s.ShowSeatRepo.BulkUpdateStatusTx(ctx, tx, showID, released, "FREE"); err != nil {
+				return err
+			}
+		}
+		expired = len(released)
+		layout, err := s.SeatRepo.GetByHall(ctx, hallID)
+		if err != nil {
+			return err
+		}
+		seats, err := s.ShowSeatRepo.ListWithStatus(ctx, showID)
+		if err != nil {
+			return err
+		}
+		pick := PickSeats(layout, seats, head.SeatCount, head.Adjacent)
+		if pick == nil {
+			return nil
+		}
+		// Availability was read outside the row locks; recheck under them
+		// and leave the offer for a later pass if a seat was taken.
+		locked, err := s.ShowSeatRepo.LockSeatsTx(ctx, tx, showID, pick)
+		if err != nil {
+			return err
+		}
+		for _, id := range pick {
+			if ls, ok := locked[id]; !ok || ls.Status != "FREE" || ls.HeldBy != nil {
+				return nil
+			}
+		}
+		expiresAt := time.Now().UTC().Add(s.OfferTTL)
+		holds, err := repository.GenerateHoldRecords(head.UserID, showID, pick, expiresAt)
+		if err != nil {
+			return err
+		}
+		for i := range holds {
+			p := locked[holds[i].SeatID].PriceCents
+			holds[i].PriceCents = &p
+		}
+		if err := s.SeatHoldRepo.CreateMultipleTx(ctx, tx, holds); err != nil {
+			return err
+		}
+		if err := s.ShowSeatRepo.BulkUpdateStatusTx(ctx, tx, showID, pick, "HELD"); err != nil {
+			return err
+		}
+		if err := s.Repo.SetStatusTx(ctx, tx, head.ID, repository.WaitlistOffered, &expiresAt); err != nil {
+			return err
+		}
+		offered, held = true, len(pick)
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	metrics.HoldsExpired.Add(float64(expired), "sweeper")
+	if offered {
+		metrics.HoldsCreated.Add(float64(held))
+		metrics.WaitlistOffers.Inc()
+	}
+	return offered, nil
+}
@@ -0,0 +1,258 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/iliyamo/cinema-seat-reservation/internal/db"
+	"github.com/iliyamo/cinema-seat-reservation/internal/metrics"
+	"github.com/iliyamo/cinema-seat-reservation/internal/repository"
+)
+
+// Headers of webhook requests.
+const (
+	WebhookIDHeader        = "X-Webhook-Id"
+	WebhookEventHeader     = "X-Webhook-Event"
+	WebhookTimestampHeader = "X-Webhook-Timestamp"
+	WebhookSignatureHeader = "X-Webhook-Signature"
+)
+
+// maxWebhookBackoff caps the delay between delivery attempts.
+const maxWebhookBackoff = time.Hour
+
+// WebhookEnvelope is the JSON body posted to webhook URLs.  ID is the
+// delivery's ID and stays the same across retries, so receivers can skip
+// deliveries they have already processed.
+type WebhookEnvelope struct {
+	ID        uint64          `json:"id"`
+	Event     string          `json:"event"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// WebhookSignature returns the signature of a webhook body sent at the
+// given Unix timestamp: the hex HMAC-SHA256, keyed with the subscription
+// secret, of the timestamp, a dot and the body.  Receivers recompute it
+// to check the sender and reject stale timestamps to stop replays.
+func WebhookSignature(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte{'.'})
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// ErrWebhookDestination is returned for webhook URLs whose host is, or
+// resolves to, an address that is not publicly routable.
+var ErrWebhookDestination = errors.New("webhook destination is a private, loopback or link-local address")
+
+// publicAddr reports whether ip may receive webhooks.  Loopback, private
+// (RFC 1918 and fc00::/7), link-local (including the 169.254.169.254
+// metadata service), unspecified and multicast addresses may not, so
+// owners cannot make the server post into its own network.
+func publicAddr(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	return ip.IsValid() && !ip.IsLoopback() && !ip.IsPrivate() && !ip.IsUnspecified() &&
+		!ip.IsLinkLocalUnicast() && !ip.IsLinkLocalMulticast() && !ip.IsInterfaceLocalMulticast() && !ip.IsMulticast()
+}
+
+// CheckWebhookHost resolves the host of a webhook URL and returns
+// ErrWebhookDestination when any of its addresses is not public, or the
+// resolver's error.  The dispatcher checks the address it dials again,
+// since the host may resolve differently by then.
+func CheckWebhookHost(ctx context.Context, host string) error {
+	ips, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return err
+	}
+	for _, ip := range ips {
+		if !publicAddr(ip) {
+			return ErrWebhookDestination
+		}
+	}
+	return nil
+}
+
+// NewWebhookClient returns the HTTP client of the dispatcher.  It dials
+// public addresses only, checked after resolution so DNS changes cannot
+// get around CheckWebhookHost, ignores proxy settings, which would hide
+// the destination from that check, and does not follow redirects: a 3xx
+// response is a failed delivery.
+func NewWebhookClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control: func(_, address string, _ syscall.RawConn) error {
+			ap, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+			if !publicAddr(ap.Addr()) {
+				return fmt.Errorf("%w: %s", ErrWebhookDestination, ap.Addr())
+			}
+			return nil
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// WebhookDispatcher posts queued webhook deliveries to the subscribed
+// URLs.  Deliveries are queued inside the transaction of the change they
+// report (see repository.WebhookRepo), so partners only hear about
+// committed changes.  Delivery is at least once: when the process stops
+// between posting and recording a delivery, it is posted again.  A
+// response other than 2xx, or none within Timeout, is retried with
+// exponential backoff starting at Interval and capped at an hour, until
+// MaxAttempts is reached.  Client comes from NewWebhookClient, so only
+// public addresses are posted to.
+//
+// The deliveries are rows of webhook_deliveries rather than queue
+// messages: the queue's file backend only publishes and the memory
+// backend drops failed messages and loses them on restart, while a
+// delivery has to survive restarts, wait out its backoff and stay in the
+// delivery log.
+//
+// Like the event relay, each pass takes a MySQL named lock
+// (WebhookDispatcherLock), so with several replicas only one delivers at
+// a time.
+type WebhookDispatcher struct {
+	Repo        *repository.WebhookRepo
+	Client      *http.Client
+	Interval    time.Duration
+	BatchSize   int    // deliveries attempted per pass
+	MaxAttempts uint32 // attempts before a delivery is FAILED
+	// Heartbeat is updated after every pass for GET /readyz.
+	Heartbeat Heartbeat
+}
+
+// WebhookDispatcherLock is the MySQL named lock held during a pass.
+const WebhookDispatcherLock = "cinema.webhooks"
+
+// NewWebhookDispatcher constructs a dispatcher running at the given
+// interval whose requests time out after timeout.
+func NewWebhookDispatcher(repo *repository.WebhookRepo, interval, timeout time.Duration) *WebhookDispatcher {
+	if repo == nil {
+		panic("nil repository passed to NewWebhookDispatcher")
+	}
+	return &WebhookDispatcher{
+		Repo:        repo,
+		Client:      NewWebhookClient(timeout),
+		Interval:    interval,
+		BatchSize:   50,
+		MaxAttempts: 8,
+	}
+}
+
+// Run delivers webhooks until ctx is cancelled.  Errors are logged and
+// the next tick retries.
+func (w *WebhookDispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.Interval)
+	defer ticker.Stop()
+	w.Heartbeat.Beat(nil)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := w.Sweep(ctx)
+			if err != nil {
+				log.Printf("webhook dispatch failed: %v", err)
+			}
+			w.Heartbeat.Beat(err)
+		}
+	}
+}
+
+// Sweep attempts one batch of due deliveries.  A delivery the receiver
+// rejects is rescheduled and does not fail the pass or hold up the
+// others; only database errors do.
+func (w *WebhookDispatcher) Sweep(ctx context.Context) error {
+	release, ok, err := db.TryLock(ctx, w.Repo.DB(), WebhookDispatcherLock)
+	if err != nil || !ok {
+		return err
+	}
+	defer release()
+	due, err := w.Repo.Due(ctx, w.BatchSize)
+	if err != nil {
+		return err
+	}
+	for _, d := range due {
+		code, postErr := w.post(ctx, &d)
+		if postErr == nil {
+			metrics.WebhookDeliveries.Inc("delivered")
+			if err := w.Repo.MarkDelivered(ctx, d.ID, code); err != nil {
+				return err
+			}
+			continue
+		}
+		var retryAt *time.Time
+		outcome := "failed"
+		if attempts := d.Attempts + 1; attempts < w.MaxAttempts {
+			delay := w.Interval << attempts
+			if delay > maxWebhookBackoff || delay <= 0 {
+				delay = maxWebhookBackoff
+			}
+			t := time.Now().UTC().Add(delay)
+			retryAt, outcome = &t, "retry"
+		}
+		log.Printf("webhook delivery %d (%s) to subscription %d failed (%s): %v", d.ID, d.Event, d.SubscriptionID, outcome, postErr)
+		metrics.WebhookDeliveries.Inc(outcome)
+		if err := w.Repo.MarkFailed(ctx, d.ID, code, postErr.Error(), retryAt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// post sends one delivery and returns the response status, 0 when there
+// was no response.
+func (w *WebhookDispatcher) post(ctx context.Context, d *repository.WebhookDelivery) (int, error) {
+	body, err := json.Marshal(WebhookEnvelope{ID: d.ID, Event: d.Event, CreatedAt: d.CreatedAt, Data: d.Data})
+	if err != nil {
+		return 0, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	ts := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "cinema-seat-reservation-webhooks")
+	req.Header.Set(WebhookIDHeader, strconv.FormatUint(d.ID, 10))
+	req.Header.Set(WebhookEventHeader, d.Event)
+	req.Header.Set(WebhookTimestampHeader, strconv.FormatInt(ts, 10))
+	req.Header.Set(WebhookSignatureHeader, WebhookSignature(d.Secret, ts, body))
+	resp, err := w.Client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("receiver responded %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
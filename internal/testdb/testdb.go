@@ -0,0 +1,248 @@
+//go:build integration
+
+// Package testdb runs the integration tests, which are built with
+// -tags integration, against a real MySQL 8 server, or against the
+// server image named by TESTDB_IMAGE (e.g. mariadb:10.6).  Main starts
+// the server in Docker through dockertest, applies the schema from
+// internal/Docs the way docker-compose.yml does (0001_init.sql, then every
+// *.up.sql in file name order) and removes the container when the tests
+// are done.  NewShow creates the rows a booking test needs; every call
+// makes its own owner, hall, show and customers, so tests do not share
+// seats.
+//
+//	func TestMain(m *testing.M) { testdb.Main(m) }
+package testdb
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/ory/dockertest/v3"
+	"github.com/ory/dockertest/v3/docker"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/iliyamo/cinema-seat-reservation/internal/database"
+	"github.com/iliyamo/cinema-seat-reservation/internal/repository"
+	"github.com/iliyamo/cinema-seat-reservation/internal/service"
+)
+
+// DB is the database of the running tests.  Main sets it.
+var DB *sql.DB
+
+const (
+	defaultImage = "mysql:8.0"
+	name         = "cinema_test"
+	password     = "secret"
+)
+
+// serverImage returns the repository and tag of the server image:
+// TESTDB_IMAGE when set, MySQL 8 otherwise.
+func serverImage() (repo, tag string) {
+	img := os.Getenv("TESTDB_IMAGE")
+	if img == "" {
+		img = defaultImage
+	}
+	repo, tag, ok := strings.Cut(img, ":")
+	if !ok {
+		tag = "latest"
+	}
+	return repo, tag
+}
+
+// Main starts the database server, applies the migrations, runs the
+// tests of m and exits with their status.  Setup failures exit with
+// status 1.
+func Main(m *testing.M) {
+	image, tag := serverImage()
+	pool, err := dockertest.NewPool("")
+	if err != nil {
+		log.Fatalf("testdb: docker: %v", err)
+	}
+	pool.MaxWait = 3 * time.Minute
+	res, err := pool.RunWithOptions(&dockertest.RunOptions{
+		Repository: image,
+		Tag:        tag,
+		// the mariadb images accept the MYSQL_ variables as well
+		Env: []string{"MYSQL_ROOT_PASSWORD=" + password, "MYSQL_DATABASE=" + name},
+	}, func(hc *docker.HostConfig) {
+		hc.AutoRemove = true
+		hc.RestartPolicy = docker.RestartPolicy{Name: "no"}
+	})
+	if err != nil {
+		log.Fatalf("testdb: start %s:%s: %v", image, tag, err)
+	}
+	// a crashed test run must not leave the container behind
+	res.Expire(uint(pool.MaxWait.Seconds()) + 600)
+
+	code, err := run(m, pool, res)
+	if purgeErr := pool.Purge(res); purgeErr != nil {
+		log.Printf("testdb: remove container: %v", purgeErr)
+	}
+	if err != nil {
+		log.Fatalf("testdb: %v", err)
+	}
+	os.Exit(code)
+}
+
+func run(m *testing.M, pool *dockertest.Pool, res *dockertest.Resource) (int, error) {
+	host, port := "localhost", res.GetPort("3306/tcp")
+	// The server restarts once after initialising its data directory, so
+	// retry until the database it creates accepts connections.
+	var conn *sql.DB
+	err := pool.Retry(func() error {
+		var err error
+		conn, err = database.Open("root", password, host, port, name, database.Options{MaxOpenConns: 50})
+		return err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("connect: %w", err)
+	}
+	defer conn.Close()
+	if err := migrate(host, port); err != nil {
+		return 0, err
+	}
+	DB = conn
+	return m.Run(), nil
+}
+
+// migrate applies the schema over a connection of its own that allows
+// several statements per Exec, as the migration files contain.
+func migrate(host, port string) error {
+	cfg := mysql.NewConfig()
+	cfg.User, cfg.Passwd = "root", password
+	cfg.Net, cfg.Addr, cfg.DBName = "tcp", host+":"+port, name
+	cfg.MultiStatements = true
+	conn, err := sql.Open("mysql", cfg.FormatDSN())
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	files, err := migrations()
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		stmts, err := os.ReadFile(f)
+		if err != nil {
+			return err
+		}
+		if _, err := conn.Exec(string(stmts)); err != nil {
+			return fmt.Errorf("apply %s: %w", filepath.Base(f), err)
+		}
+	}
+	return nil
+}
+
+// migrations returns the schema files in the order they are applied.
+func migrations() ([]string, error) {
+	_, self, _, _ := runtime.Caller(0)
+	dir := filepath.Join(filepath.Dir(self), "..", "Docs")
+	up, err := filepath.Glob(filepath.Join(dir, "*.up.sql"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(up)
+	return append([]string{filepath.Join(dir, "0001_init.sql")}, up...), nil
+}
+
+// Show is a show created by NewShow.
+type Show struct {
+	*repository.Show
+	SeatIDs   []uint64 // the hall's seats in seat order
+	Customers []uint64 // customer accounts
+}
+
+var fixtures atomic.Int64
+
+// NewShow creates an owner with a hall of one row of seats seats, a
+// SCHEDULED show of it tomorrow at 1000 cents a seat and customers
+// customer accounts.
+func NewShow(t testing.TB, seats, customers int) *Show {
+	t.Helper()
+	ctx := context.Background()
+	n := fixtures.Add(1)
+	users := repository.NewUserRepo(DB)
+	createUser := func(role string, i int) uint64 {
+		id, err := users.Create(ctx, fmt.Sprintf("it-%d-%s-%d@example.test", n, role, i), "integration", role, bcrypt.MinCost)
+		if err != nil {
+			t.Fatalf("create %s: %v", role, err)
+		}
+		return id
+	}
+	hall := &repository.Hall{
+		OwnerID:  createUser("OWNER", 1),
+		Name:     fmt.Sprintf("Hall %d", n),
+		SeatRows: sql.NullInt32{Int32: 1, Valid: true},
+		SeatCols: sql.NullInt32{Int32: int32(seats), Valid: true},
+	}
+	if err := repository.NewHallRepo(DB).Create(ctx, hall); err != nil {
+		t.Fatalf("create hall: %v", err)
+	}
+	seatRepo := repository.NewSeatRepo(DB)
+	grid := make([]repository.Seat, 0, seats)
+	for i := 1; i <= seats; i++ {
+		grid = append(grid, repository.Seat{HallID: hall.ID, RowLabel: "A", SeatNumber: uint32(i), SeatType: "STANDARD"})
+	}
+	if err := seatRepo.CreateBulk(ctx, grid); err != nil {
+		t.Fatalf("create seats: %v", err)
+	}
+	grid, err := seatRepo.GetByHall(ctx, hall.ID)
+	if err != nil {
+		t.Fatalf("load seats: %v", err)
+	}
+	shows := service.NewShowService(repository.NewShowRepo(DB), repository.NewShowSeatRepo(DB))
+	start := time.Now().UTC().Truncate(time.Hour).Add(24 * time.Hour)
+	created, err := shows.Schedule(ctx, service.ShowSpec{Hall: hall, Title: hall.Name, PriceCents: 1000}, grid, start, start.Add(2*time.Hour))
+	if err != nil {
+		t.Fatalf("schedule show: %v", err)
+	}
+	// reload so the show carries its status and currency as handlers see it
+	show, err := shows.ShowRepo.GetByID(ctx, created.ID)
+	if err != nil {
+		t.Fatalf("load show: %v", err)
+	}
+	s := &Show{Show: show}
+	for _, seat := range grid {
+		s.SeatIDs = append(s.SeatIDs, seat.ID)
+	}
+	for i := 1; i <= customers; i++ {
+		s.Customers = append(s.Customers, createUser("CUSTOMER", i))
+	}
+	return s
+}
+
+// ExpireHolds backdates every hold of userID on the show so it has
+// expired but has not been swept yet.
+func (s *Show) ExpireHolds(t testing.TB, userID uint64) {
+	t.Helper()
+	_, err := DB.Exec(`UPDATE seat_holds SET expires_at = UTC_TIMESTAMP() - INTERVAL 1 SECOND WHERE show_id = ? AND user_id = ?`, s.ID, userID)
+	if err != nil {
+		t.Fatalf("expire holds: %v", err)
+	}
+}
+
+// Tx runs fn in a transaction that is committed unless fn fails the
+// test or returns an error, which is returned.
+func Tx(t testing.TB, fn func(tx *sql.Tx) error) error {
+	t.Helper()
+	tx, err := DB.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
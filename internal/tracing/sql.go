@@ -0,0 +1,300 @@
+package tracing
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/XSAM/otelsql"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// OpenDB opens a database through c instrumented with otelsql: every
+// query, exec, commit and rollback made under a traced context becomes a
+// client span.  system is the db.system.name attribute (e.g. "mysql").
+// The span is named after the calling function in callerPkg (for example
+// "ReservationRepo.CreateTx" for the repository package), which is also
+// recorded as db.query.summary; otelsql records the SQL text in
+// db.statement (db.query.text with OTEL_SEMCONV_STABILITY_OPT_IN=database).
+//
+// When slow is positive, every statement (traced or not) that takes at
+// least slow is also reported to onSlow, named the same way.
+func OpenDB(c driver.Connector, system, callerPkg string, slow time.Duration, onSlow func(SlowQuery)) *sql.DB {
+	if onSlow == nil {
+		slow = 0
+	}
+	callerPkg += "."
+	if slow > 0 {
+		c = &connector{base: c, callerPkg: callerPkg, slow: slow, onSlow: onSlow}
+	}
+	return otelsql.OpenDB(c,
+		otelsql.WithAttributes(semconv.DBSystemNameKey.String(system)),
+		otelsql.WithSpanNameFormatter(func(_ context.Context, method otelsql.Method, _ string) string {
+			if name := queryName(callerPkg); name != "" {
+				return name
+			}
+			return string(method)
+		}),
+		otelsql.WithAttributesGetter(func(context.Context, otelsql.Method, string, []driver.NamedValue) []attribute.KeyValue {
+			if name := queryName(callerPkg); name != "" {
+				return []attribute.KeyValue{semconv.DBQuerySummary(name)}
+			}
+			return nil
+		}),
+		otelsql.WithSpanOptions(otelsql.SpanOptions{
+			DisableErrSkip:       true,
+			OmitConnResetSession: true,
+			OmitConnPrepare:      true,
+			OmitRows:             true,
+			OmitConnectorConnect: true,
+			// Statements outside a traced request (background workers,
+			// startup) would otherwise start traces of their own.
+			// Without interpolateParams, which database.Open does not
+			// set, the MySQL driver refuses statements with arguments on
+			// the connection (driver.ErrSkip) and database/sql runs them
+			// as prepared statements, which get their own spans.
+			SpanFilter: func(ctx context.Context, method otelsql.Method, _ string, args []driver.NamedValue) bool {
+				if system == "mysql" && (method == otelsql.MethodConnQuery || method == otelsql.MethodConnExec) && len(args) > 0 {
+					return false
+				}
+				return trace.SpanContextFromContext(ctx).IsValid()
+			},
+		}),
+	)
+}
+
+// SlowQuery describes a statement that exceeded the slow query threshold.
+type SlowQuery struct {
+	Name      string // calling method, e.g. "ShowSeatRepo.ListWithStatus", or the SQL verb
+	Statement string // SQL text with whitespace collapsed
+	Duration  time.Duration
+	Err       error
+}
+
+// connector times every statement of its connections and reports the
+// slow ones.
+type connector struct {
+	base      driver.Connector
+	callerPkg string
+	slow      time.Duration
+	onSlow    func(SlowQuery)
+}
+
+func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
+	base, err := c.base.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &conn{Conn: base, c: c}, nil
+}
+
+func (c *connector) Driver() driver.Driver { return c.base.Driver() }
+
+// observe reports a statement started at start to onSlow when it took
+// at least the slow threshold.
+func (c *connector) observe(query string, start time.Time, err error) {
+	if err == driver.ErrSkip {
+		// Not executed; database/sql retries through a prepared statement.
+		return
+	}
+	d := time.Since(start)
+	if d < c.slow {
+		return
+	}
+	name := queryName(c.callerPkg)
+	if name == "" {
+		name = strings.ToUpper(firstWord(query))
+	}
+	c.onSlow(SlowQuery{Name: name, Statement: strings.Join(strings.Fields(query), " "), Duration: d, Err: err})
+}
+
+// queryName returns the innermost caller in callerPkg (which ends in
+// ".") as Type.Method, or "" when the statement was not issued from that
+// package.
+func queryName(callerPkg string) string {
+	var pcs [64]uintptr
+	n := runtime.Callers(3, pcs[:])
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		f, more := frames.Next()
+		if i := strings.LastIndex(f.Function, callerPkg); i >= 0 && !strings.Contains(f.Function[i+len(callerPkg):], "/") {
+			name := f.Function[i+len(callerPkg):]
+			return strings.NewReplacer("(*", "", ")", "").Replace(name)
+		}
+		if !more {
+			return ""
+		}
+	}
+}
+
+func firstWord(q string) string {
+	q = strings.TrimSpace(q)
+	if i := strings.IndexAny(q, " \t\r\n("); i > 0 {
+		return q[:i]
+	}
+	return q
+}
+
+// conn forwards every optional driver interface the MySQL driver
+// implements so database/sql behaves exactly as without the wrapper.
+type conn struct {
+	driver.Conn
+	c *connector
+}
+
+func (cn *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	q, ok := cn.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	start := time.Now()
+	rows, err := q.QueryContext(ctx, query, args)
+	cn.c.observe(query, start, err)
+	return rows, err
+}
+
+func (cn *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	e, ok := cn.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	start := time.Now()
+	res, err := e.ExecContext(ctx, query, args)
+	cn.c.observe(query, start, err)
+	return res, err
+}
+
+func (cn *conn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	var st driver.Stmt
+	var err error
+	if p, ok := cn.Conn.(driver.ConnPrepareContext); ok {
+		st, err = p.PrepareContext(ctx, query)
+	} else {
+		st, err = cn.Conn.Prepare(query)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &stmt{Stmt: st, c: cn.c, query: query}, nil
+}
+
+func (cn *conn) Prepare(query string) (driver.Stmt, error) {
+	return cn.PrepareContext(context.Background(), query)
+}
+
+func (cn *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	var t driver.Tx
+	var err error
+	if b, ok := cn.Conn.(driver.ConnBeginTx); ok {
+		t, err = b.BeginTx(ctx, opts)
+	} else {
+		t, err = cn.Conn.Begin()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &tx{Tx: t, c: cn.c}, nil
+}
+
+func (cn *conn) Ping(ctx context.Context) error {
+	if p, ok := cn.Conn.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+func (cn *conn) ResetSession(ctx context.Context) error {
+	if r, ok := cn.Conn.(driver.SessionResetter); ok {
+		return r.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (cn *conn) IsValid() bool {
+	if v, ok := cn.Conn.(driver.Validator); ok {
+		return v.IsValid()
+	}
+	return true
+}
+
+func (cn *conn) CheckNamedValue(nv *driver.NamedValue) error {
+	if c, ok := cn.Conn.(driver.NamedValueChecker); ok {
+		return c.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+// stmt times executions of a prepared statement.
+type stmt struct {
+	driver.Stmt
+	c     *connector
+	query string
+}
+
+func (st *stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	start := time.Now()
+	var rows driver.Rows
+	var err error
+	if q, ok := st.Stmt.(driver.StmtQueryContext); ok {
+		rows, err = q.QueryContext(ctx, args)
+	} else {
+		rows, err = st.Stmt.Query(values(args))
+	}
+	st.c.observe(st.query, start, err)
+	return rows, err
+}
+
+func (st *stmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	start := time.Now()
+	var res driver.Result
+	var err error
+	if e, ok := st.Stmt.(driver.StmtExecContext); ok {
+		res, err = e.ExecContext(ctx, args)
+	} else {
+		res, err = st.Stmt.Exec(values(args))
+	}
+	st.c.observe(st.query, start, err)
+	return res, err
+}
+
+// values converts named arguments for drivers predating the context
+// interfaces, which only support positional ones.
+func values(args []driver.NamedValue) []driver.Value {
+	out := make([]driver.Value, len(args))
+	for i, a := range args {
+		out[i] = a.Value
+	}
+	return out
+}
+
+func (st *stmt) CheckNamedValue(nv *driver.NamedValue) error {
+	if c, ok := st.Stmt.(driver.NamedValueChecker); ok {
+		return c.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+// tx times commit and rollback.
+type tx struct {
+	driver.Tx
+	c *connector
+}
+
+func (t *tx) Commit() error {
+	start := time.Now()
+	err := t.Tx.Commit()
+	t.c.observe("COMMIT", start, err)
+	return err
+}
+
+func (t *tx) Rollback() error {
+	start := time.Now()
+	err := t.Tx.Rollback()
+	t.c.observe("ROLLBACK", start, err)
+	return err
+}
@@ -0,0 +1,130 @@
+package tracing_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/iliyamo/cinema-seat-reservation/internal/repository/mock"
+	"github.com/iliyamo/cinema-seat-reservation/internal/tracing"
+)
+
+// showRepo stands in for a repository: spans are named after its methods.
+type showRepo struct{ db *sql.DB }
+
+func (r *showRepo) Count(ctx context.Context) (int, error) {
+	var n int
+	err := r.db.QueryRowContext(ctx, "SELECT COUNT(*)\n\t\tFROM shows WHERE hall_id = ?", 1).Scan(&n)
+	return n, err
+}
+
+func (r *showRepo) Cancel(ctx context.Context) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.ExecContext(ctx, "UPDATE shows SET status = 'CANCELLED' WHERE id = ?", 1); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func newRepo(t *testing.T, slow time.Duration, onSlow func(tracing.SlowQuery)) *showRepo {
+	t.Helper()
+	d := &mock.Driver{QueryFn: func(string, []driver.NamedValue) ([]string, [][]driver.Value, error) {
+		return []string{"n"}, [][]driver.Value{{int64(3)}}, nil
+	}}
+	db := tracing.OpenDB(d.Connector(), "other_sql", "github.com/iliyamo/cinema-seat-reservation/internal/tracing_test", slow, onSlow)
+	t.Cleanup(func() { db.Close() })
+	return &showRepo{db: db}
+}
+
+// recordSpans installs a tracer provider recording into the returned
+// exporter for the duration of the test.
+func recordSpans(t *testing.T) (*sdktrace.TracerProvider, *tracetest.InMemoryExporter) {
+	exp := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exp))
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(tp)
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+	return tp, exp
+}
+
+func TestStatementSpans(t *testing.T) {
+	tp, exp := recordSpans(t)
+	r := newRepo(t, 0, nil)
+	ctx, root := tp.Tracer("test").Start(context.Background(), "POST /v1/shows/:id/cancel")
+	if n, err := r.Count(ctx); err != nil || n != 3 {
+		t.Fatalf("Count() = %d, %v", n, err)
+	}
+	if err := r.Cancel(ctx); err != nil {
+		t.Fatal(err)
+	}
+	root.End()
+
+	names := map[string]int{}
+	for _, s := range exp.GetSpans() {
+		if s.Name == "POST /v1/shows/:id/cancel" {
+			continue
+		}
+		names[s.Name]++
+		if s.Parent.SpanID() != root.SpanContext().SpanID() {
+			t.Errorf("span %q is not a child of the request span", s.Name)
+		}
+		attrs := map[string]string{}
+		for _, a := range s.Attributes {
+			attrs[string(a.Key)] = a.Value.Emit()
+		}
+		if attrs["db.query.summary"] != s.Name || attrs["db.system.name"] != "other_sql" {
+			t.Errorf("span %q has attributes %v", s.Name, attrs)
+		}
+		if s.Name == "showRepo.Count" && attrs["db.statement"] != "SELECT COUNT(*)\n\t\tFROM shows WHERE hall_id = ?" {
+			t.Errorf("db.statement = %q", attrs["db.statement"])
+		}
+	}
+	// the query; begin, update and commit of the transaction
+	if names["showRepo.Count"] != 1 || names["showRepo.Cancel"] != 3 || len(names) != 2 {
+		t.Fatalf("spans %v", names)
+	}
+}
+
+func TestUntracedStatementsHaveNoSpans(t *testing.T) {
+	_, exp := recordSpans(t)
+	r := newRepo(t, 0, nil)
+	if _, err := r.Count(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if spans := exp.GetSpans(); len(spans) != 0 {
+		t.Fatalf("%d spans, want none", len(spans))
+	}
+}
+
+func TestSlowQueries(t *testing.T) {
+	var slow []tracing.SlowQuery
+	r := newRepo(t, time.Nanosecond, func(q tracing.SlowQuery) { slow = append(slow, q) })
+	if _, err := r.Count(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Cancel(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"showRepo.Count", "showRepo.Cancel", "showRepo.Cancel"}
+	if len(slow) != len(want) {
+		t.Fatalf("%d slow queries, want %d: %+v", len(slow), len(want), slow)
+	}
+	for i, q := range slow {
+		if q.Name != want[i] || q.Err != nil {
+			t.Errorf("slow query %d = %+v, want %s", i, q, want[i])
+		}
+	}
+	if slow[0].Statement != "SELECT COUNT(*) FROM shows WHERE hall_id = ?" || slow[2].Statement != "COMMIT" {
+		t.Errorf("statements %q, %q", slow[0].Statement, slow[2].Statement)
+	}
+}
@@ -0,0 +1,94 @@
+// Package tracing configures OpenTelemetry tracing for the service: the
+// SDK tracer provider with ratio sampling, W3C trace context propagation
+// and an exporter that ships spans to an OpenTelemetry collector, Jaeger
+// or Tempo over OTLP/HTTP.
+//
+// Traces start at the HTTP middleware (otelecho); db.WithTx and the SQL
+// driver wrapper (otelsql, see sql.go) add child spans through the global
+// tracer provider.  Until Init is called the global provider is the
+// OpenTelemetry no-op one, so instrumented code costs next to nothing
+// when tracing is off.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+)
+
+// Config selects where spans are sent.
+type Config struct {
+	Exporter    string  // "otlp", "console", or "" / "none" to disable tracing
+	Endpoint    string  // OTLP/HTTP base URL; spans are POSTed to Endpoint + "/v1/traces"
+	Headers     string  // extra export headers as "key=value,key2=value2"
+	ServiceName string  // service.name resource attribute
+	SampleRatio float64 // fraction of new traces recorded, 0..1
+}
+
+// provider is the SDK provider installed by Init, nil while tracing is off.
+var provider *sdktrace.TracerProvider
+
+// Init enables tracing with cfg by installing an SDK tracer provider and
+// the W3C trace context propagator as the OpenTelemetry globals.  It
+// returns an error for an unknown exporter; an empty or "none" exporter
+// leaves tracing disabled.
+func Init(cfg Config) error {
+	var exp sdktrace.SpanExporter
+	var err error
+	switch cfg.Exporter {
+	case "", "none":
+		return nil
+	case "console":
+		exp, err = stdouttrace.New(stdouttrace.WithWriter(log.Writer()))
+	case "otlp":
+		endpoint := cfg.Endpoint
+		if endpoint == "" {
+			endpoint = "http://localhost:4318"
+		}
+		exp, err = otlptracehttp.New(context.Background(),
+			otlptracehttp.WithEndpointURL(strings.TrimRight(endpoint, "/")+"/v1/traces"),
+			otlptracehttp.WithHeaders(parseHeaders(cfg.Headers)))
+	default:
+		return fmt.Errorf("unknown traces exporter %q", cfg.Exporter)
+	}
+	if err != nil {
+		return err
+	}
+	provider = sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exp),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(cfg.ServiceName))),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	return nil
+}
+
+// Shutdown exports the spans still queued and stops the exporter.  It
+// does nothing when tracing is off.
+func Shutdown(ctx context.Context) error {
+	if provider == nil {
+		return nil
+	}
+	return provider.Shutdown(ctx)
+}
+
+// parseHeaders parses "key=value,key2=value2" export headers.
+func parseHeaders(s string) map[string]string {
+	headers := map[string]string{}
+	for _, kv := range strings.Split(s, ",") {
+		if k, v, ok := strings.Cut(kv, "="); ok && strings.TrimSpace(k) != "" {
+			headers[strings.TrimSpace(k)] = strings.TrimSpace(v)
+		}
+	}
+	return headers
+}
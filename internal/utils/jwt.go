@@ -7,6 +7,8 @@ import (
     "time"          // time utilities for generating expirations
 
     "github.com/golang-jwt/jwt/v5" // JWT library for creating signed tokens
+
+    "github.com/iliyamo/cinema-seat-reservation/internal/permissions" // scopes granted to each role
 )
 
 // AccessToken represents a signed JWT access token along with its expiry.
@@ -28,28 +30,28 @@ type RefreshToken struct {
 }
 
 // NewAccessToken builds and signs an HS256 JWT for a user.  It takes the
-// signing secret, the user ID, the user's role, and a TTL in minutes.  It
+// key set to sign with, the user ID, the user's role, and a TTL in minutes.  It
 // returns an AccessToken structure containing the signed token and its
 // expiration time.  The JWT includes standard claims: subject (sub), role,
-// expiration (exp) and issued at (iat).
-func NewAccessToken(secret string, userID uint64, role string, ttlMin int) (AccessToken, error) {
+// expiration (exp), issued at (iat) and the role's scopes (scope).
+func NewAccessToken(keys *KeySet, userID uint64, role string, ttlMin int) (AccessToken, error) {
     // Calculate the expiration time by adding the TTL to the current UTC time.
     exp := time.Now().UTC().Add(time.Duration(ttlMin) * time.Minute)
     // Construct the JWT claims.  Using MapClaims allows arbitrary key/value
     // pairs.  We set sub to the user ID, role to the user's role, exp to
-    // the expiration Unix timestamp, and iat to the issued at time.
+    // the expiration Unix timestamp, iat to the issued at time and scope
+    // to the space-separated scopes granted to the role.
     claims := jwt.MapClaims{
-        "sub":  userID,
-        "role": role,
-        "exp":  exp.Unix(),
-        "iat":  time.Now().UTC().Unix(),
+        "sub":   userID,
+        "role":  role,
+        "scope": permissions.Claim(permissions.ForRole(role)),
+        "exp":   exp.Unix(),
+        "iat":   time.Now().UTC().Unix(),
     }
-    // Create a new token object specifying the signing method (HS256) and
-    // include the claims.
-    t := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-    // Sign the token with the provided secret and obtain the string form.  If
-    // signing fails, return the error and a zero AccessToken.
-    signed, err := t.SignedString([]byte(secret))
+    // Sign the token with the key set's current key (recorded in the kid
+    // header) and obtain the string form.  If signing fails, return the
+    // error and a zero AccessToken.
+    signed, err := keys.Sign(claims)
     if err != nil {
         return AccessToken{}, err
     }
@@ -73,6 +75,23 @@ func NewRefreshToken(ttlDays int) (RefreshToken, error) {
     }, nil
 }
 
+// NewResetToken returns a random single-use token for password resets
+// that expires after ttl.  As with refresh tokens, only its
+// HashRefreshRaw hash is stored.
+func NewResetToken(ttl time.Duration) (RefreshToken, error) {
+    raw, err := randomHex(32) // 32 bytes -> 64 hex chars
+    if err != nil {
+        return RefreshToken{}, err
+    }
+    return RefreshToken{Raw: raw, Exp: time.Now().UTC().Add(ttl)}, nil
+}
+
+// NewSecret returns a random 64-character hex secret that does not
+// expire, such as an API key.  Store only its HashRefreshRaw hash.
+func NewSecret() (string, error) {
+    return randomHex(32)
+}
+
 // HashRefreshRaw returns the SHA‑256 hash of the raw refresh token as a hex
 // string.  Storing only the hash in the database prevents attackers from
 // using stolen database entries to refresh sessions.
@@ -0,0 +1,124 @@
+package utils
+
+import (
+    "errors"
+    "fmt"
+    "strings"
+
+    "github.com/golang-jwt/jwt/v5"
+)
+
+// SigningKey is one HMAC secret of a KeySet, identified by the "kid"
+// header of the tokens it signs.
+type SigningKey struct {
+    ID     string
+    Secret []byte
+}
+
+// KeySet holds the HMAC keys accepted for access tokens.  New tokens are
+// signed with the signing key and carry its ID in the "kid" header; tokens
+// are verified against the key their kid names, and tokens without a kid
+// (issued before key IDs were introduced) against every key.  Rotating
+// therefore means adding a key, making it the signing key once every
+// replica knows it, and dropping the old key after the access token TTL.
+type KeySet struct {
+    keys    []SigningKey
+    signing int
+}
+
+// ErrUnknownKeyID is returned for tokens whose kid is not in the set.
+var ErrUnknownKeyID = errors.New("unknown signing key id")
+
+// LegacyKeyID is the ID given to the single JWT_SECRET key.
+const LegacyKeyID = "default"
+
+// NewKeySet builds a key set from the legacy single secret and a list of
+// "kid:secret" pairs separated by commas.  The secret, when not empty,
+// becomes the key LegacyKeyID.  The signing key is signingKID, or the last
+// listed key when signingKID is empty.
+func NewKeySet(secret, keys, signingKID string) (*KeySet, error) {
+    ks := &KeySet{}
+    if secret != "" {
+        ks.keys = append(ks.keys, SigningKey{ID: LegacyKeyID, Secret: []byte(secret)})
+    }
+    for _, entry := range strings.Split(keys, ",") {
+        entry = strings.TrimSpace(entry)
+        if entry == "" {
+            continue
+        }
+        id, sec, ok := strings.Cut(entry, ":")
+        id = strings.TrimSpace(id)
+        if !ok || id == "" || sec == "" {
+            return nil, fmt.Errorf("invalid JWT key %q: want kid:secret", id)
+        }
+        if ks.index(id) >= 0 {
+            return nil, fmt.Errorf("duplicate JWT key id %q", id)
+        }
+        ks.keys = append(ks.keys, SigningKey{ID: id, Secret: []byte(sec)})
+    }
+    if len(ks.keys) == 0 {
+        return nil, errors.New("no JWT signing key configured")
+    }
+    ks.signing = len(ks.keys) - 1
+    if signingKID != "" {
+        if ks.signing = ks.index(signingKID); ks.signing < 0 {
+            return nil, fmt.Errorf("JWT signing key %q is not configured", signingKID)
+        }
+    }
+    return ks, nil
+}
+
+func (ks *KeySet) index(id string) int {
+    for i, k := range ks.keys {
+        if k.ID == id {
+            return i
+        }
+    }
+    return -1
+}
+
+// SigningKeyID returns the ID of the key new tokens are signed with.
+func (ks *KeySet) SigningKeyID() string { return ks.keys[ks.signing].ID }
+
+// KeyIDs returns the IDs of all accepted keys.
+func (ks *KeySet) KeyIDs() []string {
+    ids := make([]string, len(ks.keys))
+    for i, k := range ks.keys {
+        ids[i] = k.ID
+    }
+    return ids
+}
+
+// Sign signs claims with HS256 using the signing key.
+func (ks *KeySet) Sign(claims jwt.Claims) (string, error) {
+    k := ks.keys[ks.signing]
+    t := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+    t.Header["kid"] = k.ID
+    return t.SignedString(k.Secret)
+}
+
+// Keyfunc is a jwt.Keyfunc that accepts HMAC tokens signed by a key of
+// the set.
+func (ks *KeySet) Keyfunc(t *jwt.Token) (interface{}, error) {
+    if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+        return nil, jwt.ErrTokenSignatureInvalid
+    }
+    kid, _ := t.Header["kid"].(string)
+    if kid == "" {
+        set := jwt.VerificationKeySet{}
+        for _, k := range ks.keys {
+            set.Keys = append(set.Keys, k.Secret)
+        }
+        return set, nil
+    }
+    i := ks.index(kid)
+    if i < 0 {
+        return nil, ErrUnknownKeyID
+    }
+    return ks.keys[i].Secret, nil
+}
+
+// Parse parses and verifies a token string against the set.
+func (ks *KeySet) Parse(raw string) (*jwt.Token, error) {
+    return jwt.Parse(raw, ks.Keyfunc)
+}
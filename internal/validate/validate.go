@@ -0,0 +1,261 @@
+// Package validate checks request DTOs against `validate` struct tags and
+// reports every invalid field at once.
+//
+// Rules are comma separated:
+//
+//	required    the value must not be zero: non-blank string, non-nil
+//	            pointer, non-empty slice, non-zero number
+//	min=N       numbers >= N; strings at least N characters; slices at
+//	            least N elements
+//	max=N       as min, upper bound
+//	email       a plausible email address (one @, a dot in the domain)
+//	oneof=A B   one of the space separated values (case-insensitive)
+//	rfc3339     an RFC 3339 timestamp such as 2025-08-09T10:55:13Z
+//	timestamp   an RFC 3339 timestamp, or a date and time without offset
+//	            such as 2025-08-09T10:55:13 that the caller reads in a
+//	            time zone of its choosing
+//
+// Rules other than required are skipped for nil pointers and empty
+// strings, so optional fields only need to be valid when present.
+// Pointers are dereferenced, and structs and slices of structs are
+// validated recursively with field paths such as seats[2].seat_id.
+// Field names are taken from the json tag.
+package validate
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// FieldError describes one invalid field.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Errors is the list of invalid fields of a value.
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	parts := make([]string, len(e))
+	for i, f := range e {
+		parts[i] = f.Field + " " + f.Message
+	}
+	return strings.Join(parts, "; ")
+}
+
+// Add appends a field error; handlers use it for checks that tags cannot
+// express, such as comparing two fields.
+func (e *Errors) Add(field, message string) {
+	*e = append(*e, FieldError{Field: field, Message: message})
+}
+
+// Struct validates v, which must be a struct or a pointer to one, and
+// returns the invalid fields in declaration order (nil when v is valid).
+func Struct(v interface{}) Errors {
+	var errs Errors
+	walk(reflect.ValueOf(v), "", &errs)
+	return errs
+}
+
+func walk(v reflect.Value, prefix string, errs *Errors) {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return
+	}
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.Anonymous && sf.Tag.Get("json") == "" {
+			// Embedded structs are flattened by encoding/json; report
+			// their fields without a prefix.
+			walk(v.Field(i), prefix, errs)
+			continue
+		}
+		if !sf.IsExported() {
+			continue
+		}
+		name := fieldName(sf)
+		if name == "-" {
+			continue
+		}
+		path := name
+		if prefix != "" {
+			path = prefix + "." + name
+		}
+		fv := v.Field(i)
+		if tag := sf.Tag.Get("validate"); tag != "" {
+			if msg := check(fv, tag); msg != "" {
+				errs.Add(path, msg)
+				continue
+			}
+		}
+		descend(fv, path, errs)
+	}
+}
+
+// descend validates nested structs and slices of structs.
+func descend(v reflect.Value, path string, errs *Errors) {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		if v.Type() != reflect.TypeOf(time.Time{}) {
+			walk(v, path, errs)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			descend(v.Index(i), fmt.Sprintf("%s[%d]", path, i), errs)
+		}
+	}
+}
+
+func fieldName(sf reflect.StructField) string {
+	name := strings.Split(sf.Tag.Get("json"), ",")[0]
+	if name == "" {
+		return sf.Name
+	}
+	return name
+}
+
+// check applies the rules in tag to v and returns the first failure
+// message, or "".
+func check(v reflect.Value, tag string) string {
+	rules := strings.Split(tag, ",")
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			if hasRule(rules, "required") {
+				return "is required"
+			}
+			return ""
+		}
+		v = v.Elem()
+	}
+	if isBlank(v) {
+		if hasRule(rules, "required") {
+			return "is required"
+		}
+		if v.Kind() == reflect.String {
+			return ""
+		}
+	}
+	for _, r := range rules {
+		name, arg, _ := strings.Cut(strings.TrimSpace(r), "=")
+		var msg string
+		switch name {
+		case "required", "":
+		case "min":
+			msg = bound(v, arg, true)
+		case "max":
+			msg = bound(v, arg, false)
+		case "email":
+			msg = email(v.String())
+		case "oneof":
+			msg = oneOf(v.String(), arg)
+		case "rfc3339":
+			if _, err := time.Parse(time.RFC3339, strings.TrimSpace(v.String())); err != nil {
+				msg = "must be an RFC3339 timestamp (e.g. 2025-08-09T10:55:13Z)"
+			}
+		case "timestamp":
+			t := strings.TrimSpace(v.String())
+			if _, err := time.Parse(time.RFC3339, t); err != nil {
+				if _, err := time.Parse("2006-01-02T15:04:05", t); err != nil {
+					msg = "must be an RFC3339 timestamp or a local time (e.g. 2025-08-09T10:55:13+02:00 or 2025-08-09T10:55:13)"
+				}
+			}
+		default:
+			panic("validate: unknown rule " + name)
+		}
+		if msg != "" {
+			return msg
+		}
+	}
+	return ""
+}
+
+func hasRule(rules []string, name string) bool {
+	for _, r := range rules {
+		if strings.TrimSpace(r) == name {
+			return true
+		}
+	}
+	return false
+}
+
+func isBlank(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.String:
+		return strings.TrimSpace(v.String()) == ""
+	case reflect.Slice, reflect.Map:
+		return v.Len() == 0
+	}
+	return v.IsZero()
+}
+
+// bound implements min (lower=true) and max.
+func bound(v reflect.Value, arg string, lower bool) string {
+	n, err := strconv.ParseFloat(arg, 64)
+	if err != nil {
+		panic("validate: bad bound " + arg)
+	}
+	var got float64
+	var unit string
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		got = float64(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		got = float64(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		got = v.Float()
+	case reflect.String:
+		got, unit = float64(utf8.RuneCountInString(strings.TrimSpace(v.String()))), " characters"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		got, unit = float64(v.Len()), " items"
+	default:
+		return ""
+	}
+	switch {
+	case lower && got < n && unit == "":
+		return "must be at least " + arg
+	case lower && got < n:
+		return "must have at least " + arg + unit
+	case !lower && got > n && unit == "":
+		return "must be at most " + arg
+	case !lower && got > n:
+		return "must have at most " + arg + unit
+	}
+	return ""
+}
+
+func email(s string) string {
+	s = strings.TrimSpace(s)
+	at := strings.Index(s, "@")
+	if at <= 0 || at != strings.LastIndex(s, "@") || !strings.Contains(s[at+1:], ".") ||
+		strings.HasSuffix(s, ".") || strings.ContainsAny(s, " \t") {
+		return "must be a valid email address"
+	}
+	return ""
+}
+
+func oneOf(s, arg string) string {
+	opts := strings.Fields(arg)
+	for _, o := range opts {
+		if strings.EqualFold(strings.TrimSpace(s), o) {
+			return ""
+		}
+	}
+	return "must be one of " + strings.Join(opts, ", ")
+}
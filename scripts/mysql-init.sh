@@ -0,0 +1,8 @@
+# Sourced by the MySQL image's entrypoint when it initialises an empty
+# data directory: applies the schema from internal/Docs, mounted at
+# /migrations, to $MYSQL_DATABASE.  0001_init.sql comes first, then every
+# up migration in file name order.
+for migration in /migrations/0001_init.sql /migrations/*.up.sql; do
+    echo "applying ${migration##*/}"
+    docker_process_sql < "$migration"
+done